@@ -0,0 +1,141 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// bounds for the interval between the pruning of the expired
+	// audit records
+	minAuditPruneInterval = time.Second
+	maxAuditPruneInterval = time.Hour
+)
+
+// AuditKey is the key of the audit records, where the object ids are
+// ordered by the time of creation
+type AuditKey struct {
+	ID bson.ObjectID `bson:"id"`
+}
+
+// AuditRecord is the typed change event persisted in the audit
+// collection for every change to the audited collection
+type AuditRecord[K any, E any] struct {
+	// namespace of the audited collection
+	Ns *Namespace `bson:"ns,omitempty"`
+
+	// key of the changed entry
+	Key *K `bson:"key,omitempty"`
+
+	// operation, eg. insert, update, replace, delete
+	Op string `bson:"op"`
+
+	// cluster time of the change
+	ClusterTime bson.Timestamp `bson:"clusterTime"`
+
+	// time the record was written, used for the retention
+	Timestamp time.Time `bson:"timestamp"`
+
+	// full document after the change, nil for delete
+	Entry *E `bson:"entry,omitempty"`
+
+	// fields updated or removed by an update
+	Updates *UpdateDescription[E] `bson:"updates,omitempty"`
+}
+
+// AuditLogger persists the typed change events of a collection to an
+// audit collection, retaining the records for the configured duration
+type AuditLogger[K any, E any] struct {
+	col       StoreCollection
+	target    StoreCollection
+	retention time.Duration
+}
+
+// NewAuditLogger creates an audit logger writing the change events of
+// col as AuditRecord entries into targetCol, where the records older
+// than the retention are pruned periodically, zero retention keeps the
+// records forever
+func NewAuditLogger[K any, E any](col StoreCollection, targetCol StoreCollection, retention time.Duration) (*AuditLogger[K, E], error) {
+	if col == nil || targetCol == nil {
+		return nil, errors.Wrap(errors.InvalidArgument, "audited and audit collections are required")
+	}
+	if retention < 0 {
+		return nil, errors.Wrap(errors.InvalidArgument, "audit retention must not be negative")
+	}
+	return &AuditLogger[K, E]{
+		col:       col,
+		target:    targetCol,
+		retention: retention,
+	}, nil
+}
+
+// Start starts recording the changes to the audit collection, until the
+// context is cancelled
+func (l *AuditLogger[K, E]) Start(ctx context.Context) error {
+	if err := l.target.SetKeyType(reflect.TypeOf(&AuditKey{})); err != nil {
+		return err
+	}
+	err := l.target.EnsureIndexes(ctx, []IndexDefinition{
+		{Fields: []IndexField{{Field: "timestamp", IndexType: IndexAscending}}},
+	})
+	if err != nil {
+		return err
+	}
+	err = WatchEvents(ctx, l.col, nil, func(event *Event[K, E]) {
+		record := &AuditRecord[K, E]{
+			Ns:          event.Ns,
+			Key:         event.Doc.Key,
+			Op:          event.Op,
+			ClusterTime: event.Time,
+			Timestamp:   time.Now(),
+			Entry:       event.Entry,
+			Updates:     event.Updates,
+		}
+		key := &AuditKey{ID: bson.NewObjectID()}
+		if err := l.target.InsertOne(context.Background(), key, record); err != nil {
+			log.Printf("failed to write audit record for %v, op %s: %s", event.Doc.Key, event.Op, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if l.retention != 0 {
+		go l.pruneLoop(ctx)
+	}
+	return nil
+}
+
+// Prune removes the audit records older than the retention, returning
+// the number of records removed
+func (l *AuditLogger[K, E]) Prune(ctx context.Context) (int64, error) {
+	if l.retention == 0 {
+		return 0, nil
+	}
+	filter := bson.D{{Key: "timestamp", Value: bson.D{{Key: "$lt", Value: time.Now().Add(-l.retention)}}}}
+	return l.target.DeleteMany(ctx, filter)
+}
+
+func (l *AuditLogger[K, E]) pruneLoop(ctx context.Context) {
+	interval := min(max(l.retention/10, minAuditPruneInterval), maxAuditPruneInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := l.Prune(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("failed to prune audit records: %s", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+func Test_AuditLogger(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	waitRecords := func(col StoreCollection, count int64) []AuditRecord[MyKey, memTestData] {
+		for range 100 {
+			if n, _ := col.Count(ctx, nil); n >= count {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		list := []AuditRecord[MyKey, memTestData]{}
+		_ = col.FindMany(ctx, nil, &list)
+		return list
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := NewAuditLogger[MyKey, memTestData](nil, NewMemoryCollection("audit"), 0); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without audited collection, got %v", err)
+		}
+		if _, err := NewAuditLogger[MyKey, memTestData](NewMemoryCollection("col"), NewMemoryCollection("audit"), -time.Second); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for negative retention, got %v", err)
+		}
+	})
+
+	t.Run("records", func(t *testing.T) {
+		store := NewMemoryClient().GetDataStore("test-db")
+		col := store.GetCollection("entries")
+		audit := store.GetCollection("entries-audit")
+		logger, err := NewAuditLogger[MyKey, memTestData](col, audit, 0)
+		if err != nil {
+			t.Fatalf("failed to create audit logger: %s", err)
+		}
+		if err := logger.Start(ctx); err != nil {
+			t.Fatalf("failed to start audit logger: %s", err)
+		}
+		_ = col.InsertOne(ctx, &MyKey{Name: "a"}, &memTestData{Desc: "created"})
+		_ = col.UpdateOne(ctx, &MyKey{Name: "a"}, &memTestData{Desc: "updated"}, false)
+		_ = col.DeleteOne(ctx, &MyKey{Name: "a"})
+
+		list := waitRecords(audit, 3)
+		if len(list) != 3 {
+			t.Fatalf("expected 3 audit records, got %v", list)
+		}
+		ops := []string{"insert", "update", "delete"}
+		for i, record := range list {
+			if record.Op != ops[i] || record.Key == nil || record.Key.Name != "a" {
+				t.Errorf("expected %s of key a, got %+v", ops[i], record)
+			}
+			if record.Ns == nil || record.Ns.Collection != "entries" || record.Timestamp.IsZero() {
+				t.Errorf("expected namespace and timestamp to be recorded, got %+v", record)
+			}
+		}
+		if list[0].Entry == nil || list[0].Entry.Desc != "created" {
+			t.Errorf("expected entry to be recorded for insert, got %+v", list[0].Entry)
+		}
+		if list[2].Entry != nil {
+			t.Errorf("expected no entry for delete, got %+v", list[2].Entry)
+		}
+		if n, _ := logger.Prune(ctx); n != 0 {
+			t.Errorf("expected records to be retained forever, pruned %d", n)
+		}
+	})
+
+	t.Run("retention", func(t *testing.T) {
+		store := NewMemoryClient().GetDataStore("test-db")
+		col := store.GetCollection("entries")
+		audit := store.GetCollection("entries-audit")
+		logger, _ := NewAuditLogger[MyKey, memTestData](col, audit, 50*time.Millisecond)
+		if err := logger.Start(ctx); err != nil {
+			t.Fatalf("failed to start audit logger: %s", err)
+		}
+		_ = col.InsertOne(ctx, &MyKey{Name: "a"}, &memTestData{Desc: "a"})
+		_ = col.InsertOne(ctx, &MyKey{Name: "b"}, &memTestData{Desc: "b"})
+		if list := waitRecords(audit, 2); len(list) != 2 {
+			t.Fatalf("expected 2 audit records, got %v", list)
+		}
+		time.Sleep(100 * time.Millisecond)
+		_ = col.InsertOne(ctx, &MyKey{Name: "c"}, &memTestData{Desc: "c"})
+		waitRecords(audit, 3)
+		n, err := logger.Prune(ctx)
+		if err != nil || n != 2 {
+			t.Errorf("expected 2 expired records to be pruned, got %d, %v", n, err)
+		}
+		if count, _ := audit.Count(ctx, nil); count != 1 {
+			t.Errorf("expected 1 record to be retained, got %d", count)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+	"github.com/go-core-stack/core/rate"
+)
+
+// BlobInfo describes a blob stored in the blob store
+type BlobInfo struct {
+	// name of the blob
+	Name string
+
+	// size of the blob content in bytes
+	Size int64
+
+	// time the blob was stored
+	UploadedAt time.Time
+
+	// metadata stored along with the blob
+	Metadata map[string]string
+}
+
+// BlobStore stores the large binary objects, eg. artifacts larger than the
+// document size limit, streaming the content instead of holding it in
+// memory as part of a document
+type BlobStore interface {
+	// Put stores the content read from r until EOF as the blob with the
+	// given name, replacing the existing blob if any, returns the size
+	// of the blob stored
+	Put(ctx context.Context, name string, r io.Reader, metadata map[string]string) (int64, error)
+
+	// Get returns the reader streaming the content of the blob, which
+	// needs to be closed by the caller, the context governs the life of
+	// the reader
+	// returns NotFound error if the blob doesn't exist
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+
+	// Stat returns the information of the blob
+	// returns NotFound error if the blob doesn't exist
+	Stat(ctx context.Context, name string) (*BlobInfo, error)
+
+	// Delete removes the blob
+	// returns NotFound error if the blob doesn't exist
+	Delete(ctx context.Context, name string) error
+}
+
+// BlobStoreOptions provides the configuration of the blob store
+type BlobStoreOptions struct {
+	// ChunkSize is the size of the chunks the blob is stored in
+	// Default: 255KB for GridFS
+	ChunkSize int32
+
+	// Limiter throttles the uploads and downloads using the limiter
+	// registered with the given key in the limit manager
+	Limiter    *rate.LimitManager
+	LimiterKey string
+}
+
+// BlobStoreOption is a functional option for configuring the blob store
+type BlobStoreOption func(*BlobStoreOptions)
+
+// WithBlobChunkSize sets the size of the chunks the blob is stored in
+func WithBlobChunkSize(size int32) BlobStoreOption {
+	return func(opts *BlobStoreOptions) {
+		opts.ChunkSize = size
+	}
+}
+
+// WithBlobRateLimit throttles the uploads and downloads using the limiter
+// registered with the given key in the limit manager
+func WithBlobRateLimit(mgr *rate.LimitManager, key string) BlobStoreOption {
+	return func(opts *BlobStoreOptions) {
+		opts.Limiter = mgr
+		opts.LimiterKey = key
+	}
+}
+
+func getBlobStoreOptions(bucket string, opts []BlobStoreOption) (*BlobStoreOptions, error) {
+	if bucket == "" {
+		return nil, errors.Wrap(errors.InvalidArgument, "blob store bucket name is required")
+	}
+	bopts := &BlobStoreOptions{}
+	for _, opt := range opts {
+		opt(bopts)
+	}
+	if bopts.ChunkSize < 0 {
+		return nil, errors.Wrap(errors.InvalidArgument, "blob chunk size must not be negative")
+	}
+	if bopts.Limiter != nil && bopts.LimiterKey == "" {
+		return nil, errors.Wrap(errors.InvalidArgument, "limiter key is required for rate limiting blob store")
+	}
+	return bopts, nil
+}
+
+// wraps the blob store with the rate limiting, if configured
+func (o *BlobStoreOptions) wrap(bs BlobStore) BlobStore {
+	if o.Limiter == nil {
+		return bs
+	}
+	return &rateLimitedBlobStore{
+		BlobStore: bs,
+		mgr:       o.Limiter,
+		key:       o.LimiterKey,
+	}
+}
+
+// rateLimitedBlobStore throttles the content streamed to and from the
+// underlying blob store
+type rateLimitedBlobStore struct {
+	BlobStore
+	mgr *rate.LimitManager
+	key string
+}
+
+func (s *rateLimitedBlobStore) Put(ctx context.Context, name string, r io.Reader, metadata map[string]string) (int64, error) {
+	lr, err := s.mgr.WrapReader(ctx, s.key, io.NopCloser(r))
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = lr.Close() }()
+	return s.BlobStore.Put(ctx, name, lr, metadata)
+}
+
+func (s *rateLimitedBlobStore) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	rc, err := s.BlobStore.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	lr, err := s.mgr.WrapReader(ctx, s.key, rc)
+	if err != nil {
+		_ = rc.Close()
+		return nil, err
+	}
+	return lr, nil
+}
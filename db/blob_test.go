@@ -0,0 +1,121 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-core-stack/core/errors"
+	"github.com/go-core-stack/core/rate"
+)
+
+func Test_BlobStore(t *testing.T) {
+	ctx := context.Background()
+
+	read := func(t *testing.T, bs BlobStore, name string) string {
+		rc, err := bs.Get(ctx, name)
+		if err != nil {
+			t.Fatalf("failed to get blob %s: %s", name, err)
+		}
+		defer func() { _ = rc.Close() }()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("failed to read blob %s: %s", name, err)
+		}
+		return string(data)
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		store := NewMemoryClient().GetDataStore("test-db")
+		if _, err := store.GetBlobStore(""); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without bucket, got %v", err)
+		}
+		if _, err := store.GetBlobStore("artifacts", WithBlobChunkSize(-1)); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for negative chunk size, got %v", err)
+		}
+		if _, err := store.GetBlobStore("artifacts", WithBlobRateLimit(rate.NewLimitManager(1024), "")); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without limiter key, got %v", err)
+		}
+	})
+
+	t.Run("operations", func(t *testing.T) {
+		store := NewMemoryClient().GetDataStore("test-db")
+		bs, err := store.GetBlobStore("artifacts")
+		if err != nil {
+			t.Fatalf("failed to get blob store: %s", err)
+		}
+		if _, err := bs.Get(ctx, "missing"); !errors.IsNotFound(err) {
+			t.Errorf("expected not found for missing blob, got %v", err)
+		}
+		if _, err := bs.Put(ctx, "", strings.NewReader("data"), nil); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without name, got %v", err)
+		}
+
+		n, err := bs.Put(ctx, "build.tar", strings.NewReader("first"), map[string]string{"version": "1"})
+		if err != nil || n != 5 {
+			t.Fatalf("failed to put blob: %d, %v", n, err)
+		}
+		n, err = bs.Put(ctx, "build.tar", strings.NewReader("second revision"), map[string]string{"version": "2"})
+		if err != nil || n != 15 {
+			t.Fatalf("failed to replace blob: %d, %v", n, err)
+		}
+		if data := read(t, bs, "build.tar"); data != "second revision" {
+			t.Errorf("expected replaced content, got %q", data)
+		}
+		info, err := bs.Stat(ctx, "build.tar")
+		if err != nil || info.Size != 15 || info.Metadata["version"] != "2" || info.UploadedAt.IsZero() {
+			t.Errorf("unexpected blob info %+v, %v", info, err)
+		}
+
+		// same bucket shares the blobs
+		other, _ := store.GetBlobStore("artifacts")
+		if data := read(t, other, "build.tar"); data != "second revision" {
+			t.Errorf("expected blobs to be shared across handles, got %q", data)
+		}
+
+		if err := bs.Delete(ctx, "build.tar"); err != nil {
+			t.Errorf("failed to delete blob: %s", err)
+		}
+		if err := bs.Delete(ctx, "build.tar"); !errors.IsNotFound(err) {
+			t.Errorf("expected not found for deleted blob, got %v", err)
+		}
+		if _, err := bs.Stat(ctx, "build.tar"); !errors.IsNotFound(err) {
+			t.Errorf("expected not found for deleted blob, got %v", err)
+		}
+	})
+
+	t.Run("rate_limit", func(t *testing.T) {
+		store := NewMemoryClient().GetDataStore("test-db")
+		mgr := rate.NewLimitManager(1 << 20)
+		bs, err := store.GetBlobStore("artifacts", WithBlobRateLimit(mgr, "uploads"))
+		if err != nil {
+			t.Fatalf("failed to get blob store: %s", err)
+		}
+		if _, err := bs.Put(ctx, "a", strings.NewReader("data"), nil); !errors.IsNotFound(err) {
+			t.Errorf("expected not found for unregistered limiter, got %v", err)
+		}
+		if _, err := mgr.NewLimiter("uploads", 1<<20, 16); err != nil {
+			t.Fatalf("failed to create limiter: %s", err)
+		}
+		content := bytes.Repeat([]byte("x"), 100)
+		n, err := bs.Put(ctx, "a", bytes.NewReader(content), nil)
+		if err != nil || n != int64(len(content)) {
+			t.Fatalf("failed to put rate limited blob: %d, %v", n, err)
+		}
+		if data := read(t, bs, "a"); data != string(content) {
+			t.Errorf("expected content to be read through the limiter, got %d bytes", len(data))
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		client, _ := NewEtcdClient(newFakeEtcdKV())
+		if _, err := client.GetDataStore("test-db").GetBlobStore("artifacts"); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for etcd store, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,243 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// ChangeSink publishes the change events to an external system, eg.
+// Kafka, NATS or a webhook, for the consumers that can't talk to the
+// store directly
+type ChangeSink[K any, E any] interface {
+	// Publish publishes the event, returning error if the event is not
+	// accepted by the external system, in which case it is retried
+	Publish(ctx context.Context, event *Event[K, E]) error
+}
+
+// ChangeSinkFunc adapts a function as the ChangeSink
+type ChangeSinkFunc[K any, E any] func(ctx context.Context, event *Event[K, E]) error
+
+func (f ChangeSinkFunc[K, E]) Publish(ctx context.Context, event *Event[K, E]) error {
+	return f(ctx, event)
+}
+
+// ExportOptions provides the configuration of the change exporter
+type ExportOptions struct {
+	// Filter is the watch filter pipeline selecting the events exported
+	// Default: nil (all the events)
+	Filter any
+
+	// CheckpointEvery is the number of events published between the
+	// checkpoints of the resume token, the events published after the
+	// last checkpoint are published again on restart
+	// Default: 1 (checkpoint after every event)
+	CheckpointEvery int
+
+	// ResyncCallback is invoked when the export resumes without the
+	// history of the changes since the checkpoint, where the consumers
+	// need to resync their state with the collection
+	// Default: nil (only logged)
+	ResyncCallback func()
+
+	// ErrorCallback is invoked with the error once the watch can no
+	// longer be re-established, the export is stopped afterwards
+	// Default: nil (only logged)
+	ErrorCallback func(err error)
+}
+
+// ExportOption is a functional option for configuring the change exporter
+type ExportOption func(*ExportOptions)
+
+// WithExportFilter sets the watch filter pipeline selecting the events
+// exported
+func WithExportFilter(filter any) ExportOption {
+	return func(opts *ExportOptions) {
+		opts.Filter = filter
+	}
+}
+
+// WithExportCheckpointEvery sets the number of events published between
+// the checkpoints of the resume token
+func WithExportCheckpointEvery(n int) ExportOption {
+	return func(opts *ExportOptions) {
+		opts.CheckpointEvery = n
+	}
+}
+
+// WithExportResyncCallback sets the callback invoked when the changes
+// since the checkpoint are lost
+func WithExportResyncCallback(fn func()) ExportOption {
+	return func(opts *ExportOptions) {
+		opts.ResyncCallback = fn
+	}
+}
+
+// WithExportErrorCallback sets the callback for the irrecoverable errors
+// of the watch
+func WithExportErrorCallback(fn func(err error)) ExportOption {
+	return func(opts *ExportOptions) {
+		opts.ErrorCallback = fn
+	}
+}
+
+func getExportOptions(opts []ExportOption) (*ExportOptions, error) {
+	eopts := &ExportOptions{}
+	for _, opt := range opts {
+		opt(eopts)
+	}
+	if eopts.CheckpointEvery < 0 {
+		return nil, errors.Wrap(errors.InvalidArgument, "checkpoint interval must not be negative")
+	}
+	if eopts.CheckpointEvery == 0 {
+		eopts.CheckpointEvery = 1
+	}
+	return eopts, nil
+}
+
+// key of the checkpoint of an exporter
+type exportCheckpointKey struct {
+	Name string `bson:"name"`
+}
+
+// checkpoint of an exporter, ie. the resume token of the last event
+// published
+type exportCheckpoint struct {
+	Token       bson.Raw       `bson:"token"`
+	ClusterTime bson.Timestamp `bson:"clusterTime"`
+	UpdatedAt   time.Time      `bson:"updatedAt"`
+}
+
+// ChangeExporter tails the change stream of a collection and publishes
+// the typed events to the sink with at-least-once delivery, an event is
+// retried until the sink accepts it, holding the subsequent events, and
+// the resume token is checkpointed in the checkpoint collection once
+// published, allowing the export to resume after a restart
+type ChangeExporter[K any, E any] struct {
+	name        string
+	col         StoreCollection
+	checkpoints StoreCollection
+	sink        ChangeSink[K, E]
+	opts        *ExportOptions
+
+	// events published since the last checkpoint, events are delivered
+	// in order by a single go routine of the watch
+	pending int
+}
+
+// NewChangeExporter creates the exporter with the given name, publishing
+// the change events of col to the sink, the name identifies the
+// checkpoint in checkpointCol and needs to be unique per exporter
+func NewChangeExporter[K any, E any](name string, col StoreCollection, checkpointCol StoreCollection, sink ChangeSink[K, E], opts ...ExportOption) (*ChangeExporter[K, E], error) {
+	if name == "" {
+		return nil, errors.Wrap(errors.InvalidArgument, "change exporter name is required")
+	}
+	if col == nil || checkpointCol == nil {
+		return nil, errors.Wrap(errors.InvalidArgument, "exported and checkpoint collections are required")
+	}
+	if sink == nil {
+		return nil, errors.Wrap(errors.InvalidArgument, "change sink is required")
+	}
+	eopts, err := getExportOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeExporter[K, E]{
+		name:        name,
+		col:         col,
+		checkpoints: checkpointCol,
+		sink:        sink,
+		opts:        eopts,
+	}, nil
+}
+
+// Checkpoint returns the resume token checkpointed for the exporter, nil
+// if no event is published yet
+func (x *ChangeExporter[K, E]) Checkpoint(ctx context.Context) (bson.Raw, error) {
+	cp := &exportCheckpoint{}
+	err := x.checkpoints.FindOne(ctx, &exportCheckpointKey{Name: x.name}, cp)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return cp.Token, nil
+}
+
+// Reset removes the checkpoint, the export starts from the current time
+// on the next start
+func (x *ChangeExporter[K, E]) Reset(ctx context.Context) error {
+	err := x.checkpoints.DeleteOne(ctx, &exportCheckpointKey{Name: x.name})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Start starts exporting the changes following the checkpoint, or from
+// the current time if there is none, until the context is cancelled
+func (x *ChangeExporter[K, E]) Start(ctx context.Context) error {
+	token, err := x.Checkpoint(ctx)
+	if err != nil {
+		return err
+	}
+	wopts := []WatchOption{
+		WithWatchResumeAfter(token),
+		WithWatchResyncCallback(func() {
+			log.Printf("change exporter %s resumed without history, changes since checkpoint are lost", x.name)
+			if x.opts.ResyncCallback != nil {
+				x.opts.ResyncCallback()
+			}
+		}),
+	}
+	if x.opts.ErrorCallback != nil {
+		wopts = append(wopts, WithWatchErrorCallback(x.opts.ErrorCallback))
+	}
+	return WatchEvents(ctx, x.col, x.opts.Filter, func(event *Event[K, E]) {
+		x.export(ctx, event)
+	}, wopts...)
+}
+
+// publishes the event retrying until the sink accepts it or the context
+// is done, checkpointing the resume token once published
+func (x *ChangeExporter[K, E]) export(ctx context.Context, event *Event[K, E]) {
+	for attempt := 1; ; attempt++ {
+		err := x.sink.Publish(ctx, event)
+		if err == nil {
+			break
+		}
+		log.Printf("change exporter %s failed to publish %s event for %v, attempt %d: %s", x.name, event.Op, event.Doc.Key, attempt, err)
+		select {
+		case <-ctx.Done():
+			// not checkpointed, published again on restart
+			return
+		case <-time.After(watchBackoff(attempt)):
+		}
+	}
+
+	x.pending++
+	if x.pending < x.opts.CheckpointEvery {
+		return
+	}
+	x.pending = 0
+
+	cp := &exportCheckpoint{
+		Token:       event.Token,
+		ClusterTime: event.Time,
+		UpdatedAt:   time.Now(),
+	}
+	err := x.checkpoints.UpdateOne(ctx, &exportCheckpointKey{Name: x.name}, cp, true)
+	if err != nil && ctx.Err() == nil {
+		// events since the previous checkpoint are published again on
+		// restart
+		log.Printf("change exporter %s failed to checkpoint: %s", x.name, err)
+	}
+}
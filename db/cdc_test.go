@@ -0,0 +1,152 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// sink recording the published events, failing the configured number of
+// attempts first
+type testChangeSink struct {
+	mu       sync.Mutex
+	failures int
+	events   []*Event[MyKey, memTestData]
+}
+
+func (s *testChangeSink) Publish(ctx context.Context, event *Event[MyKey, memTestData]) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.Wrap(errors.Unavailable, "sink unavailable")
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *testChangeSink) published() []*Event[MyKey, memTestData] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Event[MyKey, memTestData]{}, s.events...)
+}
+
+func (s *testChangeSink) wait(t *testing.T, count int) []*Event[MyKey, memTestData] {
+	for range 200 {
+		if events := s.published(); len(events) >= count {
+			return events
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d events to be published, got %d", count, len(s.published()))
+	return nil
+}
+
+func Test_ChangeExporter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("invalid", func(t *testing.T) {
+		col := NewMemoryCollection("entries")
+		cps := NewMemoryCollection("checkpoints")
+		sink := &testChangeSink{}
+		if _, err := NewChangeExporter[MyKey, memTestData]("", col, cps, sink); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without name, got %v", err)
+		}
+		if _, err := NewChangeExporter[MyKey, memTestData]("export", col, nil, sink); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without checkpoint collection, got %v", err)
+		}
+		if _, err := NewChangeExporter[MyKey, memTestData]("export", col, cps, nil); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without sink, got %v", err)
+		}
+		_, err := NewChangeExporter[MyKey, memTestData]("export", col, cps, sink, WithExportCheckpointEvery(-1))
+		if !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for negative checkpoint interval, got %v", err)
+		}
+	})
+
+	t.Run("export", func(t *testing.T) {
+		col := NewMemoryCollection("entries")
+		cps := NewMemoryCollection("checkpoints")
+		// first attempts fail, event is retried holding the later ones
+		sink := &testChangeSink{failures: 2}
+		x, err := NewChangeExporter[MyKey, memTestData]("export", col, cps, sink)
+		if err != nil {
+			t.Fatalf("failed to create exporter: %s", err)
+		}
+		xctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		if err := x.Start(xctx); err != nil {
+			t.Fatalf("failed to start exporter: %s", err)
+		}
+		_ = col.InsertOne(ctx, &MyKey{Name: "a"}, &memTestData{Desc: "a"})
+		_ = col.InsertOne(ctx, &MyKey{Name: "b"}, &memTestData{Desc: "b"})
+		_ = col.DeleteOne(ctx, &MyKey{Name: "a"})
+
+		events := sink.wait(t, 3)
+		if events[0].Doc.Key.Name != "a" || events[1].Doc.Key.Name != "b" || events[2].Op != MongoDeleteOp {
+			t.Errorf("expected events to be published in order, got %v, %v, %v", events[0].Doc.Key, events[1].Doc.Key, events[2].Op)
+		}
+		if events[0].Entry == nil || events[0].Entry.Desc != "a" || events[0].Token == nil {
+			t.Errorf("expected typed event with token, got %+v", events[0])
+		}
+
+		var token bson.Raw
+		for range 100 {
+			token, err = x.Checkpoint(ctx)
+			if err == nil && token.String() == events[2].Token.String() {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if token.String() != events[2].Token.String() {
+			t.Errorf("expected checkpoint of the last event %s, got %s, %v", events[2].Token, token, err)
+		}
+
+		if err := x.Reset(ctx); err != nil {
+			t.Errorf("failed to reset checkpoint: %s", err)
+		}
+		if token, err := x.Checkpoint(ctx); token != nil || err != nil {
+			t.Errorf("expected no checkpoint after reset, got %s, %v", token, err)
+		}
+	})
+
+	t.Run("resync", func(t *testing.T) {
+		col := NewMemoryCollection("entries")
+		cps := NewMemoryCollection("checkpoints")
+		sink := &testChangeSink{}
+		var resyncs atomic.Int32
+		x, _ := NewChangeExporter[MyKey, memTestData]("export", col, cps, sink,
+			WithExportResyncCallback(func() { resyncs.Add(1) }))
+
+		xctx, cancel := context.WithCancel(ctx)
+		if err := x.Start(xctx); err != nil {
+			t.Fatalf("failed to start exporter: %s", err)
+		}
+		if resyncs.Load() != 0 {
+			t.Errorf("expected no resync without checkpoint")
+		}
+		_ = col.InsertOne(ctx, &MyKey{Name: "a"}, &memTestData{Desc: "a"})
+		sink.wait(t, 1)
+		cancel()
+
+		// in-memory store doesn't retain the history to resume from
+		// the checkpoint
+		xctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		if err := x.Start(xctx); err != nil {
+			t.Fatalf("failed to restart exporter: %s", err)
+		}
+		if resyncs.Load() != 1 {
+			t.Errorf("expected resync while resuming from checkpoint, got %d", resyncs.Load())
+		}
+	})
+}
@@ -0,0 +1,134 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// TimeSeriesGranularity is the expected interval between the consecutive
+// measurements with the same meta field value
+type TimeSeriesGranularity string
+
+const (
+	TimeSeriesSeconds TimeSeriesGranularity = "seconds"
+	TimeSeriesMinutes TimeSeriesGranularity = "minutes"
+	TimeSeriesHours   TimeSeriesGranularity = "hours"
+)
+
+// TimeSeriesOptions describes the time-series collection to be created
+type TimeSeriesOptions struct {
+	// TimeField is the name of the field holding the time of the
+	// measurement, required
+	TimeField string
+
+	// MetaField is the name of the field holding the metadata identifying
+	// the series, optional
+	MetaField string
+
+	// Granularity of the measurements
+	// Default: "" (seconds)
+	Granularity TimeSeriesGranularity
+}
+
+// CollectionOptions describes the collection to be created explicitly,
+// where the zero value creates a regular collection
+type CollectionOptions struct {
+	// Capped creates a fixed size collection, retaining the documents in
+	// insertion order and removing the oldest ones once the collection
+	// exceeds the MaxSize in bytes or MaxDocuments
+	Capped       bool
+	MaxSize      int64
+	MaxDocuments int64
+
+	// TimeSeries creates a time-series collection
+	TimeSeries *TimeSeriesOptions
+
+	// ExpireAfter removes the measurements of the time-series collection
+	// once they are older than the duration
+	// Default: 0 (never expire)
+	ExpireAfter time.Duration
+
+	// Validator is the $jsonSchema the documents of the collection are
+	// validated against, rejecting the inserts and updates resulting in
+	// malformed documents
+	// Default: nil (no validation)
+	Validator any
+}
+
+// returns true if the options ask for a regular collection
+func (o *CollectionOptions) isRegular() bool {
+	return !o.Capped && o.TimeSeries == nil
+}
+
+func (o *CollectionOptions) validate() error {
+	if o.Capped {
+		if o.TimeSeries != nil {
+			return errors.Wrap(errors.InvalidArgument, "capped collection cannot be a time-series collection")
+		}
+		if o.MaxSize <= 0 {
+			return errors.Wrap(errors.InvalidArgument, "max size is required for a capped collection")
+		}
+		if o.MaxDocuments < 0 {
+			return errors.Wrap(errors.InvalidArgument, "max documents must not be negative")
+		}
+	} else if o.MaxSize != 0 || o.MaxDocuments != 0 {
+		return errors.Wrap(errors.InvalidArgument, "max size and max documents are applicable only to capped collection")
+	}
+	if o.TimeSeries != nil {
+		if o.TimeSeries.TimeField == "" {
+			return errors.Wrap(errors.InvalidArgument, "time field is required for a time-series collection")
+		}
+		switch o.TimeSeries.Granularity {
+		case "", TimeSeriesSeconds, TimeSeriesMinutes, TimeSeriesHours:
+		default:
+			return errors.Wrapf(errors.InvalidArgument, "invalid time-series granularity %q", o.TimeSeries.Granularity)
+		}
+	}
+	if o.ExpireAfter < 0 {
+		return errors.Wrap(errors.InvalidArgument, "expire after must not be negative")
+	}
+	if o.ExpireAfter != 0 && o.TimeSeries == nil {
+		return errors.Wrap(errors.InvalidArgument, "expire after is applicable only to time-series collection")
+	}
+	if o.Validator != nil {
+		if _, err := toSchema(o.Validator); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// builds the mongo create collection options
+func (o *CollectionOptions) createOptions() *options.CreateCollectionOptionsBuilder {
+	opts := options.CreateCollection()
+	if o.Capped {
+		opts.SetCapped(true).SetSizeInBytes(o.MaxSize)
+		if o.MaxDocuments != 0 {
+			opts.SetMaxDocuments(o.MaxDocuments)
+		}
+	}
+	if o.TimeSeries != nil {
+		ts := options.TimeSeries().SetTimeField(o.TimeSeries.TimeField)
+		if o.TimeSeries.MetaField != "" {
+			ts.SetMetaField(o.TimeSeries.MetaField)
+		}
+		if o.TimeSeries.Granularity != "" {
+			ts.SetGranularity(string(o.TimeSeries.Granularity))
+		}
+		opts.SetTimeSeriesOptions(ts)
+	}
+	if o.ExpireAfter != 0 {
+		opts.SetExpireAfterSeconds(int64(o.ExpireAfter / time.Second))
+	}
+	if o.Validator != nil {
+		opts.SetValidator(bson.D{{Key: "$jsonSchema", Value: o.Validator}})
+	}
+	return opts
+}
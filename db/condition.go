@@ -0,0 +1,62 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// UpdateCondition passed along with the options of FindOneAndUpdate
+// restricts the update to the entry for the key only if it also matches
+// the filter, providing compare-and-swap semantics on the state of the
+// entry, where NotFound is returned if the entry doesn't match.
+// Conditional updates do not support upsert
+type UpdateCondition struct {
+	// Filter the entry for the key needs to match for the update to be
+	// applied
+	Filter any
+}
+
+// separates the update condition from the options passed to an
+// operation, returning the filter the entry needs to match, nil if the
+// operation is unconditional
+func splitCondition(opts []any) ([]any, bson.D, error) {
+	var filter bson.D
+	rest := opts[:0:0]
+	for _, opt := range opts {
+		var cond UpdateCondition
+		switch val := opt.(type) {
+		case UpdateCondition:
+			cond = val
+		case *UpdateCondition:
+			if val == nil {
+				continue
+			}
+			cond = *val
+		default:
+			rest = append(rest, opt)
+			continue
+		}
+		if cond.Filter == nil {
+			return nil, nil, errors.Wrap(errors.InvalidArgument, "update condition requires a filter")
+		}
+		f, err := toDocument(cond.Filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		filter = append(filter, f...)
+	}
+	return rest, filter, nil
+}
+
+// returns true if the document satisfies the update condition, where a
+// nil filter is always satisfied
+func satisfiesCondition(doc bson.D, filter bson.D) (bool, error) {
+	if filter == nil {
+		return true, nil
+	}
+	return matchDocument(doc, filter)
+}
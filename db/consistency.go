@@ -0,0 +1,171 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+	"go.mongodb.org/mongo-driver/v2/mongo/writeconcern"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// ReadPreference determines the members of the replica set serving the
+// reads for a collection
+type ReadPreference string
+
+const (
+	// reads are served only by the primary
+	ReadPrimary ReadPreference = "primary"
+
+	// reads are served by the primary, secondaries if it is unavailable
+	ReadPrimaryPreferred ReadPreference = "primaryPreferred"
+
+	// reads are served only by the secondaries
+	ReadSecondary ReadPreference = "secondary"
+
+	// reads are served by the secondaries, primary if none is available
+	ReadSecondaryPreferred ReadPreference = "secondaryPreferred"
+
+	// reads are served by the member with the least network latency
+	ReadNearest ReadPreference = "nearest"
+)
+
+// ReadConcern determines the consistency and isolation of the data read
+// from a collection
+type ReadConcern string
+
+const (
+	ReadConcernLocal        ReadConcern = "local"
+	ReadConcernAvailable    ReadConcern = "available"
+	ReadConcernMajority     ReadConcern = "majority"
+	ReadConcernLinearizable ReadConcern = "linearizable"
+	ReadConcernSnapshot     ReadConcern = "snapshot"
+)
+
+// WriteConcern determines the acknowledgement requested for the writes to
+// a collection
+type WriteConcern string
+
+const (
+	// writes are not acknowledged
+	WriteConcernUnacknowledged WriteConcern = "0"
+
+	// writes are acknowledged by the primary
+	WriteConcernAcknowledged WriteConcern = "1"
+
+	// writes are acknowledged by the primary after writing to the journal
+	WriteConcernJournaled WriteConcern = "journaled"
+
+	// writes are acknowledged once replicated to the majority of the members
+	WriteConcernMajority WriteConcern = "majority"
+)
+
+// ConsistencyOptions overrides the read preference, read concern and write
+// concern of a collection, empty values retain the client defaults
+type ConsistencyOptions struct {
+	ReadPreference ReadPreference
+	ReadConcern    ReadConcern
+	WriteConcern   WriteConcern
+
+	// WriteNodes requests a custom write concern, where the writes are
+	// acknowledged once replicated to the given number of members,
+	// can't be combined with WriteConcern
+	WriteNodes int
+}
+
+// builds the collection options for the consistency options
+func (o ConsistencyOptions) collectionOptions() (*options.CollectionOptionsBuilder, error) {
+	opts := options.Collection()
+	if o.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(string(o.ReadPreference))
+		if err != nil {
+			return nil, errors.Wrapf(errors.InvalidArgument, "invalid read preference %q", o.ReadPreference)
+		}
+		rp, err := readpref.New(mode)
+		if err != nil {
+			return nil, errors.Wrapf(errors.InvalidArgument, "invalid read preference %q: %s", o.ReadPreference, err)
+		}
+		opts.SetReadPreference(rp)
+	}
+	switch o.ReadConcern {
+	case "":
+	case ReadConcernLocal:
+		opts.SetReadConcern(readconcern.Local())
+	case ReadConcernAvailable:
+		opts.SetReadConcern(readconcern.Available())
+	case ReadConcernMajority:
+		opts.SetReadConcern(readconcern.Majority())
+	case ReadConcernLinearizable:
+		opts.SetReadConcern(readconcern.Linearizable())
+	case ReadConcernSnapshot:
+		opts.SetReadConcern(readconcern.Snapshot())
+	default:
+		return nil, errors.Wrapf(errors.InvalidArgument, "invalid read concern %q", o.ReadConcern)
+	}
+	if o.WriteNodes != 0 {
+		if o.WriteNodes < 0 || o.WriteConcern != "" {
+			return nil, errors.Wrapf(errors.InvalidArgument, "invalid write nodes %d with write concern %q", o.WriteNodes, o.WriteConcern)
+		}
+		opts.SetWriteConcern(&writeconcern.WriteConcern{W: o.WriteNodes})
+	}
+	switch o.WriteConcern {
+	case "":
+	case WriteConcernUnacknowledged:
+		opts.SetWriteConcern(writeconcern.Unacknowledged())
+	case WriteConcernAcknowledged:
+		opts.SetWriteConcern(writeconcern.W1())
+	case WriteConcernJournaled:
+		opts.SetWriteConcern(writeconcern.Journaled())
+	case WriteConcernMajority:
+		opts.SetWriteConcern(writeconcern.Majority())
+	default:
+		return nil, errors.Wrapf(errors.InvalidArgument, "invalid write concern %q", o.WriteConcern)
+	}
+	return opts, nil
+}
+
+// separates the consistency options from the options passed to an
+// operation, returning the collection options to run the operation with,
+// nil if not overridden. ConsistencyOptions passed along with the other
+// options of an operation override the consistency for that operation only
+func splitConsistency(opts []any) ([]any, *options.CollectionOptionsBuilder, error) {
+	var colOpts *options.CollectionOptionsBuilder
+	rest := opts[:0:0]
+	for _, opt := range opts {
+		var copts ConsistencyOptions
+		switch val := opt.(type) {
+		case ConsistencyOptions:
+			copts = val
+		case *ConsistencyOptions:
+			if val == nil {
+				continue
+			}
+			copts = *val
+		default:
+			rest = append(rest, opt)
+			continue
+		}
+		var err error
+		colOpts, err = copts.collectionOptions()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return rest, colOpts, nil
+}
+
+// returns the collection options to run the operation with, for the
+// operations accepting only the consistency options, nil if not overridden
+func operationConsistency(op string, opts []any) (*options.CollectionOptionsBuilder, error) {
+	rest, colOpts, err := splitConsistency(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.Wrapf(errors.InvalidArgument, "Invalid option type %T passed to %s", rest[0], op)
+	}
+	return colOpts, nil
+}
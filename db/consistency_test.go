@@ -0,0 +1,105 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+func Test_ConsistencyOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  ConsistencyOptions
+		valid bool
+	}{
+		{"defaults", ConsistencyOptions{}, true},
+		{"reporting", ConsistencyOptions{ReadPreference: ReadSecondaryPreferred, ReadConcern: ReadConcernLocal}, true},
+		{"critical", ConsistencyOptions{ReadPreference: ReadPrimary, ReadConcern: ReadConcernMajority, WriteConcern: WriteConcernMajority}, true},
+		{"invalid_read_preference", ConsistencyOptions{ReadPreference: "secondaryOnly"}, false},
+		{"invalid_read_concern", ConsistencyOptions{ReadConcern: "strong"}, false},
+		{"invalid_write_concern", ConsistencyOptions{WriteConcern: "2"}, false},
+		{"write_nodes", ConsistencyOptions{WriteNodes: 2}, true},
+		{"write_nodes_with_write_concern", ConsistencyOptions{WriteNodes: 2, WriteConcern: WriteConcernMajority}, false},
+		{"negative_write_nodes", ConsistencyOptions{WriteNodes: -1}, false},
+	}
+	col := NewMemoryCollection("consistency")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := col.WithConsistency(tt.opts)
+			if tt.valid && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if !tt.valid && !errors.IsInvalidArgument(err) {
+				t.Errorf("expected invalid argument error, got %v", err)
+			}
+		})
+	}
+}
+
+func Test_OperationConsistency(t *testing.T) {
+	ctx := context.Background()
+	find := options.Find().SetLimit(1)
+	secondary := ConsistencyOptions{ReadPreference: ReadSecondaryPreferred}
+
+	rest, colOpts, err := splitConsistency([]any{find, secondary})
+	if err != nil || colOpts == nil || len(rest) != 1 || rest[0] != any(find) {
+		t.Errorf("expected consistency options to be separated, got %v, %v, err %v", rest, colOpts, err)
+	}
+	rest, colOpts, err = splitConsistency([]any{find})
+	if err != nil || colOpts != nil || len(rest) != 1 {
+		t.Errorf("expected no consistency override, got %v, %v, err %v", rest, colOpts, err)
+	}
+
+	col := NewMemoryCollection("operation-consistency")
+	_ = col.InsertOne(ctx, &MyKey{Name: "a"}, &memTestData{Desc: "a"})
+	list := []memTestData{}
+	if err := col.FindMany(ctx, nil, &list, find, &secondary); err != nil || len(list) != 1 {
+		t.Errorf("expected find with consistency override to succeed, got %v, err %v", list, err)
+	}
+	err = col.FindMany(ctx, nil, &list, ConsistencyOptions{ReadConcern: "strong"})
+	if !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for invalid override, got %v", err)
+	}
+	data := &memTestData{}
+	update := bson.M{"$set": bson.M{"count": 1}}
+	majority := ConsistencyOptions{WriteConcern: WriteConcernMajority}
+	if err := col.FindOneAndUpdate(ctx, &MyKey{Name: "a"}, update, data, majority); err != nil {
+		t.Errorf("expected update with consistency override to succeed, got %v", err)
+	}
+
+	primary := ConsistencyOptions{ReadPreference: ReadPrimary, ReadConcern: ReadConcernMajority}
+	key := &MyKey{Name: "b"}
+	if err := col.InsertOne(ctx, key, &memTestData{Desc: "b"}, majority); err != nil {
+		t.Errorf("expected insert with consistency override to succeed, got %v", err)
+	}
+	if err := col.UpdateOne(ctx, key, &memTestData{Desc: "b", Count: 1}, false, majority); err != nil {
+		t.Errorf("expected update one with consistency override to succeed, got %v", err)
+	}
+	if err := col.FindOne(ctx, key, data, primary); err != nil || data.Count != 1 {
+		t.Errorf("expected find one with consistency override to succeed, got %v, err %v", data, err)
+	}
+	if count, err := col.Count(ctx, nil, secondary); err != nil || count != 2 {
+		t.Errorf("expected count with consistency override to succeed, got %d, err %v", count, err)
+	}
+	if err := col.DeleteOne(ctx, key, majority); err != nil {
+		t.Errorf("expected delete with consistency override to succeed, got %v", err)
+	}
+	if count, err := col.DeleteMany(ctx, nil, majority); err != nil || count != 1 {
+		t.Errorf("expected delete many with consistency override to succeed, got %d, err %v", count, err)
+	}
+
+	// driver options are not accepted by these operations
+	if err := col.FindOne(ctx, key, data, find); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for driver options, got %v", err)
+	}
+	if _, err := col.Count(ctx, nil, ConsistencyOptions{ReadConcern: "strong"}); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for invalid override, got %v", err)
+	}
+}
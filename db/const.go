@@ -8,6 +8,9 @@ package db
 
 const (
 	defaultSourceIdentifier = "MongoClientCore"
+
+	// name of the library reported as the driver info to mongo db
+	driverInfoName = "go-core-stack/core"
 )
 
 const (
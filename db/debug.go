@@ -0,0 +1,226 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// default latency beyond which the operation is logged as slow
+const defaultSlowQueryThreshold = 100 * time.Millisecond
+
+// QueryExplainer is implemented by the collections capable of reporting
+// the plan chosen by the database for a query, where op is either
+// "find" with the filter and find options, or "aggregate" with the
+// pipeline and aggregate options
+type QueryExplainer interface {
+	Explain(ctx context.Context, op string, query any, opts ...any) (bson.Raw, error)
+}
+
+// DebugOptions provides the configuration for investigating the
+// performance of the collection operations
+type DebugOptions struct {
+	// SlowThreshold is the latency beyond which the operation is logged
+	// along with its filter
+	// Default: 100ms
+	SlowThreshold time.Duration
+
+	// Explain logs the query plan of the slow find and aggregate
+	// operations, for the collections implementing QueryExplainer
+	Explain bool
+
+	// Logf is used to log the slow operations
+	// Default: log.Printf
+	Logf func(format string, args ...any)
+}
+
+// DebugOption is a functional option for configuring the debugging
+type DebugOption func(*DebugOptions)
+
+// WithSlowQueryThreshold sets the latency beyond which the operation is
+// logged as slow, zero logs every operation
+func WithSlowQueryThreshold(threshold time.Duration) DebugOption {
+	return func(opts *DebugOptions) {
+		opts.SlowThreshold = threshold
+	}
+}
+
+// WithQueryExplain enables logging the query plan of the slow find and
+// aggregate operations
+func WithQueryExplain() DebugOption {
+	return func(opts *DebugOptions) {
+		opts.Explain = true
+	}
+}
+
+// WithDebugLogger sets the function used to log the slow operations
+func WithDebugLogger(logf func(format string, args ...any)) DebugOption {
+	return func(opts *DebugOptions) {
+		opts.Logf = logf
+	}
+}
+
+func getDebugOptions(opts []DebugOption) *DebugOptions {
+	dopts := &DebugOptions{SlowThreshold: -1}
+	for _, opt := range opts {
+		opt(dopts)
+	}
+	if dopts.SlowThreshold < 0 {
+		dopts.SlowThreshold = defaultSlowQueryThreshold
+	}
+	if dopts.Logf == nil {
+		dopts.Logf = log.Printf
+	}
+	return dopts
+}
+
+// debugCollection logs the slow operations of the underlying collection
+type debugCollection struct {
+	StoreCollection
+	opts *DebugOptions
+}
+
+// NewDebugCollection returns a handle to the collection logging the
+// operations exceeding the latency threshold with their filters, and
+// optionally the query plan of the slow find and aggregate operations,
+// enabling performance investigations without the driver level access.
+// Watch is not covered, as it is long running.
+func NewDebugCollection(col StoreCollection, opts ...DebugOption) StoreCollection {
+	return &debugCollection{
+		StoreCollection: col,
+		opts:            getDebugOptions(opts),
+	}
+}
+
+// runs the operation, logging it if slow, where the query is logged as
+// part of the message and explained for find and aggregate operations
+func (c *debugCollection) run(ctx context.Context, op string, query any, fn func() error, opts ...any) error {
+	start := time.Now()
+	err := fn()
+	elapsed := time.Since(start)
+	if elapsed < c.opts.SlowThreshold {
+		return err
+	}
+	c.opts.Logf("slow %s took %s, query: %v, err: %v", op, elapsed, query, err)
+	if !c.opts.Explain {
+		return err
+	}
+	explainOp := ""
+	switch op {
+	case "FindMany":
+		explainOp = "find"
+	case "Aggregate":
+		explainOp = "aggregate"
+	default:
+		return err
+	}
+	explainer, ok := c.StoreCollection.(QueryExplainer)
+	if !ok {
+		return err
+	}
+	plan, eerr := explainer.Explain(ctx, explainOp, query, opts...)
+	if eerr != nil {
+		c.opts.Logf("failed to explain slow %s: %s", op, eerr)
+	} else {
+		c.opts.Logf("query plan of slow %s: %s", op, plan)
+	}
+	return err
+}
+
+// Explain reports the plan chosen for the query by the underlying
+// collection, if supported
+func (c *debugCollection) Explain(ctx context.Context, op string, query any, opts ...any) (bson.Raw, error) {
+	explainer, ok := c.StoreCollection.(QueryExplainer)
+	if !ok {
+		return nil, errors.Wrap(errors.InvalidArgument, "explain is not supported by the collection")
+	}
+	return explainer.Explain(ctx, op, query, opts...)
+}
+
+func (c *debugCollection) InsertOne(ctx context.Context, key any, data any, opts ...any) error {
+	return c.run(ctx, "InsertOne", key, func() error {
+		return c.StoreCollection.InsertOne(ctx, key, data, opts...)
+	})
+}
+
+func (c *debugCollection) UpdateOne(ctx context.Context, key any, data any, upsert bool, opts ...any) error {
+	return c.run(ctx, "UpdateOne", key, func() error {
+		return c.StoreCollection.UpdateOne(ctx, key, data, upsert, opts...)
+	})
+}
+
+func (c *debugCollection) FindOne(ctx context.Context, key any, data any, opts ...any) error {
+	return c.run(ctx, "FindOne", key, func() error {
+		return c.StoreCollection.FindOne(ctx, key, data, opts...)
+	})
+}
+
+func (c *debugCollection) FindOneAndUpdate(ctx context.Context, key any, update any, data any, opts ...any) error {
+	return c.run(ctx, "FindOneAndUpdate", key, func() error {
+		return c.StoreCollection.FindOneAndUpdate(ctx, key, update, data, opts...)
+	})
+}
+
+func (c *debugCollection) FindOneAndDelete(ctx context.Context, filter any, data any, opts ...any) error {
+	return c.run(ctx, "FindOneAndDelete", filter, func() error {
+		return c.StoreCollection.FindOneAndDelete(ctx, filter, data, opts...)
+	})
+}
+
+func (c *debugCollection) FindMany(ctx context.Context, filter any, data any, opts ...any) error {
+	return c.run(ctx, "FindMany", filter, func() error {
+		return c.StoreCollection.FindMany(ctx, filter, data, opts...)
+	}, opts...)
+}
+
+func (c *debugCollection) Count(ctx context.Context, filter any, opts ...any) (int64, error) {
+	var count int64
+	err := c.run(ctx, "Count", filter, func() error {
+		var err error
+		count, err = c.StoreCollection.Count(ctx, filter, opts...)
+		return err
+	})
+	return count, err
+}
+
+func (c *debugCollection) DeleteOne(ctx context.Context, key any, opts ...any) error {
+	return c.run(ctx, "DeleteOne", key, func() error {
+		return c.StoreCollection.DeleteOne(ctx, key, opts...)
+	})
+}
+
+func (c *debugCollection) DeleteMany(ctx context.Context, filter any, opts ...any) (int64, error) {
+	var count int64
+	err := c.run(ctx, "DeleteMany", filter, func() error {
+		var err error
+		count, err = c.StoreCollection.DeleteMany(ctx, filter, opts...)
+		return err
+	})
+	return count, err
+}
+
+func (c *debugCollection) Aggregate(ctx context.Context, pipeline any, result any, opts ...any) error {
+	return c.run(ctx, "Aggregate", pipeline, func() error {
+		return c.StoreCollection.Aggregate(ctx, pipeline, result, opts...)
+	}, opts...)
+}
+
+// WithConsistency returns a handle to the same collection using the
+// given consistency options, retaining the debugging
+func (c *debugCollection) WithConsistency(opts ConsistencyOptions) (StoreCollection, error) {
+	col, err := c.StoreCollection.WithConsistency(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &debugCollection{
+		StoreCollection: col,
+		opts:            c.opts,
+	}, nil
+}
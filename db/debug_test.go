@@ -0,0 +1,119 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// explainCollection reports a fixed plan for the queries
+type explainCollection struct {
+	StoreCollection
+	queries []any
+}
+
+func (c *explainCollection) Explain(ctx context.Context, op string, query any, opts ...any) (bson.Raw, error) {
+	c.queries = append(c.queries, query)
+	return bson.Marshal(bson.D{{Key: "winningPlan", Value: op}})
+}
+
+type debugLog struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *debugLog) logf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func Test_DebugCollection(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("threshold", func(t *testing.T) {
+		logs := &debugLog{}
+		col := NewDebugCollection(NewMemoryCollection("debug"), WithDebugLogger(logs.logf), WithSlowQueryThreshold(time.Hour))
+		_ = col.InsertOne(ctx, &MyKey{Name: "a"}, &memTestData{Desc: "a"})
+		_, _ = col.Count(ctx, bson.D{{Key: "desc", Value: "a"}})
+		if len(logs.lines) != 0 {
+			t.Errorf("expected no logs below the threshold, got %v", logs.lines)
+		}
+	})
+
+	t.Run("slow", func(t *testing.T) {
+		logs := &debugLog{}
+		col := NewDebugCollection(NewMemoryCollection("debug"), WithDebugLogger(logs.logf), WithSlowQueryThreshold(0))
+		_ = col.InsertOne(ctx, &MyKey{Name: "a"}, &memTestData{Desc: "a"})
+		count, err := col.Count(ctx, bson.D{{Key: "desc", Value: "a"}})
+		if err != nil || count != 1 {
+			t.Errorf("expected operation to pass through, got %d, %v", count, err)
+		}
+		if len(logs.lines) != 2 || !strings.Contains(logs.lines[1], "slow Count") || !strings.Contains(logs.lines[1], "desc") {
+			t.Errorf("expected slow operations to be logged with the filter, got %v", logs.lines)
+		}
+	})
+
+	t.Run("explain", func(t *testing.T) {
+		logs := &debugLog{}
+		inner := &explainCollection{StoreCollection: NewMemoryCollection("debug")}
+		col := NewDebugCollection(inner, WithDebugLogger(logs.logf), WithSlowQueryThreshold(0), WithQueryExplain())
+		filter := bson.D{{Key: "desc", Value: "a"}}
+		list := []memTestData{}
+		if err := col.FindMany(ctx, filter, &list, options.Find().SetLimit(1)); err != nil {
+			t.Fatalf("failed to find entries: %s", err)
+		}
+		_, _ = col.Count(ctx, filter)
+		if len(inner.queries) != 1 {
+			t.Errorf("expected only the find to be explained, got %v", inner.queries)
+		}
+		if len(logs.lines) != 3 || !strings.Contains(logs.lines[1], "query plan of slow FindMany") {
+			t.Errorf("expected the query plan to be logged, got %v", logs.lines)
+		}
+
+		// consistency handle retains the debugging
+		col, err := col.WithConsistency(ConsistencyOptions{ReadConcern: "majority"})
+		if err != nil {
+			t.Fatalf("failed to get consistency handle: %s", err)
+		}
+		if _, ok := col.(*debugCollection); !ok {
+			t.Errorf("expected debug collection, got %T", col)
+		}
+		if _, err := col.(QueryExplainer).Explain(ctx, "find", filter); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected explain to be unsupported by memory collection, got %v", err)
+		}
+	})
+
+	t.Run("find_command", func(t *testing.T) {
+		cmd, err := mongoFindCommand("items", nil, []any{options.Find().SetSort(bson.D{{Key: "name", Value: 1}}).SetLimit(5)})
+		if err != nil {
+			t.Fatalf("failed to build find command: %s", err)
+		}
+		expected := bson.D{
+			{Key: "find", Value: "items"},
+			{Key: "filter", Value: bson.D{}},
+			{Key: "sort", Value: bson.D{{Key: "name", Value: 1}}},
+			{Key: "limit", Value: int64(5)},
+		}
+		if fmt.Sprint(cmd) != fmt.Sprint(expected) {
+			t.Errorf("expected %v, got %v", expected, cmd)
+		}
+		if _, err := mongoFindCommand("items", nil, []any{"invalid"}); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for invalid option, got %v", err)
+		}
+		if _, err := mongoAggregateCommand("items", bson.D{}, nil); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for invalid pipeline, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,358 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// common document handling for the store implementations evaluating the
+// filters and generating the change events on their own, instead of
+// relying on mongodb for the same
+
+// docWatcher holds the state of a watch registered on the collection
+// events are queued by the writers and delivered in order by a dedicated
+// go routine, ensuring writers never block on the watch callbacks
+type docWatcher struct {
+	ctx     context.Context
+	filter  []bson.D // $match stages of the filter pipeline
+	deliver func(event bson.D)
+	mu      sync.Mutex
+	queue   []bson.D
+	notify  chan struct{}
+}
+
+// creates a watcher for the filter pipeline, the delivery loop needs to
+// be started by the caller using run
+func newDocWatcher(ctx context.Context, filter any, deliver func(event bson.D)) (*docWatcher, error) {
+	stages, err := watchFilterStages(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &docWatcher{
+		ctx:     ctx,
+		filter:  stages,
+		deliver: deliver,
+		notify:  make(chan struct{}, 1),
+	}, nil
+}
+
+// queue the event for delivery, if it matches the filter pipeline
+func (w *docWatcher) enqueue(event bson.D) {
+	for _, stage := range w.filter {
+		ok, err := matchDocument(event, stage)
+		if err != nil || !ok {
+			return
+		}
+	}
+	w.mu.Lock()
+	w.queue = append(w.queue, event)
+	w.mu.Unlock()
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run the delivery loop until the context is canceled
+func (w *docWatcher) run() {
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-w.notify:
+		}
+		w.mu.Lock()
+		events := w.queue
+		w.queue = nil
+		w.mu.Unlock()
+		for _, event := range events {
+			if w.ctx.Err() != nil {
+				return
+			}
+			w.deliver(event)
+		}
+	}
+}
+
+// validates the watch filter pipeline, only $match stages are supported
+func watchFilterStages(filter any) ([]bson.D, error) {
+	if filter == nil {
+		return nil, nil
+	}
+	pipeline, ok := filter.(mongo.Pipeline)
+	if !ok {
+		return nil, errors.Wrapf(errors.InvalidArgument, "Invalid watch filter pipeline type specified, %v", filter)
+	}
+	stages := []bson.D{}
+	for _, stage := range pipeline {
+		if len(stage) != 1 || stage[0].Key != "$match" {
+			return nil, errors.Wrapf(errors.InvalidArgument, "unsupported watch pipeline stage %v", stage)
+		}
+		match, err := toDocument(stage[0].Value)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, match)
+	}
+	return stages, nil
+}
+
+// builds the change event in the format of mongodb change streams
+func changeEvent(seq uint64, ts time.Time, op, dbName, colName string, id any, doc bson.D, update bson.D) bson.D {
+	event := bson.D{
+		{Key: "_id", Value: bson.D{{Key: "_data", Value: strconv.FormatUint(seq, 16)}}},
+		{Key: "operationType", Value: op},
+		{Key: "clusterTime", Value: bson.Timestamp{T: uint32(ts.Unix()), I: uint32(seq)}},
+		{Key: "wallTime", Value: bson.NewDateTimeFromTime(ts)},
+		{Key: "ns", Value: bson.D{{Key: "db", Value: dbName}, {Key: "coll", Value: colName}}},
+		{Key: "documentKey", Value: bson.D{{Key: "_id", Value: id}}},
+	}
+	if doc != nil {
+		event = append(event, bson.E{Key: "fullDocument", Value: copyDocument(doc)})
+	}
+	if update != nil {
+		event = append(event, bson.E{Key: "updateDescription", Value: update})
+	}
+	return event
+}
+
+// builds the update description for the change event
+func updateDescription(before, after bson.D) bson.D {
+	updated := bson.D{}
+	removed := bson.A{}
+	for _, e := range after {
+		if old, ok := lookupField(before, e.Key); !ok || compareValues(old, e.Value) != 0 {
+			updated = append(updated, e)
+		}
+	}
+	for _, e := range before {
+		if _, ok := lookupField(after, e.Key); !ok {
+			removed = append(removed, e.Key)
+		}
+	}
+	return bson.D{{Key: "updatedFields", Value: updated}, {Key: "removedFields", Value: removed}}
+}
+
+// decodes the document key of the change event into the key type, bson.M
+// if the key type is not set
+func decodeWatchKey(keyType reflect.Type, id any) (any, error) {
+	var key any
+	if keyType != nil {
+		key = reflect.New(keyType.Elem()).Interface()
+	} else {
+		key = bson.M{}
+	}
+	raw, err := bson.Marshal(bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return nil, err
+	}
+	err = bson.Raw(raw).Lookup("_id").Unmarshal(key)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// returns the delivery function for Watch, invoking the callback with the
+// operation type and the decoded key
+func watchDelivery(keyType reflect.Type, cb WatchCallbackfn) func(event bson.D) {
+	return func(event bson.D) {
+		op, _ := lookupField(event, "operationType")
+		id, _ := lookupField(event, "documentKey._id")
+		key, err := decodeWatchKey(keyType, id)
+		if err != nil {
+			log.Printf("failed to decode key for watch: %s", err)
+			return
+		}
+		cb(op.(string), key)
+	}
+}
+
+// returns the delivery function decoding the events into the event type
+// and passing the pointer to the callback
+func eventDelivery(eventType reflect.Type, cb func(event any)) func(event bson.D) {
+	return func(event bson.D) {
+		e := reflect.New(eventType)
+		if err := decodeDocument(event, e.Interface()); err != nil {
+			log.Printf("failed to decode event: %s", err)
+			return
+		}
+		cb(e.Interface())
+	}
+}
+
+// invokes the LogEvent method of the decoded event
+func logEvent(event any) {
+	method := reflect.ValueOf(event).MethodByName("LogEvent")
+	if !method.IsValid() {
+		log.Println("Invalid Log Events method, skipping event logging")
+		return
+	}
+	method.Call([]reflect.Value{})
+}
+
+// decodes the document into the object pointed by data
+func decodeDocument(doc bson.D, data any) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, data)
+}
+
+// decodes the list of documents into the slice pointed by data
+func decodeDocuments(docs []bson.D, data any) error {
+	val := reflect.ValueOf(data)
+	if val.Kind() != reflect.Pointer || val.Elem().Kind() != reflect.Slice {
+		return errors.Wrapf(errors.InvalidArgument, "result argument must be a pointer to a slice, got %T", data)
+	}
+	slice := val.Elem()
+	elemType := slice.Type().Elem()
+	list := reflect.MakeSlice(slice.Type(), 0, len(docs))
+	for _, doc := range docs {
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		var elem reflect.Value
+		switch {
+		case elemType == reflect.TypeOf(bson.Raw{}):
+			elem = reflect.ValueOf(bson.Raw(raw))
+		case elemType.Kind() == reflect.Pointer:
+			elem = reflect.New(elemType.Elem())
+			if err := bson.Unmarshal(raw, elem.Interface()); err != nil {
+				return err
+			}
+		default:
+			ptr := reflect.New(elemType)
+			if err := bson.Unmarshal(raw, ptr.Interface()); err != nil {
+				return err
+			}
+			elem = ptr.Elem()
+		}
+		list = reflect.Append(list, elem)
+	}
+	slice.Set(list)
+	return nil
+}
+
+// collects the find options passed to FindMany
+func findOptions(opts []any) (*options.FindOptions, error) {
+	opts, _, err := splitConsistency(opts)
+	if err != nil {
+		return nil, err
+	}
+	args := &options.FindOptions{}
+	for _, opt := range opts {
+		val, ok := opt.(options.Lister[options.FindOptions])
+		if !ok {
+			return nil, errors.Wrapf(errors.InvalidArgument, "Invalid option type %T passed to FindMany", opt)
+		}
+		for _, fn := range val.List() {
+			if err := fn(args); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return args, nil
+}
+
+// applies sort, skip and limit of the find options to the documents
+// matching the filter
+func applyFindOptions(docs []bson.D, args *options.FindOptions) ([]bson.D, error) {
+	if args.Sort != nil {
+		spec, err := toDocument(args.Sort)
+		if err != nil {
+			return nil, err
+		}
+		sortDocuments(docs, spec)
+	}
+	if args.Skip != nil && *args.Skip > 0 {
+		if int(*args.Skip) >= len(docs) {
+			docs = docs[:0]
+		} else {
+			docs = docs[*args.Skip:]
+		}
+	}
+	if args.Limit != nil && *args.Limit > 0 && int(*args.Limit) < len(docs) {
+		docs = docs[:*args.Limit]
+	}
+	return docs, nil
+}
+
+// filters the documents matching the filter
+func filterDocuments(docs []bson.D, filter any) ([]bson.D, error) {
+	f, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	matched := []bson.D{}
+	for _, doc := range docs {
+		ok, err := matchDocument(doc, f)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, doc)
+		}
+	}
+	return matched, nil
+}
+
+// runs the aggregation pipeline on the documents, only $match, $sort,
+// $skip and $limit stages are supported
+func aggregateDocuments(docs []bson.D, pipeline any) ([]bson.D, error) {
+	stages, ok := pipeline.(mongo.Pipeline)
+	if !ok {
+		return nil, errors.Wrapf(errors.InvalidArgument, "invalid aggregate pipeline type specified, %T", pipeline)
+	}
+	for _, stage := range stages {
+		if len(stage) != 1 {
+			return nil, errors.Wrapf(errors.InvalidArgument, "invalid aggregate pipeline stage %v", stage)
+		}
+		switch stage[0].Key {
+		case "$match":
+			var err error
+			docs, err = filterDocuments(docs, stage[0].Value)
+			if err != nil {
+				return nil, err
+			}
+		case "$sort":
+			spec, err := toDocument(stage[0].Value)
+			if err != nil {
+				return nil, err
+			}
+			sortDocuments(docs, spec)
+		case "$skip", "$limit":
+			val, err := toValue(stage[0].Value)
+			if err != nil {
+				return nil, err
+			}
+			n := int(toFloat(val))
+			if n > len(docs) {
+				n = len(docs)
+			}
+			if stage[0].Key == "$skip" {
+				docs = docs[n:]
+			} else {
+				docs = docs[:n]
+			}
+		default:
+			return nil, errors.Wrapf(errors.InvalidArgument, "unsupported aggregate pipeline stage %s", stage[0].Key)
+		}
+	}
+	return docs, nil
+}
@@ -0,0 +1,857 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// number of attempts for a read-modify-write on a key, before giving
+	// up due to concurrent modifications
+	etcdUpdateAttempts = 10
+)
+
+// EtcdKeyValue is a key value pair stored in etcd along with its
+// revisions
+type EtcdKeyValue struct {
+	Key            string
+	Value          []byte
+	CreateRevision int64
+	ModRevision    int64
+}
+
+// EtcdEventType is the type of the etcd watch event
+type EtcdEventType int
+
+const (
+	EtcdEventPut EtcdEventType = iota
+	EtcdEventDelete
+)
+
+// EtcdEvent is a change of a key delivered by the etcd watch
+type EtcdEvent struct {
+	Type EtcdEventType
+	Kv   EtcdKeyValue
+
+	// PrevKv is the key value before the change, if available
+	PrevKv *EtcdKeyValue
+}
+
+// EtcdKV is the subset of the etcd client used by the etcd store,
+// allowing the application to provide an adapter over clientv3 without
+// adding the etcd client as a dependency of this module
+type EtcdKV interface {
+	// Get returns the key values for the key, or all the keys with the
+	// key as prefix when prefix is set, along with the current revision
+	Get(ctx context.Context, key string, prefix bool) ([]EtcdKeyValue, int64, error)
+
+	// CompareAndPut stores the value for the key, only if the current mod
+	// revision of the key matches modRev, where zero means the key must
+	// not exist, returns false if the comparison failed
+	CompareAndPut(ctx context.Context, key string, value []byte, modRev int64) (bool, error)
+
+	// CompareAndDelete deletes the key, only if the current mod revision
+	// of the key matches modRev, returns false if the comparison failed
+	CompareAndDelete(ctx context.Context, key string, modRev int64) (bool, error)
+
+	// Watch delivers the events for the keys with the prefix starting
+	// from the given revision, until the context is canceled, with the
+	// previous key value included in the events, returns NotFound error
+	// if the revision is already compacted
+	Watch(ctx context.Context, prefix string, rev int64, cb func(ev EtcdEvent)) error
+}
+
+// returns the collection prefix, where every document is stored with key
+// as "/<db>/<collection>/<serialized _id>"
+func etcdPrefix(dbName, colName string) string {
+	return "/" + dbName + "/" + colName + "/"
+}
+
+// etcdStore implements Store over an etcd key space, where every
+// collection is a key prefix
+type etcdStore struct {
+	kv   EtcdKV
+	name string
+	res  *clientResources // watches stopped on disconnect
+}
+
+// etcdCollection implements StoreCollection over etcd, storing every
+// document as a bson encoded value keyed by the serialized `_id`.
+// Every write is a compare-and-swap on the mod revision of the key,
+// providing linearizable updates, while Watch uses the etcd watch making
+// it suitable for the coordination data of the sync package.
+type etcdCollection struct {
+	kv      EtcdKV
+	dbName  string
+	colName string
+	keyType reflect.Type
+	res     *clientResources // watches stopped on disconnect
+}
+
+func (c *etcdCollection) prefix() string {
+	return etcdPrefix(c.dbName, c.colName)
+}
+
+// returns the etcd key for the document key
+func (c *etcdCollection) docKey(key any) (string, any, error) {
+	docID, id, err := postgresDocID(key)
+	if err != nil {
+		return "", nil, err
+	}
+	return c.prefix() + docID, id, nil
+}
+
+// decodes the stored value into the document
+func etcdDecode(value []byte) (bson.D, error) {
+	doc := bson.D{}
+	if err := bson.Unmarshal(value, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// returns the documents of the collection in natural order along with
+// the mod revision of each of them
+func (c *etcdCollection) allDocs(ctx context.Context) ([]bson.D, []int64, error) {
+	kvs, _, err := c.kv.Get(ctx, c.prefix(), true)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(kvs, func(i, j int) bool {
+		return kvs[i].CreateRevision < kvs[j].CreateRevision
+	})
+	docs := make([]bson.D, 0, len(kvs))
+	revs := make([]int64, 0, len(kvs))
+	for _, kv := range kvs {
+		doc, err := etcdDecode(kv.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+		docs = append(docs, doc)
+		revs = append(revs, kv.ModRevision)
+	}
+	return docs, revs, nil
+}
+
+// returns the documents matching the filter in natural order along with
+// the mod revision of each of them
+func (c *etcdCollection) matchDocs(ctx context.Context, filter any) ([]bson.D, []int64, error) {
+	f, err := toDocument(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	docs, revs, err := c.allDocs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	mDocs := []bson.D{}
+	mRevs := []int64{}
+	for i, doc := range docs {
+		ok, err := matchDocument(doc, f)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			mDocs = append(mDocs, doc)
+			mRevs = append(mRevs, revs[i])
+		}
+	}
+	return mDocs, mRevs, nil
+}
+
+// Set KeyType for the collection, this is not mandatory
+// while the key type will be used by the interface implementer
+// mainly for Watch Callback for providing decoded key, if not
+// set watch will be working with the default decoders of
+// interface implementer
+// only pointer key type is supported as of now
+// returns error if the key type is not a pointer
+func (c *etcdCollection) SetKeyType(keyType reflect.Type) error {
+	if keyType.Kind() != reflect.Ptr {
+		// return error, as only pointer key type is supported
+		return errors.Wrap(errors.InvalidArgument, "key type is not a pointer")
+	}
+	c.keyType = keyType
+	return nil
+}
+
+// inserts one entry with given key and data to the collection
+// returns errors if entry already exists
+func (c *etcdCollection) InsertOne(ctx context.Context, key any, data any, opts ...any) error {
+	if _, err := operationConsistency("InsertOne", opts); err != nil {
+		return err
+	}
+	if data == nil {
+		return errors.Wrap(errors.InvalidArgument, "db Insert error: No data to store")
+	}
+	if key == nil {
+		return errors.Wrap(errors.InvalidArgument, "db Insert error: No Key specified to store")
+	}
+	k, id, err := c.docKey(key)
+	if err != nil {
+		return err
+	}
+	doc, err := memoryNewDoc(id, data)
+	if err != nil {
+		return err
+	}
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	ok, err := c.kv.CompareAndPut(ctx, k, raw, 0)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Wrapf(errors.AlreadyExists, "duplicate key error, key %v", key)
+	}
+	return nil
+}
+
+// applies the update to the document for the given key, returning the
+// document state before and after the update, before is nil if the
+// document is inserted as part of upsert
+func (c *etcdCollection) updateDoc(ctx context.Context, key any, update any, upsert bool, filter bson.D) (bson.D, bson.D, error) {
+	if key == nil {
+		return nil, nil, errors.Wrap(errors.InvalidArgument, "db Update error: No Key specified")
+	}
+	k, id, err := c.docKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	upd, err := toDocument(update)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := 0; i < etcdUpdateAttempts; i++ {
+		kvs, _, err := c.kv.Get(ctx, k, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		var before, after bson.D
+		modRev := int64(0)
+		if len(kvs) == 0 {
+			if !upsert {
+				return nil, nil, errors.Wrap(errors.NotFound, "No Document found")
+			}
+			after, err = applyUpdate(bson.D{{Key: "_id", Value: id}}, upd, true)
+		} else {
+			modRev = kvs[0].ModRevision
+			before, err = etcdDecode(kvs[0].Value)
+			if err != nil {
+				return nil, nil, err
+			}
+			if match, err := satisfiesCondition(before, filter); err != nil {
+				return nil, nil, err
+			} else if !match {
+				return nil, nil, errors.Wrap(errors.NotFound, "No Document found")
+			}
+			after, err = applyUpdate(before, upd, false)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		raw, err := bson.Marshal(after)
+		if err != nil {
+			return nil, nil, err
+		}
+		ok, err := c.kv.CompareAndPut(ctx, k, raw, modRev)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			return before, after, nil
+		}
+		// concurrent modification, retry with the latest revision
+	}
+	return nil, nil, errors.Wrapf(errors.Unknown, "db Update error: too many concurrent modifications for key %v", key)
+}
+
+// inserts or updates one entry with given key and data to the collection
+// acts based on the flag passed for upsert
+// returns errors if entry not found while upsert flag is false
+func (c *etcdCollection) UpdateOne(ctx context.Context, key any, data any, upsert bool, opts ...any) error {
+	if _, err := operationConsistency("UpdateOne", opts); err != nil {
+		return err
+	}
+	if data == nil {
+		return errors.Wrap(errors.InvalidArgument, "db Insert error: No data to store")
+	}
+	if key == nil {
+		return errors.Wrap(errors.InvalidArgument, "db Insert error: No Key specified to store")
+	}
+	_, _, err := c.updateDoc(ctx, key, bson.D{{Key: "$set", Value: data}}, upsert, nil)
+	return err
+}
+
+// Find one entry from the store collection for the given key, where the data
+// value is returned based on the object type passed to it
+func (c *etcdCollection) FindOne(ctx context.Context, key any, data any, opts ...any) error {
+	if _, err := operationConsistency("FindOne", opts); err != nil {
+		return err
+	}
+	k, _, err := c.docKey(key)
+	if err != nil {
+		return err
+	}
+	kvs, _, err := c.kv.Get(ctx, k, false)
+	if err != nil {
+		return err
+	}
+	if len(kvs) == 0 {
+		return errors.Wrap(errors.NotFound, mongo.ErrNoDocuments.Error())
+	}
+	return bson.Unmarshal(kvs[0].Value, data)
+}
+
+// Find one entry from the store collection for the given key and atomically
+// apply the update document to it, where the data value is returned based
+// on the object type passed to it
+func (c *etcdCollection) FindOneAndUpdate(ctx context.Context, key any, update any, data any, opts ...any) error {
+	if key == nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndUpdate error: No Key specified")
+	}
+	if update == nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndUpdate error: No update specified")
+	}
+	args := &options.FindOneAndUpdateOptions{}
+	opts, _, err := splitConsistency(opts)
+	if err != nil {
+		return err
+	}
+	opts, filter, err := splitCondition(opts)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		val, ok := opt.(options.Lister[options.FindOneAndUpdateOptions])
+		if !ok {
+			return errors.Wrapf(errors.InvalidArgument, "Invalid option type %T passed to FindOneAndUpdate", opt)
+		}
+		for _, fn := range val.List() {
+			if err := fn(args); err != nil {
+				return err
+			}
+		}
+	}
+	upsert := args.Upsert != nil && *args.Upsert
+	if upsert && filter != nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndUpdate error: Upsert not supported with update condition")
+	}
+	before, after, err := c.updateDoc(ctx, key, update, upsert, filter)
+	if err != nil {
+		return err
+	}
+	doc := before
+	if args.ReturnDocument != nil && *args.ReturnDocument == options.After {
+		doc = after
+	}
+	if doc == nil {
+		return errors.Wrap(errors.NotFound, mongo.ErrNoDocuments.Error())
+	}
+	return decodeDocument(doc, data)
+}
+
+// Find one entry from the store collection matching the given filter and
+// atomically delete it, where the data value holds the deleted entry
+// based on the object type passed to it
+func (c *etcdCollection) FindOneAndDelete(ctx context.Context, filter any, data any, opts ...any) error {
+	if filter == nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndDelete error: No filter specified")
+	}
+	args := &options.FindOneAndDeleteOptions{}
+	opts, _, err := splitConsistency(opts)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		val, ok := opt.(options.Lister[options.FindOneAndDeleteOptions])
+		if !ok {
+			return errors.Wrapf(errors.InvalidArgument, "Invalid option type %T passed to FindOneAndDelete", opt)
+		}
+		for _, fn := range val.List() {
+			if err := fn(args); err != nil {
+				return err
+			}
+		}
+	}
+	for i := 0; i < etcdUpdateAttempts; i++ {
+		docs, revs, err := c.matchDocs(ctx, filter)
+		if err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return errors.Wrap(errors.NotFound, mongo.ErrNoDocuments.Error())
+		}
+		idx := 0
+		if args.Sort != nil {
+			spec, err := toDocument(args.Sort)
+			if err != nil {
+				return err
+			}
+			sorted := make([]bson.D, len(docs))
+			copy(sorted, docs)
+			sortDocuments(sorted, spec)
+			for j, d := range docs {
+				if compareValues(d[0].Value, sorted[0][0].Value) == 0 {
+					idx = j
+					break
+				}
+			}
+		}
+		k, _, err := c.docKey(docs[idx][0].Value)
+		if err != nil {
+			return err
+		}
+		ok, err := c.kv.CompareAndDelete(ctx, k, revs[idx])
+		if err != nil {
+			return err
+		}
+		if ok {
+			return decodeDocument(docs[idx], data)
+		}
+		// document changed since evaluating the filter, retry
+	}
+	return errors.Wrap(errors.Unknown, "db FindOneAndDelete error: too many concurrent modifications")
+}
+
+// Find multiple entries from the store collection for the given filter, where the data
+// value is returned as a list based on the object type passed to it
+func (c *etcdCollection) FindMany(ctx context.Context, filter any, data any, opts ...any) error {
+	args, err := findOptions(opts)
+	if err != nil {
+		return err
+	}
+	docs, _, err := c.matchDocs(ctx, filter)
+	if err != nil {
+		return err
+	}
+	docs, err = applyFindOptions(docs, args)
+	if err != nil {
+		return err
+	}
+	return decodeDocuments(docs, data)
+}
+
+// Return count of entries matching the provided filter
+func (c *etcdCollection) Count(ctx context.Context, filter any, opts ...any) (int64, error) {
+	if _, err := operationConsistency("Count", opts); err != nil {
+		return 0, err
+	}
+	docs, _, err := c.matchDocs(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(docs)), nil
+}
+
+// remove one entry from the collection matching the given key
+func (c *etcdCollection) DeleteOne(ctx context.Context, key any, opts ...any) error {
+	if _, err := operationConsistency("DeleteOne", opts); err != nil {
+		return err
+	}
+	k, _, err := c.docKey(key)
+	if err != nil {
+		return err
+	}
+	kvs, _, err := c.kv.Get(ctx, k, false)
+	if err != nil {
+		return err
+	}
+	if len(kvs) == 0 {
+		return errors.Wrap(errors.NotFound, "No Document found")
+	}
+	ok, err := c.kv.CompareAndDelete(ctx, k, kvs[0].ModRevision)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// deleted or modified concurrently, retry to find out
+		return c.DeleteOne(ctx, key)
+	}
+	return nil
+}
+
+// Delete Many entries matching the delete criteria
+// returns number of entries deleted and if there is any error processing the request
+// entries modified concurrently after evaluating the filter are skipped
+func (c *etcdCollection) DeleteMany(ctx context.Context, filter any, opts ...any) (int64, error) {
+	if _, err := operationConsistency("DeleteMany", opts); err != nil {
+		return 0, err
+	}
+	docs, revs, err := c.matchDocs(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	if len(docs) == 0 {
+		return 0, errors.Wrap(errors.NotFound, "No matching entries found to delete")
+	}
+	count := int64(0)
+	for i, doc := range docs {
+		k, _, err := c.docKey(doc[0].Value)
+		if err != nil {
+			return count, err
+		}
+		ok, err := c.kv.CompareAndDelete(ctx, k, revs[i])
+		if err != nil {
+			return count, err
+		}
+		if ok {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// registers a watcher delivering the events to the callback until the
+// context is canceled, using the etcd watch on the collection prefix,
+// which is re-established from the last revision observed on failures
+func (c *etcdCollection) addWatcher(ctx context.Context, filter any, wopts *WatchOptions, deliver func(event bson.D)) error {
+	ctx, release, err := c.res.derive(ctx)
+	if err != nil {
+		return err
+	}
+	w, err := newDocWatcher(ctx, filter, deliver)
+	if err != nil {
+		release()
+		return err
+	}
+	_, rev, err := c.kv.Get(ctx, c.prefix(), true)
+	if err != nil {
+		release()
+		return err
+	}
+	c.res.goroutine(w.run)
+	c.res.goroutine(func() {
+		defer release()
+		failures := 0
+		for {
+			err := c.kv.Watch(ctx, c.prefix(), rev+1, func(ev EtcdEvent) {
+				failures = 0
+				rev = ev.Kv.ModRevision
+				event, err := c.changeEvent(ev)
+				if err != nil {
+					log.Printf("failed to process etcd event for %s: %s", ev.Kv.Key, err)
+					return
+				}
+				w.enqueue(event)
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			resync := false
+			if errors.IsNotFound(err) {
+				// revision compacted, continue from the current revision
+				_, cur, gerr := c.kv.Get(ctx, c.prefix(), true)
+				if gerr == nil {
+					rev = cur
+					resync = true
+				}
+			}
+			failures++
+			if failures > wopts.MaxRetries {
+				wopts.fail(c.prefix(), err)
+				return
+			}
+			if resync {
+				wopts.resync(c.prefix())
+				continue
+			}
+			log.Printf("etcd watch for %s interrupted, attempting to re-establish: %v", c.prefix(), err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(watchBackoff(failures)):
+			}
+		}
+	})
+	return nil
+}
+
+// converts the etcd event to the change event
+func (c *etcdCollection) changeEvent(ev EtcdEvent) (bson.D, error) {
+	key := bson.D{}
+	err := bson.UnmarshalExtJSON([]byte(strings.TrimPrefix(ev.Kv.Key, c.prefix())), true, &key)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, errors.Wrapf(errors.InvalidArgument, "invalid document key %s", ev.Kv.Key)
+	}
+	op := "delete"
+	var doc, update bson.D
+	if ev.Type == EtcdEventPut {
+		doc, err = etcdDecode(ev.Kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		op = "insert"
+		if ev.Kv.CreateRevision != ev.Kv.ModRevision {
+			op = "update"
+			var before bson.D
+			if ev.PrevKv != nil {
+				before, err = etcdDecode(ev.PrevKv.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+			update = updateDescription(before, doc)
+		}
+	}
+	return changeEvent(uint64(ev.Kv.ModRevision), time.Now(), op, c.dbName, c.colName, key[0].Value, doc, update), nil
+}
+
+// watch allows getting notified whenever a change happens to a document
+// in the collection
+// allow provisiong for a filter to be passed on, where the callback
+// function to receive only conditional notifications of the events
+// listener is interested about
+func (c *etcdCollection) Watch(ctx context.Context, filter any, cb WatchCallbackfn, opts ...WatchOption) error {
+	wopts := getWatchOptions(opts)
+	if err := c.addWatcher(ctx, filter, wopts, watchDelivery(c.keyType, cb)); err != nil {
+		return err
+	}
+	wopts.resumeUnsupported(c.prefix())
+	return nil
+}
+
+// Aggregate runs an aggregation pipeline against the collection, the
+// etcd collection only supports $match, $sort, $skip and $limit stages
+func (c *etcdCollection) Aggregate(ctx context.Context, pipeline any, result any, opts ...any) error {
+	docs, _, err := c.allDocs(ctx)
+	if err != nil {
+		return err
+	}
+	docs, err = aggregateDocuments(docs, pipeline)
+	if err != nil {
+		return err
+	}
+	return decodeDocuments(docs, result)
+}
+
+// EnsureIndexes records the indexes for the etcd collection, while the
+// indexes are not enforced as the documents are always looked up by
+// scanning the collection prefix
+func (c *etcdCollection) EnsureIndexes(ctx context.Context, indexes []IndexDefinition) error {
+	for _, idx := range indexes {
+		if _, err := c.CreateIndex(ctx, idx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returns the prefix for the index definitions of the collection, kept
+// outside the collection prefix
+func (c *etcdCollection) indexPrefix() string {
+	return "/" + c.dbName + "/.indexes/" + c.colName + "/"
+}
+
+// CreateIndex records the index definition, returning the name of the
+// index, the index is not enforced
+func (c *etcdCollection) CreateIndex(ctx context.Context, index IndexDefinition) (string, error) {
+	if err := prepareIndex(&index); err != nil {
+		return "", err
+	}
+	raw, err := bson.Marshal(&index)
+	if err != nil {
+		return "", err
+	}
+	k := c.indexPrefix() + index.Name
+	ok, err := c.kv.CompareAndPut(ctx, k, raw, 0)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		kvs, _, err := c.kv.Get(ctx, k, false)
+		if err != nil {
+			return "", err
+		}
+		if len(kvs) != 0 && !bytes.Equal(kvs[0].Value, raw) {
+			return "", errors.Wrapf(errors.AlreadyExists, "index %s already exists with different definition", index.Name)
+		}
+	}
+	return index.Name, nil
+}
+
+// ListIndexes returns the indexes created on the collection
+func (c *etcdCollection) ListIndexes(ctx context.Context) ([]IndexDefinition, error) {
+	kvs, _, err := c.kv.Get(ctx, c.indexPrefix(), true)
+	if err != nil {
+		return nil, err
+	}
+	list := []IndexDefinition{idIndex()}
+	for _, kv := range kvs {
+		idx := IndexDefinition{}
+		if err := bson.Unmarshal(kv.Value, &idx); err != nil {
+			return nil, err
+		}
+		list = append(list, idx)
+	}
+	return list, nil
+}
+
+// DropIndex removes the index with the given name
+func (c *etcdCollection) DropIndex(ctx context.Context, name string) error {
+	if name == idIndexName {
+		return errors.Wrapf(errors.InvalidArgument, "cannot drop the default index %s", idIndexName)
+	}
+	k := c.indexPrefix() + name
+	kvs, _, err := c.kv.Get(ctx, k, false)
+	if err != nil {
+		return err
+	}
+	if len(kvs) == 0 {
+		return errors.Wrapf(errors.NotFound, "index %s not found", name)
+	}
+	_, err = c.kv.CompareAndDelete(ctx, k, kvs[0].ModRevision)
+	return err
+}
+
+// WithConsistency validates the options and returns a handle to the same
+// collection, as etcd always provides linearizable reads and writes
+func (c *etcdCollection) WithConsistency(opts ConsistencyOptions) (StoreCollection, error) {
+	if _, err := opts.collectionOptions(); err != nil {
+		return nil, err
+	}
+	return &etcdCollection{
+		kv:      c.kv,
+		dbName:  c.dbName,
+		colName: c.colName,
+		keyType: c.keyType,
+	}, nil
+}
+
+func (c *etcdCollection) startEventLogger(ctx context.Context, eventType reflect.Type, timestamp *bson.Timestamp) error {
+	return c.addWatcher(ctx, nil, getWatchOptions(nil), eventDelivery(eventType, logEvent))
+}
+
+func (c *etcdCollection) watchEvents(ctx context.Context, filter any, eventType reflect.Type, cb func(event any), wopts *WatchOptions) error {
+	if err := c.addWatcher(ctx, filter, wopts, eventDelivery(eventType, cb)); err != nil {
+		return err
+	}
+	wopts.resumeUnsupported(c.prefix())
+	return nil
+}
+
+// Gets collection corresponding to the collection name
+func (s *etcdStore) GetCollection(col string) StoreCollection {
+	return &etcdCollection{
+		kv:      s.kv,
+		dbName:  s.name,
+		colName: col,
+		res:     s.res,
+	}
+}
+
+// CreateCollection explicitly creates the collection, where the
+// collections are implicit in etcd, capped and time-series collections
+// are not supported
+func (s *etcdStore) CreateCollection(ctx context.Context, name string, opts CollectionOptions) (StoreCollection, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if !opts.isRegular() {
+		return nil, errors.Wrap(errors.InvalidArgument, "capped and time-series collections are not supported by etcd store")
+	}
+	if opts.Validator != nil {
+		return nil, errors.Wrap(errors.InvalidArgument, "document validation is not supported by etcd store")
+	}
+	col := s.GetCollection(name).(*etcdCollection)
+	kvs, _, err := s.kv.Get(ctx, col.prefix(), true)
+	if err != nil {
+		return nil, err
+	}
+	if len(kvs) != 0 {
+		return nil, errors.Wrapf(errors.AlreadyExists, "collection %s already exists", name)
+	}
+	return col, nil
+}
+
+// SetValidator is not supported by etcd store
+func (s *etcdStore) SetValidator(ctx context.Context, col string, schema any) error {
+	return errors.Wrap(errors.InvalidArgument, "document validation is not supported by etcd store")
+}
+
+// GetBlobStore is not supported by etcd store
+func (s *etcdStore) GetBlobStore(bucket string, opts ...BlobStoreOption) (BlobStore, error) {
+	return nil, errors.Wrap(errors.InvalidArgument, "blob store is not supported by etcd store")
+}
+
+// Gets Name of the database corresponding to the store
+func (s *etcdStore) Name() string {
+	return s.name
+}
+
+// etcdClient implements StoreClient over etcd
+type etcdClient struct {
+	kv  EtcdKV
+	res *clientResources // watches stopped on disconnect
+}
+
+// NewEtcdClient creates a StoreClient backed by etcd using the provided
+// adapter over the etcd client. Meant for the coordination data like the
+// owner, lock and provider tables of the sync package, which benefit from
+// the linearizable writes and watches of etcd, while not suited for
+// large collections as every query scans the collection prefix
+func NewEtcdClient(kv EtcdKV) (StoreClient, error) {
+	if kv == nil {
+		return nil, errors.Wrap(errors.InvalidArgument, "etcd client is not specified")
+	}
+	return &etcdClient{kv: kv, res: newClientResources()}, nil
+}
+
+// Get the Data Store interface given the database name
+func (c *etcdClient) GetDataStore(dbName string) Store {
+	return &etcdStore{
+		kv:   c.kv,
+		name: dbName,
+		res:  c.res,
+	}
+}
+
+// Gets collection corresponding to the collection name inside the
+// requested database name
+func (c *etcdClient) GetCollection(dbName, col string) StoreCollection {
+	return c.GetDataStore(dbName).GetCollection(col)
+}
+
+// WithTransaction is not supported by the etcd store, as the updates are
+// done as compare-and-swap on individual keys
+func (c *etcdClient) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return errors.Wrap(errors.InvalidArgument, "transactions are not supported by etcd store")
+}
+
+// Disconnect stops all the watches started on the collections of the
+// client, the etcd client itself is owned by the caller and needs to be
+// closed separately
+func (c *etcdClient) Disconnect(ctx context.Context) error {
+	return c.res.close(ctx)
+}
+
+// Health Check, whether the etcd cluster is reachable
+func (c *etcdClient) HealthCheck(ctx context.Context) error {
+	_, _, err := c.kv.Get(ctx, "/health", false)
+	return err
+}
+
+// HealthReport returns the health of the etcd cluster, without the
+// member details
+func (c *etcdClient) HealthReport(ctx context.Context) (*HealthReport, error) {
+	return simpleHealthReport(ctx, c.HealthCheck)
+}
@@ -0,0 +1,363 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// in memory implementation of the etcd key value semantics used by the
+// etcd collection
+type fakeEtcdKV struct {
+	mu       sync.Mutex
+	rev      int64
+	kvs      map[string]EtcdKeyValue
+	history  []EtcdEvent
+	watchers []*fakeEtcdWatcher
+	compact  int64 // revisions up to compact are no longer available
+}
+
+type fakeEtcdWatcher struct {
+	ctx    context.Context
+	prefix string
+	events chan EtcdEvent
+	closed chan error
+}
+
+func newFakeEtcdKV() *fakeEtcdKV {
+	return &fakeEtcdKV{kvs: map[string]EtcdKeyValue{}}
+}
+
+func (f *fakeEtcdKV) Get(ctx context.Context, key string, prefix bool) ([]EtcdKeyValue, int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	list := []EtcdKeyValue{}
+	for k, kv := range f.kvs {
+		if k == key || (prefix && strings.HasPrefix(k, key)) {
+			list = append(list, kv)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list, f.rev, nil
+}
+
+func (f *fakeEtcdKV) notify(ev EtcdEvent) {
+	f.history = append(f.history, ev)
+	for _, w := range f.watchers {
+		if w.ctx.Err() == nil && strings.HasPrefix(ev.Kv.Key, w.prefix) {
+			w.events <- ev
+		}
+	}
+}
+
+func (f *fakeEtcdKV) CompareAndPut(ctx context.Context, key string, value []byte, modRev int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cur, ok := f.kvs[key]
+	if cur.ModRevision != modRev {
+		return false, nil
+	}
+	f.rev++
+	kv := EtcdKeyValue{Key: key, Value: value, CreateRevision: f.rev, ModRevision: f.rev}
+	var prev *EtcdKeyValue
+	if ok {
+		kv.CreateRevision = cur.CreateRevision
+		prev = &cur
+	}
+	f.kvs[key] = kv
+	f.notify(EtcdEvent{Type: EtcdEventPut, Kv: kv, PrevKv: prev})
+	return true, nil
+}
+
+func (f *fakeEtcdKV) CompareAndDelete(ctx context.Context, key string, modRev int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cur, ok := f.kvs[key]
+	if !ok || cur.ModRevision != modRev {
+		return false, nil
+	}
+	f.rev++
+	delete(f.kvs, key)
+	f.notify(EtcdEvent{Type: EtcdEventDelete, Kv: EtcdKeyValue{Key: key, ModRevision: f.rev}, PrevKv: &cur})
+	return true, nil
+}
+
+func (f *fakeEtcdKV) Watch(ctx context.Context, prefix string, rev int64, cb func(ev EtcdEvent)) error {
+	w := &fakeEtcdWatcher{ctx: ctx, prefix: prefix, events: make(chan EtcdEvent, 100), closed: make(chan error, 1)}
+	// replay the events since the revision, similar to etcd
+	f.mu.Lock()
+	if rev <= f.compact {
+		f.mu.Unlock()
+		return errors.Wrapf(errors.NotFound, "required revision %d has been compacted", rev)
+	}
+	replay := []EtcdEvent{}
+	for _, ev := range f.history {
+		if ev.Kv.ModRevision >= rev && strings.HasPrefix(ev.Kv.Key, prefix) {
+			replay = append(replay, ev)
+		}
+	}
+	f.watchers = append(f.watchers, w)
+	f.mu.Unlock()
+	for _, ev := range replay {
+		cb(ev)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-w.closed:
+			return err
+		case ev := <-w.events:
+			cb(ev)
+		}
+	}
+}
+
+// compacts the history and cancels the active watchers, while the
+// changes done afterwards are not delivered to the watchers until they
+// are re-established
+func (f *fakeEtcdKV) compactAndCancel(changes func()) {
+	f.mu.Lock()
+	watchers := f.watchers
+	f.watchers = nil
+	f.mu.Unlock()
+	for _, w := range watchers {
+		w.closed <- errors.Wrap(errors.Unknown, "watch canceled")
+	}
+	changes()
+	f.mu.Lock()
+	f.compact = f.rev
+	f.mu.Unlock()
+}
+
+type etcdTestKey struct {
+	Name string `bson:"name,omitempty"`
+}
+
+type etcdTestData struct {
+	Desc  string `bson:"desc,omitempty"`
+	Count int    `bson:"count,omitempty"`
+}
+
+func Test_EtcdFindOneAndUpdate(t *testing.T) {
+	ctx := context.Background()
+	client, _ := NewEtcdClient(newFakeEtcdKV())
+	col := client.GetCollection("test", "counters")
+	key := &etcdTestKey{Name: "counter"}
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "count", Value: 1}}}}
+
+	// concurrent increments must not be lost, with the first one
+	// creating the entry
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 2; j++ {
+				data := &etcdTestData{}
+				opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+				if err := col.FindOneAndUpdate(ctx, key, update, data, opts); err != nil {
+					t.Errorf("failed to increment: %s", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	data := &etcdTestData{}
+	err := col.FindOneAndUpdate(ctx, key, update, data, options.FindOneAndUpdate().SetReturnDocument(options.Before))
+	if err != nil || data.Count != 10 {
+		t.Errorf("expected count 10 before update, got %d, err %v", data.Count, err)
+	}
+	err = col.FindOneAndUpdate(ctx, &etcdTestKey{Name: "missing"}, update, data)
+	if !errors.IsNotFound(err) {
+		t.Errorf("expected not found without upsert, got %v", err)
+	}
+
+	if err := col.FindOneAndDelete(ctx, bson.D{{Key: "count", Value: 11}}, data); err != nil || data.Count != 11 {
+		t.Errorf("expected entry to be deleted, got %v, err %v", data, err)
+	}
+	if err := col.FindOneAndDelete(ctx, bson.D{{Key: "count", Value: 11}}, data); !errors.IsNotFound(err) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func Test_EtcdCollection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client, err := NewEtcdClient(newFakeEtcdKV())
+	if err != nil {
+		t.Fatalf("failed to create etcd client: %s", err)
+	}
+	col := client.GetCollection("test", "locks")
+	if err := col.SetKeyType(reflect.TypeOf(&etcdTestKey{})); err != nil {
+		t.Fatalf("failed to set key type: %s", err)
+	}
+
+	type watchEvent struct {
+		op  string
+		key string
+	}
+	events := make(chan watchEvent, 10)
+	err = col.Watch(ctx, nil, func(op string, key any) {
+		events <- watchEvent{op: op, key: key.(*etcdTestKey).Name}
+	})
+	if err != nil {
+		t.Fatalf("failed to watch: %s", err)
+	}
+	expectEvent := func(op, key string) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if ev.op != op || ev.key != key {
+				t.Errorf("expected event %s %s, got %v", op, key, ev)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("timed out waiting for event %s %s", op, key)
+		}
+	}
+
+	t.Run("insert", func(t *testing.T) {
+		key := &etcdTestKey{Name: "one"}
+		if err := col.InsertOne(ctx, key, &etcdTestData{Desc: "first", Count: 1}); err != nil {
+			t.Fatalf("failed to insert: %s", err)
+		}
+		expectEvent("insert", "one")
+		err := col.InsertOne(ctx, key, &etcdTestData{Desc: "again"})
+		if !errors.IsAlreadyExists(err) {
+			t.Errorf("expected already exists, got %v", err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		key := &etcdTestKey{Name: "one"}
+		if err := col.UpdateOne(ctx, key, &etcdTestData{Desc: "updated", Count: 2}, false); err != nil {
+			t.Fatalf("failed to update: %s", err)
+		}
+		expectEvent("update", "one")
+		data := &etcdTestData{}
+		if err := col.FindOne(ctx, key, data); err != nil {
+			t.Fatalf("failed to find: %s", err)
+		}
+		if data.Desc != "updated" || data.Count != 2 {
+			t.Errorf("unexpected data %v", data)
+		}
+		err := col.UpdateOne(ctx, &etcdTestKey{Name: "two"}, &etcdTestData{Desc: "second"}, false)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found, got %v", err)
+		}
+		if err := col.UpdateOne(ctx, &etcdTestKey{Name: "two"}, &etcdTestData{Desc: "second", Count: 5}, true); err != nil {
+			t.Fatalf("failed to upsert: %s", err)
+		}
+		expectEvent("insert", "two")
+	})
+
+	t.Run("find_many", func(t *testing.T) {
+		list := []etcdTestData{}
+		err := col.FindMany(ctx, bson.D{{Key: "count", Value: bson.D{{Key: "$gt", Value: 1}}}}, &list)
+		if err != nil {
+			t.Fatalf("failed to find many: %s", err)
+		}
+		if len(list) != 2 || list[0].Desc != "updated" || list[1].Desc != "second" {
+			t.Errorf("unexpected entries %v", list)
+		}
+		count, err := col.Count(ctx, bson.D{{Key: "desc", Value: "second"}})
+		if err != nil || count != 1 {
+			t.Errorf("expected count 1, got %d, %v", count, err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := col.DeleteOne(ctx, &etcdTestKey{Name: "one"}); err != nil {
+			t.Fatalf("failed to delete: %s", err)
+		}
+		expectEvent("delete", "one")
+		if err := col.DeleteOne(ctx, &etcdTestKey{Name: "one"}); !errors.IsNotFound(err) {
+			t.Errorf("expected not found, got %v", err)
+		}
+		count, err := col.DeleteMany(ctx, bson.D{})
+		if err != nil || count != 1 {
+			t.Errorf("expected one entry deleted, got %d, %v", count, err)
+		}
+		expectEvent("delete", "two")
+	})
+}
+
+func Test_EtcdWatchResync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kv := newFakeEtcdKV()
+	client, _ := NewEtcdClient(kv)
+	col := client.GetCollection("test", "resync")
+	if err := col.SetKeyType(reflect.TypeOf(&etcdTestKey{})); err != nil {
+		t.Fatalf("failed to set key type: %s", err)
+	}
+
+	resync := make(chan struct{}, 1)
+	ops := make(chan string, 10)
+	err := col.Watch(ctx, nil, func(op string, key any) {
+		ops <- op
+	}, WithWatchResyncCallback(func() {
+		resync <- struct{}{}
+	}))
+	if err != nil {
+		t.Fatalf("failed to watch: %s", err)
+	}
+
+	// changes done while the watch is interrupted are lost due to
+	// compaction, requiring a resync
+	kv.compactAndCancel(func() {
+		if err := col.InsertOne(ctx, &etcdTestKey{Name: "lost"}, &etcdTestData{Desc: "lost"}); err != nil {
+			t.Fatalf("failed to insert: %s", err)
+		}
+	})
+	select {
+	case <-resync:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for resync")
+	}
+
+	if err := col.InsertOne(ctx, &etcdTestKey{Name: "after"}, &etcdTestData{Desc: "after"}); err != nil {
+		t.Fatalf("failed to insert: %s", err)
+	}
+	select {
+	case op := <-ops:
+		if op != "insert" {
+			t.Errorf("expected insert after resync, got %s", op)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("timed out waiting for event after resync")
+	}
+}
+
+func Test_EtcdCreateCollection(t *testing.T) {
+	ctx := context.Background()
+	client, _ := NewEtcdClient(newFakeEtcdKV())
+	store := client.GetDataStore("test")
+	_, err := store.CreateCollection(ctx, "events", CollectionOptions{Capped: true, MaxSize: 1024})
+	if !errors.IsInvalidArgument(err) {
+		t.Errorf("expected capped collection to be rejected, got %v", err)
+	}
+	col, err := store.CreateCollection(ctx, "events", CollectionOptions{})
+	if err != nil {
+		t.Fatalf("failed to create collection: %s", err)
+	}
+	if err := col.InsertOne(ctx, &etcdTestKey{Name: "one"}, &etcdTestData{Desc: "first"}); err != nil {
+		t.Fatalf("failed to insert: %s", err)
+	}
+	if _, err := store.CreateCollection(ctx, "events", CollectionOptions{}); !errors.IsAlreadyExists(err) {
+		t.Errorf("expected already exists error, got %v", err)
+	}
+}
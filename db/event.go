@@ -10,6 +10,8 @@ import (
 	"reflect"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/errors"
 )
 
 type DocumentKey[K any] struct {
@@ -27,6 +29,9 @@ type Namespace struct {
 }
 
 type Event[K any, E any] struct {
+	// Token is the resume token of the event, allowing the watch to be
+	// resumed after this event, see WithWatchResumeAfter
+	Token   bson.Raw              `bson:"_id,omitempty"`
 	Doc     DocumentKey[K]        `bson:"documentKey,omitempty"`
 	Op      string                `bson:"operationType,omitempty"`
 	Time    bson.Timestamp        `bson:"clusterTime,omitempty"`
@@ -72,3 +77,26 @@ func (l *EventLogger[K, E]) Start(ctx context.Context) error {
 
 	return l.col.startEventLogger(ctx, eventType, l.ts)
 }
+
+// EventCallbackfn is invoked for every typed change event observed on a
+// collection, carrying the decoded key and full document if available
+type EventCallbackfn[K any, E any] func(event *Event[K, E])
+
+// WatchEvents allows getting notified with typed change events whenever a
+// change happens to a document in the collection, unlike Watch which only
+// provides the key, event here also carries the full document for insert,
+// replace and update operations, while it remains nil for delete
+// allow provisioning for a filter pipeline to be passed on, where the
+// callback function to receive only conditional notifications of the
+// events listener is interested about
+func WatchEvents[K any, E any](ctx context.Context, col StoreCollection, filter any, cb EventCallbackfn[K, E], opts ...WatchOption) error {
+	if cb == nil {
+		return errors.Wrap(errors.InvalidArgument, "watch events callback is not specified")
+	}
+	var event Event[K, E]
+	eventType := reflect.TypeOf(event)
+
+	return col.watchEvents(ctx, filter, eventType, func(e any) {
+		cb(e.(*Event[K, E]))
+	}, getWatchOptions(opts))
+}
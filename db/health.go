@@ -0,0 +1,87 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// HealthStatus is the overall health of the store
+type HealthStatus string
+
+const (
+	// all the members are reachable and in sync
+	HealthStatusHealthy HealthStatus = "healthy"
+
+	// the store is serving the operations, while some of the members are
+	// unreachable or lagging behind
+	HealthStatusDegraded HealthStatus = "degraded"
+
+	// the store is unreachable or can't serve writes
+	HealthStatusDown HealthStatus = "down"
+)
+
+// MemberHealth describes the health of a member of the cluster
+type MemberHealth struct {
+	// address of the member
+	Name string
+
+	// state of the member, eg. PRIMARY, SECONDARY, RECOVERING
+	State string
+
+	// whether the member is reachable
+	Healthy bool
+
+	// replication lag of the member behind the primary
+	Lag time.Duration
+
+	// round trip latency to the member as observed by the server the
+	// client is connected to, zero for the server itself
+	Latency time.Duration
+}
+
+// HealthReport describes the health of the store in detail, allowing
+// the readiness probes to distinguish a degraded store from the one
+// that is down
+type HealthReport struct {
+	// overall health of the store
+	Status HealthStatus
+
+	// round trip latency observed by the client
+	Latency time.Duration
+
+	// name of the replica set, empty if not running as a replica set
+	ReplicaSet string
+
+	// address of the primary, empty if there is no primary
+	Primary string
+
+	// members of the cluster, empty if the store doesn't expose them
+	Members []MemberHealth
+
+	// reason for the store not being healthy, or for the details being
+	// incomplete
+	Reason string
+}
+
+// default replication lag beyond which the store is considered degraded
+const defaultMaxReplicationLag = 10 * time.Second
+
+// builds the health report for the stores without the member details,
+// using the health check of the store
+func simpleHealthReport(ctx context.Context, check func(ctx context.Context) error) (*HealthReport, error) {
+	start := time.Now()
+	err := check(ctx)
+	report := &HealthReport{
+		Status:  HealthStatusHealthy,
+		Latency: time.Since(start),
+	}
+	if err != nil {
+		report.Status = HealthStatusDown
+		report.Reason = err.Error()
+		return report, err
+	}
+	return report, nil
+}
@@ -0,0 +1,63 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_HealthReport(t *testing.T) {
+	now := time.Now()
+	member := func(name, state string, health float64, lag time.Duration) mongoReplMemberStatus {
+		return mongoReplMemberStatus{Name: name, StateStr: state, Health: health, OptimeDate: now.Add(-lag), PingMs: 2}
+	}
+
+	tests := []struct {
+		name    string
+		members []mongoReplMemberStatus
+		status  HealthStatus
+		primary string
+	}{
+		{"healthy", []mongoReplMemberStatus{
+			member("db-0:27017", "PRIMARY", 1, 0),
+			member("db-1:27017", "SECONDARY", 1, time.Second),
+		}, HealthStatusHealthy, "db-0:27017"},
+		{"lagging", []mongoReplMemberStatus{
+			member("db-0:27017", "PRIMARY", 1, 0),
+			member("db-1:27017", "SECONDARY", 1, time.Minute),
+		}, HealthStatusDegraded, "db-0:27017"},
+		{"unreachable", []mongoReplMemberStatus{
+			member("db-0:27017", "PRIMARY", 1, 0),
+			member("db-1:27017", "(not reachable/healthy)", 0, time.Hour),
+		}, HealthStatusDegraded, "db-0:27017"},
+		{"no_primary", []mongoReplMemberStatus{
+			member("db-0:27017", "SECONDARY", 1, 0),
+			member("db-1:27017", "SECONDARY", 1, 0),
+		}, HealthStatusDown, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &mongoReplSetStatus{Set: "rs0", Members: tt.members}
+			report := mongoHealthReport(status, time.Millisecond, defaultMaxReplicationLag)
+			if report.Status != tt.status || report.Primary != tt.primary {
+				t.Errorf("expected %s with primary %q, got %s with %q (%s)", tt.status, tt.primary, report.Status, report.Primary, report.Reason)
+			}
+			if report.Status != HealthStatusHealthy && report.Reason == "" {
+				t.Errorf("expected reason for status %s", report.Status)
+			}
+			if len(report.Members) != len(tt.members) || report.ReplicaSet != "rs0" {
+				t.Errorf("unexpected members %v", report.Members)
+			}
+		})
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		report, err := NewMemoryClient().HealthReport(context.Background())
+		if err != nil || report.Status != HealthStatusHealthy {
+			t.Errorf("expected healthy report, got %v, err %v", report, err)
+		}
+	})
+}
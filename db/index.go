@@ -0,0 +1,116 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// name of the default index on `_id` present on every collection
+const idIndexName = "_id_"
+
+// validates the index definition
+func validateIndex(idx *IndexDefinition) error {
+	if len(idx.Fields) == 0 {
+		return errors.Wrap(errors.InvalidArgument, "index definition must have at least one field")
+	}
+	for _, f := range idx.Fields {
+		if f.Field == "" {
+			return errors.Wrap(errors.InvalidArgument, "index field name must not be empty")
+		}
+		switch f.IndexType {
+		case IndexAscending, IndexDescending, IndexText:
+		default:
+			return errors.Wrapf(errors.InvalidArgument, "invalid index type %d for field %q", f.IndexType, f.Field)
+		}
+	}
+	if idx.TTL < 0 {
+		return errors.Wrap(errors.InvalidArgument, "index TTL must not be negative")
+	}
+	return nil
+}
+
+// returns the name of the index, generated from the fields similar to
+// mongodb if not specified
+func indexName(idx *IndexDefinition) string {
+	if idx.Name != "" {
+		return idx.Name
+	}
+	parts := []string{}
+	for _, f := range idx.Fields {
+		if f.IndexType == IndexText {
+			parts = append(parts, f.Field+"_text")
+		} else {
+			parts = append(parts, fmt.Sprintf("%s_%d", f.Field, f.IndexType))
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+// returns the definition of the default index on `_id`
+func idIndex() IndexDefinition {
+	return IndexDefinition{
+		Fields: []IndexField{{Field: "_id", IndexType: IndexAscending}},
+		Name:   idIndexName,
+	}
+}
+
+// indexSet tracks the index definitions of the collections not building
+// the indexes on their own, allowing ListIndexes to reflect the indexes
+// created
+type indexSet []IndexDefinition
+
+// validates the index definition and sets the name of the index, for
+// the collections tracking the index definitions on their own
+func prepareIndex(idx *IndexDefinition) error {
+	if err := validateIndex(idx); err != nil {
+		return err
+	}
+	idx.Name = indexName(idx)
+	if idx.Name == idIndexName {
+		return errors.Wrapf(errors.InvalidArgument, "index name %s is reserved", idIndexName)
+	}
+	return nil
+}
+
+// adds the index to the set, returning the name of the index, creating
+// an index with the same name and same definition is a no-op
+func (s *indexSet) add(idx IndexDefinition) (string, error) {
+	if err := prepareIndex(&idx); err != nil {
+		return "", err
+	}
+	for _, cur := range *s {
+		if cur.Name == idx.Name {
+			if !reflect.DeepEqual(cur, idx) {
+				return "", errors.Wrapf(errors.AlreadyExists, "index %s already exists with different definition", idx.Name)
+			}
+			return idx.Name, nil
+		}
+	}
+	*s = append(*s, idx)
+	return idx.Name, nil
+}
+
+// returns the indexes in the set, including the default index on `_id`
+func (s indexSet) list() []IndexDefinition {
+	return append([]IndexDefinition{idIndex()}, s...)
+}
+
+// removes the index with the given name from the set
+func (s *indexSet) remove(name string) error {
+	if name == idIndexName {
+		return errors.Wrapf(errors.InvalidArgument, "cannot drop the default index %s", idIndexName)
+	}
+	for i, cur := range *s {
+		if cur.Name == name {
+			*s = append((*s)[:i], (*s)[i+1:]...)
+			return nil
+		}
+	}
+	return errors.Wrapf(errors.NotFound, "index %s not found", name)
+}
@@ -0,0 +1,282 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+)
+
+// OperationInfo describes the database operation being instrumented
+type OperationInfo struct {
+	// name of the database (store), empty for client level operations
+	Database string
+
+	// name of the collection, empty for client level operations
+	Collection string
+
+	// name of the operation, eg. FindOne, InsertOne, Transaction
+	Operation string
+}
+
+// Instrumentation receives the database operations allowing them to be
+// traced and measured, eg. creating an OpenTelemetry span per operation
+// and recording the duration histogram and error counters, without the
+// dependency of this package on a specific telemetry library
+type Instrumentation interface {
+	// StartOperation is invoked before the operation, returning the
+	// context to run the operation with (eg. carrying the span) and the
+	// function to be invoked with the outcome once it completes
+	StartOperation(ctx context.Context, op OperationInfo) (context.Context, func(err error))
+}
+
+type instrumentedClient struct {
+	StoreClient
+	inst Instrumentation
+}
+
+// NewInstrumentedClient returns a handle to the client reporting every
+// operation on the stores and collections derived from it to the given
+// instrumentation. Watch and event logging are long running and only
+// the start of the stream is reported.
+func NewInstrumentedClient(client StoreClient, inst Instrumentation) StoreClient {
+	if inst == nil {
+		return client
+	}
+	return &instrumentedClient{
+		StoreClient: client,
+		inst:        inst,
+	}
+}
+
+func (c *instrumentedClient) GetDataStore(dbName string) Store {
+	return &instrumentedStore{
+		Store: c.StoreClient.GetDataStore(dbName),
+		inst:  c.inst,
+	}
+}
+
+func (c *instrumentedClient) GetCollection(dbName, col string) StoreCollection {
+	return c.GetDataStore(dbName).GetCollection(col)
+}
+
+func (c *instrumentedClient) HealthCheck(ctx context.Context) error {
+	ctx, done := c.inst.StartOperation(ctx, OperationInfo{Operation: "HealthCheck"})
+	err := c.StoreClient.HealthCheck(ctx)
+	done(err)
+	return err
+}
+
+func (c *instrumentedClient) HealthReport(ctx context.Context) (*HealthReport, error) {
+	ctx, done := c.inst.StartOperation(ctx, OperationInfo{Operation: "HealthReport"})
+	report, err := c.StoreClient.HealthReport(ctx)
+	done(err)
+	return report, err
+}
+
+func (c *instrumentedClient) Disconnect(ctx context.Context) error {
+	ctx, done := c.inst.StartOperation(ctx, OperationInfo{Operation: "Disconnect"})
+	err := c.StoreClient.Disconnect(ctx)
+	done(err)
+	return err
+}
+
+func (c *instrumentedClient) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, done := c.inst.StartOperation(ctx, OperationInfo{Operation: "Transaction"})
+	err := c.StoreClient.WithTransaction(ctx, fn)
+	done(err)
+	return err
+}
+
+type instrumentedStore struct {
+	Store
+	inst Instrumentation
+}
+
+func (s *instrumentedStore) GetCollection(col string) StoreCollection {
+	return s.wrap(s.Store.GetCollection(col), col)
+}
+
+func (s *instrumentedStore) CreateCollection(ctx context.Context, name string, opts CollectionOptions) (StoreCollection, error) {
+	ctx, done := s.inst.StartOperation(ctx, OperationInfo{
+		Database:   s.Name(),
+		Collection: name,
+		Operation:  "CreateCollection",
+	})
+	col, err := s.Store.CreateCollection(ctx, name, opts)
+	done(err)
+	if err != nil {
+		return nil, err
+	}
+	return s.wrap(col, name), nil
+}
+
+func (s *instrumentedStore) SetValidator(ctx context.Context, col string, schema any) error {
+	ctx, done := s.inst.StartOperation(ctx, OperationInfo{
+		Database:   s.Name(),
+		Collection: col,
+		Operation:  "SetValidator",
+	})
+	err := s.Store.SetValidator(ctx, col, schema)
+	done(err)
+	return err
+}
+
+func (s *instrumentedStore) wrap(col StoreCollection, name string) StoreCollection {
+	return &instrumentedCollection{
+		StoreCollection: col,
+		inst:            s.inst,
+		dbName:          s.Name(),
+		colName:         name,
+	}
+}
+
+// instrumentedCollection reports the operations of the underlying
+// collection to the instrumentation
+type instrumentedCollection struct {
+	StoreCollection
+	inst    Instrumentation
+	dbName  string
+	colName string
+}
+
+// runs the operation reporting it to the instrumentation
+func (c *instrumentedCollection) run(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	ctx, done := c.inst.StartOperation(ctx, OperationInfo{
+		Database:   c.dbName,
+		Collection: c.colName,
+		Operation:  op,
+	})
+	err := fn(ctx)
+	done(err)
+	return err
+}
+
+func (c *instrumentedCollection) InsertOne(ctx context.Context, key any, data any, opts ...any) error {
+	return c.run(ctx, "InsertOne", func(ctx context.Context) error {
+		return c.StoreCollection.InsertOne(ctx, key, data, opts...)
+	})
+}
+
+func (c *instrumentedCollection) UpdateOne(ctx context.Context, key any, data any, upsert bool, opts ...any) error {
+	return c.run(ctx, "UpdateOne", func(ctx context.Context) error {
+		return c.StoreCollection.UpdateOne(ctx, key, data, upsert, opts...)
+	})
+}
+
+func (c *instrumentedCollection) FindOne(ctx context.Context, key any, data any, opts ...any) error {
+	return c.run(ctx, "FindOne", func(ctx context.Context) error {
+		return c.StoreCollection.FindOne(ctx, key, data, opts...)
+	})
+}
+
+func (c *instrumentedCollection) FindOneAndUpdate(ctx context.Context, key any, update any, data any, opts ...any) error {
+	return c.run(ctx, "FindOneAndUpdate", func(ctx context.Context) error {
+		return c.StoreCollection.FindOneAndUpdate(ctx, key, update, data, opts...)
+	})
+}
+
+func (c *instrumentedCollection) FindOneAndDelete(ctx context.Context, filter any, data any, opts ...any) error {
+	return c.run(ctx, "FindOneAndDelete", func(ctx context.Context) error {
+		return c.StoreCollection.FindOneAndDelete(ctx, filter, data, opts...)
+	})
+}
+
+func (c *instrumentedCollection) FindMany(ctx context.Context, filter any, data any, opts ...any) error {
+	return c.run(ctx, "FindMany", func(ctx context.Context) error {
+		return c.StoreCollection.FindMany(ctx, filter, data, opts...)
+	})
+}
+
+func (c *instrumentedCollection) Count(ctx context.Context, filter any, opts ...any) (int64, error) {
+	var count int64
+	err := c.run(ctx, "Count", func(ctx context.Context) error {
+		var err error
+		count, err = c.StoreCollection.Count(ctx, filter, opts...)
+		return err
+	})
+	return count, err
+}
+
+func (c *instrumentedCollection) DeleteOne(ctx context.Context, key any, opts ...any) error {
+	return c.run(ctx, "DeleteOne", func(ctx context.Context) error {
+		return c.StoreCollection.DeleteOne(ctx, key, opts...)
+	})
+}
+
+func (c *instrumentedCollection) DeleteMany(ctx context.Context, filter any, opts ...any) (int64, error) {
+	var count int64
+	err := c.run(ctx, "DeleteMany", func(ctx context.Context) error {
+		var err error
+		count, err = c.StoreCollection.DeleteMany(ctx, filter, opts...)
+		return err
+	})
+	return count, err
+}
+
+func (c *instrumentedCollection) Watch(ctx context.Context, filter any, cb WatchCallbackfn, opts ...WatchOption) error {
+	// only the start of the stream is reported, the context passed to
+	// the instrumentation is not used for the stream, as it would keep
+	// the span open for the life of the watch
+	_, done := c.inst.StartOperation(ctx, OperationInfo{
+		Database:   c.dbName,
+		Collection: c.colName,
+		Operation:  "Watch",
+	})
+	err := c.StoreCollection.Watch(ctx, filter, cb, opts...)
+	done(err)
+	return err
+}
+
+func (c *instrumentedCollection) Aggregate(ctx context.Context, pipeline any, result any, opts ...any) error {
+	return c.run(ctx, "Aggregate", func(ctx context.Context) error {
+		return c.StoreCollection.Aggregate(ctx, pipeline, result, opts...)
+	})
+}
+
+func (c *instrumentedCollection) EnsureIndexes(ctx context.Context, indexes []IndexDefinition) error {
+	return c.run(ctx, "EnsureIndexes", func(ctx context.Context) error {
+		return c.StoreCollection.EnsureIndexes(ctx, indexes)
+	})
+}
+
+func (c *instrumentedCollection) CreateIndex(ctx context.Context, index IndexDefinition) (string, error) {
+	var name string
+	err := c.run(ctx, "CreateIndex", func(ctx context.Context) error {
+		var err error
+		name, err = c.StoreCollection.CreateIndex(ctx, index)
+		return err
+	})
+	return name, err
+}
+
+func (c *instrumentedCollection) ListIndexes(ctx context.Context) ([]IndexDefinition, error) {
+	var indexes []IndexDefinition
+	err := c.run(ctx, "ListIndexes", func(ctx context.Context) error {
+		var err error
+		indexes, err = c.StoreCollection.ListIndexes(ctx)
+		return err
+	})
+	return indexes, err
+}
+
+func (c *instrumentedCollection) DropIndex(ctx context.Context, name string) error {
+	return c.run(ctx, "DropIndex", func(ctx context.Context) error {
+		return c.StoreCollection.DropIndex(ctx, name)
+	})
+}
+
+// WithConsistency returns a handle to the same collection using the
+// given consistency options, retaining the instrumentation
+func (c *instrumentedCollection) WithConsistency(opts ConsistencyOptions) (StoreCollection, error) {
+	col, err := c.StoreCollection.WithConsistency(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedCollection{
+		StoreCollection: col,
+		inst:            c.inst,
+		dbName:          c.dbName,
+		colName:         c.colName,
+	}, nil
+}
@@ -0,0 +1,100 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+type testInstrumentationKey struct{}
+
+// testInstrumentation records the operations along with the outcome
+type testInstrumentation struct {
+	mu   sync.Mutex
+	ops  []string
+	errs []error
+	seen bool
+}
+
+func (i *testInstrumentation) StartOperation(ctx context.Context, op OperationInfo) (context.Context, func(err error)) {
+	return context.WithValue(ctx, testInstrumentationKey{}, op), func(err error) {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		i.ops = append(i.ops, op.Database+"/"+op.Collection+"/"+op.Operation)
+		i.errs = append(i.errs, err)
+	}
+}
+
+// spanCollection records whether the operation received the context
+// returned by the instrumentation
+type spanCollection struct {
+	StoreCollection
+	inst *testInstrumentation
+}
+
+func (c *spanCollection) FindOne(ctx context.Context, key any, data any, opts ...any) error {
+	_, c.inst.seen = ctx.Value(testInstrumentationKey{}).(OperationInfo)
+	return c.StoreCollection.FindOne(ctx, key, data, opts...)
+}
+
+func Test_InstrumentedClient(t *testing.T) {
+	ctx := context.Background()
+	inst := &testInstrumentation{}
+	client := NewInstrumentedClient(NewMemoryClient(), inst)
+	col := client.GetCollection("test-db", "items")
+
+	key := &MyKey{Name: "key-1"}
+	if err := col.InsertOne(ctx, key, &memTestData{Desc: "first"}); err != nil {
+		t.Fatalf("failed to insert: %s", err)
+	}
+	err := col.InsertOne(ctx, key, &memTestData{Desc: "dup"})
+	if !errors.IsAlreadyExists(err) {
+		t.Errorf("expected already exists error, got %v", err)
+	}
+	if _, err := col.Count(ctx, nil); err != nil {
+		t.Errorf("failed to count: %s", err)
+	}
+	if err := client.WithTransaction(ctx, func(ctx context.Context) error { return nil }); err != nil {
+		t.Errorf("failed to run transaction: %s", err)
+	}
+	consistent, err := col.WithConsistency(ConsistencyOptions{})
+	if err != nil {
+		t.Fatalf("failed to apply consistency: %s", err)
+	}
+	if err := consistent.DeleteOne(ctx, key); err != nil {
+		t.Errorf("failed to delete: %s", err)
+	}
+
+	expected := []string{
+		"test-db/items/InsertOne",
+		"test-db/items/InsertOne",
+		"test-db/items/Count",
+		"//Transaction",
+		"test-db/items/DeleteOne",
+	}
+	if !reflect.DeepEqual(inst.ops, expected) {
+		t.Errorf("expected operations %v, got %v", expected, inst.ops)
+	}
+	if inst.errs[0] != nil || !errors.IsAlreadyExists(inst.errs[1]) {
+		t.Errorf("unexpected outcomes reported %v", inst.errs)
+	}
+
+	wrapped := &instrumentedCollection{
+		StoreCollection: &spanCollection{StoreCollection: NewMemoryCollection("span"), inst: inst},
+		inst:            inst,
+	}
+	_ = wrapped.FindOne(ctx, key, &memTestData{})
+	if !inst.seen {
+		t.Errorf("expected operation to run with the instrumented context")
+	}
+
+	if NewInstrumentedClient(NewMemoryClient(), nil) == nil {
+		t.Errorf("expected client to be returned as is without instrumentation")
+	}
+}
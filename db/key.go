@@ -0,0 +1,55 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// KeyField returns the path of the field within the structured key of
+// the entries, to be used in filters, sort and index definitions
+//
+//	KeyField("extKey")           // "_id.extKey"
+//	KeyField("tenant", "region") // "_id.tenant.region"
+func KeyField(path ...string) string {
+	if len(path) == 0 {
+		return "_id"
+	}
+	return "_id." + strings.Join(path, ".")
+}
+
+// KeyFilter builds a filter matching all the entries whose structured
+// key has the given field values, where the field names are as stored
+// in the key document, eg. all entries of a tenant for key {Tenant, Name}
+//
+//	filter := KeyFilter(bson.E{Key: "tenant", Value: "t1"})
+func KeyFilter(fields ...bson.E) bson.D {
+	filter := bson.D{}
+	for _, f := range fields {
+		filter = append(filter, bson.E{Key: KeyField(f.Key), Value: f.Value})
+	}
+	return filter
+}
+
+// KeyRange builds the filter element matching the entries with the given
+// key field in the range [from, to), where either of from or to can be
+// nil for an open range, to be combined with the KeyFilter
+//
+//	filter := append(KeyFilter(bson.E{Key: "tenant", Value: "t1"}), KeyRange("name", "a", "m"))
+func KeyRange(field string, from, to any) bson.E {
+	cond := bson.D{}
+	if from != nil {
+		cond = append(cond, bson.E{Key: "$gte", Value: from})
+	}
+	if to != nil {
+		cond = append(cond, bson.E{Key: "$lt", Value: to})
+	}
+	if len(cond) == 0 {
+		// open on both the ends, matches the entries having the field
+		cond = append(cond, bson.E{Key: "$exists", Value: true})
+	}
+	return bson.E{Key: KeyField(field), Value: cond}
+}
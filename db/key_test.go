@@ -0,0 +1,76 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type keyTestKey struct {
+	Tenant string `bson:"tenant"`
+	Name   string `bson:"name"`
+}
+
+func Test_KeyHelpers(t *testing.T) {
+	t.Run("field", func(t *testing.T) {
+		cases := map[string][]string{
+			"_id":               nil,
+			"_id.extKey":        {"extKey"},
+			"_id.tenant.region": {"tenant", "region"},
+		}
+		for expected, path := range cases {
+			if got := KeyField(path...); got != expected {
+				t.Errorf("expected %q for %v, got %q", expected, path, got)
+			}
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		filter := append(KeyFilter(bson.E{Key: "tenant", Value: "t1"}), KeyRange("name", "a", "m"))
+		expected := bson.D{
+			{Key: "_id.tenant", Value: "t1"},
+			{Key: "_id.name", Value: bson.D{{Key: "$gte", Value: "a"}, {Key: "$lt", Value: "m"}}},
+		}
+		if !reflect.DeepEqual(filter, expected) {
+			t.Errorf("expected %v, got %v", expected, filter)
+		}
+		open := KeyRange("name", nil, nil)
+		if !reflect.DeepEqual(open.Value, bson.D{{Key: "$exists", Value: true}}) {
+			t.Errorf("expected open range to match existing field, got %v", open)
+		}
+	})
+
+	t.Run("query", func(t *testing.T) {
+		ctx := context.Background()
+		col := NewMemoryCollection("keys")
+		keys := []keyTestKey{
+			{Tenant: "t1", Name: "alpha"},
+			{Tenant: "t1", Name: "mike"},
+			{Tenant: "t1", Name: "zulu"},
+			{Tenant: "t2", Name: "bravo"},
+		}
+		for _, k := range keys {
+			if err := col.InsertOne(ctx, &k, bson.D{{Key: "desc", Value: k.Name}}); err != nil {
+				t.Fatalf("failed to insert %v: %s", k, err)
+			}
+		}
+		count, err := col.Count(ctx, KeyFilter(bson.E{Key: "tenant", Value: "t1"}))
+		if err != nil || count != 3 {
+			t.Errorf("expected 3 entries of tenant t1, got %d, %v", count, err)
+		}
+		filter := append(KeyFilter(bson.E{Key: "tenant", Value: "t1"}), KeyRange("name", "b", "n"))
+		count, err = col.Count(ctx, filter)
+		if err != nil || count != 1 {
+			t.Errorf("expected 1 entry in range, got %d, %v", count, err)
+		}
+		count, err = col.Count(ctx, bson.D{KeyRange("name", "m", nil)})
+		if err != nil || count != 2 {
+			t.Errorf("expected 2 entries in open range, got %d, %v", count, err)
+		}
+	})
+}
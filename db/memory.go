@@ -0,0 +1,623 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// memoryDoc is a document stored in the memory collection
+type memoryDoc struct {
+	seq uint64 // insertion sequence, providing natural order
+	doc bson.D // full document with `_id` as first field
+}
+
+// documents and watchers of a collection, shared across all the handles
+// to the collection obtained from the store
+type memoryData struct {
+	dbName   string
+	colName  string
+	mu       sync.RWMutex
+	docs     map[string]*memoryDoc
+	seq      uint64
+	watchers []*docWatcher
+	indexes  indexSet
+	options  *CollectionOptions // options if created explicitly
+	schema   bson.D             // $jsonSchema validating the documents
+	res      *clientResources   // watchers stopped on disconnect
+}
+
+// memoryCollection implements StoreCollection entirely in memory, meant
+// for unit tests that would otherwise need a running MongoDB
+type memoryCollection struct {
+	*memoryData
+	keyType reflect.Type
+}
+
+// NewMemoryCollection creates a standalone StoreCollection holding the
+// documents in memory, mimicking the behavior of the mongo collection
+// including filter evaluation for simple filters and change notifications
+// for Watch. Intended for unit tests of the packages working with the
+// store abstraction, without requiring a running MongoDB
+func NewMemoryCollection(name string) StoreCollection {
+	return newMemoryCollection("memory", name)
+}
+
+func newMemoryCollection(dbName, name string) *memoryCollection {
+	return &memoryCollection{
+		memoryData: &memoryData{
+			dbName:  dbName,
+			colName: name,
+			docs:    map[string]*memoryDoc{},
+		},
+	}
+}
+
+// returns the internal identifier for the key, encoded as part of a
+// document to allow working with any key type
+func memoryDocID(key any) (string, any, error) {
+	id, err := toValue(key)
+	if err != nil {
+		return "", nil, err
+	}
+	raw, err := bson.Marshal(bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return "", nil, err
+	}
+	return string(raw), id, nil
+}
+
+// builds a document storing the data with the given key
+func memoryNewDoc(id any, data any) (bson.D, error) {
+	doc, err := toDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	doc = unsetField(doc, []string{"_id"})
+	return append(bson.D{{Key: "_id", Value: id}}, doc...), nil
+}
+
+// returns the documents in natural order
+func (c *memoryCollection) sortedDocs() []*memoryDoc {
+	list := make([]*memoryDoc, 0, len(c.docs))
+	for _, d := range c.docs {
+		list = append(list, d)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].seq < list[j].seq
+	})
+	return list
+}
+
+// returns the documents matching the filter in natural order
+func (c *memoryCollection) matchDocs(filter any) ([]*memoryDoc, error) {
+	f, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	list := []*memoryDoc{}
+	for _, d := range c.sortedDocs() {
+		ok, err := matchDocument(d.doc, f)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			list = append(list, d)
+		}
+	}
+	return list, nil
+}
+
+// publishes the change event to all the active watchers, must be called
+// with the collection lock held to ensure ordering of the events
+func (c *memoryCollection) publish(op string, id any, doc bson.D, update bson.D) {
+	event := changeEvent(c.seq, time.Now(), op, c.dbName, c.colName, id, doc, update)
+	active := c.watchers[:0]
+	for _, w := range c.watchers {
+		if w.ctx.Err() != nil {
+			continue
+		}
+		active = append(active, w)
+		w.enqueue(event)
+	}
+	c.watchers = active
+}
+
+// Set KeyType for the collection, this is not mandatory
+// while the key type will be used by the interface implementer
+// mainly for Watch Callback for providing decoded key, if not
+// set watch will be working with the default decoders of
+// interface implementer
+// only pointer key type is supported as of now
+// returns error if the key type is not a pointer
+func (c *memoryCollection) SetKeyType(keyType reflect.Type) error {
+	if keyType.Kind() != reflect.Ptr {
+		// return error, as only pointer key type is supported
+		return errors.Wrap(errors.InvalidArgument, "key type is not a pointer")
+	}
+	c.keyType = keyType
+	return nil
+}
+
+// inserts one entry with given key and data to the collection
+// returns errors if entry already exists
+func (c *memoryCollection) InsertOne(ctx context.Context, key any, data any, opts ...any) error {
+	if _, err := operationConsistency("InsertOne", opts); err != nil {
+		return err
+	}
+	if data == nil {
+		return errors.Wrap(errors.InvalidArgument, "db Insert error: No data to store")
+	}
+	if key == nil {
+		return errors.Wrap(errors.InvalidArgument, "db Insert error: No Key specified to store")
+	}
+	docID, id, err := memoryDocID(key)
+	if err != nil {
+		return err
+	}
+	doc, err := memoryNewDoc(id, data)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.docs[docID]; ok {
+		return errors.Wrapf(errors.AlreadyExists, "duplicate key error, key %v", key)
+	}
+	if err := c.validateDoc(doc); err != nil {
+		return err
+	}
+	c.seq++
+	c.docs[docID] = &memoryDoc{seq: c.seq, doc: doc}
+	c.publish("insert", id, doc, nil)
+	c.trimCapped()
+	return nil
+}
+
+// validates the document against the schema of the collection, if any
+func (c *memoryData) validateDoc(doc bson.D) error {
+	if c.schema == nil {
+		return nil
+	}
+	return validateSchema(c.schema, doc, "")
+}
+
+// removes the oldest documents of a capped collection exceeding the
+// max size or max documents, similar to mongodb no events are generated
+// for the documents removed
+func (c *memoryData) trimCapped() {
+	if c.options == nil || !c.options.Capped {
+		return
+	}
+	for len(c.docs) > 1 {
+		size := int64(0)
+		oldest := ""
+		for id, d := range c.docs {
+			raw, _ := bson.Marshal(d.doc)
+			size += int64(len(raw))
+			if oldest == "" || d.seq < c.docs[oldest].seq {
+				oldest = id
+			}
+		}
+		exceeded := size > c.options.MaxSize
+		if c.options.MaxDocuments > 0 && int64(len(c.docs)) > c.options.MaxDocuments {
+			exceeded = true
+		}
+		if !exceeded {
+			return
+		}
+		delete(c.docs, oldest)
+	}
+}
+
+// applies the update to the document for the given key, returning the
+// document state before and after the update, before is nil if the
+// document is inserted as part of upsert
+func (c *memoryCollection) updateDoc(key any, update any, upsert bool, filter bson.D) (bson.D, bson.D, error) {
+	if key == nil {
+		return nil, nil, errors.Wrap(errors.InvalidArgument, "db Update error: No Key specified")
+	}
+	docID, id, err := memoryDocID(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	upd, err := toDocument(update)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.docs[docID]
+	if !ok {
+		if !upsert {
+			return nil, nil, errors.Wrap(errors.NotFound, "No Document found")
+		}
+		doc, err := applyUpdate(bson.D{{Key: "_id", Value: id}}, upd, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := c.validateDoc(doc); err != nil {
+			return nil, nil, err
+		}
+		c.seq++
+		c.docs[docID] = &memoryDoc{seq: c.seq, doc: doc}
+		c.publish("insert", id, doc, nil)
+		c.trimCapped()
+		return nil, copyDocument(doc), nil
+	}
+
+	before := entry.doc
+	if match, err := satisfiesCondition(before, filter); err != nil {
+		return nil, nil, err
+	} else if !match {
+		return nil, nil, errors.Wrap(errors.NotFound, "No Document found")
+	}
+	doc, err := applyUpdate(before, upd, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.validateDoc(doc); err != nil {
+		return nil, nil, err
+	}
+	entry.doc = doc
+	c.seq++
+	c.publish("update", id, doc, updateDescription(before, doc))
+	return copyDocument(before), copyDocument(doc), nil
+}
+
+// inserts or updates one entry with given key and data to the collection
+// acts based on the flag passed for upsert
+// returns errors if entry not found while upsert flag is false
+func (c *memoryCollection) UpdateOne(ctx context.Context, key any, data any, upsert bool, opts ...any) error {
+	if _, err := operationConsistency("UpdateOne", opts); err != nil {
+		return err
+	}
+	if data == nil {
+		return errors.Wrap(errors.InvalidArgument, "db Insert error: No data to store")
+	}
+	if key == nil {
+		return errors.Wrap(errors.InvalidArgument, "db Insert error: No Key specified to store")
+	}
+	_, _, err := c.updateDoc(key, bson.D{{Key: "$set", Value: data}}, upsert, nil)
+	return err
+}
+
+// Find one entry from the store collection for the given key, where the data
+// value is returned based on the object type passed to it
+func (c *memoryCollection) FindOne(ctx context.Context, key any, data any, opts ...any) error {
+	if _, err := operationConsistency("FindOne", opts); err != nil {
+		return err
+	}
+	docID, _, err := memoryDocID(key)
+	if err != nil {
+		return err
+	}
+	c.mu.RLock()
+	var doc bson.D
+	entry, ok := c.docs[docID]
+	if ok {
+		// documents are replaced on update, snapshot under the lock
+		doc = entry.doc
+	}
+	c.mu.RUnlock()
+	if !ok {
+		return errors.Wrap(errors.NotFound, mongo.ErrNoDocuments.Error())
+	}
+	return decodeDocument(doc, data)
+}
+
+// Find one entry from the store collection for the given key and atomically
+// apply the update document to it, where the data value is returned based
+// on the object type passed to it
+func (c *memoryCollection) FindOneAndUpdate(ctx context.Context, key any, update any, data any, opts ...any) error {
+	if key == nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndUpdate error: No Key specified")
+	}
+	if update == nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndUpdate error: No update specified")
+	}
+	args := &options.FindOneAndUpdateOptions{}
+	opts, _, err := splitConsistency(opts)
+	if err != nil {
+		return err
+	}
+	opts, filter, err := splitCondition(opts)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		val, ok := opt.(options.Lister[options.FindOneAndUpdateOptions])
+		if !ok {
+			return errors.Wrapf(errors.InvalidArgument, "Invalid option type %T passed to FindOneAndUpdate", opt)
+		}
+		for _, fn := range val.List() {
+			if err := fn(args); err != nil {
+				return err
+			}
+		}
+	}
+	upsert := args.Upsert != nil && *args.Upsert
+	if upsert && filter != nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndUpdate error: Upsert not supported with update condition")
+	}
+	before, after, err := c.updateDoc(key, update, upsert, filter)
+	if err != nil {
+		return err
+	}
+	doc := before
+	if args.ReturnDocument != nil && *args.ReturnDocument == options.After {
+		doc = after
+	}
+	if doc == nil {
+		return errors.Wrap(errors.NotFound, mongo.ErrNoDocuments.Error())
+	}
+	return decodeDocument(doc, data)
+}
+
+// Find one entry from the store collection matching the given filter and
+// atomically delete it, where the data value holds the deleted entry
+// based on the object type passed to it
+func (c *memoryCollection) FindOneAndDelete(ctx context.Context, filter any, data any, opts ...any) error {
+	if filter == nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndDelete error: No filter specified")
+	}
+	args := &options.FindOneAndDeleteOptions{}
+	opts, _, err := splitConsistency(opts)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		val, ok := opt.(options.Lister[options.FindOneAndDeleteOptions])
+		if !ok {
+			return errors.Wrapf(errors.InvalidArgument, "Invalid option type %T passed to FindOneAndDelete", opt)
+		}
+		for _, fn := range val.List() {
+			if err := fn(args); err != nil {
+				return err
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list, err := c.matchDocs(filter)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return errors.Wrap(errors.NotFound, mongo.ErrNoDocuments.Error())
+	}
+	docs := make([]bson.D, 0, len(list))
+	for _, d := range list {
+		docs = append(docs, d.doc)
+	}
+	if args.Sort != nil {
+		spec, err := toDocument(args.Sort)
+		if err != nil {
+			return err
+		}
+		sortDocuments(docs, spec)
+	}
+	doc := docs[0]
+	docID, id, err := memoryDocID(doc[0].Value)
+	if err != nil {
+		return err
+	}
+	delete(c.docs, docID)
+	c.seq++
+	c.publish("delete", id, nil, nil)
+	return decodeDocument(doc, data)
+}
+
+// Find multiple entries from the store collection for the given filter, where the data
+// value is returned as a list based on the object type passed to it
+func (c *memoryCollection) FindMany(ctx context.Context, filter any, data any, opts ...any) error {
+	args, err := findOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	list, err := c.matchDocs(filter)
+	docs := []bson.D{}
+	for _, d := range list {
+		docs = append(docs, copyDocument(d.doc))
+	}
+	c.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	docs, err = applyFindOptions(docs, args)
+	if err != nil {
+		return err
+	}
+	return decodeDocuments(docs, data)
+}
+
+// Return count of entries matching the provided filter
+func (c *memoryCollection) Count(ctx context.Context, filter any, opts ...any) (int64, error) {
+	if _, err := operationConsistency("Count", opts); err != nil {
+		return 0, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	list, err := c.matchDocs(filter)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(list)), nil
+}
+
+// remove one entry from the collection matching the given key
+func (c *memoryCollection) DeleteOne(ctx context.Context, key any, opts ...any) error {
+	if _, err := operationConsistency("DeleteOne", opts); err != nil {
+		return err
+	}
+	docID, id, err := memoryDocID(key)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.docs[docID]; !ok {
+		return errors.Wrap(errors.NotFound, "No Document found")
+	}
+	delete(c.docs, docID)
+	c.seq++
+	c.publish("delete", id, nil, nil)
+	return nil
+}
+
+// Delete Many entries matching the delete criteria
+// returns number of entries deleted and if there is any error processing the request
+func (c *memoryCollection) DeleteMany(ctx context.Context, filter any, opts ...any) (int64, error) {
+	if _, err := operationConsistency("DeleteMany", opts); err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list, err := c.matchDocs(filter)
+	if err != nil {
+		return 0, err
+	}
+	if len(list) == 0 {
+		return 0, errors.Wrap(errors.NotFound, "No matching entries found to delete")
+	}
+	for _, d := range list {
+		id := d.doc[0].Value
+		docID, _, err := memoryDocID(id)
+		if err != nil {
+			return 0, err
+		}
+		delete(c.docs, docID)
+		c.seq++
+		c.publish("delete", id, nil, nil)
+	}
+	return int64(len(list)), nil
+}
+
+// registers a watcher delivering the events to the callback until the
+// context is canceled
+func (c *memoryCollection) addWatcher(ctx context.Context, filter any, deliver func(event bson.D)) error {
+	ctx, release, err := c.res.derive(ctx)
+	if err != nil {
+		return err
+	}
+	w, err := newDocWatcher(ctx, filter, deliver)
+	if err != nil {
+		release()
+		return err
+	}
+	c.mu.Lock()
+	c.watchers = append(c.watchers, w)
+	c.mu.Unlock()
+	c.res.goroutine(func() {
+		defer release()
+		w.run()
+	})
+	return nil
+}
+
+// watch allows getting notified whenever a change happens to a document
+// in the collection
+// allow provisiong for a filter to be passed on, where the callback
+// function to receive only conditional notifications of the events
+// listener is interested about
+func (c *memoryCollection) Watch(ctx context.Context, filter any, cb WatchCallbackfn, opts ...WatchOption) error {
+	// take a snapshot of keyTpe for processing watch
+	if err := c.addWatcher(ctx, filter, watchDelivery(c.keyType, cb)); err != nil {
+		return err
+	}
+	getWatchOptions(opts).resumeUnsupported(c.colName)
+	return nil
+}
+
+// Aggregate runs an aggregation pipeline against the collection, the
+// memory collection only supports $match, $sort, $skip and $limit stages
+func (c *memoryCollection) Aggregate(ctx context.Context, pipeline any, result any, opts ...any) error {
+	c.mu.RLock()
+	docs := []bson.D{}
+	for _, d := range c.sortedDocs() {
+		docs = append(docs, copyDocument(d.doc))
+	}
+	c.mu.RUnlock()
+
+	docs, err := aggregateDocuments(docs, pipeline)
+	if err != nil {
+		return err
+	}
+	return decodeDocuments(docs, result)
+}
+
+// EnsureIndexes records the indexes for the memory collection, while the
+// indexes are not enforced as the documents are always looked up by
+// scanning the collection
+func (c *memoryCollection) EnsureIndexes(ctx context.Context, indexes []IndexDefinition) error {
+	for _, idx := range indexes {
+		if _, err := c.CreateIndex(ctx, idx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateIndex records the index definition, returning the name of the
+// index, the index is not enforced
+func (c *memoryCollection) CreateIndex(ctx context.Context, index IndexDefinition) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.indexes.add(index)
+}
+
+// ListIndexes returns the indexes created on the collection
+func (c *memoryCollection) ListIndexes(ctx context.Context) ([]IndexDefinition, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.indexes.list(), nil
+}
+
+// DropIndex removes the index with the given name
+func (c *memoryCollection) DropIndex(ctx context.Context, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.indexes.remove(name)
+}
+
+// WithConsistency validates the options and returns a handle to the same
+// collection, as consistency settings are not relevant for the in-memory
+// collection
+func (c *memoryCollection) WithConsistency(opts ConsistencyOptions) (StoreCollection, error) {
+	if _, err := opts.collectionOptions(); err != nil {
+		return nil, err
+	}
+	return &memoryCollection{
+		memoryData: c.memoryData,
+		keyType:    c.keyType,
+	}, nil
+}
+
+func (c *memoryCollection) startEventLogger(ctx context.Context, eventType reflect.Type, timestamp *bson.Timestamp) error {
+	return c.addWatcher(ctx, nil, eventDelivery(eventType, logEvent))
+}
+
+func (c *memoryCollection) watchEvents(ctx context.Context, filter any, eventType reflect.Type, cb func(event any), wopts *WatchOptions) error {
+	if err := c.addWatcher(ctx, filter, eventDelivery(eventType, cb)); err != nil {
+		return err
+	}
+	wopts.resumeUnsupported(c.colName)
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+type memoryBlob struct {
+	info BlobInfo
+	data []byte
+}
+
+// memoryBlobStore implements BlobStore holding the blobs in memory
+type memoryBlobStore struct {
+	mu    sync.RWMutex
+	blobs map[string]*memoryBlob
+}
+
+// GetBlobStore returns the blob store holding the blobs in memory, where
+// every call for the same bucket works with the same blobs
+func (s *memoryStore) GetBlobStore(bucket string, opts ...BlobStoreOption) (BlobStore, error) {
+	bopts, err := getBlobStoreOptions(bucket, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bs, ok := s.blobs[bucket]
+	if !ok {
+		bs = &memoryBlobStore{blobs: map[string]*memoryBlob{}}
+		s.blobs[bucket] = bs
+	}
+	return bopts.wrap(bs), nil
+}
+
+// Put reads the content into memory and stores it as the blob, replacing
+// the existing blob if any
+func (s *memoryBlobStore) Put(ctx context.Context, name string, r io.Reader, metadata map[string]string) (int64, error) {
+	if name == "" {
+		return 0, errors.Wrap(errors.InvalidArgument, "blob name is required")
+	}
+	if r == nil {
+		return 0, errors.Wrap(errors.InvalidArgument, "blob content reader is required")
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[name] = &memoryBlob{
+		info: BlobInfo{
+			Name:       name,
+			Size:       int64(len(data)),
+			UploadedAt: time.Now(),
+			Metadata:   maps.Clone(metadata),
+		},
+		data: data,
+	}
+	return int64(len(data)), nil
+}
+
+// Get returns the reader over the content of the blob
+func (s *memoryBlobStore) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	blob, ok := s.blobs[name]
+	if !ok {
+		return nil, errors.Wrapf(errors.NotFound, "blob %s not found", name)
+	}
+	// the content is never modified once stored, replaced as a whole
+	return io.NopCloser(bytes.NewReader(blob.data)), nil
+}
+
+// Stat returns the information of the blob
+func (s *memoryBlobStore) Stat(ctx context.Context, name string) (*BlobInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	blob, ok := s.blobs[name]
+	if !ok {
+		return nil, errors.Wrapf(errors.NotFound, "blob %s not found", name)
+	}
+	info := blob.info
+	info.Metadata = maps.Clone(info.Metadata)
+	return &info, nil
+}
+
+// Delete removes the blob
+func (s *memoryBlobStore) Delete(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.blobs[name]; !ok {
+		return errors.Wrapf(errors.NotFound, "blob %s not found", name)
+	}
+	delete(s.blobs, name)
+	return nil
+}
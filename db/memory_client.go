@@ -0,0 +1,218 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// memoryStore implements Store holding the collections in memory, where
+// every GetCollection for the same name works with the same documents
+type memoryStore struct {
+	name  string
+	mu    sync.Mutex
+	cols  map[string]*memoryData
+	blobs map[string]*memoryBlobStore
+	res   *clientResources // watchers stopped on disconnect
+}
+
+// Gets collection corresponding to the collection name, creating an
+// empty collection on first use similar to mongodb
+func (s *memoryStore) GetCollection(name string) StoreCollection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.cols[name]
+	if !ok {
+		data = newMemoryCollection(s.name, name).memoryData
+		data.res = s.res
+		s.cols[name] = data
+	}
+	return &memoryCollection{memoryData: data}
+}
+
+// CreateCollection explicitly creates the collection with the given
+// options, where capped collections retain only the latest documents
+// within the limits, while time-series collections behave as regular
+// collections
+func (s *memoryStore) CreateCollection(ctx context.Context, name string, opts CollectionOptions) (StoreCollection, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.cols[name]
+	if ok {
+		data.mu.Lock()
+		defer data.mu.Unlock()
+		if data.options != nil || len(data.docs) != 0 {
+			return nil, errors.Wrapf(errors.AlreadyExists, "collection %s already exists", name)
+		}
+	} else {
+		data = newMemoryCollection(s.name, name).memoryData
+		data.res = s.res
+		s.cols[name] = data
+	}
+	data.options = &opts
+	if opts.Validator != nil {
+		data.schema, _ = toSchema(opts.Validator)
+	}
+	return &memoryCollection{memoryData: data}, nil
+}
+
+// SetValidator installs the $jsonSchema validation rules on the
+// collection, evaluating the commonly used keywords of the schema for
+// the documents inserted or updated afterwards
+func (s *memoryStore) SetValidator(ctx context.Context, col string, schema any) error {
+	var doc bson.D
+	if schema != nil {
+		var err error
+		doc, err = toSchema(schema)
+		if err != nil {
+			return err
+		}
+	}
+	data := s.GetCollection(col).(*memoryCollection).memoryData
+	data.mu.Lock()
+	defer data.mu.Unlock()
+	data.schema = doc
+	return nil
+}
+
+// Gets Name of the database corresponding to the store
+func (s *memoryStore) Name() string {
+	return s.name
+}
+
+// memoryClient implements StoreClient holding all the databases in memory
+type memoryClient struct {
+	mu     sync.Mutex
+	stores map[string]*memoryStore
+	txMu   sync.Mutex       // serializes the transactions
+	res    *clientResources // watchers stopped on disconnect
+}
+
+// context key marking the context as part of a memory transaction
+type memoryTxKey struct{}
+
+// NewMemoryClient creates a StoreClient holding all the data in memory,
+// providing the same semantics as the mongo client for the Store and
+// StoreCollection interfaces, including filter evaluation for simple
+// filters and change notifications for Watch. Data is lost once the
+// client is garbage collected.
+// Intended for unit tests of the packages working with the store
+// abstraction (table, sync, reconciler), without a running MongoDB
+func NewMemoryClient() StoreClient {
+	return &memoryClient{
+		stores: map[string]*memoryStore{},
+		res:    newClientResources(),
+	}
+}
+
+// Get the Data Store interface given the database name, creating an
+// empty database on first use
+func (c *memoryClient) GetDataStore(dbName string) Store {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.stores[dbName]
+	if !ok {
+		s = &memoryStore{
+			name:  dbName,
+			cols:  map[string]*memoryData{},
+			blobs: map[string]*memoryBlobStore{},
+			res:   c.res,
+		}
+		c.stores[dbName] = s
+	}
+	return s
+}
+
+// Gets collection corresponding to the collection name inside the
+// requested database name
+func (c *memoryClient) GetCollection(dbName, col string) StoreCollection {
+	return c.GetDataStore(dbName).GetCollection(col)
+}
+
+// Health Check, in-memory client is always healthy
+func (c *memoryClient) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+// HealthReport returns the health of the in-memory client, without the
+// member details
+func (c *memoryClient) HealthReport(ctx context.Context) (*HealthReport, error) {
+	return simpleHealthReport(ctx, c.HealthCheck)
+}
+
+// Disconnect stops all the watchers started on the collections of the
+// client, the data is retained
+func (c *memoryClient) Disconnect(ctx context.Context) error {
+	return c.res.close(ctx)
+}
+
+// returns a copy of the documents of all the collections, used to restore
+// the state when a transaction is aborted
+func (c *memoryClient) snapshot() map[*memoryData]map[string]*memoryDoc {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snap := map[*memoryData]map[string]*memoryDoc{}
+	for _, s := range c.stores {
+		s.mu.Lock()
+		for _, data := range s.cols {
+			data.mu.RLock()
+			docs := make(map[string]*memoryDoc, len(data.docs))
+			for id, d := range data.docs {
+				docs[id] = &memoryDoc{seq: d.seq, doc: d.doc}
+			}
+			data.mu.RUnlock()
+			snap[data] = docs
+		}
+		s.mu.Unlock()
+	}
+	return snap
+}
+
+// restores the documents of all the collections from the snapshot, where
+// the collections created after the snapshot are emptied
+func (c *memoryClient) restore(snap map[*memoryData]map[string]*memoryDoc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.stores {
+		s.mu.Lock()
+		for _, data := range s.cols {
+			docs, ok := snap[data]
+			if !ok {
+				docs = map[string]*memoryDoc{}
+			}
+			data.mu.Lock()
+			data.docs = docs
+			data.mu.Unlock()
+		}
+		s.mu.Unlock()
+	}
+}
+
+// WithTransaction runs the function serialized with other transactions
+// of the client, restoring the documents of all the collections if the
+// function fails. Writes done outside of the transaction while it is
+// running are lost on abort, and the change events already delivered
+// for the aborted writes are not retracted
+func (c *memoryClient) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, ok := ctx.Value(memoryTxKey{}).(*memoryClient); ok && tx == c {
+		return fn(ctx)
+	}
+	c.txMu.Lock()
+	defer c.txMu.Unlock()
+	snap := c.snapshot()
+	err := fn(context.WithValue(ctx, memoryTxKey{}, c))
+	if err != nil {
+		c.restore(snap)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,585 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// toDocument converts the provided value (struct, map, bson.M, bson.D
+// etc) to an ordered bson document, normalizing all the nested values
+// to the types used while decoding from the database
+func toDocument(v any) (bson.D, error) {
+	if v == nil {
+		return bson.D{}, nil
+	}
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "failed to marshal document: %s", err)
+	}
+	doc := bson.D{}
+	err = bson.Unmarshal(raw, &doc)
+	if err != nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "failed to unmarshal document: %s", err)
+	}
+	return doc, nil
+}
+
+// toValue normalizes a single value to the type used while decoding
+// from the database
+func toValue(v any) (any, error) {
+	doc, err := toDocument(bson.D{{Key: "v", Value: v}})
+	if err != nil {
+		return nil, err
+	}
+	return doc[0].Value, nil
+}
+
+// lookupField returns the value for the dotted path in the document,
+// while traversing arrays at any level the values are collected from
+// all the elements
+func lookupField(doc bson.D, path string) (any, bool) {
+	return lookupPath(doc, strings.Split(path, "."))
+}
+
+func lookupPath(val any, path []string) (any, bool) {
+	if len(path) == 0 {
+		return val, true
+	}
+	switch v := val.(type) {
+	case bson.D:
+		for _, e := range v {
+			if e.Key == path[0] {
+				return lookupPath(e.Value, path[1:])
+			}
+		}
+	case bson.A:
+		values := bson.A{}
+		for _, item := range v {
+			if res, ok := lookupPath(item, path); ok {
+				values = append(values, res)
+			}
+		}
+		if len(values) != 0 {
+			return values, true
+		}
+	}
+	return nil, false
+}
+
+// setField sets the value for the dotted path in the document, creating
+// the intermediate documents as needed
+func setField(doc bson.D, path []string, value any) bson.D {
+	for i, e := range doc {
+		if e.Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			doc[i].Value = value
+		} else {
+			sub, _ := e.Value.(bson.D)
+			doc[i].Value = setField(sub, path[1:], value)
+		}
+		return doc
+	}
+	if len(path) == 1 {
+		return append(doc, bson.E{Key: path[0], Value: value})
+	}
+	return append(doc, bson.E{Key: path[0], Value: setField(bson.D{}, path[1:], value)})
+}
+
+// unsetField removes the dotted path from the document
+func unsetField(doc bson.D, path []string) bson.D {
+	for i, e := range doc {
+		if e.Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return append(doc[:i:i], doc[i+1:]...)
+		}
+		if sub, ok := e.Value.(bson.D); ok {
+			doc[i].Value = unsetField(sub, path[1:])
+		}
+		return doc
+	}
+	return doc
+}
+
+// copyDocument returns a deep copy of the document
+func copyDocument(doc bson.D) bson.D {
+	res := make(bson.D, len(doc))
+	for i, e := range doc {
+		res[i] = bson.E{Key: e.Key, Value: copyValue(e.Value)}
+	}
+	return res
+}
+
+func copyValue(val any) any {
+	switch v := val.(type) {
+	case bson.D:
+		return copyDocument(v)
+	case bson.A:
+		res := make(bson.A, len(v))
+		for i, item := range v {
+			res[i] = copyValue(item)
+		}
+		return res
+	}
+	return val
+}
+
+// canonical ordering of the types, as used by mongodb while comparing
+// values of different types
+func typeOrder(val any) int {
+	switch val.(type) {
+	case nil, bson.Null, bson.Undefined:
+		return 1
+	case int32, int64, float64, bson.Decimal128:
+		return 2
+	case string, bson.Symbol:
+		return 3
+	case bson.D:
+		return 4
+	case bson.A:
+		return 5
+	case bson.Binary:
+		return 6
+	case bson.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case bson.DateTime:
+		return 9
+	case bson.Timestamp:
+		return 10
+	case bson.Regex:
+		return 11
+	}
+	return 12
+}
+
+func toFloat(val any) float64 {
+	switch v := val.(type) {
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+func cmpOrdered[T int64 | float64 | uint32 | string](a, b T) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// compareValues compares two normalized values, following the mongodb
+// ordering across different types
+func compareValues(a, b any) int {
+	ta, tb := typeOrder(a), typeOrder(b)
+	if ta != tb {
+		return cmpOrdered(int64(ta), int64(tb))
+	}
+	switch av := a.(type) {
+	case int32, int64, float64:
+		ai, aok := a.(int64)
+		bi, bok := b.(int64)
+		if aok && bok {
+			return cmpOrdered(ai, bi)
+		}
+		return cmpOrdered(toFloat(a), toFloat(b))
+	case string:
+		return cmpOrdered(av, b.(string))
+	case bson.D:
+		bv := b.(bson.D)
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			if c := cmpOrdered(av[i].Key, bv[i].Key); c != 0 {
+				return c
+			}
+			if c := compareValues(av[i].Value, bv[i].Value); c != 0 {
+				return c
+			}
+		}
+		return cmpOrdered(int64(len(av)), int64(len(bv)))
+	case bson.A:
+		bv := b.(bson.A)
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			if c := compareValues(av[i], bv[i]); c != 0 {
+				return c
+			}
+		}
+		return cmpOrdered(int64(len(av)), int64(len(bv)))
+	case bson.Binary:
+		return bytes.Compare(av.Data, b.(bson.Binary).Data)
+	case bson.ObjectID:
+		bv := b.(bson.ObjectID)
+		return bytes.Compare(av[:], bv[:])
+	case bool:
+		bv := b.(bool)
+		if av == bv {
+			return 0
+		}
+		if !av {
+			return -1
+		}
+		return 1
+	case bson.DateTime:
+		return cmpOrdered(int64(av), int64(b.(bson.DateTime)))
+	case bson.Timestamp:
+		bv := b.(bson.Timestamp)
+		if c := cmpOrdered(av.T, bv.T); c != 0 {
+			return c
+		}
+		return cmpOrdered(av.I, bv.I)
+	}
+	return 0
+}
+
+// returns list of candidate values for matching, where array values
+// are matched as a whole as well as element wise
+func candidates(val any, found bool) []any {
+	if !found {
+		return []any{nil}
+	}
+	if arr, ok := val.(bson.A); ok {
+		return append([]any{arr}, arr...)
+	}
+	return []any{val}
+}
+
+// matchDocument evaluates whether the document matches the filter,
+// supporting commonly used query operators
+func matchDocument(doc bson.D, filter bson.D) (bool, error) {
+	for _, e := range filter {
+		var ok bool
+		var err error
+		switch e.Key {
+		case "$and", "$or", "$nor":
+			ok, err = matchLogical(doc, e.Key, e.Value)
+		case "$text":
+			ok, err = matchText(doc, e.Value)
+		default:
+			if strings.HasPrefix(e.Key, "$") {
+				return false, errors.Wrapf(errors.InvalidArgument, "unsupported query operator %s", e.Key)
+			}
+			val, found := lookupField(doc, e.Key)
+			ok, err = matchCondition(val, found, e.Value)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// approximates $text search without a text index, matching documents
+// where any string value contains any of the search terms, ignoring case
+func matchText(doc bson.D, value any) (bool, error) {
+	cond, ok := value.(bson.D)
+	if !ok {
+		return false, errors.Wrap(errors.InvalidArgument, "$text expects a document")
+	}
+	val, _ := lookupField(cond, "$search")
+	search, ok := val.(string)
+	if !ok {
+		return false, errors.Wrap(errors.InvalidArgument, "$text requires a $search string")
+	}
+	terms := strings.Fields(strings.ToLower(search))
+	var match func(v any) bool
+	match = func(v any) bool {
+		switch v := v.(type) {
+		case string:
+			lower := strings.ToLower(v)
+			for _, t := range terms {
+				if strings.Contains(lower, strings.Trim(t, "\"")) {
+					return true
+				}
+			}
+		case bson.D:
+			for _, e := range v {
+				if match(e.Value) {
+					return true
+				}
+			}
+		case bson.A:
+			for _, item := range v {
+				if match(item) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for _, e := range doc {
+		if e.Key != "_id" && match(e.Value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchLogical(doc bson.D, op string, value any) (bool, error) {
+	list, ok := value.(bson.A)
+	if !ok {
+		return false, errors.Wrapf(errors.InvalidArgument, "%s expects an array", op)
+	}
+	for _, item := range list {
+		sub, ok := item.(bson.D)
+		if !ok {
+			return false, errors.Wrapf(errors.InvalidArgument, "%s expects an array of documents", op)
+		}
+		match, err := matchDocument(doc, sub)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case op == "$and" && !match:
+			return false, nil
+		case op == "$or" && match:
+			return true, nil
+		case op == "$nor" && match:
+			return false, nil
+		}
+	}
+	return op != "$or", nil
+}
+
+// returns true if the condition is an operator document, eg. {$gt: 1}
+func isOperatorDoc(cond any) bool {
+	d, ok := cond.(bson.D)
+	return ok && len(d) != 0 && strings.HasPrefix(d[0].Key, "$")
+}
+
+func matchCondition(val any, found bool, cond any) (bool, error) {
+	if !isOperatorDoc(cond) {
+		if re, ok := cond.(bson.Regex); ok {
+			return matchRegex(val, found, re.Pattern, re.Options)
+		}
+		return matchEqual(val, found, cond), nil
+	}
+	ops := cond.(bson.D)
+	for _, op := range ops {
+		var ok bool
+		var err error
+		switch op.Key {
+		case "$eq":
+			ok = matchEqual(val, found, op.Value)
+		case "$ne":
+			ok = !matchEqual(val, found, op.Value)
+		case "$gt", "$gte", "$lt", "$lte":
+			ok = matchCompare(val, found, op.Key, op.Value)
+		case "$in", "$nin":
+			list, isList := op.Value.(bson.A)
+			if !isList {
+				return false, errors.Wrapf(errors.InvalidArgument, "%s expects an array", op.Key)
+			}
+			for _, item := range list {
+				if matchEqual(val, found, item) {
+					ok = true
+					break
+				}
+			}
+			if op.Key == "$nin" {
+				ok = !ok
+			}
+		case "$exists":
+			ok = found == isTruthy(op.Value)
+		case "$not":
+			ok, err = matchCondition(val, found, op.Value)
+			ok = !ok
+		case "$regex":
+			pattern, _ := op.Value.(string)
+			options := ""
+			if re, isRe := op.Value.(bson.Regex); isRe {
+				pattern, options = re.Pattern, re.Options
+			}
+			if opt, exists := lookupField(ops, "$options"); exists {
+				options, _ = opt.(string)
+			}
+			ok, err = matchRegex(val, found, pattern, options)
+		case "$options":
+			// handled as part of $regex
+			ok = true
+		case "$size":
+			arr, isArr := val.(bson.A)
+			ok = isArr && compareValues(int64(len(arr)), op.Value) == 0
+		default:
+			return false, errors.Wrapf(errors.InvalidArgument, "unsupported query operator %s", op.Key)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func isTruthy(val any) bool {
+	switch v := val.(type) {
+	case bool:
+		return v
+	case nil, bson.Null:
+		return false
+	case int32, int64, float64:
+		return toFloat(v) != 0
+	}
+	return true
+}
+
+func matchEqual(val any, found bool, cond any) bool {
+	for _, c := range candidates(val, found) {
+		if typeOrder(c) == typeOrder(cond) && compareValues(c, cond) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func matchCompare(val any, found bool, op string, cond any) bool {
+	if !found {
+		return false
+	}
+	for _, c := range candidates(val, found) {
+		// comparison operators only match values of the same type
+		if typeOrder(c) != typeOrder(cond) {
+			continue
+		}
+		res := compareValues(c, cond)
+		switch {
+		case op == "$gt" && res > 0,
+			op == "$gte" && res >= 0,
+			op == "$lt" && res < 0,
+			op == "$lte" && res <= 0:
+			return true
+		}
+	}
+	return false
+}
+
+func matchRegex(val any, found bool, pattern, options string) (bool, error) {
+	if strings.Contains(options, "i") {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, errors.Wrapf(errors.InvalidArgument, "invalid regex %s: %s", pattern, err)
+	}
+	for _, c := range candidates(val, found) {
+		if s, ok := c.(string); ok && re.MatchString(s) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// applyUpdate applies the update operators to a copy of the document,
+// returning the updated document. insert is set when the update results
+// in creation of a new document, enabling $setOnInsert
+func applyUpdate(doc bson.D, update bson.D, insert bool) (bson.D, error) {
+	doc = copyDocument(doc)
+	for _, op := range update {
+		fields, ok := op.Value.(bson.D)
+		if !ok {
+			return nil, errors.Wrapf(errors.InvalidArgument, "update operator %s expects a document", op.Key)
+		}
+		for _, f := range fields {
+			path := strings.Split(f.Key, ".")
+			if f.Key == "_id" && op.Key != "$setOnInsert" {
+				// key is immutable, ignore if it is same as existing
+				if id, found := lookupField(doc, "_id"); found && compareValues(id, f.Value) == 0 {
+					continue
+				}
+				return nil, errors.Wrapf(errors.InvalidArgument, "update of immutable field _id is not allowed")
+			}
+			switch op.Key {
+			case "$set":
+				doc = setField(doc, path, f.Value)
+			case "$setOnInsert":
+				if insert {
+					doc = setField(doc, path, f.Value)
+				}
+			case "$unset":
+				doc = unsetField(doc, path)
+			case "$inc":
+				cur, found := lookupField(doc, f.Key)
+				if !found {
+					cur = int32(0)
+				}
+				if typeOrder(cur) != 2 || typeOrder(f.Value) != 2 {
+					return nil, errors.Wrapf(errors.InvalidArgument, "$inc on non numeric field %s", f.Key)
+				}
+				doc = setField(doc, path, addNumbers(cur, f.Value))
+			case "$max", "$min":
+				cur, found := lookupField(doc, f.Key)
+				c := compareValues(f.Value, cur)
+				if !found || (op.Key == "$max" && c > 0) || (op.Key == "$min" && c < 0) {
+					doc = setField(doc, path, f.Value)
+				}
+			default:
+				return nil, errors.Wrapf(errors.InvalidArgument, "unsupported update operator %s", op.Key)
+			}
+		}
+	}
+	return doc, nil
+}
+
+// adds two numeric values, preserving the wider integer type
+func addNumbers(a, b any) any {
+	switch av := a.(type) {
+	case int32:
+		switch bv := b.(type) {
+		case int32:
+			sum := int64(av) + int64(bv)
+			if sum == int64(int32(sum)) {
+				return int32(sum)
+			}
+			return sum
+		case int64:
+			return int64(av) + bv
+		}
+	case int64:
+		switch bv := b.(type) {
+		case int32:
+			return av + int64(bv)
+		case int64:
+			return av + bv
+		}
+	}
+	return toFloat(a) + toFloat(b)
+}
+
+// sortDocuments sorts the documents as per the sort specification
+func sortDocuments(docs []bson.D, spec bson.D) {
+	if len(spec) == 0 {
+		return
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, s := range spec {
+			a, _ := lookupField(docs[i], s.Key)
+			b, _ := lookupField(docs[j], s.Key)
+			c := compareValues(a, b)
+			if c == 0 {
+				continue
+			}
+			if toFloat(s.Value) < 0 {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
@@ -0,0 +1,537 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+type memTestData struct {
+	Desc  string
+	Count int
+	Tags  []string
+	Val   *InternaData
+}
+
+func Test_MemoryCollection(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("crud", func(t *testing.T) {
+		col := NewMemoryCollection("crud")
+		key := &MyKey{Name: "key-1"}
+
+		err := col.InsertOne(ctx, key, &memTestData{Desc: "first", Val: &InternaData{Test: "x"}})
+		if err != nil {
+			t.Fatalf("failed to insert: %s", err)
+		}
+		err = col.InsertOne(ctx, key, &memTestData{Desc: "dup"})
+		if !errors.IsAlreadyExists(err) {
+			t.Errorf("expected already exists error, got %v", err)
+		}
+
+		data := &memTestData{}
+		err = col.FindOne(ctx, key, data)
+		if err != nil || data.Desc != "first" || data.Val == nil || data.Val.Test != "x" {
+			t.Errorf("unexpected find result %v, err %v", data, err)
+		}
+
+		err = col.UpdateOne(ctx, key, bson.M{"count": 5}, false)
+		if err != nil {
+			t.Errorf("failed to update: %s", err)
+		}
+		data = &memTestData{}
+		_ = col.FindOne(ctx, key, data)
+		if data.Desc != "first" || data.Count != 5 {
+			t.Errorf("expected $set semantics on update, got %v", data)
+		}
+
+		err = col.UpdateOne(ctx, &MyKey{Name: "key-2"}, &memTestData{Desc: "second"}, false)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		err = col.UpdateOne(ctx, &MyKey{Name: "key-2"}, &memTestData{Desc: "second"}, true)
+		if err != nil {
+			t.Errorf("failed to upsert: %s", err)
+		}
+
+		count, _ := col.Count(ctx, nil)
+		if count != 2 {
+			t.Errorf("expected 2 entries, got %d", count)
+		}
+
+		err = col.DeleteOne(ctx, key)
+		if err != nil {
+			t.Errorf("failed to delete: %s", err)
+		}
+		err = col.FindOne(ctx, key, data)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		err = col.DeleteOne(ctx, key)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("find_and_update", func(t *testing.T) {
+		col := NewMemoryCollection("find-and-update")
+		key := &MyKey{Name: "counter"}
+		update := bson.M{"$inc": bson.M{"count": 1}}
+
+		data := &memTestData{}
+		opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+		err := col.FindOneAndUpdate(ctx, key, update, data, opts)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found for upserted entry, got %v", err)
+		}
+
+		opts = options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err = col.FindOneAndUpdate(ctx, key, update, data, opts)
+		if err != nil || data.Count != 2 {
+			t.Errorf("expected count 2, got %v, err %v", data.Count, err)
+		}
+
+		err = col.FindOneAndUpdate(ctx, key, update, data, options.Find())
+		if !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for wrong option type, got %v", err)
+		}
+
+		// $max and $min only move the value in their direction
+		for _, u := range []bson.M{
+			{"$max": bson.M{"count": 5}},
+			{"$max": bson.M{"count": 3}},
+			{"$min": bson.M{"count": 4}},
+		} {
+			err = col.FindOneAndUpdate(ctx, key, u, data, opts)
+			if err != nil {
+				t.Errorf("failed to apply %v: %s", u, err)
+			}
+		}
+		if data.Count != 4 {
+			t.Errorf("expected count 4 after $max and $min, got %d", data.Count)
+		}
+	})
+
+	t.Run("find_and_update_condition", func(t *testing.T) {
+		col := NewMemoryCollection("find-and-update-condition")
+		key := &MyKey{Name: "entry"}
+		_ = col.InsertOne(ctx, key, &memTestData{Desc: "first", Count: 1})
+		update := bson.M{"$set": bson.M{"desc": "second"}}
+
+		data := &memTestData{}
+		cond := UpdateCondition{Filter: bson.M{"count": bson.M{"$gt": 1}}}
+		err := col.FindOneAndUpdate(ctx, key, update, data, cond)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found for unmatched condition, got %v", err)
+		}
+		_ = col.FindOne(ctx, key, data)
+		if data.Desc != "first" {
+			t.Errorf("expected entry to be unchanged, got %s", data.Desc)
+		}
+
+		cond = UpdateCondition{Filter: bson.M{"desc": "first"}}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err = col.FindOneAndUpdate(ctx, key, update, data, cond, opts)
+		if err != nil || data.Desc != "second" {
+			t.Errorf("expected entry to be updated, got %v, err %v", data.Desc, err)
+		}
+
+		err = col.FindOneAndUpdate(ctx, &MyKey{Name: "missing"}, update, data, cond)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found for missing entry, got %v", err)
+		}
+		opts = options.FindOneAndUpdate().SetUpsert(true)
+		err = col.FindOneAndUpdate(ctx, &MyKey{Name: "missing"}, update, data, cond, opts)
+		if !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for conditional upsert, got %v", err)
+		}
+	})
+
+	t.Run("find_and_delete", func(t *testing.T) {
+		col := NewMemoryCollection("find-and-delete")
+		for i, name := range []string{"a", "b", "c"} {
+			_ = col.InsertOne(ctx, &MyKey{Name: name}, &memTestData{Desc: name, Count: i})
+		}
+
+		data := &memTestData{}
+		opts := options.FindOneAndDelete().SetSort(bson.D{{Key: "count", Value: -1}})
+		err := col.FindOneAndDelete(ctx, bson.M{"count": bson.M{"$lt": 2}}, data, opts)
+		if err != nil || data.Desc != "b" {
+			t.Errorf("expected entry b to be deleted, got %v, err %v", data, err)
+		}
+		err = col.FindOneAndDelete(ctx, bson.M{"desc": "b"}, data)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		count, _ := col.Count(ctx, nil)
+		if count != 2 {
+			t.Errorf("expected 2 entries, got %d", count)
+		}
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		col := NewMemoryCollection("aggregate")
+		for i, name := range []string{"c", "a", "d", "b"} {
+			_ = col.InsertOne(ctx, &MyKey{Name: name}, &memTestData{Desc: name, Count: i})
+		}
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bson.D{{Key: "count", Value: bson.D{{Key: "$gt", Value: 0}}}}}},
+			{{Key: "$sort", Value: bson.D{{Key: "desc", Value: 1}}}},
+			{{Key: "$limit", Value: 2}},
+		}
+		list := []memTestData{}
+		if err := col.Aggregate(ctx, pipeline, &list); err != nil {
+			t.Fatalf("failed to aggregate: %s", err)
+		}
+		if len(list) != 2 || list[0].Desc != "a" || list[1].Desc != "b" {
+			t.Errorf("unexpected aggregate result %v", list)
+		}
+		unsupported := mongo.Pipeline{{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$desc"}}}}}
+		if err := col.Aggregate(ctx, unsupported, &list); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected unsupported stage to fail, got %v", err)
+		}
+		if err := col.Aggregate(ctx, bson.D{}, &list); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid pipeline type to fail, got %v", err)
+		}
+	})
+
+	t.Run("find_many", func(t *testing.T) {
+		col := NewMemoryCollection("find-many")
+		for i, name := range []string{"c", "a", "d", "b"} {
+			data := &memTestData{Desc: name, Count: i, Tags: []string{"all", name}}
+			if err := col.InsertOne(ctx, &MyKey{Name: name}, data); err != nil {
+				t.Fatalf("failed to insert: %s", err)
+			}
+		}
+
+		tests := []struct {
+			name   string
+			filter any
+			opts   []any
+			want   []string
+		}{
+			{"natural_order", nil, nil, []string{"c", "a", "d", "b"}},
+			{"equality", bson.M{"desc": "a"}, nil, []string{"a"}},
+			{"key_field", bson.M{"_id.name": "d"}, nil, []string{"d"}},
+			{"array_element", bson.M{"tags": "b"}, nil, []string{"b"}},
+			{"comparison", bson.M{"count": bson.M{"$gte": 1, "$lt": 3}}, nil, []string{"a", "d"}},
+			{"in", bson.M{"desc": bson.M{"$in": bson.A{"a", "b"}}}, nil, []string{"a", "b"}},
+			{"or", bson.M{"$or": bson.A{bson.M{"desc": "c"}, bson.M{"count": 3}}}, nil, []string{"c", "b"}},
+			{"regex", bson.M{"desc": bson.M{"$regex": "^[A-B]$", "$options": "i"}}, nil, []string{"a", "b"}},
+			{"exists", bson.M{"missing": bson.M{"$exists": true}}, nil, []string{}},
+			{"null_matches_missing", bson.M{"missing": nil}, nil, []string{"c", "a", "d", "b"}},
+			{"sort_skip_limit", nil, []any{options.Find().SetSort(bson.D{{Key: "desc", Value: -1}}).SetSkip(1).SetLimit(2)}, []string{"c", "b"}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				list := []*memTestData{}
+				err := col.FindMany(ctx, tt.filter, &list, tt.opts...)
+				if err != nil {
+					t.Fatalf("failed to find: %s", err)
+				}
+				got := []string{}
+				for _, d := range list {
+					got = append(got, d.Desc)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("expected %v, got %v", tt.want, got)
+				}
+			})
+		}
+
+		count, err := col.DeleteMany(ctx, bson.M{"count": bson.M{"$gt": 1}})
+		if err != nil || count != 2 {
+			t.Errorf("expected 2 entries deleted, got %d, err %v", count, err)
+		}
+		_, err = col.DeleteMany(ctx, bson.M{"count": bson.M{"$gt": 1}})
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("client", func(t *testing.T) {
+		client := NewMemoryClient()
+		if err := client.HealthCheck(ctx); err != nil {
+			t.Errorf("expected healthy client, got %s", err)
+		}
+
+		store := client.GetDataStore("test-db")
+		if store.Name() != "test-db" {
+			t.Errorf("unexpected store name %s", store.Name())
+		}
+		col := store.GetCollection("shared")
+		err := col.InsertOne(ctx, &MyKey{Name: "key-1"}, &memTestData{Desc: "first"})
+		if err != nil {
+			t.Fatalf("failed to insert: %s", err)
+		}
+
+		data := &memTestData{}
+		err = client.GetCollection("test-db", "shared").FindOne(ctx, &MyKey{Name: "key-1"}, data)
+		if err != nil || data.Desc != "first" {
+			t.Errorf("expected entry to be visible across handles, got %v, err %v", data, err)
+		}
+		err = client.GetCollection("other-db", "shared").FindOne(ctx, &MyKey{Name: "key-1"}, data)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected databases to be isolated, got %v", err)
+		}
+	})
+
+	t.Run("create_collection", func(t *testing.T) {
+		store := NewMemoryClient().GetDataStore("test-db")
+		invalid := []CollectionOptions{
+			{Capped: true},
+			{MaxDocuments: 5},
+			{Capped: true, MaxSize: 1024, TimeSeries: &TimeSeriesOptions{TimeField: "ts"}},
+			{TimeSeries: &TimeSeriesOptions{}},
+			{TimeSeries: &TimeSeriesOptions{TimeField: "ts", Granularity: "days"}},
+			{ExpireAfter: time.Hour},
+		}
+		for _, opts := range invalid {
+			if _, err := store.CreateCollection(ctx, "invalid", opts); !errors.IsInvalidArgument(err) {
+				t.Errorf("expected invalid argument for %+v, got %v", opts, err)
+			}
+		}
+
+		col, err := store.CreateCollection(ctx, "capped", CollectionOptions{Capped: true, MaxSize: 1 << 20, MaxDocuments: 2})
+		if err != nil {
+			t.Fatalf("failed to create collection: %s", err)
+		}
+		for _, name := range []string{"a", "b", "c"} {
+			_ = col.InsertOne(ctx, &MyKey{Name: name}, &memTestData{Desc: name})
+		}
+		list := []memTestData{}
+		_ = store.GetCollection("capped").FindMany(ctx, nil, &list)
+		if len(list) != 2 || list[0].Desc != "b" || list[1].Desc != "c" {
+			t.Errorf("expected only the latest 2 entries to be retained, got %v", list)
+		}
+		if _, err := store.CreateCollection(ctx, "capped", CollectionOptions{}); !errors.IsAlreadyExists(err) {
+			t.Errorf("expected already exists error, got %v", err)
+		}
+
+		_, err = store.CreateCollection(ctx, "metrics", CollectionOptions{
+			TimeSeries:  &TimeSeriesOptions{TimeField: "ts", MetaField: "source", Granularity: TimeSeriesMinutes},
+			ExpireAfter: 24 * time.Hour,
+		})
+		if err != nil {
+			t.Errorf("failed to create time-series collection: %s", err)
+		}
+	})
+
+	t.Run("validator", func(t *testing.T) {
+		store := NewMemoryClient().GetDataStore("test-db")
+		schema := bson.D{
+			{Key: "bsonType", Value: "object"},
+			{Key: "required", Value: bson.A{"desc", "count"}},
+			{Key: "properties", Value: bson.D{
+				{Key: "desc", Value: bson.D{{Key: "bsonType", Value: "string"}, {Key: "minLength", Value: 1}}},
+				{Key: "count", Value: bson.D{{Key: "bsonType", Value: "number"}, {Key: "minimum", Value: 0}}},
+				{Key: "tags", Value: bson.D{
+					{Key: "bsonType", Value: bson.A{"array", "null"}},
+					{Key: "items", Value: bson.D{{Key: "enum", Value: bson.A{"red", "blue"}}}},
+				}},
+			}},
+		}
+		if err := store.SetValidator(ctx, "validated", "not-a-schema"); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for malformed schema, got %v", err)
+		}
+		if err := store.SetValidator(ctx, "validated", schema); err != nil {
+			t.Fatalf("failed to set validator: %s", err)
+		}
+		col := store.GetCollection("validated")
+		if err := col.InsertOne(ctx, &MyKey{Name: "ok"}, &memTestData{Desc: "ok", Count: 1, Tags: []string{"red"}}); err != nil {
+			t.Errorf("expected valid document to be inserted, got %s", err)
+		}
+		invalid := []*memTestData{
+			{Desc: "", Count: 1},
+			{Desc: "neg", Count: -1},
+			{Desc: "tag", Count: 1, Tags: []string{"green"}},
+		}
+		for _, data := range invalid {
+			if err := col.InsertOne(ctx, &MyKey{Name: "bad"}, data); !errors.IsInvalidArgument(err) {
+				t.Errorf("expected invalid argument for %+v, got %v", data, err)
+			}
+		}
+		if err := col.InsertOne(ctx, &MyKey{Name: "bad"}, bson.D{{Key: "desc", Value: "x"}}); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for missing required field, got %v", err)
+		}
+		if err := col.UpdateOne(ctx, &MyKey{Name: "ok"}, bson.D{{Key: "count", Value: -5}}, false); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for invalid update, got %v", err)
+		}
+		data := &memTestData{}
+		_ = col.FindOne(ctx, &MyKey{Name: "ok"}, data)
+		if data.Count != 1 {
+			t.Errorf("expected rejected update to leave the document unchanged, got %+v", data)
+		}
+
+		// removing the validator accepts any document
+		if err := store.SetValidator(ctx, "validated", nil); err != nil {
+			t.Fatalf("failed to remove validator: %s", err)
+		}
+		if err := col.InsertOne(ctx, &MyKey{Name: "bad"}, bson.D{{Key: "desc", Value: "x"}}); err != nil {
+			t.Errorf("expected document to be inserted without validator, got %s", err)
+		}
+
+		col, err := store.CreateCollection(ctx, "created", CollectionOptions{Validator: schema})
+		if err != nil {
+			t.Fatalf("failed to create collection with validator: %s", err)
+		}
+		if err := col.InsertOne(ctx, &MyKey{Name: "bad"}, bson.D{{Key: "desc", Value: "x"}}); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for collection created with validator, got %v", err)
+		}
+	})
+
+	t.Run("indexes", func(t *testing.T) {
+		col := NewMemoryCollection("indexes")
+		name, err := col.CreateIndex(ctx, IndexDefinition{
+			Fields: []IndexField{{Field: "desc", IndexType: IndexAscending}, {Field: "ts", IndexType: IndexDescending}},
+			Unique: true,
+		})
+		if err != nil {
+			t.Fatalf("failed to create index: %s", err)
+		}
+		if name != "desc_1_ts_-1" {
+			t.Errorf("unexpected generated index name %s", name)
+		}
+		err = col.EnsureIndexes(ctx, []IndexDefinition{{
+			Fields: []IndexField{{Field: "created", IndexType: IndexAscending}},
+			TTL:    time.Hour,
+			Name:   "expiry",
+		}})
+		if err != nil {
+			t.Fatalf("failed to ensure indexes: %s", err)
+		}
+		_, err = col.CreateIndex(ctx, IndexDefinition{
+			Fields: []IndexField{{Field: "created", IndexType: IndexAscending}},
+			Name:   "expiry",
+		})
+		if !errors.IsAlreadyExists(err) {
+			t.Errorf("expected conflicting index to fail, got %v", err)
+		}
+
+		list, err := col.ListIndexes(ctx)
+		if err != nil {
+			t.Fatalf("failed to list indexes: %s", err)
+		}
+		if len(list) != 3 || list[0].Name != "_id_" || list[1].Name != name || list[2].TTL != time.Hour {
+			t.Errorf("unexpected indexes %v", list)
+		}
+
+		if err := col.DropIndex(ctx, "expiry"); err != nil {
+			t.Errorf("failed to drop index: %s", err)
+		}
+		if err := col.DropIndex(ctx, "expiry"); !errors.IsNotFound(err) {
+			t.Errorf("expected not found for dropped index, got %v", err)
+		}
+		if err := col.DropIndex(ctx, "_id_"); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected default index drop to fail, got %v", err)
+		}
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		client := NewMemoryClient()
+		orders := client.GetCollection("test-db", "orders")
+		stock := client.GetCollection("test-db", "stock")
+		err := stock.InsertOne(ctx, &MyKey{Name: "item"}, &memTestData{Desc: "available"})
+		if err != nil {
+			t.Fatalf("failed to insert: %s", err)
+		}
+
+		err = client.WithTransaction(ctx, func(ctx context.Context) error {
+			if err := orders.InsertOne(ctx, &MyKey{Name: "order-1"}, &memTestData{Desc: "placed"}); err != nil {
+				return err
+			}
+			if err := stock.UpdateOne(ctx, &MyKey{Name: "item"}, &memTestData{Desc: "reserved"}, false); err != nil {
+				return err
+			}
+			return errors.Wrap(errors.InvalidArgument, "abort")
+		})
+		if !errors.IsInvalidArgument(err) {
+			t.Errorf("expected error from the transaction function, got %v", err)
+		}
+		if err := orders.FindOne(ctx, &MyKey{Name: "order-1"}, &memTestData{}); !errors.IsNotFound(err) {
+			t.Errorf("expected insert to be rolled back, got %v", err)
+		}
+		data := &memTestData{}
+		if err := stock.FindOne(ctx, &MyKey{Name: "item"}, data); err != nil || data.Desc != "available" {
+			t.Errorf("expected update to be rolled back, got %v, err %v", data, err)
+		}
+
+		err = client.WithTransaction(ctx, func(ctx context.Context) error {
+			return orders.InsertOne(ctx, &MyKey{Name: "order-2"}, &memTestData{Desc: "placed"})
+		})
+		if err != nil {
+			t.Fatalf("failed to commit transaction: %s", err)
+		}
+		if err := orders.FindOne(ctx, &MyKey{Name: "order-2"}, &memTestData{}); err != nil {
+			t.Errorf("expected committed insert, got %v", err)
+		}
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		col := NewMemoryCollection("watch")
+		err := col.SetKeyType(reflect.TypeOf(&MyKey{}))
+		if err != nil {
+			t.Fatalf("failed to set key type: %s", err)
+		}
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		ops := make(chan string, 10)
+		err = col.Watch(watchCtx, nil, func(op string, wKey any) {
+			key, ok := wKey.(*MyKey)
+			if !ok {
+				t.Errorf("unexpected key type %T", wKey)
+				return
+			}
+			ops <- op + ":" + key.Name
+		})
+		if err != nil {
+			t.Fatalf("failed to start watch: %s", err)
+		}
+
+		filtered := make(chan *Event[MyKey, memTestData], 10)
+		pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"operationType": "update"}}}}
+		err = WatchEvents(watchCtx, col, pipeline, func(e *Event[MyKey, memTestData]) {
+			filtered <- e
+		})
+		if err != nil {
+			t.Fatalf("failed to start watch events: %s", err)
+		}
+
+		key := &MyKey{Name: "watched"}
+		_ = col.InsertOne(ctx, key, &memTestData{Desc: "one"})
+		_ = col.UpdateOne(ctx, key, &memTestData{Desc: "two"}, false)
+		_ = col.DeleteOne(ctx, key)
+
+		for _, want := range []string{"insert:watched", "update:watched", "delete:watched"} {
+			select {
+			case got := <-ops:
+				if got != want {
+					t.Errorf("expected %s, got %s", want, got)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("timed out waiting for %s", want)
+			}
+		}
+
+		select {
+		case e := <-filtered:
+			if e.Op != "update" || e.Doc.Key.Name != "watched" || e.Entry == nil || e.Entry.Desc != "two" {
+				t.Errorf("unexpected event %v", e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for update event")
+		}
+	})
+}
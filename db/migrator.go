@@ -0,0 +1,193 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"io"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// collection recording the migrations applied to the store
+	migrationCollection = "db-migrations"
+
+	// interval at which a runner retries acquiring the migration lock
+	// held by another replica
+	migrationLockRetryInterval = time.Second
+)
+
+// MigrationLocker provides the cluster wide lock coordinating the
+// migration runners across the replicas, sync.NewMigrationLocker
+// provides the one backed by the sync lock tables
+type MigrationLocker interface {
+	// TryAcquire acquires the lock with the given name, closing the
+	// returned lock releases it
+	// returns AlreadyExists error if the lock is held by another runner
+	TryAcquire(ctx context.Context, name string) (io.Closer, error)
+}
+
+// MigrationFunc performs a migration on the store, eg. creating
+// collections, indexes or moving data across collections
+type MigrationFunc func(ctx context.Context, store Store) error
+
+// Migration describes a single migration of the store
+type Migration struct {
+	// Version orders the migrations, migrations are applied in the
+	// increasing order of version and each version is applied only once
+	Version int
+
+	// Name describes the migration, recorded along with the version
+	Name string
+
+	// Apply performs the migration, it should be idempotent, as it may be
+	// retried if the replica fails before recording the migration
+	Apply MigrationFunc
+}
+
+// key of the record for an applied migration
+type migrationKey struct {
+	Scope   string `bson:"scope"`
+	Version int    `bson:"version"`
+}
+
+type migrationKeyOnly struct {
+	Key migrationKey `bson:"_id"`
+}
+
+// record for an applied migration
+type migrationData struct {
+	Name      string `bson:"name,omitempty"`
+	AppliedAt int64  `bson:"appliedAt,omitempty"`
+}
+
+// Migrator runs the ordered migrations of the store exactly once across
+// all the replicas, recording the applied migrations in the store under
+// the given scope, allowing multiple services to share the store while
+// maintaining their own set of migrations
+type Migrator struct {
+	store         Store
+	scope         string
+	locker        MigrationLocker
+	records       StoreCollection
+	migrations    []Migration
+	retryInterval time.Duration
+}
+
+// NewMigrator creates a migrator for the given scope of the store, using
+// the locker to prevent concurrent runners from different replicas
+func NewMigrator(store Store, scope string, locker MigrationLocker, migrations ...Migration) (*Migrator, error) {
+	if scope == "" {
+		return nil, errors.Wrapf(errors.InvalidArgument, "Migrator: scope is not specified")
+	}
+	if locker == nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "Migrator: locker is not specified for scope %s", scope)
+	}
+	versions := map[int]bool{}
+	for _, m := range migrations {
+		if m.Apply == nil {
+			return nil, errors.Wrapf(errors.InvalidArgument, "Migrator: migration %d for scope %s has no apply function", m.Version, scope)
+		}
+		if versions[m.Version] {
+			return nil, errors.Wrapf(errors.InvalidArgument, "Migrator: duplicate migration version %d for scope %s", m.Version, scope)
+		}
+		versions[m.Version] = true
+	}
+	list := append([]Migration{}, migrations...)
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return &Migrator{
+		store:         store,
+		scope:         scope,
+		locker:        locker,
+		records:       store.GetCollection(migrationCollection),
+		migrations:    list,
+		retryInterval: migrationLockRetryInterval,
+	}, nil
+}
+
+// Run applies the pending migrations in order, while holding the
+// migration lock for the scope. If another replica is running the
+// migrations, it waits for the lock to be released and then applies
+// whatever is still pending.
+// Returns error if the lock cannot be acquired before the context is done,
+// or if any of the migration fails, in which case the subsequent
+// migrations are not applied
+func (m *Migrator) Run(ctx context.Context) error {
+	var lock io.Closer
+	var err error
+	for {
+		lock, err = m.locker.TryAcquire(ctx, m.store.Name()+"/"+m.scope)
+		if err == nil {
+			break
+		}
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(errors.Unknown, "Migrator: failed to acquire lock for scope %s: %s", m.scope, ctx.Err())
+		case <-time.After(m.retryInterval):
+		}
+	}
+	defer func() {
+		_ = lock.Close()
+	}()
+
+	return m.apply(ctx)
+}
+
+// Applied returns the versions of the migrations already applied for
+// the scope
+func (m *Migrator) Applied(ctx context.Context) ([]int, error) {
+	list := []migrationKeyOnly{}
+	err := m.records.FindMany(ctx, bson.D{{Key: "_id.scope", Value: m.scope}}, &list)
+	if err != nil {
+		return nil, err
+	}
+	versions := []int{}
+	for _, k := range list {
+		versions = append(versions, k.Key.Version)
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// applies the pending migrations, expected to be called while holding
+// the migration lock
+func (m *Migrator) apply(ctx context.Context) error {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return err
+	}
+	done := map[int]bool{}
+	for _, v := range applied {
+		done[v] = true
+	}
+
+	for _, mig := range m.migrations {
+		if done[mig.Version] {
+			continue
+		}
+		log.Printf("Migrator: applying migration %d (%s) for scope %s", mig.Version, mig.Name, m.scope)
+		err = mig.Apply(ctx, m.store)
+		if err != nil {
+			return errors.Wrapf(errors.GetErrCode(err), "Migrator: migration %d (%s) failed for scope %s: %s", mig.Version, mig.Name, m.scope, err)
+		}
+		key := &migrationKey{Scope: m.scope, Version: mig.Version}
+		data := &migrationData{Name: mig.Name, AppliedAt: time.Now().Unix()}
+		err = m.records.InsertOne(ctx, key, data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
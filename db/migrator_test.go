@@ -0,0 +1,170 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// in process locker, standing in for the sync lock tables
+type testMigrationLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+type testMigrationLock struct {
+	locker *testMigrationLocker
+	name   string
+}
+
+func (l *testMigrationLock) Close() error {
+	l.locker.mu.Lock()
+	defer l.locker.mu.Unlock()
+	delete(l.locker.held, l.name)
+	return nil
+}
+
+func (l *testMigrationLocker) TryAcquire(ctx context.Context, name string) (io.Closer, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[name] {
+		return nil, errors.Wrapf(errors.AlreadyExists, "lock %s is held", name)
+	}
+	l.held[name] = true
+	return &testMigrationLock{locker: l, name: name}, nil
+}
+
+func Test_Migrator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("invalid", func(t *testing.T) {
+		store := NewMemoryClient().GetDataStore("test-db")
+		locker := &testMigrationLocker{held: map[string]bool{}}
+		noop := func(ctx context.Context, store Store) error { return nil }
+		if _, err := NewMigrator(store, "", locker); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without scope, got %v", err)
+		}
+		if _, err := NewMigrator(store, "svc", nil); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without locker, got %v", err)
+		}
+		if _, err := NewMigrator(store, "svc", locker, Migration{Version: 1}); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without apply, got %v", err)
+		}
+		_, err := NewMigrator(store, "svc", locker, Migration{Version: 1, Apply: noop}, Migration{Version: 1, Apply: noop})
+		if !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for duplicate version, got %v", err)
+		}
+	})
+
+	t.Run("ordered", func(t *testing.T) {
+		store := NewMemoryClient().GetDataStore("test-db")
+		locker := &testMigrationLocker{held: map[string]bool{}}
+		order := []int{}
+		record := func(v int) MigrationFunc {
+			return func(ctx context.Context, store Store) error {
+				order = append(order, v)
+				return nil
+			}
+		}
+		m, err := NewMigrator(store, "svc", locker,
+			Migration{Version: 2, Name: "second", Apply: record(2)},
+			Migration{Version: 1, Name: "first", Apply: record(1)},
+			Migration{Version: 3, Name: "failing", Apply: func(ctx context.Context, store Store) error {
+				return errors.Wrap(errors.Unavailable, "not yet")
+			}},
+		)
+		if err != nil {
+			t.Fatalf("failed to create migrator: %s", err)
+		}
+		if err := m.Run(ctx); !errors.IsUnavailable(err) {
+			t.Errorf("expected failing migration error, got %v", err)
+		}
+		if err := m.Run(ctx); !errors.IsUnavailable(err) {
+			t.Errorf("expected failing migration error, got %v", err)
+		}
+		if !reflect.DeepEqual(order, []int{1, 2}) {
+			t.Errorf("expected migrations to be applied once in order, got %v", order)
+		}
+		applied, err := m.Applied(ctx)
+		if err != nil || !reflect.DeepEqual(applied, []int{1, 2}) {
+			t.Errorf("unexpected applied migrations %v, err %v", applied, err)
+		}
+
+		// other scopes maintain their own migrations
+		other, _ := NewMigrator(store, "other", locker, Migration{Version: 1, Apply: record(1)})
+		if err := other.Run(ctx); err != nil {
+			t.Errorf("failed to run migrations of other scope: %s", err)
+		}
+		if !reflect.DeepEqual(order, []int{1, 2, 1}) {
+			t.Errorf("expected other scope migration to be applied, got %v", order)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		store := NewMemoryClient().GetDataStore("test-db")
+		locker := &testMigrationLocker{held: map[string]bool{}}
+		var calls [3]atomic.Int32
+		migrations := []Migration{}
+		for i := range calls {
+			migrations = append(migrations, Migration{
+				Version: i + 1,
+				Name:    fmt.Sprintf("migration-%d", i+1),
+				Apply: func(ctx context.Context, store Store) error {
+					calls[i].Add(1)
+					time.Sleep(10 * time.Millisecond)
+					return nil
+				},
+			})
+		}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 4)
+		for range 4 {
+			// each runner stands for a replica
+			m, err := NewMigrator(store, "svc", locker, migrations...)
+			if err != nil {
+				t.Fatalf("failed to create migrator: %s", err)
+			}
+			m.retryInterval = 5 * time.Millisecond
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- m.Run(ctx)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Errorf("failed to run migrations: %s", err)
+			}
+		}
+		for i := range calls {
+			if n := calls[i].Load(); n != 1 {
+				t.Errorf("expected migration %d to be applied once, got %d", i+1, n)
+			}
+		}
+	})
+
+	t.Run("lock_timeout", func(t *testing.T) {
+		store := NewMemoryClient().GetDataStore("test-db")
+		locker := &testMigrationLocker{held: map[string]bool{"test-db/svc": true}}
+		m, _ := NewMigrator(store, "svc", locker)
+		m.retryInterval = 5 * time.Millisecond
+		tctx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+		defer cancel()
+		if err := m.Run(tctx); err == nil {
+			t.Errorf("expected error while lock is held by another runner")
+		}
+	})
+}
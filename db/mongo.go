@@ -8,10 +8,11 @@ package db
 
 import (
 	"context"
-	"log"
+	"crypto/tls"
 	"net"
 	"reflect"
 	"strconv"
+	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -38,9 +39,33 @@ func interpretMongoError(err error) error {
 	if err == mongo.ErrNoDocuments {
 		return errors.Wrap(errors.NotFound, err.Error())
 	}
+	if serr, ok := err.(mongo.ServerError); ok && serr.HasErrorCode(mongoDocumentValidationFailure) {
+		return errors.Wrap(errors.InvalidArgument, err.Error())
+	}
 	return err
 }
 
+// returns the context for an operation bounded by the default operation
+// timeout, unless the context already carries a deadline
+func withOperationTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// returns the handle to the collection to run an operation with, using
+// the consistency overridden for the operation if any
+func (c *mongoCollection) handle(colOpts *options.CollectionOptionsBuilder) *mongo.Collection {
+	if colOpts == nil {
+		return c.col
+	}
+	return c.col.Clone(colOpts)
+}
+
 // Set KeyType for the collection, this is not mandatory
 // while the key type will be used by the interface implementer
 // mainly for Watch Callback for providing decoded key, if not
@@ -60,7 +85,13 @@ func (c *mongoCollection) SetKeyType(keyType reflect.Type) error {
 // inserts one entry with given key and data to the collection
 // returns errors if entry already exists or if there is a connection
 // error with the database server
-func (c *mongoCollection) InsertOne(ctx context.Context, key any, data any) error {
+func (c *mongoCollection) InsertOne(ctx context.Context, key any, data any, opts ...any) error {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
+	colOpts, err := operationConsistency("InsertOne", opts)
+	if err != nil {
+		return err
+	}
 	if data == nil {
 		return errors.Wrap(errors.InvalidArgument, "db Insert error: No data to store")
 	}
@@ -94,7 +125,7 @@ func (c *mongoCollection) InsertOne(ctx context.Context, key any, data any) erro
 	}
 	bd = append(bd, primKey)
 
-	_, err = c.col.InsertOne(ctx, bd)
+	_, err = c.handle(colOpts).InsertOne(ctx, bd, options.InsertOne().SetComment(c.parent.source))
 	if err != nil {
 		// identify and differentiate Already Exist error here.
 		return interpretMongoError(err)
@@ -106,7 +137,13 @@ func (c *mongoCollection) InsertOne(ctx context.Context, key any, data any) erro
 // acts based on the flag passed for upsert
 // returns errors if entry not found while upsert flag is false or if
 // there is a connection error with the database server
-func (c *mongoCollection) UpdateOne(ctx context.Context, key any, data any, upsert bool) error {
+func (c *mongoCollection) UpdateOne(ctx context.Context, key any, data any, upsert bool, opts ...any) error {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
+	colOpts, err := operationConsistency("UpdateOne", opts)
+	if err != nil {
+		return err
+	}
 	if data == nil {
 		return errors.Wrap(errors.InvalidArgument, "db Insert error: No data to store")
 	}
@@ -114,14 +151,14 @@ func (c *mongoCollection) UpdateOne(ctx context.Context, key any, data any, upse
 		return errors.Wrap(errors.InvalidArgument, "db Insert error: No Key specified to store")
 	}
 
-	opts := options.UpdateOne().SetUpsert(upsert)
-	resp, err := c.col.UpdateOne(
+	updateOpts := options.UpdateOne().SetUpsert(upsert).SetComment(c.parent.source)
+	resp, err := c.handle(colOpts).UpdateOne(
 		ctx,
 		bson.M{"_id": key},
 		bson.D{
 			{Key: "$set", Value: data},
 		},
-		opts)
+		updateOpts)
 
 	if err != nil {
 		return interpretMongoError(err)
@@ -136,10 +173,95 @@ func (c *mongoCollection) UpdateOne(ctx context.Context, key any, data any, upse
 	return nil
 }
 
+// Find one entry from the store collection for the given key and atomically
+// apply the update document to it, where the data value is returned based
+// on the object type passed to it. Depending on the options passed, data
+// holds the state of the entry either before or after the update.
+// returns NotFound error if no entry existed before, while working with
+// options returning the entry state before update
+func (c *mongoCollection) FindOneAndUpdate(ctx context.Context, key any, update any, data any, opts ...any) error {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
+	if key == nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndUpdate error: No Key specified")
+	}
+	if update == nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndUpdate error: No update specified")
+	}
+	updateOpts := []options.Lister[options.FindOneAndUpdateOptions]{
+		options.FindOneAndUpdate().SetComment(c.parent.source),
+	}
+	opts, colOpts, err := splitConsistency(opts)
+	if err != nil {
+		return err
+	}
+	opts, cond, err := splitCondition(opts)
+	if err != nil {
+		return err
+	}
+	filter := bson.D{{Key: "_id", Value: key}}
+	if cond != nil {
+		filter = append(filter, bson.E{Key: "$and", Value: bson.A{cond}})
+	}
+	for _, opt := range opts {
+		val, ok := opt.(options.Lister[options.FindOneAndUpdateOptions])
+		if !ok {
+			return errors.Wrapf(errors.InvalidArgument, "Invalid option type %T passed to FindOneAndUpdate", opt)
+		}
+		updateOpts = append(updateOpts, val)
+	}
+	resp := c.handle(colOpts).FindOneAndUpdate(ctx, filter, update, updateOpts...)
+	// decode the value returned by the mongodb client into the data
+	// object passed by the caller
+	if err := resp.Decode(data); err != nil {
+		return interpretMongoError(err)
+	}
+	return nil
+}
+
+// Find one entry from the store collection matching the given filter and
+// atomically delete it, where the data value holds the deleted entry
+// based on the object type passed to it
+// returns NotFound error if no entry matches the filter
+func (c *mongoCollection) FindOneAndDelete(ctx context.Context, filter any, data any, opts ...any) error {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
+	if filter == nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndDelete error: No filter specified")
+	}
+	deleteOpts := []options.Lister[options.FindOneAndDeleteOptions]{
+		options.FindOneAndDelete().SetComment(c.parent.source),
+	}
+	opts, colOpts, err := splitConsistency(opts)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		val, ok := opt.(options.Lister[options.FindOneAndDeleteOptions])
+		if !ok {
+			return errors.Wrapf(errors.InvalidArgument, "Invalid option type %T passed to FindOneAndDelete", opt)
+		}
+		deleteOpts = append(deleteOpts, val)
+	}
+	resp := c.handle(colOpts).FindOneAndDelete(ctx, filter, deleteOpts...)
+	// decode the value returned by the mongodb client into the data
+	// object passed by the caller
+	if err := resp.Decode(data); err != nil {
+		return interpretMongoError(err)
+	}
+	return nil
+}
+
 // Find one entry from the store collection for the given key, where the data
 // value is returned based on the object type passed to it
-func (c *mongoCollection) FindOne(ctx context.Context, key any, data any) error {
-	resp := c.col.FindOne(ctx, bson.M{"_id": key})
+func (c *mongoCollection) FindOne(ctx context.Context, key any, data any, opts ...any) error {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
+	colOpts, err := operationConsistency("FindOne", opts)
+	if err != nil {
+		return err
+	}
+	resp := c.handle(colOpts).FindOne(ctx, bson.M{"_id": key}, options.FindOne().SetComment(c.parent.source))
 	// decode the value returned by the mongodb client into the data
 	// object passed by the caller
 	if err := resp.Decode(data); err != nil {
@@ -152,10 +274,18 @@ func (c *mongoCollection) FindOne(ctx context.Context, key any, data any) error
 // Find multiple entries from the store collection for the given filter, where the data
 // value is returned as a list based on the object type passed to it
 func (c *mongoCollection) FindMany(ctx context.Context, filter any, data any, opts ...any) error {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
 	if filter == nil {
 		filter = bson.D{}
 	}
-	var findOpts []options.Lister[options.FindOptions]
+	findOpts := []options.Lister[options.FindOptions]{
+		options.Find().SetComment(c.parent.source),
+	}
+	opts, colOpts, err := splitConsistency(opts)
+	if err != nil {
+		return err
+	}
 	for _, opt := range opts {
 		val, ok := opt.(options.Lister[options.FindOptions])
 		if !ok {
@@ -163,7 +293,7 @@ func (c *mongoCollection) FindMany(ctx context.Context, filter any, data any, op
 		}
 		findOpts = append(findOpts, val)
 	}
-	cursor, err := c.col.Find(ctx, filter, findOpts...)
+	cursor, err := c.handle(colOpts).Find(ctx, filter, findOpts...)
 	if err != nil {
 		return interpretMongoError(err)
 	}
@@ -174,11 +304,17 @@ func (c *mongoCollection) FindMany(ctx context.Context, filter any, data any, op
 }
 
 // Return count of entries matching the provided filter
-func (c *mongoCollection) Count(ctx context.Context, filter any) (int64, error) {
+func (c *mongoCollection) Count(ctx context.Context, filter any, opts ...any) (int64, error) {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
+	colOpts, err := operationConsistency("Count", opts)
+	if err != nil {
+		return 0, err
+	}
 	if filter == nil {
 		filter = bson.D{}
 	}
-	count, err := c.col.CountDocuments(ctx, filter)
+	count, err := c.handle(colOpts).CountDocuments(ctx, filter, options.Count().SetComment(c.parent.source))
 	if err != nil {
 		return 0, interpretMongoError(err)
 	}
@@ -186,8 +322,14 @@ func (c *mongoCollection) Count(ctx context.Context, filter any) (int64, error)
 }
 
 // remove one entry from the collection matching the given key
-func (c *mongoCollection) DeleteOne(ctx context.Context, key any) error {
-	resp, err := c.col.DeleteOne(ctx, bson.M{"_id": key})
+func (c *mongoCollection) DeleteOne(ctx context.Context, key any, opts ...any) error {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
+	colOpts, err := operationConsistency("DeleteOne", opts)
+	if err != nil {
+		return err
+	}
+	resp, err := c.handle(colOpts).DeleteOne(ctx, bson.M{"_id": key}, options.DeleteOne().SetComment(c.parent.source))
 	if err != nil {
 		// TODO(prabhjot) we may need to identify and differentiate
 		// Not found error here
@@ -202,8 +344,14 @@ func (c *mongoCollection) DeleteOne(ctx context.Context, key any) error {
 
 // Delete Many entries matching the delete criteria
 // returns number of entries deleted and if there is any error processing the request
-func (c *mongoCollection) DeleteMany(ctx context.Context, filter any) (int64, error) {
-	resp, err := c.col.DeleteMany(ctx, filter)
+func (c *mongoCollection) DeleteMany(ctx context.Context, filter any, opts ...any) (int64, error) {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
+	colOpts, err := operationConsistency("DeleteMany", opts)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.handle(colOpts).DeleteMany(ctx, filter, options.DeleteMany().SetComment(c.parent.source))
 	if err != nil {
 		return 0, interpretMongoError(err)
 	}
@@ -218,7 +366,7 @@ func (c *mongoCollection) DeleteMany(ctx context.Context, filter any) (int64, er
 // allow provisiong for a filter to be passed on, where the callback
 // function to receive only conditional notifications of the events
 // listener is interested about
-func (c *mongoCollection) Watch(ctx context.Context, filter any, cb WatchCallbackfn) error {
+func (c *mongoCollection) Watch(ctx context.Context, filter any, cb WatchCallbackfn, opts ...WatchOption) error {
 	if filter == nil {
 		// if passed filter is nil, initialize it to empty pipeline object
 		filter = mongo.Pipeline{}
@@ -230,95 +378,24 @@ func (c *mongoCollection) Watch(ctx context.Context, filter any, cb WatchCallbac
 	default:
 		return errors.Wrapf(errors.InvalidArgument, "Invalid watch filter pipeline type specified, %v", v)
 	}
-	// start watching on the collection with passed context
-	stream, err := c.col.Watch(ctx, filter)
-	if err != nil {
-		return err
-	}
-
-	// run the loop on stream in a separate go routine
-	// allowing the watch starter to resume control and work with
-	// managing Watch stream by virtue of passed context
-	go func() {
-		// take a snapshot of keyTpe for processing watch
-		keyType := c.keyType
-		// ensure closing of the open stream in case of returning from here
-		// keeping the handles and stack clean
-		// Note: this may not be required, if loop doesn't require it
-		// but still it is safe to keep ensuring appropriate cleanup
-		defer func() {
-			// ignore the error returned by stream close as of now
-			_ = stream.Close(context.Background())
-		}()
-		defer func() {
-			if !errors.Is(ctx.Err(), context.Canceled) {
-				// panic if the return from this function is not
-				// due to context being canceled
-				log.Panicf("End of stream observed due to error %s", stream.Err())
-			}
-		}()
-		for stream.Next(ctx) {
-			var data bson.M
-			if err := stream.Decode(&data); err != nil {
-				log.Printf("Closing watch due to decoding error %s", err)
-				return
-			}
-
-			op, ok := data["operationType"].(string)
-			if !ok {
-				log.Printf("Closing watch due to error, unable to find decode operation type ")
-				return
-			}
-
-			var dk bson.M
-			mdk, err := bson.Marshal(data["documentKey"])
-			if err != nil {
-				log.Printf("Closing watch due to error, while bson Marshal dock Key : %q", err)
-				return
-			}
-
-			err = bson.Unmarshal(mdk, &dk)
-			if err != nil {
-				log.Printf("Closing watch due to error, while bson Unmarshal doc key : %q", err)
-				return
-			}
-
-			bKey, ok := dk["_id"].(bson.D)
-			if !ok {
-				log.Printf("Closing watch due to error, unable to find id")
-				return
-			}
-
-			// key that will be shared with callback function
-			var key any
-			if keyType != nil {
-				key = reflect.New(keyType.Elem()).Interface()
-			} else {
-				key = bson.M{}
-			}
-
-			marshaledData, err := bson.Marshal(bKey)
-			if err != nil {
-				log.Printf("Closing watch due to error, while bson Marshal : %q", err)
-				return
-			}
-
-			err = bson.Unmarshal(marshaledData, key)
-			if err != nil {
-				log.Printf("Closing watch due to error, while bson Unmarshal to key : %q", err)
-				return
-			}
-			cb(op, key)
+	wopts := getWatchOptions(opts)
+	newOpts := func(initial bool) *options.ChangeStreamOptionsBuilder {
+		opts := options.ChangeStream().SetComment(c.parent.source)
+		if initial && wopts.ResumeAfter != nil {
+			opts.SetResumeAfter(wopts.ResumeAfter)
 		}
-	}()
-
-	return nil
+		return opts
+	}
+	// take a snapshot of keyTpe for processing watch
+	return c.runChangeStream(ctx, filter, newOpts, wopts, watchDelivery(c.keyType, cb))
 }
 
 // Aggregate runs a MongoDB aggregation pipeline against the collection and
 // decodes all result documents into the provided result pointer (must be a
 // pointer to a slice). Cursor lifecycle is managed internally.
 func (c *mongoCollection) Aggregate(ctx context.Context, pipeline any, result any, opts ...any) error {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
 	if pipeline == nil {
 		return errors.Wrap(errors.InvalidArgument, "aggregate pipeline must not be nil")
 	}
@@ -328,7 +405,13 @@ func (c *mongoCollection) Aggregate(ctx context.Context, pipeline any, result an
 	default:
 		return errors.Wrapf(errors.InvalidArgument, "invalid aggregate pipeline type specified, %T", pipeline)
 	}
-	var aggOpts []options.Lister[options.AggregateOptions]
+	aggOpts := []options.Lister[options.AggregateOptions]{
+		options.Aggregate().SetComment(c.parent.source),
+	}
+	opts, colOpts, err := splitConsistency(opts)
+	if err != nil {
+		return err
+	}
 	for _, opt := range opts {
 		val, ok := opt.(options.Lister[options.AggregateOptions])
 		if !ok {
@@ -336,7 +419,7 @@ func (c *mongoCollection) Aggregate(ctx context.Context, pipeline any, result an
 		}
 		aggOpts = append(aggOpts, val)
 	}
-	cursor, err := c.col.Aggregate(ctx, pipeline, aggOpts...)
+	cursor, err := c.handle(colOpts).Aggregate(ctx, pipeline, aggOpts...)
 	if err != nil {
 		return interpretMongoError(err)
 	}
@@ -350,34 +433,18 @@ func (c *mongoCollection) Aggregate(ctx context.Context, pipeline any, result an
 // EnsureIndexes creates the specified indexes on the collection if they
 // don't already exist. This operation is idempotent.
 func (c *mongoCollection) EnsureIndexes(ctx context.Context, indexes []IndexDefinition) error {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
 	if len(indexes) == 0 {
 		return nil
 	}
 
 	models := make([]mongo.IndexModel, 0, len(indexes))
 	for _, idx := range indexes {
-		if len(idx.Fields) == 0 {
-			return errors.Wrap(errors.InvalidArgument, "index definition must have at least one field")
+		model, err := indexModel(&idx)
+		if err != nil {
+			return err
 		}
-		keys := bson.D{}
-		for _, f := range idx.Fields {
-			if f.Field == "" {
-				return errors.Wrap(errors.InvalidArgument, "index field name must not be empty")
-			}
-			if f.IndexType != IndexAscending && f.IndexType != IndexDescending {
-				return errors.Wrapf(errors.InvalidArgument, "invalid index type %d for field %q", f.IndexType, f.Field)
-			}
-			keys = append(keys, bson.E{Key: f.Field, Value: int(f.IndexType)})
-		}
-		model := mongo.IndexModel{Keys: keys}
-		opts := options.Index().SetUnique(idx.Unique).SetSparse(idx.Sparse)
-		if idx.TTL > 0 {
-			opts.SetExpireAfterSeconds(int32(idx.TTL.Seconds()))
-		}
-		if idx.Name != "" {
-			opts.SetName(idx.Name)
-		}
-		model.Options = opts
 		models = append(models, model)
 	}
 
@@ -388,6 +455,164 @@ func (c *mongoCollection) EnsureIndexes(ctx context.Context, indexes []IndexDefi
 	return nil
 }
 
+// builds the mongo index model for the index definition
+func indexModel(idx *IndexDefinition) (mongo.IndexModel, error) {
+	if err := validateIndex(idx); err != nil {
+		return mongo.IndexModel{}, err
+	}
+	keys := bson.D{}
+	for _, f := range idx.Fields {
+		if f.IndexType == IndexText {
+			keys = append(keys, bson.E{Key: f.Field, Value: "text"})
+		} else {
+			keys = append(keys, bson.E{Key: f.Field, Value: int(f.IndexType)})
+		}
+	}
+	opts := options.Index().SetUnique(idx.Unique).SetSparse(idx.Sparse)
+	if idx.TTL > 0 {
+		opts.SetExpireAfterSeconds(int32(idx.TTL.Seconds()))
+	}
+	if idx.Name != "" {
+		opts.SetName(idx.Name)
+	}
+	if idx.PartialFilter != nil {
+		opts.SetPartialFilterExpression(idx.PartialFilter)
+	}
+	return mongo.IndexModel{Keys: keys, Options: opts}, nil
+}
+
+// CreateIndex creates the index on the collection if it doesn't exist
+// already, returning the name of the index
+func (c *mongoCollection) CreateIndex(ctx context.Context, index IndexDefinition) (string, error) {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
+	model, err := indexModel(&index)
+	if err != nil {
+		return "", err
+	}
+	name, err := c.col.Indexes().CreateOne(ctx, model)
+	if err != nil {
+		return "", interpretMongoError(err)
+	}
+	return name, nil
+}
+
+// index specification as returned by listIndexes
+type mongoIndexSpec struct {
+	Name               string   `bson:"name"`
+	Key                bson.D   `bson:"key"`
+	Unique             bool     `bson:"unique,omitempty"`
+	Sparse             bool     `bson:"sparse,omitempty"`
+	ExpireAfterSeconds *float64 `bson:"expireAfterSeconds,omitempty"`
+	PartialFilter      bson.D   `bson:"partialFilterExpression,omitempty"`
+	Weights            bson.D   `bson:"weights,omitempty"`
+}
+
+// converts the index specification to the index definition
+func (s *mongoIndexSpec) definition() IndexDefinition {
+	idx := IndexDefinition{
+		Name:   s.Name,
+		Unique: s.Unique,
+		Sparse: s.Sparse,
+	}
+	for _, k := range s.Key {
+		switch k.Key {
+		case "_fts":
+			// text index fields are available as weights
+			for _, w := range s.Weights {
+				idx.Fields = append(idx.Fields, IndexField{Field: w.Key, IndexType: IndexText})
+			}
+		case "_ftsx":
+		default:
+			t := IndexAscending
+			if toFloat(k.Value) < 0 {
+				t = IndexDescending
+			}
+			idx.Fields = append(idx.Fields, IndexField{Field: k.Key, IndexType: t})
+		}
+	}
+	if s.ExpireAfterSeconds != nil {
+		idx.TTL = time.Duration(*s.ExpireAfterSeconds * float64(time.Second))
+	}
+	if s.PartialFilter != nil {
+		idx.PartialFilter = s.PartialFilter
+	}
+	return idx
+}
+
+// ListIndexes returns the indexes present on the collection
+func (c *mongoCollection) ListIndexes(ctx context.Context) ([]IndexDefinition, error) {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
+	cursor, err := c.col.Indexes().List(ctx)
+	if err != nil {
+		return nil, interpretMongoError(err)
+	}
+	specs := []mongoIndexSpec{}
+	if err := cursor.All(ctx, &specs); err != nil {
+		return nil, interpretMongoError(err)
+	}
+	list := make([]IndexDefinition, 0, len(specs))
+	for _, s := range specs {
+		list = append(list, s.definition())
+	}
+	return list, nil
+}
+
+// DropIndex removes the index with the given name from the collection
+func (c *mongoCollection) DropIndex(ctx context.Context, name string) error {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
+	err := c.col.Indexes().DropOne(ctx, name)
+	if cerr, ok := err.(mongo.CommandError); ok && cerr.Code == 27 {
+		// IndexNotFound
+		return errors.Wrapf(errors.NotFound, "index %s not found", name)
+	}
+	return interpretMongoError(err)
+}
+
+// WithConsistency returns a handle to the same collection using the
+// given read preference, read concern and write concern
+func (c *mongoCollection) WithConsistency(opts ConsistencyOptions) (StoreCollection, error) {
+	colOpts, err := opts.collectionOptions()
+	if err != nil {
+		return nil, err
+	}
+	return &mongoCollection{
+		parent:  c.parent,
+		colName: c.colName,
+		col:     c.col.Clone(colOpts),
+		keyType: c.keyType,
+	}, nil
+}
+
+// watchEvents starts watching the collection for change events, decoding
+// every event into a new object of the given event type (typically
+// Event[K, E]) and passing the pointer to the callback, where full
+// documents are looked up for update events as well
+func (c *mongoCollection) watchEvents(ctx context.Context, filter any, eventType reflect.Type, cb func(event any), wopts *WatchOptions) error {
+	if filter == nil {
+		// if passed filter is nil, initialize it to empty pipeline object
+		filter = mongo.Pipeline{}
+	}
+	switch v := filter.(type) {
+	case mongo.Pipeline:
+		// we are ok to proceed further
+		break
+	default:
+		return errors.Wrapf(errors.InvalidArgument, "Invalid watch filter pipeline type specified, %v", v)
+	}
+
+	newOpts := func(initial bool) *options.ChangeStreamOptionsBuilder {
+		opts := options.ChangeStream().SetComment(c.parent.source).SetFullDocument(options.UpdateLookup)
+		if initial && wopts.ResumeAfter != nil {
+			opts.SetResumeAfter(wopts.ResumeAfter)
+		}
+		return opts
+	}
+	return c.runChangeStream(ctx, filter, newOpts, wopts, eventDelivery(eventType, cb))
+}
+
 // startEventLogger starts the event logger for the collection and trigger logger for events
 func (c *mongoCollection) startEventLogger(ctx context.Context, eventType reflect.Type, timestamp *bson.Timestamp) error {
 	// TODO(prabhjot) if we may need to enable pre and post images for change streams
@@ -411,62 +636,26 @@ func (c *mongoCollection) startEventLogger(ctx context.Context, eventType reflec
 		}()
 	*/
 
-	opts := options.ChangeStream()
-	opts.SetFullDocumentBeforeChange(options.WhenAvailable)
-	opts.SetFullDocument(options.WhenAvailable)
-
-	if timestamp != nil {
-		opts.SetStartAtOperationTime(timestamp)
+	newOpts := func(initial bool) *options.ChangeStreamOptionsBuilder {
+		opts := options.ChangeStream().SetComment(c.parent.source)
+		opts.SetFullDocumentBeforeChange(options.WhenAvailable)
+		opts.SetFullDocument(options.WhenAvailable)
+		if initial && timestamp != nil {
+			opts.SetStartAtOperationTime(timestamp)
+		}
+		return opts
 	}
 
 	// start watching on the collection with required context
-	stream, err := c.col.Watch(ctx, mongo.Pipeline{}, opts)
-	if err != nil {
-		return err
-	}
-
-	// run the loop on stream in a separate go routine
-	// allowing the watch starter to resume control and work with
-	// managing Watch stream by virtue of passed context
-	go func() {
-		// ensure closing of the open stream in case of returning from here
-		// keeping the handles and stack clean
-		// Note: this may not be required, if loop doesn't require it
-		// but still it is safe to keep ensuring appropriate cleanup
-		defer func() {
-			// ignore the error returned by stream close as of now
-			_ = stream.Close(context.Background())
-		}()
-		defer func() {
-			if !errors.Is(ctx.Err(), context.Canceled) {
-				// panic if the return from this function is not
-				// due to context being canceled
-				log.Panicf("End of stream observed due to error %s", stream.Err())
-			}
-		}()
-		for stream.Next(ctx) {
-			event := reflect.New(eventType)
-
-			if err := stream.Decode(event.Interface()); err != nil {
-				log.Printf("Closing watch due to decoding error %s", err)
-				return
-			}
-
-			method := event.MethodByName("LogEvent")
-			if !method.IsValid() {
-				log.Println("Invalid Log Events method, skipping event logging")
-			} else {
-				method.Call([]reflect.Value{})
-			}
-		}
-	}()
-
-	return nil
+	return c.runChangeStream(ctx, mongo.Pipeline{}, newOpts, getWatchOptions(nil), eventDelivery(eventType, logEvent))
 }
 
 type mongoStore struct {
 	Store
-	db *mongo.Database
+	db      *mongo.Database
+	timeout time.Duration    // default operation timeout
+	res     *clientResources // watches stopped on disconnect
+	source  string           // source identifier commented on operations
 }
 
 func (s *mongoStore) GetCollection(name string) StoreCollection {
@@ -480,13 +669,69 @@ func (s *mongoStore) GetCollection(name string) StoreCollection {
 	return c
 }
 
+// server error code, when the collection being created exists already
+const mongoNamespaceExists = 48
+
+// CreateCollection explicitly creates the collection with the given
+// options, eg. capped or time-series collection
+func (s *mongoStore) CreateCollection(ctx context.Context, name string, opts CollectionOptions) (StoreCollection, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	err := s.db.CreateCollection(ctx, name, opts.createOptions())
+	if err != nil {
+		if serr, ok := err.(mongo.ServerError); ok && serr.HasErrorCode(mongoNamespaceExists) {
+			return nil, errors.Wrapf(errors.AlreadyExists, "collection %s already exists", name)
+		}
+		return nil, err
+	}
+	return s.GetCollection(name), nil
+}
+
+// server error code, when the collection doesn't exist
+const mongoNamespaceNotFound = 26
+
+// SetValidator installs the $jsonSchema validation rules on the
+// collection using collMod, creating the collection with the validator
+// if it doesn't exist yet
+func (s *mongoStore) SetValidator(ctx context.Context, col string, schema any) error {
+	ctx, cancel := withOperationTimeout(ctx, s.timeout)
+	defer cancel()
+	validator := bson.D{}
+	if schema != nil {
+		if _, err := toSchema(schema); err != nil {
+			return err
+		}
+		validator = bson.D{{Key: "$jsonSchema", Value: schema}}
+	}
+	err := s.db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: col},
+		{Key: "validator", Value: validator},
+	}).Err()
+	if serr, ok := err.(mongo.ServerError); ok && serr.HasErrorCode(mongoNamespaceNotFound) {
+		if schema == nil {
+			return nil
+		}
+		err = s.db.CreateCollection(ctx, col, options.CreateCollection().SetValidator(validator))
+		if serr, ok := err.(mongo.ServerError); ok && serr.HasErrorCode(mongoNamespaceExists) {
+			// created concurrently, retry updating the validator
+			return s.SetValidator(ctx, col, schema)
+		}
+	}
+	return err
+}
+
 func (s *mongoStore) Name() string {
 	return s.db.Name()
 }
 
 type mongoClient struct {
 	StoreClient
-	client *mongo.Client
+	client  *mongo.Client
+	maxLag  time.Duration    // replication lag considered degraded
+	timeout time.Duration    // default operation timeout
+	res     *clientResources // watches stopped on disconnect
+	source  string           // source identifier commented on operations
 }
 
 type MongoConfig struct {
@@ -495,6 +740,58 @@ type MongoConfig struct {
 	Uri      string
 	Username string
 	Password string
+
+	// X509 enables authentication using the client certificate instead
+	// of the username and password, over TLS
+	X509 *MongoX509Config
+
+	// TLS configuration to use for connecting to the database, TLS is
+	// disabled if not configured unless enabled via the uri
+	TLS *tls.Config
+
+	// timeout for establishing a connection to a server
+	// Default: 0 (driver default of 30 seconds)
+	ConnectTimeout time.Duration
+
+	// timeout for selecting a suitable server for an operation
+	// Default: 0 (driver default of 30 seconds)
+	ServerSelectionTimeout time.Duration
+
+	// minimum and maximum number of connections in the pool per server
+	// Default: 0 (driver default of 0 and 100 respectively)
+	MinPoolSize uint64
+	MaxPoolSize uint64
+
+	// maximum time a connection may remain idle in the pool before
+	// being closed
+	// Default: 0 (no limit)
+	MaxConnIdleTime time.Duration
+
+	// name of the replica set to connect to
+	ReplicaSet string
+
+	// enables or disables retry of the write and read operations once on
+	// network errors
+	// Default: nil (driver default of enabled)
+	RetryWrites *bool
+	RetryReads  *bool
+
+	// default timeout for the operations invoked with a context without
+	// deadline, eg. context.Background(), so that the operations don't
+	// hang forever against an unresponsive deployment. Watch and the
+	// transactions are not bounded by it.
+	// Default: 0 (no timeout)
+	OperationTimeout time.Duration
+
+	// replication lag of a member beyond which HealthReport considers
+	// the deployment degraded
+	// Default: 10 seconds
+	MaxReplicationLag time.Duration
+
+	// Instrumentation receives every operation performed using the
+	// client, for tracing and metrics
+	// Default: nil (not instrumented)
+	Instrumentation Instrumentation
 }
 
 func (c *MongoConfig) validate() error {
@@ -514,28 +811,93 @@ func (c *MongoConfig) validate() error {
 			}
 		}
 	}
+	if c.ConnectTimeout < 0 || c.ServerSelectionTimeout < 0 || c.MaxConnIdleTime < 0 {
+		return errors.Wrap(errors.InvalidArgument, "timeouts must not be negative")
+	}
+	if c.OperationTimeout < 0 {
+		return errors.Wrap(errors.InvalidArgument, "operation timeout must not be negative")
+	}
+	if c.MaxReplicationLag < 0 {
+		return errors.Wrap(errors.InvalidArgument, "max replication lag must not be negative")
+	}
+	if c.MaxReplicationLag == 0 {
+		c.MaxReplicationLag = defaultMaxReplicationLag
+	}
+	if c.MaxPoolSize != 0 && c.MinPoolSize > c.MaxPoolSize {
+		return errors.Wrapf(errors.InvalidArgument, "min pool size %d exceeds max pool size %d", c.MinPoolSize, c.MaxPoolSize)
+	}
+	if c.X509 != nil {
+		if c.Username != "" || c.Password != "" {
+			return errors.Wrap(errors.InvalidArgument, "cannot provide username and password with x509 authentication")
+		}
+		return c.X509.validate()
+	}
 	return nil
 }
 
-func NewMongoClient(conf *MongoConfig) (StoreClient, error) {
-	if err := conf.validate(); err != nil {
-		return nil, err
-	}
+// returns the client options for the configuration, the settings
+// explicitly configured take precedence over the ones in uri
+func (c *MongoConfig) clientOptions() (*options.ClientOptions, error) {
 	// TODO(prabhjot) better to enventually switch to just uri
 	// instead of allowing host port configuration
 	var uri string
-	if conf.Uri != "" {
-		uri = conf.Uri
+	if c.Uri != "" {
+		uri = c.Uri
 	} else {
-		uri = "mongodb://" + net.JoinHostPort(conf.Host, conf.Port)
+		uri = "mongodb://" + net.JoinHostPort(c.Host, c.Port)
 	}
 	clientOptions := options.Client()
 	clientOptions.ApplyURI(uri)
-	clientOptions.SetAuth(options.Credential{
-		AuthMechanism: "SCRAM-SHA-256",
-		AuthSource:    "admin", //getSourceIdentifier(),
-		Username:      conf.Username,
-		Password:      conf.Password,
+	if c.X509 != nil {
+		if err := c.X509.apply(clientOptions, c.TLS); err != nil {
+			return nil, err
+		}
+	} else {
+		clientOptions.SetAuth(options.Credential{
+			AuthMechanism: "SCRAM-SHA-256",
+			AuthSource:    "admin", //getSourceIdentifier(),
+			Username:      c.Username,
+			Password:      c.Password,
+		})
+		if c.TLS != nil {
+			clientOptions.SetTLSConfig(c.TLS)
+		}
+	}
+	if c.ConnectTimeout != 0 {
+		clientOptions.SetConnectTimeout(c.ConnectTimeout)
+	}
+	if c.ServerSelectionTimeout != 0 {
+		clientOptions.SetServerSelectionTimeout(c.ServerSelectionTimeout)
+	}
+	if c.MinPoolSize != 0 {
+		clientOptions.SetMinPoolSize(c.MinPoolSize)
+	}
+	if c.MaxPoolSize != 0 {
+		clientOptions.SetMaxPoolSize(c.MaxPoolSize)
+	}
+	if c.MaxConnIdleTime != 0 {
+		clientOptions.SetMaxConnIdleTime(c.MaxConnIdleTime)
+	}
+	if c.ReplicaSet != "" {
+		clientOptions.SetReplicaSet(c.ReplicaSet)
+	}
+	if c.RetryWrites != nil {
+		clientOptions.SetRetryWrites(*c.RetryWrites)
+	}
+	if c.RetryReads != nil {
+		clientOptions.SetRetryReads(*c.RetryReads)
+	}
+
+	// attribute the connections to the source for the server side
+	// profiling, app name in uri is retained only when the source
+	// identifier is not set explicitly
+	source, explicit := useSourceIdentifier()
+	if clientOptions.AppName == nil || explicit {
+		clientOptions.SetAppName(source)
+	}
+	clientOptions.SetDriverInfo(&options.DriverInfo{
+		Name:     driverInfoName,
+		Platform: source,
 	})
 
 	// by default ensure majority write concern and journal to be true
@@ -547,6 +909,18 @@ func NewMongoClient(conf *MongoConfig) (StoreClient, error) {
 	wc := writeconcern.Majority()
 	wc.Journal = utils.Pointer(true)
 	clientOptions.SetWriteConcern(wc)
+	return clientOptions, nil
+}
+
+func NewMongoClient(conf *MongoConfig) (StoreClient, error) {
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+
+	clientOptions, err := conf.clientOptions()
+	if err != nil {
+		return nil, err
+	}
 
 	client, err := mongo.Connect(clientOptions)
 	if err != nil {
@@ -555,9 +929,13 @@ func NewMongoClient(conf *MongoConfig) (StoreClient, error) {
 
 	// make the MongoStore struct hear and then call schema stuff here
 	mClient := &mongoClient{
-		client: client,
+		client:  client,
+		maxLag:  conf.MaxReplicationLag,
+		timeout: conf.OperationTimeout,
+		res:     newClientResources(),
+		source:  GetSourceIdentifier(),
 	}
-	return mClient, nil
+	return NewInstrumentedClient(mClient, conf.Instrumentation), nil
 }
 
 // Gets Mongodb Data Store for given database name
@@ -568,7 +946,10 @@ func (c *mongoClient) GetDataStore(dbName string) Store {
 
 	// make the MongoStore struct hear and then call schema stuff here
 	mongoStore := &mongoStore{
-		db: store,
+		db:      store,
+		timeout: c.timeout,
+		res:     c.res,
+		source:  c.source,
 	}
 
 	// TODO(prabhjot) we will look forward to enabling references as part of a separate effort
@@ -585,5 +966,38 @@ func (c *mongoClient) GetCollection(dbName, col string) StoreCollection {
 }
 
 func (c *mongoClient) HealthCheck(ctx context.Context) error {
+	ctx, cancel := withOperationTimeout(ctx, c.timeout)
+	defer cancel()
 	return c.client.Ping(ctx, nil)
 }
+
+// Disconnect stops all the watches started on the collections derived
+// from the client, waiting for them to complete, and closes the
+// connection pool, the client must not be used afterwards
+func (c *mongoClient) Disconnect(ctx context.Context) error {
+	err := c.res.close(ctx)
+	if derr := c.client.Disconnect(ctx); derr != nil && err == nil {
+		err = derr
+	}
+	return err
+}
+
+// WithTransaction runs the function in a mongo transaction, where the
+// session is carried by the context passed to the function, making all
+// the collection operations using it transactional. Nested calls are
+// part of the outer transaction.
+// requires mongodb to be running as a replica set
+func (c *mongoClient) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	session, err := c.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+	return interpretMongoError(err)
+}
@@ -0,0 +1,162 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// document in the files collection of the GridFS bucket
+type gridFSFile struct {
+	ID         bson.ObjectID     `bson:"_id"`
+	Name       string            `bson:"filename"`
+	Length     int64             `bson:"length"`
+	UploadDate time.Time         `bson:"uploadDate"`
+	Metadata   map[string]string `bson:"metadata,omitempty"`
+}
+
+// gridFSBlobStore implements BlobStore over a GridFS bucket, where every
+// Put uploads a new revision of the file and removes the older ones
+type gridFSBlobStore struct {
+	bucket  *mongo.GridFSBucket
+	timeout time.Duration // default operation timeout, except streaming
+}
+
+// GetBlobStore returns the blob store backed by the GridFS bucket with
+// the given name
+func (s *mongoStore) GetBlobStore(bucket string, opts ...BlobStoreOption) (BlobStore, error) {
+	bopts, err := getBlobStoreOptions(bucket, opts)
+	if err != nil {
+		return nil, err
+	}
+	bucketOpts := options.GridFSBucket().SetName(bucket)
+	if bopts.ChunkSize != 0 {
+		bucketOpts.SetChunkSizeBytes(bopts.ChunkSize)
+	}
+	return bopts.wrap(&gridFSBlobStore{
+		bucket:  s.db.GridFSBucket(bucketOpts),
+		timeout: s.timeout,
+	}), nil
+}
+
+// returns the revisions of the file with the given name, latest first
+func (s *gridFSBlobStore) revisions(ctx context.Context, name string) ([]gridFSFile, error) {
+	cursor, err := s.bucket.Find(ctx, bson.D{{Key: "filename", Value: name}},
+		options.GridFSFind().SetSort(bson.D{{Key: "uploadDate", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	files := []gridFSFile{}
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Put uploads the content as a new revision of the file, removing the
+// older revisions once the upload completes, the context governs the
+// upload and the default operation timeout doesn't apply
+func (s *gridFSBlobStore) Put(ctx context.Context, name string, r io.Reader, metadata map[string]string) (int64, error) {
+	if name == "" {
+		return 0, errors.Wrap(errors.InvalidArgument, "blob name is required")
+	}
+	if r == nil {
+		return 0, errors.Wrap(errors.InvalidArgument, "blob content reader is required")
+	}
+	uploadOpts := options.GridFSUpload()
+	if len(metadata) != 0 {
+		uploadOpts.SetMetadata(metadata)
+	}
+	counter := &countingReader{r: r}
+	id, err := s.bucket.UploadFromStream(ctx, name, counter, uploadOpts)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := withOperationTimeout(ctx, s.timeout)
+	defer cancel()
+	files, err := s.revisions(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range files {
+		if f.ID == id {
+			continue
+		}
+		if err := s.bucket.Delete(ctx, f.ID); err != nil && err != mongo.ErrFileNotFound {
+			return 0, err
+		}
+	}
+	return counter.n, nil
+}
+
+// Get opens the download stream of the latest revision of the file
+func (s *gridFSBlobStore) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	stream, err := s.bucket.OpenDownloadStreamByName(ctx, name)
+	if err != nil {
+		if err == mongo.ErrFileNotFound {
+			return nil, errors.Wrapf(errors.NotFound, "blob %s not found", name)
+		}
+		return nil, err
+	}
+	return stream, nil
+}
+
+// Stat returns the information of the latest revision of the file
+func (s *gridFSBlobStore) Stat(ctx context.Context, name string) (*BlobInfo, error) {
+	ctx, cancel := withOperationTimeout(ctx, s.timeout)
+	defer cancel()
+	files, err := s.revisions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.Wrapf(errors.NotFound, "blob %s not found", name)
+	}
+	return &BlobInfo{
+		Name:       files[0].Name,
+		Size:       files[0].Length,
+		UploadedAt: files[0].UploadDate,
+		Metadata:   files[0].Metadata,
+	}, nil
+}
+
+// Delete removes all the revisions of the file
+func (s *gridFSBlobStore) Delete(ctx context.Context, name string) error {
+	ctx, cancel := withOperationTimeout(ctx, s.timeout)
+	defer cancel()
+	files, err := s.revisions(ctx, name)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.Wrapf(errors.NotFound, "blob %s not found", name)
+	}
+	for _, f := range files {
+		if err := s.bucket.Delete(ctx, f.ID); err != nil && err != mongo.ErrFileNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// countingReader counts the bytes read from the underlying reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,216 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/go-core-stack/core/certmanager"
+	"github.com/go-core-stack/core/errors"
+	"github.com/go-core-stack/core/utils"
+)
+
+func Test_MongoConfig(t *testing.T) {
+	t.Run("validate", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			conf  MongoConfig
+			valid bool
+		}{
+			{"defaults", MongoConfig{}, true},
+			{"uri_with_host", MongoConfig{Uri: "mongodb://localhost", Host: "localhost"}, false},
+			{"invalid_port", MongoConfig{Port: "abc"}, false},
+			{"negative_timeout", MongoConfig{ConnectTimeout: -time.Second}, false},
+			{"negative_operation_timeout", MongoConfig{OperationTimeout: -time.Second}, false},
+			{"min_pool_exceeds_max", MongoConfig{MinPoolSize: 10, MaxPoolSize: 5}, false},
+			{"min_pool_without_max", MongoConfig{MinPoolSize: 10}, true},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := tt.conf.validate()
+				if tt.valid && err != nil {
+					t.Errorf("expected valid config, got %s", err)
+				}
+				if !tt.valid && !errors.IsInvalidArgument(err) {
+					t.Errorf("expected invalid argument, got %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("operation_timeout", func(t *testing.T) {
+		ctx, cancel := withOperationTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+			t.Errorf("expected deadline to be injected, got %v", deadline)
+		}
+
+		parent, cancelParent := context.WithTimeout(context.Background(), time.Hour)
+		defer cancelParent()
+		ctx, cancel = withOperationTimeout(parent, time.Minute)
+		defer cancel()
+		if deadline, _ := ctx.Deadline(); time.Until(deadline) < 59*time.Minute {
+			t.Errorf("expected deadline of the caller to be retained, got %v", deadline)
+		}
+
+		ctx, cancel = withOperationTimeout(context.Background(), 0)
+		defer cancel()
+		if _, ok := ctx.Deadline(); ok {
+			t.Errorf("expected no deadline without default timeout")
+		}
+	})
+
+	t.Run("client_options", func(t *testing.T) {
+		conf := &MongoConfig{
+			Uri:                    "mongodb://localhost:27017/?maxPoolSize=20&replicaSet=uri",
+			TLS:                    &tls.Config{ServerName: "db.example.com"},
+			ConnectTimeout:         5 * time.Second,
+			ServerSelectionTimeout: 10 * time.Second,
+			MinPoolSize:            2,
+			MaxPoolSize:            50,
+			MaxConnIdleTime:        time.Minute,
+			ReplicaSet:             "rs0",
+			RetryWrites:            utils.Pointer(false),
+		}
+		if err := conf.validate(); err != nil {
+			t.Fatalf("failed to validate config: %s", err)
+		}
+		opts, err := conf.clientOptions()
+		if err != nil {
+			t.Fatalf("failed to build client options: %s", err)
+		}
+		if opts.TLSConfig == nil || opts.TLSConfig.ServerName != "db.example.com" {
+			t.Errorf("tls config not applied, got %v", opts.TLSConfig)
+		}
+		if *opts.ConnectTimeout != 5*time.Second || *opts.ServerSelectionTimeout != 10*time.Second {
+			t.Errorf("timeouts not applied, got %v, %v", *opts.ConnectTimeout, *opts.ServerSelectionTimeout)
+		}
+		if *opts.MinPoolSize != 2 || *opts.MaxPoolSize != 50 || *opts.MaxConnIdleTime != time.Minute {
+			t.Errorf("pool settings not applied, got %d, %d, %v", *opts.MinPoolSize, *opts.MaxPoolSize, *opts.MaxConnIdleTime)
+		}
+		if *opts.ReplicaSet != "rs0" {
+			t.Errorf("expected replica set rs0, got %s", *opts.ReplicaSet)
+		}
+		if *opts.RetryWrites || opts.RetryReads != nil {
+			t.Errorf("unexpected retry settings %v, %v", opts.RetryWrites, opts.RetryReads)
+		}
+
+		opts, _ = (&MongoConfig{Uri: "mongodb://localhost:27017/?maxPoolSize=20"}).clientOptions()
+		if opts.TLSConfig != nil || *opts.MaxPoolSize != 20 {
+			t.Errorf("expected uri settings to be retained, got %v, %d", opts.TLSConfig, *opts.MaxPoolSize)
+		}
+
+		// connections are attributed to the source identifier
+		source := GetEffectiveSourceIdentifier()
+		if opts.AppName == nil || *opts.AppName != source {
+			t.Errorf("expected app name %s, got %v", source, opts.AppName)
+		}
+		if opts.DriverInfo == nil || opts.DriverInfo.Name != driverInfoName || opts.DriverInfo.Platform != source {
+			t.Errorf("unexpected driver info %+v", opts.DriverInfo)
+		}
+		opts, _ = (&MongoConfig{Uri: "mongodb://localhost:27017/?appName=custom"}).clientOptions()
+		if _, explicit := useSourceIdentifier(); !explicit && *opts.AppName != "custom" {
+			t.Errorf("expected app name from uri to be retained, got %s", *opts.AppName)
+		}
+	})
+	t.Run("x509", func(t *testing.T) {
+		ca := newTestAuthority(t)
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		signed, err := ca.SignWithPrivateKey(key, time.Now().Add(time.Hour), certmanager.Claims{
+			Subject:     pkix.Name{CommonName: "static-client"},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		if err != nil {
+			t.Fatalf("failed to sign client certificate: %s", err)
+		}
+		keyDER, _ := x509.MarshalPKCS8PrivateKey(key)
+		keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+		caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.RootCertificate().Raw})
+
+		conf := &MongoConfig{Username: "root", X509: &MongoX509Config{CertPEM: signed.PEM, KeyPEM: keyPEM}}
+		if err := conf.validate(); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument with username, got %v", err)
+		}
+		conf = &MongoConfig{X509: &MongoX509Config{CertPEM: signed.PEM}}
+		if err := conf.validate(); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without key, got %v", err)
+		}
+
+		conf = &MongoConfig{X509: &MongoX509Config{CertPEM: signed.PEM, KeyPEM: keyPEM, CAPEM: caPEM}}
+		if err := conf.validate(); err != nil {
+			t.Fatalf("failed to validate config: %s", err)
+		}
+		opts, err := conf.clientOptions()
+		if err != nil {
+			t.Fatalf("failed to build client options: %s", err)
+		}
+		if opts.Auth.AuthMechanism != "MONGODB-X509" || opts.Auth.AuthSource != "$external" {
+			t.Errorf("unexpected credential %v", opts.Auth)
+		}
+		if len(opts.TLSConfig.Certificates) != 1 || opts.TLSConfig.RootCAs == nil {
+			t.Errorf("client certificate or CA bundle not applied")
+		}
+
+		conf = &MongoConfig{X509: &MongoX509Config{
+			Provider: ca,
+			Claims:   certmanager.Claims{Subject: pkix.Name{CommonName: "issued-client"}},
+			Validity: 30 * time.Minute,
+		}}
+		if err := conf.validate(); err != nil {
+			t.Fatalf("failed to validate config: %s", err)
+		}
+		opts, err = conf.clientOptions()
+		if err != nil {
+			t.Fatalf("failed to build client options: %s", err)
+		}
+		cert, err := opts.TLSConfig.GetClientCertificate(&tls.CertificateRequestInfo{})
+		if err != nil {
+			t.Fatalf("failed to issue client certificate: %s", err)
+		}
+		details, err := ca.ValidateCertificate(cert.Leaf, time.Now())
+		if err != nil || details.Claims.Subject.CommonName != "issued-client" {
+			t.Errorf("unexpected issued certificate %v, err %v", details, err)
+		}
+		again, _ := opts.TLSConfig.GetClientCertificate(&tls.CertificateRequestInfo{})
+		if again != cert {
+			t.Errorf("expected issued certificate to be reused before renewal")
+		}
+	})
+}
+
+func newTestAuthority(t *testing.T) certmanager.Provider {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create root certificate: %s", err)
+	}
+	root, _ := x509.ParseCertificate(der)
+	ca, err := certmanager.NewCertificateAuthority(root, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate authority: %s", err)
+	}
+	return ca
+}
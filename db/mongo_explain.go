@@ -0,0 +1,99 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// builds the find command for the filter and the find options
+func mongoFindCommand(colName string, filter any, opts []any) (bson.D, error) {
+	if filter == nil {
+		filter = bson.D{}
+	}
+	opts, _, err := splitConsistency(opts)
+	if err != nil {
+		return nil, err
+	}
+	fo := &options.FindOptions{}
+	for _, opt := range opts {
+		val, ok := opt.(options.Lister[options.FindOptions])
+		if !ok {
+			return nil, errors.Wrapf(errors.InvalidArgument, "Invalid option type %T passed to explain find", opt)
+		}
+		for _, set := range val.List() {
+			if err := set(fo); err != nil {
+				return nil, errors.Wrapf(errors.InvalidArgument, "invalid find option: %s", err)
+			}
+		}
+	}
+	cmd := bson.D{{Key: "find", Value: colName}, {Key: "filter", Value: filter}}
+	if fo.Sort != nil {
+		cmd = append(cmd, bson.E{Key: "sort", Value: fo.Sort})
+	}
+	if fo.Projection != nil {
+		cmd = append(cmd, bson.E{Key: "projection", Value: fo.Projection})
+	}
+	if fo.Hint != nil {
+		cmd = append(cmd, bson.E{Key: "hint", Value: fo.Hint})
+	}
+	if fo.Skip != nil {
+		cmd = append(cmd, bson.E{Key: "skip", Value: *fo.Skip})
+	}
+	if fo.Limit != nil {
+		cmd = append(cmd, bson.E{Key: "limit", Value: *fo.Limit})
+	}
+	return cmd, nil
+}
+
+// builds the aggregate command for the pipeline, options other than the
+// consistency overrides don't affect the plan and are ignored
+func mongoAggregateCommand(colName string, pipeline any, opts []any) (bson.D, error) {
+	if _, ok := pipeline.(mongo.Pipeline); !ok {
+		return nil, errors.Wrapf(errors.InvalidArgument, "invalid aggregate pipeline type specified, %T", pipeline)
+	}
+	if _, _, err := splitConsistency(opts); err != nil {
+		return nil, err
+	}
+	return bson.D{
+		{Key: "aggregate", Value: colName},
+		{Key: "pipeline", Value: pipeline},
+		{Key: "cursor", Value: bson.D{}},
+	}, nil
+}
+
+// Explain reports the plan chosen by the server for the find or aggregate
+// query, using the queryPlanner verbosity which doesn't run the query
+func (c *mongoCollection) Explain(ctx context.Context, op string, query any, opts ...any) (bson.Raw, error) {
+	ctx, cancel := withOperationTimeout(ctx, c.parent.timeout)
+	defer cancel()
+	var cmd bson.D
+	var err error
+	switch op {
+	case "find":
+		cmd, err = mongoFindCommand(c.colName, query, opts)
+	case "aggregate":
+		cmd, err = mongoAggregateCommand(c.colName, query, opts)
+	default:
+		return nil, errors.Wrapf(errors.InvalidArgument, "explain is not supported for operation %q", op)
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, err := c.parent.db.RunCommand(ctx, bson.D{
+		{Key: "explain", Value: cmd},
+		{Key: "verbosity", Value: "queryPlanner"},
+		{Key: "comment", Value: c.parent.source},
+	}).Raw()
+	if err != nil {
+		return nil, interpretMongoError(err)
+	}
+	return raw, nil
+}
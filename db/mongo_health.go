@@ -0,0 +1,103 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// server error code, when the server is not running as a replica set
+const mongoNoReplicationEnabled = 76
+
+// status of the replica set as reported by replSetGetStatus
+type mongoReplSetStatus struct {
+	Set     string                  `bson:"set"`
+	Members []mongoReplMemberStatus `bson:"members"`
+}
+
+type mongoReplMemberStatus struct {
+	Name       string    `bson:"name"`
+	Health     float64   `bson:"health"`
+	StateStr   string    `bson:"stateStr"`
+	OptimeDate time.Time `bson:"optimeDate"`
+	PingMs     int64     `bson:"pingMs"`
+}
+
+// builds the health report from the replica set status, where the store
+// is down without a primary and degraded if any of the members is
+// unreachable or lagging behind the primary beyond maxLag
+func mongoHealthReport(status *mongoReplSetStatus, latency, maxLag time.Duration) *HealthReport {
+	report := &HealthReport{
+		Status:     HealthStatusHealthy,
+		Latency:    latency,
+		ReplicaSet: status.Set,
+	}
+	var primaryOptime time.Time
+	for _, m := range status.Members {
+		if m.StateStr == "PRIMARY" {
+			report.Primary = m.Name
+			primaryOptime = m.OptimeDate
+		}
+	}
+	for _, m := range status.Members {
+		member := MemberHealth{
+			Name:    m.Name,
+			State:   m.StateStr,
+			Healthy: m.Health == 1,
+			Latency: time.Duration(m.PingMs) * time.Millisecond,
+		}
+		if report.Primary != "" && member.Healthy && m.StateStr != "ARBITER" && !m.OptimeDate.IsZero() {
+			member.Lag = max(primaryOptime.Sub(m.OptimeDate), 0)
+		}
+		if report.Status == HealthStatusHealthy {
+			if !member.Healthy {
+				report.Status = HealthStatusDegraded
+				report.Reason = fmt.Sprintf("member %s is unreachable", m.Name)
+			} else if member.Lag > maxLag {
+				report.Status = HealthStatusDegraded
+				report.Reason = fmt.Sprintf("member %s is lagging by %s", m.Name, member.Lag)
+			}
+		}
+		report.Members = append(report.Members, member)
+	}
+	if report.Primary == "" {
+		report.Status = HealthStatusDown
+		report.Reason = "no primary available"
+	}
+	return report
+}
+
+// HealthReport returns the detailed health of the deployment, including
+// the state and replication lag of every replica set member
+func (c *mongoClient) HealthReport(ctx context.Context) (*HealthReport, error) {
+	ctx, cancel := withOperationTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	if err := c.client.Ping(ctx, nil); err != nil {
+		return &HealthReport{Status: HealthStatusDown, Reason: err.Error()}, err
+	}
+	latency := time.Since(start)
+
+	status := &mongoReplSetStatus{}
+	err := c.client.Database("admin").RunCommand(ctx, bson.D{{Key: "replSetGetStatus", Value: 1}}).Decode(status)
+	if err != nil {
+		if serr, ok := err.(mongo.ServerError); ok && serr.HasErrorCode(mongoNoReplicationEnabled) {
+			// standalone server is healthy as long as it is reachable
+			return &HealthReport{Status: HealthStatusHealthy, Latency: latency}, nil
+		}
+		// the status requires clusterMonitor privileges, report what is
+		// known from the ping
+		return &HealthReport{
+			Status:  HealthStatusHealthy,
+			Latency: latency,
+			Reason:  fmt.Sprintf("replica set status unavailable: %s", err),
+		}, nil
+	}
+	return mongoHealthReport(status, latency, c.maxLag), nil
+}
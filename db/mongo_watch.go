@@ -0,0 +1,132 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// server error codes, where the change stream can't be resumed from
+	// the resume token, as the history is no longer available
+	mongoChangeStreamFatalError   = 280
+	mongoChangeStreamHistoryLost  = 286
+	mongoChangeStreamInvalidToken = 260
+)
+
+// returns true if the change stream can't be resumed from the token
+func isChangeStreamHistoryLost(err error) bool {
+	var serr mongo.ServerError
+	if !errors.As(err, &serr) {
+		return false
+	}
+	return serr.HasErrorCode(mongoChangeStreamHistoryLost) ||
+		serr.HasErrorCode(mongoChangeStreamFatalError) ||
+		serr.HasErrorCode(mongoChangeStreamInvalidToken)
+}
+
+// runs the change stream on the collection delivering the events until
+// the context is canceled. The stream is re-established on failures
+// resuming after the last event delivered, or from the current time with
+// a resync signal if the history is no longer available.
+// newOpts provides the change stream options, where initial is false
+// while re-establishing the stream.
+func (c *mongoCollection) runChangeStream(ctx context.Context, pipeline any, newOpts func(initial bool) *options.ChangeStreamOptionsBuilder, wopts *WatchOptions, deliver func(event bson.D)) error {
+	// stream is also stopped when the client disconnects
+	ctx, release, err := c.parent.res.derive(ctx)
+	if err != nil {
+		return err
+	}
+
+	// start watching on the collection with passed context
+	stream, err := c.col.Watch(ctx, pipeline, newOpts(true))
+	if err != nil {
+		release()
+		return err
+	}
+
+	// run the loop on stream in a separate go routine
+	// allowing the watch starter to resume control and work with
+	// managing Watch stream by virtue of passed context
+	c.parent.res.goroutine(func() {
+		defer release()
+		var token bson.Raw
+		invalidated := false
+		resync := false
+		failures := 0
+		for {
+			if stream != nil {
+				for stream.Next(ctx) {
+					failures = 0
+					token = stream.ResumeToken()
+					event := bson.D{}
+					if err := bson.Unmarshal(stream.Current, &event); err != nil {
+						log.Printf("Skipping watch event due to decoding error %s", err)
+						continue
+					}
+					if op, _ := lookupField(event, "operationType"); op == "invalidate" {
+						// collection dropped or renamed, stream will be
+						// closed by the server
+						invalidated = true
+						continue
+					}
+					invalidated = false
+					deliver(event)
+				}
+				err = stream.Err()
+				// ignore the error returned by stream close as of now
+				_ = stream.Close(context.Background())
+				stream = nil
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil {
+				err = errors.Wrapf(errors.Unknown, "change stream closed by server")
+			}
+			if isChangeStreamHistoryLost(err) {
+				token = nil
+				resync = true
+			}
+			failures++
+			if failures > wopts.MaxRetries {
+				wopts.fail(c.colName, err)
+				return
+			}
+			log.Printf("watch on %s interrupted, attempting to re-establish: %s", c.colName, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(watchBackoff(failures)):
+			}
+
+			opts := newOpts(token == nil && !resync)
+			if token != nil {
+				if invalidated {
+					opts.SetStartAfter(token)
+				} else {
+					opts.SetResumeAfter(token)
+				}
+			}
+			stream, err = c.col.Watch(ctx, pipeline, opts)
+			if err != nil {
+				continue
+			}
+			if resync {
+				resync = false
+				wopts.resync(c.colName)
+			}
+		}
+	})
+
+	return nil
+}
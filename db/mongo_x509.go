@@ -0,0 +1,155 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/certmanager"
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// authentication mechanism and source for the certificate based
+	// authentication
+	mongoX509AuthMechanism = "MONGODB-X509"
+	mongoX509AuthSource    = "$external"
+
+	// default validity of the client certificate issued by the provider
+	defaultMongoX509Validity = 24 * time.Hour
+)
+
+// MongoX509Config provides the configuration for authenticating to the
+// database using the client certificate (MONGODB-X509) instead of the
+// username and password
+type MongoX509Config struct {
+	// PEM encoded client certificate and private key, required unless
+	// the certificate is issued by the Provider
+	CertPEM []byte
+	KeyPEM  []byte
+
+	// PEM encoded bundle of the CA certificates to validate the server
+	// certificate with, if not provided the root certificate of the
+	// Provider is used when configured, otherwise the system pool
+	CAPEM []byte
+
+	// Provider issues the client certificate on demand, re-issuing it
+	// once half of its validity has passed, so that the new connections
+	// always present a valid certificate
+	Provider certmanager.Provider
+
+	// Claims for the client certificate issued by the Provider, the
+	// subject of the certificate identifies the database user
+	Claims certmanager.Claims
+
+	// Validity of the client certificate issued by the Provider
+	// Default: 24 hours
+	Validity time.Duration
+}
+
+func (c *MongoX509Config) validate() error {
+	if c.Provider == nil {
+		if len(c.CertPEM) == 0 || len(c.KeyPEM) == 0 {
+			return errors.Wrap(errors.InvalidArgument, "client certificate and key are required for x509 authentication")
+		}
+	} else if len(c.CertPEM) != 0 || len(c.KeyPEM) != 0 {
+		return errors.Wrap(errors.InvalidArgument, "cannot provide client certificate if provider is configured")
+	}
+	if c.Validity < 0 {
+		return errors.Wrap(errors.InvalidArgument, "client certificate validity must not be negative")
+	}
+	if c.Validity == 0 {
+		c.Validity = defaultMongoX509Validity
+	}
+	return nil
+}
+
+// applies the certificate based authentication to the client options,
+// base is the TLS configuration to extend if any
+func (c *MongoX509Config) apply(clientOptions *options.ClientOptions, base *tls.Config) error {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if base != nil {
+		tlsConfig = base.Clone()
+	}
+
+	if len(c.CAPEM) != 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(c.CAPEM) {
+			return errors.Wrap(errors.InvalidArgument, "failed to parse CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+	} else if c.Provider != nil && tlsConfig.RootCAs == nil {
+		pool := x509.NewCertPool()
+		pool.AddCert(c.Provider.RootCertificate())
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.Provider != nil {
+		issuer := &mongoCertIssuer{conf: c}
+		tlsConfig.GetClientCertificate = issuer.getClientCertificate
+	} else {
+		cert, err := tls.X509KeyPair(c.CertPEM, c.KeyPEM)
+		if err != nil {
+			return errors.Wrapf(errors.InvalidArgument, "failed to load client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	clientOptions.SetTLSConfig(tlsConfig)
+	// username is derived by the server from the subject of the client
+	// certificate
+	clientOptions.SetAuth(options.Credential{
+		AuthMechanism: mongoX509AuthMechanism,
+		AuthSource:    mongoX509AuthSource,
+	})
+	return nil
+}
+
+// mongoCertIssuer issues the client certificate using the provider,
+// caching it until half of its validity has passed
+type mongoCertIssuer struct {
+	mu      sync.Mutex
+	conf    *MongoX509Config
+	cert    *tls.Certificate
+	renewAt time.Time
+}
+
+func (i *mongoCertIssuer) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	now := time.Now()
+	if i.cert != nil && now.Before(i.renewAt) {
+		return i.cert, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.Wrapf(errors.Unknown, "failed to generate client key: %s", err)
+	}
+	claims := i.conf.Claims
+	if len(claims.ExtKeyUsage) == 0 {
+		claims.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	signed, err := i.conf.Provider.SignWithPrivateKey(key, now.Add(i.conf.Validity), claims)
+	if err != nil {
+		return nil, err
+	}
+
+	i.cert = &tls.Certificate{
+		Certificate: [][]byte{signed.Certificate.Raw},
+		PrivateKey:  key,
+		Leaf:        signed.Certificate,
+	}
+	leaf := signed.Certificate
+	i.renewAt = leaf.NotBefore.Add(leaf.NotAfter.Sub(leaf.NotBefore) / 2)
+	return i.cert, nil
+}
@@ -0,0 +1,1276 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// name of the table holding the change log in every schema
+	postgresChangesTable = "_changes"
+
+	// name of the table holding the index definitions in every schema
+	postgresIndexesTable = "_indexes"
+
+	// channel used for notifying the changes using pg_notify, where the
+	// payload carries the schema and table name
+	postgresNotifyChannel = "core_store_changes"
+
+	// default interval for polling the change log for Watch
+	defaultPostgresWatchInterval = time.Second
+
+	// default retention of the entries in the change log
+	defaultPostgresChangeRetention = 24 * time.Hour
+
+	// maximum number of change log entries fetched per poll
+	postgresChangeBatchSize = 1000
+
+	// minimum interval between pruning of the change log
+	postgresPruneInterval = time.Minute
+
+	// number of attempts for an upsert racing with concurrent inserts
+	postgresUpsertAttempts = 3
+)
+
+// PostgresListener subscribes to the notifications on the channel using
+// the LISTEN support of the driver, invoking notify for every notification
+// received until the context is canceled
+type PostgresListener func(ctx context.Context, channel string, notify func()) error
+
+// PostgresConfig provides the configuration for the postgres client
+type PostgresConfig struct {
+	// DriverName of the database/sql driver registered by the application
+	// for postgres, eg. "pgx" or "postgres"
+	DriverName string
+
+	// Uri is the connection string passed to the driver
+	Uri string
+
+	// WatchInterval is the interval for polling the change log for Watch,
+	// defaults to one second
+	WatchInterval time.Duration
+
+	// ChangeRetention is the duration for which the change log entries
+	// are kept, defaults to 24 hours
+	ChangeRetention time.Duration
+
+	// Listener is optional, allowing Watch to be notified of the changes
+	// using LISTEN instead of waiting for the next poll interval
+	Listener PostgresListener
+}
+
+func (c *PostgresConfig) validate() error {
+	if c.DriverName == "" {
+		return errors.Wrap(errors.InvalidArgument, "postgres driver name is not specified")
+	}
+	if c.Uri == "" {
+		return errors.Wrap(errors.InvalidArgument, "postgres connection uri is not specified")
+	}
+	if c.WatchInterval <= 0 {
+		c.WatchInterval = defaultPostgresWatchInterval
+	}
+	if c.ChangeRetention <= 0 {
+		c.ChangeRetention = defaultPostgresChangeRetention
+	}
+	return nil
+}
+
+// quotes the identifier for use in the sql statements
+func postgresIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quotes the string literal for use in the sql statements, used only for
+// the statements not supporting parameters, like create index
+func postgresLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// returns the expression extracting the field from the document, where
+// the nested fields are separated by "."
+func postgresFieldExpr(field string) string {
+	if field == "_id" {
+		return "id"
+	}
+	parts := strings.Split(field, ".")
+	for i, p := range parts {
+		p = strings.ReplaceAll(p, `\`, `\\`)
+		parts[i] = `"` + strings.ReplaceAll(p, `"`, `\"`) + `"`
+	}
+	return "(doc #> " + postgresLiteral("{"+strings.Join(parts, ",")+"}") + ")"
+}
+
+// interprets the errors returned by the driver, mapping them to the
+// errors expected by the callers of the store interfaces
+func interpretPostgresError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if err == sql.ErrNoRows {
+		return errors.Wrap(errors.NotFound, mongo.ErrNoDocuments.Error())
+	}
+	// driver specific error types are not known here, so rely on the
+	// sql state for unique violation carried in the error message
+	msg := err.Error()
+	if strings.Contains(msg, "23505") || strings.Contains(msg, "duplicate key value") {
+		return errors.Wrap(errors.AlreadyExists, msg)
+	}
+	return err
+}
+
+// returns the serialized `_id` stored as key of the row, canonical
+// extended JSON ensures the same serialization for the same key
+func postgresDocID(key any) (string, any, error) {
+	id, err := toValue(key)
+	if err != nil {
+		return "", nil, err
+	}
+	raw, err := bson.MarshalExtJSON(bson.D{{Key: "_id", Value: id}}, true, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(raw), id, nil
+}
+
+// serializes the document as canonical extended JSON, preserving the
+// types of the values while stored as JSONB
+func postgresEncode(doc bson.D) (any, error) {
+	if doc == nil {
+		return nil, nil
+	}
+	raw, err := bson.MarshalExtJSON(doc, true, false)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+// decodes the row into the document with `_id` as the first field, since
+// JSONB doesn't preserve the order of the fields, `_id` is restored from
+// the serialized key
+func postgresDecode(id, doc []byte) (bson.D, error) {
+	key := bson.D{}
+	if err := bson.UnmarshalExtJSON(id, true, &key); err != nil {
+		return nil, err
+	}
+	d := bson.D{}
+	if err := bson.UnmarshalExtJSON(doc, true, &d); err != nil {
+		return nil, err
+	}
+	return append(key, unsetField(d, []string{"_id"})...), nil
+}
+
+// postgresQuerier runs the queries either on the database or as part of
+// a transaction
+type postgresQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// context key for the transaction started by WithTransaction
+type postgresTxKey struct{}
+
+// transaction started by WithTransaction of the client
+type postgresTx struct {
+	client *postgresClient
+	tx     *sql.Tx
+}
+
+// postgresStore implements Store over a postgres schema, where every
+// collection is a table in the schema
+type postgresStore struct {
+	client *postgresClient
+	name   string
+	mu     sync.Mutex
+	tables map[string]bool // tables known to exist
+}
+
+// postgresCollection implements StoreCollection over a postgres table,
+// storing the documents as JSONB keyed by the serialized `_id`.
+// Filters are evaluated by the client similar to the memory collection,
+// over the candidate rows selected by the translated filter, while the
+// change events are recorded in the change log of the schema
+// as part of the same transaction, allowing Watch across the processes.
+type postgresCollection struct {
+	store   *postgresStore
+	colName string
+	keyType reflect.Type
+}
+
+func (c *postgresCollection) db() *sql.DB {
+	return c.store.client.db
+}
+
+func (c *postgresCollection) table() string {
+	return postgresIdent(c.store.name) + "." + postgresIdent(c.colName)
+}
+
+func (c *postgresCollection) changes() string {
+	return postgresIdent(c.store.name) + "." + postgresIdent(postgresChangesTable)
+}
+
+// ensures the schema, change log and the table for the collection exist
+func (c *postgresCollection) ensureTable(ctx context.Context) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	if c.store.tables[c.colName] {
+		return nil
+	}
+	stmts := []string{
+		"CREATE SCHEMA IF NOT EXISTS " + postgresIdent(c.store.name),
+		"CREATE TABLE IF NOT EXISTS " + c.changes() + " (" +
+			"seq BIGSERIAL PRIMARY KEY, " +
+			"coll TEXT NOT NULL, " +
+			"op TEXT NOT NULL, " +
+			"doc_id TEXT NOT NULL, " +
+			"full_doc JSONB, " +
+			"updated JSONB, " +
+			"ts TIMESTAMPTZ NOT NULL DEFAULT now(), " +
+			"xid XID8 NOT NULL DEFAULT pg_current_xact_id())",
+		"CREATE INDEX IF NOT EXISTS " + postgresIdent(postgresChangesTable+"_coll_seq") +
+			" ON " + c.changes() + " (coll, seq)",
+		"CREATE INDEX IF NOT EXISTS " + postgresIdent(postgresChangesTable+"_coll_xid") +
+			" ON " + c.changes() + " (coll, xid)",
+		"CREATE TABLE IF NOT EXISTS " + c.indexes() + " (" +
+			"coll TEXT NOT NULL, " +
+			"name TEXT NOT NULL, " +
+			"spec JSONB NOT NULL, " +
+			"PRIMARY KEY (coll, name))",
+		"CREATE TABLE IF NOT EXISTS " + c.table() + " (" +
+			"id TEXT PRIMARY KEY, " +
+			"doc JSONB NOT NULL, " +
+			"seq BIGSERIAL)",
+	}
+	for _, stmt := range stmts {
+		if _, err := c.db().ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	c.store.tables[c.colName] = true
+	return nil
+}
+
+// returns the transaction started by WithTransaction of the client, nil
+// if the context is not part of a transaction
+func (c *postgresCollection) txFromContext(ctx context.Context) *sql.Tx {
+	ptx, ok := ctx.Value(postgresTxKey{}).(*postgresTx)
+	if !ok || ptx.client != c.store.client {
+		return nil
+	}
+	return ptx.tx
+}
+
+// returns the transaction if the context is part of one, otherwise the
+// database for running the queries
+func (c *postgresCollection) querier(ctx context.Context) postgresQuerier {
+	if tx := c.txFromContext(ctx); tx != nil {
+		return tx
+	}
+	return c.db()
+}
+
+// runs the function in a transaction, committing it if the function
+// succeeds, while working with the transaction started by WithTransaction
+// if the context is part of one
+func (c *postgresCollection) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if err := c.ensureTable(ctx); err != nil {
+		return err
+	}
+	if tx := c.txFromContext(ctx); tx != nil {
+		return interpretPostgresError(fn(tx))
+	}
+	tx, err := c.db().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return interpretPostgresError(err)
+	}
+	return interpretPostgresError(tx.Commit())
+}
+
+// records the change in the change log as part of the transaction
+func (c *postgresCollection) recordChange(ctx context.Context, tx *sql.Tx, op, docID string, doc bson.D, update bson.D) error {
+	full, err := postgresEncode(doc)
+	if err != nil {
+		return err
+	}
+	upd, err := postgresEncode(update)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO "+c.changes()+
+		" (coll, op, doc_id, full_doc, updated) VALUES ($1, $2, $3, $4::jsonb, $5::jsonb)",
+		c.colName, op, docID, full, upd)
+	if err != nil {
+		return err
+	}
+	// notification is delivered only on commit of the transaction
+	_, err = tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", postgresNotifyChannel, c.store.name+"."+c.colName)
+	return err
+}
+
+// queries the rows and decodes them into documents in natural order
+func (c *postgresCollection) queryDocs(ctx context.Context, q postgresQuerier, query string, args ...any) ([]bson.D, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	docs := []bson.D{}
+	for rows.Next() {
+		var id, doc []byte
+		if err := rows.Scan(&id, &doc); err != nil {
+			return nil, err
+		}
+		d, err := postgresDecode(id, doc)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
+
+// returns the documents matching the filter in natural order, locking
+// the matched rows if running as part of the transaction. The filter is
+// translated to select the candidate rows, which are then evaluated
+// against the complete filter
+func (c *postgresCollection) matchDocs(ctx context.Context, tx *sql.Tx, filter any) ([]bson.D, error) {
+	where, args, err := postgresWhere(filter)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT id, doc FROM " + c.table() + where + " ORDER BY seq"
+	var docs []bson.D
+	if tx != nil {
+		docs, err = c.queryDocs(ctx, tx, query+" FOR UPDATE", args...)
+	} else {
+		if err = c.ensureTable(ctx); err != nil {
+			return nil, err
+		}
+		docs, err = c.queryDocs(ctx, c.querier(ctx), query, args...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return filterDocuments(docs, filter)
+}
+
+// Set KeyType for the collection, this is not mandatory
+// while the key type will be used by the interface implementer
+// mainly for Watch Callback for providing decoded key, if not
+// set watch will be working with the default decoders of
+// interface implementer
+// only pointer key type is supported as of now
+// returns error if the key type is not a pointer
+func (c *postgresCollection) SetKeyType(keyType reflect.Type) error {
+	if keyType.Kind() != reflect.Ptr {
+		// return error, as only pointer key type is supported
+		return errors.Wrap(errors.InvalidArgument, "key type is not a pointer")
+	}
+	c.keyType = keyType
+	return nil
+}
+
+// inserts one entry with given key and data to the collection
+// returns errors if entry already exists
+func (c *postgresCollection) InsertOne(ctx context.Context, key any, data any, opts ...any) error {
+	if _, err := operationConsistency("InsertOne", opts); err != nil {
+		return err
+	}
+	if data == nil {
+		return errors.Wrap(errors.InvalidArgument, "db Insert error: No data to store")
+	}
+	if key == nil {
+		return errors.Wrap(errors.InvalidArgument, "db Insert error: No Key specified to store")
+	}
+	docID, id, err := postgresDocID(key)
+	if err != nil {
+		return err
+	}
+	doc, err := memoryNewDoc(id, data)
+	if err != nil {
+		return err
+	}
+	val, err := postgresEncode(doc)
+	if err != nil {
+		return err
+	}
+	return c.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "INSERT INTO "+c.table()+
+			" (id, doc) VALUES ($1, $2::jsonb) ON CONFLICT (id) DO NOTHING", docID, val)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return errors.Wrapf(errors.AlreadyExists, "duplicate key error, key %v", key)
+		}
+		return c.recordChange(ctx, tx, "insert", docID, doc, nil)
+	})
+}
+
+// applies the update to the document for the given key, returning the
+// document state before and after the update, before is nil if the
+// document is inserted as part of upsert
+func (c *postgresCollection) updateDoc(ctx context.Context, key any, update any, upsert bool, filter bson.D) (bson.D, bson.D, error) {
+	if key == nil {
+		return nil, nil, errors.Wrap(errors.InvalidArgument, "db Update error: No Key specified")
+	}
+	docID, id, err := postgresDocID(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	upd, err := toDocument(update)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var before, after bson.D
+	for attempt := 1; ; attempt++ {
+		// insert racing with the upsert is retried, as the update then
+		// needs to be applied to the inserted document
+		raced := false
+		err = c.withTx(ctx, func(tx *sql.Tx) error {
+			before, after = nil, nil
+			docs, err := c.queryDocs(ctx, tx, "SELECT id, doc FROM "+c.table()+" WHERE id = $1 FOR UPDATE", docID)
+			if err != nil {
+				return err
+			}
+			if len(docs) == 0 {
+				if !upsert {
+					return errors.Wrap(errors.NotFound, "No Document found")
+				}
+				doc, err := applyUpdate(bson.D{{Key: "_id", Value: id}}, upd, true)
+				if err != nil {
+					return err
+				}
+				val, err := postgresEncode(doc)
+				if err != nil {
+					return err
+				}
+				res, err := tx.ExecContext(ctx, "INSERT INTO "+c.table()+
+					" (id, doc) VALUES ($1, $2::jsonb) ON CONFLICT (id) DO NOTHING", docID, val)
+				if err != nil {
+					return err
+				}
+				if n, err := res.RowsAffected(); err != nil {
+					return err
+				} else if n == 0 {
+					raced = true
+					return errors.Wrapf(errors.AlreadyExists, "duplicate key error, key %v", key)
+				}
+				after = doc
+				return c.recordChange(ctx, tx, "insert", docID, doc, nil)
+			}
+			if match, err := satisfiesCondition(docs[0], filter); err != nil {
+				return err
+			} else if !match {
+				return errors.Wrap(errors.NotFound, "No Document found")
+			}
+			before = docs[0]
+			doc, err := applyUpdate(before, upd, false)
+			if err != nil {
+				return err
+			}
+			val, err := postgresEncode(doc)
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, "UPDATE "+c.table()+" SET doc = $2::jsonb WHERE id = $1", docID, val)
+			if err != nil {
+				return err
+			}
+			after = doc
+			return c.recordChange(ctx, tx, "update", docID, doc, updateDescription(before, doc))
+		})
+		if !raced || attempt >= postgresUpsertAttempts {
+			break
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+// inserts or updates one entry with given key and data to the collection
+// acts based on the flag passed for upsert
+// returns errors if entry not found while upsert flag is false
+func (c *postgresCollection) UpdateOne(ctx context.Context, key any, data any, upsert bool, opts ...any) error {
+	if _, err := operationConsistency("UpdateOne", opts); err != nil {
+		return err
+	}
+	if data == nil {
+		return errors.Wrap(errors.InvalidArgument, "db Insert error: No data to store")
+	}
+	if key == nil {
+		return errors.Wrap(errors.InvalidArgument, "db Insert error: No Key specified to store")
+	}
+	_, _, err := c.updateDoc(ctx, key, bson.D{{Key: "$set", Value: data}}, upsert, nil)
+	return err
+}
+
+// Find one entry from the store collection for the given key, where the data
+// value is returned based on the object type passed to it
+func (c *postgresCollection) FindOne(ctx context.Context, key any, data any, opts ...any) error {
+	if _, err := operationConsistency("FindOne", opts); err != nil {
+		return err
+	}
+	docID, _, err := postgresDocID(key)
+	if err != nil {
+		return err
+	}
+	if err := c.ensureTable(ctx); err != nil {
+		return err
+	}
+	docs, err := c.queryDocs(ctx, c.querier(ctx), "SELECT id, doc FROM "+c.table()+" WHERE id = $1", docID)
+	if err != nil {
+		return interpretPostgresError(err)
+	}
+	if len(docs) == 0 {
+		return errors.Wrap(errors.NotFound, mongo.ErrNoDocuments.Error())
+	}
+	return decodeDocument(docs[0], data)
+}
+
+// Find one entry from the store collection for the given key and atomically
+// apply the update document to it, where the data value is returned based
+// on the object type passed to it
+func (c *postgresCollection) FindOneAndUpdate(ctx context.Context, key any, update any, data any, opts ...any) error {
+	if key == nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndUpdate error: No Key specified")
+	}
+	if update == nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndUpdate error: No update specified")
+	}
+	args := &options.FindOneAndUpdateOptions{}
+	opts, _, err := splitConsistency(opts)
+	if err != nil {
+		return err
+	}
+	opts, filter, err := splitCondition(opts)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		val, ok := opt.(options.Lister[options.FindOneAndUpdateOptions])
+		if !ok {
+			return errors.Wrapf(errors.InvalidArgument, "Invalid option type %T passed to FindOneAndUpdate", opt)
+		}
+		for _, fn := range val.List() {
+			if err := fn(args); err != nil {
+				return err
+			}
+		}
+	}
+	upsert := args.Upsert != nil && *args.Upsert
+	if upsert && filter != nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndUpdate error: Upsert not supported with update condition")
+	}
+	before, after, err := c.updateDoc(ctx, key, update, upsert, filter)
+	if err != nil {
+		return err
+	}
+	doc := before
+	if args.ReturnDocument != nil && *args.ReturnDocument == options.After {
+		doc = after
+	}
+	if doc == nil {
+		return errors.Wrap(errors.NotFound, mongo.ErrNoDocuments.Error())
+	}
+	return decodeDocument(doc, data)
+}
+
+// Find one entry from the store collection matching the given filter and
+// atomically delete it, where the data value holds the deleted entry
+// based on the object type passed to it
+func (c *postgresCollection) FindOneAndDelete(ctx context.Context, filter any, data any, opts ...any) error {
+	if filter == nil {
+		return errors.Wrap(errors.InvalidArgument, "db FindOneAndDelete error: No filter specified")
+	}
+	args := &options.FindOneAndDeleteOptions{}
+	opts, _, err := splitConsistency(opts)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		val, ok := opt.(options.Lister[options.FindOneAndDeleteOptions])
+		if !ok {
+			return errors.Wrapf(errors.InvalidArgument, "Invalid option type %T passed to FindOneAndDelete", opt)
+		}
+		for _, fn := range val.List() {
+			if err := fn(args); err != nil {
+				return err
+			}
+		}
+	}
+
+	var doc bson.D
+	err = c.withTx(ctx, func(tx *sql.Tx) error {
+		docs, err := c.matchDocs(ctx, tx, filter)
+		if err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return errors.Wrap(errors.NotFound, mongo.ErrNoDocuments.Error())
+		}
+		if args.Sort != nil {
+			spec, err := toDocument(args.Sort)
+			if err != nil {
+				return err
+			}
+			sortDocuments(docs, spec)
+		}
+		doc = docs[0]
+		docID, _, err := postgresDocID(doc[0].Value)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM "+c.table()+" WHERE id = $1", docID)
+		if err != nil {
+			return err
+		}
+		return c.recordChange(ctx, tx, "delete", docID, nil, nil)
+	})
+	if err != nil {
+		return err
+	}
+	return decodeDocument(doc, data)
+}
+
+// Find multiple entries from the store collection for the given filter, where the data
+// value is returned as a list based on the object type passed to it
+func (c *postgresCollection) FindMany(ctx context.Context, filter any, data any, opts ...any) error {
+	args, err := findOptions(opts)
+	if err != nil {
+		return err
+	}
+	docs, err := c.matchDocs(ctx, nil, filter)
+	if err != nil {
+		return interpretPostgresError(err)
+	}
+	docs, err = applyFindOptions(docs, args)
+	if err != nil {
+		return err
+	}
+	return decodeDocuments(docs, data)
+}
+
+// Return count of entries matching the provided filter
+func (c *postgresCollection) Count(ctx context.Context, filter any, opts ...any) (int64, error) {
+	if _, err := operationConsistency("Count", opts); err != nil {
+		return 0, err
+	}
+	docs, err := c.matchDocs(ctx, nil, filter)
+	if err != nil {
+		return 0, interpretPostgresError(err)
+	}
+	return int64(len(docs)), nil
+}
+
+// remove one entry from the collection matching the given key
+func (c *postgresCollection) DeleteOne(ctx context.Context, key any, opts ...any) error {
+	if _, err := operationConsistency("DeleteOne", opts); err != nil {
+		return err
+	}
+	docID, _, err := postgresDocID(key)
+	if err != nil {
+		return err
+	}
+	return c.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM "+c.table()+" WHERE id = $1", docID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return errors.Wrap(errors.NotFound, "No Document found")
+		}
+		return c.recordChange(ctx, tx, "delete", docID, nil, nil)
+	})
+}
+
+// Delete Many entries matching the delete criteria
+// returns number of entries deleted and if there is any error processing the request
+func (c *postgresCollection) DeleteMany(ctx context.Context, filter any, opts ...any) (int64, error) {
+	if _, err := operationConsistency("DeleteMany", opts); err != nil {
+		return 0, err
+	}
+	count := int64(0)
+	err := c.withTx(ctx, func(tx *sql.Tx) error {
+		docs, err := c.matchDocs(ctx, tx, filter)
+		if err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return errors.Wrap(errors.NotFound, "No matching entries found to delete")
+		}
+		for _, doc := range docs {
+			docID, _, err := postgresDocID(doc[0].Value)
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, "DELETE FROM "+c.table()+" WHERE id = $1", docID)
+			if err != nil {
+				return err
+			}
+			err = c.recordChange(ctx, tx, "delete", docID, nil, nil)
+			if err != nil {
+				return err
+			}
+		}
+		count = int64(len(docs))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// postgresWatchPos is the position of the watcher in the change log
+type postgresWatchPos struct {
+	// snapshot of the previous poll, where the changes of the
+	// transactions visible in it are already delivered
+	snapshot string
+
+	// changes recorded after the time are delivered on the first poll,
+	// even if they were visible in the starting snapshot
+	since *time.Time
+}
+
+// returns the position to start watching from, all the changes after the
+// timestamp if specified, otherwise the new changes only
+func (c *postgresCollection) watchStart(ctx context.Context, timestamp *bson.Timestamp) (*postgresWatchPos, error) {
+	pos := &postgresWatchPos{}
+	if timestamp != nil {
+		since := time.Unix(int64(timestamp.T), 0)
+		pos.since = &since
+	}
+	err := c.db().QueryRowContext(ctx, "SELECT pg_current_snapshot()::text").Scan(&pos.snapshot)
+	return pos, err
+}
+
+// fetches the changes recorded for the collection by the transactions
+// committed since the previous poll and queues them to the watcher in the
+// sequence order, moving the position ahead.
+// Sequence is allocated on insert rather than on commit, so a transaction
+// may commit after another one holding a later sequence, which would be
+// skipped if polled for the sequences after the last one seen. Instead the
+// changes of the transactions not visible in the snapshot of the previous
+// poll are fetched, using a repeatable read transaction, so that all the
+// batches are read from the same snapshot, which becomes the position for
+// the next poll. Changes of the same document are always ordered by the
+// sequence, as the document remains locked till the commit.
+// The changes queued before a failure are delivered again on the next poll
+func (c *postgresCollection) pollChanges(ctx context.Context, w *docWatcher, pos *postgresWatchPos) error {
+	tx, err := c.db().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	var snapshot string
+	err = tx.QueryRowContext(ctx, "SELECT pg_current_snapshot()::text").Scan(&snapshot)
+	if err != nil {
+		return err
+	}
+
+	cond := "xid >= pg_snapshot_xmin($2::pg_snapshot) AND NOT pg_visible_in_snapshot(xid, $2::pg_snapshot)"
+	args := []any{c.colName, pos.snapshot, int64(0)}
+	if pos.since != nil {
+		cond = "(ts > $4 OR (" + cond + "))"
+		args = append(args, *pos.since)
+	}
+	query := "SELECT seq, op, doc_id, full_doc, updated, ts FROM " + c.changes() +
+		" WHERE coll = $1 AND " + cond + " AND seq > $3 ORDER BY seq LIMIT " + strconv.Itoa(postgresChangeBatchSize)
+	for {
+		seq, count, err := c.queueChanges(ctx, tx, w, query, args...)
+		if err != nil {
+			return err
+		}
+		if count < postgresChangeBatchSize {
+			break
+		}
+		args[2] = seq
+	}
+	pos.snapshot = snapshot
+	pos.since = nil
+	return nil
+}
+
+// queues the changes returned by the query to the watcher, returning the
+// last sequence queued along with the number of changes
+func (c *postgresCollection) queueChanges(ctx context.Context, tx *sql.Tx, w *docWatcher, query string, args ...any) (int64, int, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+	var seq int64
+	count := 0
+	for rows.Next() {
+		var op string
+		var docID, full, updated []byte
+		var ts time.Time
+		if err := rows.Scan(&seq, &op, &docID, &full, &updated, &ts); err != nil {
+			return seq, count, err
+		}
+		key := bson.D{}
+		if err := bson.UnmarshalExtJSON(docID, true, &key); err != nil {
+			return seq, count, err
+		}
+		var doc, upd bson.D
+		if full != nil {
+			if doc, err = postgresDecode(docID, full); err != nil {
+				return seq, count, err
+			}
+		}
+		if updated != nil {
+			if err := bson.UnmarshalExtJSON(updated, true, &upd); err != nil {
+				return seq, count, err
+			}
+		}
+		w.enqueue(changeEvent(uint64(seq), ts, op, c.store.name, c.colName, key[0].Value, doc, upd))
+		count++
+	}
+	return seq, count, rows.Err()
+}
+
+// removes the change log entries older than the configured retention
+func (c *postgresCollection) pruneChanges(ctx context.Context) error {
+	before := time.Now().Add(-c.store.client.conf.ChangeRetention)
+	_, err := c.db().ExecContext(ctx, "DELETE FROM "+c.changes()+" WHERE ts < $1", before)
+	return err
+}
+
+// registers a watcher delivering the events to the callback until the
+// context is canceled, events are fetched from the change log on every
+// poll interval or when notified by the listener
+func (c *postgresCollection) addWatcher(ctx context.Context, filter any, timestamp *bson.Timestamp, deliver func(event bson.D)) error {
+	res := c.store.client.res
+	ctx, release, err := res.derive(ctx)
+	if err != nil {
+		return err
+	}
+	w, err := newDocWatcher(ctx, filter, deliver)
+	if err != nil {
+		release()
+		return err
+	}
+	if err := c.ensureTable(ctx); err != nil {
+		release()
+		return err
+	}
+	pos, err := c.watchStart(ctx, timestamp)
+	if err != nil {
+		release()
+		return interpretPostgresError(err)
+	}
+
+	conf := c.store.client.conf
+	wake := make(chan struct{}, 1)
+	if conf.Listener != nil {
+		res.goroutine(func() {
+			err := conf.Listener(ctx, postgresNotifyChannel, func() {
+				select {
+				case wake <- struct{}{}:
+				default:
+				}
+			})
+			if err != nil && ctx.Err() == nil {
+				log.Printf("postgres listener for %s.%s stopped: %s", c.store.name, c.colName, err)
+			}
+		})
+	}
+
+	res.goroutine(w.run)
+	res.goroutine(func() {
+		defer release()
+		ticker := time.NewTicker(conf.WatchInterval)
+		defer ticker.Stop()
+		pruned := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-wake:
+			}
+			err := c.pollChanges(ctx, w, pos)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("failed to fetch changes for %s.%s: %s", c.store.name, c.colName, err)
+			}
+			if time.Since(pruned) > postgresPruneInterval {
+				pruned = time.Now()
+				if err := c.pruneChanges(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("failed to prune changes for %s: %s", c.store.name, err)
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// watch allows getting notified whenever a change happens to a document
+// in the collection
+// allow provisiong for a filter to be passed on, where the callback
+// function to receive only conditional notifications of the events
+// listener is interested about
+func (c *postgresCollection) Watch(ctx context.Context, filter any, cb WatchCallbackfn, opts ...WatchOption) error {
+	if err := c.addWatcher(ctx, filter, nil, watchDelivery(c.keyType, cb)); err != nil {
+		return err
+	}
+	getWatchOptions(opts).resumeUnsupported(c.colName)
+	return nil
+}
+
+// Aggregate runs an aggregation pipeline against the collection, the
+// postgres collection only supports $match, $sort, $skip and $limit stages
+func (c *postgresCollection) Aggregate(ctx context.Context, pipeline any, result any, opts ...any) error {
+	docs, err := c.matchDocs(ctx, nil, nil)
+	if err != nil {
+		return interpretPostgresError(err)
+	}
+	docs, err = aggregateDocuments(docs, pipeline)
+	if err != nil {
+		return err
+	}
+	return decodeDocuments(docs, result)
+}
+
+// EnsureIndexes creates expression indexes over the document fields, if
+// not already present. Text indexes are only recorded as the text search
+// is evaluated by scanning the collection, while TTL indexes and partial
+// filters are not supported
+func (c *postgresCollection) EnsureIndexes(ctx context.Context, indexes []IndexDefinition) error {
+	for _, idx := range indexes {
+		if _, err := c.CreateIndex(ctx, idx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *postgresCollection) indexes() string {
+	return postgresIdent(c.store.name) + "." + postgresIdent(postgresIndexesTable)
+}
+
+// returns the name of the postgres index, prefixed with the collection
+// name as the index names are scoped to the schema
+func (c *postgresCollection) indexRelation(name string) string {
+	return c.colName + "_" + name
+}
+
+// CreateIndex creates the expression index over the document fields,
+// where the index definition is recorded for ListIndexes
+func (c *postgresCollection) CreateIndex(ctx context.Context, index IndexDefinition) (string, error) {
+	if err := prepareIndex(&index); err != nil {
+		return "", err
+	}
+	if index.TTL != 0 {
+		return "", errors.Wrapf(errors.InvalidArgument, "TTL index is not supported for postgres collection %s", c.colName)
+	}
+	if index.PartialFilter != nil {
+		return "", errors.Wrapf(errors.InvalidArgument, "partial index is not supported for postgres collection %s", c.colName)
+	}
+	spec, err := bson.MarshalExtJSON(&index, true, false)
+	if err != nil {
+		return "", err
+	}
+	stmt := c.indexStatement(index)
+	err = c.withTx(ctx, func(tx *sql.Tx) error {
+		var cur []byte
+		err := tx.QueryRowContext(ctx, "SELECT spec FROM "+c.indexes()+" WHERE coll = $1 AND name = $2",
+			c.colName, index.Name).Scan(&cur)
+		if err == nil {
+			existing := IndexDefinition{}
+			if err := bson.UnmarshalExtJSON(cur, true, &existing); err != nil {
+				return err
+			}
+			if !reflect.DeepEqual(existing.Fields, index.Fields) || existing.Unique != index.Unique || existing.Sparse != index.Sparse {
+				return errors.Wrapf(errors.AlreadyExists, "index %s already exists with different definition", index.Name)
+			}
+			return nil
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+		if stmt != "" {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO "+c.indexes()+" (coll, name, spec) VALUES ($1, $2, $3::jsonb)",
+			c.colName, index.Name, string(spec))
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return index.Name, nil
+}
+
+// returns the create index statement for the index definition, empty if
+// the index is not needed
+func (c *postgresCollection) indexStatement(idx IndexDefinition) string {
+	exprs := []string{}
+	conds := []string{}
+	for _, f := range idx.Fields {
+		switch f.IndexType {
+		case IndexText:
+			return ""
+		case IndexDescending:
+			exprs = append(exprs, postgresFieldExpr(f.Field)+" DESC")
+		default:
+			exprs = append(exprs, postgresFieldExpr(f.Field))
+		}
+		conds = append(conds, postgresFieldExpr(f.Field)+" IS NOT NULL")
+	}
+	stmt := "CREATE "
+	if idx.Unique {
+		stmt += "UNIQUE "
+	}
+	stmt += "INDEX IF NOT EXISTS " + postgresIdent(c.indexRelation(idx.Name)) + " ON " + c.table() + " (" + strings.Join(exprs, ", ") + ")"
+	if idx.Sparse {
+		stmt += " WHERE " + strings.Join(conds, " AND ")
+	}
+	return stmt
+}
+
+// ListIndexes returns the indexes created on the collection
+func (c *postgresCollection) ListIndexes(ctx context.Context) ([]IndexDefinition, error) {
+	if err := c.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := c.querier(ctx).QueryContext(ctx, "SELECT spec FROM "+c.indexes()+" WHERE coll = $1 ORDER BY name", c.colName)
+	if err != nil {
+		return nil, interpretPostgresError(err)
+	}
+	defer rows.Close()
+	list := []IndexDefinition{idIndex()}
+	for rows.Next() {
+		var spec []byte
+		if err := rows.Scan(&spec); err != nil {
+			return nil, err
+		}
+		idx := IndexDefinition{}
+		if err := bson.UnmarshalExtJSON(spec, true, &idx); err != nil {
+			return nil, err
+		}
+		list = append(list, idx)
+	}
+	return list, rows.Err()
+}
+
+// DropIndex removes the index with the given name
+func (c *postgresCollection) DropIndex(ctx context.Context, name string) error {
+	if name == idIndexName {
+		return errors.Wrapf(errors.InvalidArgument, "cannot drop the default index %s", idIndexName)
+	}
+	return c.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM "+c.indexes()+" WHERE coll = $1 AND name = $2", c.colName, name)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return errors.Wrapf(errors.NotFound, "index %s not found", name)
+		}
+		_, err = tx.ExecContext(ctx, "DROP INDEX IF EXISTS "+postgresIdent(c.store.name)+"."+postgresIdent(c.indexRelation(name)))
+		return err
+	})
+}
+
+// WithConsistency validates the options and returns a handle to the same
+// collection, as the reads and writes are always served by the server
+// the client is connected to
+func (c *postgresCollection) WithConsistency(opts ConsistencyOptions) (StoreCollection, error) {
+	if _, err := opts.collectionOptions(); err != nil {
+		return nil, err
+	}
+	return &postgresCollection{
+		store:   c.store,
+		colName: c.colName,
+		keyType: c.keyType,
+	}, nil
+}
+
+func (c *postgresCollection) startEventLogger(ctx context.Context, eventType reflect.Type, timestamp *bson.Timestamp) error {
+	return c.addWatcher(ctx, nil, timestamp, eventDelivery(eventType, logEvent))
+}
+
+func (c *postgresCollection) watchEvents(ctx context.Context, filter any, eventType reflect.Type, cb func(event any), wopts *WatchOptions) error {
+	if err := c.addWatcher(ctx, filter, nil, eventDelivery(eventType, cb)); err != nil {
+		return err
+	}
+	wopts.resumeUnsupported(c.colName)
+	return nil
+}
+
+// Gets collection corresponding to the collection name, the table is
+// created on first use similar to mongodb
+func (s *postgresStore) GetCollection(col string) StoreCollection {
+	return &postgresCollection{
+		store:   s,
+		colName: col,
+	}
+}
+
+// CreateCollection explicitly creates the table for the collection,
+// capped and time-series collections are not supported
+func (s *postgresStore) CreateCollection(ctx context.Context, name string, opts CollectionOptions) (StoreCollection, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if !opts.isRegular() {
+		return nil, errors.Wrap(errors.InvalidArgument, "capped and time-series collections are not supported by postgres store")
+	}
+	if opts.Validator != nil {
+		return nil, errors.Wrap(errors.InvalidArgument, "document validation is not supported by postgres store")
+	}
+	col := &postgresCollection{
+		store:   s,
+		colName: name,
+	}
+	var exists bool
+	err := s.client.db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", col.table()).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.Wrapf(errors.AlreadyExists, "collection %s already exists", name)
+	}
+	if err := col.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	return col, nil
+}
+
+// SetValidator is not supported by postgres store
+func (s *postgresStore) SetValidator(ctx context.Context, col string, schema any) error {
+	return errors.Wrap(errors.InvalidArgument, "document validation is not supported by postgres store")
+}
+
+// GetBlobStore is not supported by postgres store
+func (s *postgresStore) GetBlobStore(bucket string, opts ...BlobStoreOption) (BlobStore, error) {
+	return nil, errors.Wrap(errors.InvalidArgument, "blob store is not supported by postgres store")
+}
+
+// Gets Name of the schema corresponding to the store
+func (s *postgresStore) Name() string {
+	return s.name
+}
+
+// postgresClient implements StoreClient over a postgres database, where
+// every data store is a schema in the database
+type postgresClient struct {
+	db     *sql.DB
+	conf   PostgresConfig
+	mu     sync.Mutex
+	stores map[string]*postgresStore
+	res    *clientResources // watches stopped on disconnect
+}
+
+// NewPostgresClient creates a StoreClient backed by postgres, allowing
+// table, sync and reconciler packages to work without MongoDB.
+// The database/sql driver for postgres needs to be registered by the
+// application, typically by importing the driver package, and named in
+// the config
+func NewPostgresClient(conf *PostgresConfig) (StoreClient, error) {
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(conf.DriverName, conf.Uri)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresClient{
+		db:     db,
+		conf:   *conf,
+		stores: map[string]*postgresStore{},
+		res:    newClientResources(),
+	}, nil
+}
+
+// Disconnect stops all the watches started on the collections of the
+// client and closes the connection pool
+func (c *postgresClient) Disconnect(ctx context.Context) error {
+	err := c.res.close(ctx)
+	if cerr := c.db.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Get the Data Store interface given the schema name
+func (c *postgresClient) GetDataStore(dbName string) Store {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.stores[dbName]
+	if !ok {
+		s = &postgresStore{
+			client: c,
+			name:   dbName,
+			tables: map[string]bool{},
+		}
+		c.stores[dbName] = s
+	}
+	return s
+}
+
+// Gets collection corresponding to the collection name inside the
+// requested schema name
+func (c *postgresClient) GetCollection(dbName, col string) StoreCollection {
+	return c.GetDataStore(dbName).GetCollection(col)
+}
+
+// WithTransaction runs the function in a transaction, where all the
+// collection operations of the client executed with the derived context
+// are part of the transaction, committed only if the function succeeds.
+// Nested calls are part of the outer transaction.
+func (c *postgresClient) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ptx, ok := ctx.Value(postgresTxKey{}).(*postgresTx); ok && ptx.client == c {
+		return fn(ctx)
+	}
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	err = fn(context.WithValue(ctx, postgresTxKey{}, &postgresTx{client: c, tx: tx}))
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return interpretPostgresError(tx.Commit())
+}
+
+// Health Check, whether the client is healthy or disconnected
+func (c *postgresClient) HealthCheck(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
+// HealthReport returns the health of the postgres database, without the
+// member details
+func (c *postgresClient) HealthReport(ctx context.Context) (*HealthReport, error) {
+	return simpleHealthReport(ctx, c.HealthCheck)
+}
@@ -0,0 +1,182 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// postgresWhere translates the filter into the where clause selecting the
+// candidate rows, such that the rows matching the filter are always part
+// of the candidates, while the candidates are still evaluated against the
+// filter by the client.
+// Equality and range conditions on the top level fields and equality on
+// `_id` are translated, combined using $and and $or, where the rest of
+// the conditions select all the rows. Fields holding arrays are always
+// part of the candidates, as their elements are matched by the client.
+// Returns an empty string if the filter selects all the rows
+func postgresWhere(filter any) (string, []any, error) {
+	f, err := toDocument(filter)
+	if err != nil {
+		return "", nil, err
+	}
+	w := &postgresFilter{}
+	cond, err := w.document(f)
+	if err != nil || cond == "" {
+		return "", nil, err
+	}
+	return " WHERE " + cond, w.args, nil
+}
+
+// postgresFilter accumulates the arguments of the translated conditions
+type postgresFilter struct {
+	args []any
+}
+
+// adds the argument returning the placeholder referring to it
+func (w *postgresFilter) arg(val any) string {
+	w.args = append(w.args, val)
+	return "$" + strconv.Itoa(len(w.args))
+}
+
+// translates the filter document, combining the conditions using AND
+func (w *postgresFilter) document(f bson.D) (string, error) {
+	conds := []string{}
+	for _, e := range f {
+		var cond string
+		var err error
+		switch {
+		case e.Key == "$and":
+			cond, err = w.logical(e.Value, " AND ", false)
+		case e.Key == "$or":
+			cond, err = w.logical(e.Value, " OR ", true)
+		case e.Key == "_id":
+			cond, err = w.key(e.Value)
+		case strings.HasPrefix(e.Key, "$") || strings.Contains(e.Key, "."):
+			// nested fields may traverse arrays, evaluated by the client
+		default:
+			cond = w.field(postgresFieldExpr(e.Key), e.Value)
+		}
+		if err != nil {
+			return "", err
+		}
+		if cond != "" {
+			conds = append(conds, cond)
+		}
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "(" + strings.Join(conds, " AND ") + ")", nil
+}
+
+// translates $and or $or, where $or is translated only if all its
+// branches are, as otherwise any row can match
+func (w *postgresFilter) logical(val any, sep string, all bool) (string, error) {
+	list, ok := val.(bson.A)
+	if !ok {
+		// malformed filter is reported by the client evaluation
+		return "", nil
+	}
+	// arguments of the discarded branches are dropped as well
+	n := len(w.args)
+	conds := []string{}
+	for _, item := range list {
+		f, ok := item.(bson.D)
+		if !ok {
+			w.args = w.args[:n]
+			return "", nil
+		}
+		cond, err := w.document(f)
+		if err != nil {
+			return "", err
+		}
+		if cond == "" {
+			if all {
+				w.args = w.args[:n]
+				return "", nil
+			}
+			continue
+		}
+		conds = append(conds, cond)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "(" + strings.Join(conds, sep) + ")", nil
+}
+
+// translates the equality on `_id` to the lookup of the serialized key
+func (w *postgresFilter) key(val any) (string, error) {
+	if isOperatorDoc(val) {
+		return "", nil
+	}
+	docID, _, err := postgresDocID(val)
+	if err != nil {
+		return "", err
+	}
+	return "id = " + w.arg(docID), nil
+}
+
+// translates the conditions on the top level field, where the field
+// holding an array is always a candidate
+func (w *postgresFilter) field(expr string, val any) string {
+	conds := []string{}
+	if isOperatorDoc(val) {
+		for _, op := range val.(bson.D) {
+			if cond := w.compare(expr, op.Key, op.Value); cond != "" {
+				conds = append(conds, cond)
+			}
+		}
+	} else if cond := w.compare(expr, "$eq", val); cond != "" {
+		conds = append(conds, cond)
+	}
+	if len(conds) == 0 {
+		return ""
+	}
+	return "(jsonb_typeof(" + expr + ") = 'array' OR (" + strings.Join(conds, " AND ") + "))"
+}
+
+// translates the comparison of the field with the value, as per the
+// canonical extended JSON the documents are stored in, returns empty
+// string if the comparison is not translated
+func (w *postgresFilter) compare(expr, op string, val any) string {
+	sqlOp := map[string]string{"$eq": "=", "$gt": ">", "$gte": ">=", "$lt": "<", "$lte": "<="}[op]
+	if sqlOp == "" {
+		return ""
+	}
+	switch v := val.(type) {
+	case string:
+		if op == "$eq" {
+			return expr + " = to_jsonb(" + w.arg(v) + "::text)"
+		}
+		// strings are compared by their bytes, similar to mongodb
+		return "(jsonb_typeof(" + expr + ") = 'string' AND (" + expr + " #>> '{}') COLLATE \"C\" " +
+			sqlOp + " " + w.arg(v) + "::text)"
+	case bool:
+		if op != "$eq" {
+			return ""
+		}
+		return expr + " = " + w.arg(strconv.FormatBool(v)) + "::jsonb"
+	case int32, int64, float64:
+		// numbers of all types compare with each other, approximated as
+		// double precision, where the strict comparisons are relaxed to
+		// not lose the values rounded to the same double
+		if sqlOp == ">" || sqlOp == "<" {
+			sqlOp += "="
+		}
+		num := "(CASE WHEN jsonb_typeof(" + expr + ") = 'object' THEN COALESCE(" +
+			expr + " ->> '$numberInt', " + expr + " ->> '$numberLong', " +
+			expr + " ->> '$numberDouble', " + expr + " ->> '$numberDecimal') END)::float8"
+		return num + " " + sqlOp + " " + w.arg(toFloat(v)) + "::float8"
+	case bson.DateTime:
+		date := "(CASE WHEN jsonb_typeof(" + expr + ") = 'object' THEN " +
+			expr + " #>> '{$date,$numberLong}' END)::bigint"
+		return date + " " + sqlOp + " " + w.arg(int64(v)) + "::bigint"
+	}
+	return ""
+}
@@ -0,0 +1,375 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// connection string of the postgres database used by the tests, the
+	// tests running against the database are skipped if not set
+	postgresTestUriEnv = "POSTGRES_TEST_URI"
+
+	// name of the database/sql driver used by the tests, defaults to pgx,
+	// the driver needs to be linked into the test binary, eg. by a local
+	// file importing _ "github.com/jackc/pgx/v5/stdlib"
+	postgresTestDriverEnv = "POSTGRES_TEST_DRIVER"
+)
+
+type postgresTestKey struct {
+	Name string `bson:"name,omitempty"`
+	Id   int64  `bson:"id,omitempty"`
+}
+
+type postgresTestData struct {
+	Desc  string `bson:"desc,omitempty"`
+	Count int32  `bson:"count,omitempty"`
+}
+
+// returns the client for the postgres database configured for the tests,
+// working on a schema dropped once the test completes
+func newPostgresTestClient(t *testing.T) (StoreClient, string) {
+	uri := os.Getenv(postgresTestUriEnv)
+	if uri == "" {
+		t.Skipf("%s not set, skipping tests against postgres", postgresTestUriEnv)
+	}
+	driver := os.Getenv(postgresTestDriverEnv)
+	if driver == "" {
+		driver = "pgx"
+	}
+	if !slices.Contains(sql.Drivers(), driver) {
+		t.Skipf("postgres driver %q is not linked into the test binary", driver)
+	}
+	client, err := NewPostgresClient(&PostgresConfig{
+		DriverName:    driver,
+		Uri:           uri,
+		WatchInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to create postgres client: %s", err)
+	}
+	schema := fmt.Sprintf("core_test_%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_, err := client.(*postgresClient).db.ExecContext(ctx, "DROP SCHEMA IF EXISTS "+postgresIdent(schema)+" CASCADE")
+		if err != nil {
+			t.Errorf("failed to drop schema %s: %s", schema, err)
+		}
+		_ = client.Disconnect(ctx)
+	})
+	return client, schema
+}
+
+func Test_PostgresCollection(t *testing.T) {
+	client, schema := newPostgresTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	col := client.GetCollection(schema, "items")
+	if err := col.SetKeyType(reflect.TypeOf(&postgresTestKey{})); err != nil {
+		t.Fatalf("failed to set key type: %s", err)
+	}
+
+	type watchEvent struct {
+		op  string
+		key string
+	}
+	events := make(chan watchEvent, 100)
+	err := col.Watch(ctx, nil, func(op string, key any) {
+		events <- watchEvent{op: op, key: key.(*postgresTestKey).Name}
+	})
+	if err != nil {
+		t.Fatalf("failed to watch: %s", err)
+	}
+	expectEvent := func(op, key string) {
+		t.Helper()
+		select {
+		case e := <-events:
+			if e.op != op || e.key != key {
+				t.Errorf("expected %s event for %s, got %s event for %s", op, key, e.op, e.key)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s event for %s", op, key)
+		}
+	}
+
+	t.Run("insert_and_find", func(t *testing.T) {
+		key := &postgresTestKey{Name: "first"}
+		err := col.InsertOne(ctx, key, &postgresTestData{Desc: "one", Count: 1})
+		if err != nil {
+			t.Fatalf("failed to insert: %s", err)
+		}
+		err = col.InsertOne(ctx, key, &postgresTestData{Desc: "again"})
+		if !errors.IsAlreadyExists(err) {
+			t.Errorf("expected already exists error, got %v", err)
+		}
+		data := &postgresTestData{}
+		err = col.FindOne(ctx, key, data)
+		if err != nil || data.Desc != "one" || data.Count != 1 {
+			t.Errorf("unexpected entry %v, err %v", data, err)
+		}
+		err = col.FindOne(ctx, &postgresTestKey{Name: "missing"}, data)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		expectEvent("insert", "first")
+	})
+
+	t.Run("update", func(t *testing.T) {
+		key := &postgresTestKey{Name: "first"}
+		err := col.UpdateOne(ctx, key, &postgresTestData{Desc: "updated", Count: 1}, false)
+		if err != nil {
+			t.Fatalf("failed to update: %s", err)
+		}
+		err = col.UpdateOne(ctx, &postgresTestKey{Name: "missing"}, &postgresTestData{}, false)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		expectEvent("update", "first")
+
+		data := &postgresTestData{}
+		err = col.FindOneAndUpdate(ctx, key, bson.D{{Key: "$inc", Value: bson.D{{Key: "count", Value: int32(2)}}}},
+			data, options.FindOneAndUpdate().SetReturnDocument(options.After))
+		if err != nil || data.Count != 3 || data.Desc != "updated" {
+			t.Errorf("unexpected entry after update %v, err %v", data, err)
+		}
+		expectEvent("update", "first")
+
+		err = col.FindOneAndUpdate(ctx, key, bson.D{{Key: "$set", Value: bson.D{{Key: "desc", Value: "stale"}}}},
+			data, UpdateCondition{Filter: bson.D{{Key: "count", Value: int32(1)}}})
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found error for unmet condition, got %v", err)
+		}
+
+		err = col.FindOneAndUpdate(ctx, &postgresTestKey{Name: "second"}, bson.D{{Key: "$set", Value: bson.D{{Key: "desc", Value: "two"}}}},
+			data, options.FindOneAndUpdate().SetUpsert(true))
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found error for the entry before upsert, got %v", err)
+		}
+		expectEvent("insert", "second")
+	})
+
+	t.Run("find_many", func(t *testing.T) {
+		err := col.InsertOne(ctx, &postgresTestKey{Name: "third"}, &postgresTestData{Desc: "three", Count: 10})
+		if err != nil {
+			t.Fatalf("failed to insert: %s", err)
+		}
+		expectEvent("insert", "third")
+
+		var list []*postgresTestData
+		err = col.FindMany(ctx, bson.D{{Key: "count", Value: bson.D{{Key: "$gt", Value: int64(2)}}}}, &list,
+			options.Find().SetSort(bson.D{{Key: "count", Value: -1}}))
+		if err != nil || len(list) != 2 || list[0].Count != 10 || list[1].Count != 3 {
+			t.Errorf("unexpected entries %v, err %v", list, err)
+		}
+		count, err := col.Count(ctx, bson.D{{Key: "desc", Value: "two"}})
+		if err != nil || count != 1 {
+			t.Errorf("expected one entry, got %d, err %v", count, err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		data := &postgresTestData{}
+		err := col.FindOneAndDelete(ctx, bson.D{{Key: "desc", Value: "three"}}, data)
+		if err != nil || data.Count != 10 {
+			t.Errorf("unexpected deleted entry %v, err %v", data, err)
+		}
+		expectEvent("delete", "third")
+
+		err = col.DeleteOne(ctx, &postgresTestKey{Name: "second"})
+		if err != nil {
+			t.Errorf("failed to delete: %s", err)
+		}
+		expectEvent("delete", "second")
+
+		count, err := col.DeleteMany(ctx, bson.D{{Key: "count", Value: bson.D{{Key: "$gte", Value: int32(3)}}}})
+		if err != nil || count != 1 {
+			t.Errorf("expected one entry to be deleted, got %d, err %v", count, err)
+		}
+		expectEvent("delete", "first")
+
+		_, err = col.DeleteMany(ctx, bson.D{})
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found error on empty collection, got %v", err)
+		}
+	})
+
+	t.Run("watch_commit_order", func(t *testing.T) {
+		// transaction holding the earlier sequence commits after the one
+		// holding the later sequence, both need to be delivered
+		inserted := make(chan error, 1)
+		release := make(chan struct{})
+		done := make(chan error, 1)
+		go func() {
+			done <- client.WithTransaction(ctx, func(ctx context.Context) error {
+				err := col.InsertOne(ctx, &postgresTestKey{Name: "slow"}, &postgresTestData{Desc: "slow"})
+				inserted <- err
+				if err != nil {
+					return err
+				}
+				<-release
+				return nil
+			})
+		}()
+		if err := <-inserted; err != nil {
+			close(release)
+			t.Fatalf("failed to insert in transaction: %s", err)
+		}
+		err := col.InsertOne(ctx, &postgresTestKey{Name: "fast"}, &postgresTestData{Desc: "fast"})
+		if err != nil {
+			close(release)
+			t.Fatalf("failed to insert: %s", err)
+		}
+		expectEvent("insert", "fast")
+		close(release)
+		if err := <-done; err != nil {
+			t.Fatalf("failed to commit transaction: %s", err)
+		}
+		expectEvent("insert", "slow")
+	})
+}
+
+func Test_PostgresHelpers(t *testing.T) {
+	t.Run("config", func(t *testing.T) {
+		conf := &PostgresConfig{Uri: "postgres://localhost/test"}
+		if err := conf.validate(); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for missing driver, got %v", err)
+		}
+		conf.DriverName = "pgx"
+		if err := conf.validate(); err != nil {
+			t.Fatalf("failed to validate config: %s", err)
+		}
+		if conf.WatchInterval != defaultPostgresWatchInterval || conf.ChangeRetention != defaultPostgresChangeRetention {
+			t.Errorf("defaults not applied, got %v, %v", conf.WatchInterval, conf.ChangeRetention)
+		}
+	})
+
+	t.Run("quoting", func(t *testing.T) {
+		if got := postgresIdent(`my"table`); got != `"my""table"` {
+			t.Errorf("unexpected identifier %s", got)
+		}
+		if got := postgresFieldExpr("_id"); got != "id" {
+			t.Errorf("unexpected expression for _id %s", got)
+		}
+		if got := postgresFieldExpr("spec.o'name"); got != `(doc #> '{"spec","o''name"}')` {
+			t.Errorf("unexpected field expression %s", got)
+		}
+	})
+
+	t.Run("document", func(t *testing.T) {
+		key := &postgresTestKey{Name: "test", Id: 5}
+		docID, id, err := postgresDocID(key)
+		if err != nil {
+			t.Fatalf("failed to serialize key: %s", err)
+		}
+		again, _, _ := postgresDocID(&postgresTestKey{Name: "test", Id: 5})
+		if docID != again {
+			t.Errorf("serialized key is not stable, %s != %s", docID, again)
+		}
+		doc, err := memoryNewDoc(id, bson.M{"desc": "value", "count": int32(3)})
+		if err != nil {
+			t.Fatalf("failed to build document: %s", err)
+		}
+		val, err := postgresEncode(doc)
+		if err != nil {
+			t.Fatalf("failed to encode document: %s", err)
+		}
+		decoded, err := postgresDecode([]byte(docID), []byte(val.(string)))
+		if err != nil {
+			t.Fatalf("failed to decode document: %s", err)
+		}
+		if decoded[0].Key != "_id" {
+			t.Fatalf("expected _id as first field, got %s", decoded[0].Key)
+		}
+		out := &postgresTestKey{}
+		if err := bson.UnmarshalValue(bson.TypeEmbeddedDocument, mustMarshal(t, decoded[0].Value), out); err != nil {
+			t.Fatalf("failed to decode key: %s", err)
+		}
+		if *out != *key {
+			t.Errorf("expected key %v, got %v", key, out)
+		}
+		if count, _ := lookupField(decoded, "count"); count != int32(3) {
+			t.Errorf("expected count to be preserved as int32, got %v (%T)", count, count)
+		}
+	})
+
+	t.Run("index", func(t *testing.T) {
+		c := &postgresCollection{store: &postgresStore{name: "test"}, colName: "items"}
+		idx := IndexDefinition{
+			Fields: []IndexField{{Field: "name", IndexType: IndexAscending}, {Field: "ts", IndexType: IndexDescending}},
+			Unique: true,
+			Sparse: true,
+		}
+		if err := prepareIndex(&idx); err != nil {
+			t.Fatalf("failed to prepare index: %s", err)
+		}
+		expected := `CREATE UNIQUE INDEX IF NOT EXISTS "items_name_1_ts_-1" ON "test"."items" ` +
+			`((doc #> '{"name"}'), (doc #> '{"ts"}') DESC) ` +
+			`WHERE (doc #> '{"name"}') IS NOT NULL AND (doc #> '{"ts"}') IS NOT NULL`
+		if stmt := c.indexStatement(idx); stmt != expected {
+			t.Errorf("unexpected index statement\n%s\nexpected\n%s", stmt, expected)
+		}
+		stmt := c.indexStatement(IndexDefinition{Fields: []IndexField{{Field: "desc", IndexType: IndexText}}})
+		if stmt != "" {
+			t.Errorf("expected text index to be skipped, got %q", stmt)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		where, args, err := postgresWhere(bson.D{
+			{Key: "name", Value: "test"},
+			{Key: "count", Value: bson.D{{Key: "$gt", Value: int32(3)}, {Key: "$exists", Value: true}}},
+			{Key: "$or", Value: bson.A{
+				bson.D{{Key: "active", Value: true}},
+				bson.D{{Key: "spec.name", Value: "x"}},
+			}},
+		})
+		if err != nil {
+			t.Fatalf("failed to translate filter: %s", err)
+		}
+		expected := ` WHERE ((jsonb_typeof((doc #> '{"name"}')) = 'array' OR ((doc #> '{"name"}') = to_jsonb($1::text))) AND ` +
+			`(jsonb_typeof((doc #> '{"count"}')) = 'array' OR ((CASE WHEN jsonb_typeof((doc #> '{"count"}')) = 'object' ` +
+			`THEN COALESCE((doc #> '{"count"}') ->> '$numberInt', (doc #> '{"count"}') ->> '$numberLong', ` +
+			`(doc #> '{"count"}') ->> '$numberDouble', (doc #> '{"count"}') ->> '$numberDecimal') END)::float8 >= $2::float8)))`
+		if where != expected {
+			t.Errorf("unexpected where clause\n%s\nexpected\n%s", where, expected)
+		}
+		if len(args) != 2 || args[0] != "test" || args[1] != float64(3) {
+			t.Errorf("unexpected arguments %v", args)
+		}
+
+		key := &postgresTestKey{Name: "test", Id: 5}
+		docID, _, _ := postgresDocID(key)
+		where, args, err = postgresWhere(bson.D{{Key: "_id", Value: key}, {Key: "tags", Value: bson.D{{Key: "$in", Value: bson.A{"a"}}}}})
+		if err != nil || where != " WHERE (id = $1)" || len(args) != 1 || args[0] != docID {
+			t.Errorf("unexpected translation of key lookup %q, %v, err %v", where, args, err)
+		}
+
+		where, _, err = postgresWhere(bson.D{{Key: "desc", Value: bson.D{{Key: "$regex", Value: "^a"}}}})
+		if err != nil || where != "" {
+			t.Errorf("expected untranslated filter to select all the rows, got %q, err %v", where, err)
+		}
+	})
+}
+
+func mustMarshal(t *testing.T, val any) []byte {
+	_, raw, err := bson.MarshalValue(val)
+	if err != nil {
+		t.Fatalf("failed to marshal value: %s", err)
+	}
+	return raw
+}
@@ -0,0 +1,96 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// clientResources tracks the long running resources derived from a
+// client, ie. the watch streams and their go routines, allowing them to
+// be stopped on disconnect, instead of leaking go routines that fail
+// once the connections are closed. nil tracker is valid for the
+// collections created without a client, where nothing is tracked
+type clientResources struct {
+	mu     sync.Mutex
+	closed bool
+	ctx    context.Context // canceled on disconnect
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newClientResources() *clientResources {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &clientResources{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// derive returns the context for a long running resource, which is also
+// canceled on disconnect, along with the function releasing it
+// returns Unavailable error if the client is already disconnected
+func (r *clientResources) derive(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if r == nil {
+		return ctx, func() {}, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, nil, errors.Wrap(errors.Unavailable, "client is disconnected")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(r.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}, nil
+}
+
+// goroutine runs the function in a go routine, which disconnect waits
+// for to complete, the function is expected to return once the context
+// obtained from derive is canceled
+func (r *clientResources) goroutine(fn func()) {
+	if r == nil {
+		go fn()
+		return
+	}
+	r.mu.Lock()
+	if r.closed {
+		// context is already canceled, nothing to wait for
+		r.mu.Unlock()
+		go fn()
+		return
+	}
+	r.wg.Add(1)
+	r.mu.Unlock()
+	go func() {
+		defer r.wg.Done()
+		fn()
+	}()
+}
+
+// close cancels all the resources and waits for their go routines to
+// complete, until the context is done
+func (r *clientResources) close(ctx context.Context) error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrapf(errors.Unavailable, "timed out waiting for watches to stop: %s", ctx.Err())
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+func Test_Disconnect(t *testing.T) {
+	ctx := context.Background()
+
+	clients := map[string]func() StoreClient{
+		"memory": NewMemoryClient,
+		"etcd": func() StoreClient {
+			client, _ := NewEtcdClient(newFakeEtcdKV())
+			return client
+		},
+	}
+	for name, newClient := range clients {
+		t.Run(name, func(t *testing.T) {
+			client := newClient()
+			col := client.GetCollection("test-db", "entries")
+			_ = col.SetKeyType(reflect.TypeOf(&MyKey{}))
+			var events atomic.Int32
+			err := col.Watch(ctx, nil, func(op string, key any) {
+				events.Add(1)
+			})
+			if err != nil {
+				t.Fatalf("failed to start watch: %s", err)
+			}
+			_ = col.InsertOne(ctx, &MyKey{Name: "a"}, &memTestData{Desc: "a"})
+			for range 100 {
+				if events.Load() == 1 {
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			if events.Load() != 1 {
+				t.Fatalf("expected event before disconnect, got %d", events.Load())
+			}
+
+			dctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			if err := client.Disconnect(dctx); err != nil {
+				t.Fatalf("failed to disconnect: %s", err)
+			}
+			_ = col.InsertOne(ctx, &MyKey{Name: "b"}, &memTestData{Desc: "b"})
+			time.Sleep(50 * time.Millisecond)
+			if events.Load() != 1 {
+				t.Errorf("expected no events after disconnect, got %d", events.Load())
+			}
+			err = col.Watch(ctx, nil, func(op string, key any) {})
+			if !errors.IsUnavailable(err) {
+				t.Errorf("expected unavailable error for watch after disconnect, got %v", err)
+			}
+		})
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		res := newClientResources()
+		ctx, release, err := res.derive(context.Background())
+		if err != nil {
+			t.Fatalf("failed to derive context: %s", err)
+		}
+		stop := make(chan struct{})
+		res.goroutine(func() {
+			defer release()
+			<-ctx.Done()
+			// slow to stop
+			<-stop
+		})
+		dctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := res.close(dctx); !errors.IsUnavailable(err) {
+			t.Errorf("expected unavailable error on timeout, got %v", err)
+		}
+		close(stop)
+	})
+
+	t.Run("standalone", func(t *testing.T) {
+		// collections without a client are not tracked
+		var res *clientResources
+		ctx, release, err := res.derive(context.Background())
+		if err != nil || ctx == nil {
+			t.Fatalf("expected nil tracker to pass the context, got %v", err)
+		}
+		release()
+	})
+}
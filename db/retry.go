@@ -0,0 +1,307 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// default number of attempts for an operation, including the first
+	defaultRetryMaxAttempts = 5
+
+	// default initial and maximum backoff between the attempts
+	defaultRetryInitialBackoff = 50 * time.Millisecond
+	defaultRetryMaxBackoff     = 5 * time.Second
+)
+
+// server error codes, considered transient as they are typically
+// observed during elections, failovers and concurrent transactions
+var transientServerErrorCodes = []int{
+	6,     // HostUnreachable
+	7,     // HostNotFound
+	89,    // NetworkTimeout
+	91,    // ShutdownInProgress
+	112,   // WriteConflict
+	189,   // PrimarySteppedDown
+	9001,  // SocketException
+	10107, // NotWritablePrimary
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+func init() {
+	// the transient driver errors are retryable across the packages
+	errors.RegisterRetryableFunc(isTransientError)
+}
+
+// returns true if the error is a transient driver error, where the
+// operation is expected to succeed if retried
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if mongo.IsNetworkError(err) {
+		return true
+	}
+	serr, ok := err.(mongo.ServerError)
+	if !ok {
+		return false
+	}
+	if serr.HasErrorLabel("TransientTransactionError") || serr.HasErrorLabel("RetryableWriteError") {
+		return true
+	}
+	for _, code := range transientServerErrorCodes {
+		if serr.HasErrorCode(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryOptions provides the policy for retrying the collection operations
+// failing with transient errors
+type RetryOptions struct {
+	// MaxAttempts is the number of attempts for an operation, including
+	// the first one
+	// Default: 5
+	MaxAttempts int
+
+	// InitialBackoff is the backoff before the first retry, doubled for
+	// every subsequent retry capped at MaxBackoff, with a random jitter
+	// of up to half of the backoff
+	// Default: 50ms, 5s
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// IsTransient classifies the errors to be retried
+	// Default: errors.IsRetryable, covering the network errors, not
+	// primary, write conflicts and other transient mongo server errors
+	IsTransient func(err error) bool
+}
+
+// RetryOption is a functional option for configuring the retry policy
+type RetryOption func(*RetryOptions)
+
+// WithRetryMaxAttempts sets the number of attempts for an operation
+func WithRetryMaxAttempts(attempts int) RetryOption {
+	return func(opts *RetryOptions) {
+		opts.MaxAttempts = attempts
+	}
+}
+
+// WithRetryBackoff sets the initial and maximum backoff between the
+// attempts
+func WithRetryBackoff(initial, max time.Duration) RetryOption {
+	return func(opts *RetryOptions) {
+		opts.InitialBackoff = initial
+		opts.MaxBackoff = max
+	}
+}
+
+// WithRetryClassifier sets the function classifying the errors to be
+// retried
+func WithRetryClassifier(fn func(err error) bool) RetryOption {
+	return func(opts *RetryOptions) {
+		opts.IsTransient = fn
+	}
+}
+
+func getRetryOptions(opts []RetryOption) *RetryOptions {
+	ropts := &RetryOptions{}
+	for _, opt := range opts {
+		opt(ropts)
+	}
+	if ropts.MaxAttempts <= 0 {
+		ropts.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if ropts.InitialBackoff <= 0 {
+		ropts.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if ropts.MaxBackoff < ropts.InitialBackoff {
+		ropts.MaxBackoff = max(defaultRetryMaxBackoff, ropts.InitialBackoff)
+	}
+	if ropts.IsTransient == nil {
+		ropts.IsTransient = errors.IsRetryable
+	}
+	return ropts
+}
+
+// returns the backoff before the given retry, with jitter
+func (o *RetryOptions) backoff(retry int) time.Duration {
+	backoff := o.InitialBackoff
+	for i := 1; i < retry && backoff < o.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, o.MaxBackoff)
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// retryCollection retries the operations of the underlying collection
+// failing with transient errors
+type retryCollection struct {
+	StoreCollection
+	opts *RetryOptions
+}
+
+// NewRetryCollection returns a handle to the collection retrying the
+// operations failing with transient errors with exponential backoff,
+// returning errors.Unavailable once the attempts are exhausted.
+// Watch is not covered, as it re-establishes the stream on its own.
+// Note: a write failing with a network error may have been applied,
+// where the retry of InsertOne can report AlreadyExists, and the retry of
+// a non idempotent update may apply it twice.
+func NewRetryCollection(col StoreCollection, opts ...RetryOption) StoreCollection {
+	if rc, ok := col.(*retryCollection); ok {
+		col = rc.StoreCollection
+	}
+	return &retryCollection{
+		StoreCollection: col,
+		opts:            getRetryOptions(opts),
+	}
+}
+
+// runs the operation, retrying on transient errors
+func (c *retryCollection) run(ctx context.Context, op string, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || !c.opts.IsTransient(err) {
+			return err
+		}
+		if attempt >= c.opts.MaxAttempts {
+			break
+		}
+		backoff := c.opts.backoff(attempt)
+		log.Printf("%s failed with transient error, retrying in %s: %s", op, backoff, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+	}
+	return errors.Wrapf(errors.Unavailable, "%s failed after %d attempts: %s", op, c.opts.MaxAttempts, err)
+}
+
+func (c *retryCollection) InsertOne(ctx context.Context, key any, data any, opts ...any) error {
+	return c.run(ctx, "InsertOne", func() error {
+		return c.StoreCollection.InsertOne(ctx, key, data, opts...)
+	})
+}
+
+func (c *retryCollection) UpdateOne(ctx context.Context, key any, data any, upsert bool, opts ...any) error {
+	return c.run(ctx, "UpdateOne", func() error {
+		return c.StoreCollection.UpdateOne(ctx, key, data, upsert, opts...)
+	})
+}
+
+func (c *retryCollection) FindOne(ctx context.Context, key any, data any, opts ...any) error {
+	return c.run(ctx, "FindOne", func() error {
+		return c.StoreCollection.FindOne(ctx, key, data, opts...)
+	})
+}
+
+func (c *retryCollection) FindOneAndUpdate(ctx context.Context, key any, update any, data any, opts ...any) error {
+	return c.run(ctx, "FindOneAndUpdate", func() error {
+		return c.StoreCollection.FindOneAndUpdate(ctx, key, update, data, opts...)
+	})
+}
+
+func (c *retryCollection) FindOneAndDelete(ctx context.Context, filter any, data any, opts ...any) error {
+	return c.run(ctx, "FindOneAndDelete", func() error {
+		return c.StoreCollection.FindOneAndDelete(ctx, filter, data, opts...)
+	})
+}
+
+func (c *retryCollection) FindMany(ctx context.Context, filter any, data any, opts ...any) error {
+	return c.run(ctx, "FindMany", func() error {
+		return c.StoreCollection.FindMany(ctx, filter, data, opts...)
+	})
+}
+
+func (c *retryCollection) Count(ctx context.Context, filter any, opts ...any) (int64, error) {
+	var count int64
+	err := c.run(ctx, "Count", func() error {
+		var err error
+		count, err = c.StoreCollection.Count(ctx, filter, opts...)
+		return err
+	})
+	return count, err
+}
+
+func (c *retryCollection) DeleteOne(ctx context.Context, key any, opts ...any) error {
+	return c.run(ctx, "DeleteOne", func() error {
+		return c.StoreCollection.DeleteOne(ctx, key, opts...)
+	})
+}
+
+func (c *retryCollection) DeleteMany(ctx context.Context, filter any, opts ...any) (int64, error) {
+	var count int64
+	err := c.run(ctx, "DeleteMany", func() error {
+		var err error
+		count, err = c.StoreCollection.DeleteMany(ctx, filter, opts...)
+		return err
+	})
+	return count, err
+}
+
+func (c *retryCollection) Aggregate(ctx context.Context, pipeline any, result any, opts ...any) error {
+	return c.run(ctx, "Aggregate", func() error {
+		return c.StoreCollection.Aggregate(ctx, pipeline, result, opts...)
+	})
+}
+
+func (c *retryCollection) EnsureIndexes(ctx context.Context, indexes []IndexDefinition) error {
+	return c.run(ctx, "EnsureIndexes", func() error {
+		return c.StoreCollection.EnsureIndexes(ctx, indexes)
+	})
+}
+
+func (c *retryCollection) CreateIndex(ctx context.Context, index IndexDefinition) (string, error) {
+	var name string
+	err := c.run(ctx, "CreateIndex", func() error {
+		var err error
+		name, err = c.StoreCollection.CreateIndex(ctx, index)
+		return err
+	})
+	return name, err
+}
+
+func (c *retryCollection) ListIndexes(ctx context.Context) ([]IndexDefinition, error) {
+	var indexes []IndexDefinition
+	err := c.run(ctx, "ListIndexes", func() error {
+		var err error
+		indexes, err = c.StoreCollection.ListIndexes(ctx)
+		return err
+	})
+	return indexes, err
+}
+
+func (c *retryCollection) DropIndex(ctx context.Context, name string) error {
+	return c.run(ctx, "DropIndex", func() error {
+		return c.StoreCollection.DropIndex(ctx, name)
+	})
+}
+
+// WithConsistency returns a handle to the same collection using the
+// given consistency options, retaining the retry policy
+func (c *retryCollection) WithConsistency(opts ConsistencyOptions) (StoreCollection, error) {
+	col, err := c.StoreCollection.WithConsistency(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &retryCollection{
+		StoreCollection: col,
+		opts:            c.opts,
+	}, nil
+}
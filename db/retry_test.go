@@ -0,0 +1,94 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// flakyCollection fails the FindOne with the given error for the
+// configured number of calls
+type flakyCollection struct {
+	StoreCollection
+	failures int
+	calls    int
+	err      error
+}
+
+func (c *flakyCollection) FindOne(ctx context.Context, key any, data any, opts ...any) error {
+	c.calls++
+	if c.calls <= c.failures {
+		return c.err
+	}
+	return c.StoreCollection.FindOne(ctx, key, data, opts...)
+}
+
+func Test_RetryCollection(t *testing.T) {
+	ctx := context.Background()
+	key := &MyKey{Name: "key-1"}
+	mem := NewMemoryCollection("retry")
+	if err := mem.InsertOne(ctx, key, &memTestData{Desc: "first"}); err != nil {
+		t.Fatalf("failed to insert: %s", err)
+	}
+	writeConflict := mongo.CommandError{Code: 112, Name: "WriteConflict"}
+
+	t.Run("classification", func(t *testing.T) {
+		if !isTransientError(writeConflict) {
+			t.Errorf("expected write conflict to be transient")
+		}
+		if !isTransientError(mongo.CommandError{Code: 1, Labels: []string{"RetryableWriteError"}}) {
+			t.Errorf("expected retryable write error to be transient")
+		}
+		if isTransientError(mongo.CommandError{Code: 11000}) || isTransientError(errors.Wrap(errors.NotFound, "missing")) {
+			t.Errorf("expected non transient errors to be classified as such")
+		}
+	})
+
+	t.Run("recovers", func(t *testing.T) {
+		flaky := &flakyCollection{StoreCollection: mem, failures: 2, err: writeConflict}
+		col := NewRetryCollection(flaky, WithRetryBackoff(time.Millisecond, 2*time.Millisecond))
+		data := &memTestData{}
+		if err := col.FindOne(ctx, key, data); err != nil || data.Desc != "first" {
+			t.Errorf("expected find to succeed after retries, got %v, err %v", data, err)
+		}
+		if flaky.calls != 3 {
+			t.Errorf("expected 3 attempts, got %d", flaky.calls)
+		}
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		flaky := &flakyCollection{StoreCollection: mem, failures: 10, err: writeConflict}
+		col := NewRetryCollection(flaky, WithRetryMaxAttempts(3), WithRetryBackoff(time.Millisecond, 2*time.Millisecond))
+		err := col.FindOne(ctx, key, &memTestData{})
+		if !errors.IsUnavailable(err) || flaky.calls != 3 {
+			t.Errorf("expected unavailable after 3 attempts, got %v after %d", err, flaky.calls)
+		}
+	})
+
+	t.Run("permanent", func(t *testing.T) {
+		flaky := &flakyCollection{StoreCollection: mem, failures: 10, err: errors.Wrap(errors.InvalidArgument, "bad")}
+		col := NewRetryCollection(flaky)
+		err := col.FindOne(ctx, key, &memTestData{})
+		if !errors.IsInvalidArgument(err) || flaky.calls != 1 {
+			t.Errorf("expected no retries for permanent error, got %v after %d", err, flaky.calls)
+		}
+	})
+
+	t.Run("consistency", func(t *testing.T) {
+		col := NewRetryCollection(mem)
+		consistent, err := col.WithConsistency(ConsistencyOptions{})
+		if err != nil {
+			t.Fatalf("failed to apply consistency: %s", err)
+		}
+		if _, ok := consistent.(*retryCollection); !ok {
+			t.Errorf("expected retry policy to be retained, got %T", consistent)
+		}
+	})
+}
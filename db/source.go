@@ -40,9 +40,29 @@ func SetSourceIdentifier(identifier string) bool {
 
 // for internal use only, it will also make source identifier in use
 func GetSourceIdentifier() string {
+	identifier, _ := useSourceIdentifier()
+	return identifier
+}
+
+// returns the source identifier in use, along with whether it was set
+// explicitly, marking it in use
+func useSourceIdentifier() (string, bool) {
+	sourceIdentifierLock.Lock()
+	defer sourceIdentifierLock.Unlock()
+	sourceIdentifierUsed = true
+	if sourceIdentifier != "" {
+		return sourceIdentifier, true
+	}
+	return defaultSourceIdentifier, false
+}
+
+// GetEffectiveSourceIdentifier returns the source identifier the library
+// uses (or will use) for the connections, ie. the one set explicitly or
+// the default one, for diagnostics. Unlike GetSourceIdentifier it does
+// not mark the identifier in use
+func GetEffectiveSourceIdentifier() string {
 	sourceIdentifierLock.RLock()
 	defer sourceIdentifierLock.RUnlock()
-	sourceIdentifierUsed = true
 	if sourceIdentifier != "" {
 		return sourceIdentifier
 	}
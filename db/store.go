@@ -20,6 +20,11 @@ type IndexType int
 const (
 	IndexAscending  IndexType = 1
 	IndexDescending IndexType = -1
+
+	// IndexText marks the field as part of the text index of the
+	// collection, used for $text search queries, a collection can have
+	// only one text index which may include multiple fields
+	IndexText IndexType = 2
 )
 
 // IndexField represents a single field within an index definition.
@@ -44,12 +49,17 @@ type IndexDefinition struct {
 	TTL time.Duration
 	// Name is an optional name for the index. Auto-generated by MongoDB if empty.
 	Name string
+	// PartialFilter restricts the index to the documents matching the
+	// filter expression, nil indexes all the documents.
+	PartialFilter any
 }
 
 // WatchCallbackfn responsible for
 type WatchCallbackfn func(op string, key any)
 
 // interface definition for a collection in store
+// operations reading or writing entries accept ConsistencyOptions along
+// with their options, overriding the consistency for that call only
 type StoreCollection interface {
 	// Set KeyType for the collection, this is not mandatory
 	// while the key type will be used by the interface implementer
@@ -61,37 +71,55 @@ type StoreCollection interface {
 	SetKeyType(keyType reflect.Type) error
 
 	// insert one entry to the collection for the given key and data
-	InsertOne(ctx context.Context, key any, data any) error
+	InsertOne(ctx context.Context, key any, data any, opts ...any) error
 
 	// update one entry in the collection for the given key and data
 	// if upsert flag is set, it would insert an entry if it doesn't
 	// exist while updating
-	UpdateOne(ctx context.Context, key any, data any, upsert bool) error
+	UpdateOne(ctx context.Context, key any, data any, upsert bool, opts ...any) error
 
 	// Find one entry from the store collection for the given key, where the data
 	// value is returned based on the object type passed to it
-	FindOne(ctx context.Context, key any, data any) error
+	FindOne(ctx context.Context, key any, data any, opts ...any) error
+
+	// Find one entry from the store collection for the given key and atomically
+	// apply the update document to it, where the data value is returned based
+	// on the object type passed to it. Depending on the options passed, data
+	// holds the state of the entry either before or after the update.
+	// returns NotFound error if no entry existed before, while working with
+	// options returning the entry state before update. UpdateCondition
+	// passed along with the options applies the update only if the entry
+	// matches its filter, returning NotFound otherwise
+	FindOneAndUpdate(ctx context.Context, key any, update any, data any, opts ...any) error
+
+	// Find one entry from the store collection matching the given filter and
+	// atomically delete it, where the data value holds the deleted entry
+	// based on the object type passed to it
+	// returns NotFound error if no entry matches the filter
+	FindOneAndDelete(ctx context.Context, filter any, data any, opts ...any) error
 
 	// Find multiple entries from the store collection for the given filter, where the data
 	// value is returned as a list based on the object type passed to it
 	FindMany(ctx context.Context, filter any, data any, opts ...any) error
 
 	// Return count of entries matching the provided filter
-	Count(ctx context.Context, filter any) (int64, error)
+	Count(ctx context.Context, filter any, opts ...any) (int64, error)
 
 	// remove one entry from the collection matching the given key
-	DeleteOne(ctx context.Context, key any) error
+	DeleteOne(ctx context.Context, key any, opts ...any) error
 
 	// Delete Many entries matching the delete criteria
 	// returns number of entries deleted and if there is any error processing the request
-	DeleteMany(ctx context.Context, filter any) (int64, error)
+	DeleteMany(ctx context.Context, filter any, opts ...any) (int64, error)
 
 	// watch allows getting notified whenever a change happens to a document
 	// in the collection
 	// allow provisiong for a filter to be passed on, where the callback
 	// function to receive only conditional notifications of the events
 	// listener is interested about
-	Watch(ctx context.Context, filter any, cb WatchCallbackfn) error
+	// watch is re-established on failures, where the options allow
+	// handling of irrecoverable errors and lost changes
+	Watch(ctx context.Context, filter any, cb WatchCallbackfn, opts ...WatchOption) error
 
 	// Aggregate runs a MongoDB aggregation pipeline against the collection
 	// and decodes all result documents into the provided result pointer
@@ -102,7 +130,27 @@ type StoreCollection interface {
 	// don't already exist. This operation is idempotent.
 	EnsureIndexes(ctx context.Context, indexes []IndexDefinition) error
 
+	// CreateIndex creates the index on the collection if it doesn't exist
+	// already, returning the name of the index, which is generated from
+	// the fields if not specified
+	CreateIndex(ctx context.Context, index IndexDefinition) (string, error)
+
+	// ListIndexes returns the indexes present on the collection,
+	// including the default index on `_id`
+	ListIndexes(ctx context.Context) ([]IndexDefinition, error)
+
+	// DropIndex removes the index with the given name from the collection
+	// returns NotFound error if the index doesn't exist
+	DropIndex(ctx context.Context, name string) error
+
+	// WithConsistency returns a handle to the same collection using the
+	// given read preference, read concern and write concern instead of
+	// the client defaults, the collection it is invoked on is unaffected
+	WithConsistency(opts ConsistencyOptions) (StoreCollection, error)
+
 	startEventLogger(ctx context.Context, eventType reflect.Type, timestamp *bson.Timestamp) error
+
+	watchEvents(ctx context.Context, filter any, eventType reflect.Type, cb func(event any), wopts *WatchOptions) error
 }
 
 // interface definition for a store, responsible for holding group
@@ -111,6 +159,22 @@ type Store interface {
 	// Gets collection corresponding to the collection name
 	GetCollection(col string) StoreCollection
 
+	// CreateCollection explicitly creates the collection with the given
+	// options, eg. capped or time-series collection, which can't be
+	// created implicitly on first use
+	// returns AlreadyExists error if the collection exists
+	CreateCollection(ctx context.Context, name string, opts CollectionOptions) (StoreCollection, error)
+
+	// SetValidator installs the $jsonSchema validation rules on the
+	// collection, creating the collection if it doesn't exist, so that
+	// the malformed documents are rejected by the database with the
+	// InvalidArgument error, nil schema removes the validation
+	SetValidator(ctx context.Context, col string, schema any) error
+
+	// GetBlobStore returns the store for the large binary objects in the
+	// given bucket, eg. artifacts larger than the document size limit
+	GetBlobStore(bucket string, opts ...BlobStoreOption) (BlobStore, error)
+
 	// Gets Name of the database corresponding to the store
 	Name() string
 }
@@ -129,4 +193,24 @@ type StoreClient interface {
 	// error if error is nil the health of the DB store can be
 	// considered healthy
 	HealthCheck(ctx context.Context) error
+
+	// HealthReport returns the detailed health of the store, including
+	// the reachability and replication lag of the members if available,
+	// the report is always returned while the error is set only if the
+	// store is unreachable
+	HealthReport(ctx context.Context) (*HealthReport, error)
+
+	// WithTransaction runs the function in a transaction, where all the
+	// collection operations executed with the context passed to the
+	// function are part of the transaction, committed only if the
+	// function returns nil and aborted otherwise.
+	// The function may be retried on transient transaction errors, so
+	// it needs to be safe to run more than once
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// Disconnect stops the watches started on the collections derived
+	// from the client, waiting for them to complete until the context is
+	// done, and releases the connections, the client must not be used
+	// afterwards
+	Disconnect(ctx context.Context) error
 }
@@ -0,0 +1,212 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// server error code, when the document fails the validation
+const mongoDocumentValidationFailure = 121
+
+// returns the bson type alias of the value, as used by $jsonSchema
+func bsonTypeOf(val any) string {
+	switch val.(type) {
+	case nil:
+		return "null"
+	case bson.D:
+		return "object"
+	case bson.A:
+		return "array"
+	case string:
+		return "string"
+	case int32:
+		return "int"
+	case int64:
+		return "long"
+	case float64:
+		return "double"
+	case bson.Decimal128:
+		return "decimal"
+	case bool:
+		return "bool"
+	case bson.DateTime:
+		return "date"
+	case bson.ObjectID:
+		return "objectId"
+	case bson.Binary:
+		return "binData"
+	case bson.Timestamp:
+		return "timestamp"
+	}
+	return "unknown"
+}
+
+// returns true if the value is of one of the given types, where the
+// types are either bson type aliases (bsonType) or json types (type)
+func matchSchemaType(val any, types any, json bool) bool {
+	names := []string{}
+	switch t := types.(type) {
+	case string:
+		names = append(names, t)
+	case bson.A:
+		for _, n := range t {
+			if s, ok := n.(string); ok {
+				names = append(names, s)
+			}
+		}
+	}
+	actual := bsonTypeOf(val)
+	for _, name := range names {
+		switch {
+		case name == actual:
+			return true
+		case name == "number" && (actual == "int" || actual == "long" || actual == "double" || actual == "decimal"):
+			return true
+		case json && name == "boolean" && actual == "bool":
+			return true
+		}
+	}
+	return false
+}
+
+// validates the document against the $jsonSchema, supporting the
+// commonly used keywords: bsonType, type, required, properties,
+// additionalProperties, enum, minimum, maximum, minLength, maxLength,
+// pattern, items, minItems and maxItems
+func validateSchema(schema bson.D, val any, path string) error {
+	for _, kw := range schema {
+		var err error
+		switch kw.Key {
+		case "bsonType", "type":
+			if !matchSchemaType(val, kw.Value, kw.Key == "type") {
+				err = fmt.Errorf("expected type %v, got %s", kw.Value, bsonTypeOf(val))
+			}
+		case "required":
+			doc, ok := val.(bson.D)
+			list, _ := kw.Value.(bson.A)
+			for _, name := range list {
+				if !ok {
+					break
+				}
+				field, _ := name.(string)
+				if !slices.ContainsFunc(doc, func(e bson.E) bool { return e.Key == field }) {
+					err = fmt.Errorf("missing required field %q", field)
+					break
+				}
+			}
+		case "properties":
+			doc, ok := val.(bson.D)
+			props, _ := kw.Value.(bson.D)
+			for _, prop := range props {
+				if !ok {
+					break
+				}
+				sub, _ := prop.Value.(bson.D)
+				for _, e := range doc {
+					if e.Key == prop.Key {
+						if err := validateSchema(sub, e.Value, joinPath(path, e.Key)); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		case "additionalProperties":
+			doc, ok := val.(bson.D)
+			if allowed, isBool := kw.Value.(bool); ok && isBool && !allowed {
+				props, _ := lookupField(schema, "properties")
+				known, _ := props.(bson.D)
+				for _, e := range doc {
+					if e.Key == "_id" {
+						continue
+					}
+					if !slices.ContainsFunc(known, func(p bson.E) bool { return p.Key == e.Key }) {
+						err = fmt.Errorf("additional property %q not allowed", e.Key)
+						break
+					}
+				}
+			}
+		case "enum":
+			list, _ := kw.Value.(bson.A)
+			if !slices.ContainsFunc(list, func(item any) bool { return compareValues(item, val) == 0 }) {
+				err = fmt.Errorf("value %v is not one of %v", val, list)
+			}
+		case "minimum", "maximum":
+			if bsonTypeOf(val) == "int" || bsonTypeOf(val) == "long" || bsonTypeOf(val) == "double" {
+				cmp := compareValues(val, kw.Value)
+				if (kw.Key == "minimum" && cmp < 0) || (kw.Key == "maximum" && cmp > 0) {
+					err = fmt.Errorf("value %v violates %s %v", val, kw.Key, kw.Value)
+				}
+			}
+		case "minLength", "maxLength":
+			if s, ok := val.(string); ok {
+				n := int(toFloat(kw.Value))
+				if (kw.Key == "minLength" && len([]rune(s)) < n) || (kw.Key == "maxLength" && len([]rune(s)) > n) {
+					err = fmt.Errorf("length of %q violates %s %d", s, kw.Key, n)
+				}
+			}
+		case "pattern":
+			if s, ok := val.(string); ok {
+				pattern, _ := kw.Value.(string)
+				re, rerr := regexp.Compile(pattern)
+				if rerr != nil {
+					return errors.Wrapf(errors.InvalidArgument, "invalid pattern %q in schema: %s", pattern, rerr)
+				}
+				if !re.MatchString(s) {
+					err = fmt.Errorf("value %q does not match pattern %q", s, pattern)
+				}
+			}
+		case "minItems", "maxItems":
+			if list, ok := val.(bson.A); ok {
+				n := int(toFloat(kw.Value))
+				if (kw.Key == "minItems" && len(list) < n) || (kw.Key == "maxItems" && len(list) > n) {
+					err = fmt.Errorf("number of items %d violates %s %d", len(list), kw.Key, n)
+				}
+			}
+		case "items":
+			list, ok := val.(bson.A)
+			sub, isDoc := kw.Value.(bson.D)
+			if ok && isDoc {
+				for i, item := range list {
+					if err := validateSchema(sub, item, fmt.Sprintf("%s.%d", path, i)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if err != nil {
+			if path == "" {
+				path = "document"
+			}
+			return errors.Wrapf(errors.InvalidArgument, "Document failed validation, %s: %s", path, err)
+		}
+	}
+	return nil
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// converts the schema passed by the caller to the document used for the
+// validation
+func toSchema(schema any) (bson.D, error) {
+	if schema == nil {
+		return nil, errors.Wrap(errors.InvalidArgument, "validator schema must not be nil")
+	}
+	doc, err := toDocument(schema)
+	if err != nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "invalid validator schema: %s", err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,132 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// default number of consecutive attempts to re-establish the watch
+	// before considering the failure irrecoverable
+	defaultWatchMaxRetries = 10
+
+	// initial and maximum backoff between the attempts to re-establish
+	// the watch
+	watchMinBackoff = 100 * time.Millisecond
+	watchMaxBackoff = 30 * time.Second
+)
+
+// WatchOptions provides the optional handling of the watch failures
+type WatchOptions struct {
+	// ErrorCallback is invoked with the error once the watch can no
+	// longer be re-established, the watch is stopped afterwards.
+	// Default: nil (only logged, the watch is stopped)
+	ErrorCallback func(err error)
+
+	// ResyncCallback is invoked when the watch is re-established without
+	// the history of the changes since it was interrupted, eg. when the
+	// resume token is no longer available in the oplog, where the changes
+	// in between are lost and the listener needs to resync its state
+	// with the collection.
+	// Default: nil (only logged)
+	ResyncCallback func()
+
+	// MaxRetries is the number of consecutive attempts to re-establish
+	// the watch, before considering the failure irrecoverable.
+	// Default: 10
+	MaxRetries int
+
+	// ResumeAfter is the resume token of an event delivered earlier, the
+	// watch starts with the changes following that event, instead of the
+	// current time. Only mongo retains the history, other stores start
+	// from the current time signaling resync.
+	// Default: nil (start from the current time)
+	ResumeAfter bson.Raw
+}
+
+// WatchOption is a functional option for configuring Watch
+type WatchOption func(*WatchOptions)
+
+// WithWatchErrorCallback sets the callback for the irrecoverable errors
+// of the watch
+func WithWatchErrorCallback(fn func(err error)) WatchOption {
+	return func(opts *WatchOptions) {
+		opts.ErrorCallback = fn
+	}
+}
+
+// WithWatchResyncCallback sets the callback invoked when the changes are
+// lost while re-establishing the watch
+func WithWatchResyncCallback(fn func()) WatchOption {
+	return func(opts *WatchOptions) {
+		opts.ResyncCallback = fn
+	}
+}
+
+// WithWatchMaxRetries sets the number of consecutive attempts to
+// re-establish the watch
+func WithWatchMaxRetries(retries int) WatchOption {
+	return func(opts *WatchOptions) {
+		opts.MaxRetries = retries
+	}
+}
+
+// WithWatchResumeAfter starts the watch after the event with the given
+// resume token, see Event.Token
+func WithWatchResumeAfter(token bson.Raw) WatchOption {
+	return func(opts *WatchOptions) {
+		opts.ResumeAfter = token
+	}
+}
+
+func getWatchOptions(opts []WatchOption) *WatchOptions {
+	wopts := &WatchOptions{}
+	for _, opt := range opts {
+		opt(wopts)
+	}
+	if wopts.MaxRetries <= 0 {
+		wopts.MaxRetries = defaultWatchMaxRetries
+	}
+	return wopts
+}
+
+// reports the irrecoverable error of the watch
+func (o *WatchOptions) fail(name string, err error) {
+	log.Printf("watch on %s stopped due to irrecoverable error: %s", name, err)
+	if o.ErrorCallback != nil {
+		o.ErrorCallback(err)
+	}
+}
+
+// signals that the changes are lost while re-establishing the watch
+func (o *WatchOptions) resync(name string) {
+	log.Printf("watch on %s re-established without history, changes in between are lost", name)
+	if o.ResyncCallback != nil {
+		o.ResyncCallback()
+	}
+}
+
+// signals resync if the watch was asked to resume, for the stores that
+// don't retain the history of the changes
+func (o *WatchOptions) resumeUnsupported(name string) {
+	if o.ResumeAfter != nil {
+		o.resync(name)
+	}
+}
+
+// returns the backoff before the given attempt to re-establish the watch
+func watchBackoff(attempt int) time.Duration {
+	backoff := watchMinBackoff
+	for i := 1; i < attempt && backoff < watchMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > watchMaxBackoff {
+		backoff = watchMaxBackoff
+	}
+	return backoff
+}
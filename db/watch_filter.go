@@ -0,0 +1,98 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// change event operation types, as reported to the watch callback
+const (
+	WatchOpInsert  = "insert"
+	WatchOpUpdate  = "update"
+	WatchOpReplace = "replace"
+	WatchOpDelete  = "delete"
+)
+
+// WatchFilterBuilder builds the filter pipeline for Watch and
+// WatchEvents, where all the conditions added need to be satisfied by the
+// change event for it to be notified
+type WatchFilterBuilder struct {
+	conds []bson.D
+}
+
+// WatchFilter starts building the filter pipeline for Watch
+//
+//	filter := db.WatchFilter().Ops(db.WatchOpInsert, db.WatchOpDelete).Build()
+//	filter := db.WatchFilter().Ops(db.WatchOpUpdate).FieldChanged("status").Build()
+func WatchFilter() *WatchFilterBuilder {
+	return &WatchFilterBuilder{}
+}
+
+// Ops restricts the notifications to the given operation types
+func (b *WatchFilterBuilder) Ops(ops ...string) *WatchFilterBuilder {
+	if len(ops) == 1 {
+		b.conds = append(b.conds, bson.D{{Key: "operationType", Value: ops[0]}})
+		return b
+	}
+	list := bson.A{}
+	for _, op := range ops {
+		list = append(list, op)
+	}
+	b.conds = append(b.conds, bson.D{{Key: "operationType", Value: bson.D{{Key: "$in", Value: list}}}})
+	return b
+}
+
+// FieldChanged restricts the update notifications to the ones setting or
+// removing any of the given fields of the document, while the other
+// operations are not affected, as they change the document as a whole
+func (b *WatchFilterBuilder) FieldChanged(fields ...string) *WatchFilterBuilder {
+	or := bson.A{bson.D{{Key: "operationType", Value: bson.D{{Key: "$ne", Value: WatchOpUpdate}}}}}
+	for _, field := range fields {
+		or = append(or,
+			bson.D{{Key: "updateDescription.updatedFields." + field, Value: bson.D{{Key: "$exists", Value: true}}}},
+			bson.D{{Key: "updateDescription.removedFields", Value: field}},
+		)
+	}
+	b.conds = append(b.conds, bson.D{{Key: "$or", Value: or}})
+	return b
+}
+
+// Key restricts the notifications to the entries whose key has the
+// given field values, where the field names are as stored in the key
+// document
+func (b *WatchFilterBuilder) Key(fields ...bson.E) *WatchFilterBuilder {
+	cond := bson.D{}
+	for _, f := range fields {
+		cond = append(cond, bson.E{Key: "documentKey." + KeyField(f.Key), Value: f.Value})
+	}
+	b.conds = append(b.conds, cond)
+	return b
+}
+
+// Match restricts the notifications to the entries whose full document
+// has the field matching the condition, either a value or an operator
+// document, eg. {$in: [...]}. Deletes don't carry the full document and
+// are not notified.
+func (b *WatchFilterBuilder) Match(field string, cond any) *WatchFilterBuilder {
+	b.conds = append(b.conds, bson.D{{Key: "fullDocument." + field, Value: cond}})
+	return b
+}
+
+// Build returns the filter pipeline to be passed to Watch, nil if no
+// conditions were added
+func (b *WatchFilterBuilder) Build() mongo.Pipeline {
+	switch len(b.conds) {
+	case 0:
+		return nil
+	case 1:
+		return mongo.Pipeline{{{Key: "$match", Value: b.conds[0]}}}
+	}
+	and := bson.A{}
+	for _, cond := range b.conds {
+		and = append(and, cond)
+	}
+	return mongo.Pipeline{{{Key: "$match", Value: bson.D{{Key: "$and", Value: and}}}}}
+}
@@ -0,0 +1,82 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func Test_WatchFilter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// watches the collection with the filter, returning the notified
+	// operations and keys once all the changes are applied
+	watch := func(t *testing.T, filter any, count int) []string {
+		col := NewMemoryCollection("watch-filter")
+		_ = col.SetKeyType(reflect.TypeOf(&MyKey{}))
+		var mu sync.Mutex
+		events := []string{}
+		err := col.Watch(ctx, filter, func(op string, key any) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, op+":"+key.(*MyKey).Name)
+		})
+		if err != nil {
+			t.Fatalf("failed to watch with filter: %s", err)
+		}
+		_ = col.InsertOne(ctx, &MyKey{Name: "a"}, &memTestData{Desc: "a"})
+		_ = col.InsertOne(ctx, &MyKey{Name: "b"}, &memTestData{Desc: "b"})
+		_ = col.UpdateOne(ctx, &MyKey{Name: "a"}, &memTestData{Desc: "a", Count: 1}, false)
+		_ = col.UpdateOne(ctx, &MyKey{Name: "b"}, &memTestData{Desc: "changed"}, false)
+		_ = col.DeleteOne(ctx, &MyKey{Name: "a"})
+		for range 100 {
+			mu.Lock()
+			n := len(events)
+			mu.Unlock()
+			if n >= count {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		// allow any unexpected events to be delivered
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		return events
+	}
+
+	cases := []struct {
+		name     string
+		filter   *WatchFilterBuilder
+		expected []string
+	}{
+		{"ops", WatchFilter().Ops(WatchOpDelete), []string{"delete:a"}},
+		{"multiple_ops", WatchFilter().Ops(WatchOpInsert, WatchOpDelete), []string{"insert:a", "insert:b", "delete:a"}},
+		{"field_changed", WatchFilter().Ops(WatchOpUpdate).FieldChanged("desc"), []string{"update:b"}},
+		{"field_changed_other_ops", WatchFilter().FieldChanged("count"), []string{"insert:a", "insert:b", "update:a", "delete:a"}},
+		{"key", WatchFilter().Key(bson.E{Key: "name", Value: "b"}), []string{"insert:b", "update:b"}},
+		{"match", WatchFilter().Match("desc", "a"), []string{"insert:a", "update:a"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			events := watch(t, tc.filter.Build(), len(tc.expected))
+			if !reflect.DeepEqual(events, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, events)
+			}
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		if pipeline := WatchFilter().Build(); pipeline != nil {
+			t.Errorf("expected nil pipeline without conditions, got %v", pipeline)
+		}
+	})
+}
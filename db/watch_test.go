@@ -0,0 +1,85 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package db
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+func Test_WatchOptions(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		opts := getWatchOptions(nil)
+		if opts.MaxRetries != defaultWatchMaxRetries {
+			t.Errorf("expected default max retries, got %d", opts.MaxRetries)
+		}
+	})
+
+	t.Run("callbacks", func(t *testing.T) {
+		var failed error
+		resynced := false
+		opts := getWatchOptions([]WatchOption{
+			WithWatchErrorCallback(func(err error) { failed = err }),
+			WithWatchResyncCallback(func() { resynced = true }),
+			WithWatchMaxRetries(3),
+		})
+		if opts.MaxRetries != 3 {
+			t.Errorf("expected max retries 3, got %d", opts.MaxRetries)
+		}
+		opts.fail("test", errors.Wrap(errors.Unknown, "stream failed"))
+		if failed == nil {
+			t.Errorf("expected error callback to be invoked")
+		}
+		opts.resync("test")
+		if !resynced {
+			t.Errorf("expected resync callback to be invoked")
+		}
+	})
+
+	t.Run("no_error_callback", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("expected irrecoverable error to be logged, got panic: %v", r)
+			}
+		}()
+		opts := getWatchOptions(nil)
+		opts.fail("test", errors.Wrap(errors.Unknown, "stream failed"))
+	})
+
+	t.Run("history_lost", func(t *testing.T) {
+		lost := mongo.CommandError{Code: mongoChangeStreamHistoryLost}
+		if !isChangeStreamHistoryLost(lost) {
+			t.Errorf("expected history lost to be detected")
+		}
+		if !isChangeStreamHistoryLost(fmt.Errorf("resume failed: %w", lost)) {
+			t.Errorf("expected history lost to be detected on wrapped error")
+		}
+		if isChangeStreamHistoryLost(mongo.CommandError{Code: 11000}) {
+			t.Errorf("expected other server errors to be resumable")
+		}
+		if isChangeStreamHistoryLost(errors.Wrap(errors.Unknown, "stream failed")) {
+			t.Errorf("expected non server errors to be resumable")
+		}
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		if b := watchBackoff(1); b != watchMinBackoff {
+			t.Errorf("expected min backoff for first attempt, got %s", b)
+		}
+		if b := watchBackoff(2); b != 2*watchMinBackoff {
+			t.Errorf("expected doubled backoff, got %s", b)
+		}
+		if b := watchBackoff(100); b != watchMaxBackoff {
+			t.Errorf("expected backoff to be capped at %s, got %s", watchMaxBackoff, b)
+		}
+		if watchMaxBackoff < time.Second {
+			t.Errorf("unexpected max backoff %s", watchMaxBackoff)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package errors
+
+import (
+	base "errors"
+	"strings"
+)
+
+var (
+	// severity of the error codes, where the failures of the service
+	// are more severe than the failures due to the request
+	errCodeSeverity = map[ErrCode]int{
+		NotFound:         1,
+		AlreadyExists:    2,
+		InvalidArgument:  3,
+		Unauthorized:     4,
+		Forbidden:        5,
+		Conflict:         6,
+		DeadlineExceeded: 7,
+		Unavailable:      8,
+		Unknown:          9,
+	}
+)
+
+// Aggregate combines the errors, eg. of the bulk operations partially
+// failing, into a single error carrying the most severe error code of
+// the errors, while retaining the individual errors, available using
+// Errors, and matched by Is and As. The nil errors are skipped, returns
+// nil if there are no errors, and the error as is if there is only one
+func Aggregate(errs []error) error {
+	list := []error{}
+	for _, err := range errs {
+		if err != nil {
+			list = append(list, err)
+		}
+	}
+	switch len(list) {
+	case 0:
+		return nil
+	case 1:
+		return list[0]
+	}
+	code := GetErrCode(list[0])
+	msgs := make([]string, len(list))
+	for i, err := range list {
+		if c := GetErrCode(err); errCodeSeverity[c] > errCodeSeverity[code] {
+			code = c
+		}
+		msgs[i] = err.Error()
+	}
+	return newError(code, strings.Join(msgs, "; "), base.Join(list...))
+}
+
+// Join combines the errors into a single error, same as Aggregate
+func Join(errs ...error) error {
+	return Aggregate(errs)
+}
+
+// Errors returns the individual errors combined into the error, using
+// Aggregate or Join, or the error itself otherwise
+func Errors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(*Error); ok {
+		if multi, ok := e.cause.(interface{ Unwrap() []error }); ok {
+			return multi.Unwrap()
+		}
+	}
+	return []error{err}
+}
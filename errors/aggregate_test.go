@@ -0,0 +1,53 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package errors
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_ErrorAggregate(t *testing.T) {
+	if Aggregate(nil) != nil || Join(nil, nil) != nil || Errors(nil) != nil {
+		t.Errorf("expected no error without errors")
+	}
+	single := Wrap(NotFound, "entry not found")
+	if err := Join(nil, single); err != single {
+		t.Errorf("expected single error as is, got %v", err)
+	}
+
+	err := Join(
+		Wrap(NotFound, "entry 1 not found"),
+		nil,
+		Wrap(Unavailable, "store unavailable"),
+		Wrap(InvalidArgument, "entry 3 invalid"),
+	)
+	if !IsUnavailable(err) {
+		t.Errorf("expected most severe code, got %d", GetErrCode(err))
+	}
+	if err.Error() != "entry 1 not found; store unavailable; entry 3 invalid" {
+		t.Errorf("unexpected message %q", err)
+	}
+	errs := Errors(err)
+	if len(errs) != 3 || !IsNotFound(errs[0]) || !IsInvalidArgument(errs[2]) {
+		t.Errorf("expected individual errors retained, got %v", errs)
+	}
+
+	err = Join(Wrap(Conflict, "write conflict"), Wrap(DeadlineExceeded, "timed out"), Wrap(Forbidden, "not allowed"))
+	if !IsDeadlineExceeded(err) {
+		t.Errorf("expected most severe code, got %d", GetErrCode(err))
+	}
+
+	err = Aggregate([]error{Wrap(AlreadyExists, "entry exists"), context.DeadlineExceeded})
+	if GetErrCode(err) != Unknown || !Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected uncoded errors as unknown and matched, got %d", GetErrCode(err))
+	}
+	var e *Error
+	if !As(err, &e) {
+		t.Errorf("expected aggregate to match the error type")
+	}
+	if errs := Errors(single); len(errs) != 1 || errs[0] != single {
+		t.Errorf("expected error itself for non aggregate, got %v", errs)
+	}
+}
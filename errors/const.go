@@ -25,4 +25,38 @@ const (
 
 	// Forbidden action error
 	Forbidden ErrCode = 5
+
+	// if the service is temporarily unavailable, typically after
+	// exhausting the retries on transient failures
+	Unavailable ErrCode = 6
+
+	// if the operation did not complete before its deadline
+	DeadlineExceeded ErrCode = 7
+
+	// if the operation conflicts with a concurrent change, eg. the write
+	// conflicts or the failed optimistic concurrency checks
+	Conflict ErrCode = 8
 )
+
+var (
+	// names of the error codes
+	errCodeNames = map[ErrCode]string{
+		Unknown:          "Unknown",
+		NotFound:         "NotFound",
+		AlreadyExists:    "AlreadyExists",
+		InvalidArgument:  "InvalidArgument",
+		Unauthorized:     "Unauthorized",
+		Forbidden:        "Forbidden",
+		Unavailable:      "Unavailable",
+		DeadlineExceeded: "DeadlineExceeded",
+		Conflict:         "Conflict",
+	}
+)
+
+// String returns the name of the error code
+func (c ErrCode) String() string {
+	if name, ok := errCodeNames[c]; ok {
+		return name
+	}
+	return "Unknown"
+}
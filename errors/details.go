@@ -0,0 +1,56 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package errors
+
+import (
+	base "errors"
+	"maps"
+)
+
+// WithDetail attaches the key-value detail to the error, eg. the tenant
+// or the entry the error is about, retaining the code and the message of
+// the error, returns nil if the error is nil
+func WithDetail(err error, key string, value any) error {
+	if err == nil {
+		return nil
+	}
+	e, ok := err.(*Error)
+	if !ok {
+		e = newError(GetErrCode(err), err.Error(), err)
+	}
+	details := maps.Clone(e.details)
+	if details == nil {
+		details = map[string]any{}
+	}
+	details[key] = value
+	return &Error{
+		code:    e.code,
+		msg:     e.msg,
+		cause:   e.cause,
+		details: details,
+		stack:   e.stack,
+	}
+}
+
+// Details returns the details attached to the error, along with the
+// ones attached to its causes, where the details attached later take
+// precedence, returns nil if there are no details
+func Details(err error) map[string]any {
+	var details map[string]any
+	for ; err != nil; err = base.Unwrap(err) {
+		e, ok := err.(*Error)
+		if !ok {
+			continue
+		}
+		for k, v := range e.details {
+			if details == nil {
+				details = map[string]any{}
+			}
+			if _, exists := details[k]; !exists {
+				details[k] = v
+			}
+		}
+	}
+	return details
+}
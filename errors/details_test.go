@@ -0,0 +1,46 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func Test_ErrorDetails(t *testing.T) {
+	if WithDetail(nil, "tenant", "t1") != nil || Details(nil) != nil {
+		t.Errorf("expected nil error to remain nil")
+	}
+	if Details(Wrap(NotFound, "entry not found")) != nil {
+		t.Errorf("expected no details")
+	}
+
+	orig := Wrap(NotFound, "entry not found")
+	err := WithDetail(orig, "tenant", "t1")
+	err = WithDetail(err, "entry", 10)
+	if !IsNotFound(err) || err.Error() != "entry not found" {
+		t.Errorf("expected code and message retained, got %d, %q", GetErrCode(err), err)
+	}
+	details := Details(err)
+	if len(details) != 2 || details["tenant"] != "t1" || details["entry"] != 10 {
+		t.Errorf("unexpected details %v", details)
+	}
+	if Details(orig) != nil {
+		t.Errorf("expected original error to be unchanged")
+	}
+
+	// details of the causes, overridden by the outer ones
+	err = Wrapf(Unavailable, "failed: %w", err)
+	err = WithDetail(err, "tenant", "t2")
+	if details := Details(err); len(details) != 2 || details["tenant"] != "t2" || details["entry"] != 10 {
+		t.Errorf("unexpected details %v", details)
+	}
+
+	// details attached to the errors from other packages
+	err = WithDetail(fmt.Errorf("watch: %w", context.Canceled), "collection", "users")
+	if !Is(err, context.Canceled) || Details(err)["collection"] != "users" || err.Error() != "watch: context canceled" {
+		t.Errorf("unexpected error with details %v, %v", err, Details(err))
+	}
+}
@@ -12,20 +12,52 @@ func Is(err error, target error) bool {
 	return base.Is(err, target)
 }
 
-// get the error code if the error is
-// associated to recognizable error types
+func As(err error, target any) bool {
+	return base.As(err, target)
+}
+
+// GetErrCode returns the error code of the error, or of the first error
+// in its chain carrying one, including the ones wrapped using fmt.Errorf,
+// returns Unknown for nil and the errors without an error code
 func GetErrCode(err error) ErrCode {
-	val, ok := err.(*Error)
-	if ok {
+	var val *Error
+	if base.As(err, &val) {
 		return ErrCode(val.code)
 	}
 	return Unknown
 }
 
+// Code returns the error code of the error, same as GetErrCode, allowing
+// the callers to switch on the error codes instead of the messages
+func Code(err error) ErrCode {
+	return GetErrCode(err)
+}
+
 // base error structure
 type Error struct {
 	code ErrCode
 	msg  string
+
+	// original error retained as the cause, if any
+	cause error
+
+	// details attached to the error
+	details map[string]any
+
+	// program counters of the stack where the error is created, if
+	// the stack trace capture is enabled
+	stack []uintptr
+}
+
+// creates the error, capturing the stack trace of the caller of the
+// function invoking it, if enabled
+func newError(code ErrCode, msg string, cause error) *Error {
+	return &Error{
+		code:  code,
+		msg:   msg,
+		cause: cause,
+		stack: callers(),
+	}
 }
 
 // Error() prints out the error message string
@@ -33,28 +65,56 @@ func (e Error) Error() string {
 	return e.msg
 }
 
+// Unwrap returns the original error retained as the cause, allowing
+// errors.Is and errors.As to match the cause, eg. the driver errors
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
 // Creates a new error msg without error code
 func New(msg string) error {
-	return &Error{
-		msg: msg,
-	}
+	return newError(Unknown, msg, nil)
 }
 
 // Wraps the error msg with recognized error codes
 func Wrap(code ErrCode, msg string) error {
-	return &Error{
-		code: code,
-		msg:  msg,
-	}
+	return newError(code, msg, nil)
 }
 
 // Wraps the error msg with recognized error codes
-// using specified message format
+// using specified message format, where the errors
+// formatted using %w are retained as the cause
 func Wrapf(code ErrCode, format string, v ...any) error {
-	return &Error{
-		code: code,
-		msg:  fmt.Sprintf(format, v...),
+	msg, cause := formatMessage(format, v...)
+	return newError(code, msg, cause)
+}
+
+// Newf creates a new error with the error code and the message
+// formatted as per the format specifier, same as Wrapf
+func Newf(code ErrCode, format string, v ...any) error {
+	msg, cause := formatMessage(format, v...)
+	return newError(code, msg, cause)
+}
+
+// formats the message, returning the errors formatted using %w as the
+// cause
+func formatMessage(format string, v ...any) (string, error) {
+	err := fmt.Errorf(format, v...)
+	var cause error
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		cause = e.Unwrap()
+	case interface{ Unwrap() []error }:
+		// multiple causes, retained as is
+		cause = err
 	}
+	return err.Error(), cause
+}
+
+// WrapCause wraps the error with recognized error code
+// and message, retaining the error as the cause
+func WrapCause(code ErrCode, err error, msg string) error {
+	return newError(code, msg+": "+err.Error(), err)
 }
 
 // IsNotFound returns true if err
@@ -86,3 +146,21 @@ func IsUnauthorized(err error) bool {
 func IsForbidden(err error) bool {
 	return GetErrCode(err) == Forbidden
 }
+
+// IsUnavailable returns true if err
+// is due to service being unavailable
+func IsUnavailable(err error) bool {
+	return GetErrCode(err) == Unavailable
+}
+
+// IsDeadlineExceeded returns true if err
+// is due to operation exceeding its deadline
+func IsDeadlineExceeded(err error) bool {
+	return GetErrCode(err) == DeadlineExceeded
+}
+
+// IsConflict returns true if err
+// is due to conflicting concurrent change
+func IsConflict(err error) bool {
+	return GetErrCode(err) == Conflict
+}
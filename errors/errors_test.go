@@ -4,7 +4,10 @@
 package errors
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
+	"strings"
 	"testing"
 )
 
@@ -28,4 +31,68 @@ func Test_ErrorValidations(t *testing.T) {
 	if !IsNotFound(err) {
 		t.Errorf("expected error type Not Found")
 	}
+
+	err = Wrap(Unavailable, "test unavailable error from errors pkg")
+	if !IsUnavailable(err) || IsNotFound(err) {
+		t.Errorf("expected error type Unavailable")
+	}
+}
+
+func Test_ErrorCause(t *testing.T) {
+	cause := context.DeadlineExceeded
+	err := Wrapf(Unavailable, "failed to fetch entry: %w", cause)
+	if !IsUnavailable(err) || err.Error() != "failed to fetch entry: context deadline exceeded" {
+		t.Errorf("unexpected wrapped error %q", err)
+	}
+	if !Is(err, cause) {
+		t.Errorf("expected wrapped error to match the cause")
+	}
+
+	err = Wrapf(NotFound, "%s", cause)
+	if Is(err, cause) {
+		t.Errorf("expected cause to be retained only with %%w")
+	}
+
+	err = WrapCause(Unknown, cause, "failed to watch")
+	if !Is(err, cause) || err.Error() != "failed to watch: context deadline exceeded" {
+		t.Errorf("unexpected wrapped error %q", err)
+	}
+
+	var pathErr *fs.PathError
+	err = Wrapf(Unknown, "failed: %w, %w", cause, &fs.PathError{Op: "open", Path: "x", Err: fs.ErrNotExist})
+	if !Is(err, cause) || !As(err, &pathErr) || !Is(err, fs.ErrNotExist) {
+		t.Errorf("expected all the causes to be retained")
+	}
+
+	// error codes retained through fmt wrapping
+	err = fmt.Errorf("lookup: %w", Wrap(NotFound, "entry not found"))
+	if !IsNotFound(err) {
+		t.Errorf("expected error code through fmt wrapping, got %v", GetErrCode(err))
+	}
+}
+
+func Test_ErrorCode(t *testing.T) {
+	enabled := captureStack.Load()
+	defer EnableStackTrace(enabled)
+	EnableStackTrace(true)
+
+	err := Newf(InvalidArgument, "invalid port %d: %w", 70000, fs.ErrInvalid)
+	if Code(err) != InvalidArgument || err.Error() != "invalid port 70000: invalid argument" {
+		t.Errorf("unexpected error %d, %q", Code(err), err)
+	}
+	if !Is(err, fs.ErrInvalid) {
+		t.Errorf("expected cause to be retained with %%w")
+	}
+	if trace := StackTrace(err); !strings.HasPrefix(trace, "github.com/go-core-stack/core/errors.Test_ErrorCode") {
+		t.Errorf("expected stack trace to start at the caller, got %s", trace)
+	}
+
+	for _, code := range []ErrCode{NotFound, AlreadyExists, Forbidden, Unavailable, DeadlineExceeded, Conflict} {
+		if err := fmt.Errorf("lookup: %w", Wrap(code, "failed")); Code(err) != code {
+			t.Errorf("expected code %s through fmt wrapping, got %s", code, Code(err))
+		}
+	}
+	if Code(nil) != Unknown || Code(fmt.Errorf("failed")) != Unknown {
+		t.Errorf("expected unknown code for nil and the other errors")
+	}
 }
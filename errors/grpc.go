@@ -0,0 +1,86 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package errors
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// gRPC codes for the error codes
+	grpcCodes = map[ErrCode]codes.Code{
+		Unknown:          codes.Unknown,
+		NotFound:         codes.NotFound,
+		AlreadyExists:    codes.AlreadyExists,
+		InvalidArgument:  codes.InvalidArgument,
+		Unauthorized:     codes.Unauthenticated,
+		Forbidden:        codes.PermissionDenied,
+		Unavailable:      codes.Unavailable,
+		DeadlineExceeded: codes.DeadlineExceeded,
+		Conflict:         codes.Aborted,
+	}
+
+	// error codes for the gRPC codes, including the ones without a
+	// matching error code, mapped to the closest one
+	errCodes = map[codes.Code]ErrCode{
+		codes.Unknown:            Unknown,
+		codes.NotFound:           NotFound,
+		codes.AlreadyExists:      AlreadyExists,
+		codes.InvalidArgument:    InvalidArgument,
+		codes.OutOfRange:         InvalidArgument,
+		codes.FailedPrecondition: InvalidArgument,
+		codes.Unauthenticated:    Unauthorized,
+		codes.PermissionDenied:   Forbidden,
+		codes.Unavailable:        Unavailable,
+		codes.DeadlineExceeded:   DeadlineExceeded,
+		codes.Aborted:            Conflict,
+		codes.ResourceExhausted:  Unavailable,
+	}
+)
+
+// GRPCStatus returns the gRPC status for the error, allowing the errors
+// to be returned straight from the RPC handlers
+func (e *Error) GRPCStatus() *status.Status {
+	code, ok := grpcCodes[e.code]
+	if !ok {
+		code = codes.Unknown
+	}
+	return status.New(code, e.msg)
+}
+
+// ToGRPCStatus returns the gRPC status for the error, where the errors
+// with recognized error codes are mapped to the matching gRPC codes,
+// the gRPC errors are retained as is, and any other error is Unknown
+func ToGRPCStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	var e *Error
+	if As(err, &e) {
+		s := e.GRPCStatus()
+		return status.New(s.Code(), err.Error())
+	}
+	if s, ok := status.FromError(err); ok {
+		return s
+	}
+	return status.New(codes.Unknown, err.Error())
+}
+
+// FromGRPCError converts the error returned by a gRPC call to the error
+// with the matching error code, retaining the gRPC error as the cause,
+// returns nil if the error is nil or carries the OK status
+func FromGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return newError(GetErrCode(err), err.Error(), err)
+	}
+	if s.Code() == codes.OK {
+		return nil
+	}
+	return newError(errCodes[s.Code()], s.Message(), err)
+}
@@ -0,0 +1,87 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_GRPCStatus(t *testing.T) {
+	t.Run("to grpc", func(t *testing.T) {
+		tests := []struct {
+			err  error
+			code codes.Code
+		}{
+			{nil, codes.OK},
+			{Wrap(NotFound, "entry not found"), codes.NotFound},
+			{Wrap(AlreadyExists, "entry exists"), codes.AlreadyExists},
+			{Wrap(InvalidArgument, "invalid"), codes.InvalidArgument},
+			{Wrap(Unauthorized, "no credentials"), codes.Unauthenticated},
+			{Wrap(Forbidden, "not allowed"), codes.PermissionDenied},
+			{Wrap(Unavailable, "try later"), codes.Unavailable},
+			{Wrap(DeadlineExceeded, "timed out"), codes.DeadlineExceeded},
+			{Wrap(Conflict, "write conflict"), codes.Aborted},
+			{New("failed"), codes.Unknown},
+			{fmt.Errorf("lookup: %w", Wrap(NotFound, "entry not found")), codes.NotFound},
+			{status.Error(codes.Aborted, "aborted"), codes.Aborted},
+			{context.Canceled, codes.Unknown},
+		}
+		for _, test := range tests {
+			s := ToGRPCStatus(test.err)
+			if s.Code() != test.code {
+				t.Errorf("expected code %s for %v, got %s", test.code, test.err, s.Code())
+			}
+			if test.err == nil || test.code == codes.Aborted {
+				// grpc errors are retained as is
+				continue
+			}
+			if s.Message() != test.err.Error() {
+				t.Errorf("expected message %q, got %q", test.err.Error(), s.Message())
+			}
+		}
+		// returned straight from the handlers
+		if s, _ := status.FromError(Wrap(Forbidden, "not allowed")); s.Code() != codes.PermissionDenied {
+			t.Errorf("expected error to carry the grpc status, got %s", s.Code())
+		}
+	})
+
+	t.Run("from grpc", func(t *testing.T) {
+		if FromGRPCError(nil) != nil || FromGRPCError(status.Error(codes.OK, "")) != nil {
+			t.Errorf("expected no error for OK status")
+		}
+		tests := []struct {
+			code     codes.Code
+			expected ErrCode
+		}{
+			{codes.NotFound, NotFound},
+			{codes.AlreadyExists, AlreadyExists},
+			{codes.FailedPrecondition, InvalidArgument},
+			{codes.Unauthenticated, Unauthorized},
+			{codes.PermissionDenied, Forbidden},
+			{codes.DeadlineExceeded, DeadlineExceeded},
+			{codes.Aborted, Conflict},
+			{codes.ResourceExhausted, Unavailable},
+			{codes.Internal, Unknown},
+		}
+		for _, test := range tests {
+			grpcErr := status.Error(test.code, "downstream failure")
+			err := FromGRPCError(grpcErr)
+			if GetErrCode(err) != test.expected || err.Error() != "downstream failure" {
+				t.Errorf("expected code %d for %s, got %d, %q", test.expected, test.code, GetErrCode(err), err)
+			}
+			if !Is(err, grpcErr) {
+				t.Errorf("expected grpc error retained as cause")
+			}
+		}
+		err := FromGRPCError(Wrap(NotFound, "local"))
+		if !IsNotFound(err) {
+			t.Errorf("expected local error code retained, got %d", GetErrCode(err))
+		}
+	})
+}
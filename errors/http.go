@@ -0,0 +1,51 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+var (
+	// HTTP status codes for the error codes
+	httpStatuses = map[ErrCode]int{
+		Unknown:          http.StatusInternalServerError,
+		NotFound:         http.StatusNotFound,
+		AlreadyExists:    http.StatusConflict,
+		InvalidArgument:  http.StatusBadRequest,
+		Unauthorized:     http.StatusUnauthorized,
+		Forbidden:        http.StatusForbidden,
+		Unavailable:      http.StatusServiceUnavailable,
+		DeadlineExceeded: http.StatusGatewayTimeout,
+		Conflict:         http.StatusConflict,
+	}
+)
+
+// HTTPStatus returns the HTTP status code for the error, as per its
+// error code, http.StatusOK if the error is nil
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	status, ok := httpStatuses[GetErrCode(err)]
+	if !ok {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
+// WriteError writes the error as the HTTP response, with the status as
+// per the error code, and the JSON body providing the code, message and
+// details of the error, encoded same as Marshal
+func WriteError(w http.ResponseWriter, err error) {
+	if err == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	body := toErrorJSON(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(HTTPStatus(err))
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,52 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_HTTPStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{nil, http.StatusOK},
+		{New("failed"), http.StatusInternalServerError},
+		{Wrap(NotFound, "entry not found"), http.StatusNotFound},
+		{Wrap(AlreadyExists, "entry exists"), http.StatusConflict},
+		{Wrap(InvalidArgument, "invalid"), http.StatusBadRequest},
+		{Wrap(Unauthorized, "no credentials"), http.StatusUnauthorized},
+		{Wrap(Forbidden, "not allowed"), http.StatusForbidden},
+		{Wrap(Unavailable, "try later"), http.StatusServiceUnavailable},
+		{Wrap(DeadlineExceeded, "timed out"), http.StatusGatewayTimeout},
+		{Wrap(Conflict, "write conflict"), http.StatusConflict},
+		{fmt.Errorf("lookup: %w", Wrap(NotFound, "entry not found")), http.StatusNotFound},
+	}
+	for _, test := range tests {
+		if status := HTTPStatus(test.err); status != test.status {
+			t.Errorf("expected status %d for %v, got %d", test.status, test.err, status)
+		}
+	}
+
+	t.Run("write error", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		WriteError(rec, WithDetail(Wrapf(NotFound, "entry %s not found", "x"), "tenant", "t1"))
+		if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected response %d, %v", rec.Code, rec.Header())
+		}
+		body := map[string]any{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to decode body: %s", err)
+		}
+		details, _ := body["details"].(map[string]any)
+		if body["code"] != "NotFound" || body["message"] != "entry x not found" || details["tenant"] != "t1" {
+			t.Errorf("unexpected body %v", body)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package errors
+
+import (
+	"encoding/json"
+)
+
+// JSON encoding of the error, stable across the releases, eg.
+//
+//	{"code": "NotFound", "message": "entry not found", "details": {"tenant": "t1"}}
+type errorJSON struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// ParseErrCode returns the error code with the given name, as returned
+// by ErrCode.String, returns Unknown if the name is not recognized
+func ParseErrCode(name string) ErrCode {
+	for code, n := range errCodeNames {
+		if n == name {
+			return code
+		}
+	}
+	return Unknown
+}
+
+// returns the JSON encoding of the error
+func toErrorJSON(err error) *errorJSON {
+	return &errorJSON{
+		Code:    GetErrCode(err).String(),
+		Message: err.Error(),
+		Details: Details(err),
+	}
+}
+
+// returns the error decoded from its JSON encoding
+func fromErrorJSON(v *errorJSON) *Error {
+	return &Error{
+		code:    ParseErrCode(v.Code),
+		msg:     v.Message,
+		details: v.Details,
+	}
+}
+
+// MarshalJSON encodes the error with its code name, message and details
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toErrorJSON(e))
+}
+
+// UnmarshalJSON decodes the error encoded using MarshalJSON, where the
+// codes not recognized are decoded as Unknown, while the cause and the
+// stack trace are not carried across
+func (e *Error) UnmarshalJSON(data []byte) error {
+	v := &errorJSON{}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	*e = *fromErrorJSON(v)
+	return nil
+}
+
+// Marshal encodes any error as JSON, eg. to carry it across the service
+// boundaries in the HTTP headers or the messages, with the code as per
+// GetErrCode and the details attached to the error and its causes
+func Marshal(err error) ([]byte, error) {
+	if err == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(toErrorJSON(err))
+}
+
+// Unmarshal reconstructs the error encoded using Marshal, retaining its
+// code, message and details, where the numbers in the details are
+// decoded as float64, returns nil if the error encoded is nil, along
+// with InvalidArgument error if the data is not a valid encoding
+func Unmarshal(data []byte) (error, error) {
+	v := &errorJSON{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, Wrapf(InvalidArgument, "invalid error encoding: %w", err)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return fromErrorJSON(v), nil
+}
@@ -0,0 +1,76 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func Test_ErrorJSON(t *testing.T) {
+	t.Run("codes", func(t *testing.T) {
+		for code := range errCodeNames {
+			if ParseErrCode(code.String()) != code {
+				t.Errorf("expected code %s to be parsed back", code)
+			}
+		}
+		if ParseErrCode("Bogus") != Unknown {
+			t.Errorf("expected unknown for unrecognized name")
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		err := WithDetail(Wrap(NotFound, "entry not found"), "tenant", "t1")
+		data, merr := Marshal(fmt.Errorf("lookup: %w", err))
+		if merr != nil {
+			t.Fatalf("failed to marshal: %s", merr)
+		}
+		expected := `{"code":"NotFound","message":"lookup: entry not found","details":{"tenant":"t1"}}`
+		if string(data) != expected {
+			t.Errorf("expected encoding %s, got %s", expected, data)
+		}
+		decoded, uerr := Unmarshal(data)
+		if uerr != nil {
+			t.Fatalf("failed to unmarshal: %s", uerr)
+		}
+		if !IsNotFound(decoded) || decoded.Error() != "lookup: entry not found" || Details(decoded)["tenant"] != "t1" {
+			t.Errorf("expected error reconstructed, got %d, %q, %v", GetErrCode(decoded), decoded, Details(decoded))
+		}
+	})
+
+	t.Run("nil and invalid", func(t *testing.T) {
+		data, err := Marshal(nil)
+		if err != nil || string(data) != "null" {
+			t.Errorf("expected null for nil error, got %s, %v", data, err)
+		}
+		if decoded, err := Unmarshal(data); decoded != nil || err != nil {
+			t.Errorf("expected nil error decoded, got %v, %v", decoded, err)
+		}
+		if _, err := Unmarshal([]byte("{bad")); !IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for bad encoding, got %v", err)
+		}
+		decoded, _ := Unmarshal([]byte(`{"code":"Bogus","message":"failed"}`))
+		if GetErrCode(decoded) != Unknown || decoded.Error() != "failed" {
+			t.Errorf("expected unknown code for unrecognized name, got %d", GetErrCode(decoded))
+		}
+	})
+
+	t.Run("embedded", func(t *testing.T) {
+		type response struct {
+			Err *Error `json:"error"`
+		}
+		data, err := json.Marshal(&response{Err: Wrap(Forbidden, "not allowed").(*Error)})
+		if err != nil {
+			t.Fatalf("failed to marshal: %s", err)
+		}
+		resp := &response{}
+		if err := json.Unmarshal(data, resp); err != nil {
+			t.Fatalf("failed to unmarshal: %s", err)
+		}
+		if !IsForbidden(resp.Err) || resp.Err.Error() != "not allowed" {
+			t.Errorf("expected error decoded from %s, got %v", data, resp.Err)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package errors
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// registry of the errors considered retryable, shared by the retry
+// policies across the packages, eg. the db retry layer and the
+// reconciler, so that the retry policy is decided in one place
+type retryRegistry struct {
+	mu sync.RWMutex
+
+	// error codes considered retryable
+	codes []ErrCode
+
+	// errors considered retryable, along with the errors wrapping them
+	targets []error
+
+	// classifiers of the errors without an error code of their own, eg.
+	// the errors of the drivers
+	classifiers []func(err error) bool
+}
+
+var retryable = &retryRegistry{
+	codes:   []ErrCode{Unavailable, DeadlineExceeded, Conflict},
+	targets: []error{context.DeadlineExceeded},
+}
+
+// RegisterRetryableCode considers the errors with the error code as
+// retryable, Unavailable, DeadlineExceeded and Conflict are considered
+// retryable by default
+func RegisterRetryableCode(code ErrCode) {
+	retryable.mu.Lock()
+	defer retryable.mu.Unlock()
+	if !slices.Contains(retryable.codes, code) {
+		retryable.codes = append(retryable.codes, code)
+	}
+}
+
+// RegisterRetryableError considers the target error, and the errors
+// wrapping it, as retryable, context.DeadlineExceeded is considered
+// retryable by default
+func RegisterRetryableError(target error) {
+	if target == nil {
+		return
+	}
+	retryable.mu.Lock()
+	defer retryable.mu.Unlock()
+	retryable.targets = append(retryable.targets, target)
+}
+
+// RegisterRetryableFunc adds the classifier for the errors, eg. of the
+// drivers like the network errors and the write conflicts, where an
+// error is considered retryable if any of the classifiers returns true
+// for the error or any of the errors it wraps
+func RegisterRetryableFunc(fn func(err error) bool) {
+	if fn == nil {
+		return
+	}
+	retryable.mu.Lock()
+	defer retryable.mu.Unlock()
+	retryable.classifiers = append(retryable.classifiers, fn)
+}
+
+// IsRetryable returns true if the operation failing with the error is
+// expected to succeed if retried, as per the error codes, errors and
+// classifiers registered, returns false for nil and context.Canceled
+func IsRetryable(err error) bool {
+	if err == nil || Is(err, context.Canceled) {
+		return false
+	}
+	retryable.mu.RLock()
+	defer retryable.mu.RUnlock()
+	if slices.Contains(retryable.codes, GetErrCode(err)) {
+		return true
+	}
+	for _, target := range retryable.targets {
+		if Is(err, target) {
+			return true
+		}
+	}
+	if len(retryable.classifiers) == 0 {
+		return false
+	}
+	return walk(err, func(e error) bool {
+		return slices.ContainsFunc(retryable.classifiers, func(fn func(err error) bool) bool {
+			return fn(e)
+		})
+	})
+}
+
+// walks the error chain, including the joined errors, returns true as
+// soon as the function returns true for one of the errors
+func walk(err error, fn func(err error) bool) bool {
+	if err == nil {
+		return false
+	}
+	if fn(err) {
+		return true
+	}
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return walk(e.Unwrap(), fn)
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			if walk(inner, fn) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+type conflictError struct{}
+
+func (conflictError) Error() string {
+	return "write conflict"
+}
+
+func Test_IsRetryable(t *testing.T) {
+	codes, targets := retryable.codes, retryable.targets
+	defer func() {
+		retryable.codes, retryable.targets, retryable.classifiers = codes, targets, nil
+	}()
+
+	errThrottled := New("throttled")
+	RegisterRetryableError(errThrottled)
+	RegisterRetryableFunc(func(err error) bool {
+		_, ok := err.(conflictError)
+		return ok
+	})
+
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{Wrap(Unavailable, "try later"), true},
+		{fmt.Errorf("lookup: %w", Wrap(Unavailable, "try later")), true},
+		{Wrap(DeadlineExceeded, "timed out"), true},
+		{Wrap(Conflict, "write conflict"), true},
+		{Wrap(InvalidArgument, "invalid"), false},
+		{Wrap(NotFound, "missing"), false},
+		{context.DeadlineExceeded, true},
+		{Wrapf(Unknown, "timed out: %w", context.DeadlineExceeded), true},
+		{context.Canceled, false},
+		{errThrottled, true},
+		{conflictError{}, true},
+		{WrapCause(Unknown, conflictError{}, "update failed"), true},
+		{Join(Wrap(NotFound, "missing"), fmt.Errorf("update: %w", conflictError{})), true},
+		{New("failed"), false},
+	}
+	for _, test := range tests {
+		if IsRetryable(test.err) != test.expected {
+			t.Errorf("expected retryable %v for %v", test.expected, test.err)
+		}
+	}
+
+	RegisterRetryableCode(Unavailable)
+	RegisterRetryableCode(AlreadyExists)
+	if !IsRetryable(Wrap(AlreadyExists, "exists")) {
+		t.Errorf("expected registered code to be retryable")
+	}
+	if len(retryable.codes) != 4 {
+		t.Errorf("expected codes to be registered once, got %v", retryable.codes)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package errors
+
+import (
+	base "errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// maximum depth of the stack trace captured
+	maxStackDepth = 32
+)
+
+var (
+	// true if the stack trace is captured while creating the errors,
+	// enabled using EnableStackTrace or the errorstack build tag
+	captureStack atomic.Bool
+)
+
+// EnableStackTrace enables or disables capturing the stack trace while
+// creating the errors, eg. while debugging the deep call chains, as the
+// capture adds to the cost of creating every error. It can also be
+// enabled at build time using the errorstack build tag
+func EnableStackTrace(enable bool) {
+	captureStack.Store(enable)
+}
+
+// returns the program counters of the stack, starting from the caller of
+// the exported function creating the error, if the capture is enabled
+func callers() []uintptr {
+	if !captureStack.Load() {
+		return nil
+	}
+	pcs := make([]uintptr, maxStackDepth)
+	// skip runtime.Callers, callers, newError and the exported function
+	n := runtime.Callers(4, pcs)
+	return pcs[:n]
+}
+
+// StackTrace returns the stack trace captured where the error, or the
+// innermost of its causes, is created, formatted with a function and its
+// location per line, empty if the stack trace capture is not enabled
+func StackTrace(err error) string {
+	var stack []uintptr
+	for ; err != nil; err = base.Unwrap(err) {
+		if e, ok := err.(*Error); ok && len(e.stack) != 0 {
+			stack = e.stack
+		}
+	}
+	if len(stack) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	frames := runtime.CallersFrames(stack)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&sb, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
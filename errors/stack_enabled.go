@@ -0,0 +1,11 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+//go:build errorstack
+
+package errors
+
+func init() {
+	// capture the stack traces for the builds with errorstack tag
+	captureStack.Store(true)
+}
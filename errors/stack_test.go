@@ -0,0 +1,42 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func lookupEntry() error {
+	return Wrap(NotFound, "entry not found")
+}
+
+func Test_ErrorStackTrace(t *testing.T) {
+	enabled := captureStack.Load()
+	defer EnableStackTrace(enabled)
+
+	EnableStackTrace(false)
+	if trace := StackTrace(lookupEntry()); trace != "" {
+		t.Errorf("expected no stack trace while disabled, got %s", trace)
+	}
+
+	EnableStackTrace(true)
+	err := lookupEntry()
+	trace := StackTrace(err)
+	lines := strings.Split(trace, "\n")
+	if !strings.HasSuffix(lines[0], "errors.lookupEntry") || !strings.Contains(lines[1], "stack_test.go:") {
+		t.Errorf("expected stack trace to start at the caller, got %s", trace)
+	}
+
+	// stack of the innermost cause retained through wrapping
+	wrapped := WithDetail(fmt.Errorf("lookup: %w", err), "entry", 1)
+	wrapped = Wrapf(Unavailable, "failed: %w", wrapped)
+	if StackTrace(wrapped) != trace {
+		t.Errorf("expected stack trace of the cause, got %s", StackTrace(wrapped))
+	}
+	if StackTrace(fmt.Errorf("failed")) != "" || StackTrace(nil) != "" {
+		t.Errorf("expected no stack trace for the other errors")
+	}
+}
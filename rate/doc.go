@@ -13,7 +13,7 @@
 //
 //   - LimitManager: Manages a pool of limiters and redistributes capacity
 //   - Limiter: Individual rate limiter with automatic usage reporting
-//   - Rate-limited wrappers: io.Reader and http.ResponseWriter adapters
+//   - Rate-limited wrappers: io.Reader, io.Writer and http.ResponseWriter adapters
 //
 // # Rate Limiting Strategy
 //
@@ -114,6 +114,21 @@ func (m *LimitManager) WrapHTTPResponseWriter(ctx context.Context, key string, w
 	}, nil
 }
 
+func (m *LimitManager) WrapWriter(ctx context.Context, key string, w io.Writer) (RateLimitedWriter, error) {
+	m.mu.Lock()
+	lim, ok := m.limiters[key]
+	m.mu.Unlock()
+	if !ok {
+		return nil, errors.Wrapf(errors.NotFound, "limiter %q not found", key)
+	}
+	lim.SetInUse(true)
+	return &rlIOWriter{
+		ctx: ctx,
+		w:   w,
+		lim: lim,
+	}, nil
+}
+
 // NewLimitManager constructs a LimitManager with the specified aggregate rate budget.
 func NewLimitManager(rate int64) *LimitManager {
 	return &LimitManager{
@@ -189,6 +189,42 @@ func TestWrapHTTPResponseWriterNotFound(t *testing.T) {
 	}
 }
 
+func TestWrapWriterNotFound(t *testing.T) {
+	mgr := NewLimitManager(100)
+
+	_, err := mgr.WrapWriter(context.Background(), "nonexistent", &bytes.Buffer{})
+	if !coreerrors.IsNotFound(err) {
+		t.Fatalf("expected NotFound error, got %v", err)
+	}
+}
+
+// TestRateLimitedWriterChunking verifies writes larger than burst size are
+// chunked and rate limited.
+func TestRateLimitedWriterChunking(t *testing.T) {
+	mgr := NewLimitManager(1000)
+	_, err := mgr.NewLimiter("writer", 1000, 100)
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := mgr.WrapWriter(context.Background(), "writer", buf)
+	if err != nil {
+		t.Fatalf("failed to wrap writer: %v", err)
+	}
+	defer w.Close()
+
+	start := time.Now()
+	n, err := w.Write(bytes.Repeat([]byte("a"), 300))
+	elapsed := time.Since(start)
+	if err != nil || n != 300 || buf.Len() != 300 {
+		t.Fatalf("expected 300 bytes written, got %d (buffer %d), err %v", n, buf.Len(), err)
+	}
+	if elapsed < 100*time.Millisecond {
+		t.Fatalf("write completed too fast (%v), rate limiting likely broken", elapsed)
+	}
+}
+
 // TestRateLimitedReader verifies rate limiting behavior for readers.
 func TestRateLimitedReader(t *testing.T) {
 	mgr := NewLimitManager(1000) // 1000 bytes/sec
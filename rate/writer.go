@@ -0,0 +1,51 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package rate
+
+import (
+	"context"
+	"io"
+)
+
+// RateLimitedWriter wraps an io.Writer with rate limiting, Close releases
+// the limiter and doesn't close the underlying writer
+type RateLimitedWriter interface {
+	io.WriteCloser
+}
+
+type rlIOWriter struct {
+	ctx context.Context
+	w   io.Writer
+	lim *Limiter
+}
+
+// Write implements io.Writer.Write with rate limiting, acquiring tokens
+// BEFORE writing each chunk no larger than the burst size, similar to
+// the rate limited http response writer.
+func (w *rlIOWriter) Write(p []byte) (int, error) {
+	written := 0
+	burstSize := int(w.lim.burst)
+	for written < len(p) {
+		chunk := len(p) - written
+		if chunk > burstSize {
+			chunk = burstSize
+		}
+
+		if err := w.lim.WaitN(w.ctx, chunk); err != nil {
+			return written, err
+		}
+
+		n, err := w.w.Write(p[written : written+chunk])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (w *rlIOWriter) Close() error {
+	w.lim.SetInUse(false)
+	return nil
+}
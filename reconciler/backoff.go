@@ -0,0 +1,93 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package reconciler
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+var (
+	// default backoff for the keys failing to reconcile
+	defaultBackoff = Backoff{
+		Base: 10 * time.Millisecond,
+		Cap:  5 * time.Minute,
+	}
+)
+
+// Backoff provides the exponential backoff for retrying the keys failing
+// to reconcile, where the delay doubles with every consecutive failure
+type Backoff struct {
+	// delay before the first retry
+	Base time.Duration
+
+	// upper bound on the delay
+	Cap time.Duration
+
+	// fraction of the delay randomized, spreading the retries of the
+	// keys failing together, eg. 0.1 for +/- 10%
+	Jitter float64
+}
+
+// validates the backoff configuration
+func (b Backoff) validate() error {
+	if b.Base <= 0 || b.Cap < b.Base {
+		return errors.Wrapf(errors.InvalidArgument, "invalid backoff base %s, cap %s", b.Base, b.Cap)
+	}
+	if b.Jitter < 0 || b.Jitter > 1 {
+		return errors.Wrapf(errors.InvalidArgument, "invalid backoff jitter %v", b.Jitter)
+	}
+	return nil
+}
+
+// returns the delay before the retry following the given number of
+// consecutive failures
+func (b Backoff) delay(failures int) time.Duration {
+	d := b.Cap
+	if shift := failures - 1; shift < 62 {
+		if exp := b.Base << shift; exp > 0 && exp < b.Cap {
+			d = exp
+		}
+	}
+	if b.Jitter != 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * b.Jitter * float64(d))
+	}
+	return d
+}
+
+// resets the failures of the entry on successful reconciliation
+func (p *Pipeline) resetFailures(id any) {
+	p.muProcessing.Lock()
+	defer p.muProcessing.Unlock()
+	delete(p.failures, id)
+}
+
+// schedules the retry of the entry failing to reconcile after the backoff,
+// handing it to the dead letter handler once out of retries
+func (p *Pipeline) retry(entry pipelineEntry, err error) {
+	k, id := entry.key, entry.id
+	p.muProcessing.Lock()
+	p.failures[id]++
+	failures := p.failures[id]
+	exhausted := p.opts.MaxRetries != 0 && failures > p.opts.MaxRetries
+	if exhausted {
+		delete(p.failures, id)
+	}
+	p.muProcessing.Unlock()
+
+	if exhausted {
+		p.unpersist(entry)
+		p.stats.deadLettered.Add(1)
+		if p.opts.DeadLetter != nil {
+			p.opts.DeadLetter(k, err)
+		} else {
+			p.opts.Logf("reconciler: giving up on %v after %d attempts: %s", k, failures, err)
+		}
+		return
+	}
+	p.stats.retries.Add(1)
+	_ = p.enqueueAfter(k, p.opts.Backoff.delay(failures), entry.prio)
+}
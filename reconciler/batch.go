@@ -0,0 +1,161 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package reconciler
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// default maximum number of keys handed at once to a batch controller
+	defaultBatchSize = 100
+)
+
+// BatchResult is the outcome of the reconcile of a key of the batch
+type BatchResult struct {
+	// Result of the reconcile, eg. to requeue the key after a while
+	Result *Result
+
+	// Err if the key failed to reconcile, retried with backoff
+	Err error
+}
+
+// BatchController is optionally implemented by the controllers whose
+// downstream supports the bulk operations, where the pipeline hands the
+// controller up to BatchSize pending keys at once, instead of invoking
+// Reconcile for every key. The keys of a batch are distinct and are not
+// reconciled in parallel by the other workers. ReconcileBatch returns the
+// outcome of each key, in the order of the keys, or nil if all the keys
+// reconciled successfully
+type BatchController interface {
+	ReconcileBatch(ctx context.Context, keys []any) []BatchResult
+}
+
+// batch reconciler function receiving the context of the pipeline
+type batchReconcilerFunc func(ctx context.Context, keys []any) []BatchResult
+
+// WithBatchSize hands up to n pending keys at once to the controllers
+// implementing BatchController
+func WithBatchSize(n int) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.BatchSize = n
+	}
+}
+
+// creates the pipeline for the controller, processing the entries in
+// batches if the controller supports it
+func pipelineOf(ctx context.Context, name string, crtl Controller, opts *RegisterOptions, locks *keyLocker) *Pipeline {
+	p := allocPipeline(ctx, name, opts)
+	p.setController(crtl)
+	p.keyLocks = locks
+	p.initialize()
+	return p
+}
+
+// sets the reconciler of the pipeline for the controller
+func (p *Pipeline) setController(crtl Controller) {
+	if b, ok := crtl.(BatchController); ok {
+		p.batch = b.ReconcileBatch
+	} else {
+		p.reconciler = reconcilerOf(crtl)
+	}
+}
+
+// collects the batch of entries, starting with the given entry, along
+// with the entries pending up to the batch size
+func (p *Pipeline) collect(entry pipelineEntry) []pipelineEntry {
+	entries := []pipelineEntry{entry}
+	for len(entries) < p.opts.BatchSize {
+		next, ok := p.dequeue()
+		if !ok {
+			break
+		}
+		entries = append(entries, next)
+	}
+	return entries
+}
+
+// processes the batch of entries available in the pipeline
+func (p *Pipeline) processBatch(entries []pipelineEntry) {
+	batch := make([]pipelineEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !p.startProcessing(entry) {
+			// being reconciled by another worker, which will
+			// requeue it once done
+			continue
+		}
+		batch = append(batch, entry)
+	}
+	if len(batch) == 0 {
+		return
+	}
+	keys := make([]any, len(batch))
+	for i, entry := range batch {
+		keys[i] = entry.key
+	}
+
+	// trigger the batch reconciler
+	results := p.reconcileBatch(keys)
+	for i, entry := range batch {
+		var res *Result
+		var err error
+		if results != nil {
+			res, err = results[i].Result, results[i].Err
+		}
+		p.complete(entry, res, err)
+	}
+}
+
+// triggers the batch reconciler for the keys, converting a panic or the
+// results not matching the keys into an error for all the keys
+func (p *Pipeline) reconcileBatch(keys []any) (results []BatchResult) {
+	failAll := func(err error) []BatchResult {
+		results := make([]BatchResult, len(keys))
+		for i := range results {
+			results[i].Err = err
+		}
+		return results
+	}
+	ctx := p.startHook(keys)
+	start := time.Now()
+	defer func() {
+		panicked := false
+		if r := recover(); r != nil {
+			panicked = true
+			p.stats.panics.Add(1)
+			p.opts.Logf("reconciler: %s panicked reconciling batch of %d keys: %v\n%s", p.name, len(keys), r, debug.Stack())
+			results = failAll(errors.Wrapf(errors.Unknown, "reconcile of batch panicked: %v", r))
+		}
+		d := time.Since(start)
+		outcome, firstErr := OutcomeSuccess, error(nil)
+		if panicked {
+			outcome = OutcomePanic
+		}
+		for _, r := range results {
+			p.stats.observe(d, r.Err)
+			if o := outcomeOf(r.Result, r.Err, panicked); o > outcome {
+				outcome = o
+			}
+			if firstErr == nil {
+				firstErr = r.Err
+			}
+		}
+		if results == nil {
+			for range keys {
+				p.stats.observe(d, nil)
+			}
+		}
+		p.endHook(ctx, keys, d, outcome, firstErr)
+	}()
+	results = p.batch(ctx, keys)
+	if results != nil && len(results) != len(keys) {
+		p.opts.Logf("reconciler: %s returned %d results for batch of %d keys", p.name, len(results), len(keys))
+		results = failAll(errors.Wrapf(errors.Unknown, "batch reconcile returned %d results for %d keys", len(results), len(keys)))
+	}
+	return results
+}
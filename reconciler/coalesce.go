@@ -0,0 +1,35 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package reconciler
+
+import (
+	"time"
+)
+
+// WithCoalesceWindow holds the keys enqueued for the duration, eg.
+// 100ms, before dispatching them for reconciliation, merging the
+// notifications for a key meanwhile into a single reconcile, avoiding
+// the redundant reconciles under the bursts of updates, at the cost of
+// the added latency
+func WithCoalesceWindow(d time.Duration) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.CoalesceWindow = d
+	}
+}
+
+// dispatches the entry held by the coalescing window for reconciliation,
+// as per the highest priority it is enqueued with meanwhile
+func (p *Pipeline) dispatch(entry pipelineEntry) {
+	p.muQueue.Lock()
+	prio, ok := p.pending[entry.id]
+	if ok {
+		level := priorityLevel(prio)
+		entry.prio = prio
+		p.queue[level] = append(p.queue[level], entry)
+	}
+	p.muQueue.Unlock()
+	if ok {
+		signal(p.ready)
+	}
+}
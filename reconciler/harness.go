@@ -0,0 +1,193 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package reconciler
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ManualClock is the clock advanced explicitly by the tests, firing the
+// delayed requeues and the retries of the harness as the time passes
+type ManualClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+}
+
+// timer scheduled on the manual clock
+type manualTimer struct {
+	at time.Time
+	fn func()
+}
+
+// NewManualClock creates the manual clock starting at the given time
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now returns the current time of the clock
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by the duration, firing the timers due
+// in the order of their time, synchronously on the calling goroutine
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+	for {
+		c.mu.Lock()
+		if len(c.timers) == 0 || c.timers[0].at.After(target) {
+			c.now = target
+			c.mu.Unlock()
+			return
+		}
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		c.now = t.at
+		c.mu.Unlock()
+		t.fn()
+	}
+}
+
+// schedules the function after the delay on the clock
+func (c *ManualClock) afterFunc(d time.Duration, fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &manualTimer{at: c.now.Add(d), fn: fn}
+	// timers due at the same time fire in the order of scheduling
+	i, _ := slices.BinarySearchFunc(c.timers, t.at, func(e *manualTimer, at time.Time) int {
+		if e.at.After(at) {
+			return 1
+		}
+		return -1
+	})
+	c.timers = slices.Insert(c.timers, i, t)
+}
+
+// Harness drives a controller deterministically in the tests, standing
+// in for the manager and the pipeline, where the keys are reconciled
+// synchronously on the calling goroutine by ProcessNext or Flush, while
+// the requeues and the retries are delayed on the manual clock, without
+// requiring a data store, sleeps or goroutine timing. Since there are no
+// workers, the OverflowBlock policy is treated as OverflowGrow
+type Harness struct {
+	name  string
+	clock *ManualClock
+	p     *Pipeline
+	data  *controllerData
+}
+
+// NewHarness creates the harness for the controller, configured as per
+// the registration options
+func NewHarness(crtl Controller, opts ...RegisterOption) (*Harness, error) {
+	ropts := getRegisterOptions(opts)
+	if err := ropts.validate(); err != nil {
+		return nil, err
+	}
+	if ropts.Overflow == OverflowBlock {
+		ropts.Overflow = OverflowGrow
+	}
+	name := "harness"
+	clock := NewManualClock(time.Now())
+	p := allocPipeline(context.Background(), name, ropts)
+	p.setController(crtl)
+	p.afterFunc = clock.afterFunc
+	return &Harness{
+		name:  name,
+		clock: clock,
+		p:     p,
+		data: &controllerData{
+			name:     name,
+			handle:   crtl,
+			pipeline: p,
+			opts:     ropts,
+		},
+	}, nil
+}
+
+// Clock returns the manual clock of the harness
+func (h *Harness) Clock() *ManualClock {
+	return h.clock
+}
+
+// Notify notifies the operation on the key like the manager, enqueueing
+// the key only if accepted by the key filter and the predicate of the
+// controller, returns true if the key is enqueued
+func (h *Harness) Notify(op string, key any) bool {
+	if !h.data.acceptsOp(op, key) {
+		return false
+	}
+	return h.p.Enqueue(key) == nil
+}
+
+// Resync enqueues the existing keys like the manager does on registration
+// and periodic resync, ie. with low priority, skipping the keys not
+// accepted by the key filter of the controller
+func (h *Harness) Resync(keys ...any) {
+	for _, key := range keys {
+		if h.data.accepts(key) {
+			_ = h.p.EnqueueWithPriority(key, PriorityLow)
+		}
+	}
+}
+
+// Enqueue adds the key for reconciliation, regardless of the key filter
+func (h *Harness) Enqueue(key any) error {
+	return h.p.Enqueue(key)
+}
+
+// EnqueueWithPriority adds the key for reconciliation with the priority
+func (h *Harness) EnqueueWithPriority(key any, prio Priority) error {
+	return h.p.EnqueueWithPriority(key, prio)
+}
+
+// ProcessNext reconciles the next key pending, or the next batch for the
+// batch controllers, returns false if there are no keys pending
+func (h *Harness) ProcessNext() bool {
+	entry, ok := h.p.dequeue()
+	if !ok {
+		return false
+	}
+	if h.p.batch != nil {
+		h.p.processBatch(h.p.collect(entry))
+	} else {
+		h.p.process(entry)
+	}
+	return true
+}
+
+// Flush reconciles the keys pending, including the ones enqueued by the
+// reconciles meanwhile, until there are no keys pending, without
+// advancing the clock, returns the number of times ProcessNext ran
+func (h *Harness) Flush() int {
+	count := 0
+	for h.ProcessNext() {
+		count++
+	}
+	return count
+}
+
+// Len returns the number of keys pending, including the ones held by the
+// coalescing window, while excluding the delayed requeues and retries
+func (h *Harness) Len() int {
+	return h.p.Len()
+}
+
+// Stats returns the statistics of the controller
+func (h *Harness) Stats() ControllerStats {
+	return h.p.Stats()
+}
+
+// Close stops the harness, dropping the keys pending and the delayed
+// ones
+func (h *Harness) Close() {
+	h.p.Stop()
+}
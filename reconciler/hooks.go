@@ -0,0 +1,131 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package reconciler
+
+import (
+	"context"
+	"time"
+)
+
+// Outcome of a reconcile, as reported to the hooks
+type Outcome int
+
+const (
+	// reconciled successfully
+	OutcomeSuccess Outcome = iota
+
+	// reconciled successfully, while requesting the key to be requeued
+	OutcomeRequeue
+
+	// failed to reconcile, to be retried with backoff
+	OutcomeError
+
+	// panicked while reconciling, to be retried with backoff
+	OutcomePanic
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeSuccess:
+		return "success"
+	case OutcomeRequeue:
+		return "requeue"
+	case OutcomeError:
+		return "error"
+	case OutcomePanic:
+		return "panic"
+	}
+	return "unknown"
+}
+
+// ReconcileInfo provides the details of a completed reconcile
+type ReconcileInfo struct {
+	// name of the controller
+	Controller string
+
+	// key reconciled, or the slice of the keys for the batch controllers
+	Key any
+
+	// time taken by the reconcile
+	Duration time.Duration
+
+	// outcome of the reconcile, where the outcome of a batch is the
+	// worst outcome across its keys
+	Outcome Outcome
+
+	// error of the failed reconcile, the first error for a batch
+	Err error
+}
+
+// ReconcileHooks are invoked around every reconcile of a controller,
+// allowing the reconcile activity to be observed, eg. creating a tracing
+// span on start and ending it on completion
+type ReconcileHooks struct {
+	// OnStart is invoked before the reconcile with the name of the
+	// controller and the key, or the slice of the keys for the batch
+	// controllers, returning the context for the reconcile, eg. carrying
+	// the tracing span, which is passed on to the context controllers
+	// Default: nil
+	OnStart func(ctx context.Context, name string, key any) context.Context
+
+	// OnEnd is invoked once the reconcile completes, with the context
+	// returned by OnStart
+	// Default: nil
+	OnEnd func(ctx context.Context, info ReconcileInfo)
+}
+
+// WithHooks sets the hooks invoked around every reconcile of the
+// controller, eg. to trace or log the reconcile activity
+func WithHooks(hooks ReconcileHooks) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.Hooks = hooks
+	}
+}
+
+// WithLogger sets the function used to log the activity of the
+// controller, eg. the panics and the keys given up, instead of the
+// standard logger
+func WithLogger(logf func(format string, args ...any)) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.Logf = logf
+	}
+}
+
+// returns the outcome of the reconcile
+func outcomeOf(res *Result, err error, panicked bool) Outcome {
+	switch {
+	case panicked:
+		return OutcomePanic
+	case err != nil:
+		return OutcomeError
+	case res != nil && (res.Requeue || res.RequeueAfter != 0):
+		return OutcomeRequeue
+	}
+	return OutcomeSuccess
+}
+
+// invokes the start hook, returning the context for the reconcile
+func (p *Pipeline) startHook(key any) context.Context {
+	if p.opts.Hooks.OnStart == nil {
+		return p.ctx
+	}
+	if ctx := p.opts.Hooks.OnStart(p.ctx, p.name, key); ctx != nil {
+		return ctx
+	}
+	return p.ctx
+}
+
+// invokes the end hook with the details of the completed reconcile
+func (p *Pipeline) endHook(ctx context.Context, key any, d time.Duration, outcome Outcome, err error) {
+	if p.opts.Hooks.OnEnd == nil {
+		return
+	}
+	p.opts.Hooks.OnEnd(ctx, ReconcileInfo{
+		Controller: p.name,
+		Key:        key,
+		Duration:   d,
+		Outcome:    outcome,
+		Err:        err,
+	})
+}
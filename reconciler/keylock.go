@@ -0,0 +1,99 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package reconciler
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// keyLocker serializes the reconciles of a key across the controllers of
+// a manager, where the controllers waiting for a key are handed the key in
+// the order they requested it
+type keyLocker struct {
+	mu sync.Mutex
+
+	// keys being reconciled, along with the waiters for the key in the
+	// order of their arrival
+	held map[any][]chan struct{}
+}
+
+func newKeyLocker() *keyLocker {
+	return &keyLocker{
+		held: map[any][]chan struct{}{},
+	}
+}
+
+// locks the key, waiting for the reconciles of the key by the other
+// controllers to complete, returns false if the context is done before
+func (l *keyLocker) lock(ctx context.Context, id any) bool {
+	l.mu.Lock()
+	waiters, held := l.held[id]
+	if !held {
+		l.held[id] = nil
+		l.mu.Unlock()
+		return true
+	}
+	ch := make(chan struct{})
+	l.held[id] = append(waiters, ch)
+	l.mu.Unlock()
+
+	select {
+	case <-ch:
+		return true
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	if i := slices.Index(l.held[id], ch); i != -1 {
+		l.held[id] = slices.Delete(l.held[id], i, i+1)
+		l.mu.Unlock()
+		return false
+	}
+	l.mu.Unlock()
+	// handed the key in between, pass it on to the next waiter
+	l.unlock(id)
+	return false
+}
+
+// unlocks the key, handing it to the next controller waiting for it
+func (l *keyLocker) unlock(id any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	waiters := l.held[id]
+	if len(waiters) == 0 {
+		delete(l.held, id)
+		return
+	}
+	l.held[id] = waiters[1:]
+	close(waiters[0])
+}
+
+// WithExclusiveKeys ensures a key is never reconciled by the controller
+// in parallel with the other controllers of the manager registered with
+// this option, where the controllers waiting for a key reconcile it in
+// the order they requested it, eg. for the controllers mutating the same
+// underlying document. Not supported for the batch controllers
+func WithExclusiveKeys() RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.ExclusiveKeys = true
+	}
+}
+
+// locks the key across the controllers if configured, returns false if
+// the pipeline is stopped while waiting for the key
+func (p *Pipeline) lockKey(id any) bool {
+	if p.keyLocks == nil {
+		return true
+	}
+	return p.keyLocks.lock(p.ctx, id)
+}
+
+// unlocks the key across the controllers if configured
+func (p *Pipeline) unlockKey(id any) {
+	if p.keyLocks != nil {
+		p.keyLocks.unlock(id)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package reconciler
+
+import (
+	"github.com/go-core-stack/core/errors"
+)
+
+// OverflowPolicy decides the handling of the keys enqueued while the
+// pipeline buffer is full, ie. the controller falling behind
+type OverflowPolicy int
+
+const (
+	// blocks the enqueue until the buffer has room, stalling the
+	// producer, eg. the watch stream notifying the changes
+	OverflowBlock OverflowPolicy = iota
+
+	// grows the pipeline beyond the buffer, holding the keys in memory
+	// until the controller catches up
+	OverflowGrow
+
+	// rejects the key with Unavailable error, where the key is expected
+	// to be healed later, eg. by the periodic resync
+	OverflowReject
+)
+
+// WithOverflow sets the handling of the keys enqueued while the pipeline
+// buffer is full, ensuring a slow controller doesn't stall the producers
+func WithOverflow(policy OverflowPolicy) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.Overflow = policy
+	}
+}
+
+// handles the new entry arriving while the pipeline is full, as per the
+// overflow policy, invoked holding the queue lock
+func (p *Pipeline) overflow(k any) error {
+	switch p.opts.Overflow {
+	case OverflowBlock:
+		return nil
+	case OverflowReject:
+		p.stats.overflows.Add(1)
+		return errors.Wrapf(errors.Unavailable, "pipeline is full, rejecting %v", k)
+	default:
+		// entries overflowing are held in memory beyond the buffer
+		p.stats.overflows.Add(1)
+		return nil
+	}
+}
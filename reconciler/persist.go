@@ -0,0 +1,163 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package reconciler
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+)
+
+// key of the record for a key pending reconciliation by a controller
+type persistedKey struct {
+	Controller string `bson:"controller"`
+	Key        any    `bson:"key"`
+}
+
+// record for a key pending reconciliation by a controller
+type persistedData struct {
+	// time after which the key is due for reconciliation
+	NotBefore time.Time `bson:"notBefore"`
+
+	// priority the key is enqueued with
+	Priority Priority `bson:"priority"`
+
+	// sequence of the update of the record, ensuring the record is
+	// removed only if not updated since the key was dequeued
+	Seq int64 `bson:"seq"`
+}
+
+// record for a key as read back from the collection
+type persistedRecord struct {
+	ID struct {
+		Key bson.RawValue `bson:"key"`
+	} `bson:"_id"`
+	NotBefore time.Time `bson:"notBefore"`
+	Priority  Priority  `bson:"priority"`
+}
+
+// persistence of the keys pending reconciliation by a controller
+type persistence struct {
+	col    db.StoreCollection
+	decode func(raw bson.RawValue) (any, error)
+
+	mu sync.Mutex
+
+	// sequence of the last update of the record per key
+	seq     map[any]int64
+	lastSeq int64
+}
+
+// WithPersistence records the keys pending reconciliation, including the
+// delayed requeues and retries, in the collection, where the keys of the
+// type K are restored on registration of the controller after a restart,
+// ahead of the scan of the existing keys. The record of a key is removed
+// once the key is reconciled successfully, or given up, so the keys are
+// reconciled at least once. The collection can be shared across the
+// controllers, while the keys are expected to be encoded as documents
+func WithPersistence[K any](col db.StoreCollection) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.persistence = &persistence{
+			col: col,
+			decode: func(raw bson.RawValue) (any, error) {
+				key := new(K)
+				if err := raw.Unmarshal(key); err != nil {
+					return nil, err
+				}
+				return key, nil
+			},
+			seq: map[any]int64{},
+		}
+	}
+}
+
+// returns the key of the record for the key
+func (p *Pipeline) persistedKey(k any) *persistedKey {
+	v := reflect.ValueOf(k)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		k = v.Elem().Interface()
+	}
+	return &persistedKey{Controller: p.name, Key: k}
+}
+
+// records the key pending reconciliation, due after the given time
+func (p *Pipeline) persist(k any, id any, notBefore time.Time, prio Priority) {
+	s := p.opts.persistence
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.lastSeq++
+	seq := s.lastSeq
+	s.seq[id] = seq
+	s.mu.Unlock()
+
+	data := &persistedData{NotBefore: notBefore, Priority: prio, Seq: seq}
+	if err := s.col.UpdateOne(p.ctx, p.persistedKey(k), data, true); err != nil && p.ctx.Err() == nil {
+		p.opts.Logf("reconciler: %s failed to persist %v: %s", p.name, k, err)
+	}
+}
+
+// returns the sequence of the last update of the record for the key
+func (p *Pipeline) persistedSeq(id any) int64 {
+	s := p.opts.persistence
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seq[id]
+}
+
+// removes the record of the entry, unless updated since the entry was
+// dequeued, ie. the key is pending again
+func (p *Pipeline) unpersist(entry pipelineEntry) {
+	s := p.opts.persistence
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.seq[entry.id] == entry.seq {
+		delete(s.seq, entry.id)
+	}
+	s.mu.Unlock()
+
+	key := p.persistedKey(entry.key)
+	filter := bson.D{
+		{Key: "_id.controller", Value: key.Controller},
+		{Key: "_id.key", Value: key.Key},
+		{Key: "seq", Value: entry.seq},
+	}
+	if _, err := s.col.DeleteMany(p.ctx, filter); err != nil && p.ctx.Err() == nil {
+		p.opts.Logf("reconciler: %s failed to remove persisted %v: %s", p.name, entry.key, err)
+	}
+}
+
+// restores the keys pending reconciliation, as recorded before a restart
+func (p *Pipeline) restore() {
+	s := p.opts.persistence
+	if s == nil {
+		return
+	}
+	list := []persistedRecord{}
+	err := s.col.FindMany(p.ctx, bson.D{{Key: "_id.controller", Value: p.name}}, &list)
+	if err != nil {
+		if p.ctx.Err() == nil {
+			p.opts.Logf("reconciler: %s failed to restore persisted keys: %s", p.name, err)
+		}
+		return
+	}
+	for _, r := range list {
+		key, err := s.decode(r.ID.Key)
+		if err != nil {
+			p.opts.Logf("reconciler: %s failed to decode persisted key: %s", p.name, err)
+			continue
+		}
+		_ = p.enqueueAfter(key, time.Until(r.NotBefore), r.Priority)
+	}
+}
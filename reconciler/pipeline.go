@@ -5,8 +5,14 @@ package reconciler
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/go-core-stack/core/errors"
 )
 
 // Since Reconciler Pipeline will be used across go routines, it is
@@ -24,87 +30,472 @@ type Pipeline struct {
 	// where the context closure means the pipeline is stopped
 	ctx context.Context
 
-	// map of entries to work with, here we are storing entries in a map
-	// to enable possibility of compressing notifications while trying
-	// to enqueue an entry which is already in pipeline
-	pMap sync.Map
+	// stops the pipeline
+	cancel context.CancelFunc
+
+	// set once the pipeline stops accepting new entries, ie. being
+	// drained or stopped
+	closed atomic.Bool
+
+	// closed to drain the pipeline, where the workers exit once there
+	// are no more entries pending
+	draining  chan struct{}
+	drainOnce sync.Once
+
+	// workers processing the pipeline
+	wg sync.WaitGroup
 
-	// Pipeline is internally built on a buffered channel internally
-	pChannel chan any
+	// entries pending in the pipeline per priority level, along with
+	// the map of the entries pending to their priority, enabling
+	// compressing of notifications while trying to enqueue an entry
+	// which is already in pipeline
+	muQueue sync.Mutex
+	queue   [numPriorities][]pipelineEntry
+	pending map[any]Priority
+
+	// weights of the priority levels, and the current weights of the
+	// smooth weighted round robin across the levels
+	weights [numPriorities]int
+	current [numPriorities]int
+
+	// signalled when the entries are available to the workers, and when
+	// there is room for the entries blocked on the full pipeline
+	ready chan struct{}
+	room  chan struct{}
 
 	// reconciler function to trigger while processing an entry in the
 	// pipeline
-	reconciler reconcilerFunc
+	reconciler reconcilerCtxFunc
+
+	// batch reconciler function, if set, processing the entries in
+	// batches instead of the reconciler function
+	batch batchReconcilerFunc
+
+	// name of the controller the pipeline is working for
+	name string
+
+	// options the pipeline is configured with
+	opts *RegisterOptions
+
+	// entries being reconciled by the workers, ensuring an entry is
+	// reconciled by one worker at a time, where the value tracks the
+	// entry getting dirty, ie. enqueued again while being reconciled, to
+	// be requeued once the reconciliation is done
+	muProcessing sync.Mutex
+	processing   map[any]processingState
+
+	// consecutive failed attempts to reconcile the entries
+	failures map[any]int
+
+	// statistics of the pipeline
+	stats *pipelineStats
+
+	// locks serializing the reconciles of the keys across the
+	// controllers, if configured
+	keyLocks *keyLocker
+
+	// schedules the function after the delay, allowing the tests to
+	// control the time
+	afterFunc func(d time.Duration, fn func())
+}
+
+// entry pending in the pipeline
+type pipelineEntry struct {
+	key any
+	id  any
+
+	// priority the entry is pending with, retained for the requeues
+	prio Priority
+
+	// sequence of the persisted record of the entry when dequeued
+	seq int64
+}
+
+// state of the entry being reconciled
+type processingState struct {
+	// enqueued again while being reconciled
+	dirty bool
+
+	// highest priority the entry is enqueued with while being reconciled
+	prio Priority
+}
+
+// signals the channel without blocking, where a pending signal suffices
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// returns the identity of the key, where the keys pointing to equal
+// values are the same entry, eg. the keys decoded afresh for every watch
+// notification, ensuring compressing of notifications for an entry
+func keyID(k any) any {
+	v := reflect.ValueOf(k)
+	if !v.IsValid() {
+		return k
+	}
+	if v.Kind() == reflect.Pointer {
+		if !v.IsNil() && v.Elem().Type().Comparable() {
+			return v.Elem().Interface()
+		}
+		return k
+	}
+	if !v.Type().Comparable() {
+		return fmt.Sprintf("%T:%v", k, k)
+	}
+	return k
 }
 
+// Enqueue adds the entry to the pipeline for reconciliation
+// returns Unavailable error if the pipeline is drained or stopped
 func (p *Pipeline) Enqueue(k any) error {
-	// do not allow if the context is already closed
+	return p.EnqueueWithPriority(k, PriorityNormal)
+}
+
+// EnqueueWithPriority adds the entry to the pipeline for reconciliation
+// with the given priority, an entry already pending with a lower priority
+// is promoted to the given priority
+// returns Unavailable error if the pipeline is drained or stopped
+func (p *Pipeline) EnqueueWithPriority(k any, prio Priority) error {
+	if p.closed.Load() {
+		return errors.Wrap(errors.Unavailable, "pipeline is not accepting new entries")
+	}
+	return p.enqueue(k, prio)
+}
+
+// EnqueueAfter adds the entry to the pipeline for reconciliation after
+// the given delay, the entry is dropped if the pipeline is drained or
+// stopped in between
+// returns Unavailable error if the pipeline is drained or stopped
+func (p *Pipeline) EnqueueAfter(k any, d time.Duration) error {
+	return p.enqueueAfter(k, d, PriorityNormal)
+}
+
+// adds the entry to the pipeline with the given priority after the delay
+func (p *Pipeline) enqueueAfter(k any, d time.Duration, prio Priority) error {
+	if d <= 0 {
+		return p.EnqueueWithPriority(k, prio)
+	}
+	if p.closed.Load() {
+		return errors.Wrap(errors.Unavailable, "pipeline is not accepting new entries")
+	}
 	if p.ctx.Err() != nil {
 		return p.ctx.Err()
 	}
+	p.persist(k, keyID(k), time.Now().Add(d), prio)
+	p.afterFunc(d, func() {
+		_ = p.EnqueueWithPriority(k, prio)
+	})
+	return nil
+}
+
+// adds the entry to the pipeline, while being drained as well
+func (p *Pipeline) enqueue(k any, prio Priority) error {
+	level := priorityLevel(prio)
+	id := keyID(k)
+	for {
+		// do not allow if the context is already closed
+		if p.ctx.Err() != nil {
+			return p.ctx.Err()
+		}
+
+		p.muQueue.Lock()
+		if cur, ok := p.pending[id]; ok {
+			// compress multiple notifications for a single entry
+			// into one, retaining the highest priority
+			if prio > cur {
+				p.promote(id, priorityLevel(cur), prio)
+				p.pending[id] = prio
+			}
+			p.muQueue.Unlock()
+			p.stats.coalesced.Add(1)
+			return nil
+		}
+		if len(p.pending) >= bufferLength {
+			if err := p.overflow(k); err != nil {
+				p.muQueue.Unlock()
+				return err
+			}
+			if p.opts.Overflow == OverflowBlock {
+				p.muQueue.Unlock()
+				select {
+				case <-p.room:
+				case <-p.ctx.Done():
+				}
+				continue
+			}
+		}
+		entry := pipelineEntry{key: k, id: id, prio: prio}
+		p.pending[id] = prio
+		if p.opts.CoalesceWindow == 0 {
+			p.queue[level] = append(p.queue[level], entry)
+		}
+		hasRoom := len(p.pending) < bufferLength
+		p.muQueue.Unlock()
+
+		if p.opts.CoalesceWindow != 0 {
+			// hold the entry for the notifications to be merged
+			p.afterFunc(p.opts.CoalesceWindow, func() { p.dispatch(entry) })
+		} else {
+			signal(p.ready)
+		}
+		if hasRoom {
+			// pass on the room to the other entries blocked
+			signal(p.room)
+		}
+		p.persist(k, id, time.Now(), prio)
+		p.stats.enqueued.Add(1)
+		return nil
+	}
+}
 
-	// load or store the entry to sync map, checking existence of the
-	// entry in the Pipeline, ensuring compressing multiple
-	// notifications for a single entry into one
-	// while the value stored is nil as we are treating this map more
-	// of as a set, where values do not hold relevance as of now
-	_, loaded := p.pMap.LoadOrStore(k, nil)
-	if !loaded {
-		// if entry didn't exist in the map, ensure pushing the same
-		// to the buffered channel for processing by reconciler
-		p.pChannel <- k
+// removes the entry pending in the pipeline for processing, choosing
+// across the priority levels as per their weights, returns false if
+// there are no entries pending
+func (p *Pipeline) dequeue() (pipelineEntry, bool) {
+	p.muQueue.Lock()
+	level := p.nextLevel()
+	if level < 0 {
+		p.muQueue.Unlock()
+		return pipelineEntry{}, false
 	}
+	entry := p.queue[level][0]
+	p.queue[level][0] = pipelineEntry{}
+	p.queue[level] = p.queue[level][1:]
+	delete(p.pending, entry.id)
+	remaining := len(p.pending) != 0
+	p.muQueue.Unlock()
 
-	return nil
+	if remaining {
+		// pass on to the other workers
+		signal(p.ready)
+	}
+	signal(p.room)
+	entry.seq = p.persistedSeq(entry.id)
+	return entry, true
+}
+
+// marks the entry as being reconciled, returns false if the entry is
+// already being reconciled by another worker, marking it dirty instead,
+// retaining the highest priority it is enqueued with
+func (p *Pipeline) startProcessing(entry pipelineEntry) bool {
+	p.muProcessing.Lock()
+	defer p.muProcessing.Unlock()
+	if state, ok := p.processing[entry.id]; ok {
+		if !state.dirty || entry.prio > state.prio {
+			state.prio = entry.prio
+		}
+		state.dirty = true
+		p.processing[entry.id] = state
+		return false
+	}
+	p.processing[entry.id] = processingState{}
+	return true
+}
+
+// marks the reconciliation of the entry as done, returns true if the
+// entry got dirty while being reconciled, along with the priority to
+// requeue it with
+func (p *Pipeline) doneProcessing(id any) (bool, Priority) {
+	p.muProcessing.Lock()
+	defer p.muProcessing.Unlock()
+	state := p.processing[id]
+	delete(p.processing, id)
+	return state.dirty, state.prio
 }
 
 // initialize and start the pipeline processing
 // internal function and should not be exposed outside
 func (p *Pipeline) initialize() {
+	p.wg.Add(p.opts.Workers)
+	for range p.opts.Workers {
+		go p.worker()
+	}
+}
+
+// processes the entries available in the pipeline, until the pipeline
+// is stopped, or drained of all the pending entries
+func (p *Pipeline) worker() {
+	defer p.wg.Done()
 	for {
-		select {
-		case <-p.ctx.Done():
+		if p.ctx.Err() != nil {
 			// pipeline processing is stopped return from here
 			return
-		case k := <-p.pChannel:
-			// process the entry available in the pipeline
-			// send it over to the reconciler for processing
-			// delete the key from the map while triggering
-			// the reconciler
-			p.pMap.Delete(k)
-
-			// trigger the reconciler
-			res, err := p.reconciler(k)
-			if err != nil {
-				// there was an error while processing the entry
-				// requeue it at the back of the pipeline for
-				// processing later
-				_ = p.Enqueue(k)
+		}
+		if entry, ok := p.dequeue(); ok {
+			if p.batch != nil {
+				p.processBatch(p.collect(entry))
 			} else {
-				if res != nil && res.RequeueAfter != 0 {
-					go func(k1 any) {
-						// requeue the entry after specified time
-						time.Sleep(res.RequeueAfter)
-						_ = p.Enqueue(k1)
-					}(k)
-				}
+				p.process(entry)
 			}
+			continue
+		}
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-p.ready:
+		case <-p.draining:
+			if p.Len() == 0 {
+				// no more entries pending, wake up the other
+				// workers to notice the same
+				signal(p.ready)
+				return
+			}
+			// wait for the entries held by the coalescing window
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-p.ready:
+			}
+		}
+	}
+}
+
+// processes the entry available in the pipeline
+func (p *Pipeline) process(entry pipelineEntry) {
+	// send it over to the reconciler for processing
+	k, id := entry.key, entry.id
+	if !p.startProcessing(entry) {
+		// being reconciled by another worker, which will
+		// requeue it once done
+		return
+	}
+
+	if !p.lockKey(id) {
+		// pipeline stopped while waiting for the other controllers
+		p.doneProcessing(id)
+		return
+	}
+
+	// trigger the reconciler
+	res, err := p.reconcile(k)
+	p.unlockKey(id)
+	p.complete(entry, res, err)
+}
+
+// completes the processing of the entry as per the outcome of the
+// reconcile
+func (p *Pipeline) complete(entry pipelineEntry, res *Result, err error) {
+	k, id := entry.key, entry.id
+	if dirty, prio := p.doneProcessing(id); dirty {
+		_ = p.enqueue(k, prio)
+	}
+	if err != nil {
+		if p.opts.OnError != nil {
+			p.opts.OnError(p.name, k, err)
+		}
+		if IsTerminal(err) || (p.opts.RetryableOnly && !errors.IsRetryable(err)) {
+			// retrying is pointless for the terminal errors
+			p.resetFailures(id)
+			p.stats.terminal.Add(1)
+			p.unpersist(entry)
+			return
+		}
+		// there was an error while processing the entry
+		// requeue it with backoff for processing later
+		p.retry(entry, err)
+	} else {
+		p.resetFailures(id)
+		if res != nil && (res.Requeue || res.RequeueAfter != 0) {
+			// requeue the entry after specified time, if any
+			_ = p.enqueueAfter(k, res.RequeueAfter, res.Priority)
+		} else {
+			p.unpersist(entry)
 		}
 	}
 }
 
+// triggers the reconciler for the entry, converting a panic into an
+// error, retried like any other error, to keep the worker running
+func (p *Pipeline) reconcile(k any) (res *Result, err error) {
+	ctx := p.startHook(k)
+	start := time.Now()
+	defer func() {
+		panicked := false
+		if r := recover(); r != nil {
+			panicked = true
+			p.stats.panics.Add(1)
+			p.opts.Logf("reconciler: %s panicked reconciling %v: %v\n%s", p.name, k, r, debug.Stack())
+			res, err = nil, errors.Wrapf(errors.Unknown, "reconcile of %v panicked: %v", k, r)
+		}
+		d := time.Since(start)
+		p.stats.observe(d, err)
+		p.endHook(ctx, k, d, outcomeOf(res, err, panicked), err)
+	}()
+	return p.reconciler(ctx, k)
+}
+
+// Stop stops the pipeline, no more entries are accepted and the entries
+// pending are dropped, while waiting for the reconciliations in progress
+// to complete
+func (p *Pipeline) Stop() {
+	p.closed.Store(true)
+	p.cancel()
+	p.wg.Wait()
+}
+
+// Drain stops accepting new entries, including the retries, and waits
+// for the pending entries to be reconciled before stopping the pipeline
+// returns Unavailable error if the context is done before the pipeline
+// is drained, stopping the pipeline dropping the entries still pending
+func (p *Pipeline) Drain(ctx context.Context) error {
+	p.closed.Store(true)
+	p.drainOnce.Do(func() { close(p.draining) })
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return errors.Wrapf(errors.Unavailable, "timed out draining pipeline: %s", ctx.Err())
+	}
+}
+
 // Creates a New Pipeline for queuing up and processing entries provided
 // for reconciliation
 func NewPipeline(ctx context.Context, fn reconcilerFunc) *Pipeline {
-	p := &Pipeline{
-		ctx:        ctx,
-		pMap:       sync.Map{},
-		pChannel:   make(chan any, bufferLength),
-		reconciler: fn,
-	}
+	return newPipeline(ctx, "", func(ctx context.Context, k any) (*Result, error) {
+		return fn(k)
+	}, getRegisterOptions(nil))
+}
+
+// creates the pipeline configured as per the registration options
+func newPipeline(ctx context.Context, name string, fn reconcilerCtxFunc, opts *RegisterOptions) *Pipeline {
+	p := allocPipeline(ctx, name, opts)
+	p.reconciler = fn
 
 	// initialize the pipeline before passing it externally
 	// to start the core functionality
-	go p.initialize()
+	p.initialize()
 	return p
 }
+
+// allocates the pipeline configured as per the registration options,
+// without starting the processing
+func allocPipeline(ctx context.Context, name string, opts *RegisterOptions) *Pipeline {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Pipeline{
+		ctx:        ctx,
+		cancel:     cancel,
+		draining:   make(chan struct{}),
+		pending:    map[any]Priority{},
+		weights:    opts.priorityWeights(),
+		ready:      make(chan struct{}, 1),
+		room:       make(chan struct{}, 1),
+		name:       name,
+		opts:       opts,
+		processing: map[any]processingState{},
+		failures:   map[any]int{},
+		stats:      newPipelineStats(),
+		afterFunc: func(d time.Duration, fn func()) {
+			time.AfterFunc(d, fn)
+		},
+	}
+}
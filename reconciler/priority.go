@@ -0,0 +1,107 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package reconciler
+
+import (
+	"github.com/go-core-stack/core/errors"
+)
+
+// Priority of a key enqueued for reconciliation, where the keys with
+// higher priority are reconciled ahead of the ones with lower priority,
+// eg. the user facing changes ahead of the periodic resync
+type Priority int
+
+const (
+	// priority of the bulk work, eg. the initial scan of the existing
+	// keys and the periodic resync
+	PriorityLow Priority = -1
+
+	// priority of the change notifications and the external triggers
+	PriorityNormal Priority = 0
+
+	// priority of the interactive updates, eg. the user facing changes
+	PriorityHigh Priority = 1
+
+	// number of the priority levels
+	numPriorities = 3
+)
+
+var (
+	// default share of the dequeues for the high, normal and low
+	// priority levels, while all the levels have entries pending
+	defaultPriorityWeights = PriorityWeights{High: 16, Normal: 4, Low: 1}
+)
+
+// PriorityWeights is the share of the dequeues per priority level, while
+// multiple levels have entries pending, ensuring the lower priorities
+// are not starved by a steady stream of higher priority entries
+type PriorityWeights struct {
+	High   int
+	Normal int
+	Low    int
+}
+
+// validates the priority weights
+func (w PriorityWeights) validate() error {
+	if w.High <= 0 || w.Normal <= 0 || w.Low <= 0 {
+		return errors.Wrapf(errors.InvalidArgument, "invalid priority weights %+v", w)
+	}
+	return nil
+}
+
+// WithPriorityWeights sets the share of the dequeues for the high, normal
+// and low priority levels, eg. 16, 4, 1 to reconcile 16 high priority
+// entries for every low priority entry, while both are pending
+func WithPriorityWeights(high, normal, low int) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.PriorityWeights = PriorityWeights{High: high, Normal: normal, Low: low}
+	}
+}
+
+// returns the weights indexed by the priority level
+func (o *RegisterOptions) priorityWeights() [numPriorities]int {
+	w := o.PriorityWeights
+	return [numPriorities]int{w.Low, w.Normal, w.High}
+}
+
+// returns the index of the queue for the priority, clamping the unknown
+// priorities to the nearest level
+func priorityLevel(prio Priority) int {
+	return int(min(max(prio, PriorityLow), PriorityHigh) - PriorityLow)
+}
+
+// returns the priority level to dequeue from next, using smooth weighted
+// round robin across the levels with entries pending, -1 if there are no
+// entries pending, invoked holding the queue lock
+func (p *Pipeline) nextLevel() int {
+	best, total := -1, 0
+	for level := numPriorities - 1; level >= 0; level-- {
+		if len(p.queue[level]) == 0 {
+			continue
+		}
+		p.current[level] += p.weights[level]
+		total += p.weights[level]
+		if best == -1 || p.current[level] > p.current[best] {
+			best = level
+		}
+	}
+	if best != -1 {
+		p.current[best] -= total
+	}
+	return best
+}
+
+// moves the pending entry to the level of the higher priority, invoked
+// holding the queue lock
+func (p *Pipeline) promote(id any, from int, prio Priority) {
+	to := priorityLevel(prio)
+	for i, entry := range p.queue[from] {
+		if entry.id == id {
+			p.queue[from] = append(p.queue[from][:i], p.queue[from][i+1:]...)
+			entry.prio = prio
+			p.queue[to] = append(p.queue[to], entry)
+			return
+		}
+	}
+}
@@ -5,6 +5,7 @@ package reconciler
 
 import (
 	"context"
+	base "errors"
 	"log"
 	"sync"
 	"time"
@@ -16,8 +17,46 @@ import (
 // https://github.com/kubernetes-sigs/controller-runtime/blob/main/pkg/reconcile/reconcile.go
 // enable a reconciler function
 type Result struct {
+	// Requeue tells the Controller to requeue the reconcile key right
+	// away, without any backoff, unless RequeueAfter is set
+	Requeue bool
+
 	// RequeueAfter if greater than 0, tells the Controller to requeue the reconcile key after the Duration.
 	RequeueAfter time.Duration
+
+	// Priority of the reconcile key when requeued
+	// Default: PriorityNormal
+	Priority Priority
+}
+
+// TerminalError is the error of a reconcile which is not expected to
+// succeed on retries, eg. an invalid spec, where the key is not retried
+// until notified again
+type TerminalError struct {
+	Err error
+}
+
+func (e *TerminalError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TerminalError) Unwrap() error {
+	return e.Err
+}
+
+// Terminal wraps the error of the reconcile as a terminal error,
+// suppressing the retries of the key
+func Terminal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TerminalError{Err: err}
+}
+
+// IsTerminal returns true if the error is or wraps a terminal error
+func IsTerminal(err error) bool {
+	var terr *TerminalError
+	return base.As(err, &terr)
 }
 
 type Request struct {
@@ -26,12 +65,35 @@ type Request struct {
 
 type reconcilerFunc func(k any) (*Result, error)
 
+// reconciler function receiving the context of the pipeline
+type reconcilerCtxFunc func(ctx context.Context, k any) (*Result, error)
+
 // controller interface meant for registering to database manager
 // for processing changes inoccuring to varies entries in the database
 type Controller interface {
 	Reconcile(k any) (*Result, error)
 }
 
+// ContextController is optionally implemented by the controllers needing
+// the context for the reconcile, which is then invoked instead of
+// Reconcile, where the context is derived from the context of the
+// manager and is cancelled once the controller is stopped, allowing the
+// long reconciles to be cancelled on shutdown, or to propagate the
+// deadlines and tracing into the reconcile
+type ContextController interface {
+	ReconcileCtx(ctx context.Context, k any) (*Result, error)
+}
+
+// returns the reconciler function for the controller
+func reconcilerOf(crtl Controller) reconcilerCtxFunc {
+	if c, ok := crtl.(ContextController); ok {
+		return c.ReconcileCtx
+	}
+	return func(ctx context.Context, k any) (*Result, error) {
+		return crtl.Reconcile(k)
+	}
+}
+
 // Controller data used for saving the context of a controller
 // and corresponding information along with the reconciliation
 // pipeline
@@ -39,6 +101,237 @@ type controllerData struct {
 	name     string
 	handle   Controller
 	pipeline *Pipeline
+	opts     *RegisterOptions
+}
+
+// returns true if the controller is interested in the key
+func (c *controllerData) accepts(key any) bool {
+	return c.opts.KeyFilter == nil || c.opts.KeyFilter(key)
+}
+
+// returns true if the controller is interested in the operation on the
+// key
+func (c *controllerData) acceptsOp(op string, key any) bool {
+	if c.opts.Predicate != nil && !c.opts.Predicate(op, key) {
+		return false
+	}
+	return c.accepts(key)
+}
+
+// RegisterOptions provides the configuration of a controller registered
+// with the manager
+type RegisterOptions struct {
+	// KeyFilter restricts the keys the controller is notified for, to
+	// the ones it returns true for, the other keys are not enqueued for
+	// the controller at all
+	// Default: nil (all the keys)
+	KeyFilter func(key any) bool
+
+	// Predicate restricts the operations the controller is notified
+	// for, to the ones it returns true for, eg. to ignore the deletes,
+	// where op is the operation on the key as notified by the data
+	// store, ie. insert, update, replace or delete, empty if unknown.
+	// The predicate doesn't apply to the existing keys enqueued on
+	// registration and resync
+	// Default: nil (all the operations)
+	Predicate func(op string, key any) bool
+
+	// Workers is the number of keys the controller reconciles in
+	// parallel, while a key is never reconciled in parallel with itself
+	// Default: 1
+	Workers int
+
+	// Backoff is the delay before retrying a key failing to reconcile
+	Backoff Backoff
+
+	// MaxRetries is the number of retries for a key failing to
+	// reconcile, after which the key is handed to the DeadLetter handler
+	// Default: 0 (retry forever)
+	MaxRetries int
+
+	// RetryableOnly retries only the errors considered retryable as per
+	// errors.IsRetryable, while the other errors are handled as the
+	// terminal errors, not retried until the key is notified again
+	// Default: false (all the errors are retried)
+	RetryableOnly bool
+
+	// DeadLetter is notified of the keys given up after MaxRetries, with
+	// the error of the last attempt
+	// Default: nil (the keys are dropped after logging)
+	DeadLetter func(key any, err error)
+
+	// ResyncInterval is the interval at which all the existing keys are
+	// enqueued again, healing the drift caused by the missed
+	// notifications
+	// Default: 0 (no periodic resync)
+	ResyncInterval time.Duration
+
+	// Overflow is the handling of the keys enqueued while the pipeline
+	// buffer is full
+	// Default: OverflowBlock
+	Overflow OverflowPolicy
+
+	// PriorityWeights is the share of the dequeues per priority level,
+	// while multiple levels have entries pending
+	// Default: 16, 4, 1 for high, normal and low
+	PriorityWeights PriorityWeights
+
+	// Hooks are invoked around every reconcile of the controller
+	Hooks ReconcileHooks
+
+	// Logf is the function used to log the activity of the controller
+	// Default: log.Printf
+	Logf func(format string, args ...any)
+
+	// CoalesceWindow is the duration a key is held after being
+	// enqueued, before being dispatched for reconciliation, merging the
+	// notifications for the key meanwhile
+	// Default: 0 (dispatched right away)
+	CoalesceWindow time.Duration
+
+	// records the keys pending reconciliation, restoring them after a
+	// restart, set using WithPersistence
+	persistence *persistence
+
+	// ExclusiveKeys ensures a key is never reconciled in parallel with
+	// the other controllers of the manager with ExclusiveKeys set
+	// Default: false
+	ExclusiveKeys bool
+
+	// BatchSize is the maximum number of keys handed at once to the
+	// controllers implementing BatchController
+	// Default: 100
+	BatchSize int
+
+	// OnError is notified of every failed reconcile, including the
+	// panics recovered, with the name of the controller
+	// Default: nil
+	OnError func(name string, key any, err error)
+}
+
+// RegisterOption is a functional option for registering a controller
+type RegisterOption func(*RegisterOptions)
+
+// WithKeyFilter notifies the controller only for the keys the filter
+// returns true for, eg. the keys with a specific prefix
+func WithKeyFilter(filter func(key any) bool) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.KeyFilter = filter
+	}
+}
+
+// WithPredicate notifies the controller only for the operations the
+// predicate returns true for, eg. skipping the deletes for the
+// controllers reacting only to the existing entries
+func WithPredicate(predicate func(op string, key any) bool) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.Predicate = predicate
+	}
+}
+
+// validates the registration options
+func (o *RegisterOptions) validate() error {
+	if o.Workers <= 0 {
+		return errors.Wrapf(errors.InvalidArgument, "invalid worker count %d", o.Workers)
+	}
+	if err := o.Backoff.validate(); err != nil {
+		return err
+	}
+	if o.MaxRetries < 0 {
+		return errors.Wrapf(errors.InvalidArgument, "invalid max retries %d", o.MaxRetries)
+	}
+	if o.ResyncInterval < 0 {
+		return errors.Wrapf(errors.InvalidArgument, "invalid resync interval %s", o.ResyncInterval)
+	}
+	if o.Overflow < OverflowBlock || o.Overflow > OverflowReject {
+		return errors.Wrapf(errors.InvalidArgument, "invalid overflow policy %d", o.Overflow)
+	}
+	if err := o.PriorityWeights.validate(); err != nil {
+		return err
+	}
+	if o.CoalesceWindow < 0 {
+		return errors.Wrapf(errors.InvalidArgument, "invalid coalesce window %s", o.CoalesceWindow)
+	}
+	if o.Logf == nil {
+		return errors.Wrap(errors.InvalidArgument, "logger is required")
+	}
+	if o.BatchSize <= 0 {
+		return errors.Wrapf(errors.InvalidArgument, "invalid batch size %d", o.BatchSize)
+	}
+	return nil
+}
+
+// WithWorkers reconciles up to n keys in parallel, eg. for the
+// controllers making slow external calls
+func WithWorkers(n int) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.Workers = n
+	}
+}
+
+// WithBackoff retries the keys failing to reconcile after an
+// exponentially growing delay, starting at base and capped at cap, while
+// randomizing the delay by the jitter fraction, eg. 0.1 for +/- 10%
+func WithBackoff(base time.Duration, cap time.Duration, jitter float64) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.Backoff = Backoff{Base: base, Cap: cap, Jitter: jitter}
+	}
+}
+
+// WithMaxRetries gives up on the keys failing to reconcile after n
+// retries, handing them to the dead letter handler if configured
+func WithMaxRetries(n int) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.MaxRetries = n
+	}
+}
+
+// WithRetryableOnly retries only the keys failing with the errors
+// considered retryable as per errors.IsRetryable, eg. the transient
+// data store errors, handling the other errors as terminal, including
+// the panics recovered
+func WithRetryableOnly() RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.RetryableOnly = true
+	}
+}
+
+// WithDeadLetter notifies the handler of the keys given up after the
+// maximum retries, eg. to record them for the operator to look into
+func WithDeadLetter(fn func(key any, err error)) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.DeadLetter = fn
+	}
+}
+
+// WithResyncInterval enqueues all the existing keys for reconciliation
+// by the controller periodically at the given interval
+func WithResyncInterval(d time.Duration) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.ResyncInterval = d
+	}
+}
+
+// WithOnError notifies the handler of every failed reconcile of the
+// controller, including the panics recovered, eg. to report the errors
+func WithOnError(fn func(name string, key any, err error)) RegisterOption {
+	return func(opts *RegisterOptions) {
+		opts.OnError = fn
+	}
+}
+
+func getRegisterOptions(opts []RegisterOption) *RegisterOptions {
+	ropts := &RegisterOptions{
+		Workers:         1,
+		Backoff:         defaultBackoff,
+		PriorityWeights: defaultPriorityWeights,
+		BatchSize:       defaultBatchSize,
+		Logf:            log.Printf,
+	}
+	for _, opt := range opts {
+		opt(ropts)
+	}
+	return ropts
 }
 
 // Manager interface for enforcing implementation of specific
@@ -59,21 +352,38 @@ type ManagerImpl struct {
 	parent      Manager
 	controllers sync.Map
 	ctx         context.Context
+
+	// locks serializing the reconciles of the keys across the
+	// controllers registered with exclusive keys
+	keyLocks *keyLocker
 }
 
-// callback registered with the data store
+// callback registered with the data store, for the notifications where
+// the operation is not known
 func (m *ManagerImpl) NotifyCallback(wKey any) {
+	m.NotifyOpCallback("", wKey)
+}
+
+// NotifyOpCallback notifies the registered controllers of the operation
+// on the key, as observed by the data store, ie. insert, update, replace
+// or delete
+func (m *ManagerImpl) NotifyOpCallback(op string, wKey any) {
 	// iterate over all the registered clients
 	m.controllers.Range(func(name, data any) bool {
 		crtl, ok := data.(*controllerData)
 		if !ok {
-			// this ideally should never happen
-			log.Panicln("Wrong data type of controller info received")
+			// this ideally should never happen, skip the entry while
+			// continuing to notify the other controllers
+			log.Printf("reconciler: wrong data type %T of controller info received for %v", data, name)
+			return true
+		}
+		if !crtl.acceptsOp(op, wKey) {
+			return true
 		}
 		// enqueue the entry for reconciliation
 		err := crtl.pipeline.Enqueue(wKey)
-		if err != nil {
-			log.Panicln("Failed to enqueue an entry for reconciliation", name, err)
+		if err != nil && !errors.IsUnavailable(err) {
+			crtl.opts.Logf("reconciler: %s failed to enqueue %v for reconciliation: %s", name, wKey, err)
 		}
 		return true
 	})
@@ -87,38 +397,140 @@ func (m *ManagerImpl) Initialize(ctx context.Context, parent Manager) error {
 
 	m.ctx = ctx
 	m.parent = parent
+	m.keyLocks = newKeyLocker()
 
 	return nil
 }
 
 // register a controller with manager for reconciliation
-func (m *ManagerImpl) Register(name string, crtl Controller) error {
+func (m *ManagerImpl) Register(name string, crtl Controller, opts ...RegisterOption) error {
 	if m.parent == nil {
 		return errors.Wrap(errors.InvalidArgument, "manager is not initialized")
 	}
+	ropts := getRegisterOptions(opts)
+	if err := ropts.validate(); err != nil {
+		return errors.Wrapf(errors.InvalidArgument, "invalid options for reconciler %s: %s", name, err)
+	}
+	var locks *keyLocker
+	if ropts.ExclusiveKeys {
+		if _, ok := crtl.(BatchController); ok {
+			return errors.Wrapf(errors.InvalidArgument, "exclusive keys not supported for batch reconciler %s", name)
+		}
+		locks = m.keyLocks
+	}
+	// initiate a new pipeline for reconcilation triggers, before
+	// making the controller visible to the notifications
 	data := &controllerData{
-		name:   name,
-		handle: crtl,
+		name:     name,
+		handle:   crtl,
+		pipeline: pipelineOf(m.ctx, name, crtl, ropts, locks),
+		opts:     ropts,
 	}
 	_, loaded := m.controllers.LoadOrStore(name, data)
 	if loaded {
+		data.pipeline.Stop()
 		return errors.Wrapf(errors.AlreadyExists, "Reconclier %s, already exists", name)
 	}
 
-	// initiate a new pipeline for reconcilation triggers
-	data.pipeline = NewPipeline(m.ctx, crtl.Reconcile)
-
 	// ensure triggering reconciliation of existing entries
 	// separately for reconciliation by the controller
 	go func() {
-		keys := m.parent.ReconcilerGetAllKeys()
-		for _, key := range keys {
-			err := data.pipeline.Enqueue(key)
-			if err != nil {
-				log.Panicln("failed to enqueue an entry from existing in the queue", err)
-			}
+		data.pipeline.restore()
+		m.enqueueAll(data)
+		if ropts.ResyncInterval != 0 {
+			m.resync(data)
 		}
 	}()
 
 	return nil
 }
+
+// enqueues all the existing keys for reconciliation by the controller,
+// with low priority to not delay the change notifications
+func (m *ManagerImpl) enqueueAll(data *controllerData) {
+	keys := m.parent.ReconcilerGetAllKeys()
+	for _, key := range keys {
+		if !data.accepts(key) {
+			continue
+		}
+		err := data.pipeline.EnqueueWithPriority(key, PriorityLow)
+		if err != nil {
+			if errors.IsUnavailable(err) || data.pipeline.ctx.Err() != nil {
+				// controller unregistered in between
+				return
+			}
+			data.opts.Logf("reconciler: %s failed to enqueue existing %v for reconciliation: %s", data.name, key, err)
+			return
+		}
+	}
+}
+
+// periodically enqueues all the existing keys for reconciliation by the
+// controller, until the controller is stopped
+func (m *ManagerImpl) resync(data *controllerData) {
+	ticker := time.NewTicker(data.opts.ResyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-data.pipeline.ctx.Done():
+			return
+		case <-ticker.C:
+			m.enqueueAll(data)
+		}
+	}
+}
+
+// Enqueue adds the key for reconciliation by the controller with the
+// given name, allowing the external triggers, eg. timers or API calls, to
+// feed the controller, the key is enqueued regardless of the key filter
+// of the controller
+// returns NotFound error if the controller is not registered, and
+// Unavailable error if the controller is being drained
+func (m *ManagerImpl) Enqueue(name string, key any) error {
+	data, ok := m.controllers.Load(name)
+	if !ok {
+		return errors.Wrapf(errors.NotFound, "Reconciler %s, not found", name)
+	}
+	return data.(*controllerData).pipeline.Enqueue(key)
+}
+
+// EnqueueWithPriority adds the key for reconciliation by the controller
+// with the given name and priority, eg. high priority for the user facing
+// changes to be reconciled ahead of the bulk backfills
+// returns NotFound error if the controller is not registered, and
+// Unavailable error if the controller is being drained
+func (m *ManagerImpl) EnqueueWithPriority(name string, key any, prio Priority) error {
+	data, ok := m.controllers.Load(name)
+	if !ok {
+		return errors.Wrapf(errors.NotFound, "Reconciler %s, not found", name)
+	}
+	return data.(*controllerData).pipeline.EnqueueWithPriority(key, prio)
+}
+
+// Unregister stops the controller with the given name, the keys pending
+// for the controller are dropped, while waiting for the reconciles in
+// progress to complete
+// returns NotFound error if the controller is not registered
+func (m *ManagerImpl) Unregister(name string) error {
+	data, ok := m.controllers.LoadAndDelete(name)
+	if !ok {
+		return errors.Wrapf(errors.NotFound, "Reconciler %s, not found", name)
+	}
+	data.(*controllerData).pipeline.Stop()
+	return nil
+}
+
+// Drain gracefully stops the controller with the given name, where the
+// controller stops accepting new keys and the keys already pending are
+// reconciled before stopping it, the keys failing to reconcile are not
+// retried anymore
+// returns NotFound error if the controller is not registered, and
+// Unavailable error if the context is done before the pending keys are
+// reconciled, stopping the controller anyway
+func (m *ManagerImpl) Drain(ctx context.Context, name string) error {
+	data, ok := m.controllers.LoadAndDelete(name)
+	if !ok {
+		return errors.Wrapf(errors.NotFound, "Reconciler %s, not found", name)
+	}
+	return data.(*controllerData).pipeline.Drain(ctx)
+}
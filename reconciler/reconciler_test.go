@@ -5,8 +5,13 @@ package reconciler
 
 import (
 	"context"
+	base "errors"
+	"fmt"
 	"log"
 	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -179,3 +184,1333 @@ func Test_ReconcilerBaseValidations(t *testing.T) {
 
 	tearDownMongoSetup()
 }
+
+// manager over a static set of keys, not requiring the database
+type staticManager struct {
+	ManagerImpl
+	keys []any
+}
+
+func (m *staticManager) ReconcilerGetAllKeys() []any {
+	return m.keys
+}
+
+// controller recording the reconciled keys
+type recordingController struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (c *recordingController) Reconcile(k any) (*Result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys = append(c.keys, k.(*MyKey).Name)
+	return &Result{}, nil
+}
+
+func (c *recordingController) reconciled() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.keys...)
+}
+
+func Test_ReconcilerKeyFilter(t *testing.T) {
+	m := &staticManager{
+		keys: []any{&MyKey{Name: "gpu-1"}, &MyKey{Name: "cpu-1"}},
+	}
+	if err := m.Initialize(context.Background(), m); err != nil {
+		t.Fatalf("failed to initialize manager: %s", err)
+	}
+
+	all := &recordingController{}
+	gpu := &recordingController{}
+	if err := m.Register("all", all); err != nil {
+		t.Fatalf("failed to register controller: %s", err)
+	}
+	err := m.Register("gpu", gpu, WithKeyFilter(func(k any) bool {
+		return strings.HasPrefix(k.(*MyKey).Name, "gpu-")
+	}))
+	if err != nil {
+		t.Fatalf("failed to register filtered controller: %s", err)
+	}
+	m.NotifyCallback(&MyKey{Name: "gpu-2"})
+	m.NotifyCallback(&MyKey{Name: "cpu-2"})
+
+	time.Sleep(100 * time.Millisecond)
+	if keys := all.reconciled(); len(keys) != 4 {
+		t.Errorf("expected unfiltered controller to get all the keys, got %v", keys)
+	}
+	keys := gpu.reconciled()
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"gpu-1", "gpu-2"}) {
+		t.Errorf("expected filtered controller to get only gpu keys, got %v", keys)
+	}
+}
+
+func Test_ReconcilerInvalidControllerInfo(t *testing.T) {
+	m := &staticManager{}
+	if err := m.Initialize(context.Background(), m); err != nil {
+		t.Fatalf("failed to initialize manager: %s", err)
+	}
+
+	c := &recordingController{}
+	if err := m.Register("valid", c); err != nil {
+		t.Fatalf("failed to register controller: %s", err)
+	}
+	// entry of unexpected type is skipped without affecting the others
+	m.controllers.Store("invalid", "not a controller")
+	m.NotifyCallback(&MyKey{Name: "key-1"})
+
+	time.Sleep(100 * time.Millisecond)
+	if keys := c.reconciled(); !slices.Equal(keys, []string{"key-1"}) {
+		t.Errorf("expected valid controller to be notified, got %v", keys)
+	}
+}
+
+func Test_ReconcilerPredicate(t *testing.T) {
+	m := &staticManager{
+		keys: []any{&MyKey{Name: "existing"}},
+	}
+	if err := m.Initialize(context.Background(), m); err != nil {
+		t.Fatalf("failed to initialize manager: %s", err)
+	}
+
+	crtl := &recordingController{}
+	err := m.Register("no-deletes", crtl, WithPredicate(func(op string, k any) bool {
+		return op != "delete"
+	}))
+	if err != nil {
+		t.Fatalf("failed to register controller: %s", err)
+	}
+	m.NotifyOpCallback("insert", &MyKey{Name: "inserted"})
+	m.NotifyOpCallback("delete", &MyKey{Name: "deleted"})
+	m.NotifyCallback(&MyKey{Name: "unknown"})
+
+	time.Sleep(100 * time.Millisecond)
+	keys := crtl.reconciled()
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"existing", "inserted", "unknown"}) {
+		t.Errorf("expected deletes to be skipped, got %v", keys)
+	}
+}
+
+// controller tracking the concurrency of the reconciles
+type slowController struct {
+	mu      sync.Mutex
+	active  map[string]int
+	current int
+	peak    int
+	overlap bool
+	count   int
+}
+
+func (c *slowController) Reconcile(k any) (*Result, error) {
+	name := k.(*MyKey).Name
+	c.mu.Lock()
+	c.active[name]++
+	if c.active[name] > 1 {
+		c.overlap = true
+	}
+	c.current++
+	c.peak = max(c.peak, c.current)
+	c.mu.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active[name]--
+	c.current--
+	c.count++
+	return &Result{}, nil
+}
+
+func Test_ReconcilerWorkers(t *testing.T) {
+	m := &staticManager{}
+	if err := m.Initialize(context.Background(), m); err != nil {
+		t.Fatalf("failed to initialize manager: %s", err)
+	}
+	if err := m.Register("invalid", &slowController{}, WithWorkers(0)); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for zero workers, got %v", err)
+	}
+
+	crtl := &slowController{active: map[string]int{}}
+	if err := m.Register("parallel", crtl, WithWorkers(4)); err != nil {
+		t.Fatalf("failed to register controller: %s", err)
+	}
+	for i := range 4 {
+		m.NotifyCallback(&MyKey{Name: fmt.Sprintf("key-%d", i)})
+	}
+	// notifications for a key being reconciled are serialized
+	for range 3 {
+		time.Sleep(10 * time.Millisecond)
+		m.NotifyCallback(&MyKey{Name: "key-0"})
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	crtl.mu.Lock()
+	defer crtl.mu.Unlock()
+	if crtl.peak != 4 {
+		t.Errorf("expected 4 keys reconciled in parallel, got %d", crtl.peak)
+	}
+	if crtl.overlap {
+		t.Errorf("expected a key to never be reconciled in parallel with itself")
+	}
+	if crtl.count < 5 {
+		t.Errorf("expected the notifications during reconcile to be requeued, got %d reconciles", crtl.count)
+	}
+}
+
+// controller failing the reconciles, recording the attempt times
+type failingController struct {
+	mu       sync.Mutex
+	attempts []time.Time
+}
+
+func (c *failingController) Reconcile(k any) (*Result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attempts = append(c.attempts, time.Now())
+	return nil, errors.Wrap(errors.Unknown, "test error return")
+}
+
+func Test_ReconcilerBackoff(t *testing.T) {
+	t.Run("delay", func(t *testing.T) {
+		b := Backoff{Base: 10 * time.Millisecond, Cap: 100 * time.Millisecond}
+		expected := []time.Duration{10, 20, 40, 80, 100, 100}
+		for i, d := range expected {
+			if got := b.delay(i + 1); got != d*time.Millisecond {
+				t.Errorf("expected delay %s after %d failures, got %s", d*time.Millisecond, i+1, got)
+			}
+		}
+		if got := b.delay(200); got != b.Cap {
+			t.Errorf("expected capped delay for many failures, got %s", got)
+		}
+		b.Jitter = 0.5
+		for range 100 {
+			if got := b.delay(2); got < 10*time.Millisecond || got > 30*time.Millisecond {
+				t.Errorf("expected jittered delay within bounds, got %s", got)
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		m := &staticManager{}
+		_ = m.Initialize(context.Background(), m)
+		if err := m.Register("cap", &failingController{}, WithBackoff(time.Second, time.Millisecond, 0)); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for cap below base, got %v", err)
+		}
+		if err := m.Register("retries", &failingController{}, WithMaxRetries(-1)); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for negative retries, got %v", err)
+		}
+	})
+
+	t.Run("dead letter", func(t *testing.T) {
+		m := &staticManager{}
+		_ = m.Initialize(context.Background(), m)
+		crtl := &failingController{}
+		var mu sync.Mutex
+		dead := []any{}
+		err := m.Register("failing", crtl,
+			WithBackoff(20*time.Millisecond, time.Second, 0),
+			WithMaxRetries(3),
+			WithDeadLetter(func(key any, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				dead = append(dead, key)
+			}),
+		)
+		if err != nil {
+			t.Fatalf("failed to register controller: %s", err)
+		}
+		m.NotifyCallback(&MyKey{Name: "failing"})
+		time.Sleep(300 * time.Millisecond)
+
+		crtl.mu.Lock()
+		defer crtl.mu.Unlock()
+		if len(crtl.attempts) != 4 {
+			t.Fatalf("expected 1 attempt and 3 retries, got %d", len(crtl.attempts))
+		}
+		for i := 1; i < len(crtl.attempts); i++ {
+			expected := 20 * time.Millisecond << (i - 1)
+			if gap := crtl.attempts[i].Sub(crtl.attempts[i-1]); gap < expected {
+				t.Errorf("expected retry %d after at least %s, got %s", i, expected, gap)
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(dead) != 1 || dead[0].(*MyKey).Name != "failing" {
+			t.Errorf("expected key to be dead lettered, got %v", dead)
+		}
+	})
+}
+
+func Test_ReconcilerUnregister(t *testing.T) {
+	t.Run("unregister", func(t *testing.T) {
+		m := &staticManager{}
+		_ = m.Initialize(context.Background(), m)
+		if err := m.Unregister("unknown"); !errors.IsNotFound(err) {
+			t.Errorf("expected not found for unknown controller, got %v", err)
+		}
+		crtl := &slowController{active: map[string]int{}}
+		if err := m.Register("slow", crtl); err != nil {
+			t.Fatalf("failed to register controller: %s", err)
+		}
+		for i := range 3 {
+			m.NotifyCallback(&MyKey{Name: fmt.Sprintf("key-%d", i)})
+		}
+		time.Sleep(10 * time.Millisecond)
+		if err := m.Unregister("slow"); err != nil {
+			t.Fatalf("failed to unregister: %s", err)
+		}
+		crtl.mu.Lock()
+		if crtl.current != 0 || crtl.count != 1 {
+			t.Errorf("expected in-flight reconcile to complete and pending ones dropped, got %d active, %d done", crtl.current, crtl.count)
+		}
+		crtl.mu.Unlock()
+
+		// notifications are not delivered anymore
+		m.NotifyCallback(&MyKey{Name: "key-late"})
+		time.Sleep(100 * time.Millisecond)
+		crtl.mu.Lock()
+		defer crtl.mu.Unlock()
+		if crtl.count != 1 {
+			t.Errorf("expected no reconciles after unregister, got %d", crtl.count)
+		}
+		// name can be registered again
+		if err := m.Register("slow", &slowController{active: map[string]int{}}); err != nil {
+			t.Errorf("failed to register again: %s", err)
+		}
+	})
+
+	t.Run("drain", func(t *testing.T) {
+		m := &staticManager{}
+		_ = m.Initialize(context.Background(), m)
+		crtl := &slowController{active: map[string]int{}}
+		if err := m.Register("slow", crtl, WithWorkers(2)); err != nil {
+			t.Fatalf("failed to register controller: %s", err)
+		}
+		for i := range 4 {
+			m.NotifyCallback(&MyKey{Name: fmt.Sprintf("key-%d", i)})
+		}
+		time.Sleep(10 * time.Millisecond)
+		if err := m.Drain(context.Background(), "slow"); err != nil {
+			t.Fatalf("failed to drain: %s", err)
+		}
+		crtl.mu.Lock()
+		if crtl.count != 4 {
+			t.Errorf("expected pending keys to be reconciled on drain, got %d", crtl.count)
+		}
+		crtl.mu.Unlock()
+		if err := m.Drain(context.Background(), "slow"); !errors.IsNotFound(err) {
+			t.Errorf("expected not found draining again, got %v", err)
+		}
+
+		// drain bounded by the context
+		crtl = &slowController{active: map[string]int{}}
+		_ = m.Register("slow", crtl)
+		for i := range 4 {
+			m.NotifyCallback(&MyKey{Name: fmt.Sprintf("key-%d", i)})
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 80*time.Millisecond)
+		defer cancel()
+		if err := m.Drain(ctx, "slow"); !errors.IsUnavailable(err) {
+			t.Errorf("expected unavailable on drain timeout, got %v", err)
+		}
+	})
+}
+
+func Test_ReconcilerStats(t *testing.T) {
+	m := &staticManager{}
+	_ = m.Initialize(context.Background(), m)
+	slow := &slowController{active: map[string]int{}}
+	if err := m.Register("slow", slow); err != nil {
+		t.Fatalf("failed to register controller: %s", err)
+	}
+	failing := &failingController{}
+	if err := m.Register("failing", failing, WithBackoff(time.Hour, time.Hour, 0), WithMaxRetries(1)); err != nil {
+		t.Fatalf("failed to register controller: %s", err)
+	}
+	for i := range 3 {
+		m.NotifyCallback(&MyKey{Name: fmt.Sprintf("key-%d", i)})
+	}
+	// merged into the key pending
+	m.NotifyCallback(&MyKey{Name: "key-2"})
+
+	time.Sleep(20 * time.Millisecond)
+	stats := m.Stats()
+	if s := stats["slow"]; s.QueueDepth != 2 || s.Enqueued != 3 || s.Coalesced != 1 {
+		t.Errorf("unexpected stats while reconciling %+v", s)
+	}
+	if s := stats["failing"]; s.Reconciled != 3 || s.Errors != 3 || s.Retries != 3 || s.QueueDepth != 0 {
+		t.Errorf("unexpected stats for failing controller %+v", s)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	s := m.Stats()["slow"]
+	if s.QueueDepth != 0 || s.Reconciled != 3 || s.Errors != 0 {
+		t.Errorf("unexpected stats after reconciling %+v", s)
+	}
+	// reconciles of 50ms fall in the 100ms bucket
+	if len(s.Duration.Counts) != len(s.Duration.Buckets)+1 || s.Duration.Counts[4] != 3 || s.Duration.Sum < 150*time.Millisecond {
+		t.Errorf("unexpected duration histogram %+v", s.Duration)
+	}
+}
+
+// typed controller recording the reconciled keys
+type typedRecorder struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (c *typedRecorder) Reconcile(k *MyKey) (*Result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys = append(c.keys, k.Name)
+	return &Result{}, nil
+}
+
+func Test_ReconcilerTyped(t *testing.T) {
+	m := &staticManager{
+		keys: []any{&MyKey{Name: "existing"}},
+	}
+	_ = m.Initialize(context.Background(), m)
+	crtl := &typedRecorder{}
+	if err := RegisterTyped[MyKey](m, "typed", crtl); err != nil {
+		t.Fatalf("failed to register typed controller: %s", err)
+	}
+	m.NotifyCallback(MyKey{Name: "value"})
+	// keys of other types are dropped
+	m.NotifyCallback("unexpected")
+	m.NotifyCallback(&MyData{Desc: "unexpected"})
+
+	time.Sleep(50 * time.Millisecond)
+	crtl.mu.Lock()
+	defer crtl.mu.Unlock()
+	slices.Sort(crtl.keys)
+	if !slices.Equal(crtl.keys, []string{"existing", "value"}) {
+		t.Errorf("expected typed keys to be reconciled, got %v", crtl.keys)
+	}
+	if s := m.Stats()["typed"]; s.Errors != 0 || s.Retries != 0 {
+		t.Errorf("expected mismatched keys to not be retried, got %+v", s)
+	}
+}
+
+func Test_ReconcilerResync(t *testing.T) {
+	m := &staticManager{
+		keys: []any{&MyKey{Name: "key-1"}, &MyKey{Name: "key-2"}},
+	}
+	_ = m.Initialize(context.Background(), m)
+	if err := m.Register("invalid", &recordingController{}, WithResyncInterval(-time.Second)); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for negative interval, got %v", err)
+	}
+	crtl := &recordingController{}
+	if err := m.Register("resync", crtl, WithResyncInterval(50*time.Millisecond)); err != nil {
+		t.Fatalf("failed to register controller: %s", err)
+	}
+	time.Sleep(130 * time.Millisecond)
+	if keys := crtl.reconciled(); len(keys) != 6 {
+		t.Errorf("expected initial reconcile and 2 resyncs of all keys, got %v", keys)
+	}
+
+	// resync stops along with the controller
+	_ = m.Unregister("resync")
+	count := len(crtl.reconciled())
+	time.Sleep(100 * time.Millisecond)
+	if keys := crtl.reconciled(); len(keys) != count {
+		t.Errorf("expected no resync after unregister, got %d reconciles", len(keys))
+	}
+}
+
+func Test_ReconcilerEnqueue(t *testing.T) {
+	m := &staticManager{}
+	_ = m.Initialize(context.Background(), m)
+	if err := m.Enqueue("unknown", &MyKey{Name: "key"}); !errors.IsNotFound(err) {
+		t.Errorf("expected not found for unknown controller, got %v", err)
+	}
+	crtl := &recordingController{}
+	err := m.Register("external", crtl, WithKeyFilter(func(k any) bool { return false }))
+	if err != nil {
+		t.Fatalf("failed to register controller: %s", err)
+	}
+	// explicit enqueue bypasses the key filter
+	if err := m.Enqueue("external", &MyKey{Name: "now"}); err != nil {
+		t.Fatalf("failed to enqueue: %s", err)
+	}
+	data, _ := m.controllers.Load("external")
+	p := data.(*controllerData).pipeline
+	if err := p.EnqueueAfter(&MyKey{Name: "later"}, 100*time.Millisecond); err != nil {
+		t.Fatalf("failed to enqueue after delay: %s", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if keys := crtl.reconciled(); !slices.Equal(keys, []string{"now"}) {
+		t.Errorf("expected only the immediate key reconciled, got %v", keys)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if keys := crtl.reconciled(); !slices.Equal(keys, []string{"now", "later"}) {
+		t.Errorf("expected the delayed key reconciled, got %v", keys)
+	}
+
+	// delayed keys are dropped once the controller is stopped
+	_ = p.EnqueueAfter(&MyKey{Name: "dropped"}, 50*time.Millisecond)
+	_ = m.Unregister("external")
+	if err := p.EnqueueAfter(&MyKey{Name: "rejected"}, time.Millisecond); !errors.IsUnavailable(err) {
+		t.Errorf("expected unavailable after stop, got %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if keys := crtl.reconciled(); len(keys) != 2 {
+		t.Errorf("expected no reconciles after stop, got %v", keys)
+	}
+}
+
+// controller blocking the reconcile until the context is cancelled
+type contextController struct {
+	started   chan struct{}
+	cancelled chan error
+}
+
+func (c *contextController) Reconcile(k any) (*Result, error) {
+	panic("expected ReconcileCtx to be invoked")
+}
+
+func (c *contextController) ReconcileCtx(ctx context.Context, k any) (*Result, error) {
+	close(c.started)
+	<-ctx.Done()
+	c.cancelled <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func Test_ReconcilerContext(t *testing.T) {
+	m := &staticManager{}
+	_ = m.Initialize(context.Background(), m)
+	crtl := &contextController{started: make(chan struct{}), cancelled: make(chan error, 1)}
+	if err := m.Register("ctx", crtl); err != nil {
+		t.Fatalf("failed to register controller: %s", err)
+	}
+	m.NotifyCallback(&MyKey{Name: "long"})
+	<-crtl.started
+
+	// stopping the controller cancels the reconcile in progress
+	if err := m.Unregister("ctx"); err != nil {
+		t.Fatalf("failed to unregister: %s", err)
+	}
+	select {
+	case err := <-crtl.cancelled:
+		if err != context.Canceled {
+			t.Errorf("expected reconcile context to be cancelled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("expected reconcile to be cancelled on unregister")
+	}
+}
+
+// controller blocking the reconciles until released
+type blockingController struct {
+	release chan struct{}
+	mu      sync.Mutex
+	count   int
+}
+
+func (c *blockingController) Reconcile(k any) (*Result, error) {
+	<-c.release
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.count++
+	return &Result{}, nil
+}
+
+func Test_ReconcilerOverflow(t *testing.T) {
+	m := &staticManager{}
+	_ = m.Initialize(context.Background(), m)
+	if err := m.Register("invalid", &recordingController{}, WithOverflow(OverflowPolicy(10))); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for unknown policy, got %v", err)
+	}
+
+	t.Run("grow", func(t *testing.T) {
+		crtl := &blockingController{release: make(chan struct{})}
+		if err := m.Register("grow", crtl, WithOverflow(OverflowGrow)); err != nil {
+			t.Fatalf("failed to register controller: %s", err)
+		}
+		total := bufferLength + 10
+		for i := range total {
+			if err := m.Enqueue("grow", &MyKey{Name: fmt.Sprintf("key-%d", i)}); err != nil {
+				t.Fatalf("expected enqueue to not block or fail, got %s", err)
+			}
+		}
+		s := m.Stats()["grow"]
+		// one of the keys is picked up by the worker
+		if s.QueueDepth < total-1 || s.Overflows == 0 {
+			t.Errorf("expected pipeline to grow beyond buffer, got %+v", s)
+		}
+		close(crtl.release)
+		time.Sleep(200 * time.Millisecond)
+		crtl.mu.Lock()
+		defer crtl.mu.Unlock()
+		if crtl.count != total {
+			t.Errorf("expected all %d keys reconciled, got %d", total, crtl.count)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		crtl := &blockingController{release: make(chan struct{})}
+		if err := m.Register("reject", crtl, WithOverflow(OverflowReject)); err != nil {
+			t.Fatalf("failed to register controller: %s", err)
+		}
+		rejected := 0
+		for i := range bufferLength + 10 {
+			err := m.Enqueue("reject", &MyKey{Name: fmt.Sprintf("key-%d", i)})
+			if errors.IsUnavailable(err) {
+				rejected++
+			}
+		}
+		s := m.Stats()["reject"]
+		if rejected == 0 || s.Overflows != uint64(rejected) || s.QueueDepth > bufferLength {
+			t.Errorf("expected keys beyond buffer rejected, got %d rejected, %+v", rejected, s)
+		}
+		// notifications don't block while full
+		done := make(chan struct{})
+		go func() {
+			m.NotifyCallback(&MyKey{Name: "notified"})
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Errorf("expected notification to not block on full pipeline")
+		}
+		close(crtl.release)
+	})
+}
+
+// controller panicking on the first reconcile of every key
+type panickingController struct {
+	mu    sync.Mutex
+	seen  map[string]bool
+	count int
+}
+
+func (c *panickingController) Reconcile(k any) (*Result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := k.(*MyKey).Name
+	if !c.seen[name] {
+		c.seen[name] = true
+		panic("test panic")
+	}
+	c.count++
+	return &Result{}, nil
+}
+
+func Test_ReconcilerPanic(t *testing.T) {
+	m := &staticManager{}
+	_ = m.Initialize(context.Background(), m)
+	crtl := &panickingController{seen: map[string]bool{}}
+	var mu sync.Mutex
+	reported := []string{}
+	err := m.Register("panicking", crtl, WithOnError(func(name string, key any, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, name+":"+key.(*MyKey).Name)
+	}))
+	if err != nil {
+		t.Fatalf("failed to register controller: %s", err)
+	}
+	m.NotifyCallback(&MyKey{Name: "key-1"})
+	m.NotifyCallback(&MyKey{Name: "key-2"})
+	time.Sleep(100 * time.Millisecond)
+
+	// pipeline survives the panics, retrying the keys
+	crtl.mu.Lock()
+	if crtl.count != 2 {
+		t.Errorf("expected the keys to be reconciled on retry, got %d", crtl.count)
+	}
+	crtl.mu.Unlock()
+	if s := m.Stats()["panicking"]; s.Panics != 2 || s.Errors != 2 {
+		t.Errorf("expected panics to be accounted, got %+v", s)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	slices.Sort(reported)
+	if !slices.Equal(reported, []string{"panicking:key-1", "panicking:key-2"}) {
+		t.Errorf("expected panics reported to the error hook, got %v", reported)
+	}
+}
+
+// controller recording the reconciled keys, blocking the reconcile of
+// the gate key until released
+type gatedController struct {
+	recordingController
+	started chan struct{}
+	release chan struct{}
+}
+
+func (c *gatedController) Reconcile(k any) (*Result, error) {
+	if k.(*MyKey).Name == "gate" {
+		close(c.started)
+		<-c.release
+	}
+	return c.recordingController.Reconcile(k)
+}
+
+func Test_ReconcilerPriority(t *testing.T) {
+	m := &staticManager{}
+	_ = m.Initialize(context.Background(), m)
+	if err := m.Register("invalid", &recordingController{}, WithPriorityWeights(1, 0, 1)); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for zero weight, got %v", err)
+	}
+
+	crtl := &gatedController{started: make(chan struct{}), release: make(chan struct{})}
+	if err := m.Register("priority", crtl); err != nil {
+		t.Fatalf("failed to register controller: %s", err)
+	}
+	_ = m.Enqueue("priority", &MyKey{Name: "gate"})
+	<-crtl.started
+
+	// backfill queued ahead of the interactive updates
+	for i := range 10 {
+		_ = m.EnqueueWithPriority("priority", &MyKey{Name: fmt.Sprintf("low-%d", i)}, PriorityLow)
+	}
+	for i := range 5 {
+		_ = m.EnqueueWithPriority("priority", &MyKey{Name: fmt.Sprintf("high-%d", i)}, PriorityHigh)
+	}
+	// pending key promoted on enqueue with higher priority
+	_ = m.EnqueueWithPriority("priority", &MyKey{Name: "low-9"}, PriorityHigh)
+	if s := m.Stats()["priority"]; s.QueueDepth != 15 || s.Coalesced != 1 {
+		t.Errorf("expected promoted key to be coalesced, got %+v", s)
+	}
+	close(crtl.release)
+	time.Sleep(100 * time.Millisecond)
+
+	keys := crtl.reconciled()
+	if len(keys) != 16 {
+		t.Fatalf("expected all the keys to be reconciled, got %v", keys)
+	}
+	expected := []string{"gate", "high-0", "high-1", "high-2", "high-3", "high-4", "low-9", "low-0"}
+	if !slices.Equal(keys[:len(expected)], expected) {
+		t.Errorf("expected high priority keys reconciled first, got %v", keys)
+	}
+
+	t.Run("weighted", func(t *testing.T) {
+		p := newPipeline(context.Background(), "weighted", func(ctx context.Context, k any) (*Result, error) {
+			return &Result{}, nil
+		}, getRegisterOptions([]RegisterOption{WithPriorityWeights(2, 1, 1)}))
+		p.Stop()
+		for i := range 4 {
+			p.queue[priorityLevel(PriorityHigh)] = append(p.queue[priorityLevel(PriorityHigh)], pipelineEntry{key: fmt.Sprintf("h%d", i)})
+			p.queue[priorityLevel(PriorityLow)] = append(p.queue[priorityLevel(PriorityLow)], pipelineEntry{key: fmt.Sprintf("l%d", i)})
+		}
+		order := []string{}
+		for {
+			entry, ok := p.dequeue()
+			if !ok {
+				break
+			}
+			order = append(order, entry.key.(string))
+		}
+		// low priority keys are not starved by the high priority ones
+		expected := []string{"h0", "l0", "h1", "h2", "l1", "h3", "l2", "l3"}
+		if !slices.Equal(order, expected) {
+			t.Errorf("expected weighted interleaving %v, got %v", expected, order)
+		}
+	})
+
+	// pipeline without the workers, with the retries scheduled right
+	// away, holding the backfill pending
+	newRequeuePipeline := func(t *testing.T) *Pipeline {
+		p := allocPipeline(context.Background(), "requeue", getRegisterOptions(nil))
+		p.afterFunc = func(d time.Duration, fn func()) { fn() }
+		t.Cleanup(p.Stop)
+		for i := range 3 {
+			_ = p.EnqueueWithPriority(&MyKey{Name: fmt.Sprintf("low-%d", i)}, PriorityLow)
+		}
+		return p
+	}
+	dequeueFirst := func(t *testing.T, p *Pipeline) pipelineEntry {
+		entry, ok := p.dequeue()
+		if !ok {
+			t.Fatalf("expected entries pending")
+		}
+		return entry
+	}
+
+	t.Run("requeue_dirty", func(t *testing.T) {
+		k := &MyKey{Name: "busy"}
+		p := newRequeuePipeline(t)
+		entry := pipelineEntry{key: k, id: keyID(k), prio: PriorityLow}
+		if !p.startProcessing(entry) {
+			t.Fatalf("expected entry to be processed")
+		}
+		// user facing change arrives while being reconciled
+		if p.startProcessing(pipelineEntry{key: k, id: keyID(k), prio: PriorityHigh}) {
+			t.Fatalf("expected entry being reconciled to be marked dirty")
+		}
+		if p.startProcessing(pipelineEntry{key: k, id: keyID(k), prio: PriorityNormal}) {
+			t.Fatalf("expected entry being reconciled to be marked dirty")
+		}
+		p.complete(entry, &Result{}, nil)
+		next := dequeueFirst(t, p)
+		if next.key != k || next.prio != PriorityHigh {
+			t.Errorf("expected dirty key requeued ahead with high priority, got %v with priority %d", next.key, next.prio)
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		k := &MyKey{Name: "failing"}
+		p := newRequeuePipeline(t)
+		entry := pipelineEntry{key: k, id: keyID(k), prio: PriorityHigh}
+		if !p.startProcessing(entry) {
+			t.Fatalf("expected entry to be processed")
+		}
+		p.complete(entry, nil, errors.Wrap(errors.Unknown, "failed"))
+		next := dequeueFirst(t, p)
+		if next.key != k || next.prio != PriorityHigh {
+			t.Errorf("expected failed key retried ahead with high priority, got %v with priority %d", next.key, next.prio)
+		}
+	})
+}
+
+// batch controller recording the batches, blocking the batch with the
+// gate key until released, and failing the first attempt of the keys
+// prefixed with fail
+type batchRecorder struct {
+	started chan struct{}
+	release chan struct{}
+	mu      sync.Mutex
+	batches [][]string
+	failed  map[string]bool
+}
+
+func (c *batchRecorder) Reconcile(k any) (*Result, error) {
+	panic("unexpected reconcile of single key for batch controller")
+}
+
+func (c *batchRecorder) ReconcileBatch(ctx context.Context, keys []any) []BatchResult {
+	names := []string{}
+	for _, k := range keys {
+		names = append(names, k.(*MyKey).Name)
+	}
+	if slices.Contains(names, "gate") {
+		close(c.started)
+		<-c.release
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batches = append(c.batches, names)
+	var results []BatchResult
+	for i, name := range names {
+		if strings.HasPrefix(name, "fail") && !c.failed[name] {
+			c.failed[name] = true
+			if results == nil {
+				results = make([]BatchResult, len(keys))
+			}
+			results[i].Err = errors.Wrap(errors.Unknown, "failed")
+		}
+	}
+	return results
+}
+
+func Test_ReconcilerBatch(t *testing.T) {
+	m := &staticManager{}
+	_ = m.Initialize(context.Background(), m)
+	if err := m.Register("invalid", &recordingController{}, WithBatchSize(0)); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for zero batch size, got %v", err)
+	}
+
+	crtl := &batchRecorder{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+		failed:  map[string]bool{},
+	}
+	if err := m.Register("batch", crtl, WithBatchSize(4), WithBackoff(time.Millisecond, time.Millisecond, 0)); err != nil {
+		t.Fatalf("failed to register controller: %s", err)
+	}
+	_ = m.Enqueue("batch", &MyKey{Name: "gate"})
+	<-crtl.started
+	for i := range 9 {
+		_ = m.Enqueue("batch", &MyKey{Name: fmt.Sprintf("key-%d", i)})
+	}
+	_ = m.Enqueue("batch", &MyKey{Name: "fail-1"})
+	close(crtl.release)
+	time.Sleep(100 * time.Millisecond)
+
+	crtl.mu.Lock()
+	defer crtl.mu.Unlock()
+	sizes := []int{}
+	for _, b := range crtl.batches {
+		sizes = append(sizes, len(b))
+	}
+	if !slices.Equal(sizes, []int{1, 4, 4, 2, 1}) {
+		t.Errorf("expected pending keys reconciled in batches, got %v", crtl.batches)
+	}
+	if last := crtl.batches[len(crtl.batches)-1]; !slices.Equal(last, []string{"fail-1"}) {
+		t.Errorf("expected failed key to be retried alone, got %v", last)
+	}
+	if s := m.Stats()["batch"]; s.Reconciled != 12 || s.Errors != 1 || s.Retries != 1 {
+		t.Errorf("expected batch reconciles accounted per key, got %+v", s)
+	}
+}
+
+type hookCtxKey struct{}
+
+// controller reporting the outcome as per the key, while recording the
+// value of the context set by the start hook
+type hookedController struct {
+	mu   sync.Mutex
+	seen []string
+}
+
+func (c *hookedController) Reconcile(k any) (*Result, error) {
+	return c.ReconcileCtx(context.Background(), k)
+}
+
+func (c *hookedController) ReconcileCtx(ctx context.Context, k any) (*Result, error) {
+	c.mu.Lock()
+	if v, ok := ctx.Value(hookCtxKey{}).(string); ok {
+		c.seen = append(c.seen, v)
+	}
+	c.mu.Unlock()
+	switch k.(*MyKey).Name {
+	case "requeue":
+		return &Result{RequeueAfter: time.Hour}, nil
+	case "fail":
+		return nil, errors.Wrap(errors.Unknown, "failed")
+	case "panic":
+		panic("test panic")
+	}
+	return &Result{}, nil
+}
+
+func Test_ReconcilerHooks(t *testing.T) {
+	m := &staticManager{}
+	_ = m.Initialize(context.Background(), m)
+	if err := m.Register("invalid", &recordingController{}, WithLogger(nil)); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument without logger, got %v", err)
+	}
+
+	var mu sync.Mutex
+	started := 0
+	outcomes := map[string][]Outcome{}
+	logs := []string{}
+	hooks := ReconcileHooks{
+		OnStart: func(ctx context.Context, name string, key any) context.Context {
+			mu.Lock()
+			defer mu.Unlock()
+			started++
+			return context.WithValue(ctx, hookCtxKey{}, name+":"+key.(*MyKey).Name)
+		},
+		OnEnd: func(ctx context.Context, info ReconcileInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			if ctx.Value(hookCtxKey{}) == nil {
+				t.Errorf("expected the context returned by start hook")
+			}
+			if info.Controller != "hooked" || (info.Err != nil) != (info.Outcome >= OutcomeError) {
+				t.Errorf("unexpected reconcile info %+v", info)
+			}
+			name := info.Key.(*MyKey).Name
+			outcomes[name] = append(outcomes[name], info.Outcome)
+		},
+	}
+	logf := func(format string, args ...any) {
+		mu.Lock()
+		defer mu.Unlock()
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}
+	crtl := &hookedController{}
+	err := m.Register("hooked", crtl, WithHooks(hooks), WithLogger(logf),
+		WithMaxRetries(1), WithBackoff(time.Millisecond, time.Millisecond, 0))
+	if err != nil {
+		t.Fatalf("failed to register controller: %s", err)
+	}
+	for _, name := range []string{"ok", "requeue", "fail", "panic"} {
+		m.NotifyCallback(&MyKey{Name: name})
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := map[string][]Outcome{
+		"ok":      {OutcomeSuccess},
+		"requeue": {OutcomeRequeue},
+		"fail":    {OutcomeError, OutcomeError},
+		"panic":   {OutcomePanic, OutcomePanic},
+	}
+	for name, o := range expected {
+		if !slices.Equal(outcomes[name], o) {
+			t.Errorf("expected outcomes %v for %s, got %v", o, name, outcomes[name])
+		}
+	}
+	crtl.mu.Lock()
+	if started != 6 || len(crtl.seen) != 6 {
+		t.Errorf("expected start hook context passed to reconciles, got %d started, %v", started, crtl.seen)
+	}
+	crtl.mu.Unlock()
+	joined := strings.Join(logs, "\n")
+	if !strings.Contains(joined, "panicked") || strings.Count(joined, "giving up") != 2 {
+		t.Errorf("expected panics and dead letters logged to the logger, got %v", logs)
+	}
+}
+
+// controller failing the first attempt of the keys prefixed with fail,
+// and requeueing the keys prefixed with requeue after a minute
+type scriptedController struct {
+	recordingController
+	failed map[string]bool
+}
+
+func (c *scriptedController) Reconcile(k any) (*Result, error) {
+	name := k.(*MyKey).Name
+	if strings.HasPrefix(name, "fail") && !c.failed[name] {
+		c.failed[name] = true
+		return nil, errors.Wrap(errors.Unknown, "failed")
+	}
+	res, err := c.recordingController.Reconcile(k)
+	if strings.HasPrefix(name, "requeue") {
+		res.RequeueAfter = time.Minute
+	}
+	return res, err
+}
+
+func Test_ReconcilerHarness(t *testing.T) {
+	if _, err := NewHarness(&recordingController{}, WithWorkers(0)); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for invalid options, got %v", err)
+	}
+
+	crtl := &scriptedController{failed: map[string]bool{}}
+	h, err := NewHarness(crtl, WithBackoff(time.Second, time.Second, 0),
+		WithPredicate(func(op string, k any) bool { return op != "delete" }))
+	if err != nil {
+		t.Fatalf("failed to create harness: %s", err)
+	}
+	defer h.Close()
+
+	h.Resync(&MyKey{Name: "existing"})
+	if h.Notify("delete", &MyKey{Name: "deleted"}) {
+		t.Errorf("expected delete to be skipped by the predicate")
+	}
+	h.Notify("update", &MyKey{Name: "requeue-1"})
+	h.Notify("insert", &MyKey{Name: "fail-1"})
+	if !h.ProcessNext() || !slices.Equal(crtl.reconciled(), []string{"requeue-1"}) {
+		t.Errorf("expected the notified keys ahead of resync, got %v", crtl.reconciled())
+	}
+	if n := h.Flush(); n != 2 || h.Len() != 0 {
+		t.Errorf("expected flush to process the pending keys, got %d processed, %d pending", n, h.Len())
+	}
+	if keys := crtl.reconciled(); !slices.Equal(keys, []string{"requeue-1", "existing"}) {
+		t.Errorf("expected failed key to be delayed, got %v", keys)
+	}
+
+	// retry is due after the backoff
+	h.Clock().Advance(999 * time.Millisecond)
+	if h.Len() != 0 {
+		t.Errorf("expected no retry before backoff")
+	}
+	h.Clock().Advance(time.Millisecond)
+	h.Flush()
+	if keys := crtl.reconciled(); !slices.Equal(keys, []string{"requeue-1", "existing", "fail-1"}) {
+		t.Errorf("expected failed key to be retried, got %v", keys)
+	}
+
+	// requeue is due after a minute
+	h.Clock().Advance(time.Minute)
+	h.Flush()
+	if keys := crtl.reconciled(); len(keys) != 4 || keys[3] != "requeue-1" {
+		t.Errorf("expected key to be requeued, got %v", keys)
+	}
+	if s := h.Stats(); s.Reconciled != 5 || s.Errors != 1 || s.Retries != 1 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+}
+
+func Test_ReconcilerExclusiveKeys(t *testing.T) {
+	for _, exclusive := range []bool{false, true} {
+		t.Run(fmt.Sprintf("exclusive-%v", exclusive), func(t *testing.T) {
+			m := &staticManager{}
+			_ = m.Initialize(context.Background(), m)
+			opts := []RegisterOption{}
+			if exclusive {
+				opts = append(opts, WithExclusiveKeys())
+			}
+			// controllers sharing the same underlying state
+			crtl := &slowController{active: map[string]int{}}
+			for _, name := range []string{"first", "second"} {
+				if err := m.Register(name, crtl, opts...); err != nil {
+					t.Fatalf("failed to register controller: %s", err)
+				}
+			}
+			for i := range 3 {
+				m.NotifyCallback(&MyKey{Name: fmt.Sprintf("key-%d", i)})
+			}
+			time.Sleep(400 * time.Millisecond)
+
+			crtl.mu.Lock()
+			defer crtl.mu.Unlock()
+			if crtl.count != 6 {
+				t.Errorf("expected keys reconciled by both controllers, got %d", crtl.count)
+			}
+			if crtl.overlap == exclusive || crtl.peak < 2 {
+				t.Errorf("expected overlap %v across controllers, got overlap %v, peak %d", !exclusive, crtl.overlap, crtl.peak)
+			}
+		})
+	}
+
+	t.Run("batch", func(t *testing.T) {
+		m := &staticManager{}
+		_ = m.Initialize(context.Background(), m)
+		crtl := &batchRecorder{failed: map[string]bool{}}
+		if err := m.Register("batch", crtl, WithExclusiveKeys()); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for batch controller, got %v", err)
+		}
+	})
+
+	t.Run("ordered", func(t *testing.T) {
+		l := newKeyLocker()
+		l.lock(context.Background(), "key")
+		var mu sync.Mutex
+		order := []int{}
+		done := make(chan struct{})
+		for i := range 3 {
+			go func() {
+				if l.lock(context.Background(), "key") {
+					mu.Lock()
+					order = append(order, i)
+					mu.Unlock()
+					l.unlock("key")
+				}
+				done <- struct{}{}
+			}()
+			// wait for the waiter to queue up, before the next one
+			for {
+				l.mu.Lock()
+				n := len(l.held["key"])
+				l.mu.Unlock()
+				if n == i+1 {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}
+		// waiter cancelled while waiting is skipped
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			if l.lock(ctx, "key") {
+				t.Errorf("expected cancelled waiter to not get the key")
+			}
+			done <- struct{}{}
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		<-done
+		l.unlock("key")
+		for range 3 {
+			<-done
+		}
+		if !slices.Equal(order, []int{0, 1, 2}) {
+			t.Errorf("expected waiters handed the key in order, got %v", order)
+		}
+		if len(l.held) != 0 {
+			t.Errorf("expected key released, got %v", l.held)
+		}
+	})
+}
+
+// controller returning the result or the error as per the key, for the
+// first attempt of the key
+type resultController struct {
+	recordingController
+	attempts map[string]int
+}
+
+func (c *resultController) Reconcile(k any) (*Result, error) {
+	name := k.(*MyKey).Name
+	c.attempts[name]++
+	if c.attempts[name] > 1 {
+		return c.recordingController.Reconcile(k)
+	}
+	switch name {
+	case "invalid":
+		return nil, fmt.Errorf("validating: %w", Terminal(errors.Wrap(errors.InvalidArgument, "invalid spec")))
+	case "requeue":
+		return &Result{Requeue: true, Priority: PriorityHigh}, nil
+	case "requeue-after":
+		return &Result{Requeue: true, RequeueAfter: time.Minute}, nil
+	}
+	return c.recordingController.Reconcile(k)
+}
+
+func Test_ReconcilerResult(t *testing.T) {
+	if Terminal(nil) != nil {
+		t.Errorf("expected nil error to not be wrapped")
+	}
+	err := Terminal(errors.Wrap(errors.InvalidArgument, "invalid"))
+	if !IsTerminal(err) || IsTerminal(errors.Wrap(errors.Unknown, "failed")) {
+		t.Errorf("expected terminal errors to be recognized")
+	}
+	if !errors.IsInvalidArgument(base.Unwrap(err)) {
+		t.Errorf("expected terminal error to unwrap the cause")
+	}
+
+	crtl := &resultController{attempts: map[string]int{}}
+	h, err := NewHarness(crtl)
+	if err != nil {
+		t.Fatalf("failed to create harness: %s", err)
+	}
+	defer h.Close()
+	for _, name := range []string{"invalid", "requeue-after", "requeue", "other-1", "other-2"} {
+		h.Notify("update", &MyKey{Name: name})
+	}
+	h.Flush()
+	// immediate requeue with high priority goes ahead of the pending keys
+	if keys := crtl.reconciled(); !slices.Equal(keys, []string{"requeue", "other-1", "other-2"}) {
+		t.Errorf("expected immediate requeue ahead of pending keys, got %v", keys)
+	}
+	h.Clock().Advance(time.Hour)
+	h.Flush()
+	if keys := crtl.reconciled(); !slices.Equal(keys, []string{"requeue", "other-1", "other-2", "requeue-after"}) {
+		t.Errorf("expected delayed requeue, got %v", keys)
+	}
+	if crtl.attempts["invalid"] != 1 {
+		t.Errorf("expected terminal error to not be retried, got %d attempts", crtl.attempts["invalid"])
+	}
+	if s := h.Stats(); s.Terminal != 1 || s.Retries != 0 || s.Errors != 1 {
+		t.Errorf("expected terminal error accounted without retries, got %+v", s)
+	}
+
+	t.Run("retryable only", func(t *testing.T) {
+		crtl := &retryableController{attempts: map[string]int{}}
+		h, err := NewHarness(crtl, WithRetryableOnly(), WithBackoff(time.Second, time.Second, 0))
+		if err != nil {
+			t.Fatalf("failed to create harness: %s", err)
+		}
+		defer h.Close()
+		h.Notify("update", &MyKey{Name: "unavailable"})
+		h.Notify("update", &MyKey{Name: "invalid"})
+		h.Flush()
+		h.Clock().Advance(time.Second)
+		h.Flush()
+		if crtl.attempts["unavailable"] != 2 || crtl.attempts["invalid"] != 1 {
+			t.Errorf("expected only the retryable error to be retried, got %v", crtl.attempts)
+		}
+		if s := h.Stats(); s.Terminal != 1 || s.Retries != 1 {
+			t.Errorf("expected non retryable error handled as terminal, got %+v", s)
+		}
+	})
+}
+
+type retryableController struct {
+	recordingController
+	attempts map[string]int
+}
+
+func (c *retryableController) Reconcile(k any) (*Result, error) {
+	name := k.(*MyKey).Name
+	c.attempts[name]++
+	if c.attempts[name] > 1 {
+		return c.recordingController.Reconcile(k)
+	}
+	switch name {
+	case "unavailable":
+		return nil, errors.Wrap(errors.Unavailable, "store unavailable")
+	case "invalid":
+		return nil, errors.Wrap(errors.InvalidArgument, "invalid spec")
+	}
+	return c.recordingController.Reconcile(k)
+}
+
+func Test_ReconcilerCoalesceWindow(t *testing.T) {
+	if _, err := NewHarness(&recordingController{}, WithCoalesceWindow(-time.Second)); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for negative window, got %v", err)
+	}
+
+	crtl := &recordingController{}
+	h, err := NewHarness(crtl, WithCoalesceWindow(100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create harness: %s", err)
+	}
+	defer h.Close()
+	h.Notify("update", &MyKey{Name: "key-1"})
+	h.Resync(&MyKey{Name: "key-2"})
+	if h.Flush() != 0 || h.Len() != 2 {
+		t.Errorf("expected keys held for the window, got %d pending", h.Len())
+	}
+	h.Clock().Advance(50 * time.Millisecond)
+	// burst of updates merged into the held keys
+	for range 5 {
+		h.Notify("update", &MyKey{Name: "key-1"})
+		_ = h.EnqueueWithPriority(&MyKey{Name: "key-2"}, PriorityHigh)
+	}
+	h.Flush()
+	if keys := crtl.reconciled(); len(keys) != 0 {
+		t.Errorf("expected no reconcile within the window, got %v", keys)
+	}
+	h.Clock().Advance(50 * time.Millisecond)
+	h.Flush()
+	// key promoted within the window dispatched with its priority
+	if keys := crtl.reconciled(); !slices.Equal(keys, []string{"key-2", "key-1"}) {
+		t.Errorf("expected the held keys reconciled once, got %v", keys)
+	}
+	if s := h.Stats(); s.Enqueued != 2 || s.Coalesced != 10 {
+		t.Errorf("expected notifications coalesced, got %+v", s)
+	}
+
+	t.Run("drain", func(t *testing.T) {
+		m := &staticManager{}
+		_ = m.Initialize(context.Background(), m)
+		crtl := &recordingController{}
+		err := m.Register("window", crtl, WithCoalesceWindow(50*time.Millisecond), WithWorkers(3))
+		if err != nil {
+			t.Fatalf("failed to register controller: %s", err)
+		}
+		m.NotifyCallback(&MyKey{Name: "key-1"})
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := m.Drain(ctx, "window"); err != nil {
+			t.Errorf("failed to drain: %s", err)
+		}
+		if keys := crtl.reconciled(); !slices.Equal(keys, []string{"key-1"}) {
+			t.Errorf("expected held key reconciled on drain, got %v", keys)
+		}
+	})
+}
+
+func Test_ReconcilerPersistence(t *testing.T) {
+	col := db.NewMemoryClient().GetDataStore("test-reconciler").GetCollection("reconciler-pending")
+
+	// keys pending with the controller stopped before reconciling them
+	crtl := &scriptedController{failed: map[string]bool{}}
+	h, err := NewHarness(crtl, WithPersistence[MyKey](col))
+	if err != nil {
+		t.Fatalf("failed to create harness: %s", err)
+	}
+	h.Notify("update", &MyKey{Name: "key-1"})
+	h.Notify("update", &MyKey{Name: "requeue-1"})
+	h.Notify("update", &MyKey{Name: "key-2"})
+	h.ProcessNext()
+	h.ProcessNext()
+	h.Close()
+	if keys := crtl.reconciled(); !slices.Equal(keys, []string{"key-1", "requeue-1"}) {
+		t.Fatalf("unexpected keys reconciled %v", keys)
+	}
+	if count, _ := col.Count(context.Background(), bson.D{}); count != 2 {
+		t.Errorf("expected pending and delayed keys persisted, got %d", count)
+	}
+
+	// keys restored on registration after restart
+	m := &staticManager{}
+	_ = m.Initialize(context.Background(), m)
+	restored := &recordingController{}
+	if err := m.Register("harness", restored, WithPersistence[MyKey](col)); err != nil {
+		t.Fatalf("failed to register controller: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if keys := restored.reconciled(); !slices.Equal(keys, []string{"key-2"}) {
+		t.Errorf("expected pending key restored, while delayed key still due, got %v", keys)
+	}
+	list := []persistedRecord{}
+	if err := col.FindMany(context.Background(), bson.D{}, &list); err != nil || len(list) != 1 {
+		t.Fatalf("expected only delayed key persisted, got %v, %v", list, err)
+	}
+	if d := time.Until(list[0].NotBefore); d < 50*time.Second || d > time.Minute {
+		t.Errorf("expected delayed key to retain its due time, got %s", d)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package reconciler
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// upper bounds of the reconcile duration histogram buckets, aligned
+	// with the default Prometheus buckets
+	durationBuckets = []time.Duration{
+		5 * time.Millisecond,
+		10 * time.Millisecond,
+		25 * time.Millisecond,
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+		250 * time.Millisecond,
+		500 * time.Millisecond,
+		time.Second,
+		2500 * time.Millisecond,
+		5 * time.Second,
+		10 * time.Second,
+	}
+)
+
+// DurationHistogram provides the distribution of the reconcile durations
+type DurationHistogram struct {
+	// upper bounds of the buckets
+	Buckets []time.Duration
+
+	// number of reconciles per bucket, where the last count, beyond the
+	// buckets, is for the reconciles exceeding the last upper bound
+	Counts []uint64
+
+	// total duration of all the reconciles
+	Sum time.Duration
+}
+
+// ControllerStats provides the statistics of a controller, allowing them
+// to be exported to the monitoring system, eg. as Prometheus counters and
+// gauges, to notice the controllers falling behind
+type ControllerStats struct {
+	// number of keys pending reconciliation
+	QueueDepth int
+
+	// number of keys enqueued, where the rate indicates the load on the
+	// controller
+	Enqueued uint64
+
+	// number of notifications merged into the keys already pending
+	Coalesced uint64
+
+	// number of keys overflowing the pipeline buffer, either held
+	// beyond the buffer or rejected as per the overflow policy
+	Overflows uint64
+
+	// number of reconciles performed
+	Reconciled uint64
+
+	// number of reconciles failing, including the panics
+	Errors uint64
+
+	// number of reconciles panicking
+	Panics uint64
+
+	// number of retries scheduled for the failing keys
+	Retries uint64
+
+	// number of keys given up after the maximum retries
+	DeadLettered uint64
+
+	// number of reconciles failing with terminal errors, not retried
+	Terminal uint64
+
+	// distribution of the reconcile durations
+	Duration DurationHistogram
+}
+
+// statistics tracked for a pipeline
+type pipelineStats struct {
+	enqueued     atomic.Uint64
+	coalesced    atomic.Uint64
+	overflows    atomic.Uint64
+	reconciled   atomic.Uint64
+	errors       atomic.Uint64
+	panics       atomic.Uint64
+	retries      atomic.Uint64
+	deadLettered atomic.Uint64
+	terminal     atomic.Uint64
+	durationSum  atomic.Int64
+	counts       []atomic.Uint64
+}
+
+func newPipelineStats() *pipelineStats {
+	return &pipelineStats{
+		counts: make([]atomic.Uint64, len(durationBuckets)+1),
+	}
+}
+
+// records a reconcile taking the given duration
+func (s *pipelineStats) observe(d time.Duration, err error) {
+	s.reconciled.Add(1)
+	if err != nil {
+		s.errors.Add(1)
+	}
+	s.durationSum.Add(int64(d))
+	i := 0
+	for i < len(durationBuckets) && d > durationBuckets[i] {
+		i++
+	}
+	s.counts[i].Add(1)
+}
+
+// Len returns the number of entries pending in the pipeline
+func (p *Pipeline) Len() int {
+	p.muQueue.Lock()
+	defer p.muQueue.Unlock()
+	return len(p.pending)
+}
+
+// Stats returns the snapshot of the statistics of the pipeline
+func (p *Pipeline) Stats() ControllerStats {
+	s := p.stats
+	stats := ControllerStats{
+		QueueDepth:   p.Len(),
+		Enqueued:     s.enqueued.Load(),
+		Coalesced:    s.coalesced.Load(),
+		Overflows:    s.overflows.Load(),
+		Reconciled:   s.reconciled.Load(),
+		Errors:       s.errors.Load(),
+		Panics:       s.panics.Load(),
+		Retries:      s.retries.Load(),
+		DeadLettered: s.deadLettered.Load(),
+		Terminal:     s.terminal.Load(),
+		Duration: DurationHistogram{
+			Buckets: append([]time.Duration{}, durationBuckets...),
+			Counts:  make([]uint64, len(s.counts)),
+			Sum:     time.Duration(s.durationSum.Load()),
+		},
+	}
+	for i := range s.counts {
+		stats.Duration.Counts[i] = s.counts[i].Load()
+	}
+	return stats
+}
+
+// Stats returns the snapshot of the statistics of all the controllers
+// registered with the manager, indexed by the controller name
+func (m *ManagerImpl) Stats() map[string]ControllerStats {
+	stats := map[string]ControllerStats{}
+	m.controllers.Range(func(name, data any) bool {
+		stats[name.(string)] = data.(*controllerData).pipeline.Stats()
+		return true
+	})
+	return stats
+}
@@ -0,0 +1,43 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package reconciler
+
+// TypedController is the controller receiving the keys of the expected
+// type directly, instead of type asserting the keys received as any
+type TypedController[K any] interface {
+	Reconcile(k *K) (*Result, error)
+}
+
+// Registrar allows registering the controllers, eg. the ManagerImpl and
+// the tables built over it
+type Registrar interface {
+	Register(name string, crtl Controller, opts ...RegisterOption) error
+}
+
+// adapts the typed controller to the controller interface
+type typedController[K any] struct {
+	crtl TypedController[K]
+	logf func(format string, args ...any)
+}
+
+func (c *typedController[K]) Reconcile(k any) (*Result, error) {
+	switch key := k.(type) {
+	case *K:
+		return c.crtl.Reconcile(key)
+	case K:
+		return c.crtl.Reconcile(&key)
+	}
+	// retrying the key is pointless, as it would never match the type
+	var expected *K
+	c.logf("reconciler: dropping key %v of type %T, expected %T", k, k, expected)
+	return &Result{}, nil
+}
+
+// RegisterTyped registers the typed controller with the registrar, where
+// the keys of the type K, either as the value or the pointer, are handed
+// to the controller as *K, while the keys of any other type are dropped
+// after logging, instead of failing the type assertions
+func RegisterTyped[K any](r Registrar, name string, crtl TypedController[K], opts ...RegisterOption) error {
+	return r.Register(name, &typedController[K]{crtl: crtl, logf: getRegisterOptions(opts).Logf}, opts...)
+}
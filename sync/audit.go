@@ -0,0 +1,226 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// collection hosting the audit trail of the sync constructs
+	auditCollection = "sync-audit"
+)
+
+// AuditKind is the kind of event recorded in the audit trail
+type AuditKind string
+
+const (
+	// lock acquired by the owner
+	AuditLockAcquire AuditKind = "lock-acquire"
+
+	// lock released by the owner
+	AuditLockRelease AuditKind = "lock-release"
+
+	// owner registered in the owner table
+	AuditOwnerJoin AuditKind = "owner-join"
+
+	// owner removed from the owner table, on shutdown or aging out,
+	// releasing all the locks and providers held by the owner
+	AuditOwnerLeave AuditKind = "owner-leave"
+
+	// provider created by the owner
+	AuditProviderAdd AuditKind = "provider-add"
+
+	// provider closed by the owner
+	AuditProviderRemove AuditKind = "provider-remove"
+)
+
+// AuditRecord is an event recorded in the audit trail, allowing to
+// reconstruct who held what when, eg. during the incident reviews
+type AuditRecord struct {
+	// time of the event
+	Time time.Time `bson:"time"`
+
+	// kind of the event
+	Kind AuditKind `bson:"kind"`
+
+	// name of the owner the event is about
+	Owner string `bson:"owner"`
+
+	// name of the lock or provider table, empty for the owner events
+	Table string `bson:"table,omitempty"`
+
+	// key of the lock or provider, empty for the owner events
+	Key string `bson:"key,omitempty"`
+
+	// additional details of the event, eg. the reason for owner leaving
+	Detail string `bson:"detail,omitempty"`
+}
+
+// AuditFilter selects the audit records to query, the empty fields match
+// all the records
+type AuditFilter struct {
+	// owner the events are about
+	Owner string
+
+	// kinds of the events
+	Kinds []AuditKind
+
+	// lock or provider table of the events
+	Table string
+
+	// key of the lock or provider
+	Key string
+
+	// events at or after the time
+	Since time.Time
+
+	// events before the time
+	Until time.Time
+}
+
+// auditor of the process, nil if auditing is not enabled
+var (
+	auditMu  sync.RWMutex
+	auditCol db.StoreCollection
+)
+
+// EnableAudit enables recording of the lock acquisitions and releases,
+// owner joins and leaves, and provider additions and removals of the
+// process into the audit collection hosted in the store, where the owners
+// sharing the store build a common audit trail, to be queried using
+// QueryAudit. Auditing is expected to be enabled before the owner infra
+// is initialized, to capture the owner joining. The records are written
+// best effort, failures are logged without failing the sync operations,
+// and are retained until pruned using PruneAudit
+func EnableAudit(ctx context.Context, store db.Store) error {
+	col := store.GetCollection(auditCollection)
+	if err := col.SetKeyType(reflect.TypeOf(&db.AuditKey{})); err != nil {
+		return err
+	}
+	err := col.EnsureIndexes(ctx, []db.IndexDefinition{
+		{Fields: []db.IndexField{{Field: "time", IndexType: db.IndexAscending}}},
+		{Fields: []db.IndexField{{Field: "owner", IndexType: db.IndexAscending}}},
+	})
+	if err != nil {
+		return err
+	}
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	auditCol = col
+	return nil
+}
+
+// DisableAudit stops recording the audit trail of the process, the
+// records already written are retained
+func DisableAudit() {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	auditCol = nil
+}
+
+// returns true if auditing is enabled
+func auditEnabled() bool {
+	auditMu.RLock()
+	defer auditMu.RUnlock()
+	return auditCol != nil
+}
+
+// records the event in the audit trail, if enabled
+func audit(kind AuditKind, owner string, table string, key any, detail string) {
+	auditMu.RLock()
+	col := auditCol
+	auditMu.RUnlock()
+	if col == nil {
+		return
+	}
+	record := &AuditRecord{
+		Time:   time.Now(),
+		Kind:   kind,
+		Owner:  owner,
+		Table:  table,
+		Detail: detail,
+	}
+	if key != nil {
+		record.Key = auditKey(key)
+	}
+	err := col.InsertOne(context.Background(), &db.AuditKey{ID: bson.NewObjectID()}, record)
+	if err != nil {
+		log.Printf("failed to write audit record %s for owner %s: %s", kind, owner, err)
+	}
+}
+
+// returns the printable form of the lock or provider key
+func auditKey(key any) string {
+	v := reflect.ValueOf(key)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		return fmt.Sprintf("%+v", v.Elem().Interface())
+	}
+	return fmt.Sprintf("%+v", key)
+}
+
+// QueryAudit returns the audit records hosted in the store matching the
+// filter, ordered by the time of the events
+func QueryAudit(ctx context.Context, store db.Store, filter *AuditFilter) ([]*AuditRecord, error) {
+	query := bson.D{}
+	if filter != nil {
+		if filter.Owner != "" {
+			query = append(query, bson.E{Key: "owner", Value: filter.Owner})
+		}
+		if len(filter.Kinds) != 0 {
+			kinds := bson.A{}
+			for _, kind := range filter.Kinds {
+				kinds = append(kinds, string(kind))
+			}
+			query = append(query, bson.E{Key: "kind", Value: bson.D{{Key: "$in", Value: kinds}}})
+		}
+		if filter.Table != "" {
+			query = append(query, bson.E{Key: "table", Value: filter.Table})
+		}
+		if filter.Key != "" {
+			query = append(query, bson.E{Key: "key", Value: filter.Key})
+		}
+		timeRange := bson.D{}
+		if !filter.Since.IsZero() {
+			timeRange = append(timeRange, bson.E{Key: "$gte", Value: filter.Since})
+		}
+		if !filter.Until.IsZero() {
+			timeRange = append(timeRange, bson.E{Key: "$lt", Value: filter.Until})
+		}
+		if len(timeRange) != 0 {
+			query = append(query, bson.E{Key: "time", Value: timeRange})
+		}
+	}
+	list := []*AuditRecord{}
+	err := store.GetCollection(auditCollection).FindMany(ctx, query, &list)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	slices.SortStableFunc(list, func(a, b *AuditRecord) int {
+		return a.Time.Compare(b.Time)
+	})
+	return list, nil
+}
+
+// PruneAudit removes the audit records hosted in the store older than
+// the given time, returning the number of records removed
+func PruneAudit(ctx context.Context, store db.Store, before time.Time) (int64, error) {
+	filter := bson.D{{Key: "time", Value: bson.D{{Key: "$lt", Value: before}}}}
+	count, err := store.GetCollection(auditCollection).DeleteMany(ctx, filter)
+	if err != nil && !errors.IsNotFound(err) {
+		return 0, err
+	}
+	return count, nil
+}
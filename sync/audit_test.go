@@ -0,0 +1,110 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-core-stack/core/db"
+)
+
+func auditKinds(list []*AuditRecord) []AuditKind {
+	kinds := []AuditKind{}
+	for _, r := range list {
+		kinds = append(kinds, r.Kind)
+	}
+	return kinds
+}
+
+func Test_Audit(t *testing.T) {
+	ctx := context.Background()
+	s := db.NewMemoryClient().GetDataStore("test-sync-audit")
+	start := time.Now()
+	if err := EnableAudit(ctx, s); err != nil {
+		t.Fatalf("failed to enable audit: %s", err)
+	}
+	defer DisableAudit()
+
+	owner, err := NewOwner(ctx, s, "audited")
+	if err != nil {
+		t.Fatalf("failed to create owner: %s", err)
+	}
+	name := owner.Name()
+
+	t.Run("locks and providers", func(t *testing.T) {
+		locks, err := LocateLockTable[lockKey](s, "demo-audit-test", WithLockOwner(owner))
+		if err != nil {
+			t.Fatalf("failed to locate Lock Table: %s", err)
+		}
+		lock, err := locks.TryAcquire(ctx, &lockKey{Scope: "scope-audit", Name: "test-key"})
+		if err != nil {
+			t.Fatalf("failed to acquire lock: %s", err)
+		}
+		_ = lock.Close()
+		// releasing again is not recorded
+		_ = lock.Close()
+
+		providers, err := LocateProviderTableWithOwner(s, "provider-audit-test", owner)
+		if err != nil {
+			t.Fatalf("failed to locate provider Table: %s", err)
+		}
+		p, err := providers.CreateProvider(ctx, "test-provider", nil)
+		if err != nil {
+			t.Fatalf("failed to create provider: %s", err)
+		}
+		_ = p.Close()
+
+		list, err := QueryAudit(ctx, s, &AuditFilter{Owner: name})
+		if err != nil {
+			t.Fatalf("failed to query audit: %s", err)
+		}
+		expected := []AuditKind{AuditOwnerJoin, AuditLockAcquire, AuditLockRelease, AuditProviderAdd, AuditProviderRemove}
+		if kinds := auditKinds(list); !slices.Equal(kinds, expected) {
+			t.Errorf("expected audit trail %v, got %v", expected, kinds)
+		}
+
+		list, _ = QueryAudit(ctx, s, &AuditFilter{Table: "demo-audit-test", Kinds: []AuditKind{AuditLockAcquire}})
+		if len(list) != 1 || list[0].Key != "{Scope:scope-audit Name:test-key}" || list[0].Time.Before(start.Truncate(time.Millisecond)) {
+			t.Errorf("unexpected lock acquire records %+v", list)
+		}
+		list, _ = QueryAudit(ctx, s, &AuditFilter{Owner: name, Until: start.Add(-time.Minute)})
+		if len(list) != 0 {
+			t.Errorf("expected no records before start, got %d", len(list))
+		}
+	})
+
+	t.Run("owners leaving", func(t *testing.T) {
+		// owner missing its heartbeats ages out
+		aged := &ownerKey{Name: "audit-aged-owner"}
+		if err := owner.t.col.InsertOne(ctx, aged, &ownerData{LastSeen: time.Now().Add(-time.Hour).Unix()}); err != nil {
+			t.Fatalf("failed to register aged owner: %s", err)
+		}
+		owner.t.deleteAgedOwnerTableEntries()
+		list, _ := QueryAudit(ctx, s, &AuditFilter{Owner: aged.Name})
+		if len(list) != 1 || list[0].Kind != AuditOwnerLeave || list[0].Detail != "aged out" {
+			t.Errorf("expected aged out owner leave record, got %+v", list)
+		}
+
+		if err := owner.Close(ctx); err != nil {
+			t.Fatalf("failed to close owner: %s", err)
+		}
+		list, _ = QueryAudit(ctx, s, &AuditFilter{Owner: name, Kinds: []AuditKind{AuditOwnerLeave}})
+		if len(list) != 1 || list[0].Detail != "shutdown" {
+			t.Errorf("expected owner shutdown record, got %+v", list)
+		}
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		count, err := PruneAudit(ctx, s, time.Now().Add(time.Second))
+		if err != nil || count == 0 {
+			t.Errorf("expected audit records to be pruned, got %d, %v", count, err)
+		}
+		if list, _ := QueryAudit(ctx, s, nil); len(list) != 0 {
+			t.Errorf("expected no records after prune, got %d", len(list))
+		}
+	})
+}
@@ -0,0 +1,226 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// lock table hosting the arrivals of the participants at barriers
+	barrierCollection = "barriers"
+
+	// collection recording the barriers tripped
+	barrierTrippedCollection = "barriers-tripped"
+)
+
+// key for the arrival of a participant at a barrier, held as a lock,
+// ensuring the cleanup once the participant ages out
+type barrierKey struct {
+	Name        string `bson:"name"`
+	Participant string `bson:"participant"`
+}
+
+// key of the record of a tripped barrier
+type barrierTrippedKey struct {
+	Name string `bson:"name"`
+}
+
+// record of a tripped barrier
+type barrierTripped struct {
+	Participants []string  `bson:"participants"`
+	TripTime     time.Time `bson:"tripTime"`
+}
+
+// Barrier blocks the participants until all the n participants have
+// arrived, eg. to coordinate the steps of a multi service rollout. Every
+// participant is an owner, arriving with a lock in the barriers lock
+// table, so a participant failing before the barrier trips is detected
+// as it ages out of the owner table. Once tripped, the barrier stays
+// tripped until Reset
+type Barrier struct {
+	name     string
+	n        int
+	arrivals *LockTable[barrierKey]
+	tripped  db.StoreCollection
+}
+
+// NewBarrier creates the barrier with the given name for n participants,
+// hosted in the store, all the participants are expected to use the same
+// n for a barrier, requires the owner infra to be initialized
+func NewBarrier(store db.Store, name string, n int) (*Barrier, error) {
+	if name == "" {
+		return nil, errors.Wrap(errors.InvalidArgument, "barrier name is required")
+	}
+	if n <= 0 {
+		return nil, errors.Wrapf(errors.InvalidArgument, "invalid participant count %d for barrier %s", n, name)
+	}
+	arrivals, err := LocateLockTable[barrierKey](store, barrierCollection)
+	if err != nil {
+		return nil, err
+	}
+	return &Barrier{
+		name:     name,
+		n:        n,
+		arrivals: arrivals,
+		tripped:  store.GetCollection(barrierTrippedCollection),
+	}, nil
+}
+
+// Arrived returns the owner names of the participants arrived at the
+// barrier
+func (b *Barrier) Arrived(ctx context.Context) ([]string, error) {
+	filter := db.KeyFilter(bson.E{Key: "name", Value: b.name})
+	list := []lockKeyOnly[barrierKey]{}
+	err := b.arrivals.col.FindMany(ctx, filter, &list)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	participants := []string{}
+	for _, entry := range list {
+		participants = append(participants, entry.Key.Participant)
+	}
+	slices.Sort(participants)
+	return participants, nil
+}
+
+// returns true if the barrier is tripped
+func (b *Barrier) isTripped(ctx context.Context) (bool, error) {
+	err := b.tripped.FindOne(ctx, &barrierTrippedKey{Name: b.name}, &barrierTripped{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Wait arrives at the barrier and blocks until all the participants have
+// arrived or the context is done, returns immediately if the barrier is
+// already tripped. The arrival is withdrawn if the barrier doesn't trip
+// returns Unavailable error if the context is done before all the
+// participants arrive or if an arrived participant leaves, ie. fails and
+// ages out or gives up waiting, before the barrier trips
+func (b *Barrier) Wait(ctx context.Context) error {
+	if tripped, err := b.isTripped(ctx); err != nil || tripped {
+		return err
+	}
+	if err := b.arrivals.owner.validate(); err != nil {
+		return err
+	}
+	self := b.arrivals.owner.key.Name
+	arrival, err := b.arrivals.TryAcquire(ctx, &barrierKey{Name: b.name, Participant: self})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	withdraw := func() {
+		if arrival != nil {
+			_ = arrival.Close()
+		}
+	}
+
+	// arrivals of the other participants
+	wctx, cancelFn := context.WithCancel(ctx)
+	defer cancelFn()
+	trigger := make(chan struct{}, 1)
+	err = b.arrivals.col.Watch(wctx, nil, func(op string, wKey any) {
+		if key, ok := wKey.(*barrierKey); ok && key.Name == b.name {
+			select {
+			case trigger <- struct{}{}:
+			default:
+			}
+		}
+	})
+	if err != nil {
+		withdraw()
+		return err
+	}
+
+	seen := map[string]struct{}{}
+	for {
+		released := b.arrivals.releaseNotify()
+		tripped, err := b.check(ctx, seen)
+		if err != nil {
+			if errors.IsUnavailable(err) {
+				withdraw()
+				return err
+			}
+			if ctx.Err() == nil {
+				log.Printf("barrier: failed to check arrivals at %s: %s", b.name, err)
+			}
+		}
+		if tripped {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if tripped, _ := b.isTripped(context.Background()); tripped {
+				return nil
+			}
+			withdraw()
+			return errors.Wrapf(errors.Unavailable, "timed out waiting at barrier %s: %s", b.name, ctx.Err())
+		case <-trigger:
+		case <-released:
+		case <-time.After(lockAcquireRetryInterval):
+		}
+	}
+}
+
+// checks the arrivals at the barrier, tripping it once all the
+// participants have arrived, returns true if tripped
+// returns Unavailable error if a participant seen earlier has left
+func (b *Barrier) check(ctx context.Context, seen map[string]struct{}) (bool, error) {
+	tripped, err := b.isTripped(ctx)
+	if err != nil || tripped {
+		return tripped, err
+	}
+	participants, err := b.Arrived(ctx)
+	if err != nil {
+		return false, err
+	}
+	for p := range seen {
+		if !slices.Contains(participants, p) {
+			return false, errors.Wrapf(errors.Unavailable, "participant %s left barrier %s before all arrived", p, b.name)
+		}
+	}
+	for _, p := range participants {
+		seen[p] = struct{}{}
+	}
+	if len(participants) < b.n {
+		return false, nil
+	}
+	err = b.tripped.InsertOne(ctx, &barrierTrippedKey{Name: b.name}, &barrierTripped{
+		Participants: participants,
+		TripTime:     time.Now(),
+	})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return false, err
+	}
+	log.Printf("barrier: %s tripped with participants %v", b.name, participants)
+	return true, nil
+}
+
+// Reset clears the barrier, allowing it to be used again, the arrivals
+// of all the participants are withdrawn
+func (b *Barrier) Reset(ctx context.Context) error {
+	err := b.tripped.DeleteOne(ctx, &barrierTrippedKey{Name: b.name})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	_, err = b.arrivals.col.DeleteMany(ctx, db.KeyFilter(bson.E{Key: "name", Value: b.name}))
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
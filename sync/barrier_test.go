@@ -0,0 +1,110 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// registers a peer owner arriving at the barrier
+func arriveBarrierPeer(t *testing.T, b *Barrier, name string) *ownerKey {
+	ctx := context.Background()
+	peer := &ownerKey{Name: name}
+	if err := ownerTable.col.InsertOne(ctx, peer, &ownerData{LastSeen: time.Now().Unix()}); err != nil {
+		t.Fatalf("failed to register peer owner: %s", err)
+	}
+	err := b.arrivals.col.InsertOne(ctx, &barrierKey{Name: b.name, Participant: name}, &lockData{Owner: name})
+	if err != nil {
+		t.Fatalf("failed to arrive peer: %s", err)
+	}
+	return peer
+}
+
+func Test_Barrier(t *testing.T) {
+	s := initMemoryOwner(t)
+	ctx := context.Background()
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := NewBarrier(s, "", 2); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without name, got %v", err)
+		}
+		if _, err := NewBarrier(s, "demo-barrier", 0); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for zero participants, got %v", err)
+		}
+	})
+
+	t.Run("trip", func(t *testing.T) {
+		b, err := NewBarrier(s, "demo-barrier-trip", 2)
+		if err != nil {
+			t.Fatalf("failed to create barrier: %s", err)
+		}
+		done := make(chan error, 1)
+		go func() { done <- b.Wait(ctx) }()
+		select {
+		case err := <-done:
+			t.Fatalf("expected wait to block for the peer, got %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		peer := arriveBarrierPeer(t, b, "barrier-trip-peer")
+		defer func() { _ = ownerTable.col.DeleteOne(ctx, peer) }()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("failed waiting at barrier: %s", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected barrier to trip once all arrived")
+		}
+		// tripped barrier doesn't block
+		if err := b.Wait(ctx); err != nil {
+			t.Errorf("expected tripped barrier to pass, got %v", err)
+		}
+
+		if err := b.Reset(ctx); err != nil {
+			t.Fatalf("failed to reset barrier: %s", err)
+		}
+		if arrived, err := b.Arrived(ctx); err != nil || len(arrived) != 0 {
+			t.Errorf("expected no arrivals after reset, got %v, %v", arrived, err)
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		b, _ := NewBarrier(s, "demo-barrier-failure", 3)
+		peer := arriveBarrierPeer(t, b, "barrier-failure-peer")
+		done := make(chan error, 1)
+		go func() { done <- b.Wait(ctx) }()
+		time.Sleep(50 * time.Millisecond)
+
+		// peer ages out before all arrived
+		_ = ownerTable.col.DeleteOne(ctx, peer)
+		select {
+		case err := <-done:
+			if !errors.IsUnavailable(err) {
+				t.Errorf("expected unavailable error on peer failure, got %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected wait to fail once the peer left")
+		}
+		if arrived, _ := b.Arrived(ctx); len(arrived) != 0 {
+			t.Errorf("expected arrival to be withdrawn, got %v", arrived)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		b, _ := NewBarrier(s, "demo-barrier-timeout", 2)
+		tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		if err := b.Wait(tctx); !errors.IsUnavailable(err) {
+			t.Errorf("expected unavailable error on timeout, got %v", err)
+		}
+		if arrived, _ := b.Arrived(ctx); len(arrived) != 0 {
+			t.Errorf("expected arrival to be withdrawn, got %v", arrived)
+		}
+	})
+}
@@ -0,0 +1,170 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// bound on the search for the next firing, covering the schedules
+	// firing only on leap days
+	cronSearchYears = 5
+)
+
+// bounds of a cron field
+type cronBounds struct {
+	min, max int
+}
+
+var (
+	// bounds of the minute, hour, day of month, month and day of week
+	// fields, where day of week 7 is Sunday as well
+	cronFieldBounds = []cronBounds{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+	// descriptors for the commonly used schedules
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// cronSchedule provides the firing times of a cron spec, evaluated in UTC
+// for the replicas to agree on the firings regardless of their time zone
+type cronSchedule struct {
+	// fixed interval for @every, zero otherwise
+	every time.Duration
+
+	// allowed values of the fields, indexed by the value
+	minute, hour, dom, month, dow []bool
+
+	// true if the day of month or day of week field is restricted, as
+	// the day matches either of them if both are restricted
+	domStar, dowStar bool
+}
+
+// parses the cron spec, either the standard five fields, ie. minute,
+// hour, day of month, month and day of week, supporting the lists,
+// ranges and steps, eg. "*/15 9-17 * * 1-5", or one of the descriptors
+// @hourly, @daily, @weekly, @monthly, @yearly and "@every <duration>"
+func parseCron(spec string) (*cronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || d <= 0 {
+			return nil, errors.Wrapf(errors.InvalidArgument, "invalid interval in cron spec %q", spec)
+		}
+		return &cronSchedule{every: d}, nil
+	}
+	if expanded, ok := cronDescriptors[spec]; ok {
+		spec = expanded
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFieldBounds) {
+		return nil, errors.Wrapf(errors.InvalidArgument, "cron spec %q must have %d fields", spec, len(cronFieldBounds))
+	}
+	values := make([][]bool, len(fields))
+	for i, f := range fields {
+		v, err := parseCronField(f, cronFieldBounds[i])
+		if err != nil {
+			return nil, errors.Wrapf(errors.InvalidArgument, "invalid cron spec %q: %s", spec, err)
+		}
+		values[i] = v
+	}
+	// Sunday is both 0 and 7
+	values[4][0] = values[4][0] || values[4][7]
+	return &cronSchedule{
+		minute:  values[0],
+		hour:    values[1],
+		dom:     values[2],
+		month:   values[3],
+		dow:     values[4],
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+	}, nil
+}
+
+// parses a comma separated list of values, ranges and steps of a field
+func parseCronField(field string, b cronBounds) ([]bool, error) {
+	allowed := make([]bool, b.max+1)
+	for _, part := range strings.Split(field, ",") {
+		expr, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			s, err := strconv.Atoi(stepStr)
+			if err != nil || s <= 0 {
+				return nil, errors.Wrapf(errors.InvalidArgument, "invalid step in %q", part)
+			}
+			step = s
+		}
+		lo, hi := b.min, b.max
+		if expr != "*" {
+			from, to, isRange := strings.Cut(expr, "-")
+			var err error
+			if lo, err = strconv.Atoi(from); err != nil {
+				return nil, errors.Wrapf(errors.InvalidArgument, "invalid value in %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return nil, errors.Wrapf(errors.InvalidArgument, "invalid range in %q", part)
+				}
+			} else if hasStep {
+				// "n/step" runs from n till the end of the range
+				hi = b.max
+			}
+		}
+		if lo < b.min || hi > b.max || lo > hi {
+			return nil, errors.Wrapf(errors.InvalidArgument, "%q out of range [%d-%d]", part, b.min, b.max)
+		}
+		for v := lo; v <= hi; v += step {
+			allowed[v] = true
+		}
+	}
+	return allowed, nil
+}
+
+// returns true if the day matches the day of month and day of week
+// fields
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	dom, dow := s.dom[t.Day()], s.dow[int(t.Weekday())]
+	if !s.domStar && !s.dowStar {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// returns the first firing strictly after the given time, zero time if
+// the schedule never fires
+func (s *cronSchedule) next(after time.Time) time.Time {
+	if s.every != 0 {
+		return after.Add(s.every)
+	}
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !s.month[int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !s.hour[t.Hour()]:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !s.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,203 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// lock table hosting the leadership of the elections
+	leaderElectionCollection = "leader-election"
+
+	// interval at which the leader verifies it still holds the
+	// leadership, besides the lock release notifications
+	leaderVerifyInterval = 5 * time.Second
+)
+
+// key for the leadership lock of an election
+type leaderKey struct {
+	Name string `bson:"name"`
+}
+
+// LeaderCallbackfn is invoked whenever the process is elected as the
+// leader or loses the leadership
+type LeaderCallbackfn func(leader bool)
+
+// LeaderElection elects one of the processes as the leader for the given
+// election name, where the leadership is held as a lock, which is
+// released when the leader resigns or ages out of the owner table, while
+// the other candidates campaign for it
+type LeaderElection struct {
+	name  string
+	locks *LockTable[leaderKey]
+	cb    LeaderCallbackfn
+
+	mu      sync.Mutex
+	lock    *lockImpl[leaderKey] // leadership lock if leader
+	revoked chan struct{}        // closed on losing the leadership
+}
+
+// NewLeaderElection creates the election with the given name, hosted in
+// the store, the callback if provided is notified of the changes in the
+// leadership of the process, requires the owner infra to be initialized
+func NewLeaderElection(store db.Store, name string, cb LeaderCallbackfn) (*LeaderElection, error) {
+	if name == "" {
+		return nil, errors.Wrap(errors.InvalidArgument, "leader election name is required")
+	}
+	locks, err := LocateLockTable[leaderKey](store, leaderElectionCollection)
+	if err != nil {
+		return nil, err
+	}
+	return &LeaderElection{
+		name:  name,
+		locks: locks,
+		cb:    cb,
+	}, nil
+}
+
+// IsLeader returns true if the process currently holds the leadership
+func (e *LeaderElection) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lock != nil
+}
+
+// Leader returns the owner name of the current leader
+// returns NotFound error if there is no leader
+func (e *LeaderElection) Leader(ctx context.Context) (string, error) {
+	data := &lockData{}
+	if err := e.locks.col.FindOne(ctx, &leaderKey{Name: e.name}, data); err != nil {
+		if errors.IsNotFound(err) {
+			return "", errors.Wrapf(errors.NotFound, "no leader elected for %s", e.name)
+		}
+		return "", err
+	}
+	return data.Owner, nil
+}
+
+// Campaign blocks until the process is elected as the leader or the
+// context is done, returns immediately if already the leader
+// returns Unavailable error if the context is done before being elected
+func (e *LeaderElection) Campaign(ctx context.Context) error {
+	if e.IsLeader() {
+		return nil
+	}
+	lock, err := e.locks.Acquire(ctx, &leaderKey{Name: e.name})
+	if err != nil {
+		return err
+	}
+	revoked := make(chan struct{})
+	e.mu.Lock()
+	e.lock = lock.(*lockImpl[leaderKey])
+	e.revoked = revoked
+	e.mu.Unlock()
+	log.Printf("leader-election: elected as leader for %s", e.name)
+	if e.cb != nil {
+		e.cb(true)
+	}
+	go e.monitor(e.lock, revoked)
+	return nil
+}
+
+// Resign gives up the leadership, allowing the other candidates to be
+// elected, no-op if not the leader
+func (e *LeaderElection) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	lock := e.lock
+	e.mu.Unlock()
+	if lock == nil {
+		return nil
+	}
+	err := lock.Close()
+	e.revoke(lock)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Run keeps campaigning for the leadership until the context is done,
+// campaigning again whenever the leadership is lost, and resigns when
+// the context is done
+func (e *LeaderElection) Run(ctx context.Context) {
+	for {
+		if err := e.Campaign(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("leader-election: failed to campaign for %s: %s", e.name, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(lockAcquireRetryInterval):
+			}
+			continue
+		}
+		e.mu.Lock()
+		revoked := e.revoked
+		e.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			_ = e.Resign(context.Background())
+			return
+		case <-revoked:
+		}
+	}
+}
+
+// marks the leadership held with the lock as lost, notifying the
+// callback, no-op if the leadership is already revoked
+func (e *LeaderElection) revoke(lock *lockImpl[leaderKey]) {
+	e.mu.Lock()
+	if e.lock != lock {
+		e.mu.Unlock()
+		return
+	}
+	e.lock = nil
+	close(e.revoked)
+	e.mu.Unlock()
+	log.Printf("leader-election: lost leadership for %s", e.name)
+	if e.cb != nil {
+		e.cb(false)
+	}
+}
+
+// returns true if the leadership lock is still held
+func (e *LeaderElection) held(lock *lockImpl[leaderKey]) bool {
+	data := &lockData{}
+	err := e.locks.col.FindOne(context.Background(), lock.key, data)
+	if err != nil {
+		// retain the leadership on transient errors
+		return !errors.IsNotFound(err)
+	}
+	return data.Lease == lock.data.Lease
+}
+
+// monitors the leadership lock, revoking the leadership once it is lost,
+// eg. when the lock is cleaned up as the process aged out of the owner
+// table while being wedged
+func (e *LeaderElection) monitor(lock *lockImpl[leaderKey], revoked chan struct{}) {
+	for {
+		released := e.locks.releaseNotify()
+		if !e.held(lock) {
+			e.revoke(lock)
+			return
+		}
+		select {
+		case <-revoked:
+			return
+		case <-e.locks.ctx.Done():
+			return
+		case <-released:
+		case <-time.After(leaderVerifyInterval):
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+func Test_LeaderElection(t *testing.T) {
+	s := initMemoryOwner(t)
+	ctx := context.Background()
+
+	wait := func(t *testing.T, cond func() bool) {
+		for range 200 {
+			if cond() {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("condition not met in time")
+	}
+
+	if _, err := NewLeaderElection(s, "", nil); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument without name, got %v", err)
+	}
+
+	var changes atomic.Int32
+	e1, err := NewLeaderElection(s, "demo-election", nil)
+	if err != nil {
+		t.Fatalf("failed to create election: %s", err)
+	}
+	e2, _ := NewLeaderElection(s, "demo-election", func(leader bool) {
+		changes.Add(1)
+	})
+
+	if _, err := e1.Leader(ctx); !errors.IsNotFound(err) {
+		t.Errorf("expected no leader before campaign, got %v", err)
+	}
+	if err := e1.Campaign(ctx); err != nil {
+		t.Fatalf("failed to campaign: %s", err)
+	}
+	if !e1.IsLeader() || e2.IsLeader() {
+		t.Errorf("expected only first candidate to be the leader")
+	}
+	if leader, err := e1.Leader(ctx); err != nil || leader != ownerTable.key.Name {
+		t.Errorf("expected self as leader, got %s, %v", leader, err)
+	}
+	cctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := e2.Campaign(cctx); !errors.IsUnavailable(err) {
+		t.Errorf("expected campaign to time out while leader is elected, got %v", err)
+	}
+
+	rctx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		e2.Run(rctx)
+		close(done)
+	}()
+	if err := e1.Resign(ctx); err != nil {
+		t.Fatalf("failed to resign: %s", err)
+	}
+	if e1.IsLeader() {
+		t.Errorf("expected leadership to be given up on resign")
+	}
+	wait(t, e2.IsLeader)
+
+	// leadership lost, eg. cleaned up while the process was wedged
+	_ = e2.locks.col.DeleteOne(ctx, &leaderKey{Name: "demo-election"})
+	wait(t, func() bool { return changes.Load() >= 2 })
+	// and re-elected as the only candidate
+	wait(t, e2.IsLeader)
+
+	stop()
+	<-done
+	if e2.IsLeader() {
+		t.Errorf("expected resign once run is stopped")
+	}
+	if n := changes.Load(); n != 4 {
+		t.Errorf("expected 4 leadership changes, got %d", n)
+	}
+}
@@ -5,19 +5,27 @@ package sync
 
 import (
 	"context"
+	"io"
 	"log"
 	"reflect"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
 
 	"github.com/go-core-stack/core/db"
 	"github.com/go-core-stack/core/errors"
 	"github.com/go-core-stack/core/reconciler"
 )
 
+const (
+	// interval at which Acquire retries the lock even without a release
+	// notification, covering for the notifications missed while the
+	// watch is re-established
+	lockAcquireRetryInterval = 5 * time.Second
+)
+
 var (
 	// map for holding initialized lock tables
 	lockTables map[lockTableKey]interface{} = make(map[lockTableKey]interface{})
@@ -29,24 +37,63 @@ var (
 type lockTableKey struct {
 	DbName  string
 	ColName string
+	Owner   string
 }
 
 type Lock interface {
+	// Close releases the lock, if still held
 	Close() error
+
+	// Extend extends the lease of the lock to expire ttl from now
+	// returns NotFound error if the lock is no longer held, ie. released
+	// or expired and acquired by someone else
+	Extend(ttl time.Duration) error
+
+	// Token returns the fencing token of the lock, which increases with
+	// every acquisition of the lock, see CheckFencingToken
+	Token() int64
 }
 
 type lockImpl[K any] struct {
-	key *K
-	tbl *LockTable[K]
+	key  *K
+	tbl  *LockTable[K]
+	data *lockData
+
+	// time at which the lock is acquired, for hold duration stats
+	acquired time.Time
+	release  sync.Once
+
+	// lease renewal, if enabled
+	muLease sync.Mutex
+	ttl     time.Duration
+	stop    chan struct{}
+	once    sync.Once
 }
 
 func (l *lockImpl[K]) Close() error {
-	return l.tbl.col.DeleteOne(context.Background(), l.key)
+	if l.stop != nil {
+		l.once.Do(func() { close(l.stop) })
+	}
+	l.release.Do(func() {
+		l.tbl.stats.release(l.acquired)
+		audit(AuditLockRelease, l.data.Owner, l.tbl.colName, l.key, "")
+	})
+	// release only if still held by self, as the lock might have expired
+	// and acquired by someone else
+	filter := bson.D{
+		{Key: "_id", Value: l.key},
+		{Key: "lease", Value: l.data.Lease},
+	}
+	_, err := l.tbl.col.DeleteMany(context.Background(), filter)
+	return err
 }
 
 type lockData struct {
-	CreateTime int64  `bson:"createTime,omitempty"`
-	Owner      string `bson:"owner,omitempty"`
+	CreateTime int64      `bson:"createTime,omitempty"`
+	Owner      string     `bson:"owner,omitempty"`
+	Lease      string     `bson:"lease,omitempty"` // unique per acquisition
+	Token      int64      `bson:"token,omitempty"` // fencing token
+	ExpireAt   *time.Time `bson:"expireAt,omitempty"`
 }
 
 type lockKeyOnly[K any] struct {
@@ -67,13 +114,66 @@ type LockTable[K any] struct {
 
 	// Context cancel function
 	cancelFn context.CancelFunc
+
+	// options the table is located with
+	opts *LockTableOptions
+
+	// owner the locks of the table are held by
+	owner *ownerTableType
+
+	// statistics of the locks acquired by the process
+	stats *lockStats
+
+	// collection holding the last fencing token issued for every key
+	tokens db.StoreCollection
+
+	// channel closed on the next lock release, waking up the waiters
+	// blocked in Acquire
+	muRelease sync.Mutex
+	released  chan struct{}
+
+	// locks held by self, for the reentrant table
+	muHeld sync.Mutex
+	held   map[string]*reentrantEntry[K]
+
+	// ensures the expiry index of the locks is created once
+	expiryIndex sync.Once
+
+	// store hosting the table
+	store db.Store
+
+	// waiters queue for the fair table, located on first use
+	queueOnce sync.Once
+	queue     *LockTable[lockWaiterKey]
+	queueErr  error
+}
+
+// returns the channel closed on the next lock release
+func (t *LockTable[K]) releaseNotify() <-chan struct{} {
+	t.muRelease.Lock()
+	defer t.muRelease.Unlock()
+	if t.released == nil {
+		t.released = make(chan struct{})
+	}
+	return t.released
+}
+
+// wakes up all the waiters blocked in Acquire, allowing them to retry
+func (t *LockTable[K]) notifyRelease() {
+	t.muRelease.Lock()
+	defer t.muRelease.Unlock()
+	if t.released != nil {
+		close(t.released)
+		t.released = nil
+	}
 }
 
 func (t *LockTable[K]) Callback(op string, wKey interface{}) {
 	// on lock release (delete), notify registered controllers
 	// allowing others to try acquiring the released lock
 	if op == "delete" {
-		t.NotifyCallback(wKey)
+		t.notifyRelease()
+		t.NotifyOpCallback(op, wKey)
 		return
 	}
 
@@ -92,7 +192,7 @@ func (t *LockTable[K]) Callback(op string, wKey interface{}) {
 		Name: data.Owner,
 	}
 	oData := &ownerData{}
-	err = ownerTable.col.FindOne(context.Background(), oKey, oData)
+	err = t.owner.col.FindOne(context.Background(), oKey, oData)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			filter := bson.D{{
@@ -117,8 +217,8 @@ func (t *LockTable[K]) ReconcilerGetAllKeys() []any {
 // RegisterLockRelease allows a reconciler controller to subscribe for lock
 // release notifications. The controller's Reconcile method will be called
 // with the lock key whenever a lock is released.
-func (t *LockTable[K]) RegisterLockRelease(name string, ctrl reconciler.Controller) error {
-	return t.ManagerImpl.Register(name, ctrl)
+func (t *LockTable[K]) RegisterLockRelease(name string, ctrl reconciler.Controller, opts ...reconciler.RegisterOption) error {
+	return t.ManagerImpl.Register(name, ctrl, opts...)
 }
 
 func (t *LockTable[K]) handleOwnerRelease(op string, wKey interface{}) {
@@ -134,6 +234,18 @@ func (t *LockTable[K]) handleOwnerRelease(op string, wKey interface{}) {
 	}
 }
 
+// releases all the locks held by the owner and stops working on the
+// table, as part of the shutdown of the owner
+func (t *LockTable[K]) releaseOwner(ctx context.Context, owner string) error {
+	defer t.cancelFn()
+	filter := bson.D{{
+		Key:   "owner",
+		Value: owner,
+	}}
+	_, err := t.col.DeleteMany(ctx, filter)
+	return err
+}
+
 // cleanupOrphanedLocks scans all existing locks and deletes any whose
 // owner no longer exists in the owner-table. This is called once during
 // LocateLockTable initialization to handle stale locks left by replicas
@@ -158,7 +270,7 @@ func (t *LockTable[K]) cleanupOrphanedLocks() {
 	for ownerName := range owners {
 		oKey := &ownerKey{Name: ownerName}
 		oData := &ownerData{}
-		err := ownerTable.col.FindOne(context.Background(), oKey, oData)
+		err := t.owner.col.FindOne(context.Background(), oKey, oData)
 		if err != nil && errors.IsNotFound(err) {
 			filter := bson.D{{
 				Key:   "owner",
@@ -174,42 +286,143 @@ func (t *LockTable[K]) cleanupOrphanedLocks() {
 	}
 }
 
+// TryAcquire acquires the lock for the key
+// returns AlreadyExists error if the lock is held by someone else, or by
+// self unless the table is reentrant
 func (t *LockTable[K]) TryAcquire(ctx context.Context, key *K) (Lock, error) {
-	// if ownertable is not initialized, then lock infra cannot be used
-	if ownerTable == nil || ownerTable.key == nil {
-		return nil, errors.Wrap(errors.InvalidArgument, "owner infra for lock is not initialized")
+	if t.opts.Fair {
+		// waiters queued for the lock are granted first
+		if err := t.checkQueue(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+	return t.acquireOnce(ctx, key)
+}
+
+// acquires the lock for the key, disregarding the waiters queue
+func (t *LockTable[K]) acquireOnce(ctx context.Context, key *K) (Lock, error) {
+	if t.opts.Reentrant {
+		return t.tryAcquireReentrant(ctx, key)
+	}
+	lock, err := t.tryAcquire(ctx, key, t.opts.TTL, t.opts.AutoRenew)
+	if err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// acquires the lock for the key, expiring after the ttl if not zero, and
+// renewing the lease in the background if requested
+func (t *LockTable[K]) tryAcquire(ctx context.Context, key *K, ttl time.Duration, renew bool) (*lockImpl[K], error) {
+	// if owner is shut down, then lock infra cannot be used
+	if err := t.owner.validate(); err != nil {
+		return nil, err
 	}
 
 	data := &lockData{
 		CreateTime: time.Now().Unix(),
-		Owner:      ownerTable.key.Name,
+		Owner:      t.owner.key.Name,
+		Lease:      uuid.New().String(),
+	}
+	if ttl != 0 {
+		expireAt := time.Now().Add(ttl)
+		data.ExpireAt = &expireAt
 	}
-
 	err := t.col.InsertOne(ctx, key, data)
+	if err != nil && errors.IsAlreadyExists(err) && t.releaseExpired(ctx, key) {
+		err = t.col.InsertOne(ctx, key, data)
+	}
 	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			t.stats.contended.Add(1)
+		}
+		return nil, err
+	}
+	// fencing token is issued only once the lock is acquired, to not
+	// spend the tokens on the contended attempts
+	if err := t.assignToken(ctx, key, data); err != nil {
 		return nil, err
 	}
+	t.stats.acquired.Add(1)
+	t.stats.held.Add(1)
+	audit(AuditLockAcquire, data.Owner, t.colName, key, "")
+
+	lock := &lockImpl[K]{
+		key:      key,
+		tbl:      t,
+		data:     data,
+		acquired: time.Now(),
+		ttl:      ttl,
+	}
+	if ttl != 0 && renew {
+		lock.stop = make(chan struct{})
+		go lock.renew()
+	}
+	return lock, nil
+}
 
-	return &lockImpl[K]{
-		key: key,
-		tbl: t,
-	}, nil
+// Acquire acquires the lock for the key, blocking until the lock is
+// released by the current holder or the context is done, waiters are
+// woken up by the release notifications to retry acquiring the lock, in
+// the order of arrival for the fair table
+// returns Unavailable error if the context is done before the lock is
+// acquired
+func (t *LockTable[K]) Acquire(ctx context.Context, key *K) (Lock, error) {
+	return t.AcquireWithPosition(ctx, key, nil)
 }
 
-// LocateLockTable
-func LocateLockTable[K any](store db.Store, name string) (*LockTable[K], error) {
+// AcquireWithPosition acquires the lock for the key similar to Acquire,
+// while notifying the position of the caller in the waiters queue of the
+// fair table to onPosition, whenever it changes, where position 0 is the
+// next in line. Position is not notified for the tables without fairness
+func (t *LockTable[K]) AcquireWithPosition(ctx context.Context, key *K, onPosition func(position int)) (Lock, error) {
+	if t.opts.Fair {
+		return t.acquireFair(ctx, key, onPosition)
+	}
+	for {
+		// subscribe before trying, to not miss a release in between
+		released := t.releaseNotify()
+		lock, err := t.TryAcquire(ctx, key)
+		if err == nil || !errors.IsAlreadyExists(err) {
+			return lock, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(errors.Unavailable, "failed to acquire lock %v: %s", key, ctx.Err())
+		case <-released:
+		case <-time.After(t.acquireRetryWait(ctx, key)):
+		}
+	}
+}
+
+// LocateLockTable returns the lock table hosted in the collection with the
+// given name, options are applied only when the table is located for the
+// first time in the process
+func LocateLockTable[K any](store db.Store, name string, opts ...LockTableOption) (*LockTable[K], error) {
 	muLockTables.Lock()
 	defer muLockTables.Unlock()
 
+	lopts, err := getLockTableOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	owner := ownerTable
+	if lopts.Owner != nil {
+		owner = lopts.Owner.t
+	}
+	// ensure owner table is initialized before proceeding further
+	if owner == nil {
+		return nil, errors.Wrap(errors.InvalidArgument, "Mandatory! owner table infra not initialized")
+	}
+	if err := owner.validate(); err != nil {
+		return nil, err
+	}
+	tKey := lockTableKey{store.Name(), name, owner.key.Name}
+
 	var table *LockTable[K]
-	intf, ok := lockTables[lockTableKey{store.Name(), name}]
+	intf, ok := lockTables[tKey]
 	if !ok {
-		// ensure owner table is initialized before proceeding further
-		if ownerTable == nil {
-			return nil, errors.Wrap(errors.InvalidArgument, "Mandatory! owner table infra not initialized")
-		}
-
-		ctx, cancelFn := context.WithCancel(ownerTable.ctx)
+		ctx, cancelFn := context.WithCancel(owner.ctx)
 
 		// no existing table found, allocate a new one
 		col := store.GetCollection(name)
@@ -218,6 +431,11 @@ func LocateLockTable[K any](store db.Store, name string) (*LockTable[K], error)
 			col:      col,
 			ctx:      ctx,
 			cancelFn: cancelFn,
+			opts:     lopts,
+			owner:    owner,
+			store:    store,
+			stats:    stats.lockTable(name),
+			tokens:   store.GetCollection(name + lockTokenCollectionSuffix),
 		}
 
 		// set the key type for watch notification decoding
@@ -227,7 +445,7 @@ func LocateLockTable[K any](store db.Store, name string) (*LockTable[K], error)
 			cancelFn()
 			return nil, errors.Wrap(errors.InvalidArgument, "type parameter K must be a concrete type, not an interface")
 		}
-		err := col.SetKeyType(reflect.PointerTo(kt))
+		err = col.SetKeyType(reflect.PointerTo(kt))
 		if err != nil {
 			cancelFn()
 			return nil, errors.Wrapf(errors.GetErrCode(err), "failed to set key type for lock table: %s", err)
@@ -240,18 +458,10 @@ func LocateLockTable[K any](store db.Store, name string) (*LockTable[K], error)
 			return nil, err
 		}
 
-		matchDeleteStage := mongo.Pipeline{
-			bson.D{{
-				Key: "$match",
-				Value: bson.D{{
-					Key:   "operationType",
-					Value: "delete",
-				}},
-			}},
-		}
+		matchDeleteStage := db.WatchFilter().Ops(db.WatchOpDelete).Build()
 
 		// watch only for delete notification of lock owner
-		err = ownerTable.col.Watch(ctx, matchDeleteStage, table.handleOwnerRelease)
+		err = owner.col.Watch(ctx, matchDeleteStage, table.handleOwnerRelease)
 		if err != nil {
 			cancelFn()
 			return nil, err
@@ -272,7 +482,8 @@ func LocateLockTable[K any](store db.Store, name string) (*LockTable[K], error)
 		// those stale locks, so we must clean them up eagerly.
 		table.cleanupOrphanedLocks()
 
-		lockTables[lockTableKey{store.Name(), name}] = table
+		lockTables[tKey] = table
+		owner.track(table)
 	} else {
 		table, ok = intf.(*LockTable[K])
 		if !ok {
@@ -282,3 +493,30 @@ func LocateLockTable[K any](store db.Store, name string) (*LockTable[K], error)
 
 	return table, nil
 }
+
+// lock table used to coordinate the db.Migrator runners across replicas
+const migrationLockCollection = "db-migration-locks"
+
+// key for the lock coordinating migrations of a scope
+type migrationLockKey struct {
+	Name string `bson:"name"`
+}
+
+type migrationLocker struct {
+	locks *LockTable[migrationLockKey]
+}
+
+func (l *migrationLocker) TryAcquire(ctx context.Context, name string) (io.Closer, error) {
+	return l.locks.TryAcquire(ctx, &migrationLockKey{Name: name})
+}
+
+// NewMigrationLocker returns the locker coordinating the db.Migrator
+// runners across replicas using the lock table hosted in the store,
+// requires the owner infra to be initialized
+func NewMigrationLocker(store db.Store) (db.MigrationLocker, error) {
+	locks, err := LocateLockTable[migrationLockKey](store, migrationLockCollection)
+	if err != nil {
+		return nil, err
+	}
+	return &migrationLocker{locks: locks}, nil
+}
@@ -0,0 +1,147 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// suffix of the lock table holding the waiters queue of a fair lock
+	// table
+	lockQueueSuffix = "-queue"
+)
+
+// key of a waiter queued for the lock, held as a lock in the waiters
+// queue, ensuring the cleanup of the waiters once their owner ages out
+type lockWaiterKey struct {
+	Key    any   `bson:"key"`
+	Ticket int64 `bson:"ticket"`
+}
+
+// key of the counter issuing the tickets of the waiters for a lock, kept
+// along with the fencing tokens
+type lockTicketKey[K any] struct {
+	Queue K `bson:"queue"`
+}
+
+// last ticket issued to the waiters of a lock
+type lockTicketData struct {
+	Ticket int64 `bson:"ticket,omitempty"`
+}
+
+// returns the waiters queue of the table, locating it on first use
+func (t *LockTable[K]) waiters() (*LockTable[lockWaiterKey], error) {
+	t.queueOnce.Do(func() {
+		t.queue, t.queueErr = LocateLockTable[lockWaiterKey](t.store, t.colName+lockQueueSuffix,
+			WithLockOwner(&Owner{t: t.owner}))
+	})
+	return t.queue, t.queueErr
+}
+
+// returns the next ticket for the waiters of the lock, determining their
+// order in the queue
+func (t *LockTable[K]) nextTicket(ctx context.Context, key *K) (int64, error) {
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "ticket", Value: int64(1)}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	data := &lockTicketData{}
+	if err := t.tokens.FindOneAndUpdate(ctx, &lockTicketKey[K]{Queue: *key}, update, data, opts); err != nil {
+		return 0, err
+	}
+	return data.Ticket, nil
+}
+
+// returns the number of waiters queued for the lock ahead of the ticket,
+// all the waiters if ticket is zero
+func (t *LockTable[K]) queuePosition(ctx context.Context, key *K, ticket int64) (int, error) {
+	queue, err := t.waiters()
+	if err != nil {
+		return 0, err
+	}
+	filter := bson.D{{Key: "_id.key", Value: key}}
+	if ticket != 0 {
+		filter = append(filter, bson.E{Key: "_id.ticket", Value: bson.D{{Key: "$lt", Value: ticket}}})
+	}
+	count, err := queue.col.Count(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// returns AlreadyExists error if there are waiters queued for the lock,
+// unless the lock is held by self in the reentrant table
+func (t *LockTable[K]) checkQueue(ctx context.Context, key *K) error {
+	if t.opts.Reentrant && t.heldBySelf(key) {
+		return nil
+	}
+	count, err := t.queuePosition(ctx, key, 0)
+	if err != nil {
+		return err
+	}
+	if count != 0 {
+		return errors.Wrapf(errors.AlreadyExists, "lock %v has %d waiters queued", key, count)
+	}
+	return nil
+}
+
+// acquires the lock for the key in the order of arrival, queueing self
+// as a waiter until the waiters ahead acquire the lock or leave
+func (t *LockTable[K]) acquireFair(ctx context.Context, key *K, onPosition func(position int)) (Lock, error) {
+	// acquire right away if no one holds or waits for the lock
+	lock, err := t.TryAcquire(ctx, key)
+	if err == nil || !errors.IsAlreadyExists(err) {
+		return lock, err
+	}
+
+	queue, err := t.waiters()
+	if err != nil {
+		return nil, err
+	}
+	ticket, err := t.nextTicket(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	waiter, err := queue.TryAcquire(ctx, &lockWaiterKey{Key: key, Ticket: ticket})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = waiter.Close() }()
+
+	last := -1
+	for {
+		// subscribe before trying, to not miss a release in between
+		released := t.releaseNotify()
+		left := queue.releaseNotify()
+		position, err := t.queuePosition(ctx, key, ticket)
+		if err != nil {
+			return nil, err
+		}
+		if position != last {
+			last = position
+			if onPosition != nil {
+				onPosition(position)
+			}
+		}
+		if position == 0 {
+			lock, err := t.acquireOnce(ctx, key)
+			if err == nil || !errors.IsAlreadyExists(err) {
+				return lock, err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(errors.Unavailable, "failed to acquire lock %v: %s", key, ctx.Err())
+		case <-released:
+		case <-left:
+		case <-time.After(t.acquireRetryWait(ctx, key)):
+		}
+	}
+}
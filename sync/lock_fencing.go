@@ -0,0 +1,102 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// suffix of the collection holding the fencing tokens of a lock table
+	lockTokenCollectionSuffix = "-tokens"
+)
+
+// last fencing token issued or observed for a key
+type fencingData struct {
+	Token int64 `bson:"token,omitempty"`
+}
+
+// returns the next fencing token for the key, tokens are never reused as
+// they outlive the locks
+func (t *LockTable[K]) nextToken(ctx context.Context, key *K) (int64, error) {
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "token", Value: int64(1)}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	data := &fencingData{}
+	if err := t.tokens.FindOneAndUpdate(ctx, key, update, data, opts); err != nil {
+		return 0, err
+	}
+	return data.Token, nil
+}
+
+// issues the next fencing token to the lock just acquired, recording it
+// in the lock only while still held with the lease, where the lock is
+// released if the token cannot be issued
+// returns AlreadyExists error if the lock is taken over in between
+func (t *LockTable[K]) assignToken(ctx context.Context, key *K, data *lockData) error {
+	token, err := t.nextToken(ctx, key)
+	if err != nil {
+		t.releaseLease(ctx, key, data.Lease)
+		return err
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "token", Value: token}}}}
+	cond := db.UpdateCondition{Filter: bson.D{{Key: "lease", Value: data.Lease}}}
+	if err := t.col.FindOneAndUpdate(ctx, key, update, &lockData{}, cond); err != nil {
+		if errors.IsNotFound(err) {
+			return errors.Wrapf(errors.AlreadyExists, "lock %v taken over while being acquired", key)
+		}
+		t.releaseLease(ctx, key, data.Lease)
+		return err
+	}
+	data.Token = token
+	return nil
+}
+
+// releases the lock for the key only if still held with the lease
+func (t *LockTable[K]) releaseLease(ctx context.Context, key *K, lease string) {
+	filter := bson.D{
+		{Key: "_id", Value: key},
+		{Key: "lease", Value: lease},
+	}
+	if _, err := t.col.DeleteMany(ctx, filter); err != nil && !errors.IsNotFound(err) {
+		log.Printf("lock-table: failed to release lock %v: %s", key, err)
+	}
+}
+
+func (l *lockImpl[K]) Token() int64 {
+	return l.data.Token
+}
+
+// CheckFencingToken guards the writes to the resource identified by key,
+// recording the highest fencing token observed for the resource in col.
+// Writers pass the token of the lock they hold before every write,
+// where a holder that lost the lock, eg. paused past its lease, is
+// rejected once the new holder has written to the resource
+// returns Forbidden error if the token is older than the one observed
+func CheckFencingToken(ctx context.Context, col db.StoreCollection, key any, token int64) error {
+	if token <= 0 {
+		return errors.Wrap(errors.InvalidArgument, "invalid fencing token")
+	}
+	update := bson.D{{Key: "$max", Value: bson.D{{Key: "token", Value: token}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+	prev := &fencingData{}
+	err := col.FindOneAndUpdate(ctx, key, update, prev, opts)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// first write to the resource
+			return nil
+		}
+		return err
+	}
+	if prev.Token > token {
+		return errors.Wrapf(errors.Forbidden, "stale fencing token %d, resource is guarded by token %d", token, prev.Token)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// minimum wait before retrying a lock expiring soon
+	minLockExpiryWait = 10 * time.Millisecond
+
+	// grace after the expiry of a lock before the store removes it, TTL
+	// index needs a non zero expiry
+	lockExpiryIndexGrace = time.Second
+)
+
+// LockTableOptions provides the configuration of the lock table
+type LockTableOptions struct {
+	// TTL is the lease of the locks, a lock expires once the lease
+	// elapses without being extended, even if the holder is alive,
+	// where the contenders may acquire it
+	// Default: 0 (locks are held until released or the owner ages out)
+	TTL time.Duration
+
+	// AutoRenew extends the lease of the locks in the background every
+	// one third of the TTL, until released or the process stops. Without
+	// auto renewal the holder needs to keep extending the lease while
+	// making progress, allowing a wedged holder to lose the lock
+	// Default: false
+	AutoRenew bool
+
+	// Owner is the owner the locks of the table are held by, cleaned up
+	// once the owner is closed or ages out
+	// Default: nil (package level owner, see InitializeOwner)
+	Owner *Owner
+
+	// Reentrant allows the owner to acquire a lock it already holds,
+	// where the lock is released once all the acquisitions are closed
+	// Default: false (acquiring a held lock fails with AlreadyExists)
+	Reentrant bool
+
+	// Fair grants the lock to the waiters in the order of their arrival,
+	// using a queue collection, preventing the starvation of the slower
+	// nodes, TryAcquire fails while there are waiters queued
+	// Default: false (whoever retries first acquires the lock)
+	Fair bool
+}
+
+// LockTableOption is a functional option for configuring the lock table
+type LockTableOption func(*LockTableOptions)
+
+// WithLockTTL sets the lease of the locks in the table
+func WithLockTTL(ttl time.Duration) LockTableOption {
+	return func(opts *LockTableOptions) {
+		opts.TTL = ttl
+	}
+}
+
+// WithLockAutoRenew enables extending the lease of the locks in the
+// background while held
+func WithLockAutoRenew() LockTableOption {
+	return func(opts *LockTableOptions) {
+		opts.AutoRenew = true
+	}
+}
+
+// WithLockOwner binds the lock table to the owner, instead of the package
+// level owner
+func WithLockOwner(owner *Owner) LockTableOption {
+	return func(opts *LockTableOptions) {
+		opts.Owner = owner
+	}
+}
+
+// WithLockReentrant allows the owner to acquire the locks it already
+// holds, counting the references released on Close
+func WithLockReentrant() LockTableOption {
+	return func(opts *LockTableOptions) {
+		opts.Reentrant = true
+	}
+}
+
+// WithLockFairness grants the locks to the waiters in FIFO order
+func WithLockFairness() LockTableOption {
+	return func(opts *LockTableOptions) {
+		opts.Fair = true
+	}
+}
+
+func getLockTableOptions(opts []LockTableOption) (*LockTableOptions, error) {
+	lopts := &LockTableOptions{}
+	for _, opt := range opts {
+		opt(lopts)
+	}
+	if lopts.TTL < 0 {
+		return nil, errors.Wrap(errors.InvalidArgument, "lock ttl must not be negative")
+	}
+	if lopts.AutoRenew && lopts.TTL == 0 {
+		return nil, errors.Wrap(errors.InvalidArgument, "lock auto renewal requires ttl")
+	}
+	return lopts, nil
+}
+
+// releases the lock for the key if its lease has expired, returns true
+// if released
+func (t *LockTable[K]) releaseExpired(ctx context.Context, key *K) bool {
+	filter := bson.D{
+		{Key: "_id", Value: key},
+		{Key: "expireAt", Value: bson.D{{Key: "$lt", Value: time.Now()}}},
+	}
+	count, err := t.col.DeleteMany(ctx, filter)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("lock-table: failed to release expired lock %v: %s", key, err)
+		}
+		return false
+	}
+	if count != 0 {
+		log.Printf("lock-table: released expired lock %v", key)
+	}
+	return count != 0
+}
+
+// ensures the TTL index on the expiry of the locks, allowing the store to
+// remove the expired locks even if no one contends for them, stores not
+// supporting the TTL indexes rely on the contenders stealing the locks
+func (t *LockTable[K]) ensureExpiryIndex(ctx context.Context) {
+	t.expiryIndex.Do(func() {
+		_, err := t.col.CreateIndex(ctx, db.IndexDefinition{
+			Fields: []db.IndexField{{Field: "expireAt", IndexType: db.IndexAscending}},
+			Sparse: true,
+			TTL:    lockExpiryIndexGrace,
+		})
+		if err != nil {
+			log.Printf("lock-table: failed to create expiry index for %s: %s", t.colName, err)
+		}
+	})
+}
+
+// TryAcquireWithTTL acquires the lock for the key, expiring after the
+// ttl, where the contenders may acquire the lock once expired, and the
+// store removes it even otherwise, for the workflows where the holder may
+// never get a chance to release the lock. The lease is not renewed
+// automatically and the lock doesn't participate in the reentrancy
+// returns AlreadyExists error if the lock is held by someone else
+func (t *LockTable[K]) TryAcquireWithTTL(ctx context.Context, key *K, ttl time.Duration) (Lock, error) {
+	if ttl <= 0 {
+		return nil, errors.Wrap(errors.InvalidArgument, "lock ttl must be positive")
+	}
+	t.ensureExpiryIndex(ctx)
+	lock, err := t.tryAcquire(ctx, key, ttl, false)
+	if err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// returns the wait before retrying to acquire the lock held by someone
+// else, until the lease of the lock expires if sooner than the retry
+// interval
+func (t *LockTable[K]) acquireRetryWait(ctx context.Context, key *K) time.Duration {
+	wait := lockAcquireRetryInterval
+	data := &lockData{}
+	if err := t.col.FindOne(ctx, key, data); err != nil || data.ExpireAt == nil {
+		return wait
+	}
+	return max(min(wait, time.Until(*data.ExpireAt)), minLockExpiryWait)
+}
+
+func (l *lockImpl[K]) Extend(ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.Wrap(errors.InvalidArgument, "lock ttl must be positive")
+	}
+	ctx := context.Background()
+	now := time.Now()
+	expireAt := now.Add(ttl)
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "expireAt", Value: expireAt}}}}
+	// extend only while still holding the lease, where the check and the
+	// update happen atomically, ensuring the lease of a new holder that
+	// took over the expired lock is never pushed back
+	cond := db.UpdateCondition{
+		Filter: bson.D{
+			{Key: "lease", Value: l.data.Lease},
+			{Key: "$or", Value: bson.A{
+				bson.D{{Key: "expireAt", Value: bson.D{{Key: "$exists", Value: false}}}},
+				bson.D{{Key: "expireAt", Value: bson.D{{Key: "$gt", Value: now}}}},
+			}},
+		},
+	}
+	if err := l.tbl.col.FindOneAndUpdate(ctx, l.key, update, &lockData{}, cond); err != nil {
+		if errors.IsNotFound(err) {
+			return errors.Wrapf(errors.NotFound, "lock %v is no longer held", l.key)
+		}
+		return err
+	}
+	l.muLease.Lock()
+	l.ttl = ttl
+	l.muLease.Unlock()
+	return nil
+}
+
+// extends the lease periodically until the lock is released, lost or the
+// lock table is stopped
+func (l *lockImpl[K]) renew() {
+	for {
+		l.muLease.Lock()
+		interval := l.ttl / 3
+		ttl := l.ttl
+		l.muLease.Unlock()
+		select {
+		case <-l.stop:
+			return
+		case <-l.tbl.ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		if err := l.Extend(ttl); err != nil {
+			log.Printf("lock-table: failed to renew lease of lock %v: %s", l.key, err)
+			if errors.IsNotFound(err) {
+				return
+			}
+		}
+	}
+}
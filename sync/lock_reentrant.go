@@ -0,0 +1,128 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// lock held by self in the reentrant table, along with the count of the
+// acquisitions not closed yet
+type reentrantEntry[K any] struct {
+	lock *lockImpl[K]
+	refs int
+}
+
+// handle of an acquisition of the reentrant lock, releasing the lock only
+// when the last acquisition is closed
+type reentrantLock[K any] struct {
+	*lockImpl[K]
+	entry *reentrantEntry[K]
+	once  sync.Once
+}
+
+func (l *reentrantLock[K]) Close() error {
+	var err error
+	l.once.Do(func() {
+		err = l.tbl.releaseReentrant(l.entry)
+	})
+	return err
+}
+
+// returns the identity of the key in the locks held by self
+func reentrantKey[K any](key *K) (string, error) {
+	raw, err := bson.Marshal(key)
+	if err != nil {
+		return "", errors.Wrapf(errors.InvalidArgument, "failed to encode lock key %v: %s", key, err)
+	}
+	return string(raw), nil
+}
+
+// returns true if the lock is still held, ie. neither expired nor
+// cleaned up while the owner was wedged
+func (t *LockTable[K]) stillHeld(ctx context.Context, lock *lockImpl[K]) (bool, error) {
+	cur := &lockData{}
+	if err := t.col.FindOne(ctx, lock.key, cur); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if cur.Lease != lock.data.Lease {
+		return false, nil
+	}
+	return cur.ExpireAt == nil || cur.ExpireAt.After(time.Now()), nil
+}
+
+// acquires the lock for the key, reusing the lock if already held by self
+func (t *LockTable[K]) tryAcquireReentrant(ctx context.Context, key *K) (Lock, error) {
+	id, err := reentrantKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// acquisitions are serialized, for the concurrent acquisitions by
+	// self to not contend with each other
+	t.muHeld.Lock()
+	defer t.muHeld.Unlock()
+	if t.held == nil {
+		t.held = map[string]*reentrantEntry[K]{}
+	}
+	if entry, ok := t.held[id]; ok {
+		held, err := t.stillHeld(ctx, entry.lock)
+		if err != nil {
+			return nil, err
+		}
+		if held {
+			entry.refs++
+			return &reentrantLock[K]{lockImpl: entry.lock, entry: entry}, nil
+		}
+		// lost the lock, acquire afresh
+		delete(t.held, id)
+	}
+
+	lock, err := t.tryAcquire(ctx, key, t.opts.TTL, t.opts.AutoRenew)
+	if err != nil {
+		return nil, err
+	}
+	entry := &reentrantEntry[K]{lock: lock, refs: 1}
+	t.held[id] = entry
+	return &reentrantLock[K]{lockImpl: lock, entry: entry}, nil
+}
+
+// releases an acquisition of the reentrant lock, releasing the lock once
+// all the acquisitions are released
+func (t *LockTable[K]) releaseReentrant(entry *reentrantEntry[K]) error {
+	t.muHeld.Lock()
+	entry.refs--
+	if entry.refs > 0 {
+		t.muHeld.Unlock()
+		return nil
+	}
+	id, err := reentrantKey(entry.lock.key)
+	if err == nil && t.held[id] == entry {
+		delete(t.held, id)
+	}
+	t.muHeld.Unlock()
+	return entry.lock.Close()
+}
+
+// returns true if the lock for the key is held by self in the reentrant
+// table
+func (t *LockTable[K]) heldBySelf(key *K) bool {
+	id, err := reentrantKey(key)
+	if err != nil {
+		return false
+	}
+	t.muHeld.Lock()
+	defer t.muHeld.Unlock()
+	_, ok := t.held[id]
+	return ok
+}
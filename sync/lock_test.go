@@ -5,10 +5,13 @@ package sync
 
 import (
 	"context"
+	gosync "sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"go.mongodb.org/mongo-driver/v2/bson"
+
 	"github.com/go-core-stack/core/db"
 	"github.com/go-core-stack/core/errors"
 	"github.com/go-core-stack/core/reconciler"
@@ -180,3 +183,521 @@ func Test_LockReleaseNotification(t *testing.T) {
 		t.Errorf("expected lock release notification, but controller was not notified")
 	}
 }
+
+// initializes the owner infra on an in-memory store, unless already
+// initialized, returning an in-memory store for hosting the lock tables
+func initMemoryOwner(t *testing.T) db.Store {
+	err := InitializeOwner(context.Background(), db.NewMemoryClient().GetDataStore("test-sync"), "test-owner")
+	if err != nil && !errors.IsAlreadyExists(err) {
+		t.Fatalf("failed to initialize owner: %s", err)
+	}
+	return db.NewMemoryClient().GetDataStore("test-sync-memory")
+}
+
+func Test_LockAcquire(t *testing.T) {
+	s := initMemoryOwner(t)
+	tbl, err := LocateLockTable[lockKey](s, "demo-acquire-test")
+	if err != nil {
+		t.Fatalf("failed to locate Lock Table: %s", err)
+	}
+	key := &lockKey{Scope: "scope-acquire", Name: "test-key"}
+
+	lock, err := tbl.Acquire(context.Background(), key)
+	if err != nil {
+		t.Fatalf("failed to acquire free lock: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := tbl.Acquire(ctx, key); !errors.IsUnavailable(err) {
+		t.Errorf("expected unavailable error while lock is held, got %v", err)
+	}
+
+	acquired := make(chan Lock)
+	go func() {
+		l, err := tbl.Acquire(context.Background(), key)
+		if err != nil {
+			t.Errorf("failed to acquire released lock: %s", err)
+		}
+		acquired <- l
+	}()
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	if err := lock.Close(); err != nil {
+		t.Fatalf("failed to release lock: %s", err)
+	}
+	select {
+	case l := <-acquired:
+		// woken up by the release instead of the retry interval
+		if time.Since(start) >= lockAcquireRetryInterval {
+			t.Errorf("waiter was not woken up by the release")
+		}
+		if l != nil {
+			_ = l.Close()
+		}
+	case <-time.After(2 * lockAcquireRetryInterval):
+		t.Fatalf("waiter did not acquire the released lock")
+	}
+}
+
+// collection running the hook right before the first FindOneAndUpdate,
+// to interleave a competing operation with the update
+type updateHookCollection struct {
+	db.StoreCollection
+	hook func()
+}
+
+func (c *updateHookCollection) FindOneAndUpdate(ctx context.Context, key any, update any, data any, opts ...any) error {
+	if hook := c.hook; hook != nil {
+		c.hook = nil
+		hook()
+	}
+	return c.StoreCollection.FindOneAndUpdate(ctx, key, update, data, opts...)
+}
+
+func Test_LockLease(t *testing.T) {
+	s := initMemoryOwner(t)
+	ctx := context.Background()
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := LocateLockTable[lockKey](s, "demo-lease-invalid", WithLockAutoRenew()); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for auto renewal without ttl, got %v", err)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		tbl, err := LocateLockTable[lockKey](s, "demo-lease-expiry", WithLockTTL(100*time.Millisecond))
+		if err != nil {
+			t.Fatalf("failed to locate Lock Table: %s", err)
+		}
+		key := &lockKey{Scope: "scope-lease", Name: "test-key"}
+		lock, err := tbl.TryAcquire(ctx, key)
+		if err != nil {
+			t.Fatalf("failed to acquire lock: %s", err)
+		}
+		if _, err := tbl.TryAcquire(ctx, key); !errors.IsAlreadyExists(err) {
+			t.Errorf("expected lock to be held within the lease, got %v", err)
+		}
+		if err := lock.Extend(200 * time.Millisecond); err != nil {
+			t.Errorf("failed to extend lease: %s", err)
+		}
+		time.Sleep(150 * time.Millisecond)
+		if _, err := tbl.TryAcquire(ctx, key); !errors.IsAlreadyExists(err) {
+			t.Errorf("expected lock to be held within the extended lease, got %v", err)
+		}
+
+		// wedged holder loses the lock once the lease elapses
+		start := time.Now()
+		other, err := tbl.Acquire(ctx, key)
+		if err != nil {
+			t.Fatalf("failed to acquire expired lock: %s", err)
+		}
+		if time.Since(start) >= lockAcquireRetryInterval {
+			t.Errorf("expected waiter to retry on lease expiry")
+		}
+		if err := lock.Extend(time.Second); !errors.IsNotFound(err) {
+			t.Errorf("expected not found while extending lost lock, got %v", err)
+		}
+		_ = lock.Close()
+		if _, err := tbl.TryAcquire(ctx, key); !errors.IsAlreadyExists(err) {
+			t.Errorf("expected close of lost lock to retain the new holder, got %v", err)
+		}
+		_ = other.Close()
+	})
+
+	t.Run("takeover_before_extend", func(t *testing.T) {
+		tbl, err := LocateLockTable[lockKey](s, "demo-lease-takeover", WithLockTTL(time.Minute))
+		if err != nil {
+			t.Fatalf("failed to locate Lock Table: %s", err)
+		}
+		key := &lockKey{Scope: "scope-lease", Name: "test-key"}
+		lock, err := tbl.TryAcquire(ctx, key)
+		if err != nil {
+			t.Fatalf("failed to acquire lock: %s", err)
+		}
+
+		// lease expires and someone else takes over the lock right
+		// before the extension is written
+		col := tbl.col
+		var other Lock
+		tbl.col = &updateHookCollection{
+			StoreCollection: col,
+			hook: func() {
+				_, _ = col.DeleteMany(ctx, bson.D{{Key: "_id", Value: key}})
+				other, err = tbl.TryAcquire(ctx, key)
+			},
+		}
+		extendErr := lock.Extend(time.Hour)
+		tbl.col = col
+		if err != nil {
+			t.Fatalf("failed to take over the lock: %s", err)
+		}
+		if !errors.IsNotFound(extendErr) {
+			t.Errorf("expected not found while extending lock taken over, got %v", extendErr)
+		}
+		data := &lockData{}
+		if err := col.FindOne(ctx, key, data); err != nil {
+			t.Fatalf("failed to find lock: %s", err)
+		}
+		if data.ExpireAt == nil || data.ExpireAt.After(time.Now().Add(time.Minute)) {
+			t.Errorf("expected lease of the new holder to be retained, got expiry %v", data.ExpireAt)
+		}
+		_ = lock.Close()
+		_ = other.Close()
+	})
+
+	t.Run("auto_renew", func(t *testing.T) {
+		tbl, err := LocateLockTable[lockKey](s, "demo-lease-renew", WithLockTTL(60*time.Millisecond), WithLockAutoRenew())
+		if err != nil {
+			t.Fatalf("failed to locate Lock Table: %s", err)
+		}
+		key := &lockKey{Scope: "scope-lease", Name: "test-key"}
+		lock, err := tbl.TryAcquire(ctx, key)
+		if err != nil {
+			t.Fatalf("failed to acquire lock: %s", err)
+		}
+		time.Sleep(200 * time.Millisecond)
+		if _, err := tbl.TryAcquire(ctx, key); !errors.IsAlreadyExists(err) {
+			t.Errorf("expected renewed lock to be held, got %v", err)
+		}
+		if err := lock.Close(); err != nil {
+			t.Errorf("failed to release lock: %s", err)
+		}
+		other, err := tbl.TryAcquire(ctx, key)
+		if err != nil {
+			t.Fatalf("failed to acquire released lock: %s", err)
+		}
+		_ = other.Close()
+	})
+}
+
+func Test_LockFencing(t *testing.T) {
+	s := initMemoryOwner(t)
+	ctx := context.Background()
+	tbl, err := LocateLockTable[lockKey](s, "demo-fencing", WithLockTTL(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to locate Lock Table: %s", err)
+	}
+	key := &lockKey{Scope: "scope-fencing", Name: "test-key"}
+	guard := s.GetCollection("demo-fencing-guard")
+
+	first, err := tbl.TryAcquire(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to acquire lock: %s", err)
+	}
+	if err := CheckFencingToken(ctx, guard, key, first.Token()); err != nil {
+		t.Errorf("expected write of the holder to be allowed, got %s", err)
+	}
+
+	// contended attempts do not spend the fencing tokens
+	for i := 0; i < 3; i++ {
+		if _, err := tbl.TryAcquire(ctx, key); !errors.IsAlreadyExists(err) {
+			t.Errorf("expected lock to be held, got %v", err)
+		}
+	}
+
+	// first holder pauses past its lease and the lock is reassigned
+	time.Sleep(100 * time.Millisecond)
+	second, err := tbl.TryAcquire(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to acquire expired lock: %s", err)
+	}
+	if second.Token() != first.Token()+1 {
+		t.Errorf("expected next fencing token %d, got %d", first.Token()+1, second.Token())
+	}
+	if err := CheckFencingToken(ctx, guard, key, second.Token()); err != nil {
+		t.Errorf("expected write of the new holder to be allowed, got %s", err)
+	}
+	if err := CheckFencingToken(ctx, guard, key, first.Token()); !errors.IsForbidden(err) {
+		t.Errorf("expected stale holder to be rejected, got %v", err)
+	}
+	_ = second.Close()
+
+	third, err := tbl.TryAcquire(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to acquire released lock: %s", err)
+	}
+	if third.Token() <= second.Token() {
+		t.Errorf("expected fencing token to increase across release, got %d after %d", third.Token(), second.Token())
+	}
+	_ = third.Close()
+}
+
+func Test_RWLock(t *testing.T) {
+	s := initMemoryOwner(t)
+	ctx := context.Background()
+	tbl, err := LocateRWLockTable[lockKey](s, "demo-rwlock")
+	if err != nil {
+		t.Fatalf("failed to locate RW Lock Table: %s", err)
+	}
+	key := &lockKey{Scope: "scope-rw", Name: "test-key"}
+
+	r1, err := tbl.TryRLock(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to acquire read lock: %s", err)
+	}
+	r2, err := tbl.RLock(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to acquire concurrent read lock: %s", err)
+	}
+	if _, err := tbl.TryLock(ctx, key); !errors.IsAlreadyExists(err) {
+		t.Errorf("expected write lock to fail while readers hold it, got %v", err)
+	}
+	other := &lockKey{Scope: "scope-rw", Name: "other-key"}
+	w, err := tbl.TryLock(ctx, other)
+	if err != nil {
+		t.Fatalf("failed to acquire write lock of other key: %s", err)
+	}
+	_ = w.Close()
+
+	// writer waits for the readers to drain, blocking the new readers
+	acquired := make(chan Lock)
+	go func() {
+		l, err := tbl.Lock(ctx, key)
+		if err != nil {
+			t.Errorf("failed to acquire write lock: %s", err)
+		}
+		acquired <- l
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if _, err := tbl.TryRLock(ctx, key); !errors.IsAlreadyExists(err) {
+		t.Errorf("expected new reader to be blocked by waiting writer, got %v", err)
+	}
+	_ = r1.Close()
+	_ = r2.Close()
+	var wl Lock
+	select {
+	case wl = <-acquired:
+	case <-time.After(lockAcquireRetryInterval):
+		t.Fatalf("writer was not woken up once the readers drained")
+	}
+
+	rctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := tbl.RLock(rctx, key); !errors.IsUnavailable(err) {
+		t.Errorf("expected reader to time out while writer holds the lock, got %v", err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = wl.Close()
+	}()
+	r, err := tbl.RLock(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to acquire read lock after writer release: %s", err)
+	}
+	_ = r.Close()
+}
+
+func Test_LockReentrant(t *testing.T) {
+	s := initMemoryOwner(t)
+	ctx := context.Background()
+	tbl, err := LocateLockTable[lockKey](s, "demo-reentrant-test", WithLockReentrant())
+	if err != nil {
+		t.Fatalf("failed to locate Lock Table: %s", err)
+	}
+	key := &lockKey{Scope: "scope-reentrant", Name: "test-key"}
+
+	outer, err := tbl.TryAcquire(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to acquire lock: %s", err)
+	}
+	inner, err := tbl.Acquire(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to re-acquire held lock: %s", err)
+	}
+	if inner.Token() != outer.Token() {
+		t.Errorf("expected re-acquisition to share the lock, got tokens %d, %d", outer.Token(), inner.Token())
+	}
+
+	// lock is held until the last acquisition is closed
+	_ = inner.Close()
+	_ = inner.Close()
+	if n, err := tbl.col.Count(ctx, nil); err != nil || n != 1 {
+		t.Errorf("expected lock to be held after inner close, got %d, %v", n, err)
+	}
+	_ = outer.Close()
+	if n, err := tbl.col.Count(ctx, nil); err != nil || n != 0 {
+		t.Errorf("expected lock to be released after outer close, got %d, %v", n, err)
+	}
+
+	// non reentrant table fails re-acquisition
+	plain, err := LocateLockTable[lockKey](s, "demo-non-reentrant-test")
+	if err != nil {
+		t.Fatalf("failed to locate Lock Table: %s", err)
+	}
+	lock, err := plain.TryAcquire(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to acquire lock: %s", err)
+	}
+	if _, err := plain.TryAcquire(ctx, key); !errors.IsAlreadyExists(err) {
+		t.Errorf("expected already exists re-acquiring non reentrant lock, got %v", err)
+	}
+	_ = lock.Close()
+}
+
+func Test_LockAcquireWithTTL(t *testing.T) {
+	s := initMemoryOwner(t)
+	ctx := context.Background()
+	tbl, err := LocateLockTable[lockKey](s, "demo-acquire-ttl-test")
+	if err != nil {
+		t.Fatalf("failed to locate Lock Table: %s", err)
+	}
+	key := &lockKey{Scope: "scope-acquire-ttl", Name: "test-key"}
+
+	if _, err := tbl.TryAcquireWithTTL(ctx, key, 0); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for zero ttl, got %v", err)
+	}
+	lock, err := tbl.TryAcquireWithTTL(ctx, key, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to acquire lock: %s", err)
+	}
+	if _, err := tbl.TryAcquire(ctx, key); !errors.IsAlreadyExists(err) {
+		t.Errorf("expected lock to be held before expiry, got %v", err)
+	}
+
+	indexes, err := tbl.col.ListIndexes(ctx)
+	if err != nil {
+		t.Fatalf("failed to list indexes: %s", err)
+	}
+	found := false
+	for _, idx := range indexes {
+		if len(idx.Fields) == 1 && idx.Fields[0].Field == "expireAt" && idx.TTL != 0 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected expiry index on the locks, got %+v", indexes)
+	}
+
+	// contender steals the expired lock, without the holder closing it
+	time.Sleep(100 * time.Millisecond)
+	stolen, err := tbl.TryAcquire(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to acquire expired lock: %s", err)
+	}
+	if stolen.Token() <= lock.Token() {
+		t.Errorf("expected newer fencing token for stolen lock, got %d <= %d", stolen.Token(), lock.Token())
+	}
+	// stale holder closing doesn't release the stolen lock
+	_ = lock.Close()
+	if _, err := tbl.TryAcquire(ctx, key); !errors.IsAlreadyExists(err) {
+		t.Errorf("expected stolen lock to remain held, got %v", err)
+	}
+	_ = stolen.Close()
+}
+
+func Test_LockFairness(t *testing.T) {
+	s := initMemoryOwner(t)
+	ctx := context.Background()
+	tbl, err := LocateLockTable[lockKey](s, "demo-fair-test", WithLockFairness())
+	if err != nil {
+		t.Fatalf("failed to locate Lock Table: %s", err)
+	}
+	key := &lockKey{Scope: "scope-fair", Name: "test-key"}
+
+	holder, err := tbl.TryAcquire(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to acquire lock: %s", err)
+	}
+
+	var mu gosync.Mutex
+	order := []string{}
+	positions := map[string][]int{}
+	acquired := make(chan Lock, 2)
+	wait := func(name string) {
+		lock, err := tbl.AcquireWithPosition(ctx, key, func(position int) {
+			mu.Lock()
+			defer mu.Unlock()
+			positions[name] = append(positions[name], position)
+		})
+		if err != nil {
+			t.Errorf("failed to acquire lock by %s: %s", name, err)
+			return
+		}
+		mu.Lock()
+		order = append(order, name)
+		mu.Unlock()
+		acquired <- lock
+	}
+	go wait("first")
+	time.Sleep(50 * time.Millisecond)
+	go wait("second")
+	time.Sleep(50 * time.Millisecond)
+
+	// queued waiters are granted before the new contenders
+	_ = holder.Close()
+	lock := <-acquired
+	if _, err := tbl.TryAcquire(ctx, key); !errors.IsAlreadyExists(err) {
+		t.Errorf("expected lock to be held while waiters queued, got %v", err)
+	}
+	_ = lock.Close()
+	lock = <-acquired
+	_ = lock.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("expected lock to be granted in FIFO order, got %v", order)
+	}
+	if p := positions["second"]; len(p) < 2 || p[0] != 1 || p[len(p)-1] != 0 {
+		t.Errorf("expected second waiter to move from position 1 to 0, got %v", p)
+	}
+	if n, err := tbl.queuePosition(ctx, key, 0); err != nil || n != 0 {
+		t.Errorf("expected waiters queue to be drained, got %d, %v", n, err)
+	}
+}
+
+func Test_LockWatchKey(t *testing.T) {
+	s := initMemoryOwner(t)
+	tbl, err := LocateLockTable[lockKey](s, "demo-watch-key-test")
+	if err != nil {
+		t.Fatalf("failed to locate Lock Table: %s", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	key := &lockKey{Scope: "scope-watch", Name: "watched"}
+	other := &lockKey{Scope: "scope-watch", Name: "other"}
+
+	if err := tbl.WatchKey(ctx, key, nil); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument without callback, got %v", err)
+	}
+	var fired atomic.Int32
+	if err := tbl.WatchKey(ctx, key, func() { fired.Add(1) }); err != nil {
+		t.Fatalf("failed to watch key: %s", err)
+	}
+
+	otherLock, err := tbl.TryAcquire(ctx, other)
+	if err != nil {
+		t.Fatalf("failed to acquire other lock: %s", err)
+	}
+	lock, err := tbl.TryAcquire(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to acquire watched lock: %s", err)
+	}
+	_ = otherLock.Close()
+	time.Sleep(50 * time.Millisecond)
+	if fired.Load() != 0 {
+		t.Errorf("expected no notification for other keys or acquisition, got %d", fired.Load())
+	}
+
+	_ = lock.Close()
+	for i := 0; i < 100 && fired.Load() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if fired.Load() != 1 {
+		t.Errorf("expected one release notification, got %d", fired.Load())
+	}
+
+	// no notifications once the context is done
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	lock, err = tbl.TryAcquire(context.Background(), key)
+	if err != nil {
+		t.Fatalf("failed to acquire watched lock again: %s", err)
+	}
+	_ = lock.Close()
+	time.Sleep(50 * time.Millisecond)
+	if fired.Load() != 1 {
+		t.Errorf("expected no notification after cancel, got %d", fired.Load())
+	}
+}
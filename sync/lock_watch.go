@@ -0,0 +1,45 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+// WatchKey invokes fn whenever the lock for the key is released, ie.
+// closed by the holder, expired or cleaned up as the holder aged out,
+// until the context is done or the table is shut down along with its
+// owner. The watch is filtered to the deletes of the key in the store,
+// sparing the caller the collection wide notifications of the table
+func (t *LockTable[K]) WatchKey(ctx context.Context, key *K, fn func()) error {
+	if key == nil || fn == nil {
+		return errors.Wrap(errors.InvalidArgument, "lock key and callback are required to watch")
+	}
+	if err := t.owner.validate(); err != nil {
+		return err
+	}
+
+	wctx, cancelFn := context.WithCancel(ctx)
+	// stop watching along with the table, forgetting the table once done
+	stop := context.AfterFunc(t.ctx, cancelFn)
+	context.AfterFunc(wctx, func() { stop() })
+	filter := mongo.Pipeline{{{Key: "$match", Value: bson.D{
+		{Key: "operationType", Value: db.WatchOpDelete},
+		{Key: "documentKey._id", Value: key},
+	}}}}
+	err := t.col.Watch(wctx, filter, func(op string, wKey any) {
+		fn()
+	})
+	if err != nil {
+		cancelFn()
+		return err
+	}
+	return nil
+}
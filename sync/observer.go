@@ -6,13 +6,10 @@ package sync
 import (
 	"sync"
 
+	"github.com/go-core-stack/core/db"
 	"github.com/go-core-stack/core/reconciler"
 )
 
-type observerCountKey struct {
-	ExtKey any `bson:"_id.extKey,omitempty"`
-}
-
 type observerTable struct {
 	reconciler.ManagerImpl
 	mu        sync.RWMutex
@@ -51,7 +48,7 @@ func (o *observerTable) deleteProvider(key any) {
 	if ok {
 		// since the observer is removed trigger an update
 		// for controllers
-		o.NotifyCallback(key)
+		o.NotifyOpCallback(db.WatchOpDelete, key)
 	}
 }
 
@@ -67,7 +64,7 @@ func (o *observerTable) insertProvider(key any) {
 	}()
 	if !ok {
 		// Notify an insert of new provider to providers
-		o.NotifyCallback(key)
+		o.NotifyOpCallback(db.WatchOpInsert, key)
 	}
 }
 
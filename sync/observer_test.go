@@ -6,6 +6,8 @@ package sync
 import (
 	"context"
 	"log"
+	"strings"
+	gosync "sync"
 	"testing"
 	"time"
 
@@ -66,7 +68,7 @@ func Test_ObserverBaseTesting(t *testing.T) {
 	}
 	_ = tbl.Register("test-observer", obs)
 
-	provider, err := tbl.CreateProvider(context.Background(), "test-key")
+	provider, err := tbl.CreateProvider(context.Background(), "test-key", nil)
 	if err != nil {
 		t.Errorf("failed to create Provider: %s", err)
 	}
@@ -75,7 +77,7 @@ func Test_ObserverBaseTesting(t *testing.T) {
 		t.Errorf("Expected 1 provider but got %d", len(obs.providers))
 	}
 
-	providerDup, err := tbl.CreateProvider(context.Background(), "test-key")
+	providerDup, err := tbl.CreateProvider(context.Background(), "test-key", nil)
 	if err != nil {
 		t.Errorf("failed to create Provider: %s", err)
 	}
@@ -84,7 +86,7 @@ func Test_ObserverBaseTesting(t *testing.T) {
 		t.Errorf("Expected 1 provider but got %d", len(obs.providers))
 	}
 
-	provider1, err := tbl.CreateProvider(context.Background(), "test-key-1")
+	provider1, err := tbl.CreateProvider(context.Background(), "test-key-1", nil)
 	if err != nil {
 		t.Errorf("failed to create provider: %s", err)
 	}
@@ -101,3 +103,58 @@ func Test_ObserverBaseTesting(t *testing.T) {
 		t.Errorf("Expected 0 provider but got %d", len(obs.providers))
 	}
 }
+
+// observer recording the provider keys notified
+type keyRecorder struct {
+	mu   gosync.Mutex
+	keys map[string]struct{}
+}
+
+func (o *keyRecorder) Reconcile(k any) (*reconciler.Result, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.keys[k.(string)] = struct{}{}
+	return &reconciler.Result{}, nil
+}
+
+func (o *keyRecorder) has(key string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, ok := o.keys[key]
+	return ok
+}
+
+func Test_ObserverKeyFilter(t *testing.T) {
+	s := initMemoryOwner(t)
+	tbl, err := LocateProviderTableWithName(s, "provider-filter-test")
+	if err != nil {
+		t.Fatalf("failed to locate provider Table: %s", err)
+	}
+	obs := &keyRecorder{keys: map[string]struct{}{}}
+	err = tbl.Register("gpu-observer", obs, reconciler.WithKeyFilter(func(k any) bool {
+		return strings.HasPrefix(k.(string), "gpu-")
+	}))
+	if err != nil {
+		t.Fatalf("failed to register observer: %s", err)
+	}
+
+	ctx := context.Background()
+	cpu, _ := tbl.CreateProvider(ctx, "cpu-1", nil)
+	gpu, _ := tbl.CreateProvider(ctx, "gpu-1", nil)
+	defer func() { _ = cpu.Close() }()
+	defer func() { _ = gpu.Close() }()
+
+	for range 200 {
+		if obs.has("gpu-1") {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !obs.has("gpu-1") {
+		t.Errorf("expected observer to be notified for gpu provider")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if obs.has("cpu-1") {
+		t.Errorf("expected observer not to be notified for filtered out provider")
+	}
+}
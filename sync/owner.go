@@ -12,7 +12,6 @@ import (
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
 
 	"github.com/go-core-stack/core/db"
 	"github.com/go-core-stack/core/errors"
@@ -44,16 +43,23 @@ type ownerData struct {
 
 type ownerTableType struct {
 	ctx            context.Context
+	cancelFn       context.CancelFunc
+	done           chan struct{} // closed once the heartbeats are stopped
 	store          db.Store
 	col            db.StoreCollection
 	name           string
 	key            *ownerKey
 	updateInterval time.Duration
+
+	// tables holding the entries created by the owner, released on
+	// shutdown of the owner
+	muTables sync.Mutex
+	tables   []ownedTable
 }
 
 func (t *ownerTableType) DeleteCallback(op string, wKey interface{}) {
 	key := wKey.(*ownerKey)
-	if key.Name == t.key.Name {
+	if key.Name == t.key.Name && t.ctx.Err() == nil {
 		log.Panicln("OnwerTable: receiving delete notification of self")
 	}
 }
@@ -79,12 +85,42 @@ func (t *ownerTableType) deleteAgedOwnerTableEntries() {
 			Value: bson.D{{Key: "$lt", Value: filterTime}},
 		},
 	}
+	if auditEnabled() {
+		t.deleteAgedOwnersWithAudit(filter)
+		return
+	}
 	_, err := t.col.DeleteMany(t.ctx, filter)
 	if err != nil && !errors.IsNotFound(err) {
 		log.Printf("failed to perform delete of aged owner table entries")
 	}
 }
 
+// deletes the aged owner entries one by one, recording the owners aged
+// out in the audit trail, where the owners racing to delete the same
+// entry record it only once
+func (t *ownerTableType) deleteAgedOwnersWithAudit(filter any) {
+	list := []struct {
+		Key ownerKey `bson:"_id"`
+	}{}
+	err := t.col.FindMany(t.ctx, filter, &list)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("failed to find aged owner table entries: %s", err)
+		}
+		return
+	}
+	for _, entry := range list {
+		err = t.col.DeleteOne(t.ctx, &entry.Key)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				log.Printf("failed to delete aged owner %s: %s", entry.Key.Name, err)
+			}
+			continue
+		}
+		audit(AuditOwnerLeave, entry.Key.Name, "", nil, "aged out")
+	}
+}
+
 func (t *ownerTableType) allocateOwner(name string) error {
 	id := name
 	if id == "" {
@@ -100,15 +136,7 @@ func (t *ownerTableType) allocateOwner(name string) error {
 		}
 	}
 
-	matchDeleteStage := mongo.Pipeline{
-		bson.D{{
-			Key: "$match",
-			Value: bson.D{{
-				Key:   "operationType",
-				Value: "delete",
-			}},
-		}},
-	}
+	matchDeleteStage := db.WatchFilter().Ops(db.WatchOpDelete).Build()
 
 	err := t.col.SetKeyType(reflect.TypeOf(&ownerKey{}))
 	if err != nil {
@@ -127,19 +155,30 @@ func (t *ownerTableType) allocateOwner(name string) error {
 	}
 
 	log.Printf("Registered Self as %s, in owner-table", t.key.Name)
+	audit(AuditOwnerJoin, t.key.Name, "", nil, "")
 
 	// start a go routine to keep updating the Last Seen time
 	// periodically, ensuring that we keep the entry active and
 	// not letting it age out
 	go func() {
+		defer close(t.done)
 		ticker := time.NewTicker(t.updateInterval)
+		defer ticker.Stop()
+		lastSeen := time.Now()
 		for {
 			select {
 			case <-ticker.C:
+				// account the heartbeats missed, eg. while the process
+				// was wedged or the store being slow
+				if missed := int64(time.Since(lastSeen)/t.updateInterval) - 1; missed > 0 {
+					stats.heartbeatMisses.Add(uint64(missed))
+					log.Printf("owner %s missed %d heartbeats", t.key.Name, missed)
+				}
 				// Trigger a delete for all entries those have atleast
 				// missed default number of updates to the database
 				// this helps aging out the entry
 				t.updateLastSeen()
+				lastSeen = time.Now()
 				t.deleteAgedOwnerTableEntries()
 			case <-t.ctx.Done():
 				// exit the update loop as the context under which
@@ -165,6 +204,133 @@ var (
 	ownerTableInit sync.Mutex
 )
 
+// table holding the entries, eg. locks or providers, created by the owner
+type ownedTable interface {
+	// releases all the entries created by the owner and stops working
+	// on the table
+	releaseOwner(ctx context.Context, owner string) error
+}
+
+// tracks the table for the release of entries on owner shutdown
+func (t *ownerTableType) track(tbl ownedTable) {
+	t.muTables.Lock()
+	defer t.muTables.Unlock()
+	t.tables = append(t.tables, tbl)
+}
+
+// returns error if the owner is not usable anymore, ie. shut down
+func (t *ownerTableType) validate() error {
+	if t == nil || t.key == nil {
+		return errors.Wrap(errors.InvalidArgument, "owner infra is not initialized")
+	}
+	if t.ctx.Err() != nil {
+		return errors.Wrapf(errors.InvalidArgument, "owner %s is already shut down", t.key.Name)
+	}
+	return nil
+}
+
+// allocates a new owner in the store, registering self in the owner table
+func newOwnerTable(ctx context.Context, store db.Store, name string, interval time.Duration) (*ownerTableType, error) {
+	ctx, cancelFn := context.WithCancel(ctx)
+	t := &ownerTableType{
+		ctx:            ctx,
+		cancelFn:       cancelFn,
+		done:           make(chan struct{}),
+		store:          store,
+		col:            store.GetCollection(ownerShipCollection),
+		name:           name,
+		updateInterval: time.Duration(interval * time.Second),
+	}
+
+	// allocate owner entry context
+	err := t.allocateOwner(name)
+	if err != nil {
+		cancelFn()
+		return nil, err
+	}
+	return t, nil
+}
+
+// shuts down the owner, releasing the entries created by the owner and
+// stopping the heartbeats, which also deletes the self owner entry
+func (t *ownerTableType) shutdown(ctx context.Context) error {
+	// release the entries created by self, before stopping the tables
+	t.muTables.Lock()
+	tables := t.tables
+	t.tables = nil
+	t.muTables.Unlock()
+	var rerr error
+	for _, tbl := range tables {
+		if err := tbl.releaseOwner(ctx, t.key.Name); err != nil && !errors.IsNotFound(err) {
+			log.Printf("failed releasing entries of owner %s: %s", t.key.Name, err)
+			rerr = err
+		}
+	}
+
+	t.cancelFn()
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return errors.Wrapf(errors.Unavailable, "timed out stopping owner %s: %s", t.key.Name, ctx.Err())
+	}
+
+	// forget the lock tables bound to the owner
+	muLockTables.Lock()
+	for k := range lockTables {
+		if k.Owner == t.key.Name {
+			delete(lockTables, k)
+		}
+	}
+	muLockTables.Unlock()
+
+	log.Printf("Released Self %s, from owner-table", t.key.Name)
+	audit(AuditOwnerLeave, t.key.Name, "", nil, "shutdown")
+	return rerr
+}
+
+// Owner is a handle of an owner registered in the owner table, working
+// independently of the other owners in the process, for the libraries
+// embedded in the same binary to not depend on the package level owner.
+// The lock and provider tables bind to the owner explicitly using
+// WithLockOwner and LocateProviderTableWithOwner, the entries created by
+// those tables are owned by the owner, and cleaned up when the owner is
+// closed or ages out
+type Owner struct {
+	t *ownerTableType
+}
+
+// NewOwner registers a new owner with the given name in the owner table
+// hosted in the store, the owner is kept alive until the context is done
+// or the owner is closed
+func NewOwner(ctx context.Context, store db.Store, name string) (*Owner, error) {
+	return NewOwnerWithUpdateInterval(ctx, store, name, defaultOwnerUpdateInterval)
+}
+
+// NewOwnerWithUpdateInterval registers a new owner similar to NewOwner,
+// while allowing specifying the interval of the heartbeats, in seconds
+func NewOwnerWithUpdateInterval(ctx context.Context, store db.Store, name string, interval time.Duration) (*Owner, error) {
+	t, err := newOwnerTable(ctx, store, name, interval)
+	if err != nil {
+		return nil, err
+	}
+	return &Owner{t: t}, nil
+}
+
+// Name returns the unique name of the owner in the owner table
+func (o *Owner) Name() string {
+	return o.t.key.Name
+}
+
+// Close gracefully shuts down the owner, stopping the heartbeats,
+// releasing all the locks and providers created by the owner and deleting
+// the owner entry, the tables bound to the owner are not usable anymore
+func (o *Owner) Close(ctx context.Context) error {
+	if o.t.ctx.Err() != nil {
+		return errors.Wrapf(errors.NotFound, "owner %s is already shut down", o.t.key.Name)
+	}
+	return o.t.shutdown(ctx)
+}
+
 // Initialize the Sync Owner management constructs, anyone while working with
 // this library requires to use this function before actually start consuming
 // any functionality from here.
@@ -189,18 +355,12 @@ func InitializeOwnerWithUpdateInterval(ctx context.Context, store db.Store, name
 		return errors.Wrap(errors.AlreadyExists, "Sync Owner Table is already initialized")
 	}
 
-	col := store.GetCollection(ownerShipCollection)
-
-	ownerTable = &ownerTableType{
-		ctx:            ctx,
-		store:          store,
-		col:            col,
-		name:           name,
-		updateInterval: time.Duration(interval * time.Second),
+	t, err := newOwnerTable(ctx, store, name, interval)
+	if err != nil {
+		return err
 	}
-
-	// allocate owner entry context
-	return ownerTable.allocateOwner(name)
+	ownerTable = t
+	return nil
 }
 
 // Initialize the Sync Owner management constructs, anyone while working with
@@ -213,3 +373,26 @@ func InitializeOwnerTableDefault(ctx context.Context, client db.StoreClient, nam
 	store := client.GetDataStore(ownerShipDatabase)
 	return InitializeOwnerWithUpdateInterval(ctx, store, name, defaultOwnerUpdateInterval)
 }
+
+// ShutdownOwner gracefully shuts down the Sync Owner, stopping the
+// heartbeats, releasing all the locks and providers created by the owner
+// and deleting the owner entry, allowing the others to acquire the
+// released locks right away instead of waiting for the owner to age out.
+// The package is reset afterwards, requiring the owner to be initialized
+// again before consuming any functionality, the handles obtained earlier,
+// eg. the lock tables and locks, are not usable anymore
+// returns NotFound error if the owner is not initialized
+func ShutdownOwner(ctx context.Context) error {
+	ownerTableInit.Lock()
+	defer ownerTableInit.Unlock()
+	if ownerTable == nil {
+		return errors.Wrap(errors.NotFound, "Sync Owner Table is not initialized")
+	}
+	err := ownerTable.shutdown(ctx)
+	if err != nil && errors.IsUnavailable(err) {
+		return err
+	}
+	providerTable = nil
+	ownerTable = nil
+	return err
+}
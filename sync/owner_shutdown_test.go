@@ -0,0 +1,139 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+func Test_ShutdownOwner(t *testing.T) {
+	s := initMemoryOwner(t)
+	ctx := context.Background()
+
+	locks, err := LocateLockTable[lockKey](s, "demo-shutdown-test")
+	if err != nil {
+		t.Fatalf("failed to locate Lock Table: %s", err)
+	}
+	if _, err := locks.TryAcquire(ctx, &lockKey{Scope: "scope-shutdown", Name: "test-key"}); err != nil {
+		t.Fatalf("failed to acquire lock: %s", err)
+	}
+	providers, err := LocateProviderTableWithName(s, "provider-shutdown-test")
+	if err != nil {
+		t.Fatalf("failed to locate provider Table: %s", err)
+	}
+	if _, err := providers.CreateProvider(ctx, "svc", nil); err != nil {
+		t.Fatalf("failed to create provider: %s", err)
+	}
+	owners := ownerTable.col
+	self := ownerTable.key
+
+	if err := ShutdownOwner(ctx); err != nil {
+		t.Fatalf("failed to shutdown owner: %s", err)
+	}
+
+	if n, err := locks.col.Count(ctx, nil); err != nil || n != 0 {
+		t.Errorf("expected locks to be released, got %d, %v", n, err)
+	}
+	if n, err := providers.col.Count(ctx, nil); err != nil || n != 0 {
+		t.Errorf("expected providers to be released, got %d, %v", n, err)
+	}
+	if err := owners.FindOne(ctx, self, &ownerData{}); !errors.IsNotFound(err) {
+		t.Errorf("expected owner entry to be deleted, got %v", err)
+	}
+	if err := ShutdownOwner(ctx); !errors.IsNotFound(err) {
+		t.Errorf("expected not found shutting down again, got %v", err)
+	}
+	if _, err := LocateLockTable[lockKey](s, "demo-shutdown-test"); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected lock table to require owner after shutdown, got %v", err)
+	}
+
+	// owner can be initialized again, starting afresh
+	s = initMemoryOwner(t)
+	relocated, err := LocateLockTable[lockKey](s, "demo-shutdown-test")
+	if err != nil {
+		t.Fatalf("failed to locate Lock Table after restart: %s", err)
+	}
+	if relocated == locks {
+		t.Errorf("expected lock table to be reset after shutdown")
+	}
+	lock, err := relocated.TryAcquire(ctx, &lockKey{Scope: "scope-shutdown", Name: "test-key"})
+	if err != nil {
+		t.Fatalf("failed to acquire lock after restart: %s", err)
+	}
+	_ = lock.Close()
+}
+
+func Test_MultipleOwners(t *testing.T) {
+	ctx := context.Background()
+	s := db.NewMemoryClient().GetDataStore("test-sync-owners")
+
+	first, err := NewOwner(ctx, s, "first")
+	if err != nil {
+		t.Fatalf("failed to create first owner: %s", err)
+	}
+	second, err := NewOwner(ctx, s, "second")
+	if err != nil {
+		t.Fatalf("failed to create second owner: %s", err)
+	}
+	if first.Name() == second.Name() {
+		t.Fatalf("expected unique owner names, got %s", first.Name())
+	}
+
+	firstLocks, err := LocateLockTable[lockKey](s, "demo-owners-test", WithLockOwner(first))
+	if err != nil {
+		t.Fatalf("failed to locate lock table for first owner: %s", err)
+	}
+	secondLocks, err := LocateLockTable[lockKey](s, "demo-owners-test", WithLockOwner(second))
+	if err != nil {
+		t.Fatalf("failed to locate lock table for second owner: %s", err)
+	}
+	if firstLocks == secondLocks {
+		t.Fatalf("expected independent lock tables per owner")
+	}
+	providers, err := LocateProviderTableWithOwner(s, "provider-owners-test", first)
+	if err != nil {
+		t.Fatalf("failed to locate provider table: %s", err)
+	}
+	if _, err := providers.CreateProvider(ctx, "svc", nil); err != nil {
+		t.Fatalf("failed to create provider: %s", err)
+	}
+
+	key := &lockKey{Scope: "scope-owners", Name: "test-key"}
+	if _, err := firstLocks.TryAcquire(ctx, key); err != nil {
+		t.Fatalf("failed to acquire lock by first owner: %s", err)
+	}
+	if _, err := secondLocks.TryAcquire(ctx, key); !errors.IsAlreadyExists(err) {
+		t.Errorf("expected lock to be held by first owner, got %v", err)
+	}
+
+	// closing the first owner releases its locks and providers, while
+	// the second owner keeps working
+	if err := first.Close(ctx); err != nil {
+		t.Fatalf("failed to close first owner: %s", err)
+	}
+	if err := first.Close(ctx); !errors.IsNotFound(err) {
+		t.Errorf("expected not found closing again, got %v", err)
+	}
+	if _, err := firstLocks.TryAcquire(ctx, key); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected closed owner to not acquire locks, got %v", err)
+	}
+	if details, err := providers.GetProviderDetails(ctx, "svc"); err != nil || len(details) != 0 {
+		t.Errorf("expected providers to be released, got %d, %v", len(details), err)
+	}
+	lock, err := secondLocks.TryAcquire(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to acquire released lock by second owner: %s", err)
+	}
+	if data := (&lockData{}); secondLocks.col.FindOne(ctx, key, data) != nil || data.Owner != second.Name() {
+		t.Errorf("expected lock to be held by second owner, got %s", data.Owner)
+	}
+	_ = lock.Close()
+	if err := second.Close(ctx); err != nil {
+		t.Errorf("failed to close second owner: %s", err)
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// lock table hosting the members of the partition assigners
+	partitionMemberCollection = "partition-members"
+
+	// interval at which the assignments are recomputed, besides the
+	// membership change notifications
+	partitionRebalanceInterval = 5 * time.Second
+)
+
+// key for the membership of an owner in a partition assigner, held as a
+// lock, ensuring the cleanup once the owner ages out
+type partitionMemberKey struct {
+	Group  string `bson:"group"`
+	Member string `bson:"member"`
+}
+
+// PartitionCallbackfn is invoked whenever the assignments of the process
+// change, with the partitions newly assigned and the ones revoked
+type PartitionCallbackfn func(assigned []string, revoked []string)
+
+// PartitionAssigner distributes the set of partitions across all the live
+// owners that joined the assigner with the same name, using rendezvous
+// hashing, so that every partition is assigned to exactly one member and
+// only the partitions of the joining or leaving member move on the
+// membership changes. Every member computes its assignments independently
+// from the membership, so the assignments can briefly overlap while the
+// members catch up with a change, consumers needing exclusivity should
+// additionally lock the partition
+type PartitionAssigner struct {
+	name       string
+	partitions []string
+	members    *LockTable[partitionMemberKey]
+	cb         PartitionCallbackfn
+
+	mu       sync.Mutex
+	key      *partitionMemberKey // membership key if joined
+	lock     Lock                // membership lock if joined
+	cancelFn context.CancelFunc
+	assigned map[string]struct{}
+}
+
+// NewPartitionAssigner creates the assigner with the given name for the
+// partitions, hosted in the store, the callback if provided is notified
+// of the changes in the assignments of the process, all the processes
+// are expected to use the same partitions for an assigner, requires the
+// owner infra to be initialized
+func NewPartitionAssigner(store db.Store, name string, partitions []string, cb PartitionCallbackfn) (*PartitionAssigner, error) {
+	if name == "" {
+		return nil, errors.Wrap(errors.InvalidArgument, "partition assigner name is required")
+	}
+	if len(partitions) == 0 {
+		return nil, errors.Wrapf(errors.InvalidArgument, "no partitions specified for assigner %s", name)
+	}
+	members, err := LocateLockTable[partitionMemberKey](store, partitionMemberCollection)
+	if err != nil {
+		return nil, err
+	}
+	return &PartitionAssigner{
+		name:       name,
+		partitions: slices.Clone(partitions),
+		members:    members,
+		cb:         cb,
+		assigned:   map[string]struct{}{},
+	}, nil
+}
+
+// Join registers the process as a member of the assigner, getting its
+// share of the partitions, the assignments are rebalanced as the members
+// join or leave until the context is done or Leave is invoked
+// returns AlreadyExists error if the process has already joined
+func (a *PartitionAssigner) Join(ctx context.Context) error {
+	owner := a.members.owner
+	if err := owner.validate(); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lock != nil {
+		return errors.Wrapf(errors.AlreadyExists, "already joined partition assigner %s", a.name)
+	}
+
+	key := &partitionMemberKey{Group: a.name, Member: owner.key.Name}
+	lock, err := a.members.TryAcquire(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	wctx, cancelFn := context.WithCancel(ctx)
+	trigger := make(chan struct{}, 1)
+	rebalance := func(op string, wKey any) {
+		select {
+		case trigger <- struct{}{}:
+		default:
+		}
+	}
+
+	// membership changes of the assigner
+	err = a.members.col.Watch(wctx, nil, func(op string, wKey any) {
+		if key, ok := wKey.(*partitionMemberKey); ok && key.Group == a.name {
+			rebalance(op, wKey)
+		}
+	})
+	if err == nil {
+		// owners leaving without releasing the membership
+		matchDeleteStage := db.WatchFilter().Ops(db.WatchOpDelete).Build()
+		err = owner.col.Watch(wctx, matchDeleteStage, rebalance)
+	}
+	if err != nil {
+		cancelFn()
+		_ = lock.Close()
+		return err
+	}
+
+	a.key = key
+	a.lock = lock
+	a.cancelFn = cancelFn
+	log.Printf("partition-assigner: joined %s as %s", a.name, key.Member)
+	go a.run(wctx, trigger)
+	return nil
+}
+
+// Leave deregisters the process from the assigner, revoking all its
+// partitions, which are then rebalanced across the remaining members,
+// no-op if not joined
+func (a *PartitionAssigner) Leave() error {
+	a.mu.Lock()
+	lock := a.lock
+	if lock == nil {
+		a.mu.Unlock()
+		return nil
+	}
+	a.lock = nil
+	a.cancelFn()
+	a.mu.Unlock()
+
+	a.update(nil, []string{})
+	err := lock.Close()
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Assignments returns the partitions currently assigned to the process
+func (a *PartitionAssigner) Assignments() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	list := []string{}
+	for _, p := range a.partitions {
+		if _, ok := a.assigned[p]; ok {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// Members returns the owner names of the current members of the assigner
+func (a *PartitionAssigner) Members(ctx context.Context) ([]string, error) {
+	filter := db.KeyFilter(bson.E{Key: "group", Value: a.name})
+	list := []lockKeyOnly[partitionMemberKey]{}
+	err := a.members.col.FindMany(ctx, filter, &list)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	members := []string{}
+	for _, entry := range list {
+		members = append(members, entry.Key.Member)
+	}
+	slices.Sort(members)
+	return members, nil
+}
+
+// rendezvous hashing weight of the member for the partition
+func partitionWeight(member, partition string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(member))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(partition))
+	return h.Sum64()
+}
+
+// returns the partitions assigned to the member, ie. the partitions for
+// which the member has the highest weight across all the members
+func assignPartitions(partitions []string, members []string, member string) []string {
+	assigned := []string{}
+	for _, p := range partitions {
+		owner := ""
+		var weight uint64
+		for _, m := range members {
+			if w := partitionWeight(m, p); owner == "" || w > weight || (w == weight && m < owner) {
+				owner, weight = m, w
+			}
+		}
+		if owner == member {
+			assigned = append(assigned, p)
+		}
+	}
+	return assigned
+}
+
+// recomputes the assignments of the process from the current membership
+func (a *PartitionAssigner) rebalance(ctx context.Context) {
+	a.mu.Lock()
+	key, lock := a.key, a.lock
+	a.mu.Unlock()
+	if lock == nil {
+		return
+	}
+
+	members, err := a.Members(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("partition-assigner: failed to fetch members of %s: %s", a.name, err)
+		}
+		return
+	}
+	if slices.Contains(members, key.Member) {
+		a.update(lock, assignPartitions(a.partitions, members, key.Member))
+		return
+	}
+
+	// membership is lost, eg. cleaned up while the process was wedged,
+	// revoke the partitions and join again to get a share of them
+	a.update(lock, []string{})
+	rejoined, err := a.members.TryAcquire(ctx, key)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("partition-assigner: failed to rejoin %s: %s", a.name, err)
+		}
+		return
+	}
+	a.mu.Lock()
+	if a.lock != lock {
+		// left in between
+		a.mu.Unlock()
+		_ = rejoined.Close()
+		return
+	}
+	a.lock = rejoined
+	a.mu.Unlock()
+}
+
+// updates the assignments of the process, if the membership held with
+// the lock is still current, notifying the callback of the changes
+func (a *PartitionAssigner) update(lock Lock, partitions []string) {
+	a.mu.Lock()
+	if a.lock != lock {
+		// stale update racing with Leave or a rejoin
+		a.mu.Unlock()
+		return
+	}
+	current := map[string]struct{}{}
+	added := []string{}
+	for _, p := range partitions {
+		current[p] = struct{}{}
+		if _, ok := a.assigned[p]; !ok {
+			added = append(added, p)
+		}
+	}
+	revoked := []string{}
+	for _, p := range a.partitions {
+		if _, ok := a.assigned[p]; ok {
+			if _, ok := current[p]; !ok {
+				revoked = append(revoked, p)
+			}
+		}
+	}
+	a.assigned = current
+	a.mu.Unlock()
+
+	if len(added) == 0 && len(revoked) == 0 {
+		return
+	}
+	log.Printf("partition-assigner: %s assigned %v, revoked %v", a.name, added, revoked)
+	if a.cb != nil {
+		a.cb(added, revoked)
+	}
+}
+
+// keeps the assignments in sync with the membership until the context
+// is done
+func (a *PartitionAssigner) run(ctx context.Context, trigger chan struct{}) {
+	for {
+		a.rebalance(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-trigger:
+		case <-time.After(partitionRebalanceInterval):
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+func waitAssignments(t *testing.T, a *PartitionAssigner, count int) []string {
+	for range 200 {
+		if list := a.Assignments(); len(list) == count {
+			return list
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d partitions to be assigned, got %v", count, a.Assignments())
+	return nil
+}
+
+func Test_PartitionAssigner(t *testing.T) {
+	s := initMemoryOwner(t)
+	ctx := context.Background()
+
+	partitions := []string{}
+	for i := range 16 {
+		partitions = append(partitions, fmt.Sprintf("p-%d", i))
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := NewPartitionAssigner(s, "", partitions, nil); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without name, got %v", err)
+		}
+		if _, err := NewPartitionAssigner(s, "demo-partitions", nil, nil); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without partitions, got %v", err)
+		}
+	})
+
+	t.Run("assign", func(t *testing.T) {
+		peers := []string{"a", "b", "c"}
+		total := 0
+		for _, m := range peers {
+			total += len(assignPartitions(partitions, peers, m))
+		}
+		if total != len(partitions) {
+			t.Errorf("expected every partition to be assigned once, got %d", total)
+		}
+		// only the partitions of the leaving member move
+		before := assignPartitions(partitions, peers, "a")
+		after := assignPartitions(partitions, []string{"a", "b"}, "a")
+		for _, p := range before {
+			if !slices.Contains(after, p) {
+				t.Errorf("partition %s moved away from a remaining member", p)
+			}
+		}
+	})
+
+	t.Run("rebalance", func(t *testing.T) {
+		var mu gosync.Mutex
+		var added, revoked int
+		a, err := NewPartitionAssigner(s, "demo-partitions", partitions, func(assigned []string, lost []string) {
+			mu.Lock()
+			defer mu.Unlock()
+			added += len(assigned)
+			revoked += len(lost)
+		})
+		if err != nil {
+			t.Fatalf("failed to create assigner: %s", err)
+		}
+		if err := a.Join(ctx); err != nil {
+			t.Fatalf("failed to join: %s", err)
+		}
+		if err := a.Join(ctx); !errors.IsAlreadyExists(err) {
+			t.Errorf("expected already exists joining twice, got %v", err)
+		}
+		// sole member owns all the partitions
+		waitAssignments(t, a, len(partitions))
+
+		// peer owner joining takes over its share
+		peer := &ownerKey{Name: "partition-peer"}
+		if err := ownerTable.col.InsertOne(ctx, peer, &ownerData{LastSeen: time.Now().Unix()}); err != nil {
+			t.Fatalf("failed to register peer owner: %s", err)
+		}
+		err = a.members.col.InsertOne(ctx, &partitionMemberKey{Group: "demo-partitions", Member: peer.Name}, &lockData{Owner: peer.Name})
+		if err != nil {
+			t.Fatalf("failed to join peer: %s", err)
+		}
+		self := ownerTable.key.Name
+		expected := assignPartitions(partitions, []string{peer.Name, self}, self)
+		if list := waitAssignments(t, a, len(expected)); !slices.Equal(list, expected) {
+			t.Errorf("expected assignments %v, got %v", expected, list)
+		}
+		if members, _ := a.Members(ctx); len(members) != 2 {
+			t.Errorf("expected 2 members, got %v", members)
+		}
+
+		// peer owner leaving hands back its share
+		_ = ownerTable.col.DeleteOne(ctx, peer)
+		waitAssignments(t, a, len(partitions))
+
+		if err := a.Leave(); err != nil {
+			t.Errorf("failed to leave: %s", err)
+		}
+		if list := a.Assignments(); len(list) != 0 {
+			t.Errorf("expected no assignments after leave, got %v", list)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if added != revoked || added == 0 {
+			t.Errorf("expected callbacks to balance out, got %d assigned, %d revoked", added, revoked)
+		}
+	})
+}
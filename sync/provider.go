@@ -7,11 +7,11 @@ import (
 	"context"
 	"log"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
 
 	"github.com/go-core-stack/core/db"
 	"github.com/go-core-stack/core/errors"
@@ -37,6 +37,15 @@ type providerKey struct {
 type Provider struct {
 	key *providerKey
 	tbl *ProviderTable
+
+	// health of the provider, see SetHealthy
+	mu   sync.Mutex
+	down bool
+
+	// heartbeats, if enabled
+	interval time.Duration
+	stop     chan struct{}
+	once     sync.Once
 }
 
 type listKeyEntry struct {
@@ -44,11 +53,60 @@ type listKeyEntry struct {
 }
 
 func (p *Provider) Close() error {
-	return p.tbl.col.DeleteOne(context.Background(), p.key)
+	if p.stop != nil {
+		p.once.Do(func() { close(p.stop) })
+	}
+	err := p.tbl.col.DeleteOne(context.Background(), p.key)
+	if err == nil {
+		audit(AuditProviderRemove, p.tbl.owner.key.Name, p.tbl.colName, p.key.ExtKey, "")
+	}
+	return err
 }
 
 type providerData struct {
-	Owner string `bson:"owner,omitempty"`
+	Owner     string     `bson:"owner,omitempty"`
+	Metadata  any        `bson:"metadata,omitempty"`
+	Unhealthy bool       `bson:"unhealthy,omitempty"`
+	ExpireAt  *time.Time `bson:"expireAt,omitempty"` // heartbeat deadline
+}
+
+// ProviderDetails provides the details of a live provider, including the
+// metadata attached by the provider while being created, eg. endpoint,
+// version or capacity, allowing the observers to reach the provider
+type ProviderDetails struct {
+	// owner hosting the provider
+	Owner string
+
+	// time at which the provider was created
+	CreateTime time.Time
+
+	// health of the provider, unhealthy providers are not considered
+	// available for the observers
+	Healthy bool
+
+	// raw metadata attached to the provider, nil if none
+	Metadata bson.Raw
+}
+
+// DecodeMetadata decodes the metadata attached to the provider into the
+// value pointed to by out
+// returns NotFound error if no metadata is attached to the provider
+func (d *ProviderDetails) DecodeMetadata(out any) error {
+	if d.Metadata == nil {
+		return errors.Wrapf(errors.NotFound, "no metadata attached to provider of owner %s", d.Owner)
+	}
+	if err := bson.Unmarshal(d.Metadata, out); err != nil {
+		return errors.Wrapf(errors.InvalidArgument, "failed to decode provider metadata: %s", err)
+	}
+	return nil
+}
+
+// provider entry as read from the provider table
+type providerEntry struct {
+	Key       *providerKey `bson:"_id,omitempty"`
+	Owner     string       `bson:"owner,omitempty"`
+	Metadata  bson.Raw     `bson:"metadata,omitempty"`
+	Unhealthy bool         `bson:"unhealthy,omitempty"`
 }
 
 type ProviderTable struct {
@@ -66,6 +124,9 @@ type ProviderTable struct {
 
 	// observer table
 	oTbl *observerTable
+
+	// owner the providers of the table are created by
+	owner *ownerTableType
 }
 
 // Provider Table callback function, currently meant for
@@ -76,18 +137,20 @@ func (t *ProviderTable) Callback(op string, wKey interface{}) {
 	key := wKey.(*providerKey)
 
 	// ensure updating the observer table based on availability
-	// or unavailability of provider
-	obKey := &observerCountKey{
-		ExtKey: key.ExtKey,
-	}
-	cnt, err := t.col.Count(context.Background(), obKey)
+	// or unavailability of provider, considering only the healthy ones
+	filter := append(db.KeyFilter(bson.E{Key: "extKey", Value: key.ExtKey}), bson.E{
+		Key:   "unhealthy",
+		Value: bson.D{{Key: "$ne", Value: true}},
+	})
+	cnt, err := t.col.Count(context.Background(), filter)
 	if err != nil {
 		log.Panicf("failed to fetch count of providers: %s", err)
 	}
+	stats.setProviders(t.colName, key.ExtKey, cnt)
 	if cnt == 0 {
-		t.oTbl.deleteProvider(obKey.ExtKey)
+		t.oTbl.deleteProvider(key.ExtKey)
 	} else {
-		t.oTbl.insertProvider(obKey.ExtKey)
+		t.oTbl.insertProvider(key.ExtKey)
 	}
 
 	entry := &providerData{}
@@ -98,6 +161,7 @@ func (t *ProviderTable) Callback(op string, wKey interface{}) {
 		}
 		log.Panicf("failed to find the entry while working with: %s", err)
 	}
+	t.watchHeartbeat(key, entry)
 
 	oKey := &ownerKey{
 		Name: entry.Owner,
@@ -105,7 +169,7 @@ func (t *ProviderTable) Callback(op string, wKey interface{}) {
 
 	oData := &ownerData{}
 
-	err = ownerTable.col.FindOne(context.Background(), oKey, oData)
+	err = t.owner.col.FindOne(context.Background(), oKey, oData)
 
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -138,10 +202,23 @@ func (t *ProviderTable) handleOwnerRelease(op string, wKey any) {
 	}
 }
 
+// releases all the providers created by the owner and stops working on
+// the table, as part of the shutdown of the owner
+func (t *ProviderTable) releaseOwner(ctx context.Context, owner string) error {
+	defer t.cancelFn()
+	filter := bson.D{{
+		Key:   "owner",
+		Value: owner,
+	}}
+	_, err := t.col.DeleteMany(ctx, filter)
+	return err
+}
+
 // Allow a reconciler controller to register and get notified for availability
-// and unavailability of providers
-func (t *ProviderTable) Register(name string, crtl reconciler.Controller) error {
-	return t.oTbl.Register(name, crtl)
+// and unavailability of providers, reconciler.WithKeyFilter restricts the
+// notifications to the provider keys the controller cares about
+func (t *ProviderTable) Register(name string, crtl reconciler.Controller, opts ...reconciler.RegisterOption) error {
+	return t.oTbl.Register(name, crtl, opts...)
 }
 
 // Get List of Providers
@@ -154,12 +231,41 @@ func (t *ProviderTable) IsProviderAvailable(key any) bool {
 	return t.oTbl.isProviderAvailable(key)
 }
 
+// Get details of all the live providers for the specified key, including
+// the metadata attached to them, returns empty list if no provider exists
+func (t *ProviderTable) GetProviderDetails(ctx context.Context, key any) ([]*ProviderDetails, error) {
+	filter := db.KeyFilter(bson.E{Key: "extKey", Value: key})
+	list := []providerEntry{}
+	err := t.col.FindMany(ctx, filter, &list)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	details := []*ProviderDetails{}
+	for _, entry := range list {
+		details = append(details, &ProviderDetails{
+			Owner:      entry.Owner,
+			CreateTime: time.Unix(entry.Key.CreateTime, 0),
+			Healthy:    !entry.Unhealthy,
+			Metadata:   entry.Metadata,
+		})
+	}
+	return details, nil
+}
+
 // create provider based on the specified key, typically a string,
+// attaching the metadata, if not nil, to be made available to the
+// observers using GetProviderDetails
 // Returns Provider handle, allowing to close the provider
-func (t *ProviderTable) CreateProvider(ctx context.Context, extKey any) (*Provider, error) {
-	// if ownertable is not initialized, then lock infra cannot be used
-	if ownerTable == nil || ownerTable.key == nil {
-		return nil, errors.Wrap(errors.InvalidArgument, "owner infra for provider table is not initialized")
+func (t *ProviderTable) CreateProvider(ctx context.Context, extKey any, metadata any, opts ...ProviderOption) (*Provider, error) {
+	popts, err := getProviderOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// if owner is shut down, then provider infra cannot be used
+	if err := t.owner.validate(); err != nil {
+		return nil, err
 	}
 
 	key := &providerKey{
@@ -169,18 +275,31 @@ func (t *ProviderTable) CreateProvider(ctx context.Context, extKey any) (*Provid
 	}
 
 	data := &providerData{
-		Owner: ownerTable.key.Name,
+		Owner:    t.owner.key.Name,
+		Metadata: metadata,
+	}
+	if popts.Heartbeat != 0 {
+		expireAt := time.Now().Add(providerHeartbeatMisses * popts.Heartbeat)
+		data.ExpireAt = &expireAt
 	}
 
-	err := t.col.InsertOne(ctx, key, data)
+	err = t.col.InsertOne(ctx, key, data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Provider{
-		key: key,
-		tbl: t,
-	}, nil
+	audit(AuditProviderAdd, data.Owner, t.colName, extKey, "")
+
+	p := &Provider{
+		key:      key,
+		tbl:      t,
+		interval: popts.Heartbeat,
+	}
+	if p.interval != 0 {
+		p.stop = make(chan struct{})
+		go p.heartbeat()
+	}
+	return p, nil
 }
 
 // Locate Provider table with pre-specified table name
@@ -201,7 +320,25 @@ func LocateProviderTableWithName(store db.Store, name string) (*ProviderTable, e
 		return nil, errors.Wrap(errors.InvalidArgument, "Mandatory! owner table infra not initialized")
 	}
 
-	ctx, cancelFn := context.WithCancel(ownerTable.ctx)
+	return locateProviderTable(store, name, ownerTable)
+}
+
+// Locate Provider table with specific table name, bound to the owner
+// instead of the package level owner, meant for consumers working with
+// their own owner
+func LocateProviderTableWithOwner(store db.Store, name string, owner *Owner) (*ProviderTable, error) {
+	if owner == nil {
+		return nil, errors.Wrap(errors.InvalidArgument, "owner is required for provider table")
+	}
+	return locateProviderTable(store, name, owner.t)
+}
+
+func locateProviderTable(store db.Store, name string, owner *ownerTableType) (*ProviderTable, error) {
+	if err := owner.validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFn := context.WithCancel(owner.ctx)
 
 	// no existing table found, allocate a new one
 	col := store.GetCollection(name)
@@ -213,6 +350,7 @@ func LocateProviderTableWithName(store db.Store, name string) (*ProviderTable, e
 		oTbl: &observerTable{
 			providers: make(map[any]struct{}),
 		},
+		owner: owner,
 	}
 
 	err := table.oTbl.Initialize(ctx, table.oTbl)
@@ -221,18 +359,10 @@ func LocateProviderTableWithName(store db.Store, name string) (*ProviderTable, e
 		return nil, err
 	}
 
-	matchDeleteStage := mongo.Pipeline{
-		bson.D{{
-			Key: "$match",
-			Value: bson.D{{
-				Key:   "operationType",
-				Value: "delete",
-			}},
-		}},
-	}
+	matchDeleteStage := db.WatchFilter().Ops(db.WatchOpDelete).Build()
 
 	// watch only for delete notification of lock owner
-	err = ownerTable.col.Watch(ctx, matchDeleteStage, table.handleOwnerRelease)
+	err = owner.col.Watch(ctx, matchDeleteStage, table.handleOwnerRelease)
 	if err != nil {
 		cancelFn()
 		return nil, err
@@ -252,6 +382,8 @@ func LocateProviderTableWithName(store db.Store, name string) (*ProviderTable, e
 		return nil, err
 	}
 
+	owner.track(table)
+
 	go func() {
 		list := []listKeyEntry{}
 
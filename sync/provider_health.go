@@ -0,0 +1,129 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// number of heartbeats missed before the provider is marked unhealthy
+	providerHeartbeatMisses = 3
+)
+
+// ProviderOptions provides the configuration of a provider
+type ProviderOptions struct {
+	// Heartbeat is the interval at which the provider refreshes its
+	// liveness, the provider is marked unhealthy once the heartbeats are
+	// missed for three intervals, even while its owner is alive
+	// Default: 0 (provider is live as long as its owner)
+	Heartbeat time.Duration
+}
+
+// ProviderOption is a functional option for configuring the provider
+type ProviderOption func(*ProviderOptions)
+
+// WithProviderHeartbeat enables the heartbeats of the provider at the
+// given interval
+func WithProviderHeartbeat(interval time.Duration) ProviderOption {
+	return func(opts *ProviderOptions) {
+		opts.Heartbeat = interval
+	}
+}
+
+func getProviderOptions(opts []ProviderOption) (*ProviderOptions, error) {
+	popts := &ProviderOptions{}
+	for _, opt := range opts {
+		opt(popts)
+	}
+	if popts.Heartbeat < 0 {
+		return nil, errors.Wrap(errors.InvalidArgument, "provider heartbeat interval must not be negative")
+	}
+	return popts, nil
+}
+
+// updates the health of the provider, refreshing the heartbeat deadline
+// if heartbeats are enabled
+func (p *Provider) update(ctx context.Context) error {
+	p.mu.Lock()
+	set := bson.D{{Key: "unhealthy", Value: p.down}}
+	p.mu.Unlock()
+	if p.interval != 0 {
+		set = append(set, bson.E{Key: "expireAt", Value: time.Now().Add(providerHeartbeatMisses * p.interval)})
+	}
+	update := bson.D{{Key: "$set", Value: set}}
+	err := p.tbl.col.FindOneAndUpdate(ctx, p.key, update, &providerData{})
+	if err != nil && errors.IsNotFound(err) {
+		return errors.Wrapf(errors.NotFound, "provider %v is closed", p.key.ExtKey)
+	}
+	return err
+}
+
+// SetHealthy marks the provider healthy or unhealthy, while unhealthy the
+// provider is not considered available and the observers are notified as
+// if the provider is gone, without closing the provider
+// returns NotFound error if the provider is closed
+func (p *Provider) SetHealthy(ctx context.Context, healthy bool) error {
+	p.mu.Lock()
+	p.down = !healthy
+	p.mu.Unlock()
+	return p.update(ctx)
+}
+
+// refreshes the liveness of the provider periodically until the provider
+// is closed or the provider table is stopped
+func (p *Provider) heartbeat() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-p.tbl.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := p.update(p.tbl.ctx); err != nil {
+			if errors.IsNotFound(err) {
+				return
+			}
+			log.Printf("provider-table: failed heartbeat of provider %v: %s", p.key.ExtKey, err)
+		}
+	}
+}
+
+// schedules marking the provider unhealthy once its heartbeat deadline
+// passes, every heartbeat reschedules it with the refreshed deadline
+func (t *ProviderTable) watchHeartbeat(key *providerKey, entry *providerData) {
+	if entry.ExpireAt == nil || entry.Unhealthy {
+		return
+	}
+	time.AfterFunc(time.Until(*entry.ExpireAt), func() {
+		if t.ctx.Err() != nil {
+			return
+		}
+		cur := &providerData{}
+		if err := t.col.FindOne(t.ctx, key, cur); err != nil {
+			return
+		}
+		if cur.Unhealthy || cur.ExpireAt == nil || cur.ExpireAt.After(time.Now()) {
+			// already marked or heartbeat refreshed in between
+			return
+		}
+		update := bson.D{{Key: "$set", Value: bson.D{{Key: "unhealthy", Value: true}}}}
+		if err := t.col.FindOneAndUpdate(t.ctx, key, update, &providerData{}); err != nil {
+			if !errors.IsNotFound(err) {
+				log.Printf("provider-table: failed to mark provider %v unhealthy: %s", key.ExtKey, err)
+			}
+			return
+		}
+		log.Printf("provider-table: provider %v missed heartbeats, marked unhealthy", key.ExtKey)
+	})
+}
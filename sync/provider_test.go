@@ -40,16 +40,16 @@ func Test_ProviderBaseTesting(t *testing.T) {
 		t.Errorf("failed to locate provider Table: %s", err)
 	}
 
-	provider, err := tbl.CreateProvider(context.Background(), "test-key")
+	provider, err := tbl.CreateProvider(context.Background(), "test-key", nil)
 	if err != nil {
 		t.Errorf("failed to create Provider: %s", err)
 	}
-	providerDup, err := tbl.CreateProvider(context.Background(), "test-key")
+	providerDup, err := tbl.CreateProvider(context.Background(), "test-key", nil)
 	if err != nil {
 		t.Errorf("failed to create Provider: %s", err)
 	}
 
-	provider1, err := tbl.CreateProvider(context.Background(), "test-key-1")
+	provider1, err := tbl.CreateProvider(context.Background(), "test-key-1", nil)
 	if err != nil {
 		t.Errorf("failed to create provider: %s", err)
 	}
@@ -59,3 +59,139 @@ func Test_ProviderBaseTesting(t *testing.T) {
 
 	time.Sleep(2 * time.Second)
 }
+
+type testProviderMetadata struct {
+	Endpoint string `bson:"endpoint"`
+	Capacity int    `bson:"capacity"`
+}
+
+func Test_ProviderDetails(t *testing.T) {
+	s := initMemoryOwner(t)
+	tbl, err := LocateProviderTableWithName(s, "provider-details-test")
+	if err != nil {
+		t.Fatalf("failed to locate provider Table: %s", err)
+	}
+	ctx := context.Background()
+
+	provider, err := tbl.CreateProvider(ctx, "svc", &testProviderMetadata{Endpoint: "10.0.0.1:8080", Capacity: 4})
+	if err != nil {
+		t.Fatalf("failed to create provider: %s", err)
+	}
+	plain, err := tbl.CreateProvider(ctx, "svc", nil)
+	if err != nil {
+		t.Fatalf("failed to create provider: %s", err)
+	}
+
+	t.Run("metadata", func(t *testing.T) {
+		details, err := tbl.GetProviderDetails(ctx, "svc")
+		if err != nil {
+			t.Fatalf("failed to get provider details: %s", err)
+		}
+		if len(details) != 2 {
+			t.Fatalf("expected 2 providers, got %d", len(details))
+		}
+		found := 0
+		for _, d := range details {
+			if d.Owner != ownerTable.key.Name {
+				t.Errorf("expected provider owner %s, got %s", ownerTable.key.Name, d.Owner)
+			}
+			meta := &testProviderMetadata{}
+			err := d.DecodeMetadata(meta)
+			if errors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				t.Fatalf("failed to decode metadata: %s", err)
+			}
+			if meta.Endpoint != "10.0.0.1:8080" || meta.Capacity != 4 {
+				t.Errorf("unexpected provider metadata %+v", meta)
+			}
+			found++
+		}
+		if found != 1 {
+			t.Errorf("expected one provider with metadata, got %d", found)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		_ = provider.Close()
+		_ = plain.Close()
+		details, err := tbl.GetProviderDetails(ctx, "svc")
+		if err != nil {
+			t.Fatalf("failed to get provider details: %s", err)
+		}
+		if len(details) != 0 {
+			t.Errorf("expected no providers after close, got %d", len(details))
+		}
+	})
+}
+
+func waitProviderAvailable(t *testing.T, tbl *ProviderTable, key string, available bool) {
+	for range 200 {
+		if tbl.IsProviderAvailable(key) == available {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected provider %s availability to be %v", key, available)
+}
+
+func Test_ProviderHealth(t *testing.T) {
+	s := initMemoryOwner(t)
+	tbl, err := LocateProviderTableWithName(s, "provider-health-test")
+	if err != nil {
+		t.Fatalf("failed to locate provider Table: %s", err)
+	}
+	ctx := context.Background()
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := tbl.CreateProvider(ctx, "svc", nil, WithProviderHeartbeat(-1)); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for negative heartbeat, got %v", err)
+		}
+	})
+
+	t.Run("status", func(t *testing.T) {
+		p, err := tbl.CreateProvider(ctx, "svc-status", nil)
+		if err != nil {
+			t.Fatalf("failed to create provider: %s", err)
+		}
+		waitProviderAvailable(t, tbl, "svc-status", true)
+
+		if err := p.SetHealthy(ctx, false); err != nil {
+			t.Fatalf("failed to mark provider unhealthy: %s", err)
+		}
+		waitProviderAvailable(t, tbl, "svc-status", false)
+		details, _ := tbl.GetProviderDetails(ctx, "svc-status")
+		if len(details) != 1 || details[0].Healthy {
+			t.Errorf("expected unhealthy provider in details, got %+v", details)
+		}
+
+		if err := p.SetHealthy(ctx, true); err != nil {
+			t.Fatalf("failed to mark provider healthy: %s", err)
+		}
+		waitProviderAvailable(t, tbl, "svc-status", true)
+
+		_ = p.Close()
+		if err := p.SetHealthy(ctx, true); !errors.IsNotFound(err) {
+			t.Errorf("expected not found for closed provider, got %v", err)
+		}
+	})
+
+	t.Run("heartbeat", func(t *testing.T) {
+		p, err := tbl.CreateProvider(ctx, "svc-heartbeat", nil, WithProviderHeartbeat(20*time.Millisecond))
+		if err != nil {
+			t.Fatalf("failed to create provider: %s", err)
+		}
+		waitProviderAvailable(t, tbl, "svc-heartbeat", true)
+		// kept healthy by the heartbeats
+		time.Sleep(150 * time.Millisecond)
+		if !tbl.IsProviderAvailable("svc-heartbeat") {
+			t.Errorf("expected provider to be kept available by heartbeats")
+		}
+
+		// provider wedged, while the owner is alive
+		p.once.Do(func() { close(p.stop) })
+		waitProviderAvailable(t, tbl, "svc-heartbeat", false)
+		_ = p.Close()
+	})
+}
@@ -0,0 +1,188 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// suffix of the lock table holding the readers of a read-write lock
+	// table
+	rwLockReadersSuffix = "-readers"
+)
+
+// key of a reader holding the read lock, every reader holds its own
+// entry in the readers lock table
+type rwReaderKey[K any] struct {
+	Key   K      `bson:"key"`
+	Lease string `bson:"lease"`
+}
+
+// RWLockTable provides the read-write locks, allowing multiple concurrent
+// readers or a single exclusive writer for a key. Writers hold the lock
+// in the writers lock table, while every reader holds its own entry in
+// the readers lock table, both benefiting from the owner based cleanup of
+// the lock tables. Writers are preferred, a writer waiting for the
+// readers to drain blocks the new readers.
+type RWLockTable[K any] struct {
+	writers *LockTable[K]
+	readers *LockTable[rwReaderKey[K]]
+}
+
+// LocateRWLockTable returns the read-write lock table hosted in the
+// collections with the given name, options apply to both the readers and
+// writers locks
+func LocateRWLockTable[K any](store db.Store, name string, opts ...LockTableOption) (*RWLockTable[K], error) {
+	writers, err := LocateLockTable[K](store, name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	readers, err := LocateLockTable[rwReaderKey[K]](store, name+rwLockReadersSuffix, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &RWLockTable[K]{
+		writers: writers,
+		readers: readers,
+	}, nil
+}
+
+// returns true if a writer holds the lock for the key
+func (t *RWLockTable[K]) writerHeld(ctx context.Context, key *K) (bool, error) {
+	data := &lockData{}
+	err := t.writers.col.FindOne(ctx, key, data)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if data.ExpireAt != nil && data.ExpireAt.Before(time.Now()) {
+		return !t.writers.releaseExpired(ctx, key), nil
+	}
+	return true, nil
+}
+
+// returns true if any reader holds the lock for the key
+func (t *RWLockTable[K]) readersHeld(ctx context.Context, key *K) (bool, error) {
+	filter := bson.D{{Key: "_id.key", Value: key}}
+	if t.readers.opts.TTL != 0 {
+		// expired readers don't hold the lock anymore
+		_, err := t.readers.col.DeleteMany(ctx, append(filter, bson.E{
+			Key:   "expireAt",
+			Value: bson.D{{Key: "$lt", Value: time.Now()}},
+		}))
+		if err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+	}
+	count, err := t.readers.col.Count(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+	return count != 0, nil
+}
+
+// TryRLock acquires the read lock for the key, sharing it with the other
+// readers
+// returns AlreadyExists error if a writer holds or is waiting for the lock
+func (t *RWLockTable[K]) TryRLock(ctx context.Context, key *K) (Lock, error) {
+	held, err := t.writerHeld(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if held {
+		return nil, errors.Wrapf(errors.AlreadyExists, "lock %v is held by a writer", key)
+	}
+	lock, err := t.readers.TryAcquire(ctx, &rwReaderKey[K]{Key: *key, Lease: uuid.New().String()})
+	if err != nil {
+		return nil, err
+	}
+	// writer might have acquired the lock in between, where the writer
+	// would be waiting for the readers to drain
+	held, err = t.writerHeld(ctx, key)
+	if err != nil || held {
+		_ = lock.Close()
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.Wrapf(errors.AlreadyExists, "lock %v is held by a writer", key)
+	}
+	return lock, nil
+}
+
+// RLock acquires the read lock for the key, blocking until no writer
+// holds the lock or the context is done
+// returns Unavailable error if the context is done before acquiring
+func (t *RWLockTable[K]) RLock(ctx context.Context, key *K) (Lock, error) {
+	for {
+		released := t.writers.releaseNotify()
+		lock, err := t.TryRLock(ctx, key)
+		if err == nil || !errors.IsAlreadyExists(err) {
+			return lock, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(errors.Unavailable, "failed to acquire read lock %v: %s", key, ctx.Err())
+		case <-released:
+		case <-time.After(t.writers.acquireRetryWait(ctx, key)):
+		}
+	}
+}
+
+// TryLock acquires the exclusive write lock for the key
+// returns AlreadyExists error if the lock is held by a writer or readers
+func (t *RWLockTable[K]) TryLock(ctx context.Context, key *K) (Lock, error) {
+	lock, err := t.writers.TryAcquire(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	held, err := t.readersHeld(ctx, key)
+	if err != nil || held {
+		_ = lock.Close()
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.Wrapf(errors.AlreadyExists, "lock %v is held by readers", key)
+	}
+	return lock, nil
+}
+
+// Lock acquires the exclusive write lock for the key, blocking until the
+// other writer and all the readers release the lock or the context is
+// done. The new readers are blocked while waiting for the readers to
+// drain
+// returns Unavailable error if the context is done before acquiring
+func (t *RWLockTable[K]) Lock(ctx context.Context, key *K) (Lock, error) {
+	lock, err := t.writers.Acquire(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		released := t.readers.releaseNotify()
+		held, err := t.readersHeld(ctx, key)
+		if err != nil {
+			_ = lock.Close()
+			return nil, err
+		}
+		if !held {
+			return lock, nil
+		}
+		select {
+		case <-ctx.Done():
+			_ = lock.Close()
+			return nil, errors.Wrapf(errors.Unavailable, "failed to acquire write lock %v: %s", key, ctx.Err())
+		case <-released:
+		case <-time.After(lockAcquireRetryInterval):
+		}
+	}
+}
@@ -0,0 +1,374 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// lock table serializing the firings of the scheduled jobs
+	schedulerLockCollection = "scheduler-locks"
+
+	// collection hosting the state of the scheduled jobs
+	schedulerJobCollection = "scheduler-jobs"
+
+	// interval at which the job state is refreshed while waiting for the
+	// next firing, following the firings executed by the other replicas
+	schedulerSyncInterval = time.Minute
+
+	// delay after which a firing is considered missed, eg. while no
+	// replica was running, for the catch-up policy to apply
+	schedulerMissedGrace = time.Minute
+
+	// bound on the number of missed firings executed with CatchUpAll
+	schedulerMaxCatchUp = 100
+)
+
+// CatchUpPolicy decides the handling of the firings missed, eg. while
+// all the replicas were down
+type CatchUpPolicy int
+
+const (
+	// executes the latest of the missed firings once, skipping the rest
+	CatchUpLatest CatchUpPolicy = iota
+
+	// skips all the missed firings, waiting for the next one
+	CatchUpNone
+
+	// executes every missed firing in order, bounded to the latest 100
+	CatchUpAll
+)
+
+// JobHandler is invoked to execute a firing of a scheduled job, with the
+// time the firing was scheduled at
+type JobHandler func(ctx context.Context, fireTime time.Time) error
+
+// JobOptions provides the configuration of a scheduled job
+type JobOptions struct {
+	// handling of the missed firings, CatchUpLatest by default
+	CatchUp CatchUpPolicy
+}
+
+// JobOption is a functional option for configuring the scheduled job
+type JobOption func(*JobOptions)
+
+// WithJobCatchUp sets the handling of the missed firings of the job
+func WithJobCatchUp(policy CatchUpPolicy) JobOption {
+	return func(opts *JobOptions) {
+		opts.CatchUp = policy
+	}
+}
+
+func getJobOptions(opts []JobOption) (*JobOptions, error) {
+	jopts := &JobOptions{}
+	for _, opt := range opts {
+		opt(jopts)
+	}
+	if jopts.CatchUp < CatchUpLatest || jopts.CatchUp > CatchUpAll {
+		return nil, errors.Wrapf(errors.InvalidArgument, "invalid catch-up policy %d", jopts.CatchUp)
+	}
+	return jopts, nil
+}
+
+// key of a scheduled job, for both the lock and the state
+type schedulerJobKey struct {
+	Scheduler string `bson:"scheduler"`
+	Job       string `bson:"job"`
+}
+
+// state of a scheduled job shared across the replicas
+type schedulerJobData struct {
+	// scheduled time of the last firing executed or skipped
+	LastRun time.Time `bson:"lastRun"`
+
+	// owner that executed the last firing
+	Runner string `bson:"runner"`
+
+	// error returned by the handler for the last firing
+	LastError string `bson:"lastError"`
+}
+
+// JobStatus provides the status of a scheduled job
+type JobStatus struct {
+	// scheduled time of the last firing executed or skipped
+	LastRun time.Time
+
+	// owner that executed the last firing, empty if none executed yet
+	Runner string
+
+	// error returned by the handler for the last firing, if any
+	LastError string
+
+	// scheduled time of the next firing, zero if the job never fires
+	NextRun time.Time
+}
+
+type schedulerJob struct {
+	name     string
+	schedule *cronSchedule
+	handler  JobHandler
+	opts     *JobOptions
+}
+
+// Scheduler runs the jobs on their cron schedules across the replicas,
+// where every replica registers all the jobs, while each firing is
+// executed by exactly one of the replicas, holding the lock of the job
+// while executing it. A firing interrupted by the executing replica
+// failing is executed again by another replica, once the failed replica
+// ages out of the owner table, so the handlers are expected to be
+// idempotent. Schedules are evaluated in UTC
+type Scheduler struct {
+	name  string
+	locks *LockTable[schedulerJobKey]
+	col   db.StoreCollection
+
+	mu   sync.Mutex
+	jobs map[string]*schedulerJob
+	ctx  context.Context // set once started
+}
+
+// NewScheduler creates the scheduler with the given name, hosted in the
+// store, requires the owner infra to be initialized
+func NewScheduler(store db.Store, name string) (*Scheduler, error) {
+	if name == "" {
+		return nil, errors.Wrap(errors.InvalidArgument, "scheduler name is required")
+	}
+	locks, err := LocateLockTable[schedulerJobKey](store, schedulerLockCollection)
+	if err != nil {
+		return nil, err
+	}
+	col := store.GetCollection(schedulerJobCollection)
+	if err := col.SetKeyType(reflect.TypeOf(&schedulerJobKey{})); err != nil {
+		return nil, err
+	}
+	return &Scheduler{
+		name:  name,
+		locks: locks,
+		col:   col,
+		jobs:  map[string]*schedulerJob{},
+	}, nil
+}
+
+// Register adds the job with the given name, firing on the cron spec,
+// either the standard five fields, eg. "*/15 * * * *", or one of the
+// descriptors like @hourly, @daily or "@every 10m". The jobs registered
+// after the scheduler is started start running right away
+// returns AlreadyExists error if the job is already registered
+func (s *Scheduler) Register(name string, spec string, handler JobHandler, opts ...JobOption) error {
+	if name == "" || handler == nil {
+		return errors.Wrap(errors.InvalidArgument, "job name and handler are required")
+	}
+	schedule, err := parseCron(spec)
+	if err != nil {
+		return err
+	}
+	jopts, err := getJobOptions(opts)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return errors.Wrapf(errors.AlreadyExists, "job %s already registered with scheduler %s", name, s.name)
+	}
+	job := &schedulerJob{
+		name:     name,
+		schedule: schedule,
+		handler:  handler,
+		opts:     jopts,
+	}
+	s.jobs[name] = job
+	if s.ctx != nil {
+		go s.run(s.ctx, job)
+	}
+	return nil
+}
+
+// Start starts running the registered jobs until the context is done
+// returns AlreadyExists error if the scheduler is already started
+func (s *Scheduler) Start(ctx context.Context) error {
+	if err := s.locks.owner.validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx != nil {
+		return errors.Wrapf(errors.AlreadyExists, "scheduler %s already started", s.name)
+	}
+	s.ctx = ctx
+	for _, job := range s.jobs {
+		go s.run(ctx, job)
+	}
+	return nil
+}
+
+// Status returns the status of the job with the given name
+// returns NotFound error if the job is not registered
+func (s *Scheduler) Status(ctx context.Context, name string) (*JobStatus, error) {
+	s.mu.Lock()
+	job, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, errors.Wrapf(errors.NotFound, "job %s not registered with scheduler %s", name, s.name)
+	}
+	state, err := s.state(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	return &JobStatus{
+		LastRun:   state.LastRun,
+		Runner:    state.Runner,
+		LastError: state.LastError,
+		NextRun:   job.schedule.next(state.LastRun),
+	}, nil
+}
+
+// returns the state of the job, initializing it on first use, so that
+// the job fires only from the time it is first registered
+func (s *Scheduler) state(ctx context.Context, job *schedulerJob) (*schedulerJobData, error) {
+	key := &schedulerJobKey{Scheduler: s.name, Job: job.name}
+	state := &schedulerJobData{}
+	err := s.col.FindOne(ctx, key, state)
+	if err == nil || !errors.IsNotFound(err) {
+		return state, err
+	}
+	state.LastRun = time.Now().UTC().Truncate(time.Millisecond)
+	err = s.col.InsertOne(ctx, key, state)
+	if err != nil && errors.IsAlreadyExists(err) {
+		// initialized by another replica in between
+		err = s.col.FindOne(ctx, key, state)
+	}
+	return state, err
+}
+
+// returns the firings of the job due after the last run until now, the
+// latest ones up to the bound, along with the total count of the due
+// firings
+func (j *schedulerJob) due(lastRun time.Time, now time.Time) ([]time.Time, int) {
+	skipped := 0
+	if j.schedule.every != 0 && now.Sub(lastRun) > schedulerMaxCatchUp*j.schedule.every {
+		// fast forward over the firings beyond the bound
+		skipped = int(now.Sub(lastRun)/j.schedule.every) - schedulerMaxCatchUp
+		lastRun = lastRun.Add(time.Duration(skipped) * j.schedule.every)
+	}
+	firings := []time.Time{}
+	total := skipped
+	for t := j.schedule.next(lastRun); !t.IsZero() && !t.After(now); t = j.schedule.next(t) {
+		total++
+		firings = append(firings, t)
+		if len(firings) > schedulerMaxCatchUp {
+			firings = firings[1:]
+		}
+	}
+	return firings, total
+}
+
+// executes the due firings of the job holding its lock, according to the
+// catch-up policy, returns false if the lock is held by another replica
+func (s *Scheduler) fire(ctx context.Context, job *schedulerJob) (bool, error) {
+	key := &schedulerJobKey{Scheduler: s.name, Job: job.name}
+	lock, err := s.locks.TryAcquire(ctx, key)
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer func() { _ = lock.Close() }()
+
+	// state is read again holding the lock, as the firings might have
+	// been executed by another replica meanwhile
+	state, err := s.state(ctx, job)
+	if err != nil {
+		return true, err
+	}
+	now := time.Now()
+	firings, total := job.due(state.LastRun, now)
+	if total == 0 {
+		return true, nil
+	}
+	runs := firings
+	switch job.opts.CatchUp {
+	case CatchUpLatest:
+		runs = firings[len(firings)-1:]
+	case CatchUpNone:
+		runs = []time.Time{}
+		for _, f := range firings {
+			if now.Sub(f) <= schedulerMissedGrace {
+				runs = append(runs, f)
+			}
+		}
+	}
+	if skipped := total - len(runs); skipped > 0 {
+		log.Printf("scheduler: %s skipping %d missed firings of job %s", s.name, skipped, job.name)
+	}
+
+	self := s.locks.owner.key.Name
+	for _, f := range runs {
+		state = &schedulerJobData{LastRun: f, Runner: self}
+		if herr := job.handler(ctx, f); herr != nil {
+			log.Printf("scheduler: %s job %s failed for firing at %s: %s", s.name, job.name, f, herr)
+			state.LastError = herr.Error()
+		}
+		if err := s.col.UpdateOne(context.Background(), key, state, false); err != nil {
+			return true, err
+		}
+		if ctx.Err() != nil {
+			return true, ctx.Err()
+		}
+	}
+	if last := firings[len(firings)-1]; !state.LastRun.Equal(last) {
+		// the missed firings skipped after the last execution
+		state.LastRun = last
+		if err := s.col.UpdateOne(context.Background(), key, state, false); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// runs the job on its schedule until the context is done
+func (s *Scheduler) run(ctx context.Context, job *schedulerJob) {
+	for {
+		var released <-chan struct{}
+		wait := schedulerSyncInterval
+		state, err := s.state(ctx, job)
+		if err == nil {
+			next := job.schedule.next(state.LastRun)
+			switch d := time.Until(next); {
+			case next.IsZero():
+			case d > 0:
+				wait = min(d, schedulerSyncInterval)
+			default:
+				// subscribe before trying, to not miss a release in
+				// between by the replica executing the firing
+				released = s.locks.releaseNotify()
+				var fired bool
+				fired, err = s.fire(ctx, job)
+				if fired && err == nil {
+					continue
+				}
+				wait = lockAcquireRetryInterval
+			}
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("scheduler: %s failed running job %s: %s", s.name, job.name, err)
+			wait = lockAcquireRetryInterval
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-released:
+		case <-time.After(wait):
+		}
+	}
+}
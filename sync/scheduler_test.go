@@ -0,0 +1,208 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+func Test_CronSchedule(t *testing.T) {
+	base := time.Date(2026, time.March, 13, 10, 7, 30, 0, time.UTC) // Friday
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.March, 13, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.March, 13, 10, 15, 0, 0, time.UTC)},
+		{"5 9-17 * * *", time.Date(2026, time.March, 13, 11, 5, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, time.March, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", base.Add(90 * time.Second)},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			s, err := parseCron(test.spec)
+			if err != nil {
+				t.Fatalf("failed to parse: %s", err)
+			}
+			if next := s.next(base); !next.Equal(test.expected) {
+				t.Errorf("expected next firing %s, got %s", test.expected, next)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "@every -1s", "@sometimes"} {
+			if _, err := parseCron(spec); !errors.IsInvalidArgument(err) {
+				t.Errorf("expected invalid argument for %q, got %v", spec, err)
+			}
+		}
+	})
+}
+
+func Test_SchedulerDueFirings(t *testing.T) {
+	s, err := parseCron("@every 1m")
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	job := &schedulerJob{name: "due", schedule: s}
+	now := time.Date(2026, time.March, 13, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		lastRun time.Time
+		total   int
+		runs    int
+	}{
+		{"none", now.Add(-30 * time.Second), 0, 0},
+		{"within_bound", now.Add(-10*time.Minute - 30*time.Second), 10, 10},
+		{"at_bound", now.Add(-100 * time.Minute), 100, 100},
+		{"beyond_bound", now.Add(-150 * time.Minute), 150, 100},
+		{"beyond_bound_partial_interval", now.Add(-150*time.Minute - 30*time.Second), 150, 100},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			firings, total := job.due(test.lastRun, now)
+			if total != test.total || len(firings) != test.runs {
+				t.Fatalf("expected %d firings with %d runs, got %d with %d runs", test.total, test.runs, total, len(firings))
+			}
+			if test.runs != 0 {
+				// the latest firings are retained
+				last := test.lastRun.Add(time.Duration(test.total) * time.Minute)
+				if !firings[len(firings)-1].Equal(last) {
+					t.Errorf("expected last firing at %s, got %s", last, firings[len(firings)-1])
+				}
+			}
+		})
+	}
+}
+
+func Test_Scheduler(t *testing.T) {
+	s := initMemoryOwner(t)
+	ctx := context.Background()
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := NewScheduler(s, ""); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument without name, got %v", err)
+		}
+		sched, err := NewScheduler(s, "demo-scheduler-invalid")
+		if err != nil {
+			t.Fatalf("failed to create scheduler: %s", err)
+		}
+		handler := func(ctx context.Context, fireTime time.Time) error { return nil }
+		if err := sched.Register("job", "bad spec", handler); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for bad spec, got %v", err)
+		}
+		if err := sched.Register("job", "@hourly", handler); err != nil {
+			t.Fatalf("failed to register job: %s", err)
+		}
+		if err := sched.Register("job", "@hourly", handler); !errors.IsAlreadyExists(err) {
+			t.Errorf("expected already exists registering twice, got %v", err)
+		}
+		if _, err := sched.Status(ctx, "unknown"); !errors.IsNotFound(err) {
+			t.Errorf("expected not found for unknown job, got %v", err)
+		}
+	})
+
+	t.Run("exactly once", func(t *testing.T) {
+		var mu gosync.Mutex
+		fired := map[time.Time]int{}
+		handler := func(ctx context.Context, fireTime time.Time) error {
+			mu.Lock()
+			defer mu.Unlock()
+			fired[fireTime]++
+			return nil
+		}
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		// replicas registering the same job
+		for range 3 {
+			sched, err := NewScheduler(s, "demo-scheduler-once")
+			if err != nil {
+				t.Fatalf("failed to create scheduler: %s", err)
+			}
+			if err := sched.Register("tick", "@every 100ms", handler); err != nil {
+				t.Fatalf("failed to register job: %s", err)
+			}
+			if err := sched.Start(runCtx); err != nil {
+				t.Fatalf("failed to start scheduler: %s", err)
+			}
+		}
+		time.Sleep(550 * time.Millisecond)
+		cancel()
+		time.Sleep(50 * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(fired) < 3 {
+			t.Errorf("expected the job to fire periodically, got %d firings", len(fired))
+		}
+		for f, count := range fired {
+			if count != 1 {
+				t.Errorf("expected firing at %s to be executed once, got %d", f, count)
+			}
+		}
+	})
+
+	t.Run("catch up", func(t *testing.T) {
+		policies := []struct {
+			policy   CatchUpPolicy
+			expected int
+		}{
+			{CatchUpLatest, 1},
+			{CatchUpNone, 0},
+			{CatchUpAll, 10},
+		}
+		for _, p := range policies {
+			sched, err := NewScheduler(s, fmt.Sprintf("demo-scheduler-catchup-%d", p.policy))
+			if err != nil {
+				t.Fatalf("failed to create scheduler: %s", err)
+			}
+			count := 0
+			handler := func(ctx context.Context, fireTime time.Time) error {
+				count++
+				return errors.Wrap(errors.Unknown, "failed")
+			}
+			if err := sched.Register("hourly", "@every 1h", handler, WithJobCatchUp(p.policy)); err != nil {
+				t.Fatalf("failed to register job: %s", err)
+			}
+			// firings missed while no replica was running
+			lastRun := time.Now().Add(-10*time.Hour - time.Minute*30).UTC().Truncate(time.Millisecond)
+			key := &schedulerJobKey{Scheduler: sched.name, Job: "hourly"}
+			if err := sched.col.InsertOne(ctx, key, &schedulerJobData{LastRun: lastRun}); err != nil {
+				t.Fatalf("failed to set job state: %s", err)
+			}
+			job := sched.jobs["hourly"]
+			if fired, err := sched.fire(ctx, job); !fired || err != nil {
+				t.Fatalf("failed to fire job: %v, %v", fired, err)
+			}
+			if count != p.expected {
+				t.Errorf("expected %d executions for policy %d, got %d", p.expected, p.policy, count)
+			}
+			status, err := sched.Status(ctx, "hourly")
+			if err != nil {
+				t.Fatalf("failed to get status: %s", err)
+			}
+			if expected := lastRun.Add(10 * time.Hour); !status.LastRun.Equal(expected) {
+				t.Errorf("expected last run %s, got %s", expected, status.LastRun)
+			}
+			if p.expected != 0 && status.LastError == "" {
+				t.Errorf("expected the handler error to be recorded")
+			}
+			if !status.NextRun.After(time.Now()) {
+				t.Errorf("expected next run in future, got %s", status.NextRun)
+			}
+		}
+	})
+}
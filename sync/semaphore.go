@@ -0,0 +1,106 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// lock table hosting the slots of the semaphores
+	semaphoreCollection = "semaphores"
+)
+
+// key for a slot of the semaphore, each slot is held as a lock
+type semaphoreKey struct {
+	Name string `bson:"name"`
+	Slot int    `bson:"slot"`
+}
+
+// Semaphore allows up to n concurrent holders across the cluster, where
+// each holder holds one of the n slots as a lock, released on Close or
+// when the holder ages out of the owner table like the other locks.
+// All the processes are expected to use the same n for a semaphore
+type Semaphore struct {
+	name  string
+	size  int
+	locks *LockTable[semaphoreKey]
+}
+
+// NewSemaphore creates the semaphore with the given name allowing up to
+// n concurrent holders, requires the owner infra to be initialized
+func NewSemaphore(store db.Store, name string, n int) (*Semaphore, error) {
+	if name == "" {
+		return nil, errors.Wrap(errors.InvalidArgument, "semaphore name is required")
+	}
+	if n <= 0 {
+		return nil, errors.Wrapf(errors.InvalidArgument, "semaphore %s size must be positive, got %d", name, n)
+	}
+	locks, err := LocateLockTable[semaphoreKey](store, semaphoreCollection)
+	if err != nil {
+		return nil, err
+	}
+	return &Semaphore{
+		name:  name,
+		size:  n,
+		locks: locks,
+	}, nil
+}
+
+// TryAcquire acquires one of the free slots of the semaphore
+// returns AlreadyExists error if all the slots are held
+func (s *Semaphore) TryAcquire(ctx context.Context) (Lock, error) {
+	// start with a random slot, spreading the contention across slots
+	start := rand.IntN(s.size)
+	for i := range s.size {
+		key := &semaphoreKey{Name: s.name, Slot: (start + i) % s.size}
+		lock, err := s.locks.TryAcquire(ctx, key)
+		if err == nil {
+			return lock, nil
+		}
+		if !errors.IsAlreadyExists(err) {
+			return nil, err
+		}
+	}
+	return nil, errors.Wrapf(errors.AlreadyExists, "all %d slots of semaphore %s are held", s.size, s.name)
+}
+
+// Acquire acquires one of the slots of the semaphore, blocking until a
+// slot is released or the context is done
+// returns Unavailable error if the context is done before acquiring
+func (s *Semaphore) Acquire(ctx context.Context) (Lock, error) {
+	for {
+		released := s.locks.releaseNotify()
+		lock, err := s.TryAcquire(ctx)
+		if err == nil || !errors.IsAlreadyExists(err) {
+			return lock, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(errors.Unavailable, "failed to acquire semaphore %s: %s", s.name, ctx.Err())
+		case <-released:
+		case <-time.After(lockAcquireRetryInterval):
+		}
+	}
+}
+
+// Holders returns the number of slots currently held
+func (s *Semaphore) Holders(ctx context.Context) (int, error) {
+	filter := bson.D{
+		{Key: "_id.name", Value: s.name},
+		{Key: "_id.slot", Value: bson.D{{Key: "$lt", Value: s.size}}},
+	}
+	count, err := s.locks.col.Count(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
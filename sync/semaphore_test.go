@@ -0,0 +1,68 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+func Test_Semaphore(t *testing.T) {
+	s := initMemoryOwner(t)
+	ctx := context.Background()
+
+	if _, err := NewSemaphore(s, "demo-semaphore", 0); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for zero size, got %v", err)
+	}
+	sem, err := NewSemaphore(s, "demo-semaphore", 2)
+	if err != nil {
+		t.Fatalf("failed to create semaphore: %s", err)
+	}
+
+	l1, err := sem.TryAcquire(ctx)
+	if err != nil {
+		t.Fatalf("failed to acquire first slot: %s", err)
+	}
+	l2, err := sem.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("failed to acquire second slot: %s", err)
+	}
+	if _, err := sem.TryAcquire(ctx); !errors.IsAlreadyExists(err) {
+		t.Errorf("expected all slots to be held, got %v", err)
+	}
+	if n, err := sem.Holders(ctx); err != nil || n != 2 {
+		t.Errorf("expected 2 holders, got %d, %v", n, err)
+	}
+
+	// other semaphores are independent
+	other, _ := NewSemaphore(s, "demo-semaphore-other", 1)
+	l3, err := other.TryAcquire(ctx)
+	if err != nil {
+		t.Fatalf("failed to acquire other semaphore: %s", err)
+	}
+	_ = l3.Close()
+
+	cctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := sem.Acquire(cctx); !errors.IsUnavailable(err) {
+		t.Errorf("expected acquire to time out, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = l1.Close()
+	}()
+	l4, err := sem.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("failed to acquire released slot: %s", err)
+	}
+	_ = l4.Close()
+	_ = l2.Close()
+	if n, err := sem.Holders(ctx); err != nil || n != 0 {
+		t.Errorf("expected no holders, got %d, %v", n, err)
+	}
+}
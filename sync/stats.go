@@ -0,0 +1,134 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LockStats provides the statistics of a lock table, for the locks
+// acquired by the process
+type LockStats struct {
+	// number of locks acquired
+	Acquired uint64
+
+	// number of attempts failing to acquire, as the lock is held by
+	// someone else, ie. the contention on the locks
+	Contended uint64
+
+	// number of locks released
+	Released uint64
+
+	// number of locks currently held
+	Held int64
+
+	// total duration the released locks were held for
+	HoldTime time.Duration
+}
+
+// Stats provides the statistics of the sync constructs of the process,
+// allowing them to be exported to the monitoring system, eg. as
+// Prometheus counters and gauges, to alert on the contention hotspots
+type Stats struct {
+	// lock statistics per lock table name
+	Locks map[string]LockStats
+
+	// number of live providers per key, per provider table name
+	Providers map[string]map[string]int64
+
+	// number of owner heartbeats missed, ie. the last seen time not
+	// updated within the update interval, risking the owner to age out
+	HeartbeatMisses uint64
+}
+
+// lock statistics tracked for a lock table
+type lockStats struct {
+	acquired  atomic.Uint64
+	contended atomic.Uint64
+	released  atomic.Uint64
+	held      atomic.Int64
+	holdTime  atomic.Int64
+}
+
+// records the release of the lock acquired at the given time
+func (s *lockStats) release(acquired time.Time) {
+	s.released.Add(1)
+	s.held.Add(-1)
+	s.holdTime.Add(int64(time.Since(acquired)))
+}
+
+type syncStats struct {
+	mu              sync.Mutex
+	locks           map[string]*lockStats
+	providers       map[string]map[string]int64
+	heartbeatMisses atomic.Uint64
+}
+
+var (
+	// statistics of the sync constructs of the process
+	stats = &syncStats{
+		locks:     map[string]*lockStats{},
+		providers: map[string]map[string]int64{},
+	}
+)
+
+// returns the statistics of the lock table with the given name, shared
+// across the owners working with the same table
+func (s *syncStats) lockTable(name string) *lockStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ls, ok := s.locks[name]
+	if !ok {
+		ls = &lockStats{}
+		s.locks[name] = ls
+	}
+	return ls
+}
+
+// records the count of live providers for the key of the provider table
+func (s *syncStats) setProviders(name string, key any, count int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys, ok := s.providers[name]
+	if !ok {
+		keys = map[string]int64{}
+		s.providers[name] = keys
+	}
+	if count == 0 {
+		delete(keys, fmt.Sprint(key))
+		return
+	}
+	keys[fmt.Sprint(key)] = count
+}
+
+// GetStats returns the snapshot of the statistics of the sync constructs
+// of the process
+func GetStats() *Stats {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	snapshot := &Stats{
+		Locks:           map[string]LockStats{},
+		Providers:       map[string]map[string]int64{},
+		HeartbeatMisses: stats.heartbeatMisses.Load(),
+	}
+	for name, ls := range stats.locks {
+		snapshot.Locks[name] = LockStats{
+			Acquired:  ls.acquired.Load(),
+			Contended: ls.contended.Load(),
+			Released:  ls.released.Load(),
+			Held:      ls.held.Load(),
+			HoldTime:  time.Duration(ls.holdTime.Load()),
+		}
+	}
+	for name, keys := range stats.providers {
+		snapshot.Providers[name] = map[string]int64{}
+		for k, v := range keys {
+			snapshot.Providers[name][k] = v
+		}
+	}
+	return snapshot
+}
@@ -0,0 +1,78 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func waitProviderStats(t *testing.T, name, key string, count int64) {
+	for range 200 {
+		if GetStats().Providers[name][key] == count {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d live providers for %s, got %d", count, key, GetStats().Providers[name][key])
+}
+
+func Test_Stats(t *testing.T) {
+	s := initMemoryOwner(t)
+	ctx := context.Background()
+
+	t.Run("locks", func(t *testing.T) {
+		tbl, err := LocateLockTable[lockKey](s, "demo-stats-test")
+		if err != nil {
+			t.Fatalf("failed to locate Lock Table: %s", err)
+		}
+		key := &lockKey{Scope: "scope-stats", Name: "test-key"}
+		// stats are cumulative for the process
+		base := GetStats().Locks["demo-stats-test"]
+		lock, err := tbl.TryAcquire(ctx, key)
+		if err != nil {
+			t.Fatalf("failed to acquire lock: %s", err)
+		}
+		_, _ = tbl.TryAcquire(ctx, key)
+		_, _ = tbl.TryAcquire(ctx, key)
+
+		ls := GetStats().Locks["demo-stats-test"]
+		if ls.Acquired-base.Acquired != 1 || ls.Contended-base.Contended != 2 || ls.Held != 1 || ls.Released != base.Released {
+			t.Errorf("unexpected stats while held %+v", ls)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		_ = lock.Close()
+		// releasing again is not accounted
+		_ = lock.Close()
+		ls = GetStats().Locks["demo-stats-test"]
+		if ls.Held != 0 || ls.Released-base.Released != 1 || ls.HoldTime-base.HoldTime < 10*time.Millisecond {
+			t.Errorf("unexpected stats after release %+v", ls)
+		}
+	})
+
+	t.Run("providers", func(t *testing.T) {
+		tbl, err := LocateProviderTableWithName(s, "provider-stats-test")
+		if err != nil {
+			t.Fatalf("failed to locate provider Table: %s", err)
+		}
+		p1, err := tbl.CreateProvider(ctx, "svc", nil)
+		if err != nil {
+			t.Fatalf("failed to create provider: %s", err)
+		}
+		p2, err := tbl.CreateProvider(ctx, "svc", nil)
+		if err != nil {
+			t.Fatalf("failed to create provider: %s", err)
+		}
+		waitProviderStats(t, "provider-stats-test", "svc", 2)
+
+		_ = p1.Close()
+		_ = p2.Close()
+		waitProviderStats(t, "provider-stats-test", "svc", 0)
+		if _, ok := GetStats().Providers["provider-stats-test"]["svc"]; ok {
+			t.Errorf("expected key without providers to be dropped from stats")
+		}
+	})
+}
@@ -0,0 +1,85 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// CacheStats is a point in time snapshot of the counters maintained by a
+// CachedTable, allowing to quantify the effectiveness of the cache.
+// Counters are cumulative since the initialization of the table.
+type CacheStats struct {
+	// number of Find requests served from the cache
+	Hits int64
+
+	// number of Find requests not available in the cache
+	Misses int64
+
+	// number of entries loaded from the database on cache miss, while
+	// working in read-through mode
+	ReadThroughLoads int64
+
+	// number of cache entries inserted or updated as a result of
+	// change stream notifications
+	WatchUpdates int64
+
+	// number of cache entries removed as a result of change stream
+	// notifications
+	WatchDeletes int64
+
+	// number of entries currently available in the cache
+	Size int
+
+	// time at which the cache was last updated due to a change stream
+	// notification, zero if no notification is processed yet. Used to
+	// evaluate staleness of the cache
+	LastWatchUpdate time.Time
+}
+
+// HitRatio returns the ratio of Find requests served from the cache,
+// returns 0 if no request is served yet
+func (s CacheStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// CacheStatsProvider is implemented by tables maintaining cache statistics,
+// allowing metrics exporters to work with tables of different types
+type CacheStatsProvider interface {
+	CacheStats() CacheStats
+}
+
+// internal counters maintained by the cached table
+type cacheCounters struct {
+	hits             atomic.Int64
+	misses           atomic.Int64
+	readThroughLoads atomic.Int64
+	watchUpdates     atomic.Int64
+	watchDeletes     atomic.Int64
+	lastWatchUpdate  atomic.Int64 // unix nano
+}
+
+// CacheStats returns a snapshot of the cache statistics for the table
+func (t *CachedTable[K, E]) CacheStats() CacheStats {
+	stats := CacheStats{
+		Hits:             t.stats.hits.Load(),
+		Misses:           t.stats.misses.Load(),
+		ReadThroughLoads: t.stats.readThroughLoads.Load(),
+		WatchUpdates:     t.stats.watchUpdates.Load(),
+		WatchDeletes:     t.stats.watchDeletes.Load(),
+	}
+	if ts := t.stats.lastWatchUpdate.Load(); ts != 0 {
+		stats.LastWatchUpdate = time.Unix(0, ts)
+	}
+
+	t.cacheMu.RLock()
+	defer t.cacheMu.RUnlock()
+	stats.Size = len(t.cache)
+	return stats
+}
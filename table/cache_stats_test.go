@@ -0,0 +1,32 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"testing"
+)
+
+func Test_CacheStatsHitRatio(t *testing.T) {
+	stats := CacheStats{}
+	if stats.HitRatio() != 0 {
+		t.Errorf("expected hit ratio 0 without requests, got %f", stats.HitRatio())
+	}
+
+	stats = CacheStats{Hits: 3, Misses: 1}
+	if stats.HitRatio() != 0.75 {
+		t.Errorf("expected hit ratio 0.75, got %f", stats.HitRatio())
+	}
+
+	tbl := &CachedTable[MyKey, MyData]{
+		cache: map[MyKey]*MyData{
+			{Name: "key-1"}: {Desc: "desc-1"},
+		},
+	}
+	tbl.stats.hits.Add(2)
+	var provider CacheStatsProvider = tbl
+	stats = provider.CacheStats()
+	if stats.Hits != 2 || stats.Size != 1 || !stats.LastWatchUpdate.IsZero() {
+		t.Errorf("unexpected cache stats snapshot: %+v", stats)
+	}
+}
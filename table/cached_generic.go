@@ -5,15 +5,19 @@ package table
 
 import (
 	"context"
+	"io"
 	"log"
 	"reflect"
 	"sync"
+	"time"
 
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
 	"github.com/go-core-stack/core/db"
 	"github.com/go-core-stack/core/errors"
 	"github.com/go-core-stack/core/reconciler"
+	"github.com/go-core-stack/core/utils"
 )
 
 // CachedTableConfig holds configuration options for CachedTable initialization.
@@ -37,6 +41,10 @@ type CachedTableConfig struct {
 	// a mongo.Pipeline ([]bson.D) with $match stages or similar.
 	// Default: nil (all change events)
 	WatchPipeline any
+
+	// TableConfig holds the configuration options common with Table,
+	// set using WithTableOptions
+	TableConfig
 }
 
 // CachedTableOption is a functional option for configuring CachedTable.
@@ -51,6 +59,22 @@ func WithReadThrough() CachedTableOption {
 	}
 }
 
+// WithTableOptions applies the configuration options common with Table,
+// to the CachedTable.
+//
+// Example usage:
+//
+//	err := table.InitializeWithConfig(col,
+//	    WithReadThrough(),
+//	    WithTableOptions(WithEncryptor("my-provider")))
+func WithTableOptions(opts ...TableOption) CachedTableOption {
+	return func(cfg *CachedTableConfig) {
+		for _, opt := range opts {
+			opt(&cfg.TableConfig)
+		}
+	}
+}
+
 // WithFilter sets a filter for the initial eager load and ReconcilerGetAllKeys.
 // Only entries matching this filter will be loaded into the cache at initialization
 // and returned when the reconciler enumerates all keys.
@@ -134,12 +158,16 @@ func WithWatchPipeline(pipeline any) CachedTableOption {
 // E: Entry type (must NOT be a pointer type)
 type CachedTable[K comparable, E any] struct {
 	reconciler.ManagerImpl
+	tableHooks[K, E]
 	cacheMu       sync.RWMutex
 	cache         map[K]*E
 	col           db.StoreCollection
 	readThrough   bool
-	filter        any // optional filter for FindMany (eager load + reconciler)
-	watchPipeline any // optional pipeline for Watch (change stream)
+	filter        any               // optional filter for FindMany (eager load + reconciler)
+	watchPipeline any               // optional pipeline for Watch (change stream)
+	enc           utils.IOEncryptor // optional encryptor for fields tagged encrypted
+	stats         cacheCounters     // cache effectiveness counters
+	keys          keyMapper[K]      // maps keys to the stored `_id` values
 }
 
 // Initialize sets up the Table with the provided db.StoreCollection using default configuration.
@@ -197,6 +225,12 @@ func (t *CachedTable[K, E]) InitializeWithConfig(col db.StoreCollection, opts ..
 	t.filter = config.Filter
 	t.watchPipeline = config.WatchPipeline
 
+	enc, err := config.getEncryptor()
+	if err != nil {
+		return err
+	}
+	t.enc = enc
+
 	if t.cache == nil {
 		t.cache = map[K]*E{}
 	}
@@ -211,7 +245,18 @@ func (t *CachedTable[K, E]) InitializeWithConfig(col db.StoreCollection, opts ..
 		return errors.Wrapf(errors.InvalidArgument, "Table key type must not be a pointer")
 	}
 
-	err := col.SetKeyType(reflect.PointerTo(reflect.TypeOf(k)))
+	col, err = config.getCollection(col)
+	if err != nil {
+		return err
+	}
+
+	t.keys.codec = config.KeyCodec
+	err = col.SetKeyType(t.keys.keyType())
+	if err != nil {
+		return err
+	}
+
+	err = config.ensureIndexes(context.Background(), col)
 	if err != nil {
 		return err
 	}
@@ -243,13 +288,12 @@ func (t *CachedTable[K, E]) InitializeWithConfig(col db.StoreCollection, opts ..
 
 	// Only eagerly load entries if read-through is disabled
 	if !t.readThrough {
-		list := []keyOnly[K]{}
-		err = t.col.FindMany(context.Background(), t.filter, &list)
+		list, err := t.keys.allKeys(context.Background(), t.col, t.filter)
 		if err != nil {
 			return errors.Wrapf(errors.Unknown, "failed to eager-load keys: %s", err)
 		}
 		for _, k := range list {
-			entry, err := t.DBFind(context.Background(), &k.Key)
+			entry, err := t.DBFind(context.Background(), k)
 			if err != nil {
 				// this should not happen in regular scenarios
 				// log and return from here
@@ -258,7 +302,7 @@ func (t *CachedTable[K, E]) InitializeWithConfig(col db.StoreCollection, opts ..
 				func() {
 					t.cacheMu.Lock()
 					defer t.cacheMu.Unlock()
-					t.cache[k.Key] = entry
+					t.cache[*k] = entry
 				}()
 			}
 		}
@@ -269,46 +313,75 @@ func (t *CachedTable[K, E]) InitializeWithConfig(col db.StoreCollection, opts ..
 
 // callback is invoked on collection changes and notifies the reconciler.
 func (t *CachedTable[K, E]) callback(op string, wKey any) {
-	key, ok := wKey.(*K)
+	key, err := t.keys.decode(wKey)
 	// failure should logically never happen, but lets handle just incase
-	if ok {
-		entry, err := t.DBFind(context.Background(), key)
-		if err != nil {
-			if errors.IsNotFound(err) {
-				// consider delete scenario
-				func() {
-					t.cacheMu.Lock()
-					defer t.cacheMu.Unlock()
-					delete(t.cache, *key)
-				}()
-			} else {
-				// this should not happen in regular scenarios
-				// log and return from here
-				log.Printf("failed to find an entry, got error: %s", err)
-			}
-		} else {
+	if err != nil {
+		log.Printf("failed to process notification for key %v: %s", wKey, err)
+		return
+	}
+	entry, err := t.DBFind(context.Background(), key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// consider delete scenario
 			func() {
 				t.cacheMu.Lock()
 				defer t.cacheMu.Unlock()
-				t.cache[*key] = entry
+				delete(t.cache, *key)
 			}()
+			t.stats.watchDeletes.Add(1)
+			t.stats.lastWatchUpdate.Store(time.Now().UnixNano())
+		} else {
+			// this should not happen in regular scenarios
+			// log and return from here
+			log.Printf("failed to find an entry, got error: %s", err)
 		}
+	} else {
+		func() {
+			t.cacheMu.Lock()
+			defer t.cacheMu.Unlock()
+			t.cache[*key] = entry
+		}()
+		t.stats.watchUpdates.Add(1)
+		t.stats.lastWatchUpdate.Store(time.Now().UnixNano())
+	}
+	t.NotifyOpCallback(op, key)
+}
+
+// Subscribe registers a callback to receive typed change notifications for
+// the table until the provided context is canceled, using the watch pipeline
+// if one was configured. Callback receives the decoded full document as
+// stored in the database, independent of the cache state. Entry is nil for
+// delete operations.
+// Returns an error if the table is not initialized or the watch fails.
+func (t *CachedTable[K, E]) Subscribe(ctx context.Context, fn func(op string, key *K, entry *E)) error {
+	if t.col == nil {
+		return errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	if fn == nil {
+		return errors.Wrapf(errors.InvalidArgument, "Subscribe callback is not specified")
 	}
-	t.NotifyCallback(wKey)
+	return watchTableEvents(ctx, &t.keys, t.col, t.watchPipeline, func(op string, key *K, entry *E) {
+		if entry != nil {
+			if err := decryptEntry(t.enc, entry); err != nil {
+				log.Printf("failed to decrypt entry with key %v: %s", key, err)
+				return
+			}
+		}
+		fn(op, key, entry)
+	})
 }
 
 // ReconcilerGetAllKeys returns all keys in the table.
 // If a filter was configured via WithFilter, only keys matching the filter are returned.
 // Used by the reconciler to enumerate all managed entries.
 func (t *CachedTable[K, E]) ReconcilerGetAllKeys() []any {
-	list := []keyOnly[K]{}
-	keys := []any{}
-	err := t.col.FindMany(context.Background(), t.filter, &list)
+	list, err := t.keys.allKeys(context.Background(), t.col, t.filter)
 	if err != nil {
 		log.Panicf("got error while fetching all keys %s", err)
 	}
+	keys := []any{}
 	for _, k := range list {
-		keys = append(keys, &k.Key)
+		keys = append(keys, k)
 	}
 	return []any(keys)
 }
@@ -319,16 +392,58 @@ func (t *CachedTable[K, E]) Insert(ctx context.Context, key *K, entry *E) error
 	if t.col == nil {
 		return errors.Wrapf(errors.InvalidArgument, "Table not initialized")
 	}
-	return t.col.InsertOne(ctx, key, entry)
+	err := t.runBeforeInsert(ctx, key, entry)
+	if err != nil {
+		return err
+	}
+	entry, err = encryptEntry(t.enc, entry)
+	if err != nil {
+		return err
+	}
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return err
+	}
+	return t.col.InsertOne(ctx, id, entry)
 }
 
 // Locate finds an entry by key, inserts it if it doesn't exist, or updates it if it does.
+// Reports whether the entry was created or updated, along with the previous
+// state of the entry when it already existed (nil when created).
+// Insert hooks are run when the entry is created, update hooks otherwise.
 // Returns an error if the table is not initialized or the operation fails.
-func (t *CachedTable[K, E]) Locate(ctx context.Context, key *K, entry *E) error {
+func (t *CachedTable[K, E]) Locate(ctx context.Context, key *K, entry *E) (LocateResult, *E, error) {
 	if t.col == nil {
-		return errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+		return LocateCreated, nil, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return LocateCreated, nil, err
+	}
+	return locateEntry(ctx, &t.tableHooks, t.col, t.enc, key, id, entry, nil)
+}
+
+// LocateWithInsertFields works like Locate, while the fields in onInsert
+// (top level field names mapped to values) are set only when the entry is
+// created, eg. createdAt or createdBy, and are retained as is when the
+// entry already exists, irrespective of their values in the entry.
+// Values in onInsert are stored as is without encryption.
+//
+// Example usage:
+//
+//	now := time.Now().Unix()
+//	res, prev, err := table.LocateWithInsertFields(ctx, key,
+//	    &Entry{Desc: "desc", UpdatedAt: now},
+//	    map[string]any{"createdAt": now, "createdBy": user})
+func (t *CachedTable[K, E]) LocateWithInsertFields(ctx context.Context, key *K, entry *E, onInsert map[string]any) (LocateResult, *E, error) {
+	if t.col == nil {
+		return LocateCreated, nil, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return LocateCreated, nil, err
 	}
-	return t.col.UpdateOne(ctx, key, entry, true)
+	return locateEntry(ctx, &t.tableHooks, t.col, t.enc, key, id, entry, onInsert)
 }
 
 // Update modifies an existing entry with the given key.
@@ -337,7 +452,38 @@ func (t *CachedTable[K, E]) Update(ctx context.Context, key *K, entry *E) error
 	if t.col == nil {
 		return errors.Wrapf(errors.InvalidArgument, "Table not initialized")
 	}
-	return t.col.UpdateOne(ctx, key, entry, false)
+	err := t.runBeforeUpdate(ctx, key, entry)
+	if err != nil {
+		return err
+	}
+	entry, err = encryptEntry(t.enc, entry)
+	if err != nil {
+		return err
+	}
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return err
+	}
+	return t.col.UpdateOne(ctx, id, entry, false)
+}
+
+// FindAndUpdate atomically applies the update document (eg. $inc, $set
+// operators) to the entry with the given key, returning the entry state
+// after the update if returnNew is set, otherwise the state before it.
+// Allows advancing counters and state machines without losing concurrent
+// updates, as opposed to a read-modify-write using Update.
+// Update hooks are not invoked, as the update is not a full entry.
+// Returns errors.NotFound if the entry doesn't exist, and
+// errors.InvalidArgument if the update touches the encrypted fields.
+func (t *CachedTable[K, E]) FindAndUpdate(ctx context.Context, key *K, update any, returnNew bool) (*E, error) {
+	if t.col == nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return nil, err
+	}
+	return findAndUpdateEntry[E](ctx, t.col, t.enc, id, update, returnNew)
 }
 
 // Find retrieves an entry by key from the Cache.
@@ -351,10 +497,12 @@ func (t *CachedTable[K, E]) Find(ctx context.Context, key *K) (*E, error) {
 	t.cacheMu.RUnlock()
 
 	if ok {
+		t.stats.hits.Add(1)
 		return entry, nil
 	}
 
 	// Cache miss - handle based on read-through setting
+	t.stats.misses.Add(1)
 	if !t.readThrough {
 		return nil, errors.Wrapf(errors.NotFound, "failed to find entry with key %v", key)
 	}
@@ -377,9 +525,89 @@ func (t *CachedTable[K, E]) Find(ctx context.Context, key *K) (*E, error) {
 
 	// Populate cache
 	t.cache[*key] = dbEntry
+	t.stats.readThroughLoads.Add(1)
 	return dbEntry, nil
 }
 
+// CacheFindMany retrieves all entries from the Cache for which the provided
+// predicate returns true, without round-tripping to the database. A nil
+// predicate matches all the entries available in the cache.
+// Note: in read-through mode the cache only holds entries loaded so far, so
+// the result may not reflect everything available in the database.
+func (t *CachedTable[K, E]) CacheFindMany(predicate func(key *K, entry *E) bool) []*E {
+	t.cacheMu.RLock()
+	defer t.cacheMu.RUnlock()
+
+	list := []*E{}
+	for k, e := range t.cache {
+		if predicate == nil || predicate(&k, e) {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// CacheKeys returns the list of keys currently available in the Cache.
+func (t *CachedTable[K, E]) CacheKeys() []K {
+	t.cacheMu.RLock()
+	defer t.cacheMu.RUnlock()
+
+	keys := make([]K, 0, len(t.cache))
+	for k := range t.cache {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// CacheCount returns the number of entries in the Cache for which the
+// provided predicate returns true, without round-tripping to the database.
+// A nil predicate counts all the entries available in the cache.
+// Note: in read-through mode the cache only holds entries loaded so far.
+func (t *CachedTable[K, E]) CacheCount(predicate func(key *K, entry *E) bool) int {
+	t.cacheMu.RLock()
+	defer t.cacheMu.RUnlock()
+
+	if predicate == nil {
+		return len(t.cache)
+	}
+	count := 0
+	for k, e := range t.cache {
+		if predicate(&k, e) {
+			count++
+		}
+	}
+	return count
+}
+
+// Exists checks if an entry with the given key exists, without fetching
+// the entry. Cache is looked up first, if read-through caching is enabled
+// and the entry is not in cache, it falls back to checking the database,
+// without populating the cache.
+// Returns an error if the table is not initialized or the database check fails.
+func (t *CachedTable[K, E]) Exists(ctx context.Context, key *K) (bool, error) {
+	if t.col == nil {
+		return false, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+
+	t.cacheMu.RLock()
+	_, ok := t.cache[*key]
+	t.cacheMu.RUnlock()
+
+	if ok || !t.readThrough {
+		return ok, nil
+	}
+
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return false, err
+	}
+	count, err := t.col.Count(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return count != 0, nil
+}
+
 // DBFind retrieves an entry by key from the Database
 // Returns the entry and error if not found or if the table is not initialized.
 func (t *CachedTable[K, E]) DBFind(ctx context.Context, key *K) (*E, error) {
@@ -387,11 +615,19 @@ func (t *CachedTable[K, E]) DBFind(ctx context.Context, key *K) (*E, error) {
 	if t.col == nil {
 		return nil, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
 	}
-	err := t.col.FindOne(ctx, key, &data)
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return nil, err
+	}
+	err = t.col.FindOne(ctx, id, &data)
 	if err != nil {
 		return nil, errors.Wrapf(errors.NotFound, "failed to find entry with key %v: %s", key, err)
 	}
-	return &data, err
+	err = decryptEntry(t.enc, &data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
 }
 
 // DBFindMany retrieves multiple entries matching the provided filter from database.
@@ -406,6 +642,10 @@ func (t *CachedTable[K, E]) DBFindMany(ctx context.Context, filter any, offset,
 	if err != nil {
 		return nil, errors.Wrapf(errors.NotFound, "failed to find any entry: %s", err)
 	}
+	err = decryptEntries(t.enc, data)
+	if err != nil {
+		return nil, err
+	}
 
 	return data, nil
 }
@@ -445,14 +685,33 @@ func (t *CachedTable[K, E]) DBFindManyWithOpts(ctx context.Context, filter any,
 
 	// Execute query
 	var data []*E
-	err := t.col.FindMany(ctx, filter, &data, mongoOpts)
+	err := t.col.FindMany(ctx, searchFilter(filter, findOpts), &data, mongoOpts)
 	if err != nil {
 		return nil, errors.Wrapf(errors.NotFound, "failed to find any entry: %s", err)
 	}
+	err = decryptEntries(t.enc, data)
+	if err != nil {
+		return nil, err
+	}
 
 	return data, nil
 }
 
+// DBFindPage retrieves a page of entries matching the filter, using an opaque
+// continuation token instead of offset, which remains efficient for large
+// tables and doesn't skip or duplicate entries on concurrent writes.
+// Pass an empty token for the first page and the returned next token for
+// the subsequent pages, next token is empty once the last page is returned.
+// Entries are ordered by the sort options followed by the key, the same
+// sort options must be used across pages. Entries missing a sort field
+// are ordered as null, before any value.
+func (t *CachedTable[K, E]) DBFindPage(ctx context.Context, filter any, token string, limit int32, sort ...SortOption) ([]*E, string, error) {
+	if t.col == nil {
+		return nil, "", errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	return findPage[E](ctx, t.col, t.enc, filter, token, limit, sort)
+}
+
 // Count retrieves count of entries matching the provided filter.
 // Returns count of entries and error if none found or if the table is not initialized.
 func (t *CachedTable[K, E]) Count(ctx context.Context, filter any) (int64, error) {
@@ -478,5 +737,85 @@ func (t *CachedTable[K, E]) DeleteKey(ctx context.Context, key *K) error {
 	if t.col == nil {
 		return errors.Wrapf(errors.InvalidArgument, "Table not initialized")
 	}
-	return t.col.DeleteOne(ctx, key)
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return err
+	}
+	err = t.col.DeleteOne(ctx, id)
+	if err != nil {
+		return err
+	}
+	t.runAfterDelete(ctx, key)
+	return nil
+}
+
+// DeleteAndReturn atomically removes an entry by key from the table,
+// returning the entry as it was before removal.
+// Returns NotFound error if the entry does not exist.
+func (t *CachedTable[K, E]) DeleteAndReturn(ctx context.Context, key *K) (*E, error) {
+	if t.col == nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := deleteEntry[E](ctx, t.col, t.enc, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	t.runAfterDelete(ctx, key)
+	return entry, nil
+}
+
+// DeleteIf removes an entry by key from the table only if it also matches
+// the given filter, enabling compare-and-delete semantics, for example
+// deleting an entry only if its version is unchanged.
+// Returns NotFound error if the entry does not exist or does not match
+// the filter.
+func (t *CachedTable[K, E]) DeleteIf(ctx context.Context, key *K, filter any) error {
+	if t.col == nil {
+		return errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return err
+	}
+	_, err = deleteEntry[E](ctx, t.col, t.enc, id, filter)
+	if err != nil {
+		return err
+	}
+	t.runAfterDelete(ctx, key)
+	return nil
+}
+
+// Export streams the entries of the table to the writer as JSON lines
+// (MongoDB relaxed extended JSON), one entry per line along with its key,
+// for backups, seeding test environments or moving tenant data.
+// Encrypted fields are exported decrypted.
+// Returns the number of entries exported.
+//
+// Example usage:
+//
+//	count, err := table.Export(ctx, file,
+//	    WithSnapshotFilter(bson.M{"tenant": "acme"}),
+//	    WithSnapshotRateLimit(limits, "export"))
+func (t *CachedTable[K, E]) Export(ctx context.Context, w io.Writer, opts ...SnapshotOption) (int64, error) {
+	if t.col == nil {
+		return 0, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	return exportEntries[E](ctx, t.col, t.enc, w, opts)
+}
+
+// Import reads the entries written by Export from the reader, creating or
+// updating each of them using Locate, stops at the first failure.
+// Returns the number of entries imported.
+func (t *CachedTable[K, E]) Import(ctx context.Context, r io.Reader, opts ...SnapshotOption) (int64, error) {
+	if t.col == nil {
+		return 0, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	return importEntries(ctx, &t.keys, r, opts, func(key *K, entry *E) error {
+		_, _, err := t.Locate(ctx, key, entry)
+		return err
+	})
 }
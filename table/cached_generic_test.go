@@ -5,6 +5,7 @@ package table
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"testing"
 	"time"
@@ -500,6 +501,17 @@ func Test_CachedClient(t *testing.T) {
 			t.Errorf("expected error for non-existent key, got nil")
 		}
 
+		// three Find requests are made so far, where non-existent key is
+		// always a miss, while the first Find could either be a miss
+		// loading from DB or a hit if watch callback populated it already
+		stats := readThroughTable.CacheStats()
+		if stats.Hits+stats.Misses != 3 || stats.Misses < 1 {
+			t.Errorf("unexpected cache stats, got hits=%d, misses=%d", stats.Hits, stats.Misses)
+		}
+		if stats.Size != 1 {
+			t.Errorf("expected cache size 1, got %d", stats.Size)
+		}
+
 		// Test that cache is updated via watch callback when data changes
 		key2 := &MyKey{Name: "read-through-key-2"}
 		data2 := &MyData{
@@ -606,4 +618,48 @@ func Test_CachedClient(t *testing.T) {
 			t.Errorf("failed to cleanup test data: %s", err)
 		}
 	})
+	t.Run("cache_find_many", func(t *testing.T) {
+		ctx := context.Background()
+
+		for i, score := range []int{10, 20, 30} {
+			key := &MyKey{Name: fmt.Sprintf("cache-find-%d", i)}
+			data := &MyData{
+				Desc:  fmt.Sprintf("cache-find-desc-%d", i),
+				Score: score,
+				Val:   &InternaData{Test: "test"},
+			}
+			err := myTable.Insert(ctx, key, data)
+			if err != nil {
+				t.Errorf("failed inserting entry %s: %s", key.Name, err)
+			}
+		}
+
+		// Wait for cache to update
+		time.Sleep(1 * time.Second)
+
+		results := myTable.CacheFindMany(func(k *MyKey, e *MyData) bool {
+			return e.Score >= 20
+		})
+		if len(results) != 2 {
+			t.Errorf("expected 2 entries with score >= 20, got %d", len(results))
+		}
+
+		results = myTable.CacheFindMany(nil)
+		if len(results) != 3 {
+			t.Errorf("expected 3 entries without predicate, got %d", len(results))
+		}
+
+		keys := myTable.CacheKeys()
+		if len(keys) != 3 {
+			t.Errorf("expected 3 keys in cache, got %d", len(keys))
+		}
+
+		count, err := myTable.col.DeleteMany(ctx, bson.D{})
+		if err != nil {
+			t.Errorf("failed to delete test entries: %s", err)
+		}
+		if count != 3 {
+			t.Errorf("expected to delete 3 entries, deleted %d", count)
+		}
+	})
 }
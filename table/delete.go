@@ -0,0 +1,32 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/utils"
+)
+
+// deleteEntry atomically removes the entry for the given key, provided it
+// also matches the optional condition, returning the deleted entry
+func deleteEntry[E any](ctx context.Context, col db.StoreCollection, enc utils.IOEncryptor, key any, cond any) (*E, error) {
+	filter := bson.D{{Key: "_id", Value: key}}
+	if cond != nil {
+		filter = append(filter, bson.E{Key: "$and", Value: bson.A{cond}})
+	}
+	var data E
+	err := col.FindOneAndDelete(ctx, filter, &data)
+	if err != nil {
+		return nil, err
+	}
+	err = decryptEntry(enc, &data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -0,0 +1,188 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/errors"
+	"github.com/go-core-stack/core/utils"
+)
+
+// getEncryptor returns the encryptor configured for the table, nil if
+// encryption is not enabled
+func (c *TableConfig) getEncryptor() (utils.IOEncryptor, error) {
+	if c.EncryptorProvider == "" {
+		return nil, nil
+	}
+	enc, err := utils.GetObjectEncryptor(c.EncryptorProvider)
+	if err != nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "WithEncryptor: encryptor for provider %s not available: %s", c.EncryptorProvider, err)
+	}
+	return enc, nil
+}
+
+// encryptEntry returns an encrypted copy of the entry to be written to the
+// database, entry passed by the caller is never modified.
+// returns the same entry if encryption is not enabled
+func encryptEntry[E any](enc utils.IOEncryptor, entry *E) (*E, error) {
+	if enc == nil || entry == nil {
+		return entry, nil
+	}
+
+	// make a deep copy of the entry before encrypting, as encryptor
+	// updates the values in place including the ones referred by
+	// pointers, which would otherwise be shared with the caller
+	raw, err := bson.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	var data E
+	err = bson.Unmarshal(raw, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = enc.EncryptObject(&data)
+	if err != nil {
+		return nil, errors.Wrapf(errors.Unknown, "failed to encrypt entry: %s", err)
+	}
+	return &data, nil
+}
+
+// decryptEntry decrypts the entry read from the database in place
+func decryptEntry[E any](enc utils.IOEncryptor, entry *E) error {
+	if enc == nil || entry == nil {
+		return nil
+	}
+	_, err := enc.DecryptObject(entry)
+	if err != nil {
+		return errors.Wrapf(errors.Unknown, "failed to decrypt entry: %s", err)
+	}
+	return nil
+}
+
+// decryptEntries decrypts the list of entries read from the database in place
+func decryptEntries[E any](enc utils.IOEncryptor, list []*E) error {
+	for _, entry := range list {
+		if err := decryptEntry(enc, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encryptedPaths returns the document paths of the fields of the entry
+// type holding encrypted values, ie. the fields tagged encrypted and the
+// containers (slices, arrays and maps) of the structs with such fields
+func encryptedPaths(t reflect.Type, prefix string) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	paths := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, inline := bsonFieldName(f)
+		if name == "-" {
+			continue
+		}
+		path := prefix + name
+		if inline {
+			path = strings.TrimSuffix(prefix, ".")
+		}
+		tag, tagged := f.Tag.Lookup("encrypted")
+		switch {
+		case tag == "-":
+		case tagged:
+			paths = append(paths, path)
+		default:
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			switch ft.Kind() {
+			case reflect.Struct:
+				next := path + "."
+				if inline {
+					next = prefix
+				}
+				paths = append(paths, encryptedPaths(ft, next)...)
+			case reflect.Slice, reflect.Array, reflect.Map:
+				if len(encryptedPaths(ft.Elem(), "")) != 0 {
+					paths = append(paths, path)
+				}
+			}
+		}
+	}
+	return paths
+}
+
+// returns the name of the field in the document, as encoded by bson,
+// along with whether the field is inlined in the parent document
+func bsonFieldName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("bson")
+	name, opts, _ := strings.Cut(tag, ",")
+	inline := slices.Contains(strings.Split(opts, ","), "inline")
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+	return name, inline
+}
+
+// checkEncryptedUpdate rejects the update documents touching the
+// encrypted fields of the entry, as the values of the update operators
+// are not encrypted, and would otherwise be stored in plaintext
+func checkEncryptedUpdate[E any](enc utils.IOEncryptor, update any) error {
+	if enc == nil {
+		return nil
+	}
+	paths := encryptedPaths(reflect.TypeFor[E](), "")
+	if len(paths) == 0 {
+		return nil
+	}
+	raw, err := bson.Marshal(update)
+	if err != nil {
+		return errors.Wrapf(errors.InvalidArgument, "invalid update document for table with encryption: %s", err)
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return errors.Wrapf(errors.InvalidArgument, "invalid update document for table with encryption: %s", err)
+	}
+	touches := func(field string) error {
+		for _, p := range paths {
+			if field == p || strings.HasPrefix(field, p+".") || strings.HasPrefix(p, field+".") {
+				return errors.Wrapf(errors.InvalidArgument, "update of encrypted field %s is not supported, use Update instead", field)
+			}
+		}
+		return nil
+	}
+	for _, op := range doc {
+		if !strings.HasPrefix(op.Key, "$") {
+			if err := touches(op.Key); err != nil {
+				return err
+			}
+			continue
+		}
+		fields, ok := op.Value.(bson.D)
+		if !ok {
+			continue
+		}
+		for _, f := range fields {
+			if err := touches(f.Key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+	"github.com/go-core-stack/core/utils"
+)
+
+type SecretData struct {
+	Test string `encrypted:"true"`
+}
+
+type AccountData struct {
+	Name   string
+	Secret string `encrypted:"true"`
+	Nested *SecretData
+	Opt    *SecretData
+}
+
+func Test_EntryEncryption(t *testing.T) {
+	_, err := utils.InitializeEncryptor("table-test-provider", "table-test-key")
+	if err != nil {
+		t.Fatalf("failed to initialize encryptor: %s", err)
+	}
+
+	config := &TableConfig{}
+	WithEncryptor("table-test-provider")(config)
+	enc, err := config.getEncryptor()
+	if err != nil {
+		t.Fatalf("failed to get encryptor: %s", err)
+	}
+
+	entry := &AccountData{
+		Name:   "account-1",
+		Secret: "my-secret",
+		Nested: &SecretData{Test: "nested-secret"},
+	}
+
+	encrypted, err := encryptEntry(enc, entry)
+	if err != nil {
+		t.Fatalf("failed to encrypt entry: %s", err)
+	}
+
+	// entry passed by the caller should never be modified
+	if entry.Secret != "my-secret" || entry.Nested.Test != "nested-secret" {
+		t.Errorf("caller entry got modified while encrypting: %v", *entry)
+	}
+
+	if encrypted.Name != "account-1" {
+		t.Errorf("untagged field should not be encrypted, got %s", encrypted.Name)
+	}
+	if encrypted.Secret == "my-secret" || encrypted.Nested.Test == "nested-secret" {
+		t.Errorf("tagged fields are not encrypted: %v", *encrypted)
+	}
+
+	err = decryptEntries(enc, []*AccountData{encrypted})
+	if err != nil {
+		t.Fatalf("failed to decrypt entry: %s", err)
+	}
+	if encrypted.Secret != "my-secret" || encrypted.Nested.Test != "nested-secret" {
+		t.Errorf("decrypted entry does not match, got %v", *encrypted)
+	}
+
+	// no encryption should be performed without encryptor
+	plain, err := encryptEntry(nil, entry)
+	if err != nil || plain != entry {
+		t.Errorf("expected entry to be returned as is without encryptor")
+	}
+
+	config = &TableConfig{}
+	WithEncryptor("table-test-unknown")(config)
+	_, err = config.getEncryptor()
+	if err == nil {
+		t.Errorf("expected error for unknown encryptor provider")
+	}
+}
+
+type AccountKey struct {
+	ID string
+}
+
+type CounterAccount struct {
+	Name    string
+	Visits  int
+	Secret  string `encrypted:"true"`
+	Profile *SecretData
+	Tokens  []SecretData
+	Notes   string `encrypted:"-"`
+}
+
+func Test_FindAndUpdateEncryption(t *testing.T) {
+	ctx := context.Background()
+	_, err := utils.InitializeEncryptor("table-update-provider", "table-update-key")
+	if err != nil {
+		t.Fatalf("failed to initialize encryptor: %s", err)
+	}
+	tbl := &Table[AccountKey, CounterAccount]{}
+	err = tbl.InitializeWithConfig(db.NewMemoryCollection("accounts"), WithEncryptor("table-update-provider"))
+	if err != nil {
+		t.Fatalf("failed to initialize table: %s", err)
+	}
+	key := &AccountKey{ID: "acct-1"}
+	entry := &CounterAccount{Name: "acct", Secret: "my-secret", Profile: &SecretData{Test: "nested"}}
+	if err := tbl.Insert(ctx, key, entry); err != nil {
+		t.Fatalf("failed to insert: %s", err)
+	}
+
+	// updates of the plain fields decrypt the returned entry
+	updated, err := tbl.FindAndUpdate(ctx, key, bson.M{"$inc": bson.M{"visits": 1}, "$set": bson.M{"notes": "plain"}}, true)
+	if err != nil || updated.Visits != 1 || updated.Secret != "my-secret" || updated.Profile.Test != "nested" {
+		t.Errorf("unexpected find and update result %v, err %v", updated, err)
+	}
+
+	for _, update := range []any{
+		bson.M{"$set": bson.M{"secret": "leaked"}},
+		bson.D{{Key: "$setOnInsert", Value: bson.D{{Key: "profile.test", Value: "leaked"}}}},
+		bson.M{"$set": bson.M{"profile": bson.M{"test": "leaked"}}},
+		bson.M{"$push": bson.M{"tokens": bson.M{"test": "leaked"}}},
+		bson.M{"$unset": bson.M{"secret": ""}},
+	} {
+		if _, err := tbl.FindAndUpdate(ctx, key, update, true); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected update %v of encrypted field to be rejected, got %v", update, err)
+		}
+	}
+
+	found, err := tbl.Find(ctx, key)
+	if err != nil || found.Secret != "my-secret" || found.Profile.Test != "nested" || found.Visits != 1 {
+		t.Errorf("unexpected entry %v after updates, err %v", found, err)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/utils"
+)
+
+// findAndUpdateEntry atomically applies the update document to the entry
+// for the given key, returning the entry state before or after the update,
+// updates of the encrypted fields are rejected for the tables with
+// encryption enabled
+func findAndUpdateEntry[E any](ctx context.Context, col db.StoreCollection, enc utils.IOEncryptor, key any, update any, returnNew bool) (*E, error) {
+	if err := checkEncryptedUpdate[E](enc, update); err != nil {
+		return nil, err
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+	if returnNew {
+		opts.SetReturnDocument(options.After)
+	}
+	var data E
+	err := col.FindOneAndUpdate(ctx, key, update, &data, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = decryptEntry(enc, &data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -5,6 +5,7 @@ package table
 
 import (
 	"context"
+	"io"
 	"log"
 	"reflect"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/go-core-stack/core/db"
 	"github.com/go-core-stack/core/errors"
 	"github.com/go-core-stack/core/reconciler"
+	"github.com/go-core-stack/core/utils"
 )
 
 /*
@@ -160,6 +162,14 @@ type FindOptions struct {
 	Limit  *int32
 	Offset *int32
 	Sort   []SortOption
+
+	// TextSearch restricts the results to entries matching the text
+	// search query, see WithTextSearch
+	TextSearch string
+
+	// Prefixes restricts the results to entries where the fields start
+	// with the given prefixes, see WithRegexPrefix
+	Prefixes []FieldPrefix
 }
 
 // FindOption is a functional option for configuring FindMany queries.
@@ -186,6 +196,108 @@ func WithSort(sort ...SortOption) FindOption {
 	}
 }
 
+// TableConfig holds configuration options common to Table and CachedTable
+// initialization.
+type TableConfig struct {
+	// EncryptorProvider specifies the utils.IOEncryptor provider used to
+	// transparently encrypt entry fields tagged with `encrypted` while
+	// writing to the database and decrypt them while reading back.
+	// The encryptor must already be initialized for the provider.
+	// Default: "" (no encryption)
+	EncryptorProvider string
+
+	// KeyCodec customizes how the keys are stored as `_id` documents,
+	// instead of the default bson marshalling of the key type.
+	// Default: nil (default bson marshalling)
+	KeyCodec KeyCodec
+
+	// TextIndexFields lists the fields covered by the text index ensured
+	// while initializing the table, used by WithTextSearch.
+	// Default: nil (no text index)
+	TextIndexFields []string
+
+	// PrefixIndexFields lists the fields indexed while initializing the
+	// table, used by WithRegexPrefix.
+	// Default: nil (no prefix index)
+	PrefixIndexFields []string
+
+	// Consistency overrides the read preference, read concern and write
+	// concern used by the table, eg. reading from secondaries for
+	// reporting tables or majority reads for sync critical tables.
+	// Default: empty (client defaults)
+	Consistency db.ConsistencyOptions
+
+	// Indexes lists the additional indexes ensured while initializing the
+	// table, eg. unique or TTL indexes on the entry fields.
+	// Default: nil (no additional indexes)
+	Indexes []db.IndexDefinition
+}
+
+// TableOption is a functional option for configuring Table and CachedTable.
+type TableOption func(*TableConfig)
+
+// WithEncryptor enables field-level encryption using the encryptor
+// initialized for the given provider via utils.InitializeEncryptor.
+// Entry fields tagged with `encrypted` are encrypted at rest on Insert,
+// Update and Locate and decrypted on every read. FindAndUpdate rejects the
+// update documents touching the encrypted fields, as the values of the
+// update operators are not encrypted.
+//
+// Since every encryption uses a random nonce, encrypted fields cannot be
+// used as part of query filters.
+//
+// Example usage:
+//
+//	type Account struct {
+//	    Name   string
+//	    Secret string `encrypted:"true"`
+//	}
+//
+//	_, err := utils.InitializeEncryptor("accounts", key)
+//	err = table.InitializeWithConfig(col, WithEncryptor("accounts"))
+func WithEncryptor(provider string) TableOption {
+	return func(cfg *TableConfig) {
+		cfg.EncryptorProvider = provider
+	}
+}
+
+// WithReadPreference sets the members of the replica set serving the
+// reads for the table, overriding the client default.
+func WithReadPreference(pref db.ReadPreference) TableOption {
+	return func(cfg *TableConfig) {
+		cfg.Consistency.ReadPreference = pref
+	}
+}
+
+// WithReadConcern sets the read concern for the table, overriding the
+// client default.
+func WithReadConcern(level db.ReadConcern) TableOption {
+	return func(cfg *TableConfig) {
+		cfg.Consistency.ReadConcern = level
+	}
+}
+
+// WithWriteConcern sets the write concern for the table, overriding the
+// client default.
+func WithWriteConcern(level db.WriteConcern) TableOption {
+	return func(cfg *TableConfig) {
+		cfg.Consistency.WriteConcern = level
+	}
+}
+
+// returns the collection to be used by the table, applying the
+// consistency options if configured
+func (c *TableConfig) getCollection(col db.StoreCollection) (db.StoreCollection, error) {
+	if c.Consistency == (db.ConsistencyOptions{}) {
+		return col, nil
+	}
+	col, err := col.WithConsistency(c.Consistency)
+	if err != nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "Table consistency options: %s", err)
+	}
+	return col, nil
+}
+
 // Table is a generic table type providing common functions and types to specific
 // structures each table is built using. It ensures sanity checks and provides
 // common functionality for database-backed tables.
@@ -194,20 +306,47 @@ func WithSort(sort ...SortOption) FindOption {
 // E: Entry type (must NOT be a pointer type)
 type Table[K any, E any] struct {
 	reconciler.ManagerImpl
-	col db.StoreCollection
+	tableHooks[K, E]
+	col  db.StoreCollection
+	enc  utils.IOEncryptor // optional encryptor for fields tagged encrypted
+	keys keyMapper[K]      // maps keys to the stored `_id` values
 }
 
-// Initialize sets up the Table with the provided db.StoreCollection.
+// Initialize sets up the Table with the provided db.StoreCollection using default configuration.
 // It performs sanity checks on the entry and key types and registers the key type with the collection.
 // Must be called before any other operation.
 //
 // Returns an error if the table is already initialized, the entry or key type is a pointer,
 // or if the collection setup fails.
 func (t *Table[K, E]) Initialize(col db.StoreCollection) error {
+	return t.InitializeWithConfig(col)
+}
+
+// InitializeWithConfig sets up the Table with the provided db.StoreCollection and configuration options.
+// Must be called before any other operation.
+//
+// Example usage:
+//
+//	err := table.InitializeWithConfig(col, WithEncryptor("my-provider"))
+//
+// Returns an error if the table is already initialized, the entry or key type is a pointer,
+// the configuration is invalid or if the collection setup fails.
+func (t *Table[K, E]) InitializeWithConfig(col db.StoreCollection, opts ...TableOption) error {
 	if t.col != nil {
 		return errors.Wrapf(errors.AlreadyExists, "Table is already initialized")
 	}
 
+	config := &TableConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	enc, err := config.getEncryptor()
+	if err != nil {
+		return err
+	}
+	t.enc = enc
+
 	var e E
 	if reflect.TypeOf(e).Kind() == reflect.Pointer {
 		return errors.Wrapf(errors.InvalidArgument, "Table entry type must not be a pointer")
@@ -218,7 +357,18 @@ func (t *Table[K, E]) Initialize(col db.StoreCollection) error {
 		return errors.Wrapf(errors.InvalidArgument, "Table key type must not be a pointer")
 	}
 
-	err := col.SetKeyType(reflect.PointerTo(reflect.TypeOf(k)))
+	col, err = config.getCollection(col)
+	if err != nil {
+		return err
+	}
+
+	t.keys.codec = config.KeyCodec
+	err = col.SetKeyType(t.keys.keyType())
+	if err != nil {
+		return err
+	}
+
+	err = config.ensureIndexes(context.Background(), col)
 	if err != nil {
 		return err
 	}
@@ -241,7 +391,35 @@ func (t *Table[K, E]) Initialize(col db.StoreCollection) error {
 
 // callback is invoked on collection changes and notifies the reconciler.
 func (t *Table[K, E]) callback(op string, wKey any) {
-	t.NotifyCallback(wKey)
+	key, err := t.keys.decode(wKey)
+	if err != nil {
+		log.Printf("failed to process notification for key %v: %s", wKey, err)
+		return
+	}
+	t.NotifyOpCallback(op, key)
+}
+
+// Subscribe registers a callback to receive typed change notifications for
+// the table until the provided context is canceled. Unlike the reconciler,
+// callback receives the decoded full document along with the key, avoiding
+// a re-fetch on every notification. Entry is nil for delete operations.
+// Returns an error if the table is not initialized or the watch fails.
+func (t *Table[K, E]) Subscribe(ctx context.Context, fn func(op string, key *K, entry *E)) error {
+	if t.col == nil {
+		return errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	if fn == nil {
+		return errors.Wrapf(errors.InvalidArgument, "Subscribe callback is not specified")
+	}
+	return watchTableEvents(ctx, &t.keys, t.col, nil, func(op string, key *K, entry *E) {
+		if entry != nil {
+			if err := decryptEntry(t.enc, entry); err != nil {
+				log.Printf("failed to decrypt entry with key %v: %s", key, err)
+				return
+			}
+		}
+		fn(op, key, entry)
+	})
 }
 
 // keyOnly is a helper struct for extracting keys from the collection.
@@ -252,14 +430,13 @@ type keyOnly[K any] struct {
 // ReconcilerGetAllKeys returns all keys in the table.
 // Used by the reconciler to enumerate all managed entries.
 func (t *Table[K, E]) ReconcilerGetAllKeys() []any {
-	list := []keyOnly[K]{}
-	keys := []any{}
-	err := t.col.FindMany(context.Background(), nil, &list)
+	list, err := t.keys.allKeys(context.Background(), t.col, nil)
 	if err != nil {
 		log.Panicf("got error while fetching all keys %s", err)
 	}
+	keys := []any{}
 	for _, k := range list {
-		keys = append(keys, &k.Key)
+		keys = append(keys, k)
 	}
 	return []any(keys)
 }
@@ -270,16 +447,58 @@ func (t *Table[K, E]) Insert(ctx context.Context, key *K, entry *E) error {
 	if t.col == nil {
 		return errors.Wrapf(errors.InvalidArgument, "Table not initialized")
 	}
-	return t.col.InsertOne(ctx, key, entry)
+	err := t.runBeforeInsert(ctx, key, entry)
+	if err != nil {
+		return err
+	}
+	entry, err = encryptEntry(t.enc, entry)
+	if err != nil {
+		return err
+	}
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return err
+	}
+	return t.col.InsertOne(ctx, id, entry)
 }
 
 // Locate finds an entry by key, inserts it if it doesn't exist, or updates it if it does.
+// Reports whether the entry was created or updated, along with the previous
+// state of the entry when it already existed (nil when created).
+// Insert hooks are run when the entry is created, update hooks otherwise.
 // Returns an error if the table is not initialized or the operation fails.
-func (t *Table[K, E]) Locate(ctx context.Context, key *K, entry *E) error {
+func (t *Table[K, E]) Locate(ctx context.Context, key *K, entry *E) (LocateResult, *E, error) {
 	if t.col == nil {
-		return errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+		return LocateCreated, nil, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return LocateCreated, nil, err
 	}
-	return t.col.UpdateOne(ctx, key, entry, true)
+	return locateEntry(ctx, &t.tableHooks, t.col, t.enc, key, id, entry, nil)
+}
+
+// LocateWithInsertFields works like Locate, while the fields in onInsert
+// (top level field names mapped to values) are set only when the entry is
+// created, eg. createdAt or createdBy, and are retained as is when the
+// entry already exists, irrespective of their values in the entry.
+// Values in onInsert are stored as is without encryption.
+//
+// Example usage:
+//
+//	now := time.Now().Unix()
+//	res, prev, err := table.LocateWithInsertFields(ctx, key,
+//	    &Entry{Desc: "desc", UpdatedAt: now},
+//	    map[string]any{"createdAt": now, "createdBy": user})
+func (t *Table[K, E]) LocateWithInsertFields(ctx context.Context, key *K, entry *E, onInsert map[string]any) (LocateResult, *E, error) {
+	if t.col == nil {
+		return LocateCreated, nil, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return LocateCreated, nil, err
+	}
+	return locateEntry(ctx, &t.tableHooks, t.col, t.enc, key, id, entry, onInsert)
 }
 
 // Update modifies an existing entry with the given key.
@@ -288,7 +507,38 @@ func (t *Table[K, E]) Update(ctx context.Context, key *K, entry *E) error {
 	if t.col == nil {
 		return errors.Wrapf(errors.InvalidArgument, "Table not initialized")
 	}
-	return t.col.UpdateOne(ctx, key, entry, false)
+	err := t.runBeforeUpdate(ctx, key, entry)
+	if err != nil {
+		return err
+	}
+	entry, err = encryptEntry(t.enc, entry)
+	if err != nil {
+		return err
+	}
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return err
+	}
+	return t.col.UpdateOne(ctx, id, entry, false)
+}
+
+// FindAndUpdate atomically applies the update document (eg. $inc, $set
+// operators) to the entry with the given key, returning the entry state
+// after the update if returnNew is set, otherwise the state before it.
+// Allows advancing counters and state machines without losing concurrent
+// updates, as opposed to a read-modify-write using Update.
+// Update hooks are not invoked, as the update is not a full entry.
+// Returns errors.NotFound if the entry doesn't exist, and
+// errors.InvalidArgument if the update touches the encrypted fields.
+func (t *Table[K, E]) FindAndUpdate(ctx context.Context, key *K, update any, returnNew bool) (*E, error) {
+	if t.col == nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return nil, err
+	}
+	return findAndUpdateEntry[E](ctx, t.col, t.enc, id, update, returnNew)
 }
 
 // Find retrieves an entry by key.
@@ -298,11 +548,19 @@ func (t *Table[K, E]) Find(ctx context.Context, key *K) (*E, error) {
 	if t.col == nil {
 		return nil, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
 	}
-	err := t.col.FindOne(ctx, key, &data)
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return nil, err
+	}
+	err = t.col.FindOne(ctx, id, &data)
 	if err != nil {
 		return nil, errors.Wrapf(errors.NotFound, "failed to find entry with key %v: %s", key, err)
 	}
-	return &data, err
+	err = decryptEntry(t.enc, &data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
 }
 
 // FindMany retrieves multiple entries matching the provided filter.
@@ -317,6 +575,10 @@ func (t *Table[K, E]) FindMany(ctx context.Context, filter any, offset, limit in
 	if err != nil {
 		return nil, errors.Wrapf(errors.NotFound, "failed to find any entry: %s", err)
 	}
+	err = decryptEntries(t.enc, data)
+	if err != nil {
+		return nil, err
+	}
 
 	return data, nil
 }
@@ -356,14 +618,33 @@ func (t *Table[K, E]) FindManyWithOpts(ctx context.Context, filter any, opts ...
 
 	// Execute query
 	var data []*E
-	err := t.col.FindMany(ctx, filter, &data, mongoOpts)
+	err := t.col.FindMany(ctx, searchFilter(filter, findOpts), &data, mongoOpts)
 	if err != nil {
 		return nil, errors.Wrapf(errors.NotFound, "failed to find any entry: %s", err)
 	}
+	err = decryptEntries(t.enc, data)
+	if err != nil {
+		return nil, err
+	}
 
 	return data, nil
 }
 
+// FindPage retrieves a page of entries matching the filter, using an opaque
+// continuation token instead of offset, which remains efficient for large
+// tables and doesn't skip or duplicate entries on concurrent writes.
+// Pass an empty token for the first page and the returned next token for
+// the subsequent pages, next token is empty once the last page is returned.
+// Entries are ordered by the sort options followed by the key, the same
+// sort options must be used across pages. Entries missing a sort field
+// are ordered as null, before any value.
+func (t *Table[K, E]) FindPage(ctx context.Context, filter any, token string, limit int32, sort ...SortOption) ([]*E, string, error) {
+	if t.col == nil {
+		return nil, "", errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	return findPage[E](ctx, t.col, t.enc, filter, token, limit, sort)
+}
+
 // Count retrieves count of entries matching the provided filter.
 // Returns count of entries and error if none found or if the table is not initialized.
 func (t *Table[K, E]) Count(ctx context.Context, filter any) (int64, error) {
@@ -389,5 +670,85 @@ func (t *Table[K, E]) DeleteKey(ctx context.Context, key *K) error {
 	if t.col == nil {
 		return errors.Wrapf(errors.InvalidArgument, "Table not initialized")
 	}
-	return t.col.DeleteOne(ctx, key)
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return err
+	}
+	err = t.col.DeleteOne(ctx, id)
+	if err != nil {
+		return err
+	}
+	t.runAfterDelete(ctx, key)
+	return nil
+}
+
+// DeleteAndReturn atomically removes an entry by key from the table,
+// returning the entry as it was before removal.
+// Returns NotFound error if the entry does not exist.
+func (t *Table[K, E]) DeleteAndReturn(ctx context.Context, key *K) (*E, error) {
+	if t.col == nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := deleteEntry[E](ctx, t.col, t.enc, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	t.runAfterDelete(ctx, key)
+	return entry, nil
+}
+
+// DeleteIf removes an entry by key from the table only if it also matches
+// the given filter, enabling compare-and-delete semantics, for example
+// deleting an entry only if its version is unchanged.
+// Returns NotFound error if the entry does not exist or does not match
+// the filter.
+func (t *Table[K, E]) DeleteIf(ctx context.Context, key *K, filter any) error {
+	if t.col == nil {
+		return errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	id, err := t.keys.encode(key)
+	if err != nil {
+		return err
+	}
+	_, err = deleteEntry[E](ctx, t.col, t.enc, id, filter)
+	if err != nil {
+		return err
+	}
+	t.runAfterDelete(ctx, key)
+	return nil
+}
+
+// Export streams the entries of the table to the writer as JSON lines
+// (MongoDB relaxed extended JSON), one entry per line along with its key,
+// for backups, seeding test environments or moving tenant data.
+// Encrypted fields are exported decrypted.
+// Returns the number of entries exported.
+//
+// Example usage:
+//
+//	count, err := table.Export(ctx, file,
+//	    WithSnapshotFilter(bson.M{"tenant": "acme"}),
+//	    WithSnapshotRateLimit(limits, "export"))
+func (t *Table[K, E]) Export(ctx context.Context, w io.Writer, opts ...SnapshotOption) (int64, error) {
+	if t.col == nil {
+		return 0, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	return exportEntries[E](ctx, t.col, t.enc, w, opts)
+}
+
+// Import reads the entries written by Export from the reader, creating or
+// updating each of them using Locate, stops at the first failure.
+// Returns the number of entries imported.
+func (t *Table[K, E]) Import(ctx context.Context, r io.Reader, opts ...SnapshotOption) (int64, error) {
+	if t.col == nil {
+		return 0, errors.Wrapf(errors.InvalidArgument, "Table not initialized")
+	}
+	return importEntries(ctx, &t.keys, r, opts, func(key *K, entry *E) error {
+		_, _, err := t.Locate(ctx, key, entry)
+		return err
+	})
 }
@@ -5,11 +5,14 @@ package table
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
@@ -117,6 +120,134 @@ func Test_GenericTable(t *testing.T) {
 		}
 	})
 
+	t.Run("test_locate_result", func(t *testing.T) {
+		ctx := context.Background()
+
+		key := &ProductKey{ID: "locate-001"}
+		product := &Product{
+			Name:     "Tablet",
+			Price:    500,
+			Category: "Electronics",
+			Stock:    5,
+		}
+
+		res, prev, err := productTable.Locate(ctx, key, product)
+		if err != nil {
+			t.Fatalf("failed to locate product: %s", err)
+		}
+		if res != LocateCreated || prev != nil {
+			t.Errorf("expected entry to be created, got %s, prev %v", res, prev)
+		}
+
+		product.Price = 450
+		res, prev, err = productTable.Locate(ctx, key, product)
+		if err != nil {
+			t.Fatalf("failed to locate product: %s", err)
+		}
+		if res != LocateUpdated {
+			t.Errorf("expected entry to be updated, got %s", res)
+		}
+		if prev == nil || prev.Price != 500 {
+			t.Errorf("expected previous entry with price 500, got %v", prev)
+		}
+
+		// Cleanup
+		err = productTable.DeleteKey(ctx, key)
+		if err != nil {
+			t.Errorf("failed to delete product: %s", err)
+		}
+	})
+
+	t.Run("test_find_and_update", func(t *testing.T) {
+		ctx := context.Background()
+
+		key := &ProductKey{ID: "counter-001"}
+		err := productTable.Insert(ctx, key, &Product{Name: "Counter", Stock: 10})
+		if err != nil {
+			t.Fatalf("failed to insert product: %s", err)
+		}
+
+		update := bson.M{"$inc": bson.M{"stock": -1}}
+		old, err := productTable.FindAndUpdate(ctx, key, update, false)
+		if err != nil {
+			t.Fatalf("failed to find and update product: %s", err)
+		}
+		if old.Stock != 10 {
+			t.Errorf("expected old stock 10, got %d", old.Stock)
+		}
+
+		updated, err := productTable.FindAndUpdate(ctx, key, update, true)
+		if err != nil {
+			t.Fatalf("failed to find and update product: %s", err)
+		}
+		if updated.Stock != 8 {
+			t.Errorf("expected new stock 8, got %d", updated.Stock)
+		}
+
+		_, err = productTable.FindAndUpdate(ctx, &ProductKey{ID: "counter-missing"}, update, true)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		// Cleanup
+		err = productTable.DeleteKey(ctx, key)
+		if err != nil {
+			t.Errorf("failed to delete product: %s", err)
+		}
+	})
+
+	t.Run("test_find_page", func(t *testing.T) {
+		ctx := context.Background()
+
+		for i := 0; i < 5; i++ {
+			key := &ProductKey{ID: fmt.Sprintf("page-test-%d", i)}
+			err := productTable.Insert(ctx, key, &Product{Name: key.ID, Price: 10 * (i % 3), Category: "Paging"})
+			if err != nil {
+				t.Fatalf("failed to insert product: %s", err)
+			}
+		}
+
+		filter := bson.M{"category": "Paging"}
+		sort := SortOption{Field: "price", Direction: SortAscending}
+		seen := map[string]bool{}
+		token := ""
+		pages := 0
+		lastPrice := -1
+		for {
+			list, next, err := productTable.FindPage(ctx, filter, token, 2, sort)
+			if err != nil {
+				t.Fatalf("failed to find page: %s", err)
+			}
+			pages++
+			for _, p := range list {
+				if p.Price < lastPrice {
+					t.Errorf("entries not in sort order, %d after %d", p.Price, lastPrice)
+				}
+				lastPrice = p.Price
+				if seen[p.Name] {
+					t.Errorf("entry %s returned more than once", p.Name)
+				}
+				seen[p.Name] = true
+			}
+			if next == "" {
+				break
+			}
+			token = next
+		}
+		if pages != 3 {
+			t.Errorf("expected 3 pages, got %d", pages)
+		}
+		if len(seen) != 5 {
+			t.Errorf("expected 5 entries across pages, got %d", len(seen))
+		}
+
+		// Cleanup
+		_, err := productTable.DeleteByFilter(ctx, filter)
+		if err != nil {
+			t.Errorf("failed to delete products: %s", err)
+		}
+	})
+
 	t.Run("test_sorting_single_field_ascending", func(t *testing.T) {
 		ctx := context.Background()
 
@@ -481,4 +612,56 @@ func Test_GenericTable(t *testing.T) {
 			t.Errorf("expected to delete 3 products, deleted %d", count)
 		}
 	})
+	t.Run("test_subscribe", func(t *testing.T) {
+		ctx, cancelFn := context.WithCancel(context.Background())
+		defer cancelFn()
+
+		var mu sync.Mutex
+		events := map[string]*Product{}
+		err := productTable.Subscribe(ctx, func(op string, key *ProductKey, entry *Product) {
+			mu.Lock()
+			defer mu.Unlock()
+			events[op] = entry
+		})
+		if err != nil {
+			t.Errorf("failed to subscribe to product table: %s", err)
+		}
+
+		key := &ProductKey{ID: "subscribe-1"}
+		product := &Product{
+			Name:      "Tablet",
+			Price:     500,
+			CreatedAt: time.Now(),
+		}
+		err = productTable.Insert(ctx, key, product)
+		if err != nil {
+			t.Errorf("failed to insert product: %s", err)
+		}
+
+		product.Price = 450
+		err = productTable.Update(ctx, key, product)
+		if err != nil {
+			t.Errorf("failed to update product: %s", err)
+		}
+
+		err = productTable.DeleteKey(ctx, key)
+		if err != nil {
+			t.Errorf("failed to delete product: %s", err)
+		}
+
+		// wait for the change stream events to be delivered
+		time.Sleep(1 * time.Second)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if entry, ok := events[db.MongoAddOp]; !ok || entry == nil || entry.Name != "Tablet" {
+			t.Errorf("expected insert event with full document, got %v", entry)
+		}
+		if entry, ok := events[db.MongoUpdateOp]; !ok || entry == nil || entry.Price != 450 {
+			t.Errorf("expected update event with updated document, got %v", entry)
+		}
+		if entry, ok := events[db.MongoDeleteOp]; !ok || entry != nil {
+			t.Errorf("expected delete event without document, got %v", entry)
+		}
+	})
 }
@@ -0,0 +1,87 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+	"sync"
+)
+
+// WriteHookfn is invoked before an entry is written to the database,
+// hook is allowed to mutate the entry, eg. to fill in defaults, or
+// reject the write by returning an error, which is returned as is to
+// the caller of the write operation
+type WriteHookfn[K any, E any] func(ctx context.Context, key *K, entry *E) error
+
+// DeleteHookfn is invoked once an entry is deleted from the database
+type DeleteHookfn[K any] func(ctx context.Context, key *K)
+
+// tableHooks holds the hooks registered with the table, shared by
+// Table and CachedTable
+type tableHooks[K any, E any] struct {
+	hooksMu      sync.RWMutex
+	beforeInsert []WriteHookfn[K, E]
+	beforeUpdate []WriteHookfn[K, E]
+	afterDelete  []DeleteHookfn[K]
+}
+
+// OnBeforeInsert registers a hook invoked before Insert, or Locate
+// creating the entry, writes the entry to the database. Hooks are invoked
+// in the order of registration
+func (h *tableHooks[K, E]) OnBeforeInsert(fn WriteHookfn[K, E]) {
+	h.hooksMu.Lock()
+	defer h.hooksMu.Unlock()
+	h.beforeInsert = append(h.beforeInsert, fn)
+}
+
+// OnBeforeUpdate registers a hook invoked before Update, or Locate
+// updating an existing entry, writes the entry to the database. Hooks are
+// invoked in the order of registration
+func (h *tableHooks[K, E]) OnBeforeUpdate(fn WriteHookfn[K, E]) {
+	h.hooksMu.Lock()
+	defer h.hooksMu.Unlock()
+	h.beforeUpdate = append(h.beforeUpdate, fn)
+}
+
+// OnAfterDelete registers a hook invoked after DeleteKey successfully
+// removes the entry from the database. Not invoked for DeleteByFilter,
+// as the keys of the deleted entries are not known
+func (h *tableHooks[K, E]) OnAfterDelete(fn DeleteHookfn[K]) {
+	h.hooksMu.Lock()
+	defer h.hooksMu.Unlock()
+	h.afterDelete = append(h.afterDelete, fn)
+}
+
+// runs the write hooks in order, stopping at the first error
+func applyWriteHooks[K any, E any](ctx context.Context, list []WriteHookfn[K, E], key *K, entry *E) error {
+	for _, fn := range list {
+		if err := fn(ctx, key, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *tableHooks[K, E]) runBeforeInsert(ctx context.Context, key *K, entry *E) error {
+	h.hooksMu.RLock()
+	list := h.beforeInsert
+	h.hooksMu.RUnlock()
+	return applyWriteHooks(ctx, list, key, entry)
+}
+
+func (h *tableHooks[K, E]) runBeforeUpdate(ctx context.Context, key *K, entry *E) error {
+	h.hooksMu.RLock()
+	list := h.beforeUpdate
+	h.hooksMu.RUnlock()
+	return applyWriteHooks(ctx, list, key, entry)
+}
+
+func (h *tableHooks[K, E]) runAfterDelete(ctx context.Context, key *K) {
+	h.hooksMu.RLock()
+	list := h.afterDelete
+	h.hooksMu.RUnlock()
+	for _, fn := range list {
+		fn(ctx, key)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+func Test_TableHooks(t *testing.T) {
+	ctx := context.Background()
+	tbl := &Table[ProductKey, Product]{}
+
+	order := []string{}
+	tbl.OnBeforeInsert(func(ctx context.Context, key *ProductKey, entry *Product) error {
+		order = append(order, "default")
+		if entry.Category == "" {
+			entry.Category = "General"
+		}
+		return nil
+	})
+	tbl.OnBeforeInsert(func(ctx context.Context, key *ProductKey, entry *Product) error {
+		order = append(order, "validate")
+		if entry.Price < 0 {
+			return errors.Wrapf(errors.InvalidArgument, "invalid price %d", entry.Price)
+		}
+		return nil
+	})
+
+	t.Run("mutate_entry", func(t *testing.T) {
+		order = order[:0]
+		entry := &Product{Name: "Pen", Price: 2}
+		err := tbl.runBeforeInsert(ctx, &ProductKey{ID: "pen"}, entry)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if entry.Category != "General" {
+			t.Errorf("expected default category to be filled, got %q", entry.Category)
+		}
+		if len(order) != 2 || order[0] != "default" || order[1] != "validate" {
+			t.Errorf("hooks not invoked in registration order: %v", order)
+		}
+	})
+
+	t.Run("reject_entry", func(t *testing.T) {
+		err := tbl.runBeforeInsert(ctx, &ProductKey{ID: "pen"}, &Product{Name: "Pen", Price: -1})
+		if !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument error, got %v", err)
+		}
+	})
+
+	t.Run("update_and_delete", func(t *testing.T) {
+		err := tbl.runBeforeUpdate(ctx, &ProductKey{ID: "pen"}, &Product{Price: -1})
+		if err != nil {
+			t.Errorf("insert hooks must not be invoked on update: %s", err)
+		}
+
+		var deleted *ProductKey
+		tbl.OnAfterDelete(func(ctx context.Context, key *ProductKey) {
+			deleted = key
+		})
+		key := &ProductKey{ID: "pen"}
+		tbl.runAfterDelete(ctx, key)
+		if deleted != key {
+			t.Errorf("delete hook not invoked with the key")
+		}
+	})
+}
+
+func Test_TableLocateHooks(t *testing.T) {
+	ctx := context.Background()
+	tbl := &Table[ProductKey, Product]{}
+	err := tbl.Initialize(db.NewMemoryCollection("products"))
+	if err != nil {
+		t.Fatalf("failed to initialize table: %s", err)
+	}
+
+	inserts, updates := 0, 0
+	tbl.OnBeforeInsert(func(ctx context.Context, key *ProductKey, entry *Product) error {
+		inserts++
+		if entry.Price < 0 {
+			return errors.Wrapf(errors.InvalidArgument, "invalid price %d", entry.Price)
+		}
+		if entry.Category == "" {
+			entry.Category = "General"
+		}
+		return nil
+	})
+	tbl.OnBeforeUpdate(func(ctx context.Context, key *ProductKey, entry *Product) error {
+		updates++
+		return nil
+	})
+
+	t.Run("create", func(t *testing.T) {
+		key := &ProductKey{ID: "locate-hooks"}
+		entry := &Product{Name: "Pen", Price: 2}
+		res, _, err := tbl.Locate(ctx, key, entry)
+		if err != nil || res != LocateCreated {
+			t.Fatalf("unexpected locate result %s, err %v", res, err)
+		}
+		if inserts != 1 || updates != 0 {
+			t.Errorf("expected only insert hooks on create, got %d inserts, %d updates", inserts, updates)
+		}
+		if entry.Category != "General" {
+			t.Errorf("expected default to be filled in the entry, got %q", entry.Category)
+		}
+		stored, err := tbl.Find(ctx, key)
+		if err != nil || stored.Category != "General" {
+			t.Errorf("expected default to be stored, got %v, err %v", stored, err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		key := &ProductKey{ID: "locate-hooks"}
+		res, prev, err := tbl.Locate(ctx, key, &Product{Name: "Pen", Price: -1})
+		if err != nil || res != LocateUpdated || prev == nil || prev.Price != 2 {
+			t.Fatalf("unexpected locate result %s, prev %v, err %v", res, prev, err)
+		}
+		if inserts != 1 || updates != 1 {
+			t.Errorf("expected only update hooks on update, got %d inserts, %d updates", inserts, updates)
+		}
+	})
+
+	t.Run("reject_create", func(t *testing.T) {
+		key := &ProductKey{ID: "locate-invalid"}
+		_, _, err := tbl.Locate(ctx, key, &Product{Name: "Pen", Price: -1})
+		if !errors.IsInvalidArgument(err) {
+			t.Errorf("expected insert hook to reject the entry, got %v", err)
+		}
+		_, err = tbl.Find(ctx, key)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected rejected entry not to be created, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,271 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+// KeyCodec customizes how table keys are stored as the `_id` document in
+// the database, instead of relying on the default bson marshalling of the
+// key structure. Codec must produce the same document for equal keys, as
+// the document is matched as a whole while looking up an entry.
+type KeyCodec interface {
+	// EncodeKey converts the key, passed as pointer to the key type, to
+	// the document stored as `_id`
+	EncodeKey(key any) (bson.D, error)
+
+	// DecodeKey decodes the `_id` document into the key, passed as
+	// pointer to the key type
+	DecodeKey(doc bson.D, key any) error
+}
+
+// WithKeyCodec configures the codec used for encoding the table keys as
+// `_id` documents.
+//
+// Example usage:
+//
+//	err := table.InitializeWithConfig(col, WithKeyCodec(&StrictKeyCodec{}))
+func WithKeyCodec(codec KeyCodec) TableOption {
+	return func(cfg *TableConfig) {
+		cfg.KeyCodec = codec
+	}
+}
+
+// StrictKeyCodec encodes every exported field of a struct key in the order
+// of declaration, ignoring omitempty in the bson tags. This ensures that
+// keys differing only in zero valued fields never collide, and that field
+// order remains stable for matching the `_id` document.
+type StrictKeyCodec struct {
+	// PreserveCase keeps the field names as declared, instead of the
+	// lowercase names used by default bson marshalling. Names provided
+	// via bson tags are always used as is
+	PreserveCase bool
+}
+
+// returns the document field name for the struct field, skip is set for
+// fields that should not be part of the key document
+func (c *StrictKeyCodec) fieldName(f reflect.StructField) (name string, skip bool) {
+	if !f.IsExported() {
+		return "", true
+	}
+	tag := f.Tag.Get("bson")
+	if tag == "-" {
+		return "", true
+	}
+	name, _, _ = strings.Cut(tag, ",")
+	if name != "" {
+		return name, false
+	}
+	if c.PreserveCase {
+		return f.Name, false
+	}
+	return strings.ToLower(f.Name), false
+}
+
+// EncodeKey converts the struct key to the `_id` document
+func (c *StrictKeyCodec) EncodeKey(key any) (bson.D, error) {
+	val := reflect.ValueOf(key)
+	if val.Kind() != reflect.Pointer || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return nil, errors.Wrapf(errors.InvalidArgument, "StrictKeyCodec: key must be a pointer to struct, got %T", key)
+	}
+	val = val.Elem()
+	doc := bson.D{}
+	for i := 0; i < val.NumField(); i++ {
+		name, skip := c.fieldName(val.Type().Field(i))
+		if skip {
+			continue
+		}
+		doc = append(doc, bson.E{Key: name, Value: val.Field(i).Interface()})
+	}
+	return doc, nil
+}
+
+// DecodeKey decodes the `_id` document into the struct key
+func (c *StrictKeyCodec) DecodeKey(doc bson.D, key any) error {
+	val := reflect.ValueOf(key)
+	if val.Kind() != reflect.Pointer || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return errors.Wrapf(errors.InvalidArgument, "StrictKeyCodec: key must be a pointer to struct, got %T", key)
+	}
+	values := map[string]any{}
+	for _, e := range doc {
+		values[e.Key] = e.Value
+	}
+	val = val.Elem()
+	for i := 0; i < val.NumField(); i++ {
+		name, skip := c.fieldName(val.Type().Field(i))
+		if skip {
+			continue
+		}
+		v, ok := values[name]
+		if !ok {
+			continue
+		}
+		// round trip the value via bson to convert it to the field type
+		raw, err := bson.Marshal(bson.D{{Key: "v", Value: v}})
+		if err != nil {
+			return err
+		}
+		field := reflect.New(val.Field(i).Type())
+		err = bson.Raw(raw).Lookup("v").Unmarshal(field.Interface())
+		if err != nil {
+			return errors.Wrapf(errors.InvalidArgument, "StrictKeyCodec: failed to decode field %s: %s", name, err)
+		}
+		val.Field(i).Set(field.Elem())
+	}
+	return nil
+}
+
+// KeyPrefixFilter builds a filter matching all the entries whose key starts
+// with the given fields, allowing to work with hierarchical composite keys,
+// eg. all entries of a tenant for key {Tenant, Name}. Field names should be
+// as stored in the key document
+//
+//	filter := KeyPrefixFilter(bson.E{Key: "tenant", Value: "t1"})
+func KeyPrefixFilter(fields ...bson.E) bson.D {
+	return db.KeyFilter(fields...)
+}
+
+// KeyRangeFilter builds a filter matching the entries with the given key
+// field in the range [from, to), on top of the prefix fields. Either of
+// from or to can be nil for an open range
+//
+//	filter := KeyRangeFilter("name", "a", "m", bson.E{Key: "tenant", Value: "t1"})
+func KeyRangeFilter(field string, from, to any, prefix ...bson.E) bson.D {
+	filter := db.KeyFilter(prefix...)
+	if from == nil && to == nil {
+		return filter
+	}
+	return append(filter, db.KeyRange(field, from, to))
+}
+
+// keyMapper maps the table keys to the values used with the database,
+// working with the key codec if one is configured
+type keyMapper[K any] struct {
+	codec KeyCodec
+}
+
+// returns the key type to be registered with the collection
+func (m *keyMapper[K]) keyType() reflect.Type {
+	if m.codec != nil {
+		return reflect.TypeOf(&bson.D{})
+	}
+	var k K
+	return reflect.PointerTo(reflect.TypeOf(k))
+}
+
+// encodes the key into the value used as `_id`
+func (m *keyMapper[K]) encode(key *K) (any, error) {
+	if m.codec == nil || key == nil {
+		return key, nil
+	}
+	doc, err := m.codec.EncodeKey(key)
+	if err != nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "failed to encode key: %s", err)
+	}
+	return doc, nil
+}
+
+// decodes the key notified by the collection watch
+func (m *keyMapper[K]) decode(wKey any) (*K, error) {
+	if m.codec == nil {
+		key, ok := wKey.(*K)
+		if !ok {
+			return nil, errors.Wrapf(errors.InvalidArgument, "unexpected key type %T", wKey)
+		}
+		return key, nil
+	}
+	doc, ok := wKey.(*bson.D)
+	if !ok {
+		return nil, errors.Wrapf(errors.InvalidArgument, "unexpected key type %T", wKey)
+	}
+	return m.decodeDoc(*doc)
+}
+
+// decodes the `_id` document using the codec
+func (m *keyMapper[K]) decodeDoc(doc bson.D) (*K, error) {
+	key := new(K)
+	err := m.codec.DecodeKey(doc, key)
+	if err != nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "failed to decode key: %s", err)
+	}
+	return key, nil
+}
+
+// decodes the raw `_id` value stored in the collection
+func (m *keyMapper[K]) decodeRaw(raw bson.RawValue) (*K, error) {
+	if m.codec == nil {
+		key := new(K)
+		err := raw.Unmarshal(key)
+		if err != nil {
+			return nil, errors.Wrapf(errors.InvalidArgument, "failed to decode key: %s", err)
+		}
+		return key, nil
+	}
+	var doc bson.D
+	err := raw.Unmarshal(&doc)
+	if err != nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "failed to decode key: %s", err)
+	}
+	return m.decodeDoc(doc)
+}
+
+// fetches all the keys from the collection matching the filter
+func (m *keyMapper[K]) allKeys(ctx context.Context, col db.StoreCollection, filter any) ([]*K, error) {
+	keys := []*K{}
+	if m.codec == nil {
+		list := []keyOnly[K]{}
+		err := col.FindMany(ctx, filter, &list)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list {
+			keys = append(keys, &list[i].Key)
+		}
+		return keys, nil
+	}
+	list := []keyOnly[bson.D]{}
+	err := col.FindMany(ctx, filter, &list)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range list {
+		key, err := m.decodeDoc(k.Key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// watchTableEvents subscribes to the typed change events for the
+// collection, decoding the keys using the codec if configured
+func watchTableEvents[K any, E any](ctx context.Context, m *keyMapper[K], col db.StoreCollection, filter any, fn func(op string, key *K, entry *E)) error {
+	if m.codec == nil {
+		return db.WatchEvents(ctx, col, filter, func(e *db.Event[K, E]) {
+			fn(e.Op, e.Doc.Key, e.Entry)
+		})
+	}
+	return db.WatchEvents(ctx, col, filter, func(e *db.Event[bson.D, E]) {
+		var key *K
+		if e.Doc.Key != nil {
+			var err error
+			key, err = m.decodeDoc(*e.Doc.Key)
+			if err != nil {
+				log.Printf("failed to decode key %v: %s", *e.Doc.Key, err)
+				return
+			}
+		}
+		fn(e.Op, key, e.Entry)
+	})
+}
@@ -0,0 +1,91 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type compositeKey struct {
+	Tenant string `bson:"tenant,omitempty"`
+	Name   string `bson:",omitempty"`
+	Index  int    `bson:",omitempty"`
+}
+
+func Test_StrictKeyCodec(t *testing.T) {
+	codec := &StrictKeyCodec{}
+
+	t.Run("encode_zero_fields", func(t *testing.T) {
+		doc, err := codec.EncodeKey(&compositeKey{Tenant: "t1"})
+		if err != nil {
+			t.Fatalf("failed to encode key: %s", err)
+		}
+		expected := bson.D{
+			{Key: "tenant", Value: "t1"},
+			{Key: "name", Value: ""},
+			{Key: "index", Value: 0},
+		}
+		if !reflect.DeepEqual(doc, expected) {
+			t.Errorf("expected %v, got %v", expected, doc)
+		}
+	})
+
+	t.Run("preserve_case", func(t *testing.T) {
+		doc, err := (&StrictKeyCodec{PreserveCase: true}).EncodeKey(&compositeKey{Name: "n1"})
+		if err != nil {
+			t.Fatalf("failed to encode key: %s", err)
+		}
+		if doc[0].Key != "tenant" || doc[1].Key != "Name" || doc[2].Key != "Index" {
+			t.Errorf("unexpected field names %v", doc)
+		}
+	})
+
+	t.Run("round_trip", func(t *testing.T) {
+		key := &compositeKey{Tenant: "t1", Name: "n1", Index: 3}
+		doc, err := codec.EncodeKey(key)
+		if err != nil {
+			t.Fatalf("failed to encode key: %s", err)
+		}
+
+		// ensure decoding works with the values as read back from db
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			t.Fatalf("failed to marshal key: %s", err)
+		}
+		var stored bson.D
+		if err = bson.Unmarshal(raw, &stored); err != nil {
+			t.Fatalf("failed to unmarshal key: %s", err)
+		}
+
+		m := &keyMapper[compositeKey]{codec: codec}
+		decoded, err := m.decode(&stored)
+		if err != nil {
+			t.Fatalf("failed to decode key: %s", err)
+		}
+		if *decoded != *key {
+			t.Errorf("expected %v, got %v", *key, *decoded)
+		}
+	})
+
+	t.Run("invalid_key", func(t *testing.T) {
+		_, err := codec.EncodeKey("not-a-struct")
+		if err == nil {
+			t.Errorf("expected error encoding non struct key")
+		}
+	})
+}
+
+func Test_KeyFilters(t *testing.T) {
+	filter := KeyRangeFilter("name", "a", nil, bson.E{Key: "tenant", Value: "t1"})
+	expected := bson.D{
+		{Key: "_id.tenant", Value: "t1"},
+		{Key: "_id.name", Value: bson.D{{Key: "$gte", Value: "a"}}},
+	}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Errorf("expected %v, got %v", expected, filter)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+	"github.com/go-core-stack/core/utils"
+)
+
+// LocateResult reports the outcome of a Locate operation
+type LocateResult int
+
+const (
+	// entry did not exist and was created as part of Locate
+	LocateCreated LocateResult = iota
+
+	// entry already existed and was updated as part of Locate
+	LocateUpdated
+)
+
+// String returns a readable form of the locate result
+func (r LocateResult) String() string {
+	switch r {
+	case LocateCreated:
+		return "created"
+	case LocateUpdated:
+		return "updated"
+	}
+	return "unknown"
+}
+
+// validates the fields set only on insert, expected to be top level
+// field names
+func checkInsertFields(onInsert map[string]any) error {
+	for field := range onInsert {
+		if field == "" || strings.Contains(field, ".") || strings.HasPrefix(field, "$") {
+			return errors.Wrapf(errors.InvalidArgument, "invalid insert only field %q, expected a top level field name", field)
+		}
+	}
+	return nil
+}
+
+// encodes the entry as a document
+func entryDocument[E any](entry *E) (bson.D, error) {
+	raw, err := bson.Marshal(entry)
+	if err != nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "failed to encode entry: %s", err)
+	}
+	var doc bson.D
+	err = bson.Unmarshal(raw, &doc)
+	if err != nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "failed to encode entry: %s", err)
+	}
+	return doc, nil
+}
+
+// builds the document inserted by locate, where the fields set only on
+// insert take precedence over the ones in the entry
+func locateInsert[E any](entry *E, onInsert map[string]any) (any, error) {
+	if len(onInsert) == 0 {
+		return entry, nil
+	}
+	err := checkInsertFields(onInsert)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := entryDocument(entry)
+	if err != nil {
+		return nil, err
+	}
+	insert := bson.D{}
+	for _, e := range doc {
+		if _, ok := onInsert[e.Key]; !ok {
+			insert = append(insert, e)
+		}
+	}
+	fields := make([]string, 0, len(onInsert))
+	for field := range onInsert {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		insert = append(insert, bson.E{Key: field, Value: onInsert[field]})
+	}
+	return insert, nil
+}
+
+// builds the update document for locate, where the fields set only on
+// insert are excluded from the fields set on every update, as the same
+// field cannot be part of both $set and $setOnInsert
+func locateUpdate[E any](entry *E, onInsert map[string]any) (bson.D, error) {
+	if len(onInsert) == 0 {
+		return bson.D{{Key: "$set", Value: entry}}, nil
+	}
+	err := checkInsertFields(onInsert)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := entryDocument(entry)
+	if err != nil {
+		return nil, err
+	}
+	set := bson.D{}
+	for _, e := range doc {
+		if _, ok := onInsert[e.Key]; !ok {
+			set = append(set, e)
+		}
+	}
+	update := bson.D{{Key: "$setOnInsert", Value: onInsert}}
+	if len(set) != 0 {
+		update = append(update, bson.E{Key: "$set", Value: set})
+	}
+	return update, nil
+}
+
+// locateEntry creates the entry for the key if it doesn't exist, or
+// updates it otherwise, returning whether the entry was created or
+// updated along with the previous state of the entry if it existed.
+// Insert hooks are run when the entry is created and update hooks when
+// it is updated, so Locate can't bypass the validation and defaults of
+// either. If the entry is created or deleted concurrently, the write
+// fails and the operation is attempted again, running the hooks again
+// on a fresh copy of the entry.
+// fields in onInsert are set only when the entry is created and are
+// never overwritten by the entry on subsequent updates
+func locateEntry[K any, E any](ctx context.Context, h *tableHooks[K, E], col db.StoreCollection, enc utils.IOEncryptor, key *K, id any, entry *E, onInsert map[string]any) (LocateResult, *E, error) {
+	for {
+		var cur bson.D
+		err := col.FindOne(ctx, id, &cur)
+		if err != nil && !errors.IsNotFound(err) {
+			return LocateCreated, nil, err
+		}
+		if err != nil {
+			err = locateCreate(ctx, h, col, enc, key, id, entry, onInsert)
+			if err == nil {
+				return LocateCreated, nil, nil
+			}
+			if !errors.IsAlreadyExists(err) {
+				return LocateCreated, nil, err
+			}
+		} else {
+			prev, err := locateModify(ctx, h, col, enc, key, id, entry, onInsert)
+			if err == nil {
+				return LocateUpdated, prev, nil
+			}
+			if !errors.IsNotFound(err) {
+				return LocateUpdated, nil, err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return LocateCreated, nil, err
+		}
+	}
+}
+
+// inserts the entry after running the insert hooks, returning
+// AlreadyExists error if the entry exists
+func locateCreate[K any, E any](ctx context.Context, h *tableHooks[K, E], col db.StoreCollection, enc utils.IOEncryptor, key *K, id any, entry *E, onInsert map[string]any) error {
+	// hooks work on a copy, which is handed back to the caller only
+	// if the entry is created
+	ins := *entry
+	err := h.runBeforeInsert(ctx, key, &ins)
+	if err != nil {
+		return err
+	}
+	data, err := encryptEntry(enc, &ins)
+	if err != nil {
+		return err
+	}
+	doc, err := locateInsert(data, onInsert)
+	if err != nil {
+		return err
+	}
+	err = col.InsertOne(ctx, id, doc)
+	if err != nil {
+		return err
+	}
+	*entry = ins
+	return nil
+}
+
+// updates the existing entry after running the update hooks, returning
+// the entry before the update, or NotFound error if the entry doesn't
+// exist
+func locateModify[K any, E any](ctx context.Context, h *tableHooks[K, E], col db.StoreCollection, enc utils.IOEncryptor, key *K, id any, entry *E, onInsert map[string]any) (*E, error) {
+	upd := *entry
+	err := h.runBeforeUpdate(ctx, key, &upd)
+	if err != nil {
+		return nil, err
+	}
+	data, err := encryptEntry(enc, &upd)
+	if err != nil {
+		return nil, err
+	}
+	update, err := locateUpdate(data, onInsert)
+	if err != nil {
+		return nil, err
+	}
+
+	// update only if the entry exists, returning the document state
+	// before the update
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+	var prev E
+	err = col.FindOneAndUpdate(ctx, id, update, &prev, opts)
+	if err != nil {
+		return nil, err
+	}
+	*entry = upd
+
+	err = decryptEntry(enc, &prev)
+	if err != nil {
+		return nil, err
+	}
+	return &prev, nil
+}
@@ -0,0 +1,283 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+// waits for the condition to be satisfied, failing the test on timeout
+func waitFor(t *testing.T, msg string, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", msg)
+}
+
+func Test_MemoryBackedTable(t *testing.T) {
+	ctx := context.Background()
+
+	tbl := &Table[ProductKey, Product]{}
+	err := tbl.Initialize(db.NewMemoryCollection("products"))
+	if err != nil {
+		t.Fatalf("failed to initialize table: %s", err)
+	}
+
+	t.Run("crud", func(t *testing.T) {
+		key := &ProductKey{ID: "mem-001"}
+		err := tbl.Insert(ctx, key, &Product{Name: "Pen", Price: 2})
+		if err != nil {
+			t.Fatalf("failed to insert: %s", err)
+		}
+
+		res, prev, err := tbl.Locate(ctx, key, &Product{Name: "Pen", Price: 3})
+		if err != nil || res != LocateUpdated || prev == nil || prev.Price != 2 {
+			t.Errorf("unexpected locate result %s, prev %v, err %v", res, prev, err)
+		}
+
+		entry, err := tbl.FindAndUpdate(ctx, key, bson.M{"$inc": bson.M{"stock": 5}}, true)
+		if err != nil || entry.Stock != 5 || entry.Price != 3 {
+			t.Errorf("unexpected find and update result %v, err %v", entry, err)
+		}
+
+		err = tbl.DeleteKey(ctx, key)
+		if err != nil {
+			t.Errorf("failed to delete: %s", err)
+		}
+		_, err = tbl.Find(ctx, key)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("locate_with_insert_fields", func(t *testing.T) {
+		key := &ProductKey{ID: "mem-created"}
+		created := time.UnixMilli(1700000000000).UTC()
+		res, _, err := tbl.LocateWithInsertFields(ctx, key, &Product{Name: "First"},
+			map[string]any{"createdat": created})
+		if err != nil || res != LocateCreated {
+			t.Fatalf("unexpected locate result %s, err %v", res, err)
+		}
+		res, prev, err := tbl.LocateWithInsertFields(ctx, key, &Product{Name: "Second"},
+			map[string]any{"createdat": time.Now()})
+		if err != nil || res != LocateUpdated || prev.Name != "First" {
+			t.Fatalf("unexpected locate result %s, prev %v, err %v", res, prev, err)
+		}
+		entry, err := tbl.Find(ctx, key)
+		if err != nil || entry.Name != "Second" || !entry.CreatedAt.Equal(created) {
+			t.Errorf("expected creation time to be retained, got %v, err %v", entry, err)
+		}
+
+		_, _, err = tbl.LocateWithInsertFields(ctx, key, &Product{}, map[string]any{"meta.createdat": created})
+		if !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for nested field, got %v", err)
+		}
+	})
+
+	t.Run("delete_and_return", func(t *testing.T) {
+		key := &ProductKey{ID: "mem-del"}
+		_ = tbl.Insert(ctx, key, &Product{Name: "Del", Price: 4})
+		entry, err := tbl.DeleteAndReturn(ctx, key)
+		if err != nil || entry.Name != "Del" || entry.Price != 4 {
+			t.Errorf("unexpected deleted entry %v, err %v", entry, err)
+		}
+		_, err = tbl.DeleteAndReturn(ctx, key)
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("delete_if", func(t *testing.T) {
+		key := &ProductKey{ID: "mem-cas"}
+		_ = tbl.Insert(ctx, key, &Product{Name: "Cas", Price: 7})
+		err := tbl.DeleteIf(ctx, key, bson.M{"price": 8})
+		if !errors.IsNotFound(err) {
+			t.Errorf("expected not found error for unmatched condition, got %v", err)
+		}
+		if _, err := tbl.Find(ctx, key); err != nil {
+			t.Errorf("expected entry to be retained: %s", err)
+		}
+		err = tbl.DeleteIf(ctx, key, bson.M{"price": 7})
+		if err != nil {
+			t.Errorf("failed to delete: %s", err)
+		}
+		if _, err := tbl.Find(ctx, key); !errors.IsNotFound(err) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("find_page", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			key := &ProductKey{ID: fmt.Sprintf("mem-page-%d", i)}
+			err := tbl.Insert(ctx, key, &Product{Name: key.ID, Price: 10 * (i % 2), Category: "paging"})
+			if err != nil {
+				t.Fatalf("failed to insert: %s", err)
+			}
+		}
+		names := []string{}
+		token := ""
+		for {
+			list, next, err := tbl.FindPage(ctx, bson.M{"category": "paging"}, token, 2,
+				SortOption{Field: "price", Direction: SortDescending})
+			if err != nil {
+				t.Fatalf("failed to find page: %s", err)
+			}
+			for _, p := range list {
+				names = append(names, p.Name)
+			}
+			if next == "" {
+				break
+			}
+			token = next
+		}
+		expected := "[mem-page-1 mem-page-3 mem-page-0 mem-page-2 mem-page-4]"
+		if fmt.Sprint(names) != expected {
+			t.Errorf("expected %s, got %v", expected, names)
+		}
+	})
+
+	t.Run("subscribe", func(t *testing.T) {
+		subCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		ops := make(chan string, 10)
+		err := tbl.Subscribe(subCtx, func(op string, key *ProductKey, entry *Product) {
+			ops <- op + ":" + key.ID
+		})
+		if err != nil {
+			t.Fatalf("failed to subscribe: %s", err)
+		}
+
+		key := &ProductKey{ID: "mem-sub"}
+		_ = tbl.Insert(ctx, key, &Product{Name: "Sub"})
+		_ = tbl.DeleteKey(ctx, key)
+		for _, want := range []string{"insert:mem-sub", "delete:mem-sub"} {
+			select {
+			case got := <-ops:
+				if got != want {
+					t.Errorf("expected %s, got %s", want, got)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("timed out waiting for %s", want)
+			}
+		}
+	})
+}
+
+func Test_MemoryBackedCachedTable(t *testing.T) {
+	ctx := context.Background()
+
+	col := db.NewMemoryCollection("cached-products")
+	err := col.InsertOne(ctx, &ProductKey{ID: "preloaded"}, &Product{Name: "Preloaded"})
+	if err != nil {
+		t.Fatalf("failed to insert: %s", err)
+	}
+
+	tbl := &CachedTable[ProductKey, Product]{}
+	err = tbl.InitializeWithConfig(col, WithTableOptions(WithKeyCodec(&StrictKeyCodec{})))
+	if err != nil {
+		t.Fatalf("failed to initialize table: %s", err)
+	}
+
+	if _, err := tbl.Find(ctx, &ProductKey{ID: "preloaded"}); err != nil {
+		t.Errorf("expected entry to be eagerly loaded: %s", err)
+	}
+
+	key := &ProductKey{ID: "cached"}
+	err = tbl.Insert(ctx, key, &Product{Name: "Cached", Price: 10})
+	if err != nil {
+		t.Fatalf("failed to insert: %s", err)
+	}
+	waitFor(t, "cache update", func() bool {
+		entry, err := tbl.Find(ctx, key)
+		return err == nil && entry.Price == 10
+	})
+
+	err = tbl.DeleteKey(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to delete: %s", err)
+	}
+	waitFor(t, "cache delete", func() bool {
+		_, err := tbl.Find(ctx, key)
+		return errors.IsNotFound(err)
+	})
+
+	stats := tbl.CacheStats()
+	if stats.WatchUpdates == 0 || stats.WatchDeletes == 0 || stats.Size != 1 {
+		t.Errorf("unexpected cache stats %+v", stats)
+	}
+}
+
+func Test_MemoryBackedCacheExists(t *testing.T) {
+	ctx := context.Background()
+
+	col := db.NewMemoryCollection("cached-exists")
+	for i := 0; i < 3; i++ {
+		err := col.InsertOne(ctx, &ProductKey{ID: fmt.Sprintf("exists-%d", i)}, &Product{Price: i})
+		if err != nil {
+			t.Fatalf("failed to insert: %s", err)
+		}
+	}
+
+	eager := &CachedTable[ProductKey, Product]{}
+	if err := eager.Initialize(col); err != nil {
+		t.Fatalf("failed to initialize table: %s", err)
+	}
+	if count := eager.CacheCount(nil); count != 3 {
+		t.Errorf("expected 3 entries in cache, got %d", count)
+	}
+	count := eager.CacheCount(func(key *ProductKey, entry *Product) bool {
+		return entry.Price > 0
+	})
+	if count != 2 {
+		t.Errorf("expected 2 matching entries in cache, got %d", count)
+	}
+	if ok, err := eager.Exists(ctx, &ProductKey{ID: "exists-1"}); !ok || err != nil {
+		t.Errorf("expected entry to exist, got %v, err %v", ok, err)
+	}
+	if ok, _ := eager.Exists(ctx, &ProductKey{ID: "missing"}); ok {
+		t.Errorf("expected entry to not exist")
+	}
+
+	lazy := &CachedTable[ProductKey, Product]{}
+	if err := lazy.InitializeWithConfig(col, WithReadThrough()); err != nil {
+		t.Fatalf("failed to initialize table: %s", err)
+	}
+	if ok, err := lazy.Exists(ctx, &ProductKey{ID: "exists-2"}); !ok || err != nil {
+		t.Errorf("expected entry to exist in db, got %v, err %v", ok, err)
+	}
+	if count := lazy.CacheCount(nil); count != 0 {
+		t.Errorf("expected Exists to not populate the cache, got %d entries", count)
+	}
+}
+
+func Test_MemoryBackedTableConsistency(t *testing.T) {
+	tbl := &Table[ProductKey, Product]{}
+	err := tbl.InitializeWithConfig(db.NewMemoryCollection("consistency"), WithReadPreference("secondaryOnly"))
+	if !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument error, got %v", err)
+	}
+
+	cached := &CachedTable[ProductKey, Product]{}
+	err = cached.InitializeWithConfig(db.NewMemoryCollection("consistency"),
+		WithTableOptions(
+			WithReadPreference(db.ReadSecondaryPreferred),
+			WithReadConcern(db.ReadConcernMajority),
+			WithWriteConcern(db.WriteConcernMajority)))
+	if err != nil {
+		t.Errorf("failed to initialize table: %s", err)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+	"io"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+	"github.com/go-core-stack/core/sync"
+)
+
+// MigrationFunc performs a migration on the collection backing the table
+type MigrationFunc func(ctx context.Context, col db.StoreCollection) error
+
+// Migration describes a single schema migration for a table
+type Migration struct {
+	// Version orders the migrations, migrations are applied in the
+	// increasing order of version and each version is applied only once
+	Version int
+
+	// Name describes the migration, recorded along with the version
+	Name string
+
+	// Apply performs the migration, it should be idempotent, as it may be
+	// retried if the replica fails before recording the migration
+	Apply MigrationFunc
+}
+
+// locker coordinating the migration runners using the sync lock tables,
+// located only while running the migrations, as it requires the sync
+// owner infra to be initialized
+type migrationLocker struct {
+	store db.Store
+}
+
+func (l *migrationLocker) TryAcquire(ctx context.Context, name string) (io.Closer, error) {
+	locker, err := sync.NewMigrationLocker(l.store)
+	if err != nil {
+		return nil, err
+	}
+	return locker.TryAcquire(ctx, name)
+}
+
+// Migrator runs the ordered schema migrations for a table exactly once
+// across all the replicas, using the db.Migrator with the migrations
+// scoped to the table. Runners are coordinated using sync locks, which
+// requires the sync owner infra to be initialized before running the
+// migrations.
+type Migrator struct {
+	*db.Migrator
+}
+
+// NewMigrator creates a migrator for the table backed by the given
+// collection name in the store
+func NewMigrator(store db.Store, table string, migrations ...Migration) (*Migrator, error) {
+	return newMigrator(store, table, &migrationLocker{store: store}, migrations...)
+}
+
+func newMigrator(store db.Store, table string, locker db.MigrationLocker, migrations ...Migration) (*Migrator, error) {
+	if table == "" {
+		return nil, errors.Wrapf(errors.InvalidArgument, "Migrator: table name is not specified")
+	}
+	list := []db.Migration{}
+	for _, m := range migrations {
+		mig := db.Migration{Version: m.Version, Name: m.Name}
+		if m.Apply != nil {
+			apply := m.Apply
+			mig.Apply = func(ctx context.Context, store db.Store) error {
+				return apply(ctx, store.GetCollection(table))
+			}
+		}
+		list = append(list, mig)
+	}
+	m, err := db.NewMigrator(store, "table/"+table, locker, list...)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{Migrator: m}, nil
+}
+
+// updates every document matching the filter with the given update
+func updateMatching(ctx context.Context, col db.StoreCollection, filter any, update bson.D) error {
+	list := []keyOnly[bson.RawValue]{}
+	err := col.FindMany(ctx, filter, &list)
+	if err != nil {
+		return err
+	}
+	for _, k := range list {
+		var doc bson.D
+		err = col.FindOneAndUpdate(ctx, k.Key, update, &doc)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameField returns a migration renaming the field in all the entries
+func RenameField(from, to string) MigrationFunc {
+	return func(ctx context.Context, col db.StoreCollection) error {
+		filter := bson.D{{Key: from, Value: bson.D{{Key: "$exists", Value: true}}}}
+		list := []bson.Raw{}
+		err := col.FindMany(ctx, filter, &list)
+		if err != nil {
+			return err
+		}
+		for _, doc := range list {
+			val, err := doc.LookupErr(from)
+			if err != nil {
+				continue
+			}
+			update := bson.D{
+				{Key: "$set", Value: bson.D{{Key: to, Value: val}}},
+				{Key: "$unset", Value: bson.D{{Key: from, Value: ""}}},
+			}
+			var res bson.D
+			err = col.FindOneAndUpdate(ctx, doc.Lookup("_id"), update, &res)
+			if err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// BackfillField returns a migration setting the field to the given value
+// in all the entries where the field is not present
+func BackfillField(field string, value any) MigrationFunc {
+	return func(ctx context.Context, col db.StoreCollection) error {
+		filter := bson.D{{Key: field, Value: bson.D{{Key: "$exists", Value: false}}}}
+		update := bson.D{{Key: "$set", Value: bson.D{{Key: field, Value: value}}}}
+		return updateMatching(ctx, col, filter, update)
+	}
+}
+
+// RebuildIndexes returns a migration ensuring the given indexes exist on
+// the collection
+func RebuildIndexes(indexes ...db.IndexDefinition) MigrationFunc {
+	return func(ctx context.Context, col db.StoreCollection) error {
+		return col.EnsureIndexes(ctx, indexes)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+type legacyProduct struct {
+	Title string
+	Price int
+}
+
+// in process locker, standing in for the sync lock tables
+type testMigrationLocker struct{}
+
+func (l *testMigrationLocker) TryAcquire(ctx context.Context, name string) (io.Closer, error) {
+	return io.NopCloser(nil), nil
+}
+
+func Test_MigratorApply(t *testing.T) {
+	ctx := context.Background()
+
+	store := db.NewMemoryClient().GetDataStore("test-db")
+	col := store.GetCollection("migrate-products")
+	for _, id := range []string{"p1", "p2"} {
+		err := col.InsertOne(ctx, &ProductKey{ID: id}, &legacyProduct{Title: "title-" + id, Price: 1})
+		if err != nil {
+			t.Fatalf("failed to insert: %s", err)
+		}
+	}
+
+	calls := 0
+	migrations := []Migration{
+		{Version: 1, Name: "rename title", Apply: RenameField("title", "name")},
+		{Version: 2, Name: "backfill category", Apply: BackfillField("category", "general")},
+		{Version: 3, Name: "count", Apply: func(ctx context.Context, col db.StoreCollection) error {
+			calls++
+			return nil
+		}},
+	}
+	if _, err := newMigrator(store, "", &testMigrationLocker{}, migrations...); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument without table name, got %v", err)
+	}
+	if _, err := newMigrator(store, "migrate-products", &testMigrationLocker{}, Migration{Version: 1}); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument without apply function, got %v", err)
+	}
+
+	m, err := newMigrator(store, "migrate-products", &testMigrationLocker{}, migrations...)
+	if err != nil {
+		t.Fatalf("failed to create migrator: %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := m.Run(ctx); err != nil {
+			t.Fatalf("failed to apply migrations: %s", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected migration to be applied once, got %d", calls)
+	}
+
+	applied, err := m.Applied(ctx)
+	if err != nil || !reflect.DeepEqual(applied, []int{1, 2, 3}) {
+		t.Errorf("unexpected applied migrations %v, err %v", applied, err)
+	}
+
+	p := &Product{}
+	err = col.FindOne(ctx, &ProductKey{ID: "p2"}, p)
+	if err != nil || p.Name != "title-p2" || p.Category != "general" || p.Price != 1 {
+		t.Errorf("unexpected migrated entry %+v, err %v", p, err)
+	}
+
+	// migrations are recorded per table
+	other, err := newMigrator(store, "other-products", &testMigrationLocker{})
+	if err != nil {
+		t.Fatalf("failed to create migrator: %s", err)
+	}
+	applied, err = other.Applied(ctx)
+	if err != nil || len(applied) != 0 {
+		t.Errorf("expected no applied migrations for other table, got %v, err %v", applied, err)
+	}
+
+	// failing migration stops the subsequent ones
+	migrations = append(migrations,
+		Migration{Version: 4, Name: "fail", Apply: func(ctx context.Context, col db.StoreCollection) error {
+			return errors.Wrapf(errors.InvalidArgument, "bad migration")
+		}},
+		Migration{Version: 5, Name: "never", Apply: func(ctx context.Context, col db.StoreCollection) error {
+			calls++
+			return nil
+		}},
+	)
+	m, err = newMigrator(store, "migrate-products", &testMigrationLocker{}, migrations...)
+	if err != nil {
+		t.Fatalf("failed to create migrator: %s", err)
+	}
+	err = m.Run(ctx)
+	if !errors.IsInvalidArgument(err) {
+		t.Errorf("expected migration failure, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected subsequent migrations to be skipped")
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+	"github.com/go-core-stack/core/utils"
+)
+
+// pageToken is the content of the opaque continuation token, holding the
+// sort fields used for the query along with their values for the last
+// entry returned in the page
+type pageToken struct {
+	Fields []string `bson:"f"`
+	Values bson.A   `bson:"v"`
+}
+
+// encodes the page token into an opaque url safe string
+func encodePageToken(token *pageToken) (string, error) {
+	raw, err := bson.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodes the opaque page token and validates that it was generated
+// for the same sort fields
+func decodePageToken(token string, sortBy []SortOption) (*pageToken, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "invalid page token: %s", err)
+	}
+	data := &pageToken{}
+	err = bson.Unmarshal(raw, data)
+	if err != nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "invalid page token: %s", err)
+	}
+	if len(data.Fields) != len(sortBy) || len(data.Values) != len(sortBy) {
+		return nil, errors.Wrapf(errors.InvalidArgument, "page token doesn't match the sort order")
+	}
+	for i, s := range sortBy {
+		if data.Fields[i] != s.Field {
+			return nil, errors.Wrapf(errors.InvalidArgument, "page token doesn't match the sort order")
+		}
+	}
+	return data, nil
+}
+
+// pageSortOrder returns the sort order used for pagination, which always
+// ends with the primary key as tie breaker to ensure a stable order
+func pageSortOrder(sortBy []SortOption) []SortOption {
+	for _, s := range sortBy {
+		if s.Field == "_id" {
+			return sortBy
+		}
+	}
+	order := append([]SortOption{}, sortBy...)
+	return append(order, SortOption{Field: "_id", Direction: SortAscending})
+}
+
+// builds the filter selecting entries positioned after the token, as per
+// the sort order, eg. for sort on (a, _id) it results in
+// {$or: [{a: {$gt: va}}, {a: va, _id: {$gt: vid}}]}
+// Missing fields are captured as null in the token, which sorts before
+// any other value, so no entry follows null in descending order, while
+// every entry with a value follows it in ascending order. Similarly the
+// entries missing the field follow any value in descending order
+func pageAfterFilter(sortBy []SortOption, token *pageToken) bson.D {
+	or := bson.A{}
+	for i, s := range sortBy {
+		prefix := func() bson.D {
+			cond := bson.D{}
+			for j := 0; j < i; j++ {
+				cond = append(cond, bson.E{Key: sortBy[j].Field, Value: token.Values[j]})
+			}
+			return cond
+		}
+		val := token.Values[i]
+		switch {
+		case val == nil && s.Direction == SortDescending:
+			continue
+		case val == nil:
+			or = append(or, append(prefix(), bson.E{Key: s.Field, Value: bson.D{{Key: "$ne", Value: nil}}}))
+		case s.Direction == SortDescending:
+			or = append(or, append(prefix(), bson.E{Key: s.Field, Value: bson.D{{Key: "$lt", Value: val}}}))
+			or = append(or, append(prefix(), bson.E{Key: s.Field, Value: nil}))
+		default:
+			or = append(or, append(prefix(), bson.E{Key: s.Field, Value: bson.D{{Key: "$gt", Value: val}}}))
+		}
+	}
+	return bson.D{{Key: "$or", Value: or}}
+}
+
+// findPage fetches a page of entries matching the filter, starting after
+// the position captured in the token
+func findPage[E any](ctx context.Context, col db.StoreCollection, enc utils.IOEncryptor, filter any, token string, limit int32, sortBy []SortOption) ([]*E, string, error) {
+	if limit <= 0 {
+		return nil, "", errors.Wrapf(errors.InvalidArgument, "invalid page limit %d", limit)
+	}
+	sortBy = pageSortOrder(sortBy)
+
+	if token != "" {
+		data, err := decodePageToken(token, sortBy)
+		if err != nil {
+			return nil, "", err
+		}
+		after := pageAfterFilter(sortBy, data)
+		if filter == nil {
+			filter = after
+		} else {
+			filter = bson.D{{Key: "$and", Value: bson.A{filter, after}}}
+		}
+	}
+
+	// fetch one additional entry to identify if there is a next page
+	opts := options.Find().SetSort(buildSortDocument(sortBy)).SetLimit(int64(limit) + 1)
+	var docs []bson.Raw
+	err := col.FindMany(ctx, filter, &docs, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(docs) > int(limit) {
+		docs = docs[:limit]
+		last := docs[len(docs)-1]
+		data := &pageToken{}
+		for _, s := range sortBy {
+			// entries missing the sort field, eg. zero values omitted
+			// using omitempty, are positioned as null
+			var val any
+			raw, err := last.LookupErr(strings.Split(s.Field, ".")...)
+			if err == nil && raw.Type != bson.TypeNull && raw.Type != bson.TypeUndefined {
+				val = raw
+			}
+			data.Fields = append(data.Fields, s.Field)
+			data.Values = append(data.Values, val)
+		}
+		next, err = encodePageToken(data)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	list := []*E{}
+	for _, doc := range docs {
+		entry := new(E)
+		err = bson.Unmarshal(doc, entry)
+		if err != nil {
+			return nil, "", err
+		}
+		list = append(list, entry)
+	}
+	err = decryptEntries(enc, list)
+	if err != nil {
+		return nil, "", err
+	}
+	return list, next, nil
+}
@@ -0,0 +1,131 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+func Test_PageToken(t *testing.T) {
+	sortBy := pageSortOrder([]SortOption{{Field: "price", Direction: SortDescending}})
+	if len(sortBy) != 2 || sortBy[1].Field != "_id" {
+		t.Fatalf("expected key to be added as tie breaker, got %v", sortBy)
+	}
+
+	token, err := encodePageToken(&pageToken{
+		Fields: []string{"price", "_id"},
+		Values: bson.A{int32(100), bson.D{{Key: "id", Value: "prod-1"}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode page token: %s", err)
+	}
+
+	data, err := decodePageToken(token, sortBy)
+	if err != nil {
+		t.Fatalf("failed to decode page token: %s", err)
+	}
+	if data.Values[0] != int32(100) {
+		t.Errorf("expected price value 100, got %v", data.Values[0])
+	}
+
+	filter := pageAfterFilter(sortBy, data)
+	or, ok := filter[0].Value.(bson.A)
+	if filter[0].Key != "$or" || !ok || len(or) != 3 {
+		t.Fatalf("unexpected filter %v", filter)
+	}
+	if or[0].(bson.D)[0].Value.(bson.D)[0].Key != "$lt" {
+		t.Errorf("expected descending sort field to use $lt, got %v", or[0])
+	}
+	if or[1].(bson.D)[0].Value != nil {
+		t.Errorf("expected entries missing the descending sort field to follow, got %v", or[1])
+	}
+
+	// missing sort field is positioned as null, followed by every value
+	// in ascending order and by no value in descending order
+	null := &pageToken{Fields: data.Fields, Values: bson.A{nil, data.Values[1]}}
+	filter = pageAfterFilter(sortBy, null)
+	or, _ = filter[0].Value.(bson.A)
+	if len(or) != 1 || or[0].(bson.D)[0].Key != "price" || or[0].(bson.D)[1].Key != "_id" {
+		t.Errorf("expected only the tie breaker after null in descending order, got %v", filter)
+	}
+	asc := pageSortOrder([]SortOption{{Field: "price", Direction: SortAscending}})
+	filter = pageAfterFilter(asc, null)
+	or, _ = filter[0].Value.(bson.A)
+	if len(or) != 2 || or[0].(bson.D)[0].Value.(bson.D)[0].Key != "$ne" {
+		t.Errorf("expected values to follow null in ascending order, got %v", filter)
+	}
+
+	_, err = decodePageToken(token, pageSortOrder(nil))
+	if !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for mismatched sort order, got %v", err)
+	}
+
+	_, err = decodePageToken("not-a-token", sortBy)
+	if !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for corrupt token, got %v", err)
+	}
+}
+
+type RankedItem struct {
+	Name string `bson:"name,omitempty"`
+	Rank int    `bson:"rank,omitempty"`
+}
+
+func Test_FindPageMissingSortField(t *testing.T) {
+	ctx := context.Background()
+	tbl := &Table[ProductKey, RankedItem]{}
+	err := tbl.Initialize(db.NewMemoryCollection("ranked"))
+	if err != nil {
+		t.Fatalf("failed to initialize table: %s", err)
+	}
+
+	// zero ranks are omitted from the stored entries
+	ranks := []int{2, 0, 1, 0, 3, 0}
+	for i, rank := range ranks {
+		name := fmt.Sprintf("item-%d", i)
+		err = tbl.Insert(ctx, &ProductKey{ID: name}, &RankedItem{Name: name, Rank: rank})
+		if err != nil {
+			t.Fatalf("failed to insert: %s", err)
+		}
+	}
+
+	paginate := func(dir SortDirection) []string {
+		names := []string{}
+		token := ""
+		for {
+			list, next, err := tbl.FindPage(ctx, nil, token, 2, SortOption{Field: "rank", Direction: dir})
+			if err != nil {
+				t.Fatalf("failed to find page: %s", err)
+			}
+			for _, item := range list {
+				names = append(names, item.Name)
+			}
+			if next == "" {
+				return names
+			}
+			token = next
+		}
+	}
+
+	t.Run("ascending", func(t *testing.T) {
+		expected := "[item-1 item-3 item-5 item-2 item-0 item-4]"
+		if names := paginate(SortAscending); fmt.Sprint(names) != expected {
+			t.Errorf("expected %s, got %v", expected, names)
+		}
+	})
+
+	t.Run("descending", func(t *testing.T) {
+		expected := "[item-4 item-0 item-2 item-1 item-3 item-5]"
+		if names := paginate(SortDescending); fmt.Sprint(names) != expected {
+			t.Errorf("expected %s, got %v", expected, names)
+		}
+	})
+}
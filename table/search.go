@@ -0,0 +1,105 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+)
+
+// FieldPrefix restricts the results to entries where the field value
+// starts with the given prefix
+type FieldPrefix struct {
+	Field  string
+	Prefix string
+}
+
+// WithTextSearch restricts the results to entries matching the text search
+// query, requires a text index on the table, see WithTextIndex.
+// Query follows the $text search syntax, where terms are matched
+// independently and a quoted string is matched as a phrase.
+func WithTextSearch(query string) FindOption {
+	return func(opts *FindOptions) {
+		opts.TextSearch = query
+	}
+}
+
+// WithRegexPrefix restricts the results to entries where the field value
+// starts with the given prefix, the prefix is matched literally and case
+// sensitive, allowing the query to use an index on the field, see
+// WithPrefixIndex. Multiple prefixes on different fields are combined.
+func WithRegexPrefix(field, prefix string) FindOption {
+	return func(opts *FindOptions) {
+		opts.Prefixes = append(opts.Prefixes, FieldPrefix{Field: field, Prefix: prefix})
+	}
+}
+
+// WithTextIndex ensures a text index over the given fields while
+// initializing the table, required for WithTextSearch.
+// A collection can have only one text index, so all the fields searched
+// together need to be passed in the same option.
+func WithTextIndex(fields ...string) TableOption {
+	return func(cfg *TableConfig) {
+		cfg.TextIndexFields = append(cfg.TextIndexFields, fields...)
+	}
+}
+
+// WithPrefixIndex ensures an ascending index on each of the given fields
+// while initializing the table, used by WithRegexPrefix queries.
+func WithPrefixIndex(fields ...string) TableOption {
+	return func(cfg *TableConfig) {
+		cfg.PrefixIndexFields = append(cfg.PrefixIndexFields, fields...)
+	}
+}
+
+// WithIndexes ensures the given indexes while initializing the table,
+// allowing unique, TTL or partial indexes on the entry fields
+func WithIndexes(indexes ...db.IndexDefinition) TableOption {
+	return func(cfg *TableConfig) {
+		cfg.Indexes = append(cfg.Indexes, indexes...)
+	}
+}
+
+// ensureIndexes creates the text, prefix and additional indexes
+// configured for the table, if not already present
+func (c *TableConfig) ensureIndexes(ctx context.Context, col db.StoreCollection) error {
+	indexes := append([]db.IndexDefinition{}, c.Indexes...)
+	if len(c.TextIndexFields) != 0 {
+		idx := db.IndexDefinition{}
+		for _, f := range c.TextIndexFields {
+			idx.Fields = append(idx.Fields, db.IndexField{Field: f, IndexType: db.IndexText})
+		}
+		indexes = append(indexes, idx)
+	}
+	for _, f := range c.PrefixIndexFields {
+		indexes = append(indexes, db.IndexDefinition{
+			Fields: []db.IndexField{{Field: f, IndexType: db.IndexAscending}},
+		})
+	}
+	return col.EnsureIndexes(ctx, indexes)
+}
+
+// searchFilter combines the filter with the search conditions configured
+// in the find options, returns the filter as is if there are none
+func searchFilter(filter any, opts *FindOptions) any {
+	conds := bson.A{}
+	if opts.TextSearch != "" {
+		conds = append(conds, bson.D{{Key: "$text", Value: bson.D{{Key: "$search", Value: opts.TextSearch}}}})
+	}
+	for _, p := range opts.Prefixes {
+		re := bson.Regex{Pattern: "^" + regexp.QuoteMeta(p.Prefix)}
+		conds = append(conds, bson.D{{Key: p.Field, Value: re}})
+	}
+	if len(conds) == 0 {
+		return filter
+	}
+	if filter != nil {
+		conds = append(bson.A{filter}, conds...)
+	}
+	return bson.D{{Key: "$and", Value: conds}}
+}
@@ -0,0 +1,83 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+)
+
+func Test_SearchFilter(t *testing.T) {
+	t.Run("no_search", func(t *testing.T) {
+		filter := bson.M{"category": "x"}
+		if got := searchFilter(filter, &FindOptions{}); !reflect.DeepEqual(got, filter) {
+			t.Errorf("expected filter to be unchanged, got %v", got)
+		}
+	})
+
+	t.Run("prefix_is_escaped", func(t *testing.T) {
+		opts := &FindOptions{}
+		WithRegexPrefix("name", "a.b")(opts)
+		got := searchFilter(nil, opts)
+		expected := bson.D{{Key: "$and", Value: bson.A{
+			bson.D{{Key: "name", Value: bson.Regex{Pattern: `^a\.b`}}},
+		}}}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+}
+
+func Test_MemoryBackedSearch(t *testing.T) {
+	ctx := context.Background()
+
+	tbl := &Table[ProductKey, Product]{}
+	err := tbl.InitializeWithConfig(db.NewMemoryCollection("search-products"),
+		WithTextIndex("name", "category"), WithPrefixIndex("name"))
+	if err != nil {
+		t.Fatalf("failed to initialize table: %s", err)
+	}
+
+	for i, name := range []string{"Blue Pen", "Black Pen", "Blue Ink", "a.b"} {
+		key := &ProductKey{ID: fmt.Sprintf("search-%d", i)}
+		err := tbl.Insert(ctx, key, &Product{Name: name, Category: "stationery"})
+		if err != nil {
+			t.Fatalf("failed to insert: %s", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts []FindOption
+		want []string
+	}{
+		{"text", []FindOption{WithTextSearch("ink")}, []string{"Blue Ink"}},
+		{"prefix", []FindOption{WithRegexPrefix("name", "Bl")}, []string{"Black Pen", "Blue Ink", "Blue Pen"}},
+		{"prefix_literal", []FindOption{WithRegexPrefix("name", "a.")}, []string{"a.b"}},
+		{"combined", []FindOption{WithTextSearch("pen"), WithRegexPrefix("name", "Blue")}, []string{"Blue Pen"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := tbl.FindManyWithOpts(ctx, bson.M{"category": "stationery"}, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to find: %s", err)
+			}
+			got := []string{}
+			for _, p := range list {
+				got = append(got, p.Name)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
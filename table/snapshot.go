@@ -0,0 +1,208 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"bufio"
+	"context"
+	"io"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+	"github.com/go-core-stack/core/rate"
+	"github.com/go-core-stack/core/utils"
+)
+
+const (
+	// default number of entries fetched from the database per batch
+	// while exporting the table
+	defaultSnapshotBatchSize = 100
+
+	// maximum size of a single line while importing the table
+	maxSnapshotLineSize = 16 * 1024 * 1024
+)
+
+// SnapshotOptions contains optional parameters for Export and Import
+type SnapshotOptions struct {
+	// Filter restricts the exported entries, not used for Import
+	Filter any
+
+	// BatchSize is the number of entries fetched per batch for Export
+	BatchSize int32
+
+	// Limiter rate limits the bytes written or read, using the limiter
+	// registered with LimiterKey in the limit manager
+	Limiter    *rate.LimitManager
+	LimiterKey string
+}
+
+// SnapshotOption is a functional option for configuring Export and Import
+type SnapshotOption func(*SnapshotOptions)
+
+// WithSnapshotFilter exports only the entries matching the filter
+func WithSnapshotFilter(filter any) SnapshotOption {
+	return func(opts *SnapshotOptions) {
+		opts.Filter = filter
+	}
+}
+
+// WithSnapshotBatchSize sets the number of entries fetched from the
+// database per batch while exporting
+func WithSnapshotBatchSize(size int32) SnapshotOption {
+	return func(opts *SnapshotOptions) {
+		opts.BatchSize = size
+	}
+}
+
+// WithSnapshotRateLimit rate limits the bytes written by Export or read by
+// Import, using the limiter created for the key in the limit manager
+func WithSnapshotRateLimit(mgr *rate.LimitManager, key string) SnapshotOption {
+	return func(opts *SnapshotOptions) {
+		opts.Limiter = mgr
+		opts.LimiterKey = key
+	}
+}
+
+// single line of the exported snapshot, where the key is the value stored
+// as `_id` and entry is the decrypted entry
+type snapshotRecord struct {
+	Key   bson.RawValue `bson:"key"`
+	Entry bson.Raw      `bson:"entry"`
+}
+
+func getSnapshotOptions(opts []SnapshotOption) *SnapshotOptions {
+	cfg := &SnapshotOptions{BatchSize: defaultSnapshotBatchSize}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultSnapshotBatchSize
+	}
+	return cfg
+}
+
+// exportEntries streams the entries of the collection as JSON lines,
+// fetching them in batches ordered by `_id`
+func exportEntries[E any](ctx context.Context, col db.StoreCollection, enc utils.IOEncryptor, w io.Writer, opts []SnapshotOption) (int64, error) {
+	cfg := getSnapshotOptions(opts)
+	if cfg.Limiter != nil {
+		lw, err := cfg.Limiter.WrapWriter(ctx, cfg.LimiterKey, w)
+		if err != nil {
+			return 0, err
+		}
+		defer func() {
+			_ = lw.Close()
+		}()
+		w = lw
+	}
+
+	bw := bufio.NewWriter(w)
+	count := int64(0)
+	var last *bson.RawValue
+	for {
+		filter := cfg.Filter
+		if last != nil {
+			after := bson.D{{Key: "_id", Value: bson.D{{Key: "$gt", Value: *last}}}}
+			if filter == nil {
+				filter = after
+			} else {
+				filter = bson.D{{Key: "$and", Value: bson.A{filter, after}}}
+			}
+		}
+		findOpts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(cfg.BatchSize))
+		list := []bson.Raw{}
+		err := col.FindMany(ctx, filter, &list, findOpts)
+		if err != nil && !errors.IsNotFound(err) {
+			return count, err
+		}
+		for _, doc := range list {
+			id, err := doc.LookupErr("_id")
+			if err != nil {
+				return count, errors.Wrapf(errors.Unknown, "Export: entry without key: %s", err)
+			}
+			var entry E
+			err = bson.Unmarshal(doc, &entry)
+			if err != nil {
+				return count, errors.Wrapf(errors.Unknown, "Export: failed to decode entry: %s", err)
+			}
+			err = decryptEntry(enc, &entry)
+			if err != nil {
+				return count, err
+			}
+			raw, err := bson.Marshal(&entry)
+			if err != nil {
+				return count, errors.Wrapf(errors.Unknown, "Export: failed to encode entry: %s", err)
+			}
+			line, err := bson.MarshalExtJSON(&snapshotRecord{Key: id, Entry: raw}, false, false)
+			if err != nil {
+				return count, errors.Wrapf(errors.Unknown, "Export: failed to encode entry: %s", err)
+			}
+			if _, err = bw.Write(append(line, '\n')); err != nil {
+				return count, err
+			}
+			count++
+			last = &id
+		}
+		if len(list) < int(cfg.BatchSize) {
+			break
+		}
+	}
+	return count, bw.Flush()
+}
+
+// importEntries reads the JSON lines written by exportEntries, invoking
+// the callback for every entry
+func importEntries[K any, E any](ctx context.Context, m *keyMapper[K], r io.Reader, opts []SnapshotOption, fn func(key *K, entry *E) error) (int64, error) {
+	cfg := getSnapshotOptions(opts)
+	if cfg.Limiter != nil {
+		lr, err := cfg.Limiter.WrapReader(ctx, cfg.LimiterKey, io.NopCloser(r))
+		if err != nil {
+			return 0, err
+		}
+		defer func() {
+			_ = lr.Close()
+		}()
+		r = lr
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxSnapshotLineSize)
+	count := int64(0)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		rec := &snapshotRecord{}
+		err := bson.UnmarshalExtJSON(scanner.Bytes(), false, rec)
+		if err != nil {
+			return count, errors.Wrapf(errors.InvalidArgument, "Import: invalid entry at line %d: %s", line, err)
+		}
+		key, err := m.decodeRaw(rec.Key)
+		if err != nil {
+			return count, errors.Wrapf(errors.InvalidArgument, "Import: invalid key at line %d: %s", line, err)
+		}
+		entry := new(E)
+		err = bson.Unmarshal(rec.Entry, entry)
+		if err != nil {
+			return count, errors.Wrapf(errors.InvalidArgument, "Import: invalid entry at line %d: %s", line, err)
+		}
+		err = fn(key, entry)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return count, errors.Wrapf(errors.InvalidArgument, "Import: failed to read line %d: %s", line+1, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,78 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+func Test_Snapshot(t *testing.T) {
+	ctx := context.Background()
+
+	for _, codec := range []KeyCodec{nil, &StrictKeyCodec{}} {
+		t.Run(fmt.Sprintf("codec_%T", codec), func(t *testing.T) {
+			src := &Table[ProductKey, Product]{}
+			err := src.InitializeWithConfig(db.NewMemoryCollection("snapshot-src"), WithKeyCodec(codec))
+			if err != nil {
+				t.Fatalf("failed to initialize table: %s", err)
+			}
+			for i := 0; i < 5; i++ {
+				key := &ProductKey{ID: fmt.Sprintf("snap-%d", i)}
+				err := src.Insert(ctx, key, &Product{Name: key.ID, Price: i, Category: fmt.Sprint(i % 2)})
+				if err != nil {
+					t.Fatalf("failed to insert: %s", err)
+				}
+			}
+
+			buf := &bytes.Buffer{}
+			count, err := src.Export(ctx, buf, WithSnapshotBatchSize(2))
+			if err != nil || count != 5 {
+				t.Fatalf("expected 5 entries exported, got %d, err %v", count, err)
+			}
+			if lines := strings.Count(buf.String(), "\n"); lines != 5 {
+				t.Errorf("expected 5 lines, got %d", lines)
+			}
+
+			filtered := &bytes.Buffer{}
+			count, err = src.Export(ctx, filtered, WithSnapshotFilter(bson.M{"category": "0"}))
+			if err != nil || count != 3 {
+				t.Errorf("expected 3 entries exported, got %d, err %v", count, err)
+			}
+
+			dst := &Table[ProductKey, Product]{}
+			err = dst.InitializeWithConfig(db.NewMemoryCollection("snapshot-dst"), WithKeyCodec(codec))
+			if err != nil {
+				t.Fatalf("failed to initialize table: %s", err)
+			}
+			count, err = dst.Import(ctx, buf)
+			if err != nil || count != 5 {
+				t.Fatalf("expected 5 entries imported, got %d, err %v", count, err)
+			}
+			entry, err := dst.Find(ctx, &ProductKey{ID: "snap-3"})
+			if err != nil || entry.Name != "snap-3" || entry.Price != 3 {
+				t.Errorf("unexpected imported entry %v, err %v", entry, err)
+			}
+		})
+	}
+
+	t.Run("invalid_input", func(t *testing.T) {
+		tbl := &Table[ProductKey, Product]{}
+		if err := tbl.Initialize(db.NewMemoryCollection("snapshot-invalid")); err != nil {
+			t.Fatalf("failed to initialize table: %s", err)
+		}
+		_, err := tbl.Import(ctx, strings.NewReader("{not json}\n"))
+		if !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument error, got %v", err)
+		}
+	})
+}
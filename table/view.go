@@ -0,0 +1,237 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/errors"
+	"github.com/go-core-stack/core/reconciler"
+)
+
+// ViewEntry is an entry of the view derived from a source entry, where a
+// nil Entry removes the key from the view
+type ViewEntry[K comparable, E any] struct {
+	Key   K
+	Entry *E
+}
+
+// ViewProjectionfn computes the view entries derived from the source entry
+// with the given key, typically by looking up the source entry and joining
+// it with entries of other tables. Returned entries replace the ones
+// derived earlier for the same source key, while the ones no longer
+// returned are removed from the view, unless derived by some other source
+// key. An error triggers a retry of the projection by the reconciler.
+type ViewProjectionfn[SK any, K comparable, E any] func(ctx context.Context, key *SK) ([]ViewEntry[K, E], error)
+
+// ViewSource is a table providing the change notifications for the view,
+// satisfied by both Table and CachedTable
+type ViewSource interface {
+	Register(name string, crtl reconciler.Controller, opts ...reconciler.RegisterOption) error
+}
+
+// ViewOption is a functional option for configuring the View
+type ViewOption[K comparable, E any] func(*View[K, E])
+
+// WithViewTable additionally persists the view entries to the given table,
+// allowing the denormalized view to be queried from the database
+func WithViewTable[K comparable, E any](tbl *Table[K, E]) ViewOption[K, E] {
+	return func(v *View[K, E]) {
+		v.sink = tbl
+	}
+}
+
+// View maintains a derived, denormalized set of entries from one or more
+// source tables, using the projection function registered for each of the
+// sources. View registers itself as a reconciler controller with the
+// sources, where the existing source entries are projected as part of the
+// initial backfill and subsequently on every change, failed projections
+// are retried by the reconciler to repair the view.
+//
+// K: Key type of the view (must be comparable)
+// E: Entry type of the view
+type View[K comparable, E any] struct {
+	name string
+	ctx  context.Context
+	sink *Table[K, E]
+
+	mu      sync.RWMutex
+	entries map[K]*E
+	owners  map[K]map[string]struct{} // source keys deriving the view key
+	derived map[string][]K            // view keys derived per source key
+	sources int
+}
+
+// NewView creates a view with the given name, name is used to register
+// the view with the source tables and must be unique across the views
+// registered with a source
+func NewView[K comparable, E any](ctx context.Context, name string, opts ...ViewOption[K, E]) (*View[K, E], error) {
+	if name == "" {
+		return nil, errors.Wrapf(errors.InvalidArgument, "View: name is not specified")
+	}
+	v := &View[K, E]{
+		name:    name,
+		ctx:     ctx,
+		entries: map[K]*E{},
+		owners:  map[K]map[string]struct{}{},
+		derived: map[string][]K{},
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v, nil
+}
+
+// controller registered with a source table, projecting the changed
+// source entries into the view
+type viewController[SK any, K comparable, E any] struct {
+	view    *View[K, E]
+	source  int
+	project ViewProjectionfn[SK, K, E]
+}
+
+// Reconcile projects the source entry for the key into the view
+func (c *viewController[SK, K, E]) Reconcile(k any) (*reconciler.Result, error) {
+	key, ok := k.(*SK)
+	if !ok {
+		log.Printf("View %s: unexpected source key type %T", c.view.name, k)
+		return nil, nil
+	}
+	raw, err := bson.Marshal(bson.D{{Key: "k", Value: key}})
+	if err != nil {
+		log.Printf("View %s: failed to encode source key %v: %s", c.view.name, key, err)
+		return nil, nil
+	}
+	list, err := c.project(c.view.ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return nil, c.view.apply(c.source, string(raw), list)
+}
+
+// AddViewSource registers the source table with the view, where the
+// projection function computes the view entries for the source entries.
+// Existing source entries are projected as part of registration.
+func AddViewSource[SK any, K comparable, E any](v *View[K, E], src ViewSource, project ViewProjectionfn[SK, K, E]) error {
+	if project == nil {
+		return errors.Wrapf(errors.InvalidArgument, "View %s: projection function is not specified", v.name)
+	}
+	v.mu.Lock()
+	v.sources++
+	crtl := &viewController[SK, K, E]{
+		view:    v,
+		source:  v.sources,
+		project: project,
+	}
+	v.mu.Unlock()
+	return src.Register("view:"+v.name, crtl)
+}
+
+// applies the entries derived from the source key to the view
+func (v *View[K, E]) apply(source int, srcKey string, list []ViewEntry[K, E]) error {
+	ref := fmt.Sprintf("%d/%s", source, srcKey)
+	updated := map[K]*E{}
+	removed := map[K]struct{}{}
+
+	// sink is updated while holding the lock as well, ensuring that the
+	// changes are persisted in the same order as applied to the view
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	current := map[K]struct{}{}
+	keys := []K{}
+	for _, ve := range list {
+		if ve.Entry == nil {
+			// explicit removal of the key from the view
+			delete(v.entries, ve.Key)
+			delete(v.owners, ve.Key)
+			delete(updated, ve.Key)
+			removed[ve.Key] = struct{}{}
+			continue
+		}
+		current[ve.Key] = struct{}{}
+		keys = append(keys, ve.Key)
+		v.entries[ve.Key] = ve.Entry
+		if v.owners[ve.Key] == nil {
+			v.owners[ve.Key] = map[string]struct{}{}
+		}
+		v.owners[ve.Key][ref] = struct{}{}
+		updated[ve.Key] = ve.Entry
+		delete(removed, ve.Key)
+	}
+	for _, k := range v.derived[ref] {
+		if _, ok := current[k]; ok {
+			continue
+		}
+		owners := v.owners[k]
+		delete(owners, ref)
+		if len(owners) == 0 {
+			delete(v.entries, k)
+			delete(v.owners, k)
+			removed[k] = struct{}{}
+		}
+	}
+	if len(keys) == 0 {
+		delete(v.derived, ref)
+	} else {
+		v.derived[ref] = keys
+	}
+
+	if v.sink == nil {
+		return nil
+	}
+	for k, e := range updated {
+		key := k
+		_, _, err := v.sink.Locate(v.ctx, &key, e)
+		if err != nil {
+			return err
+		}
+	}
+	for k := range removed {
+		key := k
+		err := v.sink.DeleteKey(v.ctx, &key)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Find returns the view entry for the key
+// returns NotFound error if the entry doesn't exist
+func (v *View[K, E]) Find(key *K) (*E, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	entry, ok := v.entries[*key]
+	if !ok {
+		return nil, errors.Wrapf(errors.NotFound, "View %s: entry %v not found", v.name, *key)
+	}
+	return entry, nil
+}
+
+// FindMany returns the view entries satisfying the predicate, all the
+// entries if the predicate is nil
+func (v *View[K, E]) FindMany(predicate func(key *K, entry *E) bool) []*E {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	list := []*E{}
+	for k, e := range v.entries {
+		key := k
+		if predicate == nil || predicate(&key, e) {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// Count returns the number of entries in the view
+func (v *View[K, E]) Count() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return len(v.entries)
+}
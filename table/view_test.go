@@ -0,0 +1,128 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package table
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/go-core-stack/core/db"
+	"github.com/go-core-stack/core/errors"
+)
+
+type viewCategoryKey struct {
+	ID string
+}
+
+type viewCategory struct {
+	Title string
+}
+
+type productView struct {
+	Name     string
+	Category string
+}
+
+func Test_View(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	products := &Table[ProductKey, Product]{}
+	if err := products.Initialize(db.NewMemoryCollection("view-products")); err != nil {
+		t.Fatalf("failed to initialize table: %s", err)
+	}
+	categories := &Table[viewCategoryKey, viewCategory]{}
+	if err := categories.Initialize(db.NewMemoryCollection("view-categories")); err != nil {
+		t.Fatalf("failed to initialize table: %s", err)
+	}
+	sink := &Table[string, productView]{}
+	if err := sink.Initialize(db.NewMemoryCollection("view-sink")); err != nil {
+		t.Fatalf("failed to initialize table: %s", err)
+	}
+
+	_ = categories.Insert(ctx, &viewCategoryKey{ID: "pens"}, &viewCategory{Title: "Pens"})
+	_ = products.Insert(ctx, &ProductKey{ID: "p1"}, &Product{Name: "p1", Category: "pens"})
+
+	// projects a product joined with its category
+	project := func(ctx context.Context, p *Product, id string) []ViewEntry[string, productView] {
+		title := ""
+		if c, err := categories.Find(ctx, &viewCategoryKey{ID: p.Category}); err == nil {
+			title = c.Title
+		}
+		return []ViewEntry[string, productView]{{Key: id, Entry: &productView{Name: p.Name, Category: title}}}
+	}
+
+	view, err := NewView(ctx, "products", WithViewTable(sink))
+	if err != nil {
+		t.Fatalf("failed to create view: %s", err)
+	}
+	err = AddViewSource(view, products, func(ctx context.Context, key *ProductKey) ([]ViewEntry[string, productView], error) {
+		p, err := products.Find(ctx, key)
+		if errors.IsNotFound(err) {
+			return []ViewEntry[string, productView]{{Key: key.ID}}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return project(ctx, p, key.ID), nil
+	})
+	if err != nil {
+		t.Fatalf("failed to add view source: %s", err)
+	}
+	err = AddViewSource(view, categories, func(ctx context.Context, key *viewCategoryKey) ([]ViewEntry[string, productView], error) {
+		list, err := products.FindMany(ctx, bson.M{"category": key.ID}, 0, 0)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+		entries := []ViewEntry[string, productView]{}
+		for _, p := range list {
+			// product names are same as the product ids in this test
+			entries = append(entries, project(ctx, p, p.Name)...)
+		}
+		return entries, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to add view source: %s", err)
+	}
+
+	expect := func(msg, key string, want *productView) {
+		t.Helper()
+		waitFor(t, msg, func() bool {
+			entry, err := view.Find(&key)
+			if want == nil {
+				return errors.IsNotFound(err)
+			}
+			return err == nil && *entry == *want
+		})
+		waitFor(t, msg+" in sink", func() bool {
+			entry, err := sink.Find(ctx, &key)
+			if want == nil {
+				return errors.IsNotFound(err)
+			}
+			return err == nil && *entry == *want
+		})
+	}
+
+	expect("backfill", "p1", &productView{Name: "p1", Category: "Pens"})
+
+	_ = products.Insert(ctx, &ProductKey{ID: "p2"}, &Product{Name: "p2", Category: "pens"})
+	expect("insert", "p2", &productView{Name: "p2", Category: "Pens"})
+
+	_ = categories.Update(ctx, &viewCategoryKey{ID: "pens"}, &viewCategory{Title: "Writing"})
+	expect("join update", "p1", &productView{Name: "p1", Category: "Writing"})
+
+	_ = products.DeleteKey(ctx, &ProductKey{ID: "p2"})
+	expect("delete", "p2", nil)
+	if view.Count() != 1 {
+		t.Errorf("expected 1 entry in view, got %d", view.Count())
+	}
+
+	if err := AddViewSource(view, products, func(ctx context.Context, key *ProductKey) ([]ViewEntry[string, productView], error) {
+		return nil, nil
+	}); !errors.IsAlreadyExists(err) {
+		t.Errorf("expected already exists error, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package utils
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// size of the data keys generated, for AES-256
+	dataKeySize = 32
+)
+
+// KeyProvider provides the data keys for the encryptors using envelope
+// encryption, eg. backed by an external KMS or Vault, where the data key
+// is stored only wrapped, ie. encrypted using the master key held by the
+// provider, and unwrapped in memory while initializing the encryptor,
+// avoiding the long lived raw keys passed through the configuration
+type KeyProvider interface {
+	// Generate a new data key, returning the key along with the key
+	// wrapped using the master key, to be stored for later use
+	GenerateDataKey(ctx context.Context) (key []byte, wrapped []byte, err error)
+
+	// Unwrap the data key wrapped using the master key
+	UnwrapDataKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// InitializeEncryptorWithKeyProvider initialize a new Encryptor for given
+// provider, with the data key unwrapped using the key provider, this
+// will return an error if encryptor already exists
+func InitializeEncryptorWithKeyProvider(ctx context.Context, provider string, kp KeyProvider, wrapped []byte) (IOEncryptor, error) {
+	key, err := kp.UnwrapDataKey(ctx, wrapped)
+	if err != nil {
+		return nil, errors.WrapCause(errors.GetErrCode(err), err, "Unwrap Data Key error")
+	}
+	return registerEncryptor(provider, key)
+}
+
+// AddWrappedKey adds the data key unwrapped using the key provider to the
+// encryptor with the given id, making it the active key, eg. to rotate
+// to a new data key generated using the key provider
+func AddWrappedKey(ctx context.Context, enc IOEncryptor, kp KeyProvider, id string, wrapped []byte) error {
+	key, err := kp.UnwrapDataKey(ctx, wrapped)
+	if err != nil {
+		return errors.WrapCause(errors.GetErrCode(err), err, "Unwrap Data Key error")
+	}
+	return enc.AddKey(id, string(key))
+}
+
+// localKeyProvider wraps the data keys using a master key held locally
+type localKeyProvider struct {
+	gcm cipher.AEAD
+}
+
+// NewLocalKeyProvider creates the key provider wrapping the data keys
+// using the given AES master key, of 16, 24 or 32 bytes, eg. for the
+// development setups and tests without an external KMS
+func NewLocalKeyProvider(masterKey []byte) (KeyProvider, error) {
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "Invalid Master Key: %s", err)
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &localKeyProvider{gcm: aesgcm}, nil
+}
+
+func (p *localKeyProvider) GenerateDataKey(ctx context.Context) ([]byte, []byte, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, errors.Wrapf(errors.Unknown, "generating data key: %w", err)
+	}
+	nonce := make([]byte, p.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, errors.Wrapf(errors.Unknown, "generating nonce: %w", err)
+	}
+	// nonce || wrapped key
+	return key, p.gcm.Seal(nonce, nonce, key, nil), nil
+}
+
+func (p *localKeyProvider) UnwrapDataKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	nonceSize := p.gcm.NonceSize()
+	if len(wrapped) <= nonceSize {
+		return nil, errors.Wrap(errors.InvalidArgument, "Invalid Wrapped Key length")
+	}
+	key, err := p.gcm.Open(nil, wrapped[:nonceSize], wrapped[nonceSize:], nil)
+	if err != nil {
+		return nil, errors.Wrapf(errors.InvalidArgument, "Invalid Wrapped Key: %s", err)
+	}
+	return key, nil
+}
+
+// data key unwrapped and cached locally
+type cachedDataKey struct {
+	key     []byte
+	expires time.Time
+}
+
+// cachingKeyProvider caches the data keys unwrapped by the key provider
+type cachingKeyProvider struct {
+	KeyProvider
+	ttl time.Duration
+
+	mu   sync.Mutex
+	keys map[string]*cachedDataKey
+}
+
+// NewCachingKeyProvider caches the data keys unwrapped by the key
+// provider locally for the given duration, avoiding a round trip to the
+// KMS for every encryptor using the same data key
+func NewCachingKeyProvider(kp KeyProvider, ttl time.Duration) KeyProvider {
+	return &cachingKeyProvider{
+		KeyProvider: kp,
+		ttl:         ttl,
+		keys:        map[string]*cachedDataKey{},
+	}
+}
+
+func (p *cachingKeyProvider) UnwrapDataKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	now := time.Now()
+	p.mu.Lock()
+	entry, ok := p.keys[string(wrapped)]
+	if ok && now.Before(entry.expires) {
+		p.mu.Unlock()
+		return entry.key, nil
+	}
+	// drop the expired keys, while at it
+	for k, e := range p.keys {
+		if !now.Before(e.expires) {
+			delete(p.keys, k)
+		}
+	}
+	p.mu.Unlock()
+
+	key, err := p.KeyProvider.UnwrapDataKey(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.keys[string(wrapped)] = &cachedDataKey{key: key, expires: now.Add(p.ttl)}
+	p.mu.Unlock()
+	return key, nil
+}
@@ -0,0 +1,78 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// key provider counting the unwraps
+type countingKeyProvider struct {
+	KeyProvider
+	unwraps int
+}
+
+func (p *countingKeyProvider) UnwrapDataKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	p.unwraps++
+	return p.KeyProvider.UnwrapDataKey(ctx, wrapped)
+}
+
+func Test_KeyProvider(t *testing.T) {
+	ctx := context.Background()
+	if _, err := NewLocalKeyProvider([]byte("short")); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for bad master key, got %v", err)
+	}
+	local, err := NewLocalKeyProvider(bytes.Repeat([]byte("m"), 32))
+	if err != nil {
+		t.Fatalf("failed to create key provider: %s", err)
+	}
+	counting := &countingKeyProvider{KeyProvider: local}
+	kp := NewCachingKeyProvider(counting, time.Minute)
+
+	key, wrapped, err := kp.GenerateDataKey(ctx)
+	if err != nil {
+		t.Fatalf("failed to generate data key: %s", err)
+	}
+	if len(key) != dataKeySize || bytes.Contains(wrapped, key) {
+		t.Errorf("expected data key to be wrapped, got %x, %x", key, wrapped)
+	}
+	for range 2 {
+		unwrapped, err := kp.UnwrapDataKey(ctx, wrapped)
+		if err != nil || !bytes.Equal(unwrapped, key) {
+			t.Errorf("expected data key unwrapped, got %x, %v", unwrapped, err)
+		}
+	}
+	if counting.unwraps != 1 {
+		t.Errorf("expected unwrapped data key to be cached, got %d unwraps", counting.unwraps)
+	}
+	if _, err := kp.UnwrapDataKey(ctx, []byte("bogus-wrapped-key")); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for bad wrapped key, got %v", err)
+	}
+
+	enc, err := InitializeEncryptorWithKeyProvider(ctx, "utils-kms-provider", kp, wrapped)
+	if err != nil {
+		t.Fatalf("failed to initialize encryptor: %s", err)
+	}
+	cm, _ := enc.EncryptString("my-secret")
+	if _, err := InitializeEncryptorWithKeyProvider(ctx, "utils-kms-provider", kp, wrapped); !errors.IsAlreadyExists(err) {
+		t.Errorf("expected already exists initializing twice, got %v", err)
+	}
+	if _, err := InitializeEncryptorWithKeyProvider(ctx, "utils-kms-bogus", kp, []byte("bogus")); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for bad wrapped key, got %v", err)
+	}
+
+	// rotate to a new data key
+	_, rotated, _ := kp.GenerateDataKey(ctx)
+	if err := AddWrappedKey(ctx, enc, kp, "v2", rotated); err != nil {
+		t.Fatalf("failed to add wrapped key: %s", err)
+	}
+	if msg, err := enc.DecryptString(cm); err != nil || msg != "my-secret" {
+		t.Errorf("expected value decrypted after rotation, got %q, %v", msg, err)
+	}
+}
@@ -9,7 +9,9 @@ package utils
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -24,6 +26,28 @@ import (
 // it when decrypting data written before the random-nonce migration.
 var legacyStaticNonce = []byte("core nonce")
 
+// version of the ciphertext format, prepended to the ciphertext, allowing
+// the format to evolve while still decrypting the values written using
+// the older formats
+const (
+	// version || nonce || ciphertext, with a random nonce generated per
+	// encryption
+	cipherVersion1 byte = 1
+
+	// version || key id length || key id || nonce || ciphertext, where
+	// the key id identifies the key used for the encryption, allowing
+	// the keys to be rotated
+	cipherVersion2 byte = 2
+
+	// same as version 2, while the nonce is derived from the message
+	// instead of being random, producing the same ciphertext for the
+	// same message, ie. deterministic encryption
+	cipherVersion3 byte = 3
+
+	// maximum length of the key id, as encoded in a single byte
+	maxKeyIDLength = 255
+)
+
 // IOEncryptor is responsible for encrypting and decrypting objects
 // while transacting with an IO ensuring capability of handling secret
 // fields available as part of the data. while avoiding heavy usage of
@@ -40,11 +64,86 @@ type IOEncryptor interface {
 
 	// Decrypt an existing encrypted string
 	DecryptString(ciphermessage string) (string, error)
+
+	// Encrypt a given string message deterministically, producing the
+	// same ciphertext for the same message with the active key, eg. to
+	// query the fields tagged `encrypted:"deterministic"` by exact match
+	EncryptDeterministic(message string) (string, error)
+
+	// Add a new key with the given id, making it the active key used
+	// for the encryptions, while the older keys are retained for
+	// decrypting the existing values, until retired
+	AddKey(id string, key string) error
+
+	// Retire the key with the given id, where the values encrypted
+	// using it can no longer be decrypted, hence expected to be
+	// re-encrypted before retiring it. The active key cannot be retired
+	RetireKey(id string) error
+
+	// Re-encrypt the encrypted fields of a given object using the
+	// active key, upgrading the values encrypted using the older keys
+	// or formats, while leaving the values already up to date as is
+	ReEncrypt(o interface{}) (interface{}, error)
+
+	// Encrypt the data written to the returned writer, in chunks, into
+	// the destination, where Close completes the stream, expected to be
+	// invoked once done writing
+	EncryptStream(dst io.Writer) io.WriteCloser
+
+	// Decrypt the stream encrypted using EncryptStream, read from the
+	// source, where the reads fail if the stream is tampered with or
+	// truncated
+	DecryptStream(src io.Reader) io.Reader
+}
+
+// key of the encryptor
+type encryptorKey struct {
+	id  string
+	gcm cipher.AEAD
+
+	// key deriving the nonce from the message for the deterministic
+	// encryption
+	sivKey []byte
+}
+
+// mode of encryption of a field, as per its tag
+type encryptMode int
+
+const (
+	// field not to be encrypted
+	encryptNone encryptMode = iota
+
+	// field encrypted with a random nonce, tagged `encrypted:"true"`
+	encryptRandomized
+
+	// field encrypted deterministically, tagged
+	// `encrypted:"deterministic"`
+	encryptDeterministic
+)
+
+// returns the mode of encryption for the field with the given tag,
+// nested in a field with the given mode
+func (m encryptMode) field(tag string, tagged bool) encryptMode {
+	switch {
+	case tag == "deterministic":
+		return encryptDeterministic
+	case tagged && m == encryptNone:
+		return encryptRandomized
+	}
+	return m
 }
 
 // encryptor implementation
 type encryptorImpl struct {
-	gcm         cipher.AEAD
+	mu sync.RWMutex
+
+	// keys available for decryption, indexed by the key id, where the
+	// key provided on initialization has an empty id
+	keys map[string]*encryptorKey
+
+	// key used for the encryptions
+	active *encryptorKey
+
 	legacyNonce []byte // used only for decrypting pre-migration data
 }
 
@@ -73,6 +172,11 @@ func GetObjectEncryptor(provider string) (IOEncryptor, error) {
 // provider, this will return an error if encryptor already
 // exists
 func InitializeEncryptor(provider, key string) (IOEncryptor, error) {
+	return registerEncryptor(provider, []byte(key))
+}
+
+// registers the encryptor for the given provider with the key
+func registerEncryptor(provider string, key []byte) (IOEncryptor, error) {
 	// ensure taking a write lock before processing this further
 	// to ensure thread safety along with appropriate error
 	// handling
@@ -87,7 +191,7 @@ func InitializeEncryptor(provider, key string) (IOEncryptor, error) {
 		return nil, errors.Wrap(errors.InvalidArgument, "Invalid Key length")
 	}
 
-	oe, err := createEncryptor([]byte(key))
+	oe, err := createEncryptor(key)
 	if err != nil {
 		return nil, errors.Wrap(errors.Unknown, "Create Object Encryptor error : "+err.Error())
 	}
@@ -96,7 +200,8 @@ func InitializeEncryptor(provider, key string) (IOEncryptor, error) {
 	return oe, nil
 }
 
-func createEncryptor(key []byte) (IOEncryptor, error) {
+// creates the cipher for the key
+func createKey(id string, key []byte) (*encryptorKey, error) {
 	// Pad or truncate key to 32 bytes (AES-256).
 	nkey := make([]byte, 32)
 	for i := 0; i < 32; i++ {
@@ -107,6 +212,30 @@ func createEncryptor(key []byte) (IOEncryptor, error) {
 		}
 	}
 
+	block, err := aes.NewCipher(nkey)
+	if err != nil {
+		return nil, err
+	}
+
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// derive a separate key for the nonces of the deterministic
+	// encryption, instead of using the encryption key for both
+	mac := hmac.New(sha256.New, nkey)
+	mac.Write([]byte("core deterministic nonce"))
+
+	return &encryptorKey{id: id, gcm: aesgcm, sivKey: mac.Sum(nil)}, nil
+}
+
+func createEncryptor(key []byte) (IOEncryptor, error) {
+	k, err := createKey("", key)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build the legacy static nonce so old data can still be decrypted.
 	legNonce := make([]byte, 12)
 	for i := 0; i < 12; i++ {
@@ -117,36 +246,119 @@ func createEncryptor(key []byte) (IOEncryptor, error) {
 		}
 	}
 
-	block, err := aes.NewCipher(nkey)
+	return &encryptorImpl{
+		keys:        map[string]*encryptorKey{"": k},
+		active:      k,
+		legacyNonce: legNonce,
+	}, nil
+}
+
+func (c *encryptorImpl) AddKey(id string, key string) error {
+	if id == "" || len(id) > maxKeyIDLength {
+		return errors.Wrapf(errors.InvalidArgument, "Invalid Key id %q", id)
+	}
+	if len(key) <= 0 {
+		return errors.Wrap(errors.InvalidArgument, "Invalid Key length")
+	}
+	k, err := createKey(id, []byte(key))
 	if err != nil {
-		return nil, err
+		return errors.Wrap(errors.Unknown, "Create Key error : "+err.Error())
 	}
 
-	aesgcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, err
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.keys[id]; ok {
+		return errors.Wrapf(errors.AlreadyExists, "Key %s already exists", id)
 	}
+	c.keys[id] = k
+	c.active = k
+	return nil
+}
 
-	return &encryptorImpl{gcm: aesgcm, legacyNonce: legNonce}, nil
+func (c *encryptorImpl) RetireKey(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k, ok := c.keys[id]
+	if !ok {
+		return errors.Wrapf(errors.NotFound, "Key %s not found", id)
+	}
+	if k == c.active {
+		return errors.Wrapf(errors.InvalidArgument, "Key %s is the active key", id)
+	}
+	delete(c.keys, id)
+	return nil
 }
 
 func (c *encryptorImpl) EncryptObject(o interface{}) (interface{}, error) {
-	return c.processObject(o, false, c.EncryptString)
+	return c.processObject(o, encryptNone, c.encryptString)
 }
 
 func (c *encryptorImpl) DecryptObject(o interface{}) (interface{}, error) {
-	return c.processObject(o, false, c.DecryptString)
+	return c.processObject(o, encryptNone, func(ciphermessage string, _ encryptMode) (string, error) {
+		return c.DecryptString(ciphermessage)
+	})
+}
+
+func (c *encryptorImpl) ReEncrypt(o interface{}) (interface{}, error) {
+	return c.processObject(o, encryptNone, c.reEncryptString)
+}
+
+// encrypts the message as per the mode
+func (c *encryptorImpl) encryptString(message string, mode encryptMode) (string, error) {
+	if mode == encryptDeterministic {
+		return c.EncryptDeterministic(message)
+	}
+	return c.EncryptString(message)
 }
 
 func (c *encryptorImpl) EncryptString(message string) (string, error) {
-	nonceSize := c.gcm.NonceSize()
-	nonce := make([]byte, nonceSize)
+	c.mu.RLock()
+	k := c.active
+	c.mu.RUnlock()
+
+	nonce := make([]byte, k.gcm.NonceSize())
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return "", fmt.Errorf("generating nonce: %w", err)
 	}
-	// Seal appends ciphertext to nonce, producing: nonce || ciphertext.
-	ciphermessage := c.gcm.Seal(nonce, nonce, []byte(message), nil)
-	return hex.EncodeToString(ciphermessage), nil
+	return seal(k, cipherVersion2, nonce, message), nil
+}
+
+func (c *encryptorImpl) EncryptDeterministic(message string) (string, error) {
+	c.mu.RLock()
+	k := c.active
+	c.mu.RUnlock()
+
+	// synthetic nonce derived from the message, so the same nonce is
+	// never used for different messages
+	mac := hmac.New(sha256.New, k.sivKey)
+	mac.Write([]byte(message))
+	nonce := mac.Sum(nil)[:k.gcm.NonceSize()]
+	return seal(k, cipherVersion3, nonce, message), nil
+}
+
+// seals the message using the key and the nonce, returning the hex
+// encoded ciphertext in the format of the given version
+func seal(k *encryptorKey, version byte, nonce []byte, message string) string {
+	// Seal appends ciphertext to the header, producing:
+	// version || key id length || key id || nonce || ciphertext.
+	header := make([]byte, 0, 2+len(k.id)+len(nonce)+len(message)+k.gcm.Overhead())
+	header = append(header, version, byte(len(k.id)))
+	header = append(header, k.id...)
+	header = append(header, nonce...)
+	ciphermessage := k.gcm.Seal(header, nonce, []byte(message), nil)
+	return hex.EncodeToString(ciphermessage)
+}
+
+// returns the key used for the ciphertext in the current formats, nil
+// if the ciphertext is not in the current formats or the key is retired
+func (c *encryptorImpl) keyOf(cm []byte) (*encryptorKey, []byte) {
+	if len(cm) < 2 || (cm[0] != cipherVersion2 && cm[0] != cipherVersion3) || len(cm) < 2+int(cm[1]) {
+		return nil, nil
+	}
+	id := string(cm[2 : 2+int(cm[1])])
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.keys[id], cm[2+int(cm[1]):]
 }
 
 func (c *encryptorImpl) DecryptString(ciphermessage string) (string, error) {
@@ -155,61 +367,160 @@ func (c *encryptorImpl) DecryptString(ciphermessage string) (string, error) {
 		return "", err
 	}
 
-	nonceSize := c.gcm.NonceSize()
+	// Current format: version and key id are prepended to the nonce and
+	// the ciphertext, where a failure to open falls back to the older
+	// formats, as those may start with the same bytes by chance.
+	if k, rest := c.keyOf(cm); k != nil {
+		nonceSize := k.gcm.NonceSize()
+		if len(rest) > nonceSize {
+			message, err := k.gcm.Open(nil, rest[:nonceSize], rest[nonceSize:], nil)
+			if err == nil {
+				return string(message), nil
+			}
+		}
+	}
+
+	// Older formats don't identify the key, so all the keys available
+	// are tried, the key provided on initialization first
+	c.mu.RLock()
+	keys := make([]*encryptorKey, 0, len(c.keys))
+	if k, ok := c.keys[""]; ok {
+		keys = append(keys, k)
+	}
+	for id, k := range c.keys {
+		if id != "" {
+			keys = append(keys, k)
+		}
+	}
+	c.mu.RUnlock()
+
+	err = errors.Wrap(errors.NotFound, "Key not found")
+	for _, k := range keys {
+		var message []byte
+		message, err = c.decryptLegacy(k, cm)
+		if err == nil {
+			return string(message), nil
+		}
+	}
+	return "", err
+}
+
+// decrypts the ciphertext written using the older formats
+func (c *encryptorImpl) decryptLegacy(k *encryptorKey, cm []byte) ([]byte, error) {
+	nonceSize := k.gcm.NonceSize()
 
-	// New format: nonce is prepended to the ciphertext.
+	// Versioned format: version and nonce are prepended to the
+	// ciphertext.
+	if len(cm) > 1+nonceSize && cm[0] == cipherVersion1 {
+		nonce, ct := cm[1:1+nonceSize], cm[1+nonceSize:]
+		message, err := k.gcm.Open(nil, nonce, ct, nil)
+		if err == nil {
+			return message, nil
+		}
+	}
+
+	// Unversioned format: nonce is prepended to the ciphertext.
 	if len(cm) > nonceSize {
 		nonce, ct := cm[:nonceSize], cm[nonceSize:]
-		message, err := c.gcm.Open(nil, nonce, ct, nil)
+		message, err := k.gcm.Open(nil, nonce, ct, nil)
 		if err == nil {
-			return string(message), nil
+			return message, nil
 		}
 	}
 
 	// Legacy fallback: data encrypted with the old static nonce
 	// (no prepended nonce — entire payload is ciphertext).
-	message, err := c.gcm.Open(nil, c.legacyNonce, cm, nil)
+	return k.gcm.Open(nil, c.legacyNonce, cm, nil)
+}
+
+// re-encrypts the ciphertext using the active key as per the mode,
+// unless already encrypted using the active key in the current format
+// for the mode
+func (c *encryptorImpl) reEncryptString(ciphermessage string, mode encryptMode) (string, error) {
+	cm, err := hex.DecodeString(ciphermessage)
+	if err != nil {
+		return "", err
+	}
+	message, err := c.DecryptString(ciphermessage)
 	if err != nil {
 		return "", err
 	}
+	k, _ := c.keyOf(cm)
+	c.mu.RLock()
+	active := c.active
+	c.mu.RUnlock()
+	version := cipherVersion2
+	if mode == encryptDeterministic {
+		version = cipherVersion3
+	}
+	if k == active && cm[0] == version {
+		return ciphermessage, nil
+	}
+	return c.encryptString(message, mode)
+}
 
-	return string(message), nil
+// returns the value to be set for the processed object of the given
+// type, where nil is set as the zero value, eg. for the nil interfaces
+func valueOf(o interface{}, t reflect.Type) reflect.Value {
+	if o == nil {
+		return reflect.Zero(t)
+	}
+	return reflect.ValueOf(o)
 }
 
-func (c *encryptorImpl) processObject(o interface{}, encrypt bool, oper func(string) (string, error)) (interface{}, error) {
+// processObject walks the object, applying the operation on the string
+// and the byte slice values of the fields tagged encrypted, including the
+// ones nested in the structs, pointers, interfaces, arrays, slices and
+// maps of such fields, while the fields tagged `encrypted:"-"` are skipped
+// even if nested in a field tagged encrypted. The fields tagged
+// `encrypted:"deterministic"`, along with the ones nested in them, are
+// encrypted deterministically
+func (c *encryptorImpl) processObject(o interface{}, mode encryptMode, oper func(string, encryptMode) (string, error)) (interface{}, error) {
+	if o == nil {
+		// nothing to process for a nil interface
+		return nil, nil
+	}
 	t := reflect.TypeOf(o)
 	switch t.Kind() {
 	case reflect.String:
 		// only support do encryption on string field
-		if encrypt {
-			val, err := oper(o.(string))
+		if mode != encryptNone {
+			val, err := oper(reflect.ValueOf(o).String(), mode)
 			if err != nil {
 				return nil, err
 			}
 
-			return val, nil
+			// retain the named string types
+			return reflect.ValueOf(val).Convert(t).Interface(), nil
 		}
 	case reflect.Ptr:
 		v := reflect.ValueOf(o)
-		newv, err := c.processObject(v.Elem().Interface(), encrypt, oper)
+		if v.IsNil() {
+			// nothing to process for a nil pointer
+			return o, nil
+		}
+		newv, err := c.processObject(v.Elem().Interface(), mode, oper)
 		if err != nil {
 			return nil, err
 		}
-		v.Elem().Set(reflect.ValueOf(newv))
+		v.Elem().Set(valueOf(newv, v.Elem().Type()))
 		return o, nil
 	case reflect.Struct:
 		v := reflect.ValueOf(&o).Elem()
 		newv := reflect.New(v.Elem().Type()).Elem()
 		newv.Set(v.Elem())
 		for k := 0; k < t.NumField(); k++ {
-			_, fieldEncrypt := t.Field(k).Tag.Lookup("encrypted")
-			isEncrypt := fieldEncrypt || encrypt
+			tag, tagged := t.Field(k).Tag.Lookup("encrypted")
+			if tag == "-" {
+				// field explicitly excluded from encryption
+				continue
+			}
 			if t.Field(k).IsExported() {
-				newf, err := c.processObject(newv.Field(k).Interface(), isEncrypt, oper)
+				newf, err := c.processObject(newv.Field(k).Interface(), mode.field(tag, tagged), oper)
 				if err != nil {
 					return nil, err
 				}
-				newv.Field(k).Set(reflect.ValueOf(newf))
+				newv.Field(k).Set(valueOf(newf, t.Field(k).Type))
 			}
 		}
 		return newv.Interface(), nil
@@ -217,33 +528,52 @@ func (c *encryptorImpl) processObject(o interface{}, encrypt bool, oper func(str
 		v := reflect.ValueOf(o)
 		newv := reflect.New(t).Elem()
 		for k := 0; k < v.Len(); k++ {
-			newf, err := c.processObject(v.Index(k).Interface(), encrypt, oper)
+			newf, err := c.processObject(v.Index(k).Interface(), mode, oper)
 			if err != nil {
 				return nil, err
 			}
-			newv.Index(k).Set(reflect.ValueOf(newf))
+			newv.Index(k).Set(valueOf(newf, t.Elem()))
 		}
 		return newv.Interface(), nil
 	case reflect.Slice:
 		v := reflect.ValueOf(o)
+		if v.IsNil() {
+			// retain the nil slices as is
+			return o, nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			// byte slices are processed as a whole, like the strings
+			if mode == encryptNone {
+				return o, nil
+			}
+			val, err := oper(string(v.Bytes()), mode)
+			if err != nil {
+				return nil, err
+			}
+			return reflect.ValueOf([]byte(val)).Convert(t).Interface(), nil
+		}
 		newv := reflect.MakeSlice(t, v.Len(), v.Len())
 		for k := 0; k < v.Len(); k++ {
-			newf, err := c.processObject(v.Index(k).Interface(), encrypt, oper)
+			newf, err := c.processObject(v.Index(k).Interface(), mode, oper)
 			if err != nil {
 				return nil, err
 			}
-			newv.Index(k).Set(reflect.ValueOf(newf))
+			newv.Index(k).Set(valueOf(newf, t.Elem()))
 		}
 		return newv.Interface(), nil
 	case reflect.Map:
 		v := reflect.ValueOf(o)
+		if v.IsNil() {
+			// retain the nil maps as is
+			return o, nil
+		}
 		newv := reflect.MakeMap(t)
 		for _, k := range v.MapKeys() {
-			newf, err := c.processObject(v.MapIndex(k).Interface(), encrypt, oper)
+			newf, err := c.processObject(v.MapIndex(k).Interface(), mode, oper)
 			if err != nil {
 				return nil, err
 			}
-			newv.SetMapIndex(k, reflect.ValueOf(newf))
+			newv.SetMapIndex(k, valueOf(newf, t.Elem()))
 		}
 		return newv.Interface(), nil
 	default:
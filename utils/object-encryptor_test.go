@@ -0,0 +1,282 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+func Test_EncryptorCipherFormat(t *testing.T) {
+	enc, err := createEncryptor([]byte("utils-test-key"))
+	if err != nil {
+		t.Fatalf("failed to create encryptor: %s", err)
+	}
+	c := enc.(*encryptorImpl)
+	gcm := c.active.gcm
+
+	t.Run("versioned", func(t *testing.T) {
+		first, err := c.EncryptString("my-secret")
+		if err != nil {
+			t.Fatalf("failed to encrypt: %s", err)
+		}
+		second, _ := c.EncryptString("my-secret")
+		if first == second {
+			t.Errorf("expected a random nonce per encryption")
+		}
+		raw, _ := hex.DecodeString(first)
+		if raw[0] != cipherVersion2 || raw[1] != 0 || len(raw) != 2+gcm.NonceSize()+len("my-secret")+gcm.Overhead() {
+			t.Errorf("expected versioned ciphertext, got %x", raw)
+		}
+		for _, cm := range []string{first, second} {
+			if msg, err := c.DecryptString(cm); err != nil || msg != "my-secret" {
+				t.Errorf("expected decrypted message, got %q, %v", msg, err)
+			}
+		}
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		nonce := make([]byte, gcm.NonceSize())
+		_, _ = rand.Read(nonce)
+		versioned := gcm.Seal(append([]byte{cipherVersion1}, nonce...), nonce, []byte("versioned-secret"), nil)
+		unversioned := gcm.Seal(nonce, nonce, []byte("old-secret"), nil)
+		static := gcm.Seal(nil, c.legacyNonce, []byte("older-secret"), nil)
+		if msg, err := c.DecryptString(hex.EncodeToString(versioned)); err != nil || msg != "versioned-secret" {
+			t.Errorf("expected versioned ciphertext decrypted, got %q, %v", msg, err)
+		}
+		if msg, err := c.DecryptString(hex.EncodeToString(unversioned)); err != nil || msg != "old-secret" {
+			t.Errorf("expected unversioned ciphertext decrypted, got %q, %v", msg, err)
+		}
+		if msg, err := c.DecryptString(hex.EncodeToString(static)); err != nil || msg != "older-secret" {
+			t.Errorf("expected static nonce ciphertext decrypted, got %q, %v", msg, err)
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		cm, _ := c.EncryptString("my-secret")
+		raw, _ := hex.DecodeString(cm)
+		raw[len(raw)-1] ^= 0xff
+		if _, err := c.DecryptString(hex.EncodeToString(raw)); err == nil {
+			t.Errorf("expected tampered ciphertext to fail decryption")
+		}
+		if _, err := c.DecryptString("not-hex"); err == nil {
+			t.Errorf("expected invalid ciphertext to fail decryption")
+		}
+	})
+}
+
+type rotationData struct {
+	Name   string
+	Secret string `encrypted:"true"`
+}
+
+func Test_EncryptorKeyRotation(t *testing.T) {
+	enc, err := createEncryptor([]byte("utils-rotation-key"))
+	if err != nil {
+		t.Fatalf("failed to create encryptor: %s", err)
+	}
+	c := enc.(*encryptorImpl)
+	legacy := c.active.gcm
+	static := hex.EncodeToString(legacy.Seal(nil, c.legacyNonce, []byte("static-secret"), nil))
+
+	old, _ := enc.EncryptString("old-secret")
+	obj, err := enc.EncryptObject(rotationData{Name: "entry-1", Secret: "entry-secret"})
+	if err != nil {
+		t.Fatalf("failed to encrypt object: %s", err)
+	}
+
+	if err := enc.AddKey("", "key"); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for empty key id, got %v", err)
+	}
+	if err := enc.AddKey("v2", ""); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument for empty key, got %v", err)
+	}
+	if err := enc.AddKey("v2", "utils-rotation-key-v2"); err != nil {
+		t.Fatalf("failed to add key: %s", err)
+	}
+	if err := enc.AddKey("v2", "utils-rotation-key-v2"); !errors.IsAlreadyExists(err) {
+		t.Errorf("expected already exists adding key twice, got %v", err)
+	}
+
+	current, _ := enc.EncryptString("new-secret")
+	raw, _ := hex.DecodeString(current)
+	if raw[0] != cipherVersion2 || string(raw[2:2+raw[1]]) != "v2" {
+		t.Errorf("expected encryption using the active key v2, got %x", raw)
+	}
+	for cm, expected := range map[string]string{old: "old-secret", current: "new-secret", static: "static-secret"} {
+		if msg, err := enc.DecryptString(cm); err != nil || msg != expected {
+			t.Errorf("expected %q decrypted, got %q, %v", expected, msg, err)
+		}
+	}
+
+	// upgrade the values encrypted using the older key
+	upgraded, err := enc.ReEncrypt(obj)
+	if err != nil {
+		t.Fatalf("failed to re-encrypt: %s", err)
+	}
+	data := upgraded.(rotationData)
+	raw, _ = hex.DecodeString(data.Secret)
+	if data.Name != "entry-1" || string(raw[2:2+raw[1]]) != "v2" {
+		t.Errorf("expected secret re-encrypted using v2, got %+v", data)
+	}
+	if again, _ := enc.ReEncrypt(upgraded); again.(rotationData).Secret != data.Secret {
+		t.Errorf("expected values encrypted using active key to be retained")
+	}
+
+	if err := enc.RetireKey("v2"); !errors.IsInvalidArgument(err) {
+		t.Errorf("expected invalid argument retiring the active key, got %v", err)
+	}
+	if err := enc.RetireKey("v3"); !errors.IsNotFound(err) {
+		t.Errorf("expected not found retiring unknown key, got %v", err)
+	}
+	if err := enc.RetireKey(""); err != nil {
+		t.Fatalf("failed to retire key: %s", err)
+	}
+	if _, err := enc.DecryptString(old); err == nil {
+		t.Errorf("expected values encrypted using retired key to fail decryption")
+	}
+	decrypted, err := enc.DecryptObject(upgraded)
+	if err != nil || decrypted.(rotationData).Secret != "entry-secret" {
+		t.Errorf("expected upgraded value decrypted after retiring old key, got %v, %v", decrypted, err)
+	}
+}
+
+type secretName string
+
+type shapeInner struct {
+	Token string
+	Plain string `encrypted:"-"`
+}
+
+type shapeData struct {
+	Name     string
+	Key      []byte             `encrypted:"true"`
+	Named    secretName         `encrypted:"true"`
+	Items    []*shapeInner      `encrypted:"true"`
+	Index    map[string]*string `encrypted:"true"`
+	Any      interface{}        `encrypted:"true"`
+	Empty    interface{}        `encrypted:"true"`
+	Nil      []string           `encrypted:"true"`
+	Skipped  string             `encrypted:"-"`
+	Embedded interface{}
+}
+
+func Test_EncryptorObjectShapes(t *testing.T) {
+	enc, err := createEncryptor([]byte("utils-shapes-key"))
+	if err != nil {
+		t.Fatalf("failed to create encryptor: %s", err)
+	}
+	value := "indexed"
+	obj := &shapeData{
+		Name:    "entry-1",
+		Key:     []byte("raw-key"),
+		Named:   "named-secret",
+		Items:   []*shapeInner{{Token: "token-1", Plain: "plain-1"}, nil},
+		Index:   map[string]*string{"key": &value, "nil": nil},
+		Any:     shapeInner{Token: "token-2"},
+		Skipped: "skipped",
+		Embedded: &struct {
+			Secret string `encrypted:"true"`
+		}{Secret: "embedded"},
+	}
+	if _, err := enc.EncryptObject(obj); err != nil {
+		t.Fatalf("failed to encrypt object: %s", err)
+	}
+	embedded := obj.Embedded.(*struct {
+		Secret string `encrypted:"true"`
+	})
+	if string(obj.Key) == "raw-key" || obj.Named == "named-secret" || obj.Items[0].Token == "token-1" ||
+		*obj.Index["key"] == "indexed" || obj.Any.(shapeInner).Token == "token-2" || embedded.Secret == "embedded" {
+		t.Errorf("expected tagged fields to be encrypted, got %+v", obj)
+	}
+	if obj.Name != "entry-1" || obj.Skipped != "skipped" || obj.Items[0].Plain != "plain-1" {
+		t.Errorf("expected untagged and skipped fields as is, got %+v", obj)
+	}
+	if obj.Items[1] != nil || obj.Index["nil"] != nil || obj.Empty != nil || obj.Nil != nil {
+		t.Errorf("expected nil values to be retained, got %+v", obj)
+	}
+
+	if _, err := enc.DecryptObject(obj); err != nil {
+		t.Fatalf("failed to decrypt object: %s", err)
+	}
+	if string(obj.Key) != "raw-key" || obj.Named != "named-secret" || obj.Items[0].Token != "token-1" ||
+		*obj.Index["key"] != "indexed" || obj.Any.(shapeInner).Token != "token-2" || embedded.Secret != "embedded" {
+		t.Errorf("expected tagged fields to be decrypted, got %+v", obj)
+	}
+}
+
+type deterministicInner struct {
+	Secret string `encrypted:"true"`
+}
+
+type deterministicData struct {
+	Email  string             `encrypted:"deterministic"`
+	Secret string             `encrypted:"true"`
+	Nested deterministicInner `encrypted:"deterministic"`
+}
+
+func Test_EncryptorDeterministic(t *testing.T) {
+	enc, err := createEncryptor([]byte("utils-deterministic-key"))
+	if err != nil {
+		t.Fatalf("failed to create encryptor: %s", err)
+	}
+	first, _ := enc.EncryptDeterministic("user@example.com")
+	second, _ := enc.EncryptDeterministic("user@example.com")
+	other, _ := enc.EncryptDeterministic("other@example.com")
+	if first != second || first == other {
+		t.Errorf("expected same ciphertext only for the same message")
+	}
+	if raw, _ := hex.DecodeString(first); raw[0] != cipherVersion3 {
+		t.Errorf("expected deterministic ciphertext format, got %x", raw)
+	}
+	if msg, err := enc.DecryptString(first); err != nil || msg != "user@example.com" {
+		t.Errorf("expected deterministic ciphertext decrypted, got %q, %v", msg, err)
+	}
+
+	encrypt := func() deterministicData {
+		obj, err := enc.EncryptObject(deterministicData{
+			Email:  "user@example.com",
+			Secret: "secret",
+			Nested: deterministicInner{Secret: "nested"},
+		})
+		if err != nil {
+			t.Fatalf("failed to encrypt object: %s", err)
+		}
+		return obj.(deterministicData)
+	}
+	a, b := encrypt(), encrypt()
+	if a.Email != first || a.Email != b.Email || a.Nested.Secret != b.Nested.Secret {
+		t.Errorf("expected deterministic fields to match the query value, got %+v", a)
+	}
+	if a.Secret == b.Secret {
+		t.Errorf("expected randomized fields to differ across encryptions")
+	}
+	decrypted, err := enc.DecryptObject(a)
+	if d := decrypted.(deterministicData); err != nil || d.Email != "user@example.com" || d.Secret != "secret" || d.Nested.Secret != "nested" {
+		t.Errorf("expected object decrypted, got %+v, %v", decrypted, err)
+	}
+
+	// deterministic fields remain deterministic when upgraded
+	if err := enc.AddKey("v2", "utils-deterministic-key-v2"); err != nil {
+		t.Fatalf("failed to add key: %s", err)
+	}
+	upgraded, err := enc.ReEncrypt(a)
+	if err != nil {
+		t.Fatalf("failed to re-encrypt: %s", err)
+	}
+	query, _ := enc.EncryptDeterministic("user@example.com")
+	if u := upgraded.(deterministicData); u.Email != query || u.Email == a.Email {
+		t.Errorf("expected deterministic field re-encrypted using the active key, got %+v", u)
+	}
+	// randomized values upgraded when the field becomes deterministic
+	random, _ := enc.EncryptString("user@example.com")
+	a.Email = random
+	converted, err := enc.ReEncrypt(a)
+	if err != nil || converted.(deterministicData).Email != query {
+		t.Errorf("expected randomized value converted to deterministic")
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package smtp
+
+import (
+	"net/smtp"
+	"strings"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// returns an error if the credentials would be sent in the clear, ie.
+// over an unencrypted connection to a server other than localhost
+func requireTLS(server *smtp.ServerInfo) error {
+	if server.TLS {
+		return nil
+	}
+	switch server.Name {
+	case "localhost", "127.0.0.1", "::1":
+		return nil
+	}
+	return errors.Wrap(errors.Forbidden, "unencrypted connection")
+}
+
+// auth using the LOGIN mechanism
+type loginAuth struct {
+	username string
+	password string
+}
+
+// LoginAuth returns the auth implementing the LOGIN mechanism, supported
+// by the relays not supporting PLAIN, eg. the Exchange servers, where the
+// credentials are sent only over TLS or to localhost
+func LoginAuth(username, password string) smtp.Auth {
+	return &loginAuth{username: username, password: password}
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if err := requireTLS(server); err != nil {
+		return "", nil, err
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	}
+	return nil, errors.Wrapf(errors.InvalidArgument, "unexpected LOGIN challenge %q", fromServer)
+}
+
+// CRAMMD5Auth returns the auth implementing the CRAM-MD5 mechanism, where
+// the secret is never sent to the server
+func CRAMMD5Auth(username, secret string) smtp.Auth {
+	return smtp.CRAMMD5Auth(username, secret)
+}
+
+// auth using the XOAUTH2 mechanism
+type xoauth2Auth struct {
+	username string
+	token    string
+}
+
+// XOAUTH2Auth returns the auth implementing the XOAUTH2 mechanism, using
+// the OAuth 2.0 access token, eg. for Gmail and Office 365, where the
+// token is sent only over TLS or to localhost
+func XOAUTH2Auth(username, token string) smtp.Auth {
+	return &xoauth2Auth{username: username, token: token}
+}
+
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if err := requireTLS(server); err != nil {
+		return "", nil, err
+	}
+	resp := "user=" + a.username + "\x01auth=Bearer " + a.token + "\x01\x01"
+	return "XOAUTH2", []byte(resp), nil
+}
+
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		// server responds with the error details, to be acknowledged
+		// with an empty response before failing the auth
+		return []byte{}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,236 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package smtp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// creates the self signed certificate for the localhost server
+func localhostCertificate(t *testing.T) (tls.Certificate, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, cert
+}
+
+// fake smtp server recording the auth and the messages received
+type fakeServer struct {
+	ln       net.Listener
+	tls      *tls.Config
+	starttls bool
+
+	mu    sync.Mutex
+	auth  []string
+	data  string
+	isTLS bool
+}
+
+func startFakeServer(t *testing.T, config *tls.Config, implicit bool, starttls bool) *fakeServer {
+	var ln net.Listener
+	var err error
+	if implicit {
+		ln, err = tls.Listen("tcp", "127.0.0.1:0", config)
+	} else {
+		ln, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	s := &fakeServer{ln: ln, tls: config, starttls: starttls}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, implicit)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) port() string {
+	_, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	return port
+}
+
+func (s *fakeServer) serve(conn net.Conn, secure bool) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 fake ESMTP")
+	readAuth := func() string {
+		line, _ := tp.ReadLine()
+		decoded, _ := base64.StdEncoding.DecodeString(line)
+		return string(decoded)
+	}
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO":
+			_ = tp.PrintfLine("250-fake")
+			if s.starttls && !secure {
+				_ = tp.PrintfLine("250-STARTTLS")
+			}
+			_ = tp.PrintfLine("250 AUTH PLAIN LOGIN XOAUTH2")
+		case "STARTTLS":
+			_ = tp.PrintfLine("220 ready")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+			tp = textproto.NewConn(conn)
+		case "AUTH":
+			mech, initial, _ := strings.Cut(arg, " ")
+			auth := mech
+			switch mech {
+			case "LOGIN":
+				_ = tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Username:")))
+				auth += " " + readAuth()
+				_ = tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Password:")))
+				auth += " " + readAuth()
+			default:
+				decoded, _ := base64.StdEncoding.DecodeString(initial)
+				auth += " " + string(decoded)
+			}
+			s.mu.Lock()
+			s.auth = append(s.auth, auth)
+			s.isTLS = secure
+			s.mu.Unlock()
+			_ = tp.PrintfLine("235 ok")
+		case "MAIL", "RCPT":
+			_ = tp.PrintfLine("250 ok")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, _ := tp.ReadDotBytes()
+			s.mu.Lock()
+			s.data = string(data)
+			s.mu.Unlock()
+			_ = tp.PrintfLine("250 ok")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 unknown")
+		}
+	}
+}
+
+func Test_SendTLSAndAuth(t *testing.T) {
+	serverCert, rootCert := localhostCertificate(t)
+	serverTLS := &tls.Config{Certificates: []tls.Certificate{serverCert}}
+	message := &Message{Receivers: []string{"a@example.com"}, Subject: "hello", Body: "body"}
+
+	t.Run("starttls", func(t *testing.T) {
+		s := startFakeServer(t, serverTLS, false, true)
+		config := Config{Host: "127.0.0.1", Port: s.port(), Sender: "alerts@example.com", Password: "pass"}
+		if err := New(config).Send(message); err == nil {
+			t.Errorf("expected failure verifying server certificate without root")
+		}
+		config.RootCAs = RootCAs(rootCert)
+		if err := New(config).Send(message); err != nil {
+			t.Fatalf("failed to send: %s", err)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.isTLS || len(s.auth) != 1 || s.auth[0] != "PLAIN \x00alerts@example.com\x00pass" {
+			t.Errorf("expected PLAIN auth over TLS, got %q, tls %v", s.auth, s.isTLS)
+		}
+		if !strings.Contains(s.data, "Subject: hello") {
+			t.Errorf("expected message delivered, got %q", s.data)
+		}
+	})
+
+	t.Run("starttls required", func(t *testing.T) {
+		s := startFakeServer(t, serverTLS, false, false)
+		config := Config{Host: "127.0.0.1", Port: s.port(), Sender: "alerts@example.com", TLS: TLSStartTLS}
+		if err := New(config).Send(message); !errors.IsUnavailable(err) {
+			t.Errorf("expected unavailable without STARTTLS support, got %v", err)
+		}
+	})
+
+	t.Run("implicit login", func(t *testing.T) {
+		s := startFakeServer(t, serverTLS, true, false)
+		config := Config{
+			Host:    "127.0.0.1",
+			Port:    s.port(),
+			Sender:  "alerts@example.com",
+			TLS:     TLSImplicit,
+			RootCAs: RootCAs(rootCert),
+			Auth:    LoginAuth("alerts", "secret"),
+		}
+		if err := New(config).Send(message); err != nil {
+			t.Fatalf("failed to send: %s", err)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.isTLS || len(s.auth) != 1 || s.auth[0] != "LOGIN alerts secret" {
+			t.Errorf("expected LOGIN auth over TLS, got %q, tls %v", s.auth, s.isTLS)
+		}
+	})
+
+	t.Run("xoauth2", func(t *testing.T) {
+		s := startFakeServer(t, serverTLS, false, true)
+		config := Config{Host: "127.0.0.1", Port: s.port(), Sender: "alerts@example.com", TLS: TLSNone, Auth: XOAUTH2Auth("alerts@example.com", "token")}
+		if err := New(config).Send(message); err != nil {
+			t.Fatalf("failed to send: %s", err)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.isTLS || len(s.auth) != 1 || s.auth[0] != "XOAUTH2 user=alerts@example.com\x01auth=Bearer token\x01\x01" {
+			t.Errorf("expected XOAUTH2 auth without TLS, got %q, tls %v", s.auth, s.isTLS)
+		}
+	})
+
+	t.Run("unencrypted", func(t *testing.T) {
+		server := &smtp.ServerInfo{Name: "smtp.example.com", Auth: []string{"LOGIN", "XOAUTH2"}}
+		for _, auth := range []smtp.Auth{LoginAuth("alerts", "secret"), XOAUTH2Auth("alerts", "token")} {
+			if _, _, err := auth.Start(server); !errors.IsForbidden(err) {
+				t.Errorf("expected credentials refused over unencrypted connection, got %v", err)
+			}
+		}
+		if _, err := LoginAuth("alerts", "secret").Next([]byte("Bogus:"), true); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for unexpected challenge, got %v", err)
+		}
+	})
+}
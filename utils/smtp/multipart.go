@@ -0,0 +1,199 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package smtp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"path/filepath"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+const (
+	// maximum length of the lines of the base64 encoded attachments
+	base64LineLength = 76
+)
+
+// Attachment of a message, either a file attached or an inline image
+// referred from the HTML body
+type Attachment struct {
+	// name of the file attached
+	Filename string
+
+	// MIME content type of the file, if empty derived from the extension
+	// of the filename, defaulting to application/octet-stream
+	ContentType string
+
+	// content of the file, read while sending the message
+	Content io.Reader
+
+	// if this is an inline image, referred from the HTML body using
+	// "cid:" followed by the ContentID, eg. <img src="cid:logo.png">
+	Inline bool
+
+	// content id of the inline image, defaults to the filename
+	ContentID string
+}
+
+// returns the content type of the attachment
+func (a *Attachment) contentType() string {
+	if a.ContentType != "" {
+		return a.ContentType
+	}
+	if ct := mime.TypeByExtension(filepath.Ext(a.Filename)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// returns the content id of the inline attachment
+func (a *Attachment) contentID() string {
+	if a.ContentID != "" {
+		return a.ContentID
+	}
+	return a.Filename
+}
+
+// wraps the lines written to the underlying writer at the given length
+type lineWriter struct {
+	w      io.Writer
+	length int
+	col    int
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		if l.col == l.length {
+			if _, err := io.WriteString(l.w, "\r\n"); err != nil {
+				return n, err
+			}
+			l.col = 0
+		}
+		m := min(len(p), l.length-l.col)
+		if _, err := l.w.Write(p[:m]); err != nil {
+			return n, err
+		}
+		l.col += m
+		n += m
+		p = p[m:]
+	}
+	return n, nil
+}
+
+// writes the body of the message as a quoted printable part
+func writeBody(w *multipart.Writer, m *Message) error {
+	ct := "text/plain; charset=\"UTF-8\""
+	if m.Html {
+		ct = "text/html; charset=\"UTF-8\""
+	}
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {ct},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := io.WriteString(qp, m.Body); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writes the attachment as a base64 encoded part
+func writeAttachment(w *multipart.Writer, a *Attachment) error {
+	header := textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(a.contentType(), map[string]string{"name": a.Filename})},
+		"Content-Transfer-Encoding": {"base64"},
+	}
+	if a.Inline {
+		header.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": a.Filename}))
+		header.Set("Content-ID", "<"+a.contentID()+">")
+	} else {
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}))
+	}
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	enc := base64.NewEncoder(base64.StdEncoding, &lineWriter{w: part, length: base64LineLength})
+	if _, err := io.Copy(enc, a.Content); err != nil {
+		return errors.Wrapf(errors.Unknown, "failed to read attachment %s: %s", a.Filename, err)
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(part, "\r\n")
+	return err
+}
+
+// compose the message with the attachments as MIME multipart, where the
+// body along with the inline images is sent as multipart/related, and
+// the files attached follow as parts of multipart/mixed
+func composeMultipart(header string, m *Message) ([]byte, error) {
+	inline := []*Attachment{}
+	attached := []*Attachment{}
+	for i := range m.Attachments {
+		a := &m.Attachments[i]
+		if a.Filename == "" || a.Content == nil {
+			return nil, errors.Wrapf(errors.InvalidArgument, "attachment %d requires filename and content", i)
+		}
+		if a.Inline {
+			inline = append(inline, a)
+		} else {
+			attached = append(attached, a)
+		}
+	}
+
+	body := &bytes.Buffer{}
+	mixed := multipart.NewWriter(body)
+	if len(inline) == 0 {
+		if err := writeBody(mixed, m); err != nil {
+			return nil, err
+		}
+	} else {
+		related := &bytes.Buffer{}
+		rw := multipart.NewWriter(related)
+		if err := writeBody(rw, m); err != nil {
+			return nil, err
+		}
+		for _, a := range inline {
+			if err := writeAttachment(rw, a); err != nil {
+				return nil, err
+			}
+		}
+		if err := rw.Close(); err != nil {
+			return nil, err
+		}
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {"multipart/related; boundary=" + rw.Boundary()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(related.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	for _, a := range attached {
+		if err := writeAttachment(mixed, a); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+
+	message := fmt.Appendf(nil, "%sSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=%s\r\n\r\n",
+		header, m.Subject, mixed.Boundary())
+	return append(message, body.Bytes()...), nil
+}
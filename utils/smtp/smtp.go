@@ -7,8 +7,12 @@
 package smtp
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/smtp"
+
+	"github.com/go-core-stack/core/errors"
 )
 
 // Base Configuration with which an smtp client will be created.
@@ -33,6 +37,55 @@ type Config struct {
 
 	// Password for authenticating the sender with smtp server
 	Password string
+
+	// TLS mode of the connection with the smtp server
+	// Default: TLSOpportunistic
+	TLS TLSMode
+
+	// Root certificate authorities to verify the smtp server
+	// certificate with, eg. the root certificate of the certificate
+	// authority from certmanager using RootCAs
+	// Default: nil (system roots)
+	RootCAs *x509.CertPool
+
+	// skip verifying the smtp server certificate, insecure and meant
+	// only for the tests
+	InsecureSkipVerify bool
+
+	// Auth mechanism for authenticating the sender with smtp server,
+	// eg. LoginAuth, CRAMMD5Auth or XOAUTH2Auth
+	// Default: PLAIN auth with the Sender and the Password
+	Auth smtp.Auth
+}
+
+// TLSMode decides the use of TLS for the connection with the smtp server
+type TLSMode int
+
+const (
+	// upgrades the connection using STARTTLS if supported by the server
+	TLSOpportunistic TLSMode = iota
+
+	// requires upgrading the connection using STARTTLS, failing if not
+	// supported by the server, typically on port 587
+	TLSStartTLS
+
+	// connects using TLS right away, typically on port 465
+	TLSImplicit
+
+	// never uses TLS, where the PLAIN auth is refused by the client for
+	// any server other than localhost
+	TLSNone
+)
+
+// RootCAs returns the pool of the root certificates, eg. the root
+// certificate of the certificate authority from certmanager, to be used
+// as the RootCAs of the config
+func RootCAs(certs ...*x509.Certificate) *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return pool
 }
 
 // Smtp Client handle, used for triggering sending messages
@@ -57,6 +110,10 @@ type Message struct {
 
 	// if this is an HTML message
 	Html bool
+
+	// Files attached to the message, including the inline images
+	// referred from the HTML body, sent as a MIME multipart message
+	Attachments []Attachment
 }
 
 // Create a new Client handle for the given config
@@ -70,13 +127,96 @@ func New(config Config) *Client {
 
 func (c *Client) Send(m *Message) error {
 	// Authentication.
-	auth := smtp.PlainAuth("", c.config.Sender, c.config.Password, c.config.Host)
+	auth := c.config.Auth
+	if auth == nil {
+		auth = smtp.PlainAuth("", c.config.Sender, c.config.Password, c.config.Host)
+	}
 
-	mime := ""
-	if m.Html {
-		mime = "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
+	message, err := c.compose(m)
+	if err != nil {
+		return err
+	}
+
+	// Sending email.
+	client, err := c.dial()
+	if err != nil {
+		return err
 	}
+	defer client.Close()
 
+	if ok, _ := client.Extension("AUTH"); !ok {
+		return errors.Wrap(errors.Unavailable, "smtp server doesn't support AUTH")
+	}
+	if err := client.Auth(auth); err != nil {
+		return err
+	}
+	if err := client.Mail(c.config.Sender); err != nil {
+		return err
+	}
+	for _, receiver := range m.Receivers {
+		if err := client.Rcpt(receiver); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// dial the smtp server, setting up TLS as per the config
+func (c *Client) dial() (*smtp.Client, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         c.config.Host,
+		RootCAs:            c.config.RootCAs,
+		InsecureSkipVerify: c.config.InsecureSkipVerify,
+	}
+
+	var client *smtp.Client
+	if c.config.TLS == TLSImplicit {
+		conn, err := tls.Dial("tcp", c.endpoint, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		client, err = smtp.NewClient(conn, c.config.Host)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return client, nil
+	}
+
+	client, err := smtp.Dial(c.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if c.config.TLS == TLSNone {
+		return client, nil
+	}
+	if ok, _ := client.Extension("STARTTLS"); !ok {
+		if c.config.TLS == TLSStartTLS {
+			client.Close()
+			return nil, errors.Wrap(errors.Unavailable, "smtp server doesn't support STARTTLS")
+		}
+		return client, nil
+	}
+	if err := client.StartTLS(tlsConfig); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// compose the message to be sent, including the headers
+func (c *Client) compose(m *Message) ([]byte, error) {
 	var header string
 	if c.config.SenderName != "" {
 		// If sender name is provided, use it in the From header.
@@ -99,12 +239,14 @@ func (c *Client) Send(m *Message) error {
 		header += "\r\n"
 	}
 
-	message := fmt.Appendf(nil, "%sSubject: %s\n%s%s", header, m.Subject, mime, m.Body)
-	// Sending email.
-	err := smtp.SendMail(c.endpoint, auth, c.config.Sender, m.Receivers, message)
-	if err != nil {
-		return err
+	if len(m.Attachments) != 0 {
+		return composeMultipart(header, m)
+	}
+
+	mime := ""
+	if m.Html {
+		mime = "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
 	}
 
-	return nil
+	return fmt.Appendf(nil, "%sSubject: %s\n%s%s", header, m.Subject, mime, m.Body), nil
 }
@@ -0,0 +1,123 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package smtp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// reads the parts of the multipart body, indexed by the filename or the
+// content type for the parts without a filename
+func readParts(t *testing.T, contentType string, body io.Reader, parts map[string]string) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		t.Fatalf("expected multipart content type, got %s, %v", contentType, err)
+	}
+	r := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("failed to read part: %s", err)
+		}
+		ct := part.Header.Get("Content-Type")
+		if strings.HasPrefix(ct, "multipart/") {
+			readParts(t, ct, part, parts)
+			continue
+		}
+		var content []byte
+		if part.Header.Get("Content-Transfer-Encoding") == "base64" {
+			content, err = io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		} else {
+			content, err = io.ReadAll(part)
+		}
+		if err != nil {
+			t.Fatalf("failed to read part content: %s", err)
+		}
+		key := ct
+		if name := part.FileName(); name != "" {
+			key = name + "|" + part.Header.Get("Content-Disposition") + "|" + part.Header.Get("Content-ID")
+		}
+		parts[key] = string(content)
+	}
+}
+
+func Test_ComposeMessage(t *testing.T) {
+	c := New(Config{Host: "smtp.example.com", Port: "587", Sender: "alerts@example.com", SenderName: "Alerts"})
+
+	t.Run("plain", func(t *testing.T) {
+		message, err := c.compose(&Message{Receivers: []string{"a@example.com", "b@example.com"}, Subject: "hello", Body: "body"})
+		if err != nil {
+			t.Fatalf("failed to compose: %s", err)
+		}
+		expected := "From: Alerts <alerts@example.com>\r\nTo: a@example.com, b@example.com\r\nSubject: hello\nbody"
+		if string(message) != expected {
+			t.Errorf("expected message %q, got %q", expected, message)
+		}
+	})
+
+	t.Run("attachments", func(t *testing.T) {
+		report := bytes.Repeat([]byte("report line\n"), 100)
+		message, err := c.compose(&Message{
+			Receivers: []string{"a@example.com"},
+			Subject:   "daily report",
+			Body:      `<p>see attached</p><img src="cid:logo">`,
+			Html:      true,
+			Attachments: []Attachment{
+				{Filename: "report.csv", Content: bytes.NewReader(report)},
+				{Filename: "logo.png", Content: strings.NewReader("png-data"), Inline: true, ContentID: "logo"},
+				{Filename: "data.bin", ContentType: "application/x-custom", Content: strings.NewReader("binary")},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to compose: %s", err)
+		}
+		for _, line := range strings.Split(string(message), "\r\n") {
+			if len(line) > 998 {
+				t.Errorf("expected lines within the limit, got %d", len(line))
+			}
+		}
+		msg, err := mail.ReadMessage(bytes.NewReader(message))
+		if err != nil {
+			t.Fatalf("failed to parse message: %s", err)
+		}
+		if msg.Header.Get("Subject") != "daily report" || msg.Header.Get("MIME-Version") != "1.0" {
+			t.Errorf("unexpected headers %v", msg.Header)
+		}
+		parts := map[string]string{}
+		readParts(t, msg.Header.Get("Content-Type"), msg.Body, parts)
+		expected := map[string]string{
+			`text/html; charset="UTF-8"`:                  `<p>see attached</p><img src="cid:logo">`,
+			`report.csv|attachment; filename=report.csv|`: string(report),
+			`logo.png|inline; filename=logo.png|<logo>`:   "png-data",
+			`data.bin|attachment; filename=data.bin|`:     "binary",
+		}
+		if len(parts) != len(expected) {
+			t.Errorf("expected %d parts, got %v", len(expected), parts)
+		}
+		for key, content := range expected {
+			if parts[key] != content {
+				t.Errorf("expected part %s with %q, got %q", key, content, parts[key])
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := c.compose(&Message{Attachments: []Attachment{{Filename: "missing.txt"}}})
+		if !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument for attachment without content, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,254 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// the stream is encrypted in chunks, each sealed separately, allowing
+// the large payloads to be processed without loading them fully in
+// memory, formatted as:
+//
+//	version || key id length || key id || nonce prefix || chunk...
+//
+// where every chunk is formatted as:
+//
+//	last flag || ciphertext length (uint32) || ciphertext
+//
+// and the nonce of a chunk is derived from the random nonce prefix, the
+// chunk counter and the last flag, so the chunks cannot be reordered,
+// dropped or truncated without failing the decryption
+const (
+	// version of the stream format
+	streamVersion1 byte = 1
+
+	// size of the plaintext of a chunk
+	streamChunkSize = 64 * 1024
+
+	// size of the random nonce prefix, followed by the counter and the
+	// last flag in the nonce of a chunk
+	streamNoncePrefixSize = 7
+
+	// size of the header of a chunk
+	streamChunkHeaderSize = 5
+
+	// maximum number of chunks in a stream, bounded by the counter
+	maxStreamChunks = 1<<32 - 1
+)
+
+// returns the nonce for the chunk of the stream
+func streamNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, streamNoncePrefixSize+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[streamNoncePrefixSize:], counter)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// streamWriter encrypts the data written to the destination
+type streamWriter struct {
+	dst     io.Writer
+	key     *encryptorKey
+	prefix  []byte
+	counter uint32
+
+	// plaintext pending to be sealed as a chunk
+	buf []byte
+
+	err    error
+	closed bool
+}
+
+func (c *encryptorImpl) EncryptStream(dst io.Writer) io.WriteCloser {
+	c.mu.RLock()
+	k := c.active
+	c.mu.RUnlock()
+	return &streamWriter{
+		dst: dst,
+		key: k,
+		buf: make([]byte, 0, streamChunkSize),
+	}
+}
+
+// writes the header of the stream
+func (w *streamWriter) writeHeader() error {
+	w.prefix = make([]byte, streamNoncePrefixSize)
+	if _, err := io.ReadFull(rand.Reader, w.prefix); err != nil {
+		return errors.Wrapf(errors.Unknown, "generating nonce: %w", err)
+	}
+	header := []byte{streamVersion1, byte(len(w.key.id))}
+	header = append(header, w.key.id...)
+	header = append(header, w.prefix...)
+	_, err := w.dst.Write(header)
+	return err
+}
+
+// seals the pending plaintext as a chunk, writing it to the destination
+func (w *streamWriter) flush(last bool) error {
+	if w.prefix == nil {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	if w.counter == maxStreamChunks {
+		return errors.Wrap(errors.InvalidArgument, "Stream too large")
+	}
+	size := len(w.buf) + w.key.gcm.Overhead()
+	chunk := make([]byte, streamChunkHeaderSize, streamChunkHeaderSize+size)
+	if last {
+		chunk[0] = 1
+	}
+	binary.BigEndian.PutUint32(chunk[1:], uint32(size))
+	chunk = w.key.gcm.Seal(chunk, streamNonce(w.prefix, w.counter, last), w.buf, nil)
+	w.counter++
+	w.buf = w.buf[:0]
+	_, err := w.dst.Write(chunk)
+	return err
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.closed {
+		return 0, errors.Wrap(errors.InvalidArgument, "Stream already closed")
+	}
+	n := 0
+	for len(p) > 0 {
+		// a full chunk is sealed only once more data follows, as the
+		// last chunk is sealed on close
+		if len(w.buf) == streamChunkSize {
+			if err := w.flush(false); err != nil {
+				w.err = err
+				return n, err
+			}
+		}
+		m := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+m]
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+// Close seals the last chunk, completing the stream, while the
+// destination is not closed
+func (w *streamWriter) Close() error {
+	if w.err != nil || w.closed {
+		return w.err
+	}
+	w.closed = true
+	w.err = w.flush(true)
+	return w.err
+}
+
+// streamReader decrypts the data read from the source
+type streamReader struct {
+	c       *encryptorImpl
+	src     io.Reader
+	key     *encryptorKey
+	prefix  []byte
+	counter uint32
+
+	// plaintext pending to be read
+	buf []byte
+
+	// set once the last chunk is read
+	done bool
+
+	err error
+}
+
+func (c *encryptorImpl) DecryptStream(src io.Reader) io.Reader {
+	return &streamReader{c: c, src: src}
+}
+
+// returns the error for the stream ending before the last chunk
+func truncatedStream(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errors.Wrap(errors.InvalidArgument, "Truncated Stream")
+	}
+	return err
+}
+
+// reads the header of the stream
+func (r *streamReader) readHeader() error {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r.src, header); err != nil {
+		return truncatedStream(err)
+	}
+	if header[0] != streamVersion1 {
+		return errors.Wrapf(errors.InvalidArgument, "Invalid Stream version %d", header[0])
+	}
+	rest := make([]byte, int(header[1])+streamNoncePrefixSize)
+	if _, err := io.ReadFull(r.src, rest); err != nil {
+		return truncatedStream(err)
+	}
+	id := string(rest[:header[1]])
+	r.c.mu.RLock()
+	r.key = r.c.keys[id]
+	r.c.mu.RUnlock()
+	if r.key == nil {
+		return errors.Wrapf(errors.NotFound, "Key %s not found", id)
+	}
+	r.prefix = rest[header[1]:]
+	return nil
+}
+
+// reads and opens the next chunk of the stream
+func (r *streamReader) next() error {
+	if r.key == nil {
+		if err := r.readHeader(); err != nil {
+			return err
+		}
+	}
+	header := make([]byte, streamChunkHeaderSize)
+	if _, err := io.ReadFull(r.src, header); err != nil {
+		return truncatedStream(err)
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if header[0] > 1 || size < uint32(r.key.gcm.Overhead()) || size > uint32(streamChunkSize+r.key.gcm.Overhead()) {
+		return errors.Wrap(errors.InvalidArgument, "Invalid Stream chunk")
+	}
+	chunk := make([]byte, size)
+	if _, err := io.ReadFull(r.src, chunk); err != nil {
+		return truncatedStream(err)
+	}
+	last := header[0] == 1
+	message, err := r.key.gcm.Open(chunk[:0], streamNonce(r.prefix, r.counter, last), chunk, nil)
+	if err != nil {
+		return errors.Wrapf(errors.InvalidArgument, "Invalid Stream chunk: %s", err)
+	}
+	r.counter++
+	r.buf = message
+	if last {
+		r.done = true
+		if n, _ := r.src.Read(make([]byte, 1)); n != 0 {
+			return errors.Wrap(errors.InvalidArgument, "Invalid Stream, data after the last chunk")
+		}
+	}
+	return nil
+}
+
+func (r *streamReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.err = r.next()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,110 @@
+// Copyright © 2025-2026 Prabhjot Singh Sethi, All Rights reserved
+// Author: Prabhjot Singh Sethi <prabhjot.sethi@gmail.com>
+
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/go-core-stack/core/errors"
+)
+
+// encrypts the data as a stream, written in pieces of the given size
+func encryptStream(t *testing.T, enc IOEncryptor, data []byte, piece int) []byte {
+	out := &bytes.Buffer{}
+	w := enc.EncryptStream(out)
+	for len(data) > 0 {
+		n := min(piece, len(data))
+		if _, err := w.Write(data[:n]); err != nil {
+			t.Fatalf("failed to write stream: %s", err)
+		}
+		data = data[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close stream: %s", err)
+	}
+	return out.Bytes()
+}
+
+func Test_EncryptorStream(t *testing.T) {
+	enc, err := createEncryptor([]byte("utils-stream-key"))
+	if err != nil {
+		t.Fatalf("failed to create encryptor: %s", err)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, size := range []int{0, 1, streamChunkSize, streamChunkSize + 1, 3*streamChunkSize + 17} {
+			data := make([]byte, size)
+			_, _ = rand.Read(data)
+			stream := encryptStream(t, enc, data, 1000)
+			if size > 64 && bytes.Contains(stream, data[:64]) {
+				t.Errorf("expected stream of %d bytes to be encrypted", size)
+			}
+			decrypted, err := io.ReadAll(enc.DecryptStream(bytes.NewReader(stream)))
+			if err != nil || !bytes.Equal(decrypted, data) {
+				t.Errorf("expected stream of %d bytes decrypted, got %d bytes, %v", size, len(decrypted), err)
+			}
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		w := enc.EncryptStream(io.Discard)
+		if err := w.Close(); err != nil {
+			t.Fatalf("failed to close stream: %s", err)
+		}
+		if _, err := w.Write([]byte("late")); !errors.IsInvalidArgument(err) {
+			t.Errorf("expected invalid argument writing after close, got %v", err)
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		data := make([]byte, 2*streamChunkSize+10)
+		stream := encryptStream(t, enc, data, len(data))
+		header := 2 + streamNoncePrefixSize
+		chunk := streamChunkHeaderSize + streamChunkSize + 16
+
+		flipped := bytes.Clone(stream)
+		flipped[header+chunk+100] ^= 0xff
+		// truncated at a chunk boundary, dropping the last chunk
+		truncated := stream[:header+2*chunk]
+		// first chunk marked as the last one
+		marked := bytes.Clone(stream[:header+chunk])
+		marked[header] = 1
+		// chunks reordered
+		reordered := append(bytes.Clone(stream[:header]), stream[header+chunk:header+2*chunk]...)
+		reordered = append(reordered, stream[header:header+chunk]...)
+		reordered = append(reordered, stream[header+2*chunk:]...)
+		trailing := append(bytes.Clone(stream), 0)
+
+		for name, s := range map[string][]byte{
+			"flipped":   flipped,
+			"truncated": truncated,
+			"marked":    marked,
+			"reordered": reordered,
+			"trailing":  trailing,
+			"empty":     nil,
+		} {
+			if _, err := io.ReadAll(enc.DecryptStream(bytes.NewReader(s))); !errors.IsInvalidArgument(err) {
+				t.Errorf("expected invalid argument for %s stream, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		enc, _ := createEncryptor([]byte("utils-stream-rotation-key"))
+		stream := encryptStream(t, enc, []byte("old-data"), 8)
+		if err := enc.AddKey("v2", "utils-stream-rotation-key-v2"); err != nil {
+			t.Fatalf("failed to add key: %s", err)
+		}
+		if data, err := io.ReadAll(enc.DecryptStream(bytes.NewReader(stream))); err != nil || string(data) != "old-data" {
+			t.Errorf("expected stream decrypted using older key, got %q, %v", data, err)
+		}
+		_ = enc.RetireKey("")
+		if _, err := io.ReadAll(enc.DecryptStream(bytes.NewReader(stream))); !errors.IsNotFound(err) {
+			t.Errorf("expected not found for retired key, got %v", err)
+		}
+	})
+}